@@ -0,0 +1,213 @@
+package gomplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	gtmpl "github.com/hairyhenderson/gomplate/v3/tmpl"
+)
+
+// BenchContext is the name reported for the time spent creating the template
+// context (i.e. reading the context datasources)
+const BenchContext = "(context)"
+
+// BenchResult - timings for one template over all iterations of a benchmark
+//
+// Experimental: subject to breaking changes before the next major release
+type BenchResult struct {
+	Name       string
+	Parse      BenchTiming
+	Datasource BenchTiming
+	Execute    BenchTiming
+}
+
+// BenchTiming - the median and 95th percentile durations of one phase of
+// rendering
+//
+// Experimental: subject to breaking changes before the next major release
+type BenchTiming struct {
+	P50 time.Duration
+	P95 time.Duration
+}
+
+type benchSamples struct {
+	parse, datasource, execute []time.Duration
+}
+
+func (s *benchSamples) add(parse, datasource, total time.Duration) {
+	s.parse = append(s.parse, parse)
+	s.datasource = append(s.datasource, datasource)
+	s.execute = append(s.execute, total-datasource)
+}
+
+func (s *benchSamples) result(name string) BenchResult {
+	return BenchResult{
+		Name:       name,
+		Parse:      newBenchTiming(s.parse),
+		Datasource: newBenchTiming(s.datasource),
+		Execute:    newBenchTiming(s.execute),
+	}
+}
+
+func newBenchTiming(samples []time.Duration) BenchTiming {
+	return BenchTiming{
+		P50: percentile(samples, 50),
+		P95: percentile(samples, 95),
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of the samples
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Bench renders the configured templates the given number of times, and
+// reports how long each template took to parse, to read datasources, and to
+// execute. Output is discarded. Every iteration uses a fresh Renderer, so
+// datasources are read again each time, though a datasource read by more
+// than one template is only counted for the first.
+//
+// Experimental: subject to breaking changes before the next major release
+func Bench(ctx context.Context, cfg *config.Config, iterations int) ([]BenchResult, error) {
+	defer runCleanupHooks()
+
+	if iterations < 1 {
+		return nil, fmt.Errorf("iterations must be at least 1, got %d", iterations)
+	}
+	if cfg.Matrix != nil {
+		return nil, fmt.Errorf("benchmarking matrix renders is not supported")
+	}
+
+	err := validateInputs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.EnvFiles) > 0 {
+		restoreEnv, err := loadEnvFiles(cfg.EnvFiles, cfg.EnvFileNoOverride)
+		if err != nil {
+			return nil, err
+		}
+		defer restoreEnv()
+	}
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := readInputTemplates(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather templates for rendering: %w", err)
+	}
+
+	tctxSamples := &benchSamples{}
+	samples := make([]benchSamples, len(templates))
+	for i := 0; i < iterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		tr := NewRenderer(opts)
+		tr.data.Ctx = ctx
+
+		start := time.Now()
+		stop := tr.data.RecordReads()
+		tctx, err := createTmplContext(ctx, tr.tctxAliases, tr.tctxRoots, tr.data)
+		tctxSamples.add(0, readsDuration(stop()), time.Since(start))
+		if err != nil {
+			return nil, err
+		}
+
+		f := tr.funcMap(ctx)
+		for j, it := range templates {
+			parse, ds, total, err := tr.benchTemplate(ctx, it, f, tctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render template %s: %w", it.name, err)
+			}
+			samples[j].add(parse, ds, total)
+		}
+	}
+
+	results := make([]BenchResult, 0, len(templates)+1)
+	results = append(results, tctxSamples.result(BenchContext))
+	for i, it := range templates {
+		results = append(results, samples[i].result(it.name))
+	}
+	return results, nil
+}
+
+// validateInputs applies defaults to and validates a config that's only used
+// for its templates (and datasources, etc.) - output options are ignored.
+func validateInputs(cfg *config.Config) error {
+	cfg.OutputFiles, cfg.OutputDir, cfg.OutputMap = nil, "", ""
+	cfg.ExecPipe, cfg.PostExec, cfg.RenderCache = false, nil, ""
+
+	cfg.ApplyDefaults()
+	if len(cfg.InputFiles) > 1 {
+		cfg.OutputFiles = make([]string, len(cfg.InputFiles))
+		for i := range cfg.OutputFiles {
+			cfg.OutputFiles[i] = "-"
+		}
+	}
+
+	err := cfg.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate config: %w\n%+v", err, cfg)
+	}
+	return nil
+}
+
+// benchTemplate parses and executes the template once, returning the parse
+// duration, the time spent reading datasources during execution, and the
+// total execution time
+func (t *Renderer) benchTemplate(ctx context.Context, it inputTemplate, f template.FuncMap, tctx interface{}) (parse, ds, total time.Duration, err error) {
+	start := time.Now()
+	tmpl, err := t.parse(ctx, it.name, it.text, it.bundle, f, tctx)
+	parse = time.Since(start)
+	if err != nil {
+		return parse, 0, 0, err
+	}
+
+	start = time.Now()
+	stop := t.data.RecordReads()
+	if t.htmlEscape {
+		err = executeHTML(tmpl, f, io.Discard, tctx)
+	} else {
+		err = tmpl.Execute(io.Discard, tctx)
+	}
+	total = time.Since(start)
+	ds = readsDuration(stop())
+	if errors.Is(err, gtmpl.ErrSkip) {
+		err = nil
+	}
+	return parse, ds, total, err
+}
+
+func readsDuration(reads []data.Read) (d time.Duration) {
+	for _, r := range reads {
+		d += r.Duration
+	}
+	return d
+}
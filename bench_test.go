@@ -0,0 +1,51 @@
+package gomplate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+
+	samples := []time.Duration{}
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i))
+	}
+	assert.Equal(t, time.Duration(50), percentile(samples, 50))
+	assert.Equal(t, time.Duration(95), percentile(samples, 95))
+	assert.Equal(t, time.Duration(1), percentile(samples, 0))
+	assert.Equal(t, time.Duration(100), percentile(samples, 100))
+	// the samples aren't modified
+	assert.Equal(t, time.Duration(100), samples[0])
+
+	assert.Equal(t, time.Duration(3), percentile([]time.Duration{3}, 95))
+}
+
+func TestBench(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &config.Config{
+		Input:       `{{ range seq 10 }}{{ . }}{{ end }}{{ skipFile }}`,
+		OutputFiles: []string{"out.txt"},
+	}
+	results, err := Bench(ctx, cfg, 5)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, BenchContext, results[0].Name)
+	assert.Equal(t, "<arg>", results[1].Name)
+	assert.Greater(t, int64(results[1].Parse.P50), int64(0))
+	assert.GreaterOrEqual(t, results[1].Parse.P95, results[1].Parse.P50)
+	assert.GreaterOrEqual(t, results[1].Execute.P95, results[1].Execute.P50)
+
+	_, err = Bench(ctx, &config.Config{Input: `{{ fail "oops" }}`}, 1)
+	assert.ErrorContains(t, err, "failed to render template <arg>")
+
+	_, err = Bench(ctx, &config.Config{Input: "foo"}, 0)
+	assert.EqualError(t, err, "iterations must be at least 1, got 0")
+}
@@ -0,0 +1,247 @@
+package gomplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/spf13/afero"
+)
+
+// bundleVersion is incremented when the bundle format changes, so that old
+// bundles are rejected
+const bundleVersion = 1
+
+// templateBundle is a set of templates (and nested templates) that were read
+// and validated by 'gomplate compile', so they can be rendered later without
+// reading any template files. It's serialized as JSON.
+type templateBundle struct {
+	Version    int              `json:"version"`
+	LeftDelim  string           `json:"leftDelim"`
+	RightDelim string           `json:"rightDelim"`
+	Templates  []bundleTemplate `json:"templates"`
+	Nested     []bundleNested   `json:"nested,omitempty"`
+
+	// the nested templates are only parsed once, and shared by all templates
+	nestedOnce sync.Once
+	nested     *template.Template
+	nestedErr  error
+}
+
+type bundleTemplate struct {
+	Name string `json:"name"`
+	// In is the input path, as given to output path templates
+	In      string      `json:"in,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	DirMode os.FileMode `json:"dirMode,omitempty"`
+	Text    string      `json:"text"`
+}
+
+type bundleNested struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Text string `json:"text"`
+}
+
+// loadBundle reads a bundle file
+func loadBundle(path string) (*templateBundle, error) {
+	b, err := afero.ReadFile(aferoFS, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	bundle := &templateBundle{}
+	if err := json.Unmarshal(b, bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle %s: %w", path, err)
+	}
+	if bundle.Version != bundleVersion {
+		return nil, fmt.Errorf("bundle %s has unsupported version %d (expected %d) - recompile it with 'gomplate compile'", path, bundle.Version, bundleVersion)
+	}
+	return bundle, nil
+}
+
+// parse parses the template's text, and adds the bundle's nested templates,
+// with the same precedence as parseTemplate gives them. The nested templates
+// are parsed the first time only.
+func (b *templateBundle) parse(name, text string, funcs template.FuncMap, tmplctx interface{}) (*template.Template, error) {
+	nested, err := b.parseNested(funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := template.New(name)
+	tmpl.Option("missingkey=error")
+
+	funcMap := copyFuncMap(funcs)
+	addTmplFuncs(funcMap, tmpl, tmplctx, name)
+	tmpl.Funcs(funcMap)
+	tmpl.Delims(b.LeftDelim, b.RightDelim)
+	_, err = tmpl.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// the parse trees aren't modified when executing, so can be shared
+	for _, n := range nested.Templates() {
+		if n.Tree == nil {
+			continue
+		}
+		_, err = tmpl.AddParseTree(n.Name(), n.Tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
+}
+
+func (b *templateBundle) parseNested(funcs template.FuncMap) (*template.Template, error) {
+	b.nestedOnce.Do(func() {
+		nested := template.New("")
+
+		// the functions are only needed to parse, and not to execute
+		funcMap := copyFuncMap(funcs)
+		addTmplFuncs(funcMap, nested, nil, "")
+		nested.Funcs(funcMap)
+		nested.Delims(b.LeftDelim, b.RightDelim)
+
+		for _, n := range b.Nested {
+			_, err := nested.New(n.Name).Parse(n.Text)
+			if err != nil {
+				b.nestedErr = fmt.Errorf("parse nested template %q: %w", n.File, err)
+				return
+			}
+		}
+		b.nested = nested
+	})
+	return b.nested, b.nestedErr
+}
+
+// Compile reads the templates and nested templates given in the config, and
+// validates them by parsing them with all available functions. The templates
+// are written to w as a bundle, which can be rendered later with the config's
+// Bundle option, without having to read or validate the templates again.
+//
+// Experimental: subject to breaking changes before the next major release
+func Compile(ctx context.Context, cfg *config.Config, w io.Writer) error {
+	defer runCleanupHooks()
+
+	if cfg.Bundle != "" {
+		return fmt.Errorf("a bundle can't be compiled from another bundle")
+	}
+
+	err := validateInputs(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := readInputTemplates(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to gather templates for compiling: %w", err)
+	}
+	ctx = netpolicy.ContextWithPolicy(contextWithVerifier(ctx, opts.verifier), opts.netPolicy)
+	templates := config.Templates{}
+	for alias, t := range cfg.Templates {
+		t.URL = config.ExpandScheme(cfg.Schemes, t.URL)
+		templates[alias] = t
+	}
+	nested, err := readNestedTemplates(ctx, templates)
+	if err != nil {
+		return err
+	}
+
+	b := &templateBundle{
+		Version:    bundleVersion,
+		LeftDelim:  cfg.LDelim,
+		RightDelim: cfg.RDelim,
+		Templates:  make([]bundleTemplate, len(inputs)),
+	}
+	for i, it := range inputs {
+		b.Templates[i] = bundleTemplate{
+			Name:    it.name,
+			In:      it.in,
+			Mode:    it.mode,
+			DirMode: it.dirMode,
+			Text:    it.text,
+		}
+	}
+	for _, n := range nested {
+		b.Nested = append(b.Nested, bundleNested{Name: n.name, File: n.file, Text: n.text})
+	}
+
+	// templates are parsed just as they will be when the bundle is rendered
+	f := NewRenderer(opts).funcMap(ctx)
+	for _, t := range b.Templates {
+		_, err = b.parse(t.Name, t.Text, f, nil)
+		if err != nil {
+			return fmt.Errorf("failed to compile template %s: %w", t.Name, err)
+		}
+	}
+
+	return json.NewEncoder(w).Encode(b)
+}
+
+// bundleToTemplates prepares the templates in the config's bundle for
+// rendering. When an output directory or map is set, output paths are named
+// from the templates' input paths, otherwise there must be an output file for
+// each template.
+func bundleToTemplates(ctx context.Context, cfg *config.Config, outFileNamer func(context.Context, string) (string, error), modeOverride bool) ([]Template, error) {
+	inputs, err := readInputTemplates(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	named := cfg.OutputDir != "" || cfg.OutputMap != ""
+	if !named && len(cfg.OutputFiles) != len(inputs) {
+		return nil, fmt.Errorf("must provide same number of 'outputFiles' (%d) as templates in the bundle (%d)", len(cfg.OutputFiles), len(inputs))
+	}
+
+	templates := make([]Template, len(inputs))
+	for i, it := range inputs {
+		var outFile string
+		if named {
+			outFile, err = outFileNamer(ctx, it.in)
+			if err != nil {
+				return nil, err
+			}
+			if !cfg.Preview {
+				if err = aferoFS.MkdirAll(filepath.Dir(outFile), it.dirMode); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			outFile = cfg.OutputFiles[i]
+		}
+
+		// no need to close the output, as it's closed after rendering
+		target, err := openOutput(cfg, outFile, it.dirMode, it.mode, modeOverride)
+		if err != nil {
+			return nil, err
+		}
+
+		templates[i] = Template{
+			Name:   it.name,
+			Text:   it.text,
+			Writer: target,
+			target: outFile,
+			bundle: it.bundle,
+		}
+	}
+
+	return templates, nil
+}
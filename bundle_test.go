@@ -0,0 +1,96 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/hairyhenderson/go-fsimpl"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileBundle(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = aferoFS.MkdirAll("/in/sub", 0755)
+	_ = afero.WriteFile(aferoFS, "/in/a.txt", []byte(`{{ template "p/hdr.t" }}a={{ .Values.x }}`), 0644)
+	_ = afero.WriteFile(aferoFS, "/in/sub/b.txt", []byte(`{{ define "x" }}main{{ end }}{{ template "x" }}`), 0600)
+
+	fsys := fstest.MapFS{
+		"partials/hdr.t": {Data: []byte("HDR "), Mode: 0o600},
+		// nested templates take precedence, as they're parsed last
+		"partials/x.t": {Data: []byte(`{{ define "x" }}nested{{ end }}`), Mode: 0o600},
+	}
+	ctx := ContextWithFSProvider(context.Background(), fsimpl.WrappedFSProvider(fsys, "file"))
+
+	u, _ := url.Parse("file:///partials/")
+	cfg := &config.Config{
+		InputDir:  "/in",
+		Templates: config.Templates{"p": {URL: u}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, Compile(ctx, cfg, buf))
+	_ = afero.WriteFile(aferoFS, "/app.bundle", buf.Bytes(), 0644)
+
+	// the templates are read from the bundle alone
+	_ = aferoFS.RemoveAll("/in")
+	ctx = ContextWithFSProvider(context.Background(), fsimpl.WrappedFSProvider(fstest.MapFS{}, "file"))
+
+	err := Run(ctx, &config.Config{
+		Bundle:    "/app.bundle",
+		OutputDir: "/out",
+		Values:    map[string]interface{}{"x": 42},
+	})
+	require.NoError(t, err)
+
+	b, err := afero.ReadFile(aferoFS, "/out/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "HDR a=42", string(b))
+	fi, err := aferoFS.Stat("/out/sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "-rw-------", fi.Mode().String())
+	b, err = afero.ReadFile(aferoFS, "/out/sub/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "nested", string(b))
+
+	// one output file is needed for each template
+	err = Run(ctx, &config.Config{Bundle: "/app.bundle", OutputFiles: []string{"/out.txt"}})
+	assert.ErrorContains(t, err, "must provide same number of 'outputFiles' (1) as templates in the bundle (2)")
+
+	// templates are validated
+	err = Compile(ctx, &config.Config{Input: `{{ bogus }}`}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, `failed to compile template <arg>: template: <arg>:1: function "bogus" not defined`)
+}
+
+func TestLoadBundle(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_, err := loadBundle("/missing.bundle")
+	assert.ErrorContains(t, err, "failed to read bundle")
+
+	_ = afero.WriteFile(aferoFS, "/old.bundle", []byte(`{"version": 0, "templates": []}`), 0644)
+	_, err = loadBundle("/old.bundle")
+	assert.EqualError(t, err, "bundle /old.bundle has unsupported version 0 (expected 1) - recompile it with 'gomplate compile'")
+
+	_ = afero.WriteFile(aferoFS, "/app.bundle", []byte(`{"version": 1, "leftDelim": "[[", "rightDelim": "]]",
+		"templates": [{"name": "t", "text": "[[ template \"n\" . ]]"}],
+		"nested": [{"name": "n", "file": "n.t", "text": "hello [[ . ]]"}]}`), 0644)
+	b, err := loadBundle("/app.bundle")
+	require.NoError(t, err)
+
+	tmpl, err := b.parse("t", b.Templates[0].Text, template.FuncMap{}, nil)
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	require.NoError(t, tmpl.Execute(out, "world"))
+	assert.Equal(t, "hello world", out.String())
+}
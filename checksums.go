@@ -0,0 +1,139 @@
+package gomplate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// addressLength is the number of hex digits of the digest that are added to
+// content-addressed file names
+const addressLength = 16
+
+// checksums records the SHA-256 digest of each output as it's written, so
+// that a manifest (in the format of sha256sum's SHA256SUMS files) can be
+// written once rendering is complete. It's safe for concurrent use, since
+// matrix items are rendered concurrently.
+type checksums struct {
+	mu   sync.Mutex
+	path string
+	// contentAddressed - local output files are renamed to include their
+	// digests, once they've been written
+	contentAddressed bool
+	// digests (in hex), by output path
+	digests map[string]string
+	// renames - output paths to the content-addressed paths they're renamed
+	// to
+	renames map[string]string
+}
+
+func newChecksums(path string, contentAddressed bool) *checksums {
+	return &checksums{
+		path:             path,
+		contentAddressed: contentAddressed,
+		digests:          map[string]string{},
+		renames:          map[string]string{},
+	}
+}
+
+// record records the digest (in hex) of the output at target, and returns
+// the path the output will have once rendering is complete
+func (c *checksums) record(target, digest string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.contentAddressed && isLocalPath(target) {
+		p := contentAddressedPath(target, digest)
+		c.renames[target] = p
+		target = p
+	}
+	c.digests[target] = digest
+	return target
+}
+
+// add records the digest (in hex) of an output that's already at its final
+// path - like a content-addressed output that wasn't rendered again because
+// it's unchanged
+func (c *checksums) add(p, digest string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.digests[p] = digest
+}
+
+// finish renames content-addressed outputs, and writes the manifest. It must
+// only be called once all of the outputs have been closed.
+func (c *checksums) finish() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for from, to := range c.renames {
+		if err := aferoFS.Rename(from, to); err != nil {
+			return fmt.Errorf("failed to rename output %s to %s: %w", from, to, err)
+		}
+	}
+	c.renames = map[string]string{}
+
+	b := &strings.Builder{}
+	for _, p := range c.sortedPaths() {
+		fmt.Fprintf(b, "%s  %s\n", c.digests[p], c.manifestPath(p))
+	}
+
+	if err := os.WriteFile(c.path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write checksums: %w", err)
+	}
+	return nil
+}
+
+func (c *checksums) sortedPaths() []string {
+	paths := make([]string, 0, len(c.digests))
+	for p := range c.digests {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// manifestPath returns the path of a local output relative to the manifest's
+// directory (if it's inside it), so the manifest can be checked with
+// 'sha256sum -c' from there. Other outputs (like URLs) are listed as-is.
+func (c *checksums) manifestPath(p string) string {
+	if !isLocalPath(p) {
+		return p
+	}
+	dir, err := filepath.Abs(filepath.Dir(c.path))
+	if err != nil {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// contentAddressedPath adds the start of the digest to the file name, before
+// the extension - 'out/app.js' becomes 'out/app.<digest>.js'
+func contentAddressedPath(p, digest string) string {
+	if len(digest) > addressLength {
+		digest = digest[:addressLength]
+	}
+	ext := filepath.Ext(p)
+	if ext == filepath.Base(p) {
+		// dotfiles like '.env' have no extension
+		ext = ""
+	}
+	return strings.TrimSuffix(p, ext) + "." + digest + ext
+}
+
+// isLocalPath returns true if the output is a local file (not standard
+// output or a URL)
+func isLocalPath(target string) bool {
+	return cacheable(target) && !strings.Contains(target, "://")
+}
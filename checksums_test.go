@@ -0,0 +1,104 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChecksums(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	dir := t.TempDir()
+	sumsFile := filepath.Join(dir, "SHA256SUMS")
+
+	render := func(opts Options, suppressEmpty bool, texts map[string]string) {
+		t.Helper()
+		Metrics = newMetrics()
+		tr := NewRenderer(opts)
+		templates := []Template{}
+		for _, target := range []string{"/out/a.txt", "/out/b.txt", "-"} {
+			text, ok := texts[target]
+			if !ok {
+				continue
+			}
+			w, err := openOutFile(target, 0755, 0644, false, &bytes.Buffer{}, suppressEmpty)
+			require.NoError(t, err)
+			templates = append(templates, Template{Name: target, Text: text, Writer: w, target: target})
+		}
+		require.NoError(t, tr.RenderTemplates(context.Background(), templates))
+	}
+	manifest := func() string {
+		t.Helper()
+		b, err := os.ReadFile(sumsFile)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	render(Options{Checksums: sumsFile}, true, map[string]string{
+		"/out/b.txt": "bbb",
+		"/out/a.txt": "aaa",
+		"-":          "stdout isn't listed",
+	})
+	assert.Equal(t, sha256hex("aaa")+"  /out/a.txt\n"+sha256hex("bbb")+"  /out/b.txt\n", manifest())
+
+	// suppressed empty outputs aren't listed
+	render(Options{Checksums: sumsFile}, true, map[string]string{
+		"/out/a.txt": "aaa",
+		"/out/b.txt": "  \n",
+	})
+	assert.Equal(t, sha256hex("aaa")+"  /out/a.txt\n", manifest())
+
+	// content-addressed outputs are renamed
+	addressed := "/out/a." + sha256hex("aaa")[:16] + ".txt"
+	render(Options{Checksums: sumsFile, ContentAddressed: true}, false, map[string]string{
+		"/out/a.txt": "aaa",
+	})
+	assert.Equal(t, sha256hex("aaa")+"  "+addressed+"\n", manifest())
+	b, err := afero.ReadFile(aferoFS, addressed)
+	require.NoError(t, err)
+	assert.Equal(t, "aaa", string(b))
+	_, err = aferoFS.Stat("/out/a.txt")
+	assert.True(t, os.IsNotExist(err))
+
+	// outputs that aren't rendered again because they're unchanged are
+	// listed too
+	cacheFile := filepath.Join(dir, "cache.json")
+	opts := Options{Checksums: sumsFile, ContentAddressed: true, RenderCache: cacheFile}
+	render(opts, false, map[string]string{"/out/b.txt": "bbb"})
+	assert.Equal(t, 1, Metrics.TemplatesProcessed)
+	render(opts, false, map[string]string{"/out/b.txt": "bbb"})
+	assert.Equal(t, 1, Metrics.TemplatesCached)
+	assert.Equal(t, sha256hex("bbb")+"  /out/b."+sha256hex("bbb")[:16]+".txt\n", manifest())
+}
+
+func TestChecksumsManifestPath(t *testing.T) {
+	c := newChecksums(filepath.Join("out", "SHA256SUMS"), false)
+	assert.Equal(t, "a.txt", c.manifestPath(filepath.Join("out", "a.txt")))
+	assert.Equal(t, "sub/a.txt", c.manifestPath(filepath.Join("out", "sub", "a.txt")))
+	assert.Equal(t, "other/a.txt", c.manifestPath(filepath.Join("other", "a.txt")))
+	assert.Equal(t, "nats://example.com/subject", c.manifestPath("nats://example.com/subject"))
+}
+
+func TestContentAddressedPath(t *testing.T) {
+	digest := sha256hex("foo")
+	assert.Equal(t, "out/app."+digest[:16]+".js", contentAddressedPath("out/app.js", digest))
+	assert.Equal(t, "out/Makefile."+digest[:16], contentAddressedPath("out/Makefile", digest))
+	assert.Equal(t, "out/.env."+digest[:16], contentAddressedPath("out/.env", digest))
+	assert.Equal(t, "out/x.tar."+digest[:16]+".gz", contentAddressedPath("out/x.tar.gz", digest))
+}
@@ -6,8 +6,11 @@ func addCleanupHook(hook func()) {
 	cleanupHooks = append(cleanupHooks, hook)
 }
 
+// runCleanupHooks runs and removes all cleanup hooks, so long-running
+// processes can clean up periodically
 func runCleanupHooks() {
 	for _, hook := range cleanupHooks {
 		hook()
 	}
+	cleanupHooks = make([]func(), 0)
 }
@@ -9,9 +9,11 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
 	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+	"github.com/hairyhenderson/gomplate/v3/internal/mapmeta"
 )
 
 // Slice creates a slice from a bunch of arguments
@@ -181,6 +183,7 @@ func Omit(in map[string]interface{}, keys ...string) map[string]interface{} {
 			out[k] = v
 		}
 	}
+	mapmeta.Inherit(out, in)
 	return out
 }
 
@@ -193,14 +196,18 @@ func Pick(in map[string]interface{}, keys ...string) map[string]interface{} {
 			out[k] = v
 		}
 	}
+	mapmeta.Inherit(out, in)
 	return out
 }
 
+// copyMap copies the map, along with any key order or formatting recorded
+// when it was parsed
 func copyMap(m map[string]interface{}) map[string]interface{} {
 	n := map[string]interface{}{}
 	for k, v := range m {
 		n[k] = v
 	}
+	mapmeta.Inherit(n, m)
 	return n
 }
 
@@ -208,6 +215,7 @@ func copyMap(m map[string]interface{}) map[string]interface{} {
 func mergeValues(d map[string]interface{}, o map[string]interface{}) map[string]interface{} {
 	def := copyMap(d)
 	over := copyMap(o)
+	mapmeta.Inherit(def, o)
 	for k, v := range over {
 		// If the key doesn't exist already, then just set the key to that value
 		if _, exists := def[k]; !exists {
@@ -340,3 +348,88 @@ func Flatten(list interface{}, depth int) ([]interface{}, error) {
 	}
 	return out, nil
 }
+
+// FlattenMap flattens a nested map into a single-level map, joining the keys
+// of nested maps with the given separator. Values that are not maps (including
+// slices) are left as-is. An empty nested map is kept as a value, so that the
+// output can be reversed with Unflatten.
+//
+// Returns a new map without modifying the input.
+func FlattenMap(in map[string]interface{}, sep string) map[string]interface{} {
+	out := map[string]interface{}{}
+	flattenMapInto(out, "", in, sep)
+	return out
+}
+
+func flattenMapInto(out map[string]interface{}, prefix string, in map[string]interface{}, sep string) {
+	for k, v := range in {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+
+		m, ok := v.(map[string]interface{})
+		if !ok || len(m) == 0 {
+			out[key] = v
+			continue
+		}
+		flattenMapInto(out, key, m, sep)
+	}
+}
+
+// Unflatten is the inverse of FlattenMap - it splits each key of the given
+// map on the separator, and produces a nested map. An error is returned when
+// a key would need to be both a value and a nested map (e.g. "a" and "a.b").
+//
+// Returns a new map without modifying the input.
+func Unflatten(in map[string]interface{}, sep string) (map[string]interface{}, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator must not be empty")
+	}
+
+	// sort the keys so that conflicts are reported deterministically
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := map[string]interface{}{}
+	for _, k := range keys {
+		parts := strings.Split(k, sep)
+		m := out
+		for i, p := range parts[:len(parts)-1] {
+			next, ok := m[p]
+			if !ok {
+				nm := map[string]interface{}{}
+				m[p] = nm
+				m = nm
+				continue
+			}
+			nm, ok := next.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key %q conflicts with existing value at %q", k, strings.Join(parts[:i+1], sep))
+			}
+			m = nm
+		}
+
+		last := parts[len(parts)-1]
+		if existing, ok := m[last]; ok {
+			em, isMap := existing.(map[string]interface{})
+			vm, vIsMap := in[k].(map[string]interface{})
+			if !isMap || !vIsMap || len(vm) > 0 {
+				return nil, fmt.Errorf("key %q conflicts with existing value", k)
+			}
+			// an explicit empty map for an already-nested key is a no-op
+			m[last] = em
+			continue
+		}
+		if vm, ok := in[k].(map[string]interface{}); ok && len(vm) == 0 {
+			// don't share the input's map, it may be nested into later
+			m[last] = map[string]interface{}{}
+			continue
+		}
+		m[last] = in[k]
+	}
+	return out, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hairyhenderson/gomplate/v3/internal/mapmeta"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -616,3 +617,83 @@ func TestPick(t *testing.T) {
 
 	assert.EqualValues(t, in, Pick(in, "foo", "bar", ""))
 }
+
+func TestFlattenMap(t *testing.T) {
+	in := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": 1},
+			"d": []interface{}{1, 2},
+		},
+		"e": "f",
+		"g": map[string]interface{}{},
+	}
+	expected := map[string]interface{}{
+		"a.b.c": 1,
+		"a.d":   []interface{}{1, 2},
+		"e":     "f",
+		"g":     map[string]interface{}{},
+	}
+	assert.EqualValues(t, expected, FlattenMap(in, "."))
+
+	assert.EqualValues(t, map[string]interface{}{
+		"a/b/c": 1,
+		"a/d":   []interface{}{1, 2},
+		"e":     "f",
+		"g":     map[string]interface{}{},
+	}, FlattenMap(in, "/"))
+
+	assert.EqualValues(t, map[string]interface{}{}, FlattenMap(nil, "."))
+}
+
+func TestUnflatten(t *testing.T) {
+	in := map[string]interface{}{
+		"a.b.c": 1,
+		"a.d":   []interface{}{1, 2},
+		"e":     "f",
+		"g":     map[string]interface{}{},
+	}
+	expected := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": 1},
+			"d": []interface{}{1, 2},
+		},
+		"e": "f",
+		"g": map[string]interface{}{},
+	}
+	out, err := Unflatten(in, ".")
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, out)
+
+	// round-trip
+	out, err = Unflatten(FlattenMap(expected, "/"), "/")
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, out)
+
+	_, err = Unflatten(map[string]interface{}{"a": 1, "a.b": 2}, ".")
+	assert.Error(t, err)
+
+	_, err = Unflatten(map[string]interface{}{"a": 1}, "")
+	assert.Error(t, err)
+}
+
+func TestCopiesInheritMapMeta(t *testing.T) {
+	in := map[string]interface{}{"b": 1, "a": 2, "c": 3}
+	mapmeta.Record(in, mapmeta.Meta{Keys: []string{"b", "a", "c"}})
+
+	out, err := Merge(map[string]interface{}{"d": 4}, in)
+	assert.NoError(t, err)
+	meta, ok := mapmeta.Get(out)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"b", "a", "c"}, meta.Keys)
+
+	// the destination's information is used when the source has none
+	out, err = Merge(in, map[string]interface{}{"d": 4})
+	assert.NoError(t, err)
+	_, ok = mapmeta.Get(out)
+	assert.True(t, ok)
+
+	_, ok = mapmeta.Get(Omit(in, "a"))
+	assert.True(t, ok)
+	_, ok = mapmeta.Get(Pick(in, "a"))
+	assert.True(t, ok)
+}
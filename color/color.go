@@ -0,0 +1,136 @@
+// Package color contains functions for styling text with ANSI escape codes,
+// and for working with RGB colours.
+package color
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RGB - a colour, with 8-bit red, green, and blue components
+type RGB struct {
+	R, G, B uint8
+}
+
+// ParseHex parses a hex colour, like "#ff8800" or "f80"
+func ParseHex(s string) (RGB, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return RGB{}, fmt.Errorf("invalid hex colour %q", s)
+	}
+	n, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid hex colour %q", s)
+	}
+	return RGB{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n)}, nil
+}
+
+// Hex - the colour in hex, like "#ff8800"
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Luminance - the colour's relative luminance, from 0 (black) to 1 (white),
+// as defined by WCAG 2
+func (c RGB) Luminance() float64 {
+	lin := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(c.R) + 0.7152*lin(c.G) + 0.0722*lin(c.B)
+}
+
+// Contrast - the WCAG 2 contrast ratio between two colours, from 1 (no
+// contrast) to 21 (black on white)
+func Contrast(a, b RGB) float64 {
+	l1, l2 := a.Luminance(), b.Luminance()
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+var styleCodes = map[string]string{
+	"reset":         "0",
+	"bold":          "1",
+	"dim":           "2",
+	"italic":        "3",
+	"underline":     "4",
+	"blink":         "5",
+	"reverse":       "7",
+	"hidden":        "8",
+	"strikethrough": "9",
+}
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// code returns the SGR parameters for the style, which is a style name (like
+// "bold"), a colour name (like "red" or "brightRed"), or a hex colour (like
+// "#ff8800"). Prefix colours with "bg" for the background (like "bgRed" or
+// "bg#ff8800").
+func code(style string) (string, error) {
+	s := strings.ToLower(style)
+	if c, ok := styleCodes[s]; ok {
+		return c, nil
+	}
+
+	fg, bg := 30, 40
+	if strings.HasPrefix(s, "bg") {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "bg"), "-")
+		fg = bg
+	}
+	if strings.HasPrefix(s, "#") {
+		c, err := ParseHex(s)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d;2;%d;%d;%d", fg+8, c.R, c.G, c.B), nil
+	}
+	if s == "gray" || s == "grey" {
+		return strconv.Itoa(fg + 60), nil
+	}
+	bright := strings.HasPrefix(s, "bright")
+	s = strings.TrimPrefix(s, "bright")
+	for i, n := range colorNames {
+		if s == n {
+			if bright {
+				return strconv.Itoa(fg + 60 + i), nil
+			}
+			return strconv.Itoa(fg + i), nil
+		}
+	}
+	return "", fmt.Errorf("unknown style %q", style)
+}
+
+// Style wraps the text in the ANSI escape codes for the styles, followed by
+// a reset
+func Style(text string, styles ...string) (string, error) {
+	if len(styles) == 0 {
+		return text, nil
+	}
+	codes := make([]string, len(styles))
+	for i, s := range styles {
+		c, err := code(s)
+		if err != nil {
+			return "", err
+		}
+		codes[i] = c
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m", nil
+}
+
+var ansiRE = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// Strip removes ANSI escape codes from the text
+func Strip(text string) string {
+	return ansiRE.ReplaceAllString(text, "")
+}
@@ -0,0 +1,66 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHex(t *testing.T) {
+	c, err := ParseHex("#ff8800")
+	require.NoError(t, err)
+	assert.Equal(t, RGB{255, 136, 0}, c)
+	assert.Equal(t, "#ff8800", c.Hex())
+
+	c, err = ParseHex("F80")
+	require.NoError(t, err)
+	assert.Equal(t, RGB{255, 136, 0}, c)
+
+	for _, s := range []string{"", "#ff88", "#gg8800", "#ff88000"} {
+		_, err = ParseHex(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestContrast(t *testing.T) {
+	black, white := RGB{0, 0, 0}, RGB{255, 255, 255}
+	assert.InDelta(t, 0, black.Luminance(), 0.0001)
+	assert.InDelta(t, 1, white.Luminance(), 0.0001)
+
+	assert.InDelta(t, 21, Contrast(black, white), 0.0001)
+	assert.InDelta(t, 21, Contrast(white, black), 0.0001)
+	assert.InDelta(t, 1, Contrast(white, white), 0.0001)
+	assert.InDelta(t, 4.54, Contrast(RGB{0x76, 0x76, 0x76}, white), 0.01)
+}
+
+func TestStyle(t *testing.T) {
+	testdata := []struct {
+		expected string
+		styles   []string
+	}{
+		{"hi", nil},
+		{"\x1b[1mhi\x1b[0m", []string{"bold"}},
+		{"\x1b[1;31mhi\x1b[0m", []string{"bold", "red"}},
+		{"\x1b[91;44mhi\x1b[0m", []string{"brightRed", "bgBlue"}},
+		{"\x1b[90mhi\x1b[0m", []string{"gray"}},
+		{"\x1b[38;2;255;136;0;48;2;0;0;0mhi\x1b[0m", []string{"#ff8800", "bg#000"}},
+		{"\x1b[4;107mhi\x1b[0m", []string{"UNDERLINE", "bg-brightWhite"}},
+	}
+	for _, d := range testdata {
+		out, err := Style("hi", d.styles...)
+		require.NoError(t, err)
+		assert.Equal(t, d.expected, out, d.styles)
+	}
+
+	_, err := Style("hi", "sparkly")
+	assert.Error(t, err)
+
+	_, err = Style("hi", "#zzz")
+	assert.Error(t, err)
+}
+
+func TestStrip(t *testing.T) {
+	assert.Equal(t, "hello world", Strip("\x1b[1;31mhello\x1b[0m \x1b[38;2;1;2;3mworld\x1b[0m"))
+	assert.Equal(t, "plain", Strip("plain"))
+}
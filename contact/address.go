@@ -0,0 +1,116 @@
+package contact
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// Address - a postal address
+type Address struct {
+	Name         string
+	Organization string
+	// Lines - the street address lines
+	Lines []string
+	City  string
+	// Region - the state, province, or county
+	Region     string
+	PostalCode string
+	// Country - the country's ISO 3166-1 code (like "DE") or name
+	Country string
+}
+
+// postal code before the city, like "10117 Berlin"
+var postalFirst = map[string]bool{
+	"AT": true, "BE": true, "CH": true, "CZ": true, "DE": true, "DK": true,
+	"ES": true, "FI": true, "FR": true, "GR": true, "IS": true, "IT": true,
+	"LU": true, "MX": true, "NL": true, "NO": true, "PL": true, "PT": true,
+	"SE": true, "SI": true, "SK": true,
+}
+
+// city, region, and postal code on separate lines
+var separateLines = map[string]bool{"GB": true, "IE": true}
+
+// city, then region and postal code, like "Springfield, IL 62701"
+var cityComma = map[string]bool{"US": true, "CA": true, "PR": true}
+
+// FormatAddress formats the address as lines of text, following the
+// conventions of the address's country for the placement of the city, region,
+// and postal code. The country is written (in capitals) on the last line,
+// unless it's the same as the fromCountry (the country the mail is sent
+// from). Country codes are written as the country's English name.
+func FormatAddress(a Address, fromCountry string) string {
+	code := countryCode(a.Country)
+	lines := []string{}
+	add := func(parts ...string) {
+		l := strings.Join(nonEmpty(parts), " ")
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	add(a.Name)
+	add(a.Organization)
+	for _, l := range a.Lines {
+		add(l)
+	}
+
+	switch {
+	case postalFirst[code]:
+		add(a.PostalCode, a.City, a.Region)
+	case separateLines[code]:
+		add(a.City)
+		add(a.Region)
+		add(strings.ToUpper(a.PostalCode))
+	case cityComma[code]:
+		city := a.City
+		if city != "" && (a.Region != "" || a.PostalCode != "") {
+			city += ","
+		}
+		add(city, a.Region, a.PostalCode)
+	default:
+		add(a.City, a.Region, a.PostalCode)
+	}
+
+	if a.Country != "" && (fromCountry == "" || code != countryCode(fromCountry)) {
+		add(strings.ToUpper(countryName(a.Country)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// countryCode returns the upper-case ISO 3166-1 code for a country code, or
+// the upper-case name for anything else
+func countryCode(country string) string {
+	c := strings.ToUpper(strings.TrimSpace(country))
+	if len(c) == 2 || len(c) == 3 {
+		if r, err := language.ParseRegion(c); err == nil && r.IsCountry() {
+			return r.Canonicalize().String()
+		}
+	}
+	return c
+}
+
+// countryName returns the English name for a country code, or the country
+// unchanged when it's not a code
+func countryName(country string) string {
+	c := strings.TrimSpace(country)
+	if len(c) == 2 || len(c) == 3 {
+		if r, err := language.ParseRegion(c); err == nil && r.IsCountry() {
+			if n := display.English.Regions().Name(r); n != "" {
+				return n
+			}
+		}
+	}
+	return c
+}
+
+func nonEmpty(parts []string) []string {
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
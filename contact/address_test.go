@@ -0,0 +1,58 @@
+package contact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAddress(t *testing.T) {
+	testdata := []struct {
+		from     string
+		expected string
+		a        Address
+	}{
+		{
+			a: Address{
+				Name: "Erika Mustermann", Lines: []string{"Platz der Republik 1"},
+				PostalCode: "11011", City: "Berlin", Country: "DE",
+			},
+			expected: "Erika Mustermann\nPlatz der Republik 1\n11011 Berlin\nGERMANY",
+		},
+		{
+			from: "de",
+			a: Address{
+				Name: "Erika Mustermann", Lines: []string{"Platz der Republik 1"},
+				PostalCode: "11011", City: "Berlin", Country: "DE",
+			},
+			expected: "Erika Mustermann\nPlatz der Republik 1\n11011 Berlin",
+		},
+		{
+			from: "US",
+			a: Address{
+				Name: "Jane Doe", Organization: "Example Inc.",
+				Lines: []string{"1600 Amphitheatre Pkwy", "", "Suite 100"},
+				City:  "Mountain View", Region: "CA", PostalCode: "94043", Country: "USA",
+			},
+			expected: "Jane Doe\nExample Inc.\n1600 Amphitheatre Pkwy\nSuite 100\nMountain View, CA 94043",
+		},
+		{
+			a: Address{
+				Lines: []string{"10 Downing Street"}, City: "London",
+				PostalCode: "sw1a 2aa", Country: "UK",
+			},
+			expected: "10 Downing Street\nLondon\nSW1A 2AA\nUNITED KINGDOM",
+		},
+		{
+			a:        Address{Lines: []string{"1 Main St"}, City: "Tokyo", Country: "Japan"},
+			expected: "1 Main St\nTokyo\nJAPAN",
+		},
+		{
+			a:        Address{City: "Springfield", Region: "IL"},
+			expected: "Springfield IL",
+		},
+	}
+	for _, d := range testdata {
+		assert.Equal(t, d.expected, FormatAddress(d.a, d.from))
+	}
+}
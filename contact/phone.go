@@ -0,0 +1,88 @@
+// Package contact contains functions for normalizing and formatting contact
+// details - phone numbers and postal addresses.
+package contact
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+)
+
+var phoneFormats = map[string]libphonenumber.PhoneNumberFormat{
+	"e164":          libphonenumber.E164,
+	"international": libphonenumber.INTERNATIONAL,
+	"national":      libphonenumber.NATIONAL,
+	"rfc3966":       libphonenumber.RFC3966,
+}
+
+var phoneTypes = map[libphonenumber.PhoneNumberType]string{
+	libphonenumber.FIXED_LINE:           "fixed_line",
+	libphonenumber.MOBILE:               "mobile",
+	libphonenumber.FIXED_LINE_OR_MOBILE: "fixed_line_or_mobile",
+	libphonenumber.TOLL_FREE:            "toll_free",
+	libphonenumber.PREMIUM_RATE:         "premium_rate",
+	libphonenumber.SHARED_COST:          "shared_cost",
+	libphonenumber.VOIP:                 "voip",
+	libphonenumber.PERSONAL_NUMBER:      "personal_number",
+	libphonenumber.PAGER:                "pager",
+	libphonenumber.UAN:                  "uan",
+	libphonenumber.VOICEMAIL:            "voicemail",
+	libphonenumber.UNKNOWN:              "unknown",
+}
+
+// Phone - a parsed phone number
+type Phone struct {
+	// Region - the number's ISO 3166-1 region code, like "GB"
+	Region string `json:"region"`
+	// Type - the type of number, like "mobile" or "toll_free"
+	Type      string `json:"type"`
+	E164      string `json:"e164"`
+	Extension string `json:"extension,omitempty"`
+	// CountryCode - the country calling code, like 44
+	CountryCode int `json:"countryCode"`
+	// Valid - whether the number is valid for its region (not just
+	// plausibly-shaped)
+	Valid bool `json:"valid"`
+}
+
+// ParsePhone parses the phone number. Numbers without an international
+// prefix (like "+44") are parsed as numbers in the region, which is an ISO
+// 3166-1 region code, like "US".
+func ParsePhone(number, region string) (Phone, error) {
+	n, err := libphonenumber.Parse(number, strings.ToUpper(region))
+	if err != nil {
+		return Phone{}, fmt.Errorf("failed to parse phone number %q: %w", number, err)
+	}
+	return Phone{
+		Region:      libphonenumber.GetRegionCodeForNumber(n),
+		Type:        phoneTypes[libphonenumber.GetNumberType(n)],
+		E164:        libphonenumber.Format(n, libphonenumber.E164),
+		Extension:   strings.TrimLeftFunc(n.GetExtension(), notDigit),
+		CountryCode: int(n.GetCountryCode()),
+		Valid:       libphonenumber.IsValidNumber(n),
+	}, nil
+}
+
+// FormatPhone parses the phone number (like ParsePhone), and formats it in
+// one of the formats "e164" (like "+442079460018"), "international" (like
+// "+44 20 7946 0018"), "national" (like "020 7946 0018"), or "rfc3966" (like
+// "tel:+44-20-7946-0018"). Invalid numbers are errors.
+func FormatPhone(number, region, format string) (string, error) {
+	f, ok := phoneFormats[strings.ToLower(format)]
+	if !ok {
+		return "", fmt.Errorf("unknown phone number format %q - use one of e164, international, national, or rfc3966", format)
+	}
+	n, err := libphonenumber.Parse(number, strings.ToUpper(region))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse phone number %q: %w", number, err)
+	}
+	if !libphonenumber.IsValidNumber(n) {
+		return "", fmt.Errorf("invalid phone number %q", number)
+	}
+	return libphonenumber.Format(n, f), nil
+}
+
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
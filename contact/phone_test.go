@@ -0,0 +1,61 @@
+package contact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePhone(t *testing.T) {
+	p, err := ParsePhone("07400 123456", "gb")
+	require.NoError(t, err)
+	assert.Equal(t, Phone{
+		Region:      "GB",
+		Type:        "mobile",
+		E164:        "+447400123456",
+		CountryCode: 44,
+		Valid:       true,
+	}, p)
+
+	p, err = ParsePhone("+1 800 555 0199 ext. 12", "")
+	require.NoError(t, err)
+	assert.Equal(t, "toll_free", p.Type)
+	assert.Equal(t, "12", p.Extension)
+
+	p, err = ParsePhone("12345", "US")
+	require.NoError(t, err)
+	assert.False(t, p.Valid)
+
+	_, err = ParsePhone("not a number", "US")
+	assert.Error(t, err)
+
+	// no region, and no international prefix
+	_, err = ParsePhone("020 7946 0018", "")
+	assert.Error(t, err)
+}
+
+func TestFormatPhone(t *testing.T) {
+	testdata := []struct {
+		number, region, format string
+		expected               string
+	}{
+		{"020 7946 0018", "GB", "international", "+44 20 7946 0018"},
+		{"020 7946 0018", "GB", "national", "020 7946 0018"},
+		{"020 7946 0018", "GB", "E164", "+442079460018"},
+		{"(415) 555-2671", "US", "e164", "+14155552671"},
+		{"+1 415 555 2671", "", "national", "(415) 555-2671"},
+		{"+44 20 7946 0018", "US", "rfc3966", "tel:+44-20-7946-0018"},
+	}
+	for _, d := range testdata {
+		out, err := FormatPhone(d.number, d.region, d.format)
+		require.NoError(t, err, d)
+		assert.Equal(t, d.expected, out, d)
+	}
+
+	_, err := FormatPhone("020 7946 0018", "GB", "fancy")
+	assert.Error(t, err)
+
+	_, err = FormatPhone("12345", "US", "e164")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package gomplate
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strings"
 
@@ -21,11 +22,22 @@ func (c *tmplctx) Env() map[string]string {
 	return env
 }
 
-// createTmplContext reads the datasources for the given aliases
-func createTmplContext(ctx context.Context, aliases []string, d *data.Data) (interface{}, error) {
+// createTmplContext reads the datasources for the given aliases (after
+// preloading all of the datasources, if configured to), and adds
+// the given roots (like .Values and .Args). A context datasource can replace
+// a root that hasn't been given a value, but otherwise conflicts are errors.
+func createTmplContext(ctx context.Context, aliases []string, roots tmplctx, d *data.Data) (interface{}, error) {
+	d.Preload(ctx)
+
 	var err error
 	tctx := &tmplctx{}
+	for k, v := range roots {
+		(*tctx)[k] = v
+	}
 	for _, a := range aliases {
+		if v, ok := roots[a]; ok && !isEmptyRoot(v) {
+			return nil, fmt.Errorf("context alias %q conflicts with the .%s set on the command line or in the config", a, a)
+		}
 		if a == "." {
 			return d.Datasource(a)
 		}
@@ -36,3 +48,15 @@ func createTmplContext(ctx context.Context, aliases []string, d *data.Data) (int
 	}
 	return tctx, nil
 }
+
+func isEmptyRoot(v interface{}) bool {
+	switch t := v.(type) {
+	case []string:
+		return len(t) == 0
+	case map[string]string:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return v == nil
+}
@@ -26,7 +26,7 @@ func TestEnvGetsUpdatedEnvironment(t *testing.T) {
 
 func TestCreateContext(t *testing.T) {
 	ctx := context.Background()
-	c, err := createTmplContext(ctx, nil, nil)
+	c, err := createTmplContext(ctx, nil, nil, nil)
 	assert.NoError(t, err)
 	assert.Empty(t, c)
 
@@ -42,7 +42,7 @@ func TestCreateContext(t *testing.T) {
 	}
 	os.Setenv("foo", "foo: bar")
 	defer os.Unsetenv("foo")
-	c, err = createTmplContext(ctx, []string{"foo"}, d)
+	c, err = createTmplContext(ctx, []string{"foo"}, nil, d)
 	assert.NoError(t, err)
 	assert.IsType(t, &tmplctx{}, c)
 	tctx := c.(*tmplctx)
@@ -51,9 +51,30 @@ func TestCreateContext(t *testing.T) {
 
 	os.Setenv("bar", "bar: baz")
 	defer os.Unsetenv("bar")
-	c, err = createTmplContext(ctx, []string{"."}, d)
+	c, err = createTmplContext(ctx, []string{"."}, nil, d)
 	assert.NoError(t, err)
 	assert.IsType(t, map[string]interface{}{}, c)
 	ds = c.(map[string]interface{})
 	assert.Equal(t, "baz", ds["bar"])
 }
+
+func TestCreateContextWithRoots(t *testing.T) {
+	ctx := context.Background()
+	values := map[string]interface{}{"foo": "bar"}
+	roots := tmplctx{"Values": values, "Args": []string{}}
+	c, err := createTmplContext(ctx, nil, roots, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, values, (*c.(*tmplctx))["Values"])
+	assert.Equal(t, []string{}, (*c.(*tmplctx))["Args"])
+
+	_, err = createTmplContext(ctx, []string{"Values"}, roots, &data.Data{})
+	assert.Error(t, err)
+
+	// an empty root can be replaced by a context datasource
+	u, _ := url.Parse("env:///args?type=application/yaml")
+	d := &data.Data{Sources: map[string]*data.Source{"Args": {URL: u}}}
+	t.Setenv("args", "foo: bar")
+	c, err = createTmplContext(ctx, []string{"Args"}, roots, d)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, (*c.(*tmplctx))["Args"])
+}
@@ -2,6 +2,7 @@
 package conv
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
@@ -184,8 +185,11 @@ func MustAtoi(s string) int {
 
 // ToInt64 - convert input to an int64, if convertible. Otherwise, returns 0.
 func ToInt64(v interface{}) int64 {
-	if str, ok := v.(string); ok {
+	switch str := v.(type) {
+	case string:
 		return strToInt64(str)
+	case json.Number:
+		return strToInt64(string(str))
 	}
 
 	val := reflect.Indirect(reflect.ValueOf(v))
@@ -244,8 +248,11 @@ func ToInts(in ...interface{}) []int {
 
 // ToFloat64 - convert input to a float64, if convertible. Otherwise, returns 0.
 func ToFloat64(v interface{}) float64 {
-	if str, ok := v.(string); ok {
+	switch str := v.(type) {
+	case string:
 		return strToFloat64(str)
+	case json.Number:
+		return strToFloat64(string(str))
 	}
 
 	val := reflect.Indirect(reflect.ValueOf(v))
@@ -1,6 +1,7 @@
 package conv
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"testing"
@@ -135,6 +136,7 @@ func TestToInt64(t *testing.T) {
 	assert.Equal(t, int64(3), ToInt64("3.5"))
 	assert.Equal(t, int64(-1), ToInt64(uint64(math.MaxUint64)))
 	assert.Equal(t, int64(0xFF), ToInt64(uint8(math.MaxUint8)))
+	assert.Equal(t, int64(1234567890123456789), ToInt64(json.Number("1234567890123456789")))
 
 	assert.Equal(t, int64(0), ToInt64(nil))
 	assert.Equal(t, int64(0), ToInt64(false))
@@ -194,7 +196,7 @@ func TestToFloat64(t *testing.T) {
 		assert.Equal(t, 0.0, ToFloat64(n))
 	}
 	assert.Equal(t, 1.0, ToFloat64(true))
-	z = []interface{}{42, 42.0, float32(42), "42", "42.0", uint8(42), "0x2A", "052"}
+	z = []interface{}{42, 42.0, float32(42), "42", "42.0", uint8(42), "0x2A", "052", json.Number("42.0")}
 	for _, n := range z {
 		assert.Equal(t, 42.0, ToFloat64(n))
 	}
@@ -0,0 +1,100 @@
+package gomplate
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/spf13/afero"
+)
+
+// ConvertOptions - options for Convert
+//
+// Experimental: subject to breaking changes before the next major release
+type ConvertOptions struct {
+	// Input - the file to read, or "-" (or "") for Stdin
+	Input string
+	// Output - the file to write, or "-" (or "") for Stdout
+	Output string
+	// From - the input's format. Defaults to the format of the input file's
+	// extension.
+	From string
+	// To - the output's format. Defaults to the format of the output file's
+	// extension.
+	To string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+}
+
+// Convert converts a file from one data format to another (like YAML to
+// JSON), with the same parsers as datasources, and the same serializers as
+// functions like data.ToJSON.
+//
+// Experimental: subject to breaking changes before the next major release
+func Convert(opts ConvertOptions) error {
+	from, err := convertFormat(opts.From, opts.Input, "--from", "input")
+	if err != nil {
+		return err
+	}
+	to, err := convertFormat(opts.To, opts.Output, "--to", "output")
+	if err != nil {
+		return err
+	}
+
+	var in []byte
+	if isStdio(opts.Input) {
+		stdin := opts.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		in, err = io.ReadAll(stdin)
+	} else {
+		in, err = afero.ReadFile(aferoFS, opts.Input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	v, err := data.Decode(from, in)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s input: %w", from, err)
+	}
+	out, err := data.Encode(to, v)
+	if err != nil {
+		return fmt.Errorf("failed to convert to %s: %w", to, err)
+	}
+
+	if isStdio(opts.Output) {
+		stdout := opts.Stdout
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		_, err = stdout.Write(out)
+		return err
+	}
+	return afero.WriteFile(aferoFS, opts.Output, out, 0o644)
+}
+
+// convertFormat - the named format, or the format of the file's extension
+func convertFormat(name, file, flag, which string) (string, error) {
+	if name != "" {
+		f := data.Format(name)
+		if f == "" {
+			return "", fmt.Errorf("unknown format %q", name)
+		}
+		return f, nil
+	}
+	if !isStdio(file) {
+		if f := data.Format(file); f != "" {
+			return f, nil
+		}
+		return "", fmt.Errorf("can't tell the format of %s from its extension - use %s", file, flag)
+	}
+	return "", fmt.Errorf("%s must be given for standard %s", flag, which)
+}
+
+func isStdio(file string) bool {
+	return file == "" || file == "-"
+}
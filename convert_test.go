@@ -0,0 +1,44 @@
+package gomplate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = afero.WriteFile(aferoFS, "/in.yaml", []byte("name: web\ndb:\n  port: 5432\n"), 0o644)
+
+	err := Convert(ConvertOptions{Input: "/in.yaml", Output: "/out.toml"})
+	require.NoError(t, err)
+	b, err := afero.ReadFile(aferoFS, "/out.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "name = \"web\"\n\n[db]\n  port = 5432\n", string(b))
+
+	out := &bytes.Buffer{}
+	err = Convert(ConvertOptions{
+		Stdin: strings.NewReader(`{"a": [1, 2]}`), Stdout: out,
+		From: "json", To: "yml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a:\n  - 1\n  - 2\n", out.String())
+
+	err = Convert(ConvertOptions{Input: "/in.yaml"})
+	assert.EqualError(t, err, "--to must be given for standard output")
+	err = Convert(ConvertOptions{Input: "/in.yaml", Output: "/out.txt"})
+	assert.EqualError(t, err, "can't tell the format of /out.txt from its extension - use --to")
+	err = Convert(ConvertOptions{Input: "/in.yaml", To: "jsn"})
+	assert.EqualError(t, err, `unknown format "jsn"`)
+	err = Convert(ConvertOptions{Input: "/in.yaml", To: "env", Stdout: out})
+	assert.ErrorContains(t, err, "failed to convert to env")
+	err = Convert(ConvertOptions{Stdin: strings.NewReader("{"), From: "json", To: "yaml", Stdout: out})
+	assert.ErrorContains(t, err, "failed to parse json input")
+}
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// sourceCache holds the data read from datasources, so that each is only
+// read once. When maxBytes is set, the total size of the values held in
+// memory is limited, and the least-recently used values are evicted (to be
+// read again when needed). Values larger than spillBytes (when set) are
+// written to temporary files instead of being held in memory.
+type sourceCache struct {
+	entries map[string]*list.Element
+	lru     *list.List
+	dir     string
+
+	maxBytes   int64
+	spillBytes int64
+	size       int64
+	spilled    int
+}
+
+type cacheEntry struct {
+	key string
+	// value is nil when the entry was spilled to path
+	value []byte
+	path  string
+}
+
+func newSourceCache(maxBytes, spillBytes int64) *sourceCache {
+	return &sourceCache{
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		maxBytes:   maxBytes,
+		spillBytes: spillBytes,
+	}
+}
+
+func (c *sourceCache) get(key string) ([]byte, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if entry.value == nil {
+		b, err := os.ReadFile(entry.path)
+		if err != nil {
+			// the file has gone away - the source will just be read again
+			c.remove(e)
+			return nil, false
+		}
+		return b, true
+	}
+	c.lru.MoveToFront(e)
+	return entry.value, true
+}
+
+// put caches the value. Errors spilling to disk aren't fatal - the value is
+// just not cached.
+func (c *sourceCache) put(key string, value []byte) {
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+
+	size := int64(len(value))
+	if c.spillBytes > 0 && size > c.spillBytes {
+		path, err := c.spill(value)
+		if err == nil {
+			c.entries[key] = c.lru.PushBack(&cacheEntry{key: key, path: path})
+		}
+		return
+	}
+
+	if c.maxBytes > 0 {
+		if size > c.maxBytes {
+			return
+		}
+		c.evict(c.maxBytes - size)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value})
+	c.size += size
+}
+
+// evict removes the least-recently used in-memory values until no more than
+// limit bytes are held
+func (c *sourceCache) evict(limit int64) {
+	for e := c.lru.Back(); e != nil && c.size > limit; {
+		prev := e.Prev()
+		if e.Value.(*cacheEntry).value != nil {
+			c.remove(e)
+		}
+		e = prev
+	}
+}
+
+func (c *sourceCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.key)
+	if entry.value == nil {
+		_ = os.Remove(entry.path)
+	} else {
+		c.size -= int64(len(entry.value))
+	}
+}
+
+func (c *sourceCache) spill(value []byte) (string, error) {
+	if c.dir == "" {
+		dir, err := os.MkdirTemp("", "gomplate-cache-")
+		if err != nil {
+			return "", err
+		}
+		c.dir = dir
+	}
+	c.spilled++
+	path := filepath.Join(c.dir, strconv.Itoa(c.spilled))
+	if err := os.WriteFile(path, value, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// cleanup removes any spilled values
+func (c *sourceCache) cleanup() {
+	if c.dir != "" {
+		_ = os.RemoveAll(c.dir)
+		c.dir = ""
+	}
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	c.size = 0
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceCache(t *testing.T) {
+	c := newSourceCache(0, 0)
+	c.put("a", []byte("hello"))
+	b, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(b))
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+}
+
+func TestSourceCacheEviction(t *testing.T) {
+	c := newSourceCache(10, 0)
+	c.put("a", []byte("aaaa"))
+	c.put("b", []byte("bbbb"))
+
+	// a is now the most recently used, so b is evicted
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	c.put("c", []byte("cccc"))
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+	_, ok = c.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), c.size)
+
+	// values larger than the limit aren't cached
+	c.put("d", []byte("ddddddddddd"))
+	_, ok = c.get("d")
+	assert.False(t, ok)
+	assert.Equal(t, int64(8), c.size)
+
+	// replacing a value doesn't count it twice
+	c.put("a", []byte("aa"))
+	assert.Equal(t, int64(6), c.size)
+}
+
+func TestSourceCacheSpill(t *testing.T) {
+	c := newSourceCache(4, 8)
+	c.put("small", []byte("abc"))
+	c.put("big", []byte("0123456789"))
+
+	assert.Equal(t, int64(3), c.size)
+	require.NotEmpty(t, c.dir)
+	entry := c.entries["big"].Value.(*cacheEntry)
+	assert.Nil(t, entry.value)
+
+	b, ok := c.get("big")
+	assert.True(t, ok)
+	assert.Equal(t, "0123456789", string(b))
+
+	// spilled values don't count against the limit
+	b, ok = c.get("small")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", string(b))
+
+	dir := c.dir
+	c.cleanup()
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+	_, ok = c.get("big")
+	assert.False(t, ok)
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/joho/godotenv"
+)
+
+// formatMimetypes - the formats that can be decoded with Decode, by name
+var formatMimetypes = map[string]string{
+	"csv":     csvMimetype,
+	"env":     envMimetype,
+	"hcl":     hclMimetype,
+	"ini":     iniMimetype,
+	"json":    jsonMimetype,
+	"msgpack": msgpackMimetype,
+	"ndjson":  ndjsonMimetype,
+	"toml":    tomlMimetype,
+	"xml":     xmlMimetype,
+	"yaml":    yamlMimetype,
+}
+
+// formatAliases - other names for formats, including their file extensions
+var formatAliases = map[string]string{
+	"yml":   "yaml",
+	"jsonl": "ndjson",
+	"tf":    "hcl",
+	"mpk":   "msgpack",
+}
+
+// encoders - the formats that can be encoded with Encode, by name
+var encoders = map[string]func(interface{}) (string, error){
+	"csv": func(in interface{}) (string, error) { return ToCSV(in) },
+	"env": toDotEnv,
+	"ini": ToINI,
+	"json": func(in interface{}) (string, error) {
+		s, err := ToJSONPretty("  ", in)
+		return s + "\n", err
+	},
+	"msgpack": ToMsgPack,
+	"toml":    ToTOML,
+	"xml":     ToXML,
+	"yaml":    ToYAML,
+}
+
+// Format - the canonical name of a format, given its name, an alias, or a
+// file name with the format's extension (like "config.yml"). Returns "" for
+// unknown formats.
+func Format(name string) string {
+	name = strings.ToLower(name)
+	if ext := filepath.Ext(name); ext != "" {
+		name = ext[1:]
+	}
+	if a, ok := formatAliases[name]; ok {
+		name = a
+	}
+	if _, ok := formatMimetypes[name]; ok {
+		return name
+	}
+	return ""
+}
+
+// DecodeFormats - the names of the formats that Decode supports
+func DecodeFormats() []string {
+	names := make([]string, 0, len(formatMimetypes))
+	for k := range formatMimetypes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EncodeFormats - the names of the formats that Encode supports
+func EncodeFormats() []string {
+	names := make([]string, 0, len(encoders))
+	for k := range encoders {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Decode - parse the input in the named format, with the same parser used for
+// datasources of that format
+func Decode(format string, in []byte) (interface{}, error) {
+	mimeType, ok := formatMimetypes[Format(format)]
+	if !ok {
+		return nil, fmt.Errorf("can't decode format %q - must be one of %s", format, strings.Join(DecodeFormats(), ", "))
+	}
+	return parseData(mimeType, string(in))
+}
+
+// Encode - marshal the value in the named format
+func Encode(format string, in interface{}) ([]byte, error) {
+	enc, ok := encoders[Format(format)]
+	if !ok {
+		return nil, fmt.Errorf("can't encode format %q - must be one of %s", format, strings.Join(EncodeFormats(), ", "))
+	}
+	s, err := enc(in)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// toDotEnv - marshal a flat map as a dotenv file
+func toDotEnv(in interface{}) (string, error) {
+	m, ok := in.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unable to marshal dotenv: input must be a map, got %T", in)
+	}
+	env := make(map[string]string, len(m))
+	for k, v := range m {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return "", fmt.Errorf("unable to marshal dotenv: %s: nested maps and arrays can't be represented in dotenv files", k)
+		}
+		env[k] = conv.ToString(v)
+	}
+	s, err := godotenv.Marshal(env)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal dotenv: %w", err)
+	}
+	return s + "\n", nil
+}
@@ -0,0 +1,44 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "yaml", Format("yaml"))
+	assert.Equal(t, "yaml", Format("YML"))
+	assert.Equal(t, "yaml", Format("config.yml"))
+	assert.Equal(t, "hcl", Format("/infra/main.tf"))
+	assert.Equal(t, "msgpack", Format("data.mpk"))
+	assert.Equal(t, "", Format("config.bogus"))
+	assert.Equal(t, "", Format("bogus"))
+}
+
+func TestDecodeEncode(t *testing.T) {
+	v, err := Decode("yaml", []byte("name: web\nport: 80\n"))
+	require.NoError(t, err)
+
+	b, err := Encode("json", v)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"web\",\n  \"port\": 80\n}\n", string(b))
+
+	b, err = Encode("env", v)
+	require.NoError(t, err)
+	assert.Equal(t, "name=\"web\"\nport=80\n", string(b))
+
+	v, err = Decode("csv", []byte("a,b\n1,2\n"))
+	require.NoError(t, err)
+	b, err = Encode("yaml", v)
+	require.NoError(t, err)
+	assert.Equal(t, "- - a\n  - b\n- - \"1\"\n  - \"2\"\n", string(b))
+
+	_, err = Decode("bogus", nil)
+	assert.ErrorContains(t, err, `can't decode format "bogus"`)
+	_, err = Encode("hcl", v)
+	assert.ErrorContains(t, err, `can't encode format "hcl" - must be one of csv, env, ini, json, msgpack, toml, xml, yaml`)
+	_, err = Encode("env", map[string]interface{}{"a": []interface{}{}})
+	assert.ErrorContains(t, err, "a: nested maps and arrays")
+}
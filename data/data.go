@@ -46,7 +46,7 @@ func unmarshalArray(obj []interface{}, in string, f func([]byte, interface{}) er
 // JSON - Unmarshal a JSON Object. Can be ejson-encrypted.
 func JSON(in string) (map[string]interface{}, error) {
 	obj := make(map[string]interface{})
-	out, err := unmarshalObj(obj, in, yaml.Unmarshal)
+	out, err := unmarshalObj(obj, in, yamlUnmarshal)
 	if err != nil {
 		return out, err
 	}
@@ -70,7 +70,7 @@ func decryptEJSON(in string) (map[string]interface{}, error) {
 		return nil, errors.WithStack(err)
 	}
 	obj := make(map[string]interface{})
-	out, err := unmarshalObj(obj, rOut.String(), yaml.Unmarshal)
+	out, err := unmarshalObj(obj, rOut.String(), yamlUnmarshal)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -81,7 +81,7 @@ func decryptEJSON(in string) (map[string]interface{}, error) {
 // JSONArray - Unmarshal a JSON Array
 func JSONArray(in string) ([]interface{}, error) {
 	obj := make([]interface{}, 1)
-	return unmarshalArray(obj, in, yaml.Unmarshal)
+	return unmarshalArray(obj, in, yamlUnmarshal)
 }
 
 // YAML - Unmarshal a YAML Object
@@ -89,20 +89,26 @@ func YAML(in string) (map[string]interface{}, error) {
 	obj := make(map[string]interface{})
 	s := strings.NewReader(in)
 	d := yaml.NewDecoder(s)
+	n := &yaml.Node{}
 	for {
-		err := d.Decode(&obj)
+		err := d.Decode(n)
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
 			return nil, err
 		}
+		err = n.Decode(&obj)
+		if err != nil {
+			return nil, err
+		}
 		if obj != nil {
 			break
 		}
 	}
 
 	err := stringifyYAMLMapMapKeys(obj)
+	preserveNumbers(n, obj)
 	return obj, err
 }
 
@@ -111,19 +117,25 @@ func YAMLArray(in string) ([]interface{}, error) {
 	obj := make([]interface{}, 1)
 	s := strings.NewReader(in)
 	d := yaml.NewDecoder(s)
+	n := &yaml.Node{}
 	for {
-		err := d.Decode(&obj)
+		err := d.Decode(n)
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
 			return nil, err
 		}
+		err = n.Decode(&obj)
+		if err != nil {
+			return nil, err
+		}
 		if obj != nil {
 			break
 		}
 	}
 	err := stringifyYAMLArrayMapKeys(obj)
+	preserveNumbers(n, obj)
 	return obj, err
 }
 
@@ -388,7 +400,7 @@ func toJSONBytes(in interface{}) ([]byte, error) {
 
 // ToJSON - Stringify a struct as JSON
 func ToJSON(in interface{}) (string, error) {
-	s, err := toJSONBytes(in)
+	s, err := toJSONBytes(marshalable(in))
 	if err != nil {
 		return "", err
 	}
@@ -398,7 +410,7 @@ func ToJSON(in interface{}) (string, error) {
 // ToJSONPretty - Stringify a struct as JSON (indented)
 func ToJSONPretty(indent string, in interface{}) (string, error) {
 	out := new(bytes.Buffer)
-	b, err := toJSONBytes(in)
+	b, err := toJSONBytes(marshalable(in))
 	if err != nil {
 		return "", err
 	}
@@ -423,7 +435,11 @@ func ToYAML(in interface{}) (string, error) {
 		return buf.Bytes(), err
 	}
 
-	return marshalObj(in, marshal)
+	v, err := yamlDocument(marshalable(in))
+	if err != nil {
+		return "", err
+	}
+	return marshalObj(v, marshal)
 }
 
 // ToTOML - Stringify a struct as TOML
@@ -2,6 +2,7 @@ package data
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"mime"
 	"net/http"
@@ -9,14 +10,37 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/afero"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/hairyhenderson/gomplate/v3/feed"
+	"github.com/hairyhenderson/gomplate/v3/internal/akeyless"
+	"github.com/hairyhenderson/gomplate/v3/internal/artifactrepo"
+	"github.com/hairyhenderson/gomplate/v3/internal/cfkv"
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/conjur"
+	"github.com/hairyhenderson/gomplate/v3/internal/credhelper"
+	"github.com/hairyhenderson/gomplate/v3/internal/doppler"
+	"github.com/hairyhenderson/gomplate/v3/internal/elastic"
+	"github.com/hairyhenderson/gomplate/v3/internal/gitforge"
+	"github.com/hairyhenderson/gomplate/v3/internal/integrity"
+	"github.com/hairyhenderson/gomplate/v3/internal/jira"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/hairyhenderson/gomplate/v3/internal/provenance"
+	"github.com/hairyhenderson/gomplate/v3/internal/ratelimit"
+	"github.com/hairyhenderson/gomplate/v3/internal/servicenow"
+	"github.com/hairyhenderson/gomplate/v3/internal/zkclient"
 	"github.com/hairyhenderson/gomplate/v3/libkv"
 	"github.com/hairyhenderson/gomplate/v3/vault"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/api/bigquery/v2"
 )
 
 func regExtension(ext, typ string) {
@@ -31,9 +55,18 @@ func init() {
 	regExtension(".json", jsonMimetype)
 	regExtension(".yml", yamlMimetype)
 	regExtension(".yaml", yamlMimetype)
+	regExtension(".ndjson", ndjsonMimetype)
+	regExtension(".jsonl", ndjsonMimetype)
 	regExtension(".csv", csvMimetype)
 	regExtension(".toml", tomlMimetype)
 	regExtension(".env", envMimetype)
+	regExtension(".rss", rssMimetype)
+	regExtension(".atom", atomMimetype)
+	regExtension(".xml", xmlMimetype)
+	regExtension(".hcl", hclMimetype)
+	regExtension(".tf", hclMimetype)
+	regExtension(".ini", iniMimetype)
+	regExtension(".msgpack", msgpackMimetype)
 }
 
 // registerReaders registers the source-reader functions
@@ -46,6 +79,7 @@ func (d *Data) registerReaders() {
 	d.sourceReaders["consul+http"] = readConsul
 	d.sourceReaders["consul+https"] = readConsul
 	d.sourceReaders["env"] = readEnv
+	d.sourceReaders["exec"] = readExec
 	d.sourceReaders["file"] = readFile
 	d.sourceReaders["http"] = readHTTP
 	d.sourceReaders["https"] = readHTTP
@@ -61,10 +95,74 @@ func (d *Data) registerReaders() {
 	d.sourceReaders["git+http"] = readGit
 	d.sourceReaders["git+https"] = readGit
 	d.sourceReaders["git+ssh"] = readGit
+	d.sourceReaders["nats"] = readNATS
+	d.sourceReaders["nats+kv"] = readNATS
+	d.sourceReaders["mqtt"] = readMQTT
+	d.sourceReaders["mqtts"] = readMQTT
+	d.sourceReaders["zk"] = readZooKeeper
+	d.sourceReaders["zk+tls"] = readZooKeeper
+	d.sourceReaders["conjur"] = readConjur
+	d.sourceReaders["doppler"] = readDoppler
+	d.sourceReaders["akeyless"] = readAkeyless
+	d.sourceReaders["cfkv"] = readCFKV
+	d.sourceReaders["github"] = readGitForge
+	d.sourceReaders["gitlab"] = readGitForge
+	d.sourceReaders["artifactory"] = readArtifactRepo
+	d.sourceReaders["nexus"] = readArtifactRepo
+	d.sourceReaders["jira"] = readJira
+	d.sourceReaders["servicenow"] = readServiceNow
+	d.sourceReaders["es"] = readElastic
+	d.sourceReaders["es+http"] = readElastic
+	d.sourceReaders["bigquery"] = readBigQuery
+	d.sourceReaders["athena"] = readAthena
+	d.sourceReaders["postgres"] = readSQL
+	d.sourceReaders["postgresql"] = readSQL
+	d.sourceReaders["mysql"] = readSQL
+	d.sourceReaders["k8s"] = readKube
+	d.sourceReaders["ofrep"] = readOFREP
+	d.sourceReaders["ofrep+http"] = readOFREP
+}
+
+// secretSchemes - schemes of datasources that read from secret stores. Their
+// values are recorded as secret, so they can be masked (see --preview).
+// Kubernetes Secrets are secret too - see isSecretURL.
+var secretSchemes = map[string]bool{
+	"vault":       true,
+	"vault+http":  true,
+	"vault+https": true,
+	"aws+sm":      true,
+	"aws+smp":     true,
+	"conjur":      true,
+	"doppler":     true,
+	"akeyless":    true,
+}
+
+// isSecretURL - whether the datasource reads from a secret store
+func isSecretURL(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	if u.Scheme == "k8s" {
+		return isKubeSecret(u)
+	}
+	return secretSchemes[u.Scheme]
+}
+
+// recordProvenance - records the datasource's data with the tracker in the
+// context (if any), as secret if it's from a secret store
+func recordProvenance(ctx context.Context, source *Source, name string, data interface{}) {
+	tracker := provenance.FromContext(ctx)
+	if source != nil && isSecretURL(source.URL) {
+		tracker.RecordSecret(name, data)
+		return
+	}
+	tracker.Record(name, data)
 }
 
 // lookupReader - return the reader function for the given scheme
 func (d *Data) lookupReader(scheme string) (func(context.Context, *Source, ...string) ([]byte, error), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if d.sourceReaders == nil {
 		d.registerReaders()
 	}
@@ -83,18 +181,94 @@ type Data struct {
 	Sources map[string]*Source
 
 	sourceReaders map[string]func(context.Context, *Source, ...string) ([]byte, error)
-	cache         map[string][]byte
+	cache         *sourceCache
+
+	// mu guards Sources, the cache, and the other state shared between
+	// reads, so that templates can be rendered concurrently. It's never held
+	// while a source is read - see lockSource.
+	mu sync.Mutex
+	// sourceLocks - held while each source is read, because readers keep
+	// state in their Source
+	sourceLocks map[*Source]chan struct{}
+	// reads are recorded here while RecordReads is in progress
+	reads []Read
+	// when set (by UseSnapshots), data is only read from these snapshots
+	snapshots map[string]Snapshot
 
 	// headers from the --datasource-header/-H option that don't reference datasources from the commandline
 	ExtraHeaders map[string]http.Header
+
+	// Schemes - URL scheme shortcuts, which are expanded (with
+	// config.ExpandScheme) before sources are read
+	Schemes map[string]string
+
+	// CacheDir - when set, the data from sources with a 'ttl' query parameter
+	// is cached in files in this directory, so that it can be used by later
+	// runs until it's older than the TTL
+	CacheDir string
+
+	// PreloadConcurrency - when set, Preload reads the defined sources
+	// concurrently, at most this many at a time. 0 disables preloading, so
+	// sources are only read when they're used.
+	PreloadConcurrency int
+
+	// OrderedMaps - record the key order of parsed JSON, YAML, and TOML
+	// objects, so that ToJSON and ToYAML can preserve it
+	OrderedMaps bool
+	// PreserveComments - like OrderedMaps, but also record the comments and
+	// formatting of parsed YAML, so that ToYAML can preserve them
+	PreserveComments bool
+
+	// CacheLimit - the maximum number of bytes of datasource data to hold in
+	// memory. When exceeded, the least-recently used data is evicted, and
+	// will be read again if needed. 0 means no limit.
+	CacheLimit int64
+	// SpillThreshold - data larger than this many bytes is cached in a
+	// temporary file rather than in memory. 0 means data is never spilled.
+	SpillThreshold int64
+
+	// Verifier - when set, data is verified before it's used
+	Verifier Verifier
+
+	// NetworkPolicy - when set, datasources can only be read from the schemes
+	// and hosts it allows
+	NetworkPolicy *netpolicy.Policy
+	// RateLimiter - when set, requests that datasources make to the hosts it
+	// limits are delayed to stay within the limits
+	RateLimiter *ratelimit.Limiter
+	// MaxConnsPerHost - the maximum number of connections that datasources
+	// can have open to each host at once. 0 means no limit.
+	MaxConnsPerHost int
+	// CredentialHelpers - when set, http, git, and vault datasources get
+	// their credentials from the helpers configured for their hosts, unless
+	// they're given credentials some other way
+	CredentialHelpers *credhelper.Helpers
+
+	// shared by all reads, by scheme - see transport
+	transportsMu sync.Mutex
+	transports   map[string]http.RoundTripper
+
+	// streams in progress, that aren't tracked in a context - see Stream
+	streams streamSet
+}
+
+// Verifier - verifies data read from a datasource's URL, typically by
+// checking a detached signature. The signature is read with readSignature,
+// from the same datasource with the given suffix appended.
+type Verifier interface {
+	Verify(u *url.URL, data []byte, readSignature func(suffix string) ([]byte, error)) error
 }
 
 // Cleanup - clean up datasources before shutting the process down - things
 // like Logging out happen here
 func (d *Data) Cleanup() {
+	_ = d.CloseStreams()
 	for _, s := range d.Sources {
 		s.cleanup()
 	}
+	if d.cache != nil {
+		d.cache.cleanup()
+	}
 }
 
 // NewData - constructor for Data
@@ -134,6 +308,7 @@ func FromConfig(ctx context.Context, cfg *config.Config) *Data {
 		Ctx:          ctx,
 		Sources:      sources,
 		ExtraHeaders: cfg.ExtraHeaders,
+		Schemes:      cfg.Schemes,
 	}
 }
 
@@ -143,13 +318,33 @@ type Source struct {
 	Alias             string
 	URL               *url.URL
 	Header            http.Header             // used for http[s]: URLs, nil otherwise
+	Integrity         string                  // the pinned digest of the data, if any
 	fs                afero.Fs                // used for file: URLs, nil otherwise
 	hc                *http.Client            // used for http[s]: URLs, nil otherwise
 	vc                *vault.Vault            // used for vault: URLs, nil otherwise
-	kv                *libkv.LibKV            // used for consul:, etcd:, zookeeper: URLs, nil otherwise
+	kv                *libkv.LibKV            // used for consul: URLs, nil otherwise
+	nc                *nats.Conn              // used for nats:, nats+kv: URLs, nil otherwise
+	mc                mqtt.Client             // used for mqtt:, mqtts: URLs, nil otherwise
+	zk                zkclient.Conn           // used for zk:, zk+tls: URLs, nil otherwise
+	cj                *conjur.Client          // used for conjur: URLs, nil otherwise
+	dop               *doppler.Client         // used for doppler: URLs, nil otherwise
+	ak                *akeyless.Client        // used for akeyless: URLs, nil otherwise
+	cf                *cfkv.Client            // used for cfkv: URLs, nil otherwise
+	gf                gitforge.Forge          // used for github:, gitlab: URLs, nil otherwise
+	ar                artifactrepo.Repository // used for artifactory:, nexus: URLs, nil otherwise
+	jc                *jira.Client            // used for jira: URLs, nil otherwise
+	sn                *servicenow.Client      // used for servicenow: URLs, nil otherwise
+	es                *elastic.Client         // used for es:, es+http: URLs, nil otherwise
+	bq                *bigquery.Service       // used for bigquery: URLs, nil otherwise
+	athena            athenaClient            // used for athena: URLs, nil otherwise
+	db                *sql.DB                 // used for postgres:, mysql: URLs, nil otherwise
+	kc                *kube.Client            // used for k8s: URLs, nil otherwise
 	asmpg             awssmpGetter            // used for aws+smp:, nil otherwise
 	awsSecretsManager awsSecretsManagerGetter // used for aws+sm, nil otherwise
 	mediaType         string
+	ttl               time.Duration // how long to cache the data in CacheDir - see sourceTTL
+	queryArg          bool          // the arg is a query, not a path, so it doesn't affect the MIME type
+	fromTemplate      bool          // defined by a template (or referenced by URL), rather than configured
 }
 
 func (s *Source) inherit(parent *Source) {
@@ -157,6 +352,22 @@ func (s *Source) inherit(parent *Source) {
 	s.hc = parent.hc
 	s.vc = parent.vc
 	s.kv = parent.kv
+	s.nc = parent.nc
+	s.mc = parent.mc
+	s.zk = parent.zk
+	s.cj = parent.cj
+	s.dop = parent.dop
+	s.ak = parent.ak
+	s.cf = parent.cf
+	s.gf = parent.gf
+	s.ar = parent.ar
+	s.jc = parent.jc
+	s.sn = parent.sn
+	s.es = parent.es
+	s.bq = parent.bq
+	s.athena = parent.athena
+	s.db = parent.db
+	s.kc = parent.kc
 	s.asmpg = parent.asmpg
 }
 
@@ -167,6 +378,18 @@ func (s *Source) cleanup() {
 	if s.kv != nil {
 		s.kv.Logout()
 	}
+	if s.nc != nil {
+		s.nc.Close()
+	}
+	if s.mc != nil {
+		s.mc.Disconnect(250)
+	}
+	if s.zk != nil {
+		s.zk.Close()
+	}
+	if s.db != nil {
+		_ = s.db.Close()
+	}
 }
 
 // mimeType returns the MIME type to use as a hint for parsing the datasource.
@@ -179,6 +402,9 @@ func (s *Source) cleanup() {
 // 3. otherwise, a MIME type is calculated from the file extension, if the extension is registered
 // 4. otherwise, the default type of 'text/plain' is used
 func (s *Source) mimeType(arg string) (mimeType string, err error) {
+	if s.queryArg {
+		arg = ""
+	}
 	if len(arg) > 0 {
 		if strings.HasPrefix(arg, "//") {
 			arg = arg[1:]
@@ -236,7 +462,9 @@ func (d *Data) DefineDatasource(alias, value string) (string, error) {
 	if alias == "" {
 		return "", errors.New("datasource alias must be provided")
 	}
-	if d.DatasourceExists(alias) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.Sources[alias]; ok {
 		return "", nil
 	}
 	srcURL, err := config.ParseSourceURL(value)
@@ -244,9 +472,10 @@ func (d *Data) DefineDatasource(alias, value string) (string, error) {
 		return "", err
 	}
 	s := &Source{
-		Alias:  alias,
-		URL:    srcURL,
-		Header: d.ExtraHeaders[alias],
+		Alias:        alias,
+		URL:          srcURL,
+		Header:       d.ExtraHeaders[alias],
+		fromTemplate: true,
 	}
 	if d.Sources == nil {
 		d.Sources = make(map[string]*Source)
@@ -257,10 +486,13 @@ func (d *Data) DefineDatasource(alias, value string) (string, error) {
 
 // DatasourceExists -
 func (d *Data) DatasourceExists(alias string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	_, ok := d.Sources[alias]
 	return ok
 }
 
+// lookupSource - must be called with d.mu held
 func (d *Data) lookupSource(alias string) (*Source, error) {
 	source, ok := d.Sources[alias]
 	if !ok {
@@ -269,27 +501,82 @@ func (d *Data) lookupSource(alias string) (*Source, error) {
 			return nil, errors.Errorf("Undefined datasource '%s'", alias)
 		}
 		source = &Source{
-			Alias:  alias,
-			URL:    srcURL,
-			Header: d.ExtraHeaders[alias],
+			Alias:        alias,
+			URL:          srcURL,
+			Header:       d.ExtraHeaders[alias],
+			fromTemplate: true,
 		}
 		d.Sources[alias] = source
 	}
 	if source.Alias == "" {
 		source.Alias = alias
 	}
+	d.expandScheme(source)
 	return source, nil
 }
 
+// expandScheme - expands the source's URL scheme shortcut, if it has one.
+// Must be called with d.mu held.
+func (d *Data) expandScheme(source *Source) {
+	// the URL is only replaced when it changes, as it's read concurrently
+	// by reads that hold the source's lock
+	if u := config.ExpandScheme(d.Schemes, source.URL); u != source.URL {
+		source.URL = u
+	}
+}
+
+// lockSource - waits until the source isn't being read by anything else, and
+// locks it, so that each source is read by one goroutine at a time. Reads of
+// different sources can overlap. The returned function unlocks the source.
+// Must be called without d.mu held.
+func (d *Data) lockSource(ctx context.Context, source *Source) (func(), error) {
+	d.mu.Lock()
+	if d.sourceLocks == nil {
+		d.sourceLocks = map[*Source]chan struct{}{}
+	}
+	l, ok := d.sourceLocks[source]
+	if !ok {
+		l = make(chan struct{}, 1)
+		d.sourceLocks[source] = l
+	}
+	d.mu.Unlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func (d *Data) readDataSource(ctx context.Context, alias string, args ...string) (data, mimeType string, err error) {
+	d.mu.Lock()
 	source, err := d.lookupSource(alias)
+	d.mu.Unlock()
 	if err != nil {
 		return "", "", err
 	}
+	unlock, err := d.lockSource(ctx, source)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Couldn't read datasource '%s'", alias)
+	}
+	defer unlock()
+
+	start := time.Now()
 	b, err := d.readSource(ctx, source, args...)
 	if err != nil {
 		return "", "", errors.Wrapf(err, "Couldn't read datasource '%s'", alias)
 	}
+	d.mu.Lock()
+	d.recordRead(source, args, b, time.Since(start))
+	d.mu.Unlock()
+	if isSecretURL(source.URL) {
+		// so included secrets are masked too
+		provenance.FromContext(ctx).RecordSecret("datasource "+alias, string(b))
+	}
 
 	subpath := ""
 	if len(args) > 0 {
@@ -302,20 +589,72 @@ func (d *Data) readDataSource(ctx context.Context, alias string, args ...string)
 	return string(b), mimeType, nil
 }
 
+// readContext - a context to read with, which has d.Ctx's values, and is
+// also cancelled when ctx is. The returned function must be called when the
+// read is done.
+func (d *Data) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	base := d.Ctx
+	if base == nil {
+		base = context.Background()
+	}
+	out, cancel := context.WithCancel(base)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+	return out, func() {
+		close(done)
+		cancel()
+	}
+}
+
 // Include -
 func (d *Data) Include(alias string, args ...string) (string, error) {
 	data, _, err := d.readDataSource(d.Ctx, alias, args...)
 	return data, err
 }
 
+// IncludeContext - like Include, but the read is cancelled when ctx is
+func (d *Data) IncludeContext(ctx context.Context, alias string, args ...string) (string, error) {
+	ctx, cancel := d.readContext(ctx)
+	defer cancel()
+	data, _, err := d.readDataSource(ctx, alias, args...)
+	return data, err
+}
+
 // Datasource -
 func (d *Data) Datasource(alias string, args ...string) (interface{}, error) {
-	data, mimeType, err := d.readDataSource(d.Ctx, alias, args...)
+	return d.datasource(d.Ctx, alias, args...)
+}
+
+// DatasourceContext - like Datasource, but the read is cancelled when ctx is
+func (d *Data) DatasourceContext(ctx context.Context, alias string, args ...string) (interface{}, error) {
+	ctx, cancel := d.readContext(ctx)
+	defer cancel()
+	return d.datasource(ctx, alias, args...)
+}
+
+func (d *Data) datasource(ctx context.Context, alias string, args ...string) (interface{}, error) {
+	data, mimeType, err := d.readDataSource(ctx, alias, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return parseData(mimeType, data)
+	out, err := parseData(mimeType, data)
+	if err == nil {
+		d.mu.Lock()
+		source := d.Sources[alias]
+		d.mu.Unlock()
+		recordProvenance(ctx, source, "datasource "+alias, out)
+	}
+	if err != nil || !(d.OrderedMaps || d.PreserveComments) {
+		return out, err
+	}
+	return out, recordOrder(mimeType, data, out, d.PreserveComments)
 }
 
 func parseData(mimeType, s string) (out interface{}, err error) {
@@ -328,6 +667,8 @@ func parseData(mimeType, s string) (out interface{}, err error) {
 		}
 	case jsonArrayMimetype:
 		out, err = JSONArray(s)
+	case ndjsonMimetype:
+		out, err = ndJSON(strings.NewReader(s))
 	case yamlMimetype:
 		out, err = YAML(s)
 		if err != nil {
@@ -340,6 +681,20 @@ func parseData(mimeType, s string) (out interface{}, err error) {
 		out, err = TOML(s)
 	case envMimetype:
 		out, err = dotEnv(s)
+	case rssMimetype, atomMimetype:
+		var f *feed.Feed
+		f, err = feed.Parse(s)
+		if err == nil {
+			out = f.Map()
+		}
+	case xmlMimetype:
+		out, err = XML(s)
+	case hclMimetype:
+		out, err = HCL(s)
+	case iniMimetype:
+		out, err = INI(s)
+	case msgpackMimetype:
+		out, err = MsgPack(s)
 	case textMimetype:
 		out = s
 	default:
@@ -351,42 +706,253 @@ func parseData(mimeType, s string) (out interface{}, err error) {
 // DatasourceReachable - Determines if the named datasource is reachable with
 // the given arguments. Reads from the datasource, and discards the returned data.
 func (d *Data) DatasourceReachable(alias string, args ...string) bool {
+	d.mu.Lock()
 	source, ok := d.Sources[alias]
+	d.mu.Unlock()
 	if !ok {
 		return false
 	}
-	_, err := d.readSource(d.Ctx, source, args...)
+	unlock, err := d.lockSource(d.Ctx, source)
+	if err != nil {
+		return false
+	}
+	defer unlock()
+	_, err = d.readSource(d.Ctx, source, args...)
 	return err == nil
 }
 
+// sourceWriters - the functions that write to datasources, by scheme. Only
+// some datasources can be written to.
+var sourceWriters = map[string]func(context.Context, *Source, map[string]interface{}, ...string) ([]byte, error){
+	"vault":       writeVault,
+	"vault+http":  writeVault,
+	"vault+https": writeVault,
+}
+
+// DatasourceWrite - writes data to the datasource (at the optional subpath),
+// and returns the parsed response. The last argument is the data to write, a
+// map. Writes are never cached, so each call writes again.
+func (d *Data) DatasourceWrite(alias string, args ...interface{}) (interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.Errorf("wrong number of args: wanted alias, [subpath,] data - got %d", len(args)+1)
+	}
+	body, err := writeBody(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	var subpath []string
+	if len(args) == 2 {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, errors.Errorf("subpath must be a string, not %T", args[0])
+		}
+		subpath = []string{s}
+	}
+
+	d.mu.Lock()
+	source, err := d.lookupSource(alias)
+	snapshots := d.snapshots != nil
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if snapshots {
+		return nil, errors.Errorf("can't write to datasource '%s' while rendering from a snapshot", alias)
+	}
+	unlock, err := d.lockSource(d.Ctx, source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't write to datasource '%s'", alias)
+	}
+	defer unlock()
+	w, ok := sourceWriters[source.URL.Scheme]
+	if !ok {
+		return nil, errors.Errorf("datasources with scheme %s can't be written to", source.URL.Scheme)
+	}
+	if err := d.NetworkPolicy.CheckURL(source.URL); err != nil {
+		return nil, errors.Wrapf(err, "can't write to datasource '%s'", alias)
+	}
+
+	b, err := w(d.sourceContext(d.Ctx), source, body, subpath...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Couldn't write to datasource '%s'", alias)
+	}
+	return parseData(jsonMimetype, string(b))
+}
+
+// writeBody - the data to write, which must be a map
+func writeBody(in interface{}) (map[string]interface{}, error) {
+	switch b := in.(type) {
+	case map[string]interface{}:
+		return b, nil
+	case map[string]string:
+		out := make(map[string]interface{}, len(b))
+		for k, v := range b {
+			out[k] = v
+		}
+		return out, nil
+	default:
+		return nil, errors.Errorf("data to write must be a map, not %T", in)
+	}
+}
+
 // readSource returns the (possibly cached) data from the given source,
-// as referenced by the given args
+// as referenced by the given args. Must be called with the source locked (see
+// lockSource), and without d.mu held. Concurrent reads of the same data wait
+// for the source's lock, and then find the data in the cache, so it's only
+// fetched once.
 func (d *Data) readSource(ctx context.Context, source *Source, args ...string) ([]byte, error) {
-	if d.cache == nil {
-		d.cache = make(map[string][]byte)
-	}
 	cacheKey := source.Alias
 	for _, v := range args {
 		cacheKey += v
 	}
-	cached, ok := d.cache[cacheKey]
+
+	d.mu.Lock()
+	d.expandScheme(source)
+	if d.snapshots != nil {
+		defer d.mu.Unlock()
+		return d.readSnapshot(source, args)
+	}
+	if d.cache == nil {
+		d.cache = newSourceCache(d.CacheLimit, d.SpillThreshold)
+	}
+	cached, ok := d.cache.get(cacheKey)
+	d.mu.Unlock()
 	if ok {
 		return cached, nil
 	}
+
+	data, err := d.fetchSource(ctx, source, args...)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.cache.put(cacheKey, data)
+	d.mu.Unlock()
+	return data, nil
+}
+
+// fetchSource reads the data from the given source, without caching it in
+// memory. Sources with a TTL are read from the persistent cache in CacheDir
+// when the cached data is fresh enough, and written to it otherwise. Apart
+// from the readers and transports (which are safe to share), it uses none of
+// d's state, so different sources can be fetched concurrently (see Preload).
+func (d *Data) fetchSource(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	if source.Integrity != "" && len(args) > 0 {
+		return nil, errors.Errorf("datasource '%s' has a pinned integrity, so can't be read with extra arguments", source.Alias)
+	}
+	ttl, err := sourceTTL(source)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.NetworkPolicy.CheckURL(source.URL); err != nil {
+		return nil, errors.Wrapf(err, "can't read datasource '%s'", source.Alias)
+	}
+	if d.CacheDir == "" || ttl == 0 {
+		return d.readFromSource(ctx, source, args...)
+	}
+
+	key := diskCacheKey(source, args)
+	if b, mediaType, ok := readDiskCache(d.CacheDir, key, ttl); ok {
+		// the cache directory could have been changed by anyone who can
+		// write to it, so cached data is verified just like data read from
+		// the source - and when it fails, it's read again
+		err = d.verify(ctx, source, args, b)
+		if err == nil {
+			if mediaType != "" {
+				source.mediaType = mediaType
+			}
+			return b, nil
+		}
+		zerolog.Ctx(ctx).Debug().Err(err).Str("alias", source.Alias).Msg("cached datasource failed verification, reading it again")
+	}
+	data, err := d.readFromSource(ctx, source, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeDiskCache(d.CacheDir, key, source.mediaType, data); err != nil {
+		// the data is still usable - it'll just be read again next time
+		zerolog.Ctx(ctx).Debug().Err(err).Str("alias", source.Alias).Msg("failed to write datasource to cache")
+	}
+	return data, nil
+}
+
+// readFromSource reads and verifies the data from the given source
+func (d *Data) readFromSource(ctx context.Context, source *Source, args ...string) ([]byte, error) {
 	r, err := d.lookupReader(source.URL.Scheme)
 	if err != nil {
 		return nil, errors.Wrap(err, "Datasource not yet supported")
 	}
-	data, err := r(ctx, source, args...)
+	data, err := r(d.sourceContext(ctx), source, args...)
 	if err != nil {
 		return nil, err
 	}
-	d.cache[cacheKey] = data
+	if err := d.verify(ctx, source, args, data); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
+// verify checks the source's data against its signature (when there's a
+// Verifier) and its pinned integrity, if any
+func (d *Data) verify(ctx context.Context, source *Source, args []string, data []byte) error {
+	if d.Verifier != nil {
+		r, err := d.lookupReader(source.URL.Scheme)
+		if err != nil {
+			return errors.Wrap(err, "Datasource not yet supported")
+		}
+		ctx = d.sourceContext(ctx)
+		err = d.Verifier.Verify(source.URL, data, func(suffix string) ([]byte, error) {
+			sigSource, sigArgs := signatureSource(source, args, suffix)
+			return r(ctx, sigSource, sigArgs...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if source.Integrity != "" {
+		if err := integrity.Check(source.Integrity, data); err != nil {
+			return errors.Wrapf(err, "integrity check failed for datasource '%s' (%s)", source.Alias, source.URL)
+		}
+	}
+	return nil
+}
+
+// sourceContext - the context to read from (or write to) sources with, so
+// that the network policy and shared transports are used
+func (d *Data) sourceContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		// Data can be used without setting Ctx
+		ctx = context.Background()
+	}
+	ctx = netpolicy.ContextWithPolicy(ctx, d.NetworkPolicy)
+	return contextWithTransports(ctx, d)
+}
+
+// signatureSource - the source and args to read a detached signature from,
+// which is at the same path as the data, with the suffix appended
+func signatureSource(source *Source, args []string, suffix string) (*Source, []string) {
+	if len(args) > 0 {
+		sigArgs := make([]string, len(args))
+		copy(sigArgs, args)
+		sigArgs[len(sigArgs)-1] += suffix
+		return source, sigArgs
+	}
+
+	u := *source.URL
+	if u.Opaque != "" {
+		u.Opaque += suffix
+	} else {
+		u.Path += suffix
+	}
+	sigSource := &Source{Alias: source.Alias, URL: &u, Header: source.Header}
+	sigSource.inherit(source)
+	return sigSource, nil
+}
+
 // Show all datasources  -
 func (d *Data) ListDatasources() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	datasources := make([]string, 0, len(d.Sources))
 	for source := range d.Sources {
 		datasources = append(datasources, source)
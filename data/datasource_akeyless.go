@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/akeyless"
+	"github.com/pkg/errors"
+)
+
+// readAkeyless reads the value of an Akeyless static secret, or lists the
+// items in a folder when the path ends with '/'
+func readAkeyless(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	name := source.URL.Path
+	if len(args) == 1 {
+		name = strings.TrimSuffix(name, "/") + "/" + strings.TrimPrefix(args[0], "/")
+	}
+	if name == "" {
+		return nil, errors.Errorf("a secret name must be given in %s", source.URL)
+	}
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+
+	if source.ak == nil {
+		base := os.Getenv("AKEYLESS_GATEWAY_URL")
+		if source.URL.Host != "" {
+			base = "https://" + source.URL.Host
+		}
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		source.ak = akeyless.New(hc, base, bearerToken(source.Header))
+	}
+
+	if !strings.HasSuffix(name, "/") {
+		return source.ak.Secret(ctx, name)
+	}
+
+	names, err := source.ak.List(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(names)
+}
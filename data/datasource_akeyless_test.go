@@ -0,0 +1,51 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAkeyless(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		in := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		switch {
+		case in["token"] != "t-abc":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/get-secret-value":
+			name := in["names"].([]interface{})[0].(string)
+			_ = json.NewEncoder(w).Encode(map[string]string{name: "value of " + name})
+		case r.URL.Path == "/list-items":
+			_, _ = w.Write([]byte(`{"items": [{"item_name": "/prod/a"}, {"item_name": "/prod/b"}]}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("AKEYLESS_GATEWAY_URL", srv.URL)
+	t.Setenv("AKEYLESS_TOKEN", "t-abc")
+
+	ctx := context.Background()
+	source := &Source{Alias: "foo", URL: mustParseURL("akeyless:///prod/a")}
+	b, err := readAkeyless(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, "value of /prod/a", string(b))
+
+	source = &Source{Alias: "foo", URL: mustParseURL("akeyless:///prod/")}
+	b, err = readAkeyless(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `["a","b"]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	b, err = readAkeyless(ctx, source, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "value of /prod/b", string(b))
+
+	source = &Source{Alias: "foo", URL: mustParseURL("akeyless://")}
+	_, err = readAkeyless(ctx, source)
+	assert.Error(t, err)
+}
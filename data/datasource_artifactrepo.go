@@ -0,0 +1,71 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/artifactrepo"
+	"github.com/pkg/errors"
+)
+
+// readArtifactRepo reads an artifact (validated against the server's
+// checksums), its properties, or a directory listing from an Artifactory
+// (artifactory://HOST/REPO/path) or Nexus (nexus://HOST/REPO/path) repository
+func readArtifactRepo(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	repo, p, _ := strings.Cut(strings.TrimPrefix(source.URL.Path, "/"), "/")
+	if len(args) == 1 {
+		if p != "" && !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		p += args[0]
+	}
+	if repo == "" {
+		return nil, errors.Errorf("a repository must be given in %s", source.URL)
+	}
+
+	if source.ar == nil {
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		if source.URL.Scheme == "artifactory" {
+			base := os.Getenv("ARTIFACTORY_URL")
+			if source.URL.Host != "" {
+				base = "https://" + source.URL.Host + "/artifactory"
+			}
+			if base == "" {
+				return nil, errors.Errorf("an Artifactory host must be given in %s, or a URL in ARTIFACTORY_URL", source.URL)
+			}
+			source.ar = artifactrepo.NewArtifactory(hc, base, source.URL.User)
+		} else {
+			base := os.Getenv("NEXUS_URL")
+			if source.URL.Host != "" {
+				base = "https://" + source.URL.Host
+			}
+			if base == "" {
+				return nil, errors.Errorf("a Nexus host must be given in %s, or a URL in NEXUS_URL", source.URL)
+			}
+			source.ar = artifactrepo.NewNexus(hc, base, source.URL.User)
+		}
+	}
+
+	if p == "" || strings.HasSuffix(p, "/") {
+		names, err := source.ar.List(ctx, repo, p)
+		if err != nil {
+			return nil, err
+		}
+		source.mediaType = jsonArrayMimetype
+		return json.Marshal(names)
+	}
+
+	if _, ok := source.URL.Query()["properties"]; ok {
+		source.mediaType = jsonMimetype
+		return source.ar.Properties(ctx, repo, p)
+	}
+
+	source.mediaType = mime.TypeByExtension(path.Ext(p))
+	return source.ar.Artifact(ctx, repo, p)
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/artifactrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadArtifactRepo(t *testing.T) {
+	content := `{"build": 42}`
+	digest := sha256.Sum256([]byte(content))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, props := r.URL.Query()["properties"]
+		switch r.URL.Path {
+		case "/libs/app/build.json":
+			w.Header().Set("X-Checksum-Sha256", hex.EncodeToString(digest[:]))
+			_, _ = w.Write([]byte(content))
+		case "/api/storage/libs/app/build.json":
+			if props {
+				_, _ = w.Write([]byte(`{"properties": {"vcs.revision": ["abc123"]}}`))
+			}
+		case "/api/storage/libs/app":
+			_, _ = w.Write([]byte(`{"children": [{"uri": "/build.json"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	newSource := func(u string) *Source {
+		return &Source{Alias: "foo", URL: mustParseURL(u), ar: artifactrepo.NewArtifactory(srv.Client(), srv.URL, nil)}
+	}
+
+	ctx := context.Background()
+	source := newSource("artifactory://example.jfrog.io/libs/app/build.json")
+	b, err := readArtifactRepo(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	source = newSource("artifactory://example.jfrog.io/libs/app/build.json?properties")
+	b, err = readArtifactRepo(ctx, source)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"vcs.revision": ["abc123"]}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	source = newSource("artifactory://example.jfrog.io/libs/app/")
+	b, err = readArtifactRepo(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `["build.json"]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	b, err = readArtifactRepo(ctx, source, "build.json")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(b))
+
+	_, err = readArtifactRepo(ctx, &Source{Alias: "foo", URL: mustParseURL("nexus://example.com")})
+	assert.Error(t, err)
+
+	t.Setenv("NEXUS_URL", "")
+	_, err = readArtifactRepo(ctx, &Source{Alias: "foo", URL: mustParseURL("nexus:///raw/a.txt")})
+	assert.EqualError(t, err, "a Nexus host must be given in nexus:///raw/a.txt, or a URL in NEXUS_URL")
+}
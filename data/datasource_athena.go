@@ -0,0 +1,189 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/athena"
+	"github.com/pkg/errors"
+
+	gaws "github.com/hairyhenderson/gomplate/v3/aws"
+)
+
+// athenaClient - A subset of the Athena API for use in unit testing
+type athenaClient interface {
+	StartQueryExecutionWithContext(ctx context.Context, input *athena.StartQueryExecutionInput, opts ...request.Option) (*athena.StartQueryExecutionOutput, error)
+	GetQueryExecutionWithContext(ctx context.Context, input *athena.GetQueryExecutionInput, opts ...request.Option) (*athena.GetQueryExecutionOutput, error)
+	GetQueryResultsWithContext(ctx context.Context, input *athena.GetQueryResultsInput, opts ...request.Option) (*athena.GetQueryResultsOutput, error)
+}
+
+// athenaPollInterval - how often to check whether a query has completed
+var athenaPollInterval = 500 * time.Millisecond
+
+// readAthena runs a SQL query with Amazon Athena (athena:///DATABASE), given
+// as the argument (or in the query query parameter), and returns the result
+// rows as objects
+func readAthena(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	q := source.URL.Query()
+	query := q.Get("query")
+	if len(args) == 1 {
+		query = args[0]
+		source.queryArg = true
+	}
+	if query == "" {
+		return nil, errors.Errorf("a query must be given as an argument, or in the query parameter of %s", source.URL)
+	}
+	limit, strict, err := rowLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.athena == nil {
+		source.athena = athena.New(gaws.SDKSession())
+	}
+
+	input := &athena.StartQueryExecutionInput{
+		QueryString:           aws.String(query),
+		QueryExecutionContext: &athena.QueryExecutionContext{},
+	}
+	if db := strings.Trim(source.URL.Path, "/"); db != "" {
+		input.QueryExecutionContext.Database = aws.String(db)
+	}
+	if c := q.Get("catalog"); c != "" {
+		input.QueryExecutionContext.Catalog = aws.String(c)
+	}
+	if wg := q.Get("workgroup"); wg != "" {
+		input.WorkGroup = aws.String(wg)
+	}
+	if out := q.Get("output"); out != "" {
+		input.ResultConfiguration = &athena.ResultConfiguration{OutputLocation: aws.String(out)}
+	}
+
+	start, err := source.athena.StartQueryExecutionWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start athena query")
+	}
+	id := start.QueryExecutionId
+
+	if err := waitForAthena(ctx, source.athena, id); err != nil {
+		return nil, err
+	}
+
+	rows := []map[string]interface{}{}
+	var token *string
+	for first := true; ; first = false {
+		res, err := source.athena.GetQueryResultsWithContext(ctx, &athena.GetQueryResultsInput{
+			QueryExecutionId: id,
+			NextToken:        token,
+			MaxResults:       aws.Int64(1000),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read athena query results")
+		}
+		if res.ResultSet == nil || res.ResultSet.ResultSetMetadata == nil {
+			return nil, errors.New("athena returned no result set")
+		}
+
+		cols := res.ResultSet.ResultSetMetadata.ColumnInfo
+		page := res.ResultSet.Rows
+		// the first row of a SELECT's results is the column names
+		if first && len(page) > 0 && isAthenaHeader(cols, page[0]) {
+			page = page[1:]
+		}
+		for _, r := range page {
+			row, err := athenaRow(cols, r)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+
+		var more bool
+		rows, more, err = applyRowLimit(rows, limit, strict)
+		if err != nil {
+			return nil, err
+		}
+		token = res.NextToken
+		if token == nil || !more {
+			break
+		}
+	}
+
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(rows)
+}
+
+// waitForAthena polls until the query has succeeded, or returns an error when
+// it's failed or been cancelled
+func waitForAthena(ctx context.Context, c athenaClient, id *string) error {
+	for {
+		res, err := c.GetQueryExecutionWithContext(ctx, &athena.GetQueryExecutionInput{QueryExecutionId: id})
+		if err != nil {
+			return errors.Wrap(err, "failed to get athena query status")
+		}
+		status := res.QueryExecution.Status
+		switch aws.StringValue(status.State) {
+		case athena.QueryExecutionStateSucceeded:
+			return nil
+		case athena.QueryExecutionStateFailed, athena.QueryExecutionStateCancelled:
+			return errors.Errorf("athena query %s %s: %s", aws.StringValue(id),
+				strings.ToLower(aws.StringValue(status.State)), aws.StringValue(status.StateChangeReason))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(athenaPollInterval):
+		}
+	}
+}
+
+func isAthenaHeader(cols []*athena.ColumnInfo, row *athena.Row) bool {
+	if len(row.Data) != len(cols) {
+		return false
+	}
+	for i, d := range row.Data {
+		if aws.StringValue(d.VarCharValue) != aws.StringValue(cols[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// athenaRow converts a row to an object keyed by the column names, with
+// values converted to the columns' types
+func athenaRow(cols []*athena.ColumnInfo, row *athena.Row) (map[string]interface{}, error) {
+	if len(row.Data) != len(cols) {
+		return nil, errors.Errorf("athena row has %d values, but there are %d columns", len(row.Data), len(cols))
+	}
+	out := make(map[string]interface{}, len(cols))
+	for i, col := range cols {
+		name := aws.StringValue(col.Name)
+		if row.Data[i].VarCharValue == nil {
+			out[name] = nil
+			continue
+		}
+		s := *row.Data[i].VarCharValue
+
+		var v interface{} = s
+		var err error
+		switch aws.StringValue(col.Type) {
+		case "tinyint", "smallint", "integer", "bigint":
+			v, err = strconv.ParseInt(s, 10, 64)
+		case "float", "real", "double":
+			v, err = strconv.ParseFloat(s, 64)
+		case "boolean":
+			v, err = strconv.ParseBool(s)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s", name)
+		}
+		out[name] = v
+	}
+	return out, nil
+}
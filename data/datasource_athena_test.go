@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/athena"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAthena - runs every query successfully (after one poll), returning the
+// pages of rows, unless the query is "FAIL"
+type fakeAthena struct {
+	input *athena.StartQueryExecutionInput
+	polls int
+	cols  []*athena.ColumnInfo
+	pages [][]*athena.Row
+}
+
+func (f *fakeAthena) StartQueryExecutionWithContext(_ context.Context, input *athena.StartQueryExecutionInput, _ ...request.Option) (*athena.StartQueryExecutionOutput, error) {
+	f.input = input
+	f.polls = 0
+	return &athena.StartQueryExecutionOutput{QueryExecutionId: aws.String("q1")}, nil
+}
+
+func (f *fakeAthena) GetQueryExecutionWithContext(_ context.Context, _ *athena.GetQueryExecutionInput, _ ...request.Option) (*athena.GetQueryExecutionOutput, error) {
+	f.polls++
+	state := athena.QueryExecutionStateRunning
+	switch {
+	case aws.StringValue(f.input.QueryString) == "FAIL":
+		state = athena.QueryExecutionStateFailed
+	case f.polls > 1:
+		state = athena.QueryExecutionStateSucceeded
+	}
+	return &athena.GetQueryExecutionOutput{QueryExecution: &athena.QueryExecution{
+		Status: &athena.QueryExecutionStatus{State: aws.String(state), StateChangeReason: aws.String("SYNTAX_ERROR")},
+	}}, nil
+}
+
+func (f *fakeAthena) GetQueryResultsWithContext(_ context.Context, input *athena.GetQueryResultsInput, _ ...request.Option) (*athena.GetQueryResultsOutput, error) {
+	i := 0
+	if input.NextToken != nil {
+		i = 1
+	}
+	out := &athena.GetQueryResultsOutput{ResultSet: &athena.ResultSet{
+		ResultSetMetadata: &athena.ResultSetMetadata{ColumnInfo: f.cols},
+		Rows:              f.pages[i],
+	}}
+	if i+1 < len(f.pages) {
+		out.NextToken = aws.String("next")
+	}
+	return out, nil
+}
+
+func athenaTestRow(values ...*string) *athena.Row {
+	r := &athena.Row{}
+	for _, v := range values {
+		r.Data = append(r.Data, &athena.Datum{VarCharValue: v})
+	}
+	return r
+}
+
+func TestReadAthena(t *testing.T) {
+	defer func(d time.Duration) { athenaPollInterval = d }(athenaPollInterval)
+	athenaPollInterval = time.Millisecond
+
+	fake := &fakeAthena{
+		cols: []*athena.ColumnInfo{
+			{Name: aws.String("name"), Type: aws.String("varchar")},
+			{Name: aws.String("count"), Type: aws.String("bigint")},
+			{Name: aws.String("ratio"), Type: aws.String("double")},
+			{Name: aws.String("ok"), Type: aws.String("boolean")},
+		},
+		pages: [][]*athena.Row{
+			{
+				athenaTestRow(aws.String("name"), aws.String("count"), aws.String("ratio"), aws.String("ok")),
+				athenaTestRow(aws.String("a"), aws.String("1"), aws.String("0.5"), aws.String("true")),
+			},
+			{
+				athenaTestRow(aws.String("b"), nil, aws.String("1.5"), aws.String("false")),
+			},
+		},
+	}
+	ctx := context.Background()
+	source := &Source{Alias: "foo", URL: mustParseURL("athena:///analytics?workgroup=reports&output=s3://bucket/results/"), athena: fake}
+
+	b, err := readAthena(ctx, source, "SELECT * FROM t WHERE name LIKE 'a%'")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name": "a", "count": 1, "ratio": 0.5, "ok": true}, {"name": "b", "count": null, "ratio": 1.5, "ok": false}]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+	assert.Equal(t, "analytics", aws.StringValue(fake.input.QueryExecutionContext.Database))
+	assert.Equal(t, "reports", aws.StringValue(fake.input.WorkGroup))
+	assert.Equal(t, "s3://bucket/results/", aws.StringValue(fake.input.ResultConfiguration.OutputLocation))
+	assert.Equal(t, 2, fake.polls)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("athena:///analytics?max=1"), athena: fake}
+	b, err = readAthena(ctx, source, "SELECT * FROM t")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name": "a", "count": 1, "ratio": 0.5, "ok": true}]`, string(b))
+
+	_, err = readAthena(ctx, source, "FAIL")
+	assert.EqualError(t, err, "athena query q1 failed: SYNTAX_ERROR")
+
+	_, err = readAthena(ctx, &Source{Alias: "foo", URL: mustParseURL("athena:///analytics"), athena: fake})
+	assert.Error(t, err)
+}
+
+func TestApplyRowLimit(t *testing.T) {
+	rows := []map[string]interface{}{{"a": 1}, {"a": 2}, {"a": 3}}
+
+	out, more, err := applyRowLimit(rows, 0, false)
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Len(t, out, 3)
+
+	out, more, err = applyRowLimit(rows, 2, false)
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Len(t, out, 2)
+
+	// at the default limit, more rows must be read to know whether it's exceeded
+	out, more, err = applyRowLimit(rows, 3, true)
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Len(t, out, 3)
+
+	_, _, err = applyRowLimit(rows, 2, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,231 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gocloud.dev/gcp"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+// defaultMaxRows - the number of rows a query can return when no max is set.
+// More is an error, so that huge results aren't read by accident.
+const defaultMaxRows = 10000
+
+// rowLimit - the maximum number of rows to read (0 for no limit), from the max
+// query parameter, and whether exceeding it is an error, which it is when no
+// max is set
+func rowLimit(q url.Values) (int, bool, error) {
+	if q.Get("max") == "" {
+		return defaultMaxRows, true, nil
+	}
+	n, err := queryLimit(q)
+	return n, false, err
+}
+
+// applyRowLimit - the rows, truncated to the limit, and whether there's room
+// for more
+func applyRowLimit(rows []map[string]interface{}, limit int, strict bool) ([]map[string]interface{}, bool, error) {
+	switch {
+	case limit == 0:
+		return rows, true, nil
+	case strict && len(rows) > limit:
+		return nil, false, errors.Errorf("query returned more than %d rows - set max to read more (or fewer), or add a LIMIT to the query", limit)
+	case !strict && len(rows) >= limit:
+		return rows[:limit], false, nil
+	}
+	return rows, true, nil
+}
+
+// readBigQuery runs a (standard SQL) query in a BigQuery project
+// (bigquery://PROJECT[/DATASET]), given as the argument (or in the query query
+// parameter), and returns the result rows as objects
+func readBigQuery(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	project := source.URL.Host
+	dataset := strings.Trim(source.URL.Path, "/")
+	if project == "" {
+		return nil, errors.Errorf("a project must be given in %s", source.URL)
+	}
+	q := source.URL.Query()
+	query := q.Get("query")
+	if len(args) == 1 {
+		query = args[0]
+		source.queryArg = true
+	}
+	if query == "" {
+		return nil, errors.Errorf("a query must be given as an argument, or in the query parameter of %s", source.URL)
+	}
+	limit, strict, err := rowLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.bq == nil {
+		creds, err := gcp.DefaultCredentials(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve GCP credentials")
+		}
+		client, err := gcp.NewHTTPClient(transportFromContext(ctx, source.URL.Scheme), gcp.CredentialsTokenSource(creds))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create GCP HTTP client")
+		}
+		source.bq, err = bigquery.NewService(ctx, option.WithHTTPClient(&client.Client))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create BigQuery client")
+		}
+	}
+
+	legacy := false
+	req := &bigquery.QueryRequest{
+		Query:        query,
+		UseLegacySql: &legacy,
+		Location:     q.Get("location"),
+		MaxResults:   1000,
+		TimeoutMs:    10000,
+	}
+	if dataset != "" {
+		req.DefaultDataset = &bigquery.DatasetReference{ProjectId: project, DatasetId: dataset}
+	}
+	if s := q.Get("max_bytes_billed"); s != "" {
+		req.MaximumBytesBilled, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid max_bytes_billed %q", s)
+		}
+	}
+
+	resp, err := source.bq.Jobs.Query(project, req).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "bigquery query failed")
+	}
+	complete, schema, page, token, job := resp.JobComplete, resp.Schema, resp.Rows, resp.PageToken, resp.JobReference
+
+	rows := []map[string]interface{}{}
+	for {
+		if complete {
+			for _, r := range page {
+				values := make([]interface{}, len(r.F))
+				for i, c := range r.F {
+					values[i] = c.V
+				}
+				row, err := bqRecord(schema.Fields, values)
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, row)
+			}
+
+			var more bool
+			rows, more, err = applyRowLimit(rows, limit, strict)
+			if err != nil {
+				return nil, err
+			}
+			if token == "" || !more {
+				break
+			}
+		}
+
+		// wait for the job to complete, or read the next page of results
+		call := source.bq.Jobs.GetQueryResults(project, job.JobId).Location(job.Location).
+			MaxResults(1000).TimeoutMs(10000).Context(ctx)
+		if token != "" {
+			call = call.PageToken(token)
+		}
+		r, err := call.Do()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read bigquery query results")
+		}
+		complete, schema, page, token = r.JobComplete, r.Schema, r.Rows, r.PageToken
+	}
+
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(rows)
+}
+
+// bqRecord converts a row (or RECORD value) of cell values to an object, keyed
+// by the fields' names
+func bqRecord(fields []*bigquery.TableFieldSchema, values []interface{}) (map[string]interface{}, error) {
+	if len(values) != len(fields) {
+		return nil, errors.Errorf("bigquery row has %d values, but the schema has %d fields", len(values), len(fields))
+	}
+	out := make(map[string]interface{}, len(fields))
+	for i, f := range fields {
+		v, err := bqValue(f, values[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s", f.Name)
+		}
+		out[f.Name] = v
+	}
+	return out, nil
+}
+
+// bqValue converts a cell value, which the API encodes as a string (or a
+// nested structure for RECORD and REPEATED fields), to the field's type
+func bqValue(f *bigquery.TableFieldSchema, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if f.Mode == "REPEATED" {
+		cells, ok := v.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("expected an array, got %T", v)
+		}
+		elem := *f
+		elem.Mode = ""
+		out := make([]interface{}, len(cells))
+		for i, c := range cells {
+			cell, _ := c.(map[string]interface{})
+			ev, err := bqValue(&elem, cell["v"])
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ev
+		}
+		return out, nil
+	}
+
+	if f.Type == "RECORD" || f.Type == "STRUCT" {
+		rec, _ := v.(map[string]interface{})
+		cells, ok := rec["f"].([]interface{})
+		if !ok {
+			return nil, errors.Errorf("expected a record, got %T", v)
+		}
+		values := make([]interface{}, len(cells))
+		for i, c := range cells {
+			cell, _ := c.(map[string]interface{})
+			values[i] = cell["v"]
+		}
+		return bqRecord(f.Fields, values)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("expected a string, got %T", v)
+	}
+	switch f.Type {
+	case "INTEGER", "INT64":
+		return strconv.ParseInt(s, 10, 64)
+	case "FLOAT", "FLOAT64":
+		return strconv.ParseFloat(s, 64)
+	case "BOOLEAN", "BOOL":
+		return strconv.ParseBool(s)
+	case "TIMESTAMP":
+		// timestamps are seconds since the epoch, as floats
+		secs, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		whole, frac := math.Modf(secs)
+		t := time.Unix(int64(whole), int64(math.Round(frac*1e6))*1e3)
+		return t.UTC().Format(time.RFC3339Nano), nil
+	default:
+		return s, nil
+	}
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+func TestReadBigQuery(t *testing.T) {
+	schema := `{"fields": [
+		{"name": "name", "type": "STRING"},
+		{"name": "n", "type": "INTEGER"},
+		{"name": "at", "type": "TIMESTAMP"},
+		{"name": "tags", "type": "STRING", "mode": "REPEATED"},
+		{"name": "owner", "type": "RECORD", "fields": [{"name": "id", "type": "INT64"}, {"name": "admin", "type": "BOOLEAN"}]}
+	]}`
+	row1 := `{"f": [{"v": "a"}, {"v": "1"}, {"v": "1.6725312E9"}, {"v": [{"v": "x"}, {"v": "y"}]}, {"v": {"f": [{"v": "7"}, {"v": "true"}]}}]}`
+	row2 := `{"f": [{"v": "b"}, {"v": null}, {"v": null}, {"v": []}, {"v": null}]}`
+
+	var req map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/bigquery/v2/projects/proj/queries":
+			b, _ := io.ReadAll(r.Body)
+			req = map[string]interface{}{}
+			_ = json.Unmarshal(b, &req)
+			// the job isn't complete yet
+			_, _ = w.Write([]byte(`{"jobComplete": false, "jobReference": {"projectId": "proj", "jobId": "job1", "location": "US"}}`))
+		case "/bigquery/v2/projects/proj/queries/job1":
+			assert.Equal(t, "US", r.URL.Query().Get("location"))
+			if r.URL.Query().Get("pageToken") == "" {
+				_, _ = w.Write([]byte(`{"jobComplete": true, "schema": ` + schema + `, "rows": [` + row1 + `], "pageToken": "p2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"jobComplete": true, "schema": ` + schema + `, "rows": [` + row2 + `]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	svc, err := bigquery.NewService(ctx, option.WithEndpoint(srv.URL+"/bigquery/v2/"), option.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+
+	source := &Source{Alias: "foo", URL: mustParseURL("bigquery://proj/reports?max_bytes_billed=1000000"), bq: svc}
+	b, err := readBigQuery(ctx, source, "SELECT * FROM t WHERE name LIKE 'a%'")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "a", "n": 1, "at": "2023-01-01T00:00:00Z", "tags": ["x", "y"], "owner": {"id": 7, "admin": true}},
+		{"name": "b", "n": null, "at": null, "tags": [], "owner": null}
+	]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+	assert.Equal(t, "SELECT * FROM t WHERE name LIKE 'a%'", req["query"])
+	assert.Equal(t, false, req["useLegacySql"])
+	assert.Equal(t, "1000000", req["maximumBytesBilled"])
+	assert.Equal(t, map[string]interface{}{"projectId": "proj", "datasetId": "reports"}, req["defaultDataset"])
+
+	source = &Source{Alias: "foo", URL: mustParseURL("bigquery://proj?max=1"), bq: svc}
+	b, err = readBigQuery(ctx, source, "SELECT 1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name": "a", "n": 1, "at": "2023-01-01T00:00:00Z", "tags": ["x", "y"], "owner": {"id": 7, "admin": true}}]`, string(b))
+
+	_, err = readBigQuery(ctx, &Source{Alias: "foo", URL: mustParseURL("bigquery:///ds"), bq: svc}, "SELECT 1")
+	assert.Error(t, err)
+	_, err = readBigQuery(ctx, &Source{Alias: "foo", URL: mustParseURL("bigquery://proj"), bq: svc})
+	assert.Error(t, err)
+}
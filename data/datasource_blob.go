@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"io"
 	"mime"
+	"net/http"
 	"net/url"
 	"path"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	gaws "github.com/hairyhenderson/gomplate/v3/aws"
 	"github.com/hairyhenderson/gomplate/v3/env"
 	"github.com/pkg/errors"
@@ -65,6 +67,7 @@ func newOpener(ctx context.Context, u *url.URL) (opener blob.BucketURLOpener, er
 	case "s3":
 		// set up a "regular" gomplate AWS SDK session
 		sess := gaws.SDKSession()
+		sess = sess.Copy(&aws.Config{HTTPClient: &http.Client{Transport: transportFromContext(ctx, u.Scheme)}})
 		// see https://gocloud.dev/concepts/urls/#muxes
 		opener = &s3blob.URLOpener{ConfigProvider: sess}
 	case "gs":
@@ -74,7 +77,7 @@ func newOpener(ctx context.Context, u *url.URL) (opener blob.BucketURLOpener, er
 		}
 
 		client, err := gcp.NewHTTPClient(
-			gcp.DefaultTransport(),
+			transportFromContext(ctx, u.Scheme),
 			gcp.CredentialsTokenSource(creds))
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create GCP HTTP client")
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/cfkv"
+	"github.com/pkg/errors"
+)
+
+// readCFKV reads the value of a key in a Cloudflare Workers KV namespace
+// (cfkv://ACCOUNT_ID/NAMESPACE_ID/key), or lists the keys when the key ends
+// with '/' (or is empty)
+func readCFKV(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	namespace, key, _ := strings.Cut(strings.TrimPrefix(source.URL.Path, "/"), "/")
+	if len(args) == 1 {
+		if key != "" && !strings.HasSuffix(key, "/") {
+			key += "/"
+		}
+		key += args[0]
+	}
+	if source.URL.Host == "" || namespace == "" {
+		return nil, errors.Errorf("an account ID and namespace ID must be given in %s", source.URL)
+	}
+
+	if source.cf == nil {
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		c, err := cfkv.New(hc, source.URL.Host, namespace)
+		if err != nil {
+			return nil, err
+		}
+		source.cf = c
+	}
+
+	if key != "" && !strings.HasSuffix(key, "/") {
+		return source.cf.Get(ctx, key)
+	}
+
+	names, err := source.cf.List(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(names)
+}
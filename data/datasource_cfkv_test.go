@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/cfkv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCFKV(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/accounts/acct/storage/kv/namespaces/ns/values/site%2Fbanner":
+			_, _ = w.Write([]byte("hello"))
+		case "/accounts/acct/storage/kv/namespaces/ns/keys":
+			_, _ = w.Write([]byte(`{"result": [{"name": "` + r.URL.Query().Get("prefix") + `banner"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("CLOUDFLARE_API_TOKEN", "x")
+
+	newSource := func(u string) *Source {
+		s := &Source{Alias: "foo", URL: mustParseURL(u)}
+		c, err := cfkv.New(srv.Client(), s.URL.Host, "ns")
+		require.NoError(t, err)
+		c.Base = srv.URL
+		s.cf = c
+		return s
+	}
+
+	ctx := context.Background()
+	b, err := readCFKV(ctx, newSource("cfkv://acct/ns/site/banner"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	source := newSource("cfkv://acct/ns/site/")
+	b, err = readCFKV(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `["banner"]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	b, err = readCFKV(ctx, source, "banner")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	b, err = readCFKV(ctx, newSource("cfkv://acct/ns"), "site/banner")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	b, err = readCFKV(ctx, newSource("cfkv://acct/ns/"))
+	require.NoError(t, err)
+	assert.Equal(t, `["banner"]`, string(b))
+
+	_, err = readCFKV(ctx, &Source{Alias: "foo", URL: mustParseURL("cfkv://acct")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/conjur"
+	"github.com/pkg/errors"
+)
+
+// readConjur reads the value of a CyberArk Conjur variable, or lists the
+// variables under a path ending with '/'
+func readConjur(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	id := strings.TrimPrefix(source.URL.Path, "/")
+	if len(args) == 1 {
+		id = strings.TrimSuffix(id, "/") + "/" + strings.TrimPrefix(args[0], "/")
+		id = strings.TrimPrefix(id, "/")
+	}
+	if id == "" {
+		return nil, errors.Errorf("a variable ID must be given in %s", source.URL)
+	}
+
+	if source.cj == nil {
+		c, err := conjur.New(ctx, source.URL)
+		if err != nil {
+			return nil, err
+		}
+		source.cj = c
+	}
+
+	if !strings.HasSuffix(id, "/") {
+		return source.cj.Secret(ctx, id)
+	}
+
+	ids, err := source.cj.List(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(ids)
+}
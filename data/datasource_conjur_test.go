@@ -0,0 +1,55 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConjur(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authn/myorg/app/authenticate":
+			_, _ = w.Write([]byte("dG9rZW4="))
+		case "/secrets/myorg/variable/prod/db/password":
+			_, _ = w.Write([]byte("s3cr3t"))
+		case "/resources/myorg/variable":
+			_, _ = w.Write([]byte(`[{"id": "myorg:variable:prod/db/password"}, {"id": "myorg:variable:prod/db/username"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("CONJUR_APPLIANCE_URL", srv.URL)
+	t.Setenv("CONJUR_ACCOUNT", "myorg")
+	t.Setenv("CONJUR_AUTHN_LOGIN", "app")
+	t.Setenv("CONJUR_AUTHN_API_KEY", "key")
+
+	ctx := context.Background()
+	source := &Source{Alias: "foo", URL: mustParseURL("conjur:///prod/db/password")}
+	b, err := readConjur(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(b))
+
+	source = &Source{Alias: "foo", URL: mustParseURL("conjur:///prod/")}
+	b, err = readConjur(ctx, source, "db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(b))
+
+	b, err = readConjur(ctx, source, "db/")
+	require.NoError(t, err)
+	assert.Equal(t, `["password","username"]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	_, err = readConjur(ctx, source, "db/missing")
+	assert.Error(t, err)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("conjur://")}
+	_, err = readConjur(ctx, source)
+	assert.Error(t, err)
+}
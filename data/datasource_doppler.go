@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/doppler"
+	"github.com/pkg/errors"
+)
+
+// readDoppler reads all of a Doppler config's secrets (doppler:///project/config),
+// or a single secret (doppler:///project/config/NAME). With a service token,
+// which is scoped to a config already, the project and config can be left out.
+func readDoppler(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	p := strings.Trim(source.URL.Path, "/")
+	segs := []string{}
+	if p != "" {
+		segs = strings.Split(p, "/")
+	}
+	if len(args) == 1 {
+		segs = append(segs, args[0])
+	}
+
+	var project, config, name string
+	switch len(segs) {
+	case 0:
+	case 1:
+		name = segs[0]
+	case 2:
+		project, config = segs[0], segs[1]
+	case 3:
+		project, config, name = segs[0], segs[1], segs[2]
+	default:
+		return nil, errors.Errorf("invalid Doppler path in %s - expected /project/config or /project/config/SECRET", source.URL)
+	}
+
+	if source.dop == nil {
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		c, err := doppler.New(hc, bearerToken(source.Header))
+		if err != nil {
+			return nil, err
+		}
+		source.dop = c
+	}
+
+	if name != "" {
+		return source.dop.Secret(ctx, project, config, name)
+	}
+
+	format := source.URL.Query().Get("format")
+	switch format {
+	case "", "json":
+		format = "json"
+		source.mediaType = jsonMimetype
+	case "env":
+		source.mediaType = envMimetype
+	default:
+		return nil, errors.Errorf("unsupported format %q - must be json or env", format)
+	}
+	return source.dop.Download(ctx, project, config, format)
+}
+
+// bearerToken - the token in an "Authorization: Bearer" header, if any
+func bearerToken(h http.Header) string {
+	auth := h.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
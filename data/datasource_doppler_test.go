@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDoppler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.Header.Get("Authorization") != "Bearer dp.st.test":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v3/configs/config/secret":
+			_, _ = w.Write([]byte(`{"value": {"computed": "` + q.Get("project") + "/" + q.Get("config") + "/" + q.Get("name") + `"}}`))
+		case q.Get("format") == "env":
+			_, _ = w.Write([]byte("A=b\n"))
+		default:
+			_, _ = w.Write([]byte(`{"A": "b"}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("DOPPLER_API_HOST", srv.URL)
+	t.Setenv("DOPPLER_TOKEN", "")
+
+	ctx := context.Background()
+	hdr := http.Header{"Authorization": {"Bearer dp.st.test"}}
+
+	source := &Source{Alias: "foo", URL: mustParseURL("doppler:///app/prd"), Header: hdr}
+	b, err := readDoppler(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `{"A": "b"}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	b, err = readDoppler(ctx, source, "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "app/prd/DB_PASSWORD", string(b))
+
+	source = &Source{Alias: "foo", URL: mustParseURL("doppler://?format=env"), Header: hdr}
+	b, err = readDoppler(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, "A=b\n", string(b))
+	assert.Equal(t, envMimetype, source.mediaType)
+
+	b, err = readDoppler(ctx, source, "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "//DB_PASSWORD", string(b))
+
+	source = &Source{Alias: "foo", URL: mustParseURL("doppler:///a/b/c/d"), Header: hdr}
+	_, err = readDoppler(ctx, source)
+	assert.Error(t, err)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("doppler:///app/prd?format=yaml"), Header: hdr}
+	_, err = readDoppler(ctx, source)
+	assert.Error(t, err)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("doppler:///app/prd")}
+	_, err = readDoppler(ctx, source)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/elastic"
+	"github.com/pkg/errors"
+)
+
+// readElastic runs a search against an Elasticsearch or OpenSearch index
+// (es://HOST/INDEX), with the query DSL given as the argument (or in the query
+// query parameter), and returns the hits
+func readElastic(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	index := strings.Trim(source.URL.Path, "/")
+	if index == "" {
+		return nil, errors.Errorf("an index must be given in %s", source.URL)
+	}
+	q := source.URL.Query()
+	query := q.Get("query")
+	if len(args) == 1 {
+		query = args[0]
+		source.queryArg = true
+	}
+	_, meta := q["meta"]
+
+	if source.es == nil {
+		c, err := elastic.New(ctx, source.URL)
+		if err != nil {
+			return nil, err
+		}
+		source.es = c
+	}
+
+	hits, err := source.es.Search(ctx, index, []byte(query), meta)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(hits)
+}
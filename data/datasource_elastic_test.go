@@ -0,0 +1,44 @@
+package data
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadElastic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orders/_search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"hits": {"hits": [{"_id": "1", "_source": {"query": ` + string(b) + `}}]}}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	t.Setenv("ES_API_KEY", "")
+	t.Setenv("ES_USERNAME", "")
+	source := &Source{Alias: "foo", URL: mustParseURL("es+http://" + srv.Listener.Addr().String() + "/orders")}
+
+	b, err := readElastic(ctx, source, `{"query": {"match": {"note": "50% off"}}}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"query": {"query": {"match": {"note": "50% off"}}}}]`, string(b))
+	mt, err := source.mimeType(`{"query": {"match": {"note": "50% off"}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, jsonArrayMimetype, mt)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("es+http://" + srv.Listener.Addr().String() + "/orders?meta&query=%7B%22size%22%3A1%7D")}
+	b, err = readElastic(ctx, source)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"_id": "1", "_source": {"query": {"size": 1}}}]`, string(b))
+
+	_, err = readElastic(ctx, &Source{Alias: "foo", URL: mustParseURL("es://localhost:9200/")})
+	assert.Error(t, err)
+}
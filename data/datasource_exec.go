@@ -0,0 +1,47 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// readExec runs the command named by the URL (like 'exec:terraform', or
+// 'exec:///usr/local/bin/script'), and returns what it prints. The command's
+// arguments are given with 'arg' query parameters, followed by the args the
+// datasource is read with. The output is JSON unless the type says otherwise.
+//
+// Since they run commands, exec datasources can't be defined by templates -
+// only on the command line or in the config file.
+func readExec(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	if source.fromTemplate {
+		return nil, errors.Errorf("exec datasource '%s' must be defined with --datasource, --context, or in the config file", source.Alias)
+	}
+
+	name := source.URL.Opaque
+	if name == "" {
+		name = source.URL.Host + source.URL.Path
+	}
+	if name == "" {
+		return nil, errors.Errorf("no command given for exec datasource '%s'", source.Alias)
+	}
+
+	cmdArgs := append(append([]string{}, source.URL.Query()["arg"]...), args...)
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, name, cmdArgs...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Errorf("command %s for datasource '%s' failed: %v: %s", name, source.Alias, err, strings.TrimSpace(stderr.String()))
+	}
+
+	// the args are arguments, not paths, so they don't affect the type
+	source.queryArg = true
+	source.mediaType = jsonMimetype
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"net/url"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix commands")
+	}
+
+	mustParse := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		require.NoError(t, err)
+		return u
+	}
+	d := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"json": {Alias: "json", URL: mustParse(`exec:echo?arg={"a":1}`)},
+			"yaml": {Alias: "yaml", URL: mustParse(`exec:echo?arg=a:&arg=b&type=application/yaml`)},
+			"path": {Alias: "path", URL: mustParse(`exec:///bin/echo?arg=[1,`)},
+			"fail": {Alias: "fail", URL: mustParse(`exec:sh?arg=-c&arg=echo+oops+>%262%3B+exit+1`)},
+		},
+	}
+
+	v, err := d.Datasource("json")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, v)
+
+	v, err = d.Datasource("yaml")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "b"}, v)
+
+	// args are appended to the command's arguments
+	v, err = d.Datasource("path", "2]")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, v)
+
+	_, err = d.Datasource("fail")
+	assert.ErrorContains(t, err, "oops")
+
+	// templates can't define exec datasources
+	_, err = d.DefineDatasource("defined", `exec:echo?arg={}`)
+	require.NoError(t, err)
+	_, err = d.Datasource("defined")
+	assert.ErrorContains(t, err, "must be defined with --datasource")
+
+	_, err = d.Datasource(`exec:echo?arg={}`)
+	assert.ErrorContains(t, err, "must be defined with --datasource")
+}
@@ -16,24 +16,9 @@ import (
 )
 
 func readFile(ctx context.Context, source *Source, args ...string) ([]byte, error) {
-	if source.fs == nil {
-		source.fs = afero.NewOsFs()
-	}
-
-	p := filepath.FromSlash(source.URL.Path)
-
-	if len(args) == 1 {
-		parsed, err := url.Parse(args[0])
-		if err != nil {
-			return nil, err
-		}
-
-		if parsed.Path != "" {
-			p = filepath.Join(p, parsed.Path)
-		}
-
-		// reset the media type - it may have been set by a parent dir read
-		source.mediaType = ""
+	p, err := filePath(source, args...)
+	if err != nil {
+		return nil, err
 	}
 
 	// make sure we can access the file
@@ -64,6 +49,31 @@ func readFile(ctx context.Context, source *Source, args ...string) ([]byte, erro
 	return b, nil
 }
 
+// filePath - the path of the file the source (and optional subpath arg)
+// refers to
+func filePath(source *Source, args ...string) (string, error) {
+	if source.fs == nil {
+		source.fs = afero.NewOsFs()
+	}
+
+	p := filepath.FromSlash(source.URL.Path)
+
+	if len(args) == 1 {
+		parsed, err := url.Parse(args[0])
+		if err != nil {
+			return "", err
+		}
+
+		if parsed.Path != "" {
+			p = filepath.Join(p, parsed.Path)
+		}
+
+		// reset the media type - it may have been set by a parent dir read
+		source.mediaType = ""
+	}
+	return p, nil
+}
+
 func readFileDir(source *Source, p string) ([]byte, error) {
 	names, err := afero.ReadDir(source.fs, p)
 	if err != nil {
@@ -201,10 +201,21 @@ func (g gitsource) clone(ctx context.Context, repoURL *url.URL, depth int) (bill
 	// preserve repoURL by cloning it
 	u := cloneURL(repoURL)
 
-	auth, err := g.auth(u)
+	auth, err := g.helperAuth(ctx, u)
 	if err != nil {
 		return nil, nil, err
 	}
+	if auth == nil {
+		auth, err = g.auth(u)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	auth, err = g.policyAuth(ctx, u, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	installGitHTTP()
 
 	if strings.HasPrefix(u.Scheme, "git+") {
 		scheme := u.Scheme[len("git+"):]
@@ -291,6 +302,26 @@ func (g gitsource) readDir(fs billy.Filesystem, path string) ([]byte, error) {
 	return b[:len(b)-1], nil
 }
 
+// helperAuth - basic auth with the credentials from the credential helper
+// for the repo's host, if there is one. Passwords in the URL take precedence.
+func (g gitsource) helperAuth(ctx context.Context, u *url.URL) (transport.AuthMethod, error) {
+	if u.Scheme != "git+http" && u.Scheme != "git+https" {
+		return nil, nil
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, nil
+	}
+	creds, err := credentials(ctx, strings.TrimPrefix(u.Scheme, "git+"), u.Host)
+	if err != nil || creds == nil {
+		return nil, err
+	}
+	user := creds.Username
+	if user == "" {
+		user = u.User.Username()
+	}
+	return &http.BasicAuth{Username: user, Password: creds.Password}, nil
+}
+
 /*
 auth methods:
 - ssh named key (no password support)
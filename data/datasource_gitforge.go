@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/gitforge"
+	"github.com/pkg/errors"
+)
+
+// readGitForge reads a file, directory listing, or release's metadata through
+// the GitHub (github://OWNER/REPO/path@ref) or GitLab
+// (gitlab://HOST/GROUP/PROJECT/-/path@ref) API, without cloning the repository
+func readGitForge(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	repo, p, ref, err := parseForgeURL(source.URL)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		argPath, argRef := gitforge.SplitRef(args[0])
+		if p != "" && !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		p += argPath
+		if argRef != "" {
+			ref = argRef
+		}
+	}
+
+	if source.gf == nil {
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		if source.URL.Scheme == "github" {
+			source.gf = gitforge.NewGitHub(hc)
+		} else {
+			source.gf = gitforge.NewGitLab(hc, "https://"+source.URL.Host+"/api/v4")
+		}
+	}
+
+	if tag := source.URL.Query().Get("release"); tag != "" {
+		source.mediaType = jsonMimetype
+		return source.gf.Release(ctx, repo, tag)
+	}
+
+	if p == "" || strings.HasSuffix(p, "/") {
+		names, err := source.gf.List(ctx, repo, p, ref)
+		if err != nil {
+			return nil, err
+		}
+		source.mediaType = jsonArrayMimetype
+		return json.Marshal(names)
+	}
+
+	// the '@ref' suffix hides the extension from the usual MIME type detection
+	source.mediaType = mime.TypeByExtension(path.Ext(p))
+	return source.gf.File(ctx, repo, p, ref)
+}
+
+// parseForgeURL splits a github: or gitlab: URL into the repository's full
+// name, the path in the repository, and the ref
+func parseForgeURL(src *url.URL) (repo, p, ref string, err error) {
+	rest, ref := gitforge.SplitRef(strings.TrimPrefix(src.Path, "/"))
+
+	if src.Scheme == "github" {
+		name, inRepo, _ := strings.Cut(rest, "/")
+		if src.Host == "" || name == "" {
+			return "", "", "", errors.Errorf("an owner and repository must be given in %s", src)
+		}
+		return src.Host + "/" + name, inRepo, ref, nil
+	}
+
+	repo, p, _ = strings.Cut(rest, "/-/")
+	repo = strings.TrimSuffix(repo, "/-")
+	if src.Host == "" || !strings.Contains(repo, "/") {
+		return "", "", "", errors.Errorf("a host and project path (GROUP/PROJECT) must be given in %s", src)
+	}
+	return repo, p, ref, nil
+}
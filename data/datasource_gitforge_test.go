@@ -0,0 +1,90 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/gitforge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseForgeURL(t *testing.T) {
+	data := []struct {
+		u, repo, p, ref string
+	}{
+		{"github://o/r", "o/r", "", ""},
+		{"github://o/r@main", "o/r", "", "main"},
+		{"github://o/r/configs/", "o/r", "configs/", ""},
+		{"github://o/r/configs/app.yaml@v1.0", "o/r", "configs/app.yaml", "v1.0"},
+		{"gitlab://gitlab.com/g/p", "g/p", "", ""},
+		{"gitlab://gitlab.com/g/sub/p/-/", "g/sub/p", "", ""},
+		{"gitlab://gitlab.com/g/sub/p/-/a/b.json@feature/x", "g/sub/p", "a/b.json", "feature/x"},
+		{"gitlab://gitlab.com/g/p@main", "g/p", "", "main"},
+	}
+	for _, d := range data {
+		repo, p, ref, err := parseForgeURL(mustParseURL(d.u))
+		require.NoError(t, err, d.u)
+		assert.Equal(t, d.repo, repo, d.u)
+		assert.Equal(t, d.p, p, d.u)
+		assert.Equal(t, d.ref, ref, d.u)
+	}
+
+	for _, u := range []string{"github://o", "github:///r", "gitlab://gitlab.com/p", "gitlab:///g/p"} {
+		_, _, _, err := parseForgeURL(mustParseURL(u))
+		assert.Error(t, err, u)
+	}
+}
+
+func TestReadGitForge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/contents/configs/app.yaml":
+			_, _ = w.Write([]byte("ref: " + r.URL.Query().Get("ref")))
+		case "/repos/o/r/contents/configs":
+			_, _ = w.Write([]byte(`[{"name": "app.yaml", "type": "file"}]`))
+		case "/repos/o/r/releases/latest":
+			_, _ = w.Write([]byte(`{"tag_name": "v2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	newSource := func(u string) *Source {
+		return &Source{Alias: "foo", URL: mustParseURL(u), gf: gitforge.NewGitHub(srv.Client())}
+	}
+
+	ctx := context.Background()
+	source := newSource("github://o/r/configs/app.yaml@v1")
+	b, err := readGitForge(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, "ref: v1", string(b))
+	assert.Equal(t, yamlMimetype, source.mediaType)
+
+	source = newSource("github://o/r/configs/@v1")
+	b, err = readGitForge(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `["app.yaml"]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	b, err = readGitForge(ctx, source, "app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "ref: v1", string(b))
+
+	b, err = readGitForge(ctx, source, "app.yaml@v2")
+	require.NoError(t, err)
+	assert.Equal(t, "ref: v2", string(b))
+
+	source = newSource("github://o/r?release=latest")
+	b, err = readGitForge(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `{"tag_name": "v2"}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	_, err = readGitForge(ctx, newSource("github://o/r/missing.txt"))
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/hairyhenderson/gomplate/v3/internal/keyring"
 	"github.com/pkg/errors"
 )
 
@@ -22,21 +23,75 @@ func buildURL(base *url.URL, args ...string) (*url.URL, error) {
 	return base.ResolveReference(p), nil
 }
 
+// expandKeyring - the URL and headers to request, with references to secrets
+// stored in the keyring filled in. They're only filled in for the request, so
+// they don't appear in errors, and only for datasources defined on the command
+// line or in the config file - never in URLs or arguments given by templates,
+// which could send the secrets anywhere. Headers are only expanded when the
+// arguments don't change the host.
+func expandKeyring(source *Source, args ...string) (*url.URL, http.Header, error) {
+	if source.fromTemplate {
+		u, err := buildURL(source.URL, args...)
+		return u, source.Header, err
+	}
+	base, err := keyring.ExpandURL(source.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := buildURL(base, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Host != source.URL.Host {
+		return u, source.Header, nil
+	}
+	header, err := keyring.ExpandHeader(source.Header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, header, nil
+}
+
 func readHTTP(ctx context.Context, source *Source, args ...string) ([]byte, error) {
 	if source.hc == nil {
-		source.hc = &http.Client{Timeout: time.Second * 5}
+		source.hc = &http.Client{Timeout: time.Second * 5, Transport: transportFromContext(ctx, source.URL.Scheme)}
 	}
 	u, err := buildURL(source.URL, args...)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	reqURL, header, err := expandKeyring(source, args...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = source.Header
+	req.Header = header
+	if req.Header.Get("Authorization") == "" {
+		creds, err := credentials(ctx, u.Scheme, u.Host)
+		if err != nil {
+			return nil, err
+		}
+		if creds != nil {
+			req.Header = req.Header.Clone()
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			if creds.Username != "" {
+				req.SetBasicAuth(creds.Username, creds.Password)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+creds.Password)
+			}
+		}
+	}
 	res, err := source.hc.Do(req)
 	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			uerr.URL = u.String()
+		}
 		return nil, err
 	}
 	body, err := ioutil.ReadAll(res.Body)
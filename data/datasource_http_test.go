@@ -7,9 +7,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/credhelper"
+	"github.com/hairyhenderson/gomplate/v3/internal/keyring"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gokeyring "github.com/zalando/go-keyring"
 )
 
 func must(r interface{}, err error) interface{} {
@@ -122,6 +130,108 @@ func TestHTTPFileWithHeaders(t *testing.T) {
 	assert.Equal(t, must(marshalObj(expected, json.Marshal)), must(marshalObj(actual, json.Marshal)))
 }
 
+func TestHTTPFileWithCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helpers are shell scripts")
+	}
+	server, _ := setupHTTP(200, jsonMimetype, "")
+	defer server.Close()
+
+	helper := filepath.Join(t.TempDir(), "helper")
+	require.NoError(t, os.WriteFile(helper, []byte("#!/bin/sh\necho username=alice\necho password=s3cret\n"), 0o755))
+
+	u, _ := url.Parse(server.URL)
+	data := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"foo": {Alias: "foo", URL: u},
+			"bar": {Alias: "bar", URL: u, Header: http.Header{"Authorization": {"Bearer explicit"}}},
+		},
+		CredentialHelpers: credhelper.New([]config.CredentialHelper{
+			{Host: "127.0.0.1", Command: helper},
+		}),
+	}
+
+	actual, err := data.Datasource("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Basic YWxpY2U6czNjcmV0"}, actual.(map[string]interface{})["Authorization"])
+	assert.Nil(t, data.Sources["foo"].Header)
+
+	// credentials given with the datasource take precedence
+	actual, err = data.Datasource("bar")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Bearer explicit"}, actual.(map[string]interface{})["Authorization"])
+}
+
+func TestHTTPFileWithKeyring(t *testing.T) {
+	gokeyring.MockInit()
+	require.NoError(t, keyring.Set("api-token", "s3cret"))
+
+	server, _ := setupHTTP(200, jsonMimetype, "")
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	header := http.Header{"Authorization": {"Bearer keyring://api-token"}}
+	data := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"foo":     {Alias: "foo", URL: u, Header: header},
+			"missing": {Alias: "missing", URL: u, Header: http.Header{"X-Token": {"keyring://missing"}}},
+		},
+	}
+
+	actual, err := data.Datasource("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Bearer s3cret"}, actual.(map[string]interface{})["Authorization"])
+	assert.Equal(t, "Bearer keyring://api-token", header.Get("Authorization"))
+
+	_, err = data.Datasource("missing")
+	assert.ErrorContains(t, err, `no secret for "missing"`)
+}
+
+func TestHTTPFileWithKeyring_FromTemplate(t *testing.T) {
+	gokeyring.MockInit()
+	require.NoError(t, keyring.Set("api-token", "s3cret"))
+
+	// mirror back the query and headers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", jsonMimetype)
+		fmt.Fprintln(w, must(marshalObj(map[string]interface{}{
+			"query":  r.URL.RawQuery,
+			"header": r.Header.Get("X-Token"),
+		}, json.Marshal)))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL + "/x?t=keyring://api-token")
+	data := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"cfg": {Alias: "cfg", URL: u, Header: http.Header{"X-Token": {"keyring://api-token"}}},
+		},
+		ExtraHeaders: map[string]http.Header{
+			"evil": {"X-Token": {"keyring://api-token"}},
+		},
+	}
+
+	// configured datasources get the secrets
+	actual, err := data.Datasource("cfg")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"query": "t=s3cret", "header": "s3cret"}, actual)
+
+	// ...but not in the arguments given by templates
+	actual, err = data.Datasource("cfg", "/y?t=keyring://api-token")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"query": "t=keyring://api-token", "header": "s3cret"}, actual)
+
+	// ...and datasources defined by templates never get them
+	_, err = data.DefineDatasource("evil", server.URL+"/x?t=keyring://api-token")
+	require.NoError(t, err)
+	actual, err = data.Datasource("evil")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"query": "t=keyring://api-token", "header": "keyring://api-token"}, actual)
+}
+
 func TestBuildURL(t *testing.T) {
 	expected := "https://example.com/index.html"
 	base := mustParseURL(expected)
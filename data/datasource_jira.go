@@ -0,0 +1,78 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/jira"
+	"github.com/pkg/errors"
+)
+
+// readJira reads the issues matching a JQL query (given as the argument, or
+// in the jql query parameter) from Jira (jira://HOST), or a single issue
+// (jira://HOST/KEY-123)
+func readJira(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	q := source.URL.Query()
+	key := strings.Trim(source.URL.Path, "/")
+	jql := q.Get("jql")
+	if len(args) == 1 {
+		jql = args[0]
+		source.queryArg = true
+	}
+	if key == "" && jql == "" {
+		return nil, errors.Errorf("an issue key must be given in %s, or a JQL query as an argument or in the jql query parameter", source.URL)
+	}
+	limit, err := queryLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.jc == nil {
+		base := os.Getenv("JIRA_URL")
+		if source.URL.Host != "" {
+			base = "https://" + source.URL.Host
+		}
+		if base == "" {
+			return nil, errors.Errorf("a Jira host must be given in %s, or a URL in JIRA_URL", source.URL)
+		}
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		source.jc = jira.New(hc, base)
+	}
+
+	var fields []string
+	if f := q.Get("fields"); f != "" {
+		fields = strings.Split(f, ",")
+	}
+
+	if key != "" && len(args) == 0 {
+		source.mediaType = jsonMimetype
+		return source.jc.Issue(ctx, key, fields)
+	}
+
+	issues, err := source.jc.Search(ctx, jql, fields, limit)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(issues)
+}
+
+// queryLimit - the maximum number of records to read, from the max query
+// parameter, or 0 for no limit
+func queryLimit(q url.Values) (int, error) {
+	s := q.Get("max")
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid max %q: must be a non-negative number", s)
+	}
+	return n, nil
+}
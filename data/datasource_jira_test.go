@@ -0,0 +1,58 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/jira"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadJira(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/issue/OPS-1":
+			_, _ = w.Write([]byte(`{"key": "OPS-1"}`))
+		case "/rest/api/2/search":
+			jql, _ := json.Marshal(r.URL.Query().Get("jql"))
+			_, _ = w.Write([]byte(`{"total": 1, "issues": [{"key": "OPS-1", "jql": ` + string(jql) + `}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	newSource := func(u string) *Source {
+		return &Source{Alias: "foo", URL: mustParseURL(u), jc: jira.New(srv.Client(), srv.URL)}
+	}
+
+	ctx := context.Background()
+	source := newSource("jira://example.atlassian.net/OPS-1")
+	b, err := readJira(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `{"key": "OPS-1"}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	// JQL isn't a valid path, so it mustn't be used to find the MIME type
+	source = newSource("jira://example.atlassian.net")
+	b, err = readJira(ctx, source, `text ~ "100%" ORDER BY created`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"key": "OPS-1", "jql": "text ~ \"100%\" ORDER BY created"}]`, string(b))
+	mt, err := source.mimeType(`text ~ "100%" ORDER BY created`)
+	require.NoError(t, err)
+	assert.Equal(t, jsonArrayMimetype, mt)
+
+	b, err = readJira(ctx, newSource("jira://example.atlassian.net?jql=project%3DOPS"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"key": "OPS-1", "jql": "project=OPS"}]`, string(b))
+
+	_, err = readJira(ctx, newSource("jira://example.atlassian.net"))
+	assert.Error(t, err)
+
+	_, err = readJira(ctx, newSource("jira://example.atlassian.net?jql=x&max=lots"))
+	assert.EqualError(t, err, `invalid max "lots": must be a non-negative number`)
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/pkg/errors"
+)
+
+// kubeResource - the API resource for the kind in a k8s: URL's path
+func kubeResource(kind string) (string, bool) {
+	switch strings.ToLower(kind) {
+	case "configmap", "configmaps", "cm":
+		return "configmaps", true
+	case "secret", "secrets":
+		return "secrets", true
+	}
+	return "", false
+}
+
+// isKubeSecret - whether the k8s: URL is for a Secret
+func isKubeSecret(u *url.URL) bool {
+	kind := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+	r, _ := kubeResource(kind)
+	return r == "secrets"
+}
+
+// readKube reads a ConfigMap (k8s://NAMESPACE/configmap/NAME) or Secret
+// (k8s://NAMESPACE/secret/NAME) from the cluster gomplate is running in, or
+// the current kubeconfig context. The data is returned as an object, with
+// Secrets' values decoded. With a key (in the path, or as the argument), only
+// that key's value is returned.
+func readKube(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	parts := strings.Split(strings.Trim(source.URL.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+		return nil, errors.Errorf("the path in %s must be /configmap/NAME[/KEY] or /secret/NAME[/KEY]", source.URL)
+	}
+	resource, ok := kubeResource(parts[0])
+	if !ok {
+		return nil, errors.Errorf("unsupported kind %q in %s - must be configmap or secret", parts[0], source.URL)
+	}
+	name, key := parts[1], ""
+	if len(parts) == 3 {
+		key = parts[2]
+	}
+	if len(args) == 1 {
+		key = strings.Trim(args[0], "/")
+	}
+
+	if source.kc == nil {
+		c, err := kube.Default()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create kubernetes client")
+		}
+		source.kc = c
+	}
+
+	// like kubectl, the context's namespace (or the default namespace) is
+	// used when none is given
+	namespace := source.URL.Host
+	if namespace == "" {
+		namespace = source.kc.Namespace
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	obj, err := source.kc.Get(ctx, resource, namespace, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s/%s in namespace %s", resource, name, namespace)
+	}
+
+	data, err := kubeData(resource, obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s/%s in namespace %s", resource, name, namespace)
+	}
+
+	if key != "" {
+		v, ok := data[key]
+		if !ok {
+			return nil, errors.Errorf("key %q not found in %s/%s in namespace %s", key, resource, name, namespace)
+		}
+		source.mediaType = mime.TypeByExtension(path.Ext(key))
+		return []byte(v), nil
+	}
+
+	source.mediaType = jsonMimetype
+	return json.Marshal(data)
+}
+
+// kubeData - the object's data, with base64-encoded values (Secrets' data,
+// and ConfigMaps' binaryData) decoded
+func kubeData(resource string, obj *kube.Object) (map[string]string, error) {
+	data := map[string]string{}
+	encoded := obj.BinaryData
+	if resource == "secrets" {
+		encoded = obj.Data
+	} else {
+		for k, v := range obj.Data {
+			data[k] = v
+		}
+	}
+	for k, v := range encoded {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid base64 value for key %q", k)
+		}
+		data[k] = string(b)
+	}
+	return data, nil
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube/kubetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadKube(t *testing.T) {
+	srv := kubetest.NewServer()
+	defer srv.Close()
+	client, err := kube.New(srv.URL)
+	require.NoError(t, err)
+	client.Namespace = "team"
+
+	srv.Put("configmaps", kube.Object{
+		Metadata:   kube.ObjectMeta{Name: "app", Namespace: "team"},
+		Data:       map[string]string{"config.yaml": "foo: bar\n", "mode": "fast"},
+		BinaryData: map[string]string{"logo": base64.StdEncoding.EncodeToString([]byte("PNG"))},
+	})
+	srv.Put("secrets", kube.Object{
+		Metadata: kube.ObjectMeta{Name: "db", Namespace: "prod"},
+		Data:     map[string]string{"password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+	})
+
+	read := func(u string, args ...string) (string, string, error) {
+		t.Helper()
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		s := &Source{Alias: "k", URL: parsed, kc: client}
+		b, err := readKube(context.Background(), s, args...)
+		if err != nil {
+			return "", "", err
+		}
+		mt, err := s.mimeType("")
+		require.NoError(t, err)
+		return string(b), mt, nil
+	}
+
+	// the context's namespace is the default
+	out, mt, err := read("k8s:///configmap/app")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"config.yaml": "foo: bar\n", "mode": "fast", "logo": "PNG"}`, out)
+	assert.Equal(t, jsonMimetype, mt)
+
+	// secrets are decoded
+	out, _, err = read("k8s://prod/secret/db")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password": "hunter2"}`, out)
+
+	out, _, err = read("k8s://prod/secrets/db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", out)
+
+	out, mt, err = read("k8s://team/cm/app", "config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "foo: bar\n", out)
+	assert.Equal(t, yamlMimetype, mt)
+
+	_, _, err = read("k8s://team/configmap/app/missing")
+	assert.ErrorContains(t, err, `key "missing" not found`)
+
+	_, _, err = read("k8s://team/configmap/nope")
+	assert.ErrorIs(t, err, kube.ErrNotFound)
+
+	_, _, err = read("k8s://team/pod/app")
+	assert.ErrorContains(t, err, "unsupported kind")
+
+	_, _, err = read("k8s://team/secret")
+	assert.Error(t, err)
+}
+
+func TestIsSecretURL(t *testing.T) {
+	for u, expected := range map[string]bool{
+		"vault:///secret/foo":  true,
+		"aws+sm:///foo":        true,
+		"k8s://ns/secret/foo":  true,
+		"k8s:///secrets/foo/k": true,
+		"k8s://ns/configmap/x": false,
+		"file:///etc/hosts":    false,
+	} {
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		assert.Equal(t, expected, isSecretURL(parsed), u)
+	}
+	assert.False(t, isSecretURL(nil))
+}
@@ -28,35 +28,17 @@ func (d *Data) readMerge(ctx context.Context, source *Source, args ...string) ([
 	}
 	data := make([]map[string]interface{}, len(parts))
 	for i, part := range parts {
-		// supports either URIs or aliases
-		subSource, err := d.lookupSource(part)
+		subSource, b, mimeType, err := d.readMergePart(ctx, source, part)
 		if err != nil {
-			// maybe it's a relative filename?
-			u, uerr := config.ParseSourceURL(part)
-			if uerr != nil {
-				return nil, uerr
-			}
-			subSource = &Source{
-				Alias: part,
-				URL:   u,
-			}
-		}
-		subSource.inherit(source)
-
-		b, err := d.readSource(ctx, subSource)
-		if err != nil {
-			return nil, errors.Wrapf(err, "Couldn't read datasource '%s'", part)
-		}
-
-		mimeType, err := subSource.mimeType("")
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to read datasource %s", subSource.URL)
+			return nil, err
 		}
 
 		data[i], err = parseMap(mimeType, string(b))
 		if err != nil {
 			return nil, err
 		}
+		// record each layer, so merged values can be traced to their layer
+		recordProvenance(ctx, subSource, "datasource "+part, data[i])
 	}
 
 	// Merge the data together
@@ -69,6 +51,45 @@ func (d *Data) readMerge(ctx context.Context, source *Source, args ...string) ([
 	return b, nil
 }
 
+// readMergePart reads one of the sources to merge, which is either a URI or
+// an alias
+func (d *Data) readMergePart(ctx context.Context, source *Source, part string) (*Source, []byte, string, error) {
+	d.mu.Lock()
+	subSource, err := d.lookupSource(part)
+	d.mu.Unlock()
+	if err == nil {
+		// defined sources can be read by other templates at the same time
+		unlock, lerr := d.lockSource(ctx, subSource)
+		if lerr != nil {
+			return nil, nil, "", errors.Wrapf(lerr, "Couldn't read datasource '%s'", part)
+		}
+		defer unlock()
+	} else {
+		// maybe it's a relative filename?
+		u, uerr := config.ParseSourceURL(part)
+		if uerr != nil {
+			return nil, nil, "", uerr
+		}
+		subSource = &Source{
+			Alias:        part,
+			URL:          u,
+			fromTemplate: true,
+		}
+	}
+	subSource.inherit(source)
+
+	b, err := d.readSource(ctx, subSource)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "Couldn't read datasource '%s'", part)
+	}
+
+	mimeType, err := subSource.mimeType("")
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed to read datasource %s", subSource.URL)
+	}
+	return subSource, b, mimeType, nil
+}
+
 func mergeData(data []map[string]interface{}) (out []byte, err error) {
 	dst := data[0]
 	data = data[1:]
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/mqttclient"
+	"github.com/pkg/errors"
+)
+
+// readMQTT reads the retained message on a topic. Topic filters with
+// wildcards, and topics ending with '/', are listed instead.
+func readMQTT(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	topic := strings.TrimPrefix(source.URL.Path, "/")
+	if len(args) == 1 {
+		topic = strings.TrimSuffix(topic, "/") + "/" + strings.TrimPrefix(args[0], "/")
+	}
+	if topic == "" || topic == "/" {
+		return nil, errors.Errorf("a topic must be given in %s", source.URL)
+	}
+
+	if source.mc == nil {
+		mc, err := mqttclient.Connect(ctx, source.URL)
+		if err != nil {
+			return nil, err
+		}
+		source.mc = mc
+	}
+
+	if !strings.HasSuffix(topic, "/") && !mqttclient.IsWildcard(topic) {
+		return mqttclient.Get(ctx, source.mc, topic)
+	}
+
+	// a trailing '/' lists the topics beneath, relative to the prefix, so they
+	// can be read by passing them back as arguments
+	filter := topic
+	if strings.HasSuffix(topic, "/") {
+		filter += "#"
+	}
+	topics, err := mqttclient.List(ctx, source.mc, filter)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(topic, "/") {
+		for i, t := range topics {
+			topics[i] = strings.TrimPrefix(t, topic)
+		}
+	}
+
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(topics)
+}
@@ -0,0 +1,19 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMQTTInvalidPaths(t *testing.T) {
+	ctx := context.Background()
+
+	for _, u := range []string{"mqtt://", "mqtt:///", "mqtts://broker"} {
+		source := &Source{Alias: "foo", URL: mustParseURL(u)}
+		_, err := readMQTT(ctx, source)
+		assert.Error(t, err, u)
+		assert.Nil(t, source.mc, u)
+	}
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/natsclient"
+	"github.com/pkg/errors"
+)
+
+// readNATS reads the latest message on a JetStream subject (nats:), or the
+// value of a key in a JetStream key/value bucket (nats+kv:)
+func readNATS(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	var bucket, key, subject string
+	if source.URL.Scheme == natsclient.KVScheme {
+		bucket, key = natsclient.ParseKV(source.URL.Path)
+		if len(args) == 1 {
+			key = strings.Trim(key, "/")
+			if key != "" {
+				key += "/"
+			}
+			key += args[0]
+		}
+		if bucket == "" || key == "" {
+			return nil, errors.Errorf("a bucket and key must be given in %s", source.URL)
+		}
+	} else {
+		subject = natsclient.ParseSubject(source.URL.Path)
+		if len(args) == 1 {
+			subject = strings.TrimSuffix(subject, ".") + "." + args[0]
+		}
+		if subject == "" || strings.HasPrefix(subject, ".") {
+			return nil, errors.Errorf("a subject must be given in %s", source.URL)
+		}
+	}
+
+	if source.nc == nil {
+		nc, err := natsclient.Connect(ctx, source.URL)
+		if err != nil {
+			return nil, err
+		}
+		source.nc = nc
+	}
+
+	if bucket != "" {
+		return natsclient.KVGet(ctx, source.nc, bucket, key)
+	}
+	return natsclient.Latest(ctx, source.nc, subject)
+}
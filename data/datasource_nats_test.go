@@ -0,0 +1,23 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNATSInvalidPaths(t *testing.T) {
+	ctx := context.Background()
+
+	for _, u := range []string{"nats+kv://", "nats+kv:///bucket", "nats+kv:///bucket/", "nats://", "nats:///"} {
+		source := &Source{Alias: "foo", URL: mustParseURL(u)}
+		_, err := readNATS(ctx, source)
+		assert.Error(t, err, u)
+		assert.Nil(t, source.nc, u)
+	}
+
+	source := &Source{Alias: "foo", URL: mustParseURL("nats://")}
+	_, err := readNATS(ctx, source, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/ofrep"
+	"github.com/pkg/errors"
+)
+
+// readOFREP evaluates all of the feature flags from an OpenFeature (OFREP)
+// service (ofrep://HOST/path, or ofrep+http://HOST/path), or a single flag,
+// given as an argument. The query parameters are the evaluation context.
+// Without a host, the service at OFREP_URL is used.
+func readOFREP(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	if len(args) > 1 {
+		return nil, errors.New("only one flag key can be given")
+	}
+
+	base := ""
+	if source.URL.Host != "" {
+		scheme := "https"
+		if source.URL.Scheme == "ofrep+http" {
+			scheme = "http"
+		}
+		base = scheme + "://" + source.URL.Host + strings.TrimSuffix(source.URL.Path, "/")
+	}
+	hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+	c, err := ofrep.New(hc, base, bearerToken(source.Header))
+	if err != nil {
+		return nil, err
+	}
+
+	evalCtx := map[string]interface{}{}
+	for k, v := range source.URL.Query() {
+		evalCtx[k] = v[0]
+	}
+
+	source.mediaType = jsonMimetype
+	if len(args) == 1 {
+		e, err := c.Evaluate(ctx, args[0], evalCtx)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.Err(); err != nil {
+			return nil, err
+		}
+		// flag values can be scalars, which are read as text
+		switch v := e.Value.(type) {
+		case map[string]interface{}:
+		case []interface{}:
+			source.mediaType = jsonArrayMimetype
+		case string:
+			source.mediaType = textMimetype
+			return []byte(v), nil
+		default:
+			source.mediaType = textMimetype
+		}
+		return json.Marshal(e.Value)
+	}
+
+	flags, err := c.EvaluateAll(ctx, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	// flags that couldn't be evaluated are left out, so defaults apply
+	out := make(map[string]interface{}, len(flags))
+	for _, f := range flags {
+		if f.Err() == nil {
+			out[f.Key] = f.Value
+		}
+	}
+	return json.Marshal(out)
+}
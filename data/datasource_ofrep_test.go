@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOFREP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Context map[string]interface{} `json:"context"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/flags/ofrep/v1/evaluate/flags":
+			_, _ = w.Write([]byte(`{"flags": [
+				{"key": "new-ui", "value": true},
+				{"key": "env", "value": "` + req.Context["env"].(string) + `"},
+				{"key": "broken", "errorCode": "PARSE_ERROR"}
+			]}`))
+		case "/flags/ofrep/v1/evaluate/flags/limits":
+			_, _ = w.Write([]byte(`{"key": "limits", "value": {"max": 10}}`))
+		case "/flags/ofrep/v1/evaluate/flags/banner":
+			_, _ = w.Write([]byte(`{"key": "banner", "value": "hello"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errorCode": "FLAG_NOT_FOUND"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	u := mustParseURL(srv.URL)
+
+	source := &Source{Alias: "flags", URL: mustParseURL("ofrep+http://" + u.Host + "/flags/?env=prod")}
+	b, err := readOFREP(ctx, source)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"new-ui": true, "env": "prod"}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	b, err = readOFREP(ctx, source, "limits")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"max": 10}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	b, err = readOFREP(ctx, source, "banner")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.Equal(t, textMimetype, source.mediaType)
+
+	_, err = readOFREP(ctx, source, "missing")
+	assert.EqualError(t, err, "failed to evaluate flag missing: FLAG_NOT_FOUND")
+
+	// without a host, OFREP_URL is used
+	t.Setenv("OFREP_URL", srv.URL+"/flags")
+	source = &Source{Alias: "flags", URL: mustParseURL("ofrep:///?env=dev")}
+	b, err = readOFREP(ctx, source)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"new-ui": true, "env": "dev"}`, string(b))
+}
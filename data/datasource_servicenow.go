@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/servicenow"
+	"github.com/pkg/errors"
+)
+
+// readServiceNow reads the records in a ServiceNow table matching an encoded
+// query (given as the argument, or in the query query parameter)
+// (servicenow://HOST/TABLE), or a single record (servicenow://HOST/TABLE/SYS_ID)
+func readServiceNow(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	q := source.URL.Query()
+	table, sysID, _ := strings.Cut(strings.Trim(source.URL.Path, "/"), "/")
+	if table == "" {
+		return nil, errors.Errorf("a table must be given in %s", source.URL)
+	}
+	query := q.Get("query")
+	if len(args) == 1 {
+		query = args[0]
+		source.queryArg = true
+	}
+	limit, err := queryLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.sn == nil {
+		base := os.Getenv("SERVICENOW_URL")
+		if source.URL.Host != "" {
+			base = "https://" + source.URL.Host
+		}
+		if base == "" {
+			return nil, errors.Errorf("a ServiceNow instance's host must be given in %s, or a URL in SERVICENOW_URL", source.URL)
+		}
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: transportFromContext(ctx, source.URL.Scheme)}
+		source.sn = servicenow.New(hc, base)
+	}
+
+	params := url.Values{}
+	if f := q.Get("fields"); f != "" {
+		params.Set("sysparm_fields", f)
+	}
+	if d := q.Get("display_value"); d != "" {
+		params.Set("sysparm_display_value", d)
+	}
+
+	if sysID != "" && len(args) == 0 {
+		source.mediaType = jsonMimetype
+		return source.sn.Record(ctx, table, sysID, params)
+	}
+
+	records, err := source.sn.Query(ctx, table, query, params, limit)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(records)
+}
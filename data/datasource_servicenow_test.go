@@ -0,0 +1,47 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/servicenow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadServiceNow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/now/table/change_request/abc":
+			_, _ = w.Write([]byte(`{"result": {"number": "CHG0001", "display": "` + q.Get("sysparm_display_value") + `"}}`))
+		case "/api/now/table/change_request":
+			_, _ = w.Write([]byte(`{"result": [{"query": "` + q.Get("sysparm_query") + `", "fields": "` + q.Get("sysparm_fields") + `"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	newSource := func(u string) *Source {
+		return &Source{Alias: "foo", URL: mustParseURL(u), sn: servicenow.New(srv.Client(), srv.URL)}
+	}
+
+	ctx := context.Background()
+	source := newSource("servicenow://example.service-now.com/change_request/abc?display_value=true")
+	b, err := readServiceNow(ctx, source)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"number": "CHG0001", "display": "true"}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	source = newSource("servicenow://example.service-now.com/change_request?fields=number")
+	b, err = readServiceNow(ctx, source, "state=new^short_descriptionLIKE50%")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"query": "state=new^short_descriptionLIKE50%", "fields": "number"}]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	_, err = readServiceNow(ctx, newSource("servicenow://example.service-now.com"))
+	assert.Error(t, err)
+}
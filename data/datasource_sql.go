@@ -0,0 +1,199 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+
+	// registers the postgres driver
+	_ "github.com/lib/pq"
+)
+
+// sqlParams - query parameters used by gomplate, which aren't passed on to
+// the database driver
+var sqlParams = []string{"query", "max", "type", "socket"}
+
+// readSQL runs a SQL query against a PostgreSQL (postgres://HOST/DATABASE) or
+// MySQL (mysql://HOST/DATABASE) database, given as the argument (or in the
+// query query parameter), and returns the result rows as objects. Any further
+// arguments are the query's parameters (for placeholders like $1 or ?).
+func readSQL(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	q := source.URL.Query()
+	query := q.Get("query")
+	if len(args) > 0 {
+		query = args[0]
+		source.queryArg = true
+	}
+	if query == "" {
+		return nil, errors.Errorf("a query must be given as an argument, or in the query parameter of %s", source.URL.Redacted())
+	}
+	limit, strict, err := rowLimit(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.db == nil {
+		source.db, err = openSQL(source.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := []interface{}{}
+	for i := 1; i < len(args); i++ {
+		params = append(params, args[i])
+	}
+	rs, err := source.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "query failed on %s", source.URL.Redacted())
+	}
+	defer rs.Close()
+
+	cols, err := rs.ColumnTypes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read query result columns")
+	}
+
+	rows := []map[string]interface{}{}
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rs.Next() {
+		if err := rs.Scan(ptrs...); err != nil {
+			return nil, errors.Wrap(err, "failed to read query result row")
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			row[c.Name()] = sqlValue(c.DatabaseTypeName(), values[i])
+		}
+		rows = append(rows, row)
+
+		var more bool
+		rows, more, err = applyRowLimit(rows, limit, strict)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read query results")
+	}
+
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(rows)
+}
+
+// openSQL opens a connection pool for the database URL, with gomplate's
+// query parameters removed
+func openSQL(u *url.URL) (*sql.DB, error) {
+	q := u.Query()
+	socket := q.Get("socket")
+	for _, p := range sqlParams {
+		q.Del(p)
+	}
+
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		dsn := *u
+		dsn.RawQuery = q.Encode()
+		db, err := sql.Open("postgres", dsn.String())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", u.Redacted())
+		}
+		return db, nil
+	case "mysql":
+		cfg, err := mysqlConfig(u, q, socket)
+		if err != nil {
+			return nil, err
+		}
+		c, err := mysql.NewConnector(cfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", u.Redacted())
+		}
+		return sql.OpenDB(c), nil
+	}
+	return nil, errors.Errorf("unsupported SQL scheme %s", u.Scheme)
+}
+
+// mysqlConfig converts the URL to the MySQL driver's config. The driver's
+// parameters (like tls or parseTime) are given as query parameters, and the
+// socket parameter connects with a Unix socket instead of TCP.
+func mysqlConfig(u *url.URL, q url.Values, socket string) (*mysql.Config, error) {
+	addr := "tcp(" + u.Host + ")"
+	if u.Host != "" && u.Port() == "" {
+		addr = "tcp(" + net.JoinHostPort(u.Hostname(), "3306") + ")"
+	}
+	if socket != "" {
+		addr = "unix(" + socket + ")"
+	}
+	dsn := addr + "/" + strings.TrimPrefix(u.Path, "/")
+	if len(q) > 0 {
+		dsn += "?" + q.Encode()
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid MySQL URL %s", u.Redacted())
+	}
+	cfg.User = u.User.Username()
+	cfg.Passwd, _ = u.User.Password()
+	return cfg, nil
+}
+
+// sqlValue converts a scanned value to a value that can be marshalled as
+// JSON. Drivers return many types as bytes (MySQL returns all of them as
+// bytes for queries without parameters), so they're converted according to
+// the column's type. DECIMAL and NUMERIC values are left as strings, so that
+// they don't lose precision.
+func sqlValue(typ string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return sqlBytesValue(strings.ToUpper(typ), v)
+	default:
+		return v
+	}
+}
+
+func sqlBytesValue(typ string, b []byte) interface{} {
+	s := string(b)
+	switch strings.TrimPrefix(typ, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR", "INT2", "INT4", "INT8":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "BOOL", "BOOLEAN":
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
+	case "JSON", "JSONB":
+		var out interface{}
+		if err := json.Unmarshal(b, &out); err == nil {
+			return out
+		}
+	case "BYTEA", "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BIT":
+		// binary data is marshalled as base64
+		return b
+	}
+	return s
+}
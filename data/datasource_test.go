@@ -4,14 +4,20 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
 	"github.com/spf13/afero"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const osWindows = "windows"
@@ -85,10 +91,19 @@ func TestDatasource(t *testing.T) {
 
 	testObj("json", jsonMimetype, []byte(`{"hello":{"cruel":"world"}}`))
 	testObj("yml", yamlMimetype, []byte("hello:\n  cruel: world\n"))
+	testObj("xml", "text/xml", []byte("<hello><cruel>world</cruel></hello>"))
+	testObj("hcl", hclMimetype, []byte("hello {\n  cruel = \"world\"\n}\n"))
+	testObj("tf", "", []byte("hello {\n  cruel = \"world\"\n}\n"))
 	test("json", jsonMimetype, []byte(`[1, "two", true]`),
 		[]interface{}{1, "two", true})
 	test("yaml", yamlMimetype, []byte("---\n- 1\n- two\n- true\n"),
 		[]interface{}{1, "two", true})
+	test("rss", rssMimetype, []byte(`<rss version="2.0"><channel><title>t</title>
+<item><title>one</title></item></channel></rss>`),
+		map[string]interface{}{
+			"title": "t",
+			"items": []interface{}{map[string]interface{}{"title": "one"}},
+		})
 
 	d := setup("", textMimetype, nil)
 	actual, err := d.Datasource("foo")
@@ -178,6 +193,51 @@ func TestInclude(t *testing.T) {
 	assert.Equal(t, contents, actual)
 }
 
+func TestDatasourceContext(t *testing.T) {
+	calls := int32(0)
+	started := make(chan struct{}, 1)
+	d := &Data{
+		Sources: map[string]*Source{
+			"slow": {Alias: "slow", URL: &url.URL{Scheme: "slow", Opaque: "x"}, mediaType: jsonMimetype},
+			"fast": {Alias: "fast", URL: &url.URL{Scheme: "fast", Opaque: "x"}, mediaType: jsonMimetype},
+		},
+		sourceReaders: map[string]func(context.Context, *Source, ...string) ([]byte, error){
+			"slow": func(ctx context.Context, _ *Source, _ ...string) ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				started <- struct{}{}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			"fast": func(context.Context, *Source, ...string) ([]byte, error) {
+				return []byte(`{"a": 1}`), nil
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := d.DatasourceContext(ctx, "slow")
+		errc <- err
+	}()
+	<-started
+
+	// other sources can be read while the slow one is being read
+	out, err := d.Datasource("fast")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, out)
+
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+
+	// the cancelled read doesn't keep the source locked
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = d.IncludeContext(ctx, "slow")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
 type errorReader struct{}
 
 func (e errorReader) Read(p []byte) (n int, err error) {
@@ -426,3 +486,146 @@ func TestListDatasources(t *testing.T) {
 
 	assert.Equal(t, []string{"bar", "foo"}, data.ListDatasources())
 }
+
+type fakeVerifier struct{}
+
+func (fakeVerifier) Verify(u *url.URL, data []byte, readSignature func(string) ([]byte, error)) error {
+	sig, err := readSignature(".sig")
+	if err != nil {
+		return err
+	}
+	if string(sig) != "signed:"+string(data) {
+		return fmt.Errorf("bad signature for %s", u)
+	}
+	return nil
+}
+
+func TestReadSourceVerified(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = fs.Mkdir("/dir", 0777)
+	_ = afero.WriteFile(fs, "/foo.json", []byte(`{"a": 1}`), 0600)
+	_ = afero.WriteFile(fs, "/foo.json.sig", []byte(`signed:{"a": 1}`), 0600)
+	_ = afero.WriteFile(fs, "/dir/bar.json", []byte(`{"b": 2}`), 0600)
+	_ = afero.WriteFile(fs, "/dir/bar.json.sig", []byte(`signed:{"b": 2}`), 0600)
+	_ = afero.WriteFile(fs, "/dir/baz.json", []byte(`{"c": 3}`), 0600)
+	_ = afero.WriteFile(fs, "/dir/baz.json.sig", []byte(`signed:{"c": 4}`), 0600)
+
+	d := &Data{
+		Sources: map[string]*Source{
+			"foo": {Alias: "foo", URL: &url.URL{Scheme: "file", Path: "/foo.json"}, fs: fs},
+			"dir": {Alias: "dir", URL: &url.URL{Scheme: "file", Path: "/dir/"}, fs: fs},
+		},
+		Verifier: fakeVerifier{},
+	}
+
+	out, err := d.Datasource("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, out)
+
+	out, err = d.Datasource("dir", "bar.json")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"b": 2}, out)
+
+	_, err = d.Datasource("dir", "baz.json")
+	assert.Error(t, err)
+}
+
+func TestReadSourceIntegrity(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = fs.Mkdir("/dir", 0777)
+	_ = afero.WriteFile(fs, "/foo.json", []byte(`{"a": 1}`), 0600)
+
+	d := &Data{
+		Sources: map[string]*Source{
+			"foo": {
+				Alias: "foo", URL: &url.URL{Scheme: "file", Path: "/foo.json"}, fs: fs,
+				Integrity: "sha256-f9d86028c6e0d64e225186f96acb69338b2c59764df79162107f5c4bb34d1310",
+			},
+			"bar": {
+				Alias: "bar", URL: &url.URL{Scheme: "file", Path: "/foo.json"}, fs: fs,
+				Integrity: "sha256-b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			},
+			"dir": {
+				Alias: "dir", URL: &url.URL{Scheme: "file", Path: "/dir/"}, fs: fs,
+				Integrity: "sha256-b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			},
+		},
+	}
+
+	out, err := d.Datasource("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, out)
+
+	_, err = d.Datasource("bar")
+	assert.ErrorContains(t, err, "integrity check failed for datasource 'bar'")
+
+	_, err = d.Datasource("dir", "foo.json")
+	assert.ErrorContains(t, err, "can't be read with extra arguments")
+}
+
+func TestReadSourceNetworkPolicy(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// same server, but by a different name
+			http.Redirect(w, r, "http://localhost:"+srv.URL[strings.LastIndex(srv.URL, ":")+1:]+"/foo.json", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", jsonMimetype)
+		w.Write([]byte(`{"a": 1}`))
+	}))
+	defer srv.Close()
+
+	mustParse := func(s string) *url.URL {
+		u, _ := url.Parse(s)
+		return u
+	}
+	d := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"foo":      {Alias: "foo", URL: mustParse(srv.URL + "/foo.json")},
+			"redirect": {Alias: "redirect", URL: mustParse(srv.URL + "/redirect")},
+			"other":    {Alias: "other", URL: mustParse("http://example.com/foo.json")},
+		},
+		NetworkPolicy: netpolicy.New(&config.NetworkPolicy{Allow: []config.NetworkRule{
+			{Schemes: []string{"http"}, Hosts: []string{"127.0.0.1"}},
+		}}),
+	}
+
+	out, err := d.Datasource("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, out)
+
+	_, err = d.Datasource("other")
+	assert.ErrorContains(t, err, "network policy doesn't allow http URLs to host example.com")
+
+	// enforced when connecting too, so redirects can't escape the policy
+	_, err = d.Datasource("redirect")
+	assert.ErrorContains(t, err, "network policy doesn't allow http connections to localhost:")
+}
+
+func TestReadSourceSchemes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", jsonMimetype)
+		fmt.Fprintf(w, `{"path": %q}`, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	d := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"web": {Alias: "web", URL: &url.URL{Scheme: "cfg", Host: "apps", Path: "/web"}},
+		},
+		Schemes: map[string]string{"cfg": srv.URL + "/v1/"},
+	}
+
+	out, err := d.Datasource("web")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"path": "/v1/apps/web"}, out)
+	assert.Equal(t, srv.URL+"/v1/apps/web", d.Sources["web"].URL.String())
+
+	// URLs used directly in templates are expanded too
+	out, err = d.Datasource("cfg://apps/worker")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"path": "/v1/apps/worker"}, out)
+}
@@ -2,32 +2,80 @@ package data
 
 import (
 	"context"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 
+	"github.com/hairyhenderson/gomplate/v3/internal/credhelper"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
 	"github.com/hairyhenderson/gomplate/v3/vault"
 )
 
-func readVault(ctx context.Context, source *Source, args ...string) (data []byte, err error) {
-	if source.vc == nil {
-		source.vc, err = vault.New(source.URL)
-		if err != nil {
-			return nil, err
-		}
-		err = source.vc.Login()
+// vaultClient returns the source's Vault client, creating and logging in with
+// it first if needed
+func vaultClient(ctx context.Context, source *Source) (*vault.Vault, error) {
+	if source.vc != nil {
+		return source.vc, nil
+	}
+
+	var opts []vault.Option
+	if p := netpolicy.FromContext(ctx); p != nil {
+		opts = append(opts, vault.WithDialContext(p.DialContext(source.URL.Scheme, (&net.Dialer{}).DialContext)))
+	}
+	if d := transportsFromContext(ctx); d != nil {
+		opts = append(opts,
+			vault.WithMaxConnsPerHost(d.MaxConnsPerHost),
+			vault.WithRateLimit(d.RateLimiter.ForHost))
+	}
+	vc, err := vault.New(source.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// a token from a credential helper takes precedence over the usual auth
+	// methods
+	var creds *credhelper.Credentials
+	if addr, perr := url.Parse(vc.Address()); perr == nil {
+		creds, err = credentials(ctx, addr.Scheme, addr.Host)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if creds != nil {
+		vc.SetToken(creds.Password)
+	} else if err = vc.Login(); err != nil {
+		return nil, err
+	}
+	source.vc = vc
+	return vc, nil
+}
+
+func readVault(ctx context.Context, source *Source, args ...string) (data []byte, err error) {
+	if _, err = vaultClient(ctx, source); err != nil {
+		return nil, err
+	}
 
 	params, p, err := parseDatasourceURLArgs(source.URL, args...)
 	if err != nil {
 		return nil, err
 	}
 
+	// 'version' selects a version of a KV v2 secret to read, so it's not a
+	// parameter to write
+	version, err := secretVersion(params)
+	if err != nil {
+		return nil, err
+	}
+
 	source.mediaType = jsonMimetype
 	switch {
+	case version != "" && (len(params) > 0 || strings.HasSuffix(p, "/")):
+		return nil, errors.Errorf("version can only be given when reading a single secret, not with path %s", p)
+	case version != "":
+		data, err = source.vc.ReadVersion(p, version)
 	case len(params) > 0:
 		data, err = source.vc.Write(p, params)
 	case strings.HasSuffix(p, "/"):
@@ -46,3 +94,47 @@ func readVault(ctx context.Context, source *Source, args ...string) (data []byte
 
 	return data, nil
 }
+
+// secretVersion removes the 'version' parameter from params and returns it,
+// if it's a valid version number
+func secretVersion(params map[string]interface{}) (string, error) {
+	v, ok := params["version"]
+	if !ok {
+		return "", nil
+	}
+	delete(params, "version")
+
+	version, _ := v.(string)
+	if n, err := strconv.Atoi(version); err != nil || n < 1 {
+		return "", errors.Errorf("invalid secret version %q - must be a positive integer", version)
+	}
+	return version, nil
+}
+
+// writeVault writes the body to the path, merged with any parameters from the
+// URL's query, and returns the response's data (if any)
+func writeVault(ctx context.Context, source *Source, body map[string]interface{}, args ...string) ([]byte, error) {
+	vc, err := vaultClient(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	params, p, err := parseDatasourceURLArgs(source.URL, args...)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range body {
+		params[k] = v
+	}
+
+	source.mediaType = jsonMimetype
+	data, err := vc.Write(p, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		// writes to some endpoints (like KV v1) have no response
+		data = []byte("{}")
+	}
+	return data, nil
+}
@@ -2,11 +2,15 @@ package data
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
 	"github.com/hairyhenderson/gomplate/v3/vault"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReadVault(t *testing.T) {
@@ -48,3 +52,118 @@ func TestReadVault(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []byte(expected), r)
 }
+
+func TestDatasourceWriteVault(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "foo")
+
+	var method, path string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if path == "/v1/secret/foo" {
+			// KV v1 writes have no response
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", jsonMimetype)
+		_, _ = w.Write([]byte(`{"data":{"certificate":"CERT","serial_number":"01:02"}}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	d := &Data{
+		Sources: map[string]*Source{
+			"v":   {Alias: "v", URL: &url.URL{Scheme: "vault+http", Host: u.Host, Path: "/"}},
+			"pki": {Alias: "pki", URL: &url.URL{Scheme: "vault+http", Host: u.Host, Path: "/pki/issue/web", RawQuery: "ttl=1h"}},
+		},
+	}
+
+	out, err := d.DatasourceWrite("v", "secret/foo", map[string]interface{}{"password": "s3cr3t", "port": 8080})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, out)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/v1/secret/foo", path)
+	assert.Equal(t, map[string]interface{}{"password": "s3cr3t", "port": 8080.0}, body)
+
+	// the URL's query params are included, but the data takes precedence
+	out, err = d.DatasourceWrite("pki", map[string]string{"common_name": "example.com", "ttl": "10m"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"certificate": "CERT", "serial_number": "01:02"}, out)
+	assert.Equal(t, "/v1/pki/issue/web", path)
+	assert.Equal(t, map[string]interface{}{"common_name": "example.com", "ttl": "10m"}, body)
+
+	_, err = d.DatasourceWrite("v")
+	assert.Error(t, err)
+	_, err = d.DatasourceWrite("v", "secret/foo", "not a map")
+	assert.EqualError(t, err, "data to write must be a map, not string")
+	_, err = d.DatasourceWrite("v", 42, map[string]string{})
+	assert.Error(t, err)
+
+	d.Sources["f"] = &Source{Alias: "f", URL: &url.URL{Scheme: "file", Path: "/tmp/foo"}}
+	_, err = d.DatasourceWrite("f", map[string]string{})
+	assert.EqualError(t, err, "datasources with scheme file can't be written to")
+
+	d.UseSnapshots(nil)
+	_, err = d.DatasourceWrite("v", "secret/foo", map[string]string{})
+	assert.ErrorContains(t, err, "while rendering from a snapshot")
+}
+
+func TestReadVaultVersion(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "foo")
+
+	var method, path, version string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		version = r.URL.Query().Get("version")
+		v := version
+		if v == "" {
+			v = "4"
+		}
+		w.Header().Set("Content-Type", jsonMimetype)
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"pw` + v + `"},` +
+			`"metadata":{"created_time":"2022-01-02T03:04:05Z","version":` + v + `}}}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	d := &Data{
+		Sources: map[string]*Source{
+			"v":      {Alias: "v", URL: &url.URL{Scheme: "vault+http", Host: u.Host, Path: "/"}},
+			"pinned": {Alias: "pinned", URL: &url.URL{Scheme: "vault+http", Host: u.Host, Path: "/secret/data/foo", RawQuery: "version=3"}},
+		},
+	}
+
+	out, err := d.Datasource("pinned")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, method)
+	assert.Equal(t, "/v1/secret/data/foo", path)
+	assert.Equal(t, "3", version)
+	assert.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{"password": "pw3"},
+		"metadata": map[string]interface{}{
+			"created_time": "2022-01-02T03:04:05Z",
+			"version":      3,
+		},
+	}, out)
+
+	_, err = d.Datasource("v", "secret/data/foo?version=2")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, method)
+	assert.Equal(t, "2", version)
+
+	// without a version, the latest is read
+	_, err = d.Datasource("v", "secret/data/foo")
+	require.NoError(t, err)
+	assert.Equal(t, "", version)
+
+	_, err = d.Datasource("v", "secret/data/foo?version=latest")
+	assert.ErrorContains(t, err, `invalid secret version "latest"`)
+	_, err = d.Datasource("v", "secret/data/foo?version=0")
+	assert.Error(t, err)
+	_, err = d.Datasource("v", "secret/data/?version=2")
+	assert.ErrorContains(t, err, "version can only be given")
+	_, err = d.Datasource("v", "secret/data/foo?version=2&ttl=1h")
+	assert.ErrorContains(t, err, "version can only be given")
+}
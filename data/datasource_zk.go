@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/zkclient"
+	"github.com/pkg/errors"
+)
+
+// readZooKeeper reads the data in a ZooKeeper node. When the path ends with
+// '/', the node's children are listed instead - with their data, as an
+// object, when the 'data' query parameter is set.
+func readZooKeeper(ctx context.Context, source *Source, args ...string) ([]byte, error) {
+	p := source.URL.Path
+	if len(args) == 1 {
+		p = strings.TrimSuffix(p, "/") + "/" + strings.TrimPrefix(args[0], "/")
+	}
+	if p == "" {
+		return nil, errors.Errorf("a node path must be given in %s", source.URL)
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	if source.zk == nil {
+		conn, err := zkclient.Connect(ctx, source.URL)
+		if err != nil {
+			return nil, err
+		}
+		source.zk = conn
+	}
+
+	if !strings.HasSuffix(p, "/") {
+		return zkclient.Get(source.zk, p)
+	}
+
+	// directory semantics
+	node := path.Clean(p)
+	if source.URL.Query().Has("data") {
+		children, err := zkclient.ChildrenData(source.zk, node)
+		if err != nil {
+			return nil, err
+		}
+		source.mediaType = jsonMimetype
+		return json.Marshal(children)
+	}
+
+	children, err := zkclient.Children(source.zk, node)
+	if err != nil {
+		return nil, err
+	}
+	source.mediaType = jsonArrayMimetype
+	return json.Marshal(children)
+}
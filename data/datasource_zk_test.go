@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeZK map[string]string
+
+func (f fakeZK) Get(p string) ([]byte, *zk.Stat, error) {
+	v, ok := f[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return []byte(v), &zk.Stat{}, nil
+}
+
+func (f fakeZK) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	_, ok := f[p]
+	return ok, &zk.Stat{}, nil, nil
+}
+
+func (f fakeZK) Children(p string) ([]string, *zk.Stat, error) {
+	if _, ok := f[p]; !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	children := []string{}
+	for k := range f {
+		if k != p && path.Dir(k) == p {
+			children = append(children, path.Base(k))
+		}
+	}
+	return children, &zk.Stat{}, nil
+}
+
+func (f fakeZK) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	children, stat, err := f.Children(p)
+	return children, stat, nil, err
+}
+
+func (f fakeZK) Close() {}
+
+func TestReadZooKeeper(t *testing.T) {
+	ctx := context.Background()
+	conn := fakeZK{
+		"/app":        "",
+		"/app/config": `{"replicas": 3}`,
+		"/app/db":     "postgres",
+	}
+
+	source := &Source{Alias: "foo", URL: mustParseURL("zk:///app/db"), zk: conn}
+	b, err := readZooKeeper(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", string(b))
+
+	source = &Source{Alias: "foo", URL: mustParseURL("zk:///app"), zk: conn}
+	b, err = readZooKeeper(ctx, source, "config")
+	require.NoError(t, err)
+	assert.Equal(t, `{"replicas": 3}`, string(b))
+
+	_, err = readZooKeeper(ctx, source, "missing")
+	assert.Error(t, err)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("zk:///app/"), zk: conn}
+	b, err = readZooKeeper(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `["config","db"]`, string(b))
+	assert.Equal(t, jsonArrayMimetype, source.mediaType)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("zk:///app/?data"), zk: conn}
+	b, err = readZooKeeper(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, `{"config":"{\"replicas\": 3}","db":"postgres"}`, string(b))
+	assert.Equal(t, jsonMimetype, source.mediaType)
+
+	source = &Source{Alias: "foo", URL: mustParseURL("zk://localhost")}
+	_, err = readZooKeeper(ctx, source)
+	assert.Error(t, err)
+	assert.Nil(t, source.zk)
+}
@@ -0,0 +1,109 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Read - a record of a datasource read, with a digest of the data that was
+// read
+type Read struct {
+	Alias  string   `json:"alias"`
+	URL    string   `json:"url"`
+	Args   []string `json:"args,omitempty"`
+	Digest string   `json:"digest"`
+
+	// Duration - the total time spent reading (including cached reads)
+	Duration time.Duration `json:"-"`
+}
+
+// RecordReads - record every datasource read until the returned function is
+// called, which returns the reads (in order, without duplicates). Only one
+// recording can be in progress at a time.
+func (d *Data) RecordReads() func() []Read {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reads = []Read{}
+
+	return func() []Read {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		reads := d.reads
+		d.reads = nil
+		return reads
+	}
+}
+
+// recordRead - must be called with d.mu held
+func (d *Data) recordRead(source *Source, args []string, b []byte, dur time.Duration) {
+	d.recordDigest(source, args, digest(b), dur)
+}
+
+// recordDigest - like recordRead, for when the digest is already computed.
+// Must be called with d.mu held.
+func (d *Data) recordDigest(source *Source, args []string, dgst string, dur time.Duration) {
+	if d.reads == nil {
+		return
+	}
+	r := Read{
+		Alias:    source.Alias,
+		URL:      source.URL.String(),
+		Args:     args,
+		Digest:   dgst,
+		Duration: dur,
+	}
+	for i, e := range d.reads {
+		if e.Alias == r.Alias && e.Digest == r.Digest && equalArgs(e.Args, r.Args) {
+			d.reads[i].Duration += dur
+			return
+		}
+	}
+	d.reads = append(d.reads, r)
+}
+
+// Unchanged - reports whether the datasource would still read the same data.
+// Datasources that aren't defined yet (because they were defined by a
+// template) are defined with the recorded URL. Any error reading the
+// datasource counts as a change.
+func (d *Data) Unchanged(r Read) bool {
+	if !d.DatasourceExists(r.Alias) {
+		if _, err := d.DefineDatasource(r.Alias, r.URL); err != nil {
+			return false
+		}
+	}
+
+	d.mu.Lock()
+	source, err := d.lookupSource(r.Alias)
+	d.mu.Unlock()
+	if err != nil {
+		return false
+	}
+	unlock, err := d.lockSource(d.Ctx, source)
+	if err != nil {
+		return false
+	}
+	defer unlock()
+	b, err := d.readSource(d.Ctx, source, r.Args...)
+	if err != nil {
+		return false
+	}
+	return digest(b) == r.Digest
+}
+
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReads(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/foo.json", []byte(`{"a": 1}`), 0600)
+
+	d := &Data{
+		Sources: map[string]*Source{
+			"foo": {
+				Alias:     "foo",
+				URL:       &url.URL{Scheme: "file", Path: "/foo.json"},
+				mediaType: jsonMimetype,
+				fs:        fs,
+			},
+		},
+	}
+
+	// reads aren't recorded unless a recording is in progress
+	_, err := d.Datasource("foo")
+	require.NoError(t, err)
+
+	stop := d.RecordReads()
+	_, err = d.Datasource("foo")
+	require.NoError(t, err)
+	_, err = d.Include("foo")
+	require.NoError(t, err)
+	reads := stop()
+
+	require.Len(t, reads, 1)
+	assert.Equal(t, "foo", reads[0].Alias)
+	assert.Equal(t, "file:///foo.json", reads[0].URL)
+	assert.Equal(t, "sha256:f9d86028c6e0d64e225186f96acb69338b2c59764df79162107f5c4bb34d1310", reads[0].Digest)
+
+	assert.True(t, d.Unchanged(reads[0]))
+
+	// the cache is bypassed by a new Data
+	_ = afero.WriteFile(fs, "/foo.json", []byte(`{"a": 2}`), 0600)
+	d2 := &Data{Sources: d.Sources}
+	assert.False(t, d2.Unchanged(reads[0]))
+
+	assert.False(t, d2.Unchanged(Read{Alias: "bogus", URL: "bogus:///"}))
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sourceTTL - the source's 'ttl' query parameter, which sets how long its data
+// is kept in the persistent cache (see CacheDir). The parameter is removed
+// from the URL, so that it isn't passed on when the source is read.
+func sourceTTL(source *Source) (time.Duration, error) {
+	if source.URL == nil {
+		return source.ttl, nil
+	}
+	q := source.URL.Query()
+	v, ok := q["ttl"]
+	if !ok {
+		return source.ttl, nil
+	}
+	ttl, err := time.ParseDuration(v[0])
+	if err != nil || ttl < 0 {
+		return 0, errors.Errorf("invalid ttl %q for datasource '%s' - must be a duration, like 5m", v[0], source.Alias)
+	}
+	q.Del("ttl")
+	u := *source.URL
+	u.RawQuery = q.Encode()
+	source.URL = &u
+	source.ttl = ttl
+	return ttl, nil
+}
+
+// diskCacheKey - the name of the cache file for the source's data, as read
+// with the given args. The pinned integrity and the headers are included, so
+// that changing them doesn't use data cached before the change.
+func diskCacheKey(source *Source, args []string) string {
+	h := sha256.New()
+	h.Write([]byte(source.URL.String()))
+	for _, a := range args {
+		h.Write([]byte{0})
+		h.Write([]byte(a))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(source.Integrity))
+	names := make([]string, 0, len(source.Header))
+	for name := range source.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range source.Header[name] {
+			h.Write([]byte{0})
+			h.Write([]byte(name + ": " + v))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readDiskCache - the cached data and media type, if the cache file exists
+// and was written less than ttl ago. The first line of the file is the media
+// type, and the rest is the data.
+func readDiskCache(dir, key string, ttl time.Duration) ([]byte, string, bool) {
+	path := filepath.Join(dir, key)
+	fi, err := os.Stat(path)
+	if err != nil || time.Since(fi.ModTime()) >= ttl {
+		return nil, "", false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", false
+	}
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return nil, "", false
+	}
+	return b[i+1:], string(b[:i]), true
+}
+
+// writeDiskCache - write the data to the cache file. It's written to a
+// temporary file first, so concurrent readers never see partial data.
+func writeDiskCache(dir, key, mediaType string, data []byte) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, key+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append([]byte(mediaType+"\n"), data...))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, key))
+}
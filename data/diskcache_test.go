@@ -0,0 +1,163 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/integrity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCache(t *testing.T) {
+	mu := sync.Mutex{}
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		// no extension, so the type comes from the response
+		w.Header().Set("Content-Type", jsonMimetype)
+		w.Write([]byte(`{"path": "` + r.URL.Path + `", "query": "` + r.URL.RawQuery + `"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	newData := func() *Data {
+		sources := map[string]*Source{}
+		for alias, s := range map[string]string{
+			"cached":   srv.URL + "/cached?ttl=1h&x=1",
+			"uncached": srv.URL + "/uncached",
+		} {
+			u, err := url.Parse(s)
+			require.NoError(t, err)
+			sources[alias] = &Source{Alias: alias, URL: u}
+		}
+		return &Data{Ctx: context.Background(), Sources: sources, CacheDir: dir}
+	}
+	count := func(p string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[p]
+	}
+
+	// each Data is like a separate run
+	for i := 0; i < 2; i++ {
+		d := newData()
+		v, err := d.Datasource("cached")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"path": "/cached", "query": "x=1"}, v)
+
+		_, err = d.Datasource("uncached")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, count("/cached"))
+	assert.Equal(t, 2, count("/uncached"))
+
+	// once the cached data is older than the TTL, it's read again
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(files[0], old, old))
+
+	_, err = newData().Datasource("cached")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count("/cached"))
+
+	// without a cache dir, the TTL is ignored
+	d := newData()
+	d.CacheDir = ""
+	_, err = d.Datasource("cached")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count("/cached"))
+
+	u, _ := url.Parse(srv.URL + "/bad?ttl=soon")
+	d = &Data{Sources: map[string]*Source{"bad": {Alias: "bad", URL: u}}, CacheDir: dir}
+	_, err = d.Datasource("bad")
+	assert.ErrorContains(t, err, `invalid ttl "soon"`)
+}
+
+func TestDiskCacheVerified(t *testing.T) {
+	mu := sync.Mutex{}
+	body, requests := "v1", 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		w.Header().Set("Content-Type", textMimetype)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	read := func(pin string) (interface{}, error) {
+		u, err := url.Parse(srv.URL + "/data.txt?ttl=1h")
+		require.NoError(t, err)
+		d := &Data{
+			Ctx:      context.Background(),
+			Sources:  map[string]*Source{"data": {Alias: "data", URL: u, Integrity: pin}},
+			CacheDir: dir,
+		}
+		return d.Datasource("data")
+	}
+
+	v, err := read(integrity.Of([]byte("v1")))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", v)
+	_, err = read(integrity.Of([]byte("v1")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// cached data that was changed is checked against the pin, and read again
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.NoError(t, os.WriteFile(files[0], []byte(textMimetype+"\ntampered"), 0o600))
+	v, err = read(integrity.Of([]byte("v1")))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", v)
+	assert.Equal(t, 2, requests)
+
+	// bumping the pin doesn't use the data cached before
+	mu.Lock()
+	body = "v2"
+	mu.Unlock()
+	v, err = read(integrity.Of([]byte("v2")))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", v)
+	assert.Equal(t, 3, requests)
+}
+
+func TestSourceTTL(t *testing.T) {
+	u, _ := url.Parse("vault:///secret/foo?ttl=5m&version=2")
+	s := &Source{Alias: "foo", URL: u}
+	ttl, err := sourceTTL(s)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, ttl)
+	assert.Equal(t, "vault:///secret/foo?version=2", s.URL.String())
+	// the original URL isn't modified
+	assert.Equal(t, "vault:///secret/foo?ttl=5m&version=2", u.String())
+
+	// the TTL is remembered once it's removed from the URL
+	ttl, err = sourceTTL(s)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, ttl)
+
+	u, _ = url.Parse("file:///tmp/foo.json")
+	ttl, err = sourceTTL(&Source{URL: u})
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), ttl)
+
+	u, _ = url.Parse("file:///tmp/foo.json?ttl=-1s")
+	_, err = sourceTTL(&Source{URL: u})
+	assert.Error(t, err)
+}
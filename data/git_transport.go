@@ -0,0 +1,102 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"golang.org/x/crypto/ssh"
+)
+
+var installGitHTTPOnce sync.Once
+
+// installGitHTTP - replaces go-git's http(s) client with one that makes each
+// request with the shared transport in the request's context. go-git's
+// clients are global, but requests are made with the clone's context, so the
+// network policy (which also applies to redirects) and rate limits of the
+// render that's cloning are used.
+func installGitHTTP() {
+	installGitHTTPOnce.Do(func() {
+		c := githttp.NewClient(&http.Client{Transport: gitRoundTripper{}})
+		client.InstallProtocol("http", c)
+		client.InstallProtocol("https", c)
+	})
+}
+
+type gitRoundTripper struct{}
+
+func (gitRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return transportFromContext(r.Context(), "git+"+r.URL.Scheme).RoundTrip(r)
+}
+
+// policyAuth - for git+ssh repos, checks the address that go-git will
+// connect to against the network policy in the context. go-git's ssh client
+// can't be given a dialer, so the auth is also wrapped, to check the address
+// that was actually dialed before the handshake.
+func (g gitsource) policyAuth(ctx context.Context, u *url.URL, auth transport.AuthMethod) (transport.AuthMethod, error) {
+	p := netpolicy.FromContext(ctx)
+	if p == nil || u.Scheme != "git+ssh" {
+		return auth, nil
+	}
+	if err := p.CheckHost(u.Scheme, sshAddr(u)); err != nil {
+		return nil, err
+	}
+	a, ok := auth.(gitssh.AuthMethod)
+	if !ok {
+		return nil, fmt.Errorf("unsupported auth method %T for %s", auth, u.Redacted())
+	}
+	return &policySSHAuth{AuthMethod: a, policy: p}, nil
+}
+
+// sshAddr - the address go-git connects to for the URL, which can be changed
+// by the Hostname and Port settings in ssh_config
+func sshAddr(u *url.URL) string {
+	host, port := u.Hostname(), u.Port()
+	if gitssh.DefaultSSHConfig != nil {
+		if h := gitssh.DefaultSSHConfig.Get(u.Hostname(), "Hostname"); h != "" {
+			host = h
+			if p := gitssh.DefaultSSHConfig.Get(u.Hostname(), "Port"); p != "" {
+				port = p
+			}
+		}
+	}
+	if port == "" {
+		port = strconv.Itoa(gitssh.DefaultPort)
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// policySSHAuth - an ssh auth method that refuses hosts the network policy
+// doesn't allow
+type policySSHAuth struct {
+	gitssh.AuthMethod
+	policy *netpolicy.Policy
+}
+
+func (a *policySSHAuth) ClientConfig() (*ssh.ClientConfig, error) {
+	c, err := a.AuthMethod.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback := c.HostKeyCallback
+	if hostKeyCallback == nil {
+		// the ssh client refuses to connect without one
+		return c, nil
+	}
+	c.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := a.policy.CheckHost("git+ssh", hostname); err != nil {
+			return err
+		}
+		return hostKeyCallback(hostname, remote, key)
+	}
+	return c, nil
+}
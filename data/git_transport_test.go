@@ -0,0 +1,97 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"golang.org/x/crypto/ssh"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGitHTTPNetworkPolicy(t *testing.T) {
+	hits := 0
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer other.Close()
+
+	// redirect to the same server, but by a name the policy doesn't allow
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, otherURL+r.URL.RequestURI(), http.StatusFound)
+	}))
+	defer srv.Close()
+
+	d := &Data{NetworkPolicy: netpolicy.New(&config.NetworkPolicy{Allow: []config.NetworkRule{
+		{Schemes: []string{"git+http"}, Hosts: []string{"127.0.0.1"}},
+	}})}
+	ctx := d.sourceContext(context.Background())
+
+	u := mustParseURL("git+" + srv.URL + "/repo.git#main")
+	_, _, err := gitsource{}.clone(ctx, u, 1)
+	assert.ErrorContains(t, err, "network policy doesn't allow git+http connections to localhost:")
+	assert.Equal(t, 0, hits)
+
+	// the policy applies to the repo's host too, not just to redirects
+	d = &Data{NetworkPolicy: netpolicy.New(&config.NetworkPolicy{Allow: []config.NetworkRule{
+		{Schemes: []string{"git+http"}, Hosts: []string{"example.com"}},
+	}})}
+	_, _, err = gitsource{}.clone(d.sourceContext(context.Background()), u, 1)
+	assert.ErrorContains(t, err, "network policy doesn't allow git+http connections to 127.0.0.1:")
+}
+
+type fakeSSHConfig map[string]string
+
+func (c fakeSSHConfig) Get(alias, key string) string {
+	return c[alias+"/"+key]
+}
+
+func TestGitSSHNetworkPolicy(t *testing.T) {
+	p := netpolicy.New(&config.NetworkPolicy{Allow: []config.NetworkRule{
+		{Schemes: []string{"git+ssh"}, Hosts: []string{"github.com"}},
+	}})
+	ctx := netpolicy.ContextWithPolicy(context.Background(), p)
+	auth := &gitssh.Password{
+		User: "git",
+		HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		},
+	}
+
+	orig := gitssh.DefaultSSHConfig
+	defer func() { gitssh.DefaultSSHConfig = orig }()
+	gitssh.DefaultSSHConfig = fakeSSHConfig{
+		"gh/Hostname": "example.com",
+		"gh/Port":     "2222",
+	}
+
+	_, err := gitsource{}.policyAuth(ctx, mustParseURL("git+ssh://git@example.com/foo.git"), auth)
+	assert.ErrorContains(t, err, "network policy doesn't allow git+ssh connections to example.com:22")
+
+	// ssh_config can point an allowed-looking name at another host
+	_, err = gitsource{}.policyAuth(ctx, mustParseURL("git+ssh://git@gh/foo.git"), auth)
+	assert.ErrorContains(t, err, "network policy doesn't allow git+ssh connections to example.com:2222")
+
+	a, err := gitsource{}.policyAuth(ctx, mustParseURL("git+ssh://git@github.com/foo.git"), auth)
+	assert.NilError(t, err)
+
+	c, err := a.(gitssh.AuthMethod).ClientConfig()
+	assert.NilError(t, err)
+	assert.NilError(t, c.HostKeyCallback("github.com:22", nil, nil))
+	// the address that's actually dialed is checked before the handshake
+	err = c.HostKeyCallback("example.com:22", nil, nil)
+	assert.ErrorContains(t, err, "network policy doesn't allow git+ssh connections to example.com:22")
+
+	// without a policy, the auth isn't changed
+	a, err = gitsource{}.policyAuth(context.Background(), mustParseURL("git+ssh://git@example.com/foo.git"), auth)
+	assert.NilError(t, err)
+	assert.Equal(t, auth, a)
+}
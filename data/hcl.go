@@ -0,0 +1,144 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// HCL - Unmarshal an HCL (or Terraform) document into a map. Attributes
+// become keys, and blocks become nested maps, keyed by their type and then
+// by each of their labels - so `resource "a" "b" { ... }` is found at
+// .resource.a.b. Repeated blocks become arrays.
+//
+// Expressions that can't be evaluated on their own (like references to
+// variables, or function calls) are left as strings in Terraform's JSON
+// syntax, like "${var.region}".
+func HCL(in string) (map[string]interface{}, error) {
+	src := []byte(in)
+	f, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("unable to unmarshal HCL: %w", diags)
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("unable to unmarshal HCL: unexpected body type %T", f.Body)
+	}
+	return hclBody(src, body), nil
+}
+
+func hclBody(src []byte, body *hclsyntax.Body) map[string]interface{} {
+	m := map[string]interface{}{}
+	for name, attr := range body.Attributes {
+		m[name] = hclExpr(src, attr.Expr)
+	}
+
+	for _, block := range body.Blocks {
+		// walk (and create) the maps for the block's type and labels, so
+		// the block's body is set in the map for its last label
+		parent, key := m, block.Type
+		for _, label := range block.Labels {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent, key = child, label
+		}
+
+		v := hclBody(src, block.Body)
+		switch existing := parent[key].(type) {
+		case nil:
+			parent[key] = v
+		case []interface{}:
+			parent[key] = append(existing, v)
+		default:
+			parent[key] = []interface{}{existing, v}
+		}
+	}
+	return m
+}
+
+// hclExpr evaluates the expression without any variables or functions. Parts
+// that can't be evaluated are left as strings of their source.
+func hclExpr(src []byte, expr hclsyntax.Expression) interface{} {
+	v, diags := expr.Value(nil)
+	if !diags.HasErrors() && v.IsWhollyKnown() {
+		return ctyValue(v)
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		out := make([]interface{}, len(e.Exprs))
+		for i, item := range e.Exprs {
+			out[i] = hclExpr(src, item)
+		}
+		return out
+	case *hclsyntax.ObjectConsExpr:
+		out := make(map[string]interface{}, len(e.Items))
+		for _, item := range e.Items {
+			key := hclSource(src, item.KeyExpr)
+			k, diags := item.KeyExpr.Value(nil)
+			if !diags.HasErrors() && k.Type() == cty.String && k.IsKnown() && !k.IsNull() {
+				key = k.AsString()
+			}
+			out[key] = hclExpr(src, item.ValueExpr)
+		}
+		return out
+	case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+		// already in the "${...}" form, inside quotes
+		s := hclSource(src, e)
+		if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+			return s[1 : len(s)-1]
+		}
+		return s
+	}
+	return "${" + hclSource(src, expr) + "}"
+}
+
+func hclSource(src []byte, expr hclsyntax.Expression) string {
+	r := expr.Range()
+	return string(r.SliceBytes(src))
+}
+
+// ctyValue converts a (known) cty value to a plain Go value
+func ctyValue(v cty.Value) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString()
+	case t == cty.Bool:
+		return v.True()
+	case t == cty.Number:
+		f := v.AsBigFloat()
+		if f.IsInt() {
+			if i, acc := f.Int64(); acc == 0 {
+				return i
+			}
+		}
+		n, _ := f.Float64()
+		return n
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		out := []interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			out = append(out, ctyValue(ev))
+		}
+		return out
+	case t.IsMapType() || t.IsObjectType():
+		out := map[string]interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			out[k.AsString()] = ctyValue(ev)
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHCL(t *testing.T) {
+	in := `# a comment
+name    = "web"
+port    = 8080
+ratio   = 0.5
+enabled = true
+nothing = null
+tags    = ["a", "b"]
+labels  = { tier = "front", "app.io/name" = "web" }
+
+region   = var.region
+greeting = "hello, ${var.name}!"
+mixed    = ["x", local.y, upper("z")]
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+
+  ingress {
+    port = 80
+  }
+  ingress {
+    port = 443
+  }
+}
+
+resource "aws_instance" "db" {
+  ami = "ami-456"
+}
+
+module "vpc" {
+  source = "./vpc"
+}
+`
+	expected := map[string]interface{}{
+		"name":     "web",
+		"port":     int64(8080),
+		"ratio":    0.5,
+		"enabled":  true,
+		"nothing":  nil,
+		"tags":     []interface{}{"a", "b"},
+		"labels":   map[string]interface{}{"tier": "front", "app.io/name": "web"},
+		"region":   "${var.region}",
+		"greeting": "hello, ${var.name}!",
+		"mixed":    []interface{}{"x", "${local.y}", `${upper("z")}`},
+		"resource": map[string]interface{}{
+			"aws_instance": map[string]interface{}{
+				"web": map[string]interface{}{
+					"ami": "ami-123",
+					"ingress": []interface{}{
+						map[string]interface{}{"port": int64(80)},
+						map[string]interface{}{"port": int64(443)},
+					},
+				},
+				"db": map[string]interface{}{"ami": "ami-456"},
+			},
+		},
+		"module": map[string]interface{}{
+			"vpc": map[string]interface{}{"source": "./vpc"},
+		},
+	}
+
+	actual, err := HCL(in)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	actual, err = HCL("")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, actual)
+
+	_, err = HCL("foo = ")
+	assert.ErrorContains(t, err, "unable to unmarshal HCL")
+	_, err = HCL(`foo "bar" {`)
+	assert.ErrorContains(t, err, "unable to unmarshal HCL")
+}
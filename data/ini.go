@@ -0,0 +1,130 @@
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+)
+
+// INI - Unmarshal an INI file into a map. Keys before the first section are
+// top-level keys, and each section becomes a map of its keys. Lines starting
+// with ';' or '#' are comments, and values are always strings (surrounding
+// quotes are removed).
+func INI(in string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	section := out
+
+	s := bufio.NewScanner(strings.NewReader(in))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, ";"), strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("unable to unmarshal INI: line %d: unterminated section header", n)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("unable to unmarshal INI: line %d: empty section name", n)
+			}
+			m, ok := out[name].(map[string]interface{})
+			if !ok {
+				m = map[string]interface{}{}
+				out[name] = m
+			}
+			section = m
+		default:
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("unable to unmarshal INI: line %d: expected key = value", n)
+			}
+			k := strings.TrimSpace(parts[0])
+			if k == "" {
+				return nil, fmt.Errorf("unable to unmarshal INI: line %d: empty key", n)
+			}
+			section[k] = iniUnquote(strings.TrimSpace(parts[1]))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal INI: %w", err)
+	}
+	return out, nil
+}
+
+func iniUnquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// ToINI - marshal a map as an INI file. Values that are maps become sections,
+// and other values become top-level keys. Sections can't contain maps or
+// arrays.
+func ToINI(in interface{}) (string, error) {
+	m, ok := in.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unable to marshal INI: input must be a map, got %T", in)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := &strings.Builder{}
+	sections := []string{}
+	for _, k := range keys {
+		if _, ok := m[k].(map[string]interface{}); ok {
+			sections = append(sections, k)
+			continue
+		}
+		if err := writeINIKey(b, "", k, m[k]); err != nil {
+			return "", err
+		}
+	}
+
+	for i, name := range sections {
+		if i > 0 || b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "[%s]\n", name)
+
+		section := m[name].(map[string]interface{})
+		skeys := make([]string, 0, len(section))
+		for k := range section {
+			skeys = append(skeys, k)
+		}
+		sort.Strings(skeys)
+		for _, k := range skeys {
+			if err := writeINIKey(b, name, k, section[k]); err != nil {
+				return "", err
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func writeINIKey(b *strings.Builder, section, k string, v interface{}) error {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if section != "" {
+			k = section + "." + k
+		}
+		return fmt.Errorf("unable to marshal INI: %s: nested maps and arrays can't be represented in INI", k)
+	}
+	s := ""
+	if v != nil {
+		s = conv.ToString(v)
+	}
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, ";#") {
+		s = `"` + s + `"`
+	}
+	fmt.Fprintf(b, "%s = %s\n", k, s)
+	return nil
+}
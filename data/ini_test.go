@@ -0,0 +1,73 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestINI(t *testing.T) {
+	in := `; a comment
+name = web
+# another comment
+empty =
+
+[db]
+host = db.local
+password = "  secret "
+
+[db]
+port=5432
+`
+	expected := map[string]interface{}{
+		"name":  "web",
+		"empty": "",
+		"db": map[string]interface{}{
+			"host":     "db.local",
+			"password": "  secret ",
+			"port":     "5432",
+		},
+	}
+	actual, err := INI(in)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	for _, bad := range []string{"[db", "[]", "no value", " = x"} {
+		_, err = INI(bad)
+		assert.ErrorContains(t, err, "line 1", bad)
+	}
+}
+
+func TestToINI(t *testing.T) {
+	out, err := ToINI(map[string]interface{}{
+		"name": "web",
+		"port": 80,
+		"none": nil,
+		"db":   map[string]interface{}{"host": "db.local", "password": "a;b", "pad": " x"},
+		"app":  map[string]interface{}{"debug": true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `name = web
+none = 
+port = 80
+
+[app]
+debug = true
+
+[db]
+host = db.local
+pad = " x"
+password = "a;b"
+`, out)
+
+	// round trip
+	v, err := INI(out)
+	require.NoError(t, err)
+	assert.Equal(t, " x", v["db"].(map[string]interface{})["pad"])
+
+	_, err = ToINI([]interface{}{"a"})
+	assert.ErrorContains(t, err, "input must be a map")
+	_, err = ToINI(map[string]interface{}{"db": map[string]interface{}{"hosts": []interface{}{"a"}}})
+	assert.ErrorContains(t, err, "db.hosts: nested maps and arrays")
+}
@@ -5,16 +5,26 @@ const (
 	csvMimetype       = "text/csv"
 	jsonMimetype      = "application/json"
 	jsonArrayMimetype = "application/array+json"
+	ndjsonMimetype    = "application/x-ndjson"
 	tomlMimetype      = "application/toml"
 	yamlMimetype      = "application/yaml"
 	envMimetype       = "application/x-env"
+	rssMimetype       = "application/rss+xml"
+	atomMimetype      = "application/atom+xml"
+	xmlMimetype       = "application/xml"
+	hclMimetype       = "application/hcl"
+	iniMimetype       = "text/x-ini"
+	msgpackMimetype   = "application/msgpack"
 )
 
 // mimeTypeAliases defines a mapping for non-canonical mime types that are
 // sometimes seen in the wild
 var mimeTypeAliases = map[string]string{
-	"application/x-yaml": yamlMimetype,
-	"application/text":   textMimetype,
+	"application/x-yaml":    yamlMimetype,
+	"application/text":      textMimetype,
+	"application/jsonl":     ndjsonMimetype,
+	"text/xml":              xmlMimetype,
+	"application/x-msgpack": msgpackMimetype,
 }
 
 func mimeAlias(m string) string {
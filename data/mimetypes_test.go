@@ -14,6 +14,7 @@ func TestMimeAlias(t *testing.T) {
 		{csvMimetype, csvMimetype},
 		{yamlMimetype, yamlMimetype},
 		{"application/x-yaml", yamlMimetype},
+		{"text/xml", xmlMimetype},
 	}
 
 	for _, d := range data {
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+func msgpackHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.Canonical = true
+	// encode strings as the str type, rather than as raw bytes
+	h.WriteExt = true
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}
+
+// MsgPack - Unmarshal a MessagePack-encoded value
+func MsgPack(in string) (interface{}, error) {
+	var out interface{}
+	err := codec.NewDecoderBytes([]byte(in), msgpackHandle()).Decode(&out)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal MessagePack: %w", err)
+	}
+	if v, replaced := stringifyMapKeys(out); replaced {
+		out = v
+	}
+	return out, nil
+}
+
+// ToMsgPack - marshal a value as MessagePack. The result is binary.
+func ToMsgPack(in interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	err := codec.NewEncoder(buf, msgpackHandle()).Encode(in)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal MessagePack: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,31 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgPack(t *testing.T) {
+	in := map[string]interface{}{
+		"name": "web",
+		"port": int64(80),
+		"tags": []interface{}{"a", true, nil},
+		"db":   map[string]interface{}{"ratio": 0.5},
+	}
+	b, err := ToMsgPack(in)
+	require.NoError(t, err)
+
+	out, err := MsgPack(b)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+
+	// fixmap with one key, "a" => 1
+	out, err = MsgPack("\x81\xa1a\x01")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": int64(1)}, out)
+
+	_, err = MsgPack("\x81")
+	assert.ErrorContains(t, err, "unable to unmarshal MessagePack")
+}
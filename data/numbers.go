@@ -0,0 +1,149 @@
+package data
+
+import (
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hairyhenderson/yaml"
+	"github.com/pkg/errors"
+)
+
+// decimalNumber matches numbers written in plain decimal or exponent form -
+// hex, octal, and special values like .inf are never preserved
+var decimalNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)(?:[eE]([-+]?\d+))?$`)
+
+// yamlUnmarshal is like yaml.Unmarshal, but numbers that can't be decoded
+// exactly are decoded as json.Number. Only the first document is decoded.
+func yamlUnmarshal(b []byte, out interface{}) error {
+	n := &yaml.Node{}
+	if err := yaml.Unmarshal(b, n); err != nil {
+		return err
+	}
+	if n.Kind == 0 {
+		// empty input
+		return nil
+	}
+	if err := n.Decode(out); err != nil {
+		return err
+	}
+	switch o := out.(type) {
+	case *map[string]interface{}:
+		preserveNumbers(n, *o)
+	case *[]interface{}:
+		preserveNumbers(n, *o)
+	}
+	return nil
+}
+
+// preserveNumbers walks the decoded value alongside the node it was decoded
+// from, and replaces numbers that weren't decoded exactly (integers too large
+// for 64 bits, and decimals with more precision than a float64 has) with
+// json.Numbers holding their original text. Maps and slices are modified in
+// place, and the (possibly replaced) value is returned.
+func preserveNumbers(n *yaml.Node, v interface{}) interface{} {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) > 0 {
+			return preserveNumbers(n.Content[0], v)
+		}
+	case yaml.AliasNode:
+		return preserveNumbers(n.Alias, v)
+	case yaml.MappingNode:
+		if m, ok := v.(map[string]interface{}); ok {
+			preserveMappingNumbers(n, m, map[string]bool{})
+		}
+	case yaml.SequenceNode:
+		if l, ok := v.([]interface{}); ok && len(l) == len(n.Content) {
+			for i, e := range n.Content {
+				l[i] = preserveNumbers(e, l[i])
+			}
+		}
+	case yaml.ScalarNode:
+		if s, ok := inexactNumber(n, v); ok {
+			return json.Number(s)
+		}
+	}
+	return v
+}
+
+// preserveMappingNumbers walks the mapping's values. Values that were merged
+// in (with <<) are only walked when they weren't overridden.
+func preserveMappingNumbers(n *yaml.Node, m map[string]interface{}, done map[string]bool) {
+	merges := []*yaml.Node{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if k.Kind != yaml.ScalarNode {
+			continue
+		}
+		if k.Tag == "!!merge" {
+			merges = append(merges, mergedMappings(val)...)
+			continue
+		}
+		if done[k.Value] {
+			continue
+		}
+		done[k.Value] = true
+		if child, ok := m[k.Value]; ok {
+			m[k.Value] = preserveNumbers(val, child)
+		}
+	}
+	for _, merged := range merges {
+		preserveMappingNumbers(merged, m, done)
+	}
+}
+
+// inexactNumber returns the text of a number whose decoded value doesn't
+// exactly match it. Integers are only inexact when they were too large to
+// decode as an int64 or uint64, and decimals when the float64 is a different
+// number.
+func inexactNumber(n *yaml.Node, v interface{}) (string, bool) {
+	if n.Tag != "!!int" && n.Tag != "!!float" {
+		return "", false
+	}
+	match := decimalNumber.FindStringSubmatch(n.Value)
+	if match == nil {
+		return "", false
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return "", false
+	}
+	s := strings.TrimPrefix(n.Value, "+")
+
+	// avoid computing huge powers of 10 - exponents this large can't be
+	// represented exactly anyway
+	if exp, _ := strconv.Atoi(match[2]); exp > 1000 || exp < -1000 {
+		return s, true
+	}
+
+	lit, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", false
+	}
+	dec, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok || lit.Cmp(dec) != 0 {
+		return s, true
+	}
+	return "", false
+}
+
+// number marshals a json.Number as a number, rather than as a string
+type number json.Number
+
+func (n number) MarshalJSON() ([]byte, error) {
+	return []byte(n), nil
+}
+
+// UnmarshalJSON - not used, but without it codec ignores MarshalJSON
+func (n *number) UnmarshalJSON([]byte) error {
+	return errors.New("number can't be unmarshalled")
+}
+
+// MarshalYAML - the node is untagged, otherwise integers too large to
+// resolve as !!int would be output with an explicit tag
+func (n number) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: string(n)}, nil
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreserveNumbers(t *testing.T) {
+	in := `{"id": 1234567890123456789, "big": 18446744073709551617, "neg": -9223372036854775809,
+"pi": 3.14159265358979323846264, "f": 1.5, "e": 1e3, "tiny": 1e-2000, "s": "18446744073709551617",
+"l": [18446744073709551617, 1]}`
+	expected := map[string]interface{}{
+		"id":   1234567890123456789,
+		"big":  json.Number("18446744073709551617"),
+		"neg":  json.Number("-9223372036854775809"),
+		"pi":   json.Number("3.14159265358979323846264"),
+		"f":    1.5,
+		"e":    1000.0,
+		"tiny": json.Number("1e-2000"),
+		"s":    "18446744073709551617",
+		"l":    []interface{}{json.Number("18446744073709551617"), 1},
+	}
+
+	out, err := JSON(in)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	out, err = YAML(in)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	arr, err := JSONArray(`[18446744073709551617, 0.1]`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{json.Number("18446744073709551617"), 0.1}, arr)
+
+	arr, err = YAMLArray("- 18446744073709551617\n- 0.1\n")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{json.Number("18446744073709551617"), 0.1}, arr)
+
+	// merged values are preserved, unless they're overridden
+	out, err = YAML(`base: &base
+  a: 18446744073709551617
+  b: 18446744073709551618
+top:
+  <<: *base
+  b: 2
+`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": json.Number("18446744073709551617"),
+		"b": 2,
+	}, out["top"])
+}
+
+func TestNumberRoundTrip(t *testing.T) {
+	in := `{"big":18446744073709551617,"id":1234567890123456789,"pi":3.14159265358979323846264}`
+	out, err := JSON(in)
+	require.NoError(t, err)
+
+	actual, err := ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, in, actual)
+
+	actual, err = ToYAML(out)
+	require.NoError(t, err)
+	assert.Equal(t, `big: 18446744073709551617
+id: 1234567890123456789
+pi: 3.14159265358979323846264
+`, actual)
+
+	// the input isn't modified
+	assert.Equal(t, json.Number("18446744073709551617"), out["big"])
+}
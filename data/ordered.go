@@ -0,0 +1,420 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/mapmeta"
+	"github.com/hairyhenderson/toml"
+	"github.com/hairyhenderson/yaml"
+	"github.com/pkg/errors"
+)
+
+// orderedKeys returns the map's keys in the recorded order, if there is one.
+// Keys that weren't recorded (because they were added later) follow in sorted
+// order, as they would without a recorded order.
+func orderedKeys(m map[string]interface{}) ([]string, mapmeta.Meta, bool) {
+	meta, ok := mapmeta.Get(m)
+	if !ok {
+		return nil, meta, false
+	}
+
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	for _, k := range meta.Keys {
+		if _, present := m[k]; present && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := []string{}
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...), meta, true
+}
+
+// orderedMap marshals a map with its keys in their recorded order. When the
+// YAML node the map was parsed from is known, it's used to preserve comments
+// and formatting.
+type orderedMap struct {
+	m    map[string]interface{}
+	keys []string
+	node *yaml.Node
+	doc  *yaml.Node
+}
+
+// marshalable prepares a value to be marshalled - maps with a recorded key
+// order are wrapped so that their keys are output in that order, and
+// json.Numbers are wrapped so that they're output as numbers. The input
+// isn't modified.
+func marshalable(in interface{}) interface{} {
+	switch t := in.(type) {
+	case map[string]interface{}:
+		if keys, meta, ok := orderedKeys(t); ok {
+			return orderedMap{m: t, keys: keys, node: meta.Node, doc: meta.Document}
+		}
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			out[k] = marshalable(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = marshalable(e)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = marshalable(e)
+		}
+		return out
+	case json.Number:
+		return number(t)
+	default:
+		return in
+	}
+}
+
+func (o orderedMap) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := toJSONBytes(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := toJSONBytes(marshalable(o.m[k]))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON is never used, but the codec package only uses MarshalJSON
+// for types that implement both.
+func (o *orderedMap) UnmarshalJSON([]byte) error {
+	return errors.New("orderedMap can't be unmarshalled")
+}
+
+func (o orderedMap) MarshalYAML() (interface{}, error) {
+	return o.yamlNode()
+}
+
+func (o orderedMap) yamlNode() (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if o.node != nil {
+		n.Style = o.node.Style
+		copyComments(n, o.node)
+	}
+	for _, k := range o.keys {
+		v := o.m[k]
+		kn, err := yamlNode(k)
+		if err != nil {
+			return nil, err
+		}
+		vn, err := yamlNode(marshalable(v))
+		if err != nil {
+			return nil, err
+		}
+		if okn, ovn := mappingPair(o.node, k); okn != nil {
+			kn = reuseNode(okn, k, kn)
+			vn = reuseNode(ovn, v, vn)
+		}
+		n.Content = append(n.Content, kn, vn)
+	}
+	return n, nil
+}
+
+// yamlDocument wraps a top-level map in a document node, if it was parsed
+// from a document with comments
+func yamlDocument(v interface{}) (interface{}, error) {
+	o, ok := v.(orderedMap)
+	if !ok || o.doc == nil {
+		return v, nil
+	}
+	n, err := o.yamlNode()
+	if err != nil {
+		return nil, err
+	}
+	return &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: o.doc.HeadComment,
+		FootComment: o.doc.FootComment,
+		Content:     []*yaml.Node{n},
+	}, nil
+}
+
+// yamlNode encodes the value as a node. Node.Encode re-parses its output,
+// which loses comments, so it's only used for values that don't contain
+// orderedMaps.
+func yamlNode(v interface{}) (*yaml.Node, error) {
+	switch t := v.(type) {
+	case orderedMap:
+		return t.yamlNode()
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return orderedMap{m: t, keys: keys}.yamlNode()
+	case []interface{}:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, e := range t {
+			en, err := yamlNode(e)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, en)
+		}
+		return n, nil
+	default:
+		n := &yaml.Node{}
+		err := n.Encode(v)
+		return n, err
+	}
+}
+
+// mappingPair finds the key and value nodes for the key in the mapping node
+func mappingPair(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if n == nil {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := n.Content[i]; k.Kind == yaml.ScalarNode && k.Value == key {
+			return k, n.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// reuseNode returns the original node for the value if the value hasn't
+// changed, so that its formatting is preserved. Otherwise, the comments from
+// the original node are copied to the newly-encoded node. Sequences are
+// compared element by element.
+func reuseNode(orig *yaml.Node, v interface{}, encoded *yaml.Node) *yaml.Node {
+	if orig.Kind == yaml.AliasNode && orig.Alias != nil {
+		orig = orig.Alias
+	}
+	switch {
+	case orig.Kind == yaml.ScalarNode && encoded.Kind == yaml.ScalarNode:
+		var ov interface{}
+		if err := orig.Decode(&ov); err == nil && reflect.DeepEqual(ov, v) {
+			c := *orig
+			return &c
+		}
+	case orig.Kind == yaml.SequenceNode && encoded.Kind == yaml.SequenceNode:
+		l, _ := v.([]interface{})
+		for i := range encoded.Content {
+			if i < len(orig.Content) && i < len(l) {
+				encoded.Content[i] = reuseNode(orig.Content[i], l[i], encoded.Content[i])
+			}
+		}
+		encoded.Style = orig.Style
+	}
+	copyComments(encoded, orig)
+	return encoded
+}
+
+func copyComments(dst, src *yaml.Node) {
+	if dst.HeadComment == "" {
+		dst.HeadComment = src.HeadComment
+	}
+	if dst.LineComment == "" {
+		dst.LineComment = src.LineComment
+	}
+	if dst.FootComment == "" {
+		dst.FootComment = src.FootComment
+	}
+}
+
+// recordOrder records the key order of the maps in out, which was parsed
+// from s, so that it can be preserved when the data is marshalled again. For
+// YAML, comments and formatting can also be preserved.
+func recordOrder(mimeType, s string, out interface{}, comments bool) error {
+	switch mimeAlias(mimeType) {
+	case jsonMimetype, jsonArrayMimetype:
+		// JSON is parsed as YAML, so it can be walked the same way, but its
+		// formatting isn't YAML's, so mustn't be preserved
+		return recordYAMLOrder(s, out, false)
+	case yamlMimetype:
+		return recordYAMLOrder(s, out, comments)
+	case tomlMimetype:
+		return recordTOMLOrder(s, out)
+	}
+	return nil
+}
+
+func recordYAMLOrder(s string, out interface{}, comments bool) error {
+	d := yaml.NewDecoder(strings.NewReader(s))
+	for {
+		n := &yaml.Node{}
+		err := d.Decode(n)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// find the first non-null document, as YAML and YAMLArray do
+		if len(n.Content) > 0 && n.Content[0].Tag != "!!null" {
+			w := &orderWalker{comments: comments}
+			w.walk(n, out)
+			if m, ok := out.(map[string]interface{}); ok && comments {
+				meta, _ := mapmeta.Get(m)
+				meta.Document = n
+				mapmeta.Record(m, meta)
+			}
+			return nil
+		}
+	}
+}
+
+// orderWalker walks a decoded value alongside the node it was decoded from,
+// recording the key order of each map
+type orderWalker struct {
+	// comments - also record the nodes, to preserve comments
+	comments bool
+}
+
+func (w *orderWalker) walk(n *yaml.Node, v interface{}) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) > 0 {
+			w.walk(n.Content[0], v)
+		}
+	case yaml.AliasNode:
+		w.walk(n.Alias, v)
+	case yaml.MappingNode:
+		if m, ok := v.(map[string]interface{}); ok {
+			meta := mapmeta.Meta{Keys: w.mappingKeys(n, m, nil)}
+			if w.comments {
+				meta.Node = n
+			}
+			mapmeta.Record(m, meta)
+		}
+	case yaml.SequenceNode:
+		if l, ok := v.([]interface{}); ok && len(l) == len(n.Content) {
+			for i, e := range n.Content {
+				w.walk(e, l[i])
+			}
+		}
+	}
+}
+
+// mappingKeys returns the mapping's keys in document order, walking the
+// corresponding values. Merged (<<) keys are placed where the merge is.
+func (w *orderWalker) mappingKeys(n *yaml.Node, m map[string]interface{}, keys []string) []string {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if k.Kind != yaml.ScalarNode {
+			continue
+		}
+		if k.Tag == "!!merge" {
+			for _, merged := range mergedMappings(val) {
+				keys = w.mappingKeys(merged, m, keys)
+			}
+			continue
+		}
+		keys = append(keys, k.Value)
+		if child, ok := m[k.Value]; ok {
+			w.walk(val, child)
+		}
+	}
+	return keys
+}
+
+func mergedMappings(n *yaml.Node) []*yaml.Node {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		return []*yaml.Node{n}
+	case yaml.SequenceNode:
+		out := []*yaml.Node{}
+		for _, e := range n.Content {
+			out = append(out, mergedMappings(e)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func recordTOMLOrder(s string, out interface{}) error {
+	root, ok := out.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	md, err := toml.Decode(s, &map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+
+	// arrays of tables appear in the keys once per table, without an
+	// index, so count them to know which table later keys belong to
+	tableIndex := map[string]int{}
+	orders := map[uintptr][]string{}
+	maps := map[uintptr]map[string]interface{}{}
+	for _, key := range md.Keys() {
+		parent := tomlTable(root, key[:len(key)-1], tableIndex)
+		if parent == nil {
+			continue
+		}
+		p := reflect.ValueOf(parent).Pointer()
+		maps[p] = parent
+		orders[p] = append(orders[p], key[len(key)-1])
+
+		if isTableArray(parent[key[len(key)-1]]) {
+			tableIndex[key.String()]++
+		}
+	}
+	for p, keys := range orders {
+		mapmeta.Record(maps[p], mapmeta.Meta{Keys: keys})
+	}
+	return nil
+}
+
+// tomlTable finds the table at the given path, using the current table for
+// arrays of tables
+func tomlTable(root map[string]interface{}, path []string, tableIndex map[string]int) map[string]interface{} {
+	m := root
+	for i, k := range path {
+		switch t := m[k].(type) {
+		case map[string]interface{}:
+			m = t
+		case []map[string]interface{}:
+			n := tableIndex[toml.Key(path[:i+1]).String()]
+			if n < 1 || n > len(t) {
+				return nil
+			}
+			m = t[n-1]
+		default:
+			return nil
+		}
+	}
+	return m
+}
+
+func isTableArray(v interface{}) bool {
+	_, ok := v.([]map[string]interface{})
+	return ok
+}
@@ -0,0 +1,219 @@
+package data
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordOrderYAML(t *testing.T) {
+	in := `zebra: 1
+apple:
+  - name: one
+    id: 1
+  - name: two
+    id: 2
+mango:
+  z: true
+  a: false
+`
+	out, err := parseData(yamlMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(yamlMimetype, in, out, false))
+
+	actual, err := ToYAML(out)
+	require.NoError(t, err)
+	assert.Equal(t, in, actual)
+
+	actual, err = ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"zebra":1,"apple":[{"name":"one","id":1},{"name":"two","id":2}],"mango":{"z":true,"a":false}}`, actual)
+
+	actual, err = ToJSONPretty("  ", out)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "zebra": 1,
+  "apple": [
+    {
+      "name": "one",
+      "id": 1
+    },
+    {
+      "name": "two",
+      "id": 2
+    }
+  ],
+  "mango": {
+    "z": true,
+    "a": false
+  }
+}`, actual)
+
+	// keys added later are sorted, after the recorded keys
+	m := out.(map[string]interface{})
+	m["banana"] = 2
+	m["aardvark"] = 3
+	actual, err = ToJSON(m)
+	require.NoError(t, err)
+	assert.Equal(t, `{"zebra":1,"apple":[{"name":"one","id":1},{"name":"two","id":2}],"mango":{"z":true,"a":false},"aardvark":3,"banana":2}`, actual)
+
+	// maps without a recorded order are still sorted
+	actual, err = ToJSON(map[string]interface{}{"b": 1, "a": 2})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":2,"b":1}`, actual)
+}
+
+func TestRecordOrderYAMLMerge(t *testing.T) {
+	in := `base: &base
+  y: 1
+  x: 2
+top:
+  c: 3
+  <<: *base
+  a: 4
+`
+	out, err := parseData(yamlMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(yamlMimetype, in, out, false))
+
+	actual, err := ToJSON(out.(map[string]interface{})["top"])
+	require.NoError(t, err)
+	assert.Equal(t, `{"c":3,"y":1,"x":2,"a":4}`, actual)
+}
+
+func TestRecordOrderJSON(t *testing.T) {
+	in := `{"z": {"y": 1, "b": [{"d": 1, "c": 2}]}, "a": "x"}`
+	out, err := parseData(jsonMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(jsonMimetype, in, out, false))
+
+	actual, err := ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"z":{"y":1,"b":[{"d":1,"c":2}]},"a":"x"}`, actual)
+
+	in = `[{"b": 1, "a": 2}]`
+	out, err = parseData(jsonArrayMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(jsonArrayMimetype, in, out, false))
+
+	actual, err = ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"b":1,"a":2}]`, actual)
+}
+
+func TestRecordOrderTOML(t *testing.T) {
+	in := `title = "t"
+name = "n"
+
+[server]
+port = 80
+host = "h"
+
+[[backend]]
+weight = 1
+addr = "a"
+
+[[backend]]
+weight = 2
+addr = "b"
+`
+	out, err := parseData(tomlMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(tomlMimetype, in, out, false))
+
+	actual, err := ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"t","name":"n","server":{"port":80,"host":"h"},"backend":[{"weight":1,"addr":"a"},{"weight":2,"addr":"b"}]}`, actual)
+}
+
+func TestDatasourceOrderedMaps(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = fs.Mkdir("/tmp", 0777)
+	_ = afero.WriteFile(fs, "/tmp/foo.yaml", []byte("b: 1\na: 2\n"), 0600)
+
+	d := &Data{
+		Sources: map[string]*Source{
+			"foo": {
+				Alias:     "foo",
+				URL:       &url.URL{Scheme: "file", Path: "/tmp/foo.yaml"},
+				mediaType: yamlMimetype,
+				fs:        fs,
+			},
+		},
+	}
+
+	out, err := d.Datasource("foo")
+	require.NoError(t, err)
+	actual, err := ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":2,"b":1}`, actual)
+
+	d.OrderedMaps = true
+	out, err = d.Datasource("foo")
+	require.NoError(t, err)
+	actual, err = ToJSON(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":1,"a":2}`, actual)
+}
+
+func TestPreserveComments(t *testing.T) {
+	in := `# config
+
+server:
+  # the port
+  port: 8080 # must be > 1024
+  host: "0.0.0.0"
+  tags: [a, b]
+backends:
+  - name: one # primary
+    weight: 10
+  - name: two
+    weight: 5
+
+# the end
+`
+	out, err := parseData(yamlMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(yamlMimetype, in, out, true))
+
+	actual, err := ToYAML(out)
+	require.NoError(t, err)
+	assert.Equal(t, in, actual)
+
+	// changed values keep their comments, and new keys are added at the end
+	server := out.(map[string]interface{})["server"].(map[string]interface{})
+	server["port"] = 9090
+	server["debug"] = true
+	server["tags"] = []interface{}{"a", "c"}
+	actual, err = ToYAML(out)
+	require.NoError(t, err)
+	assert.Equal(t, `# config
+
+server:
+  # the port
+  port: 9090 # must be > 1024
+  host: "0.0.0.0"
+  tags: [a, c]
+  debug: true
+backends:
+  - name: one # primary
+    weight: 10
+  - name: two
+    weight: 5
+
+# the end
+`, actual)
+
+	// JSON's formatting isn't preserved
+	in = `{"b": "x", "a": [1]}`
+	out, err = parseData(jsonMimetype, in)
+	require.NoError(t, err)
+	require.NoError(t, recordOrder(jsonMimetype, in, out, true))
+
+	actual, err = ToYAML(out)
+	require.NoError(t, err)
+	assert.Equal(t, "b: x\na:\n  - 1\n", actual)
+}
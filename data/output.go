@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+)
+
+// outputStores - the functions that open remote outputs, by scheme. All
+// remote outputs are written with compare-and-swap semantics, where the
+// backend supports them - see OpenOutput.
+var outputStores = map[string]func(context.Context, *url.URL) (cas.Store, error){
+	"s3":        blobOutput,
+	"gs":        blobOutput,
+	"http":      httpOutput,
+	"https":     httpOutput,
+	"git+file":  gitOutput,
+	"git+http":  gitOutput,
+	"git+https": gitOutput,
+	"git+ssh":   gitOutput,
+
+	"k8s+secret":    kubeOutput,
+	"k8s+configmap": kubeOutput,
+
+	"consul":       consulOutput,
+	"consul+http":  consulOutput,
+	"consul+https": consulOutput,
+	"vault":        vaultOutput,
+	"vault+http":   vaultOutput,
+	"vault+https":  vaultOutput,
+}
+
+// IsOutputURL returns true when the output path is a URL that can be opened
+// with OpenOutput
+func IsOutputURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	_, ok := outputStores[u.Scheme]
+	return ok
+}
+
+// OpenOutput opens a remote output, using the same URLs as datasources:
+//
+//   - s3:// and gs:// URLs are written to as objects
+//   - http:// and https:// URLs are written to with PUT requests
+//   - git+file://, git+http://, git+https://, and git+ssh:// URLs (with the path
+//     of the file in the repo after a '//') are written to by committing and
+//     pushing the file
+//   - k8s+secret:// and k8s+configmap:// URLs (with the path
+//     [namespace/]name/key) are written to by applying the key to the Secret
+//     or ConfigMap
+//   - consul:// (and consul+http://, consul+https://) URLs are written to as
+//     keys
+//   - vault:// (and vault+http://, vault+https://) URLs are written to as
+//     secrets, with the content as the secret's data (a JSON object), or as
+//     the field given in the 'field' query parameter
+//
+// The content is buffered, and only written when the writer is closed. The
+// output's version is read when it's opened, and when the backend supports
+// it, the content is only written if the output still has that version.
+// Otherwise, the error from Close wraps cas.ErrConflict. Writes to S3 and to
+// Vault secrets outside of KV v2 mounts (which don't support conditional
+// writes) are unconditional.
+func OpenOutput(ctx context.Context, s string) (io.WriteCloser, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output URL %q: %w", s, err)
+	}
+	open, ok := outputStores[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("scheme %s can't be used for outputs", u.Scheme)
+	}
+	store, err := open(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	w, err := cas.NewWriter(ctx, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output %s: %w", u.Redacted(), err)
+	}
+	return w, nil
+}
+
+// httpOutput - HTTP resources are versioned by their ETags. Resources that
+// exist but have no ETag are written unconditionally.
+func httpOutput(ctx context.Context, u *url.URL) (cas.Store, error) {
+	hdr := http.Header{}
+	if ct := mime.TypeByExtension(path.Ext(u.Path)); ct != "" {
+		hdr.Set("Content-Type", ct)
+	}
+	return &cas.HTTPStore{
+		Client:           &http.Client{Transport: transportFromContext(ctx, u.Scheme)},
+		URL:              u.String(),
+		Header:           hdr,
+		AllowUnversioned: true,
+	}, nil
+}
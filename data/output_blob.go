@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// blobStore - an object in an S3 or GCS bucket. GCS objects are versioned by
+// their generation, and written with generation preconditions. S3 doesn't
+// support conditional writes, so S3 objects are always written (atomically),
+// regardless of their version.
+type blobStore struct {
+	u   *url.URL
+	key string
+}
+
+var _ cas.Store = (*blobStore)(nil)
+
+func blobOutput(_ context.Context, u *url.URL) (cas.Store, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("an object key must be given in %s", u.Redacted())
+	}
+	return &blobStore{u: u, key: key}, nil
+}
+
+func (s *blobStore) conditional() bool {
+	return s.u.Scheme == "gs"
+}
+
+func (s *blobStore) bucket(ctx context.Context) (*blob.Bucket, error) {
+	opener, err := newOpener(ctx, s.u)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := blob.URLMux{}
+	mux.RegisterBucket(s.u.Scheme, opener)
+	return mux.OpenBucket(ctx, blobURL(s.u))
+}
+
+// Version - the GCS object's generation, or "" if it doesn't exist (or isn't
+// in GCS)
+func (s *blobStore) Version(ctx context.Context) (string, error) {
+	if !s.conditional() {
+		return "", nil
+	}
+
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer bucket.Close()
+
+	attrs, err := bucket.Attributes(ctx, s.key)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve attributes for %s: %w", s.key, err)
+	}
+
+	var oa storage.ObjectAttrs
+	if !attrs.As(&oa) {
+		return "", fmt.Errorf("couldn't read the generation of %s", s.key)
+	}
+	return strconv.FormatInt(oa.Generation, 10), nil
+}
+
+// Write - writes the object. In GCS, it's only written if it still has the
+// generation (or doesn't exist, if version is "").
+func (s *blobStore) Write(ctx context.Context, content []byte, version string) (string, error) {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer bucket.Close()
+
+	opts := &blob.WriterOptions{
+		ContentType: mime.TypeByExtension(path.Ext(s.key)),
+	}
+	// the GCS writer, so the new generation can be read after writing
+	var w *storage.Writer
+	if s.conditional() {
+		cond := storage.Conditions{DoesNotExist: true}
+		if version != "" {
+			gen, err := strconv.ParseInt(version, 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid generation %q: %w", version, err)
+			}
+			cond = storage.Conditions{GenerationMatch: gen}
+		}
+		opts.BeforeWrite = func(as func(interface{}) bool) error {
+			var oh **storage.ObjectHandle
+			if as(&oh) {
+				*oh = (*oh).If(cond)
+			}
+			as(&w)
+			return nil
+		}
+	}
+
+	err = bucket.WriteAll(ctx, s.key, content, opts)
+	if gcerrors.Code(err) == gcerrors.FailedPrecondition {
+		return "", &cas.ConflictError{Target: s.u.Redacted(), Version: version}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", s.key, err)
+	}
+	if w != nil && w.Attrs() != nil {
+		return strconv.FormatInt(w.Attrs().Generation, 10), nil
+	}
+	return "", nil
+}
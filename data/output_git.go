@@ -0,0 +1,146 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/env"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// gitStore - a file in a git repo, versioned by the commit the branch is at.
+// It's written by committing the file and pushing the commit, which is only
+// accepted if the branch hasn't moved since the repo was cloned.
+type gitStore struct {
+	u       *url.URL
+	repoURL *url.URL
+	path    string
+
+	// the clone, from when the version was read
+	fs   billy.Filesystem
+	repo *git.Repository
+}
+
+var _ cas.Store = (*gitStore)(nil)
+
+func gitOutput(_ context.Context, u *url.URL) (cas.Store, error) {
+	repoURL, p, err := gitsource{}.parseGitPath(u)
+	if err != nil {
+		return nil, err
+	}
+	if p == "/" || strings.HasSuffix(p, "/") {
+		return nil, fmt.Errorf("the path of a file in the repo must be given (after '//') in %s", u.Redacted())
+	}
+	return &gitStore{u: u, repoURL: repoURL, path: p}, nil
+}
+
+// Version - clones the repo, and returns the commit the branch is at
+func (s *gitStore) Version(ctx context.Context) (string, error) {
+	// a full clone is needed for the new commit to be pushed
+	fs, repo, err := gitsource{}.clone(ctx, s.repoURL, 0)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("couldn't find the branch to write to in %s: %w", s.u.Redacted(), err)
+	}
+	s.fs, s.repo = fs, repo
+	return head.Hash().String(), nil
+}
+
+// Write - commits the file and pushes the commit, if the branch is still at
+// the version. Nothing is committed when the file is unchanged.
+func (s *gitStore) Write(ctx context.Context, content []byte, version string) (string, error) {
+	if s.repo == nil {
+		if _, err := s.Version(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	if err := util.WriteFile(s.fs, s.path, content, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+
+	wt, err := s.repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	if _, err = wt.Add(strings.TrimPrefix(s.path, "/")); err != nil {
+		return "", fmt.Errorf("failed to add %s: %w", s.path, err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return "", err
+	}
+	if status.IsClean() {
+		return version, nil
+	}
+
+	hash, err := wt.Commit(s.message(), &git.CommitOptions{Author: gitSignature()})
+	if err != nil {
+		return "", fmt.Errorf("failed to commit %s: %w", s.path, err)
+	}
+
+	head, err := s.repo.Head()
+	if err != nil {
+		return "", err
+	}
+	auth, err := gitsource{}.auth(s.repoURL)
+	if err != nil {
+		return "", err
+	}
+	auth, err = gitsource{}.policyAuth(ctx, s.repoURL, auth)
+	if err != nil {
+		return "", err
+	}
+	ref := head.Name()
+	err = s.repo.PushContext(ctx, &git.PushOptions{
+		Auth:              auth,
+		RefSpecs:          []config.RefSpec{config.RefSpec(ref + ":" + ref)},
+		RequireRemoteRefs: []config.RefSpec{config.RefSpec(version + ":" + ref.String())},
+	})
+	if err != nil && isGitConflict(err) {
+		return "", &cas.ConflictError{Target: s.u.Redacted(), Version: version}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to push %s to %s: %w", s.path, s.repoURL.Redacted(), err)
+	}
+	return hash.String(), nil
+}
+
+// message - the commit message, from the 'message' query parameter, or a
+// default
+func (s *gitStore) message() string {
+	if m := s.u.Query().Get("message"); m != "" {
+		return m
+	}
+	return "Update " + path.Base(s.path)
+}
+
+// gitSignature - the commit author, from the same environment variables as
+// git uses
+func gitSignature() *object.Signature {
+	return &object.Signature{
+		Name:  env.Getenv("GIT_AUTHOR_NAME", "gomplate"),
+		Email: env.Getenv("GIT_AUTHOR_EMAIL", "gomplate@localhost"),
+		When:  time.Now(),
+	}
+}
+
+// isGitConflict - whether the push was rejected because the branch moved
+func isGitConflict(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "non-fast-forward update") ||
+		(strings.Contains(msg, "remote ref") && strings.Contains(msg, "required to be"))
+}
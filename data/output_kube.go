@@ -0,0 +1,160 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+)
+
+const (
+	// kubeManagedByLabel - the label that marks Secrets and ConfigMaps as
+	// managed by gomplate. Objects without it aren't written to, unless forced.
+	kubeManagedByLabel = "app.kubernetes.io/managed-by"
+	kubeManagedBy      = "gomplate"
+
+	// field manager names can't be longer than this
+	kubeMaxFieldManager = 128
+)
+
+// kubeStore - a key in a Kubernetes Secret or ConfigMap, versioned by the
+// object's resourceVersion. It's written with server-side apply, as a field
+// manager for just that key, so that several outputs can write different keys
+// of the same object.
+type kubeStore struct {
+	u         *url.URL
+	client    *kube.Client
+	resource  string
+	kind      string
+	namespace string
+	name      string
+	key       string
+	// force - whether objects that aren't managed by gomplate (or keys owned
+	// by other field managers) can be written to
+	force bool
+}
+
+var _ cas.Store = (*kubeStore)(nil)
+
+// kubeOutput - k8s+secret:// and k8s+configmap:// URLs, with the path
+// [namespace/]name/key. The API server is the URL's host (such as one served
+// by 'kubectl proxy'), or the cluster gomplate is running in, when there's no
+// host.
+func kubeOutput(_ context.Context, u *url.URL) (cas.Store, error) {
+	s := &kubeStore{u: u}
+	switch u.Scheme {
+	case "k8s+secret":
+		s.resource, s.kind = "secrets", "Secret"
+	case "k8s+configmap":
+		s.resource, s.kind = "configmaps", "ConfigMap"
+	default:
+		return nil, fmt.Errorf("unsupported scheme %s", u.Scheme)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch len(parts) {
+	case 2:
+		// like kubectl, the default namespace is used outside of a cluster
+		s.namespace = kube.CurrentNamespace()
+		if s.namespace == "" {
+			s.namespace = "default"
+		}
+		s.name, s.key = parts[0], parts[1]
+	case 3:
+		s.namespace, s.name, s.key = parts[0], parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("the path in %s must be [namespace/]name/key", u.Redacted())
+	}
+	if s.name == "" || s.key == "" {
+		return nil, fmt.Errorf("the path in %s must be [namespace/]name/key", u.Redacted())
+	}
+
+	if f := u.Query().Get("force"); f != "" {
+		force, err := strconv.ParseBool(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid force parameter %q in %s: %w", f, u.Redacted(), err)
+		}
+		s.force = force
+	}
+
+	server := ""
+	if u.Host != "" {
+		server = "http://" + u.Host
+	}
+	client, err := kube.New(server)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+
+	return s, nil
+}
+
+// Version - the object's resourceVersion, or "" if it doesn't exist. Objects
+// that aren't managed by gomplate are refused, unless forced.
+func (s *kubeStore) Version(ctx context.Context) (string, error) {
+	o, err := s.client.Get(ctx, s.resource, s.namespace, s.name)
+	if errors.Is(err, kube.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %s/%s: %w", s.kind, s.namespace, s.name, err)
+	}
+	if o.Metadata.Labels[kubeManagedByLabel] != kubeManagedBy && !s.force {
+		return "", fmt.Errorf("%s %s/%s isn't managed by gomplate (it has no %s=%s label) - add force=true to %s to write to it anyway",
+			s.kind, s.namespace, s.name, kubeManagedByLabel, kubeManagedBy, s.u.Redacted())
+	}
+	return o.Metadata.ResourceVersion, nil
+}
+
+// Write - applies the key (and the managed-by label), if the object still has
+// the version. Objects that don't exist yet are created.
+func (s *kubeStore) Write(ctx context.Context, content []byte, version string) (string, error) {
+	obj := &kube.Object{
+		APIVersion: "v1",
+		Kind:       s.kind,
+		Metadata: kube.ObjectMeta{
+			Name:            s.name,
+			Namespace:       s.namespace,
+			ResourceVersion: version,
+			Labels:          map[string]string{kubeManagedByLabel: kubeManagedBy},
+		},
+	}
+	switch {
+	case s.kind == "Secret":
+		obj.Data = map[string]string{s.key: base64.StdEncoding.EncodeToString(content)}
+	case utf8.Valid(content):
+		obj.Data = map[string]string{s.key: string(content)}
+	default:
+		return "", fmt.Errorf("%s isn't valid UTF-8, so it can't be written to a ConfigMap - use a Secret instead", s.u.Redacted())
+	}
+
+	o, err := s.client.Apply(ctx, s.resource, obj, s.fieldManager(), s.force)
+	if errors.Is(err, kube.ErrConflict) {
+		return "", &cas.ConflictError{Target: s.u.Redacted(), Version: version}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to apply %s %s/%s: %w", s.kind, s.namespace, s.name, err)
+	}
+	return o.Metadata.ResourceVersion, nil
+}
+
+// fieldManager - the field manager for the key. Each key needs its own,
+// since applying removes the fields the field manager applied before.
+func (s *kubeStore) fieldManager() string {
+	m := kubeManagedBy + "-" + s.key
+	if len(m) > kubeMaxFieldManager {
+		sum := sha256.Sum256([]byte(s.key))
+		m = kubeManagedBy + "-" + hex.EncodeToString(sum[:])
+	}
+	return m
+}
@@ -0,0 +1,136 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+	"github.com/hairyhenderson/gomplate/v3/libkv"
+	"github.com/hairyhenderson/gomplate/v3/vault"
+)
+
+// consulOutput - Consul keys are versioned by their modify index, and written
+// with check-and-set
+func consulOutput(_ context.Context, u *url.URL) (cas.Store, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("a key must be given in %s", u.Redacted())
+	}
+	kv, err := libkv.NewConsul(u)
+	if err != nil {
+		return nil, err
+	}
+	if err = kv.Login(); err != nil {
+		return nil, err
+	}
+	return kv.Store(key), nil
+}
+
+// vaultStore - a Vault secret. Secrets in KV v2 mounts (with paths like
+// 'secret/data/app') are versioned, and written with check-and-set. Other
+// secrets are always written, regardless of their version.
+type vaultStore struct {
+	u    *url.URL
+	vc   *vault.Vault
+	path string
+	// field - when set, the content is written to this field of the secret.
+	// Otherwise, it must be a JSON object, which is written as the secret.
+	field string
+}
+
+var _ cas.Store = (*vaultStore)(nil)
+
+func vaultOutput(ctx context.Context, u *url.URL) (cas.Store, error) {
+	p := strings.Trim(u.Path, "/")
+	if p == "" {
+		return nil, fmt.Errorf("a secret path must be given in %s", u.Redacted())
+	}
+	vc, err := vaultClient(ctx, &Source{URL: u})
+	if err != nil {
+		return nil, err
+	}
+	return &vaultStore{u: u, vc: vc, path: p, field: u.Query().Get("field")}, nil
+}
+
+// kv2 - whether the secret is in a KV v2 mount, with a path like
+// 'mount/data/name'
+func (s *vaultStore) kv2() bool {
+	parts := strings.SplitN(s.path, "/", 3)
+	return len(parts) == 3 && parts[1] == "data"
+}
+
+// Version - the KV v2 secret's current version, or "" if it doesn't exist
+// (or isn't in a KV v2 mount)
+func (s *vaultStore) Version(_ context.Context) (string, error) {
+	if !s.kv2() {
+		return "", nil
+	}
+	b, err := s.vc.Read(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	if len(b) == 0 {
+		return "", nil
+	}
+
+	secret := struct {
+		Metadata struct {
+			Version json.Number `json:"version"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(b, &secret); err != nil {
+		return "", fmt.Errorf("couldn't read the version of %s: %w", s.path, err)
+	}
+	return secret.Metadata.Version.String(), nil
+}
+
+// Write - writes the secret. In KV v2 mounts, it's only written if it's
+// still at the version (or doesn't exist, if version is "").
+func (s *vaultStore) Write(_ context.Context, content []byte, version string) (string, error) {
+	data := map[string]interface{}{}
+	if s.field != "" {
+		data[s.field] = string(content)
+	} else if err := json.Unmarshal(content, &data); err != nil {
+		return "", fmt.Errorf("output to %s must be a JSON object (or a field must be given with the 'field' parameter): %w", s.u.Redacted(), err)
+	}
+
+	if !s.kv2() {
+		if _, err := s.vc.Write(s.path, data); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", s.path, err)
+		}
+		return "", nil
+	}
+
+	n := 0
+	if version != "" {
+		var err error
+		n, err = strconv.Atoi(version)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q: %w", version, err)
+		}
+	}
+	b, err := s.vc.Write(s.path, map[string]interface{}{
+		"data":    data,
+		"options": map[string]interface{}{"cas": n},
+	})
+	if err != nil && strings.Contains(err.Error(), "check-and-set parameter did not match") {
+		return "", &cas.ConflictError{Target: s.u.Redacted(), Version: version}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+
+	resp := struct {
+		Version json.Number `json:"version"`
+	}{}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return "", fmt.Errorf("couldn't read the new version of %s: %w", s.path, err)
+		}
+	}
+	return resp.Version.String(), nil
+}
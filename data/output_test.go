@@ -0,0 +1,374 @@
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube/kubetest"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOutputURL(t *testing.T) {
+	for _, s := range []string{
+		"s3://bucket/key", "gs://bucket/key",
+		"http://example.com/out.txt", "https://example.com/out.txt",
+		"git+ssh://git@example.com/repo.git//out.txt",
+		"git+file:///repo//out.txt",
+		"k8s+secret:///ns/name/key", "k8s+configmap:///name/key",
+		"consul:///app/config", "vault:///secret/data/app",
+	} {
+		assert.True(t, IsOutputURL(s), s)
+	}
+	for _, s := range []string{
+		"-", "out.txt", "/tmp/out.txt", "C:\\out.txt",
+		"env:///FOO", "git://example.com/repo//out.txt", "%zz",
+	} {
+		assert.False(t, IsOutputURL(s), s)
+	}
+
+	_, err := OpenOutput(context.Background(), "env:///FOO")
+	assert.Error(t, err)
+}
+
+func TestHTTPOutput(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		content     string
+		contentType string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodHead:
+			// no ETag, so it's written unconditionally
+			w.WriteHeader(http.StatusOK)
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			content = string(b)
+			contentType = r.Header.Get("Content-Type")
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	w, err := OpenOutput(context.Background(), srv.URL+"/out.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`{"hello": "world"}`))
+	require.NoError(t, err)
+
+	// nothing's written until it's closed
+	assert.Equal(t, "", content)
+	require.NoError(t, w.Close())
+	assert.Equal(t, `{"hello": "world"}`, content)
+	assert.Equal(t, "application/json", contentType)
+}
+
+func TestBlobOutput(t *testing.T) {
+	backend := s3mem.New()
+	ts := httptest.NewServer(gofakes3.New(backend).Server())
+	defer ts.Close()
+	require.NoError(t, backend.CreateBucket("mybucket"))
+
+	os.Setenv("AWS_ANON", "true")
+	defer os.Unsetenv("AWS_ANON")
+
+	q := "?region=us-east-1&disableSSL=true&s3ForcePathStyle=true&endpoint=" + ts.Listener.Addr().String()
+
+	_, err := OpenOutput(context.Background(), "s3://mybucket/dir/"+q)
+	assert.Error(t, err)
+
+	w, err := OpenOutput(context.Background(), "s3://mybucket/dir/out.yaml"+q)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello: world\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	obj, err := backend.GetObject("mybucket", "dir/out.yaml", nil)
+	require.NoError(t, err)
+	defer obj.Contents.Close()
+	b, err := io.ReadAll(obj.Contents)
+	require.NoError(t, err)
+	assert.Equal(t, "hello: world\n", string(b))
+}
+
+func TestGitOutput(t *testing.T) {
+	ctx := context.Background()
+	repoFS := setupGitRepo(t)
+	overrideFSLoader(repoFS)
+	defer overrideFSLoader(osfs.New(""))
+
+	_, err := OpenOutput(ctx, "git+file:///bare.git")
+	assert.Error(t, err)
+
+	w1, err := OpenOutput(ctx, "git+file:///bare.git//out/hello.txt?message=Render+hello")
+	require.NoError(t, err)
+	w2, err := OpenOutput(ctx, "git+file:///bare.git//out/other.txt")
+	require.NoError(t, err)
+
+	_, err = w1.Write([]byte("hello from gomplate"))
+	require.NoError(t, err)
+	require.NoError(t, w1.Close())
+
+	b, err := readGit(ctx, &Source{URL: mustParseURL("git+file:///bare.git//out/hello.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, "hello from gomplate", string(b))
+
+	_, repo, err := gitsource{}.clone(ctx, mustParseURL("git+file:///bare.git"), 0)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, "Render hello", commit.Message)
+	assert.Equal(t, "gomplate", commit.Author.Name)
+
+	// w2 was opened before w1 pushed, so its push conflicts
+	_, err = w2.Write([]byte("other"))
+	require.NoError(t, err)
+	err = w2.Close()
+	assert.True(t, cas.IsConflict(err), err)
+
+	// unchanged files aren't committed again
+	w3, err := OpenOutput(ctx, "git+file:///bare.git//out/hello.txt")
+	require.NoError(t, err)
+	_, err = w3.Write([]byte("hello from gomplate"))
+	require.NoError(t, err)
+	require.NoError(t, w3.Close())
+
+	_, repo, err = gitsource{}.clone(ctx, mustParseURL("git+file:///bare.git"), 0)
+	require.NoError(t, err)
+	newHead, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, head.Hash(), newHead.Hash())
+}
+
+func TestKubeOutput(t *testing.T) {
+	srv := kubetest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	base := "k8s+secret://" + strings.TrimPrefix(srv.URL, "http://")
+
+	write := func(u, content string) error {
+		w, err := OpenOutput(ctx, u)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+		return w.Close()
+	}
+
+	// secrets are created, and other keys are merged in
+	require.NoError(t, write(base+"/ns1/creds/user", "admin"))
+	require.NoError(t, write(base+"/ns1/creds/password", "hunter2"))
+
+	o, ok := srv.Get("secrets", "ns1", "creds")
+	require.True(t, ok)
+	assert.Equal(t, "gomplate", o.Metadata.Labels["app.kubernetes.io/managed-by"])
+	assert.Equal(t, map[string]string{
+		"user":     base64.StdEncoding.EncodeToString([]byte("admin")),
+		"password": base64.StdEncoding.EncodeToString([]byte("hunter2")),
+	}, o.Data)
+
+	// configmaps hold the content as-is
+	cmURL := "k8s+configmap://" + strings.TrimPrefix(srv.URL, "http://") + "/ns1/app/config.yaml"
+	require.NoError(t, write(cmURL, "foo: bar\n"))
+	o, ok = srv.Get("configmaps", "ns1", "app")
+	require.True(t, ok)
+	assert.Equal(t, "foo: bar\n", o.Data["config.yaml"])
+
+	err := write(cmURL, "\xff\xfe")
+	assert.Error(t, err)
+
+	// objects not managed by gomplate are only written to when forced
+	srv.Put("secrets", kube.Object{Metadata: kube.ObjectMeta{Name: "theirs", Namespace: "ns1"}})
+	err = write(base+"/ns1/theirs/key", "value")
+	assert.Error(t, err)
+	require.NoError(t, write(base+"/ns1/theirs/key?force=true", "value"))
+
+	// the secret was modified after w was opened, so writing it conflicts
+	w, err := OpenOutput(ctx, base+"/ns1/creds/user")
+	require.NoError(t, err)
+	require.NoError(t, write(base+"/ns1/creds/password", "changed"))
+	_, err = w.Write([]byte("root"))
+	require.NoError(t, err)
+	err = w.Close()
+	assert.True(t, cas.IsConflict(err), err)
+
+	for _, u := range []string{
+		base + "/creds", base + "/a/b/c/d", base + "//creds/",
+		base + "/ns1/creds/key?force=maybe",
+	} {
+		_, err = OpenOutput(ctx, u)
+		assert.Error(t, err, u)
+	}
+}
+
+func TestConsulOutput(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		value []byte
+		index uint64
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v1/kv/app/config" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if index == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"Key": "app/config", "Value": value, "ModifyIndex": index},
+			})
+		case http.MethodPut:
+			if r.URL.Query().Get("cas") != strconv.FormatUint(index, 10) {
+				_, _ = w.Write([]byte("false"))
+				return
+			}
+			value, _ = io.ReadAll(r.Body)
+			index++
+			_, _ = w.Write([]byte("true"))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	u := "consul+http://" + strings.TrimPrefix(srv.URL, "http://") + "/app/config"
+
+	w1, err := OpenOutput(ctx, u)
+	require.NoError(t, err)
+	w2, err := OpenOutput(ctx, u)
+	require.NoError(t, err)
+
+	_, err = w1.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w1.Close())
+	assert.Equal(t, "hello", string(value))
+
+	// w2 was opened before the key was created
+	_, err = w2.Write([]byte("other"))
+	require.NoError(t, err)
+	err = w2.Close()
+	assert.True(t, cas.IsConflict(err), err)
+
+	w3, err := OpenOutput(ctx, u)
+	require.NoError(t, err)
+	_, err = w3.Write([]byte("updated"))
+	require.NoError(t, err)
+	require.NoError(t, w3.Close())
+	assert.Equal(t, "updated", string(value))
+
+	_, err = OpenOutput(ctx, "consul+http://"+strings.TrimPrefix(srv.URL, "http://")+"/app/")
+	assert.Error(t, err)
+}
+
+func TestVaultOutput(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "foo")
+
+	var (
+		mu      sync.Mutex
+		secret  map[string]interface{}
+		version int
+		v1      map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", jsonMimetype)
+		switch {
+		case r.URL.Path == "/v1/kv/foo" && r.Method == http.MethodPut:
+			_ = json.NewDecoder(r.Body).Decode(&v1)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path != "/v1/secret/data/app":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		case r.Method == http.MethodGet && version == 0:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": secret, "metadata": map[string]interface{}{"version": version}},
+			})
+		case r.Method == http.MethodPut:
+			body := struct {
+				Data    map[string]interface{}
+				Options struct{ CAS int }
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Options.CAS != version {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+				return
+			}
+			secret = body.Data
+			version++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"version": version},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	base := "vault+http://" + strings.TrimPrefix(srv.URL, "http://")
+
+	write := func(w io.WriteCloser, content string) error {
+		_, err := w.Write([]byte(content))
+		require.NoError(t, err)
+		return w.Close()
+	}
+
+	w1, err := OpenOutput(ctx, base+"/secret/data/app")
+	require.NoError(t, err)
+	w2, err := OpenOutput(ctx, base+"/secret/data/app")
+	require.NoError(t, err)
+
+	require.NoError(t, write(w1, `{"user": "admin", "password": "hunter2"}`))
+	assert.Equal(t, map[string]interface{}{"user": "admin", "password": "hunter2"}, secret)
+	assert.Equal(t, 1, version)
+
+	err = write(w2, `{"user": "root"}`)
+	assert.True(t, cas.IsConflict(err), err)
+
+	w3, err := OpenOutput(ctx, base+"/secret/data/app?field=token")
+	require.NoError(t, err)
+	require.NoError(t, write(w3, "s3cr3t"))
+	assert.Equal(t, map[string]interface{}{"token": "s3cr3t"}, secret)
+	assert.Equal(t, 2, version)
+
+	w4, err := OpenOutput(ctx, base+"/secret/data/app")
+	require.NoError(t, err)
+	assert.Error(t, write(w4, "not an object"))
+
+	// KV v1 secrets are written unconditionally
+	w5, err := OpenOutput(ctx, base+"/kv/foo?field=value")
+	require.NoError(t, err)
+	require.NoError(t, write(w5, "bar"))
+	assert.Equal(t, map[string]interface{}{"value": "bar"}, v1)
+
+	_, err = OpenOutput(ctx, base+"/")
+	assert.Error(t, err)
+}
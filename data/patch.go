@@ -0,0 +1,486 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diff - compute a JSON Patch (RFC 6902) that transforms a into b. The patch
+// is returned as a list of operations, each a map with "op", "path", and
+// (when relevant) "value" keys.
+//
+// Maps are compared key-by-key, and lists element-by-element, so the patch
+// only contains the minimal set of changed paths.
+func Diff(a, b interface{}) []interface{} {
+	ops := []interface{}{}
+	return diffValues(ops, "", a, b)
+}
+
+func diffValues(ops []interface{}, path string, a, b interface{}) []interface{} {
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		return diffMaps(ops, path, am, bm)
+	}
+
+	al, aIsList := a.([]interface{})
+	bl, bIsList := b.([]interface{})
+	if aIsList && bIsList {
+		return diffLists(ops, path, al, bl)
+	}
+
+	if !jsonEqual(a, b) {
+		ops = append(ops, patchOp("replace", path, b))
+	}
+	return ops
+}
+
+func diffMaps(ops []interface{}, path string, a, b map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := path + "/" + escapePointerToken(k)
+		bv, ok := b[k]
+		if !ok {
+			ops = append(ops, map[string]interface{}{"op": "remove", "path": p})
+			continue
+		}
+		ops = diffValues(ops, p, a[k], bv)
+	}
+
+	keys = keys[:0]
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ops = append(ops, patchOp("add", path+"/"+escapePointerToken(k), b[k]))
+	}
+	return ops
+}
+
+func diffLists(ops []interface{}, path string, a, b []interface{}) []interface{} {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		ops = diffValues(ops, path+"/"+strconv.Itoa(i), a[i], b[i])
+	}
+	// remove from the end so that indexes stay valid
+	for i := len(a) - 1; i >= n; i-- {
+		ops = append(ops, map[string]interface{}{"op": "remove", "path": path + "/" + strconv.Itoa(i)})
+	}
+	for i := n; i < len(b); i++ {
+		ops = append(ops, patchOp("add", path+"/-", b[i]))
+	}
+	return ops
+}
+
+func patchOp(op, path string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{"op": op, "path": path, "value": value}
+}
+
+func escapePointerToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescapePointerToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+// Patch - apply a patch to the given document. The patch can be either a JSON
+// Patch (RFC 6902), given as a list of operations, or a JSON Merge Patch
+// (RFC 7386), given as a map.
+//
+// The input document is not modified.
+func Patch(patch, doc interface{}) (interface{}, error) {
+	switch p := patch.(type) {
+	case []interface{}:
+		return applyJSONPatch(p, doc)
+	case []map[string]interface{}:
+		ops := make([]interface{}, len(p))
+		for i, op := range p {
+			ops[i] = op
+		}
+		return applyJSONPatch(ops, doc)
+	case map[string]interface{}:
+		return mergePatch(doc, p), nil
+	default:
+		return nil, fmt.Errorf("patch must be a list of JSON Patch operations or a merge patch map, got %T", patch)
+	}
+}
+
+// mergePatch - apply an RFC 7386 JSON Merge Patch
+func mergePatch(doc interface{}, patch map[string]interface{}) interface{} {
+	target, ok := doc.(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+	}
+	out := make(map[string]interface{}, len(target))
+	for k, v := range target {
+		out[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		if pm, ok := v.(map[string]interface{}); ok {
+			out[k] = mergePatch(out[k], pm)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func applyJSONPatch(ops []interface{}, doc interface{}) (out interface{}, err error) {
+	out = deepCopy(doc)
+	for i, o := range ops {
+		op, ok := o.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("patch operation %d must be a map, got %T", i, o)
+		}
+		out, err = applyPatchOp(op, out)
+		if err != nil {
+			return nil, fmt.Errorf("patch operation %d (%v): %w", i, op["op"], err)
+		}
+	}
+	return out, nil
+}
+
+func applyPatchOp(op map[string]interface{}, doc interface{}) (interface{}, error) {
+	path, ok := op["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing or invalid 'path'")
+	}
+	name, _ := op["op"].(string)
+
+	switch name {
+	case "add":
+		v, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("missing 'value'")
+		}
+		return pointerSet(doc, path, deepCopy(v), true)
+	case "replace":
+		v, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("missing 'value'")
+		}
+		if _, err := pointerGet(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerSet(doc, path, deepCopy(v), false)
+	case "remove":
+		return pointerRemove(doc, path)
+	case "move", "copy":
+		from, ok := op["from"].(string)
+		if !ok {
+			return nil, fmt.Errorf("missing or invalid 'from'")
+		}
+		v, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if name == "move" {
+			if strings.HasPrefix(path, from+"/") {
+				return nil, fmt.Errorf("can not move %q into one of its children", from)
+			}
+			doc, err = pointerRemove(doc, from)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopy(v)
+		}
+		return pointerSet(doc, path, v, true)
+	case "test":
+		v, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(v, op["value"]) {
+			return nil, fmt.Errorf("test failed: value at %q is %v, not %v", path, v, op["value"])
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+}
+
+func splitPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with '/'", path)
+	}
+	parts := strings.Split(path[1:], "/")
+	for i, p := range parts {
+		parts[i] = unescapePointerToken(p)
+	}
+	return parts, nil
+}
+
+func listIndex(l []interface{}, tok string, allowEnd bool) (int, error) {
+	if allowEnd && tok == "-" {
+		return len(l), nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0, fmt.Errorf("invalid list index %q", tok)
+	}
+	max := len(l) - 1
+	if allowEnd {
+		max = len(l)
+	}
+	if i < 0 || i > max {
+		return 0, fmt.Errorf("list index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func pointerGet(doc interface{}, path string) (interface{}, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, tok := range parts {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			v, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", path)
+			}
+			cur = v
+		case []interface{}:
+			i, err := listIndex(c, tok, false)
+			if err != nil {
+				return nil, err
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("path %q not found", path)
+		}
+	}
+	return cur, nil
+}
+
+// pointerSet sets the value at the given path, returning the (possibly new)
+// document. When insert is true, values are inserted into lists rather than
+// replacing the existing element.
+func pointerSet(doc interface{}, path string, value interface{}, insert bool) (interface{}, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return value, nil
+	}
+	return setIn(doc, parts, value, insert)
+}
+
+func setIn(cur interface{}, parts []string, value interface{}, insert bool) (interface{}, error) {
+	tok := parts[0]
+	last := len(parts) == 1
+	switch c := cur.(type) {
+	case map[string]interface{}:
+		if last {
+			c[tok] = value
+			return c, nil
+		}
+		child, ok := c[tok]
+		if !ok {
+			return nil, fmt.Errorf("path element %q not found", tok)
+		}
+		v, err := setIn(child, parts[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		c[tok] = v
+		return c, nil
+	case []interface{}:
+		i, err := listIndex(c, tok, last && insert)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			if insert {
+				c = append(c, nil)
+				copy(c[i+1:], c[i:])
+			}
+			c[i] = value
+			return c, nil
+		}
+		v, err := setIn(c[i], parts[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+		return c, nil
+	default:
+		return nil, fmt.Errorf("can not set %q in a %T", tok, cur)
+	}
+}
+
+func pointerRemove(doc interface{}, path string) (interface{}, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("can not remove the document root")
+	}
+	return removeIn(doc, parts)
+}
+
+func removeIn(cur interface{}, parts []string) (interface{}, error) {
+	tok := parts[0]
+	last := len(parts) == 1
+	switch c := cur.(type) {
+	case map[string]interface{}:
+		child, ok := c[tok]
+		if !ok {
+			return nil, fmt.Errorf("path element %q not found", tok)
+		}
+		if last {
+			delete(c, tok)
+			return c, nil
+		}
+		v, err := removeIn(child, parts[1:])
+		if err != nil {
+			return nil, err
+		}
+		c[tok] = v
+		return c, nil
+	case []interface{}:
+		i, err := listIndex(c, tok, false)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			return append(c[:i], c[i+1:]...), nil
+		}
+		v, err := removeIn(c[i], parts[1:])
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+		return c, nil
+	default:
+		return nil, fmt.Errorf("can not remove %q from a %T", tok, cur)
+	}
+}
+
+// deepCopy - copy nested maps and lists so that patches don't modify their
+// inputs
+func deepCopy(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	default:
+		return in
+	}
+}
+
+// jsonEqual - whether a and b are equal as JSON values. Numbers are equal when
+// their values are, regardless of their types, so an int from YAML equals the
+// same float64 from JSON.
+func jsonEqual(a, b interface{}) bool {
+	ra, aIsNum := jsonNumber(a)
+	rb, bIsNum := jsonNumber(b)
+	if aIsNum || bIsNum {
+		return aIsNum && bIsNum && ra.Cmp(rb) == 0
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, e := range av {
+			be, ok := bv[k]
+			if !ok || !jsonEqual(e, be) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// jsonNumber - the exact value of v, if it's a number
+func jsonNumber(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case uint:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(uint64(n))), true
+	case uint8:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case uint16:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case uint32:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(n)), true
+	case float32:
+		return jsonNumber(float64(n))
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(n) == nil {
+			// NaN and infinities aren't JSON numbers
+			return nil, false
+		}
+		return r, true
+	case json.Number:
+		r, ok := new(big.Rat).SetString(string(n))
+		return r, ok
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,147 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	a := map[string]interface{}{
+		"foo": "bar",
+		"baz": map[string]interface{}{"qux": 1, "quux": true},
+		"a/b": "c",
+		"l":   []interface{}{1, 2, 3},
+	}
+	b := map[string]interface{}{
+		"foo": "bar",
+		"baz": map[string]interface{}{"qux": 2},
+		"new": "value",
+		"l":   []interface{}{1, 5},
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"op": "remove", "path": "/a~1b"},
+		map[string]interface{}{"op": "remove", "path": "/baz/quux"},
+		map[string]interface{}{"op": "replace", "path": "/baz/qux", "value": 2},
+		map[string]interface{}{"op": "replace", "path": "/l/1", "value": 5},
+		map[string]interface{}{"op": "remove", "path": "/l/2"},
+		map[string]interface{}{"op": "add", "path": "/new", "value": "value"},
+	}
+	assert.EqualValues(t, expected, Diff(a, b))
+
+	assert.Empty(t, Diff(a, a))
+	assert.EqualValues(t, []interface{}{
+		map[string]interface{}{"op": "replace", "path": "", "value": "b"},
+	}, Diff("a", "b"))
+
+	// the diff must always be applicable
+	out, err := Patch(Diff(a, b), a)
+	require.NoError(t, err)
+	assert.EqualValues(t, b, out)
+
+	out, err = Patch(Diff(b, a), b)
+	require.NoError(t, err)
+	assert.EqualValues(t, a, out)
+}
+
+func TestPatch_JSONPatch(t *testing.T) {
+	doc := map[string]interface{}{
+		"foo": "bar",
+		"l":   []interface{}{"a", "b"},
+		"m":   map[string]interface{}{"x": 1},
+	}
+
+	patch := []interface{}{
+		map[string]interface{}{"op": "add", "path": "/l/1", "value": "z"},
+		map[string]interface{}{"op": "add", "path": "/l/-", "value": "end"},
+		map[string]interface{}{"op": "copy", "from": "/m", "path": "/n"},
+		map[string]interface{}{"op": "move", "from": "/foo", "path": "/m/foo"},
+		map[string]interface{}{"op": "test", "path": "/m/x", "value": 1},
+		map[string]interface{}{"op": "remove", "path": "/n/x"},
+	}
+	out, err := Patch(patch, doc)
+	require.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{
+		"l": []interface{}{"a", "z", "b", "end"},
+		"m": map[string]interface{}{"x": 1, "foo": "bar"},
+		"n": map[string]interface{}{},
+	}, out)
+
+	// the input must not be modified
+	assert.EqualValues(t, map[string]interface{}{
+		"foo": "bar",
+		"l":   []interface{}{"a", "b"},
+		"m":   map[string]interface{}{"x": 1},
+	}, doc)
+
+	testdata := []interface{}{
+		map[string]interface{}{"op": "test", "path": "/foo", "value": "baz"},
+		map[string]interface{}{"op": "replace", "path": "/missing", "value": 1},
+		map[string]interface{}{"op": "remove", "path": "/l/5"},
+		map[string]interface{}{"op": "add", "path": "foo", "value": 1},
+		map[string]interface{}{"op": "move", "from": "/m", "path": "/m/y"},
+		map[string]interface{}{"op": "bogus", "path": "/foo"},
+		map[string]interface{}{"op": "add", "path": "/foo"},
+		"not an op",
+	}
+	for _, d := range testdata {
+		_, err = Patch([]interface{}{d}, doc)
+		assert.Error(t, err, "%v", d)
+	}
+}
+
+func TestPatch_TestNumbers(t *testing.T) {
+	// numbers from different sources (like YAML ints and JSON floats) are
+	// compared by value
+	doc := map[string]interface{}{
+		"i":   1,
+		"f":   2.5,
+		"big": json.Number("12345678901234567890"),
+		"l":   []interface{}{int64(3), map[string]interface{}{"x": uint64(4)}},
+	}
+	patch := []interface{}{
+		map[string]interface{}{"op": "test", "path": "/i", "value": 1.0},
+		map[string]interface{}{"op": "test", "path": "/f", "value": json.Number("2.50")},
+		map[string]interface{}{"op": "test", "path": "/big", "value": uint64(12345678901234567890)},
+		map[string]interface{}{"op": "test", "path": "/l", "value": []interface{}{3.0, map[string]interface{}{"x": 4}}},
+	}
+	_, err := Patch(patch, doc)
+	assert.NoError(t, err)
+
+	for _, v := range []interface{}{1.5, "1", nil, []interface{}{1}} {
+		_, err = Patch([]interface{}{
+			map[string]interface{}{"op": "test", "path": "/i", "value": v},
+		}, doc)
+		assert.Error(t, err, "%v", v)
+	}
+
+	assert.Empty(t, Diff(doc, map[string]interface{}{
+		"i":   1.0,
+		"f":   2.5,
+		"big": json.Number("12345678901234567890"),
+		"l":   []interface{}{3, map[string]interface{}{"x": 4.0}},
+	}))
+}
+
+func TestPatch_MergePatch(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": "e", "f": "g"},
+	}
+	patch := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"f": nil},
+	}
+	out, err := Patch(patch, doc)
+	require.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"d": "e"},
+	}, out)
+
+	_, err = Patch(42, doc)
+	assert.Error(t, err)
+}
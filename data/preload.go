@@ -0,0 +1,100 @@
+package data
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Preload reads the defined sources that haven't been read yet, at most
+// PreloadConcurrency at a time, and caches their data. Templates read sources
+// as they use them, so a template that uses several remote sources (outside
+// of parallel) would otherwise wait for each in turn.
+//
+// Sources that fail to preload aren't cached, so they're read again when
+// they're used, and errors are reported as usual. Merged sources (which are
+// made of other sources), stdin, and directories (which are read with a
+// subpath) aren't preloaded. Preload does nothing when PreloadConcurrency is
+// 0, or when reading from snapshots.
+func (d *Data) Preload(ctx context.Context) {
+	if d == nil || d.PreloadConcurrency <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	if d.snapshots != nil {
+		d.mu.Unlock()
+		return
+	}
+	if d.cache == nil {
+		d.cache = newSourceCache(d.CacheLimit, d.SpillThreshold)
+	}
+
+	aliases := make([]string, 0, len(d.Sources))
+	for alias := range d.Sources {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	sources := []*Source{}
+	for _, alias := range aliases {
+		source, err := d.lookupSource(alias)
+		if err != nil || !preloadable(source) {
+			continue
+		}
+		if _, ok := d.cache.get(source.Alias); ok {
+			continue
+		}
+		sources = append(sources, source)
+	}
+	d.mu.Unlock()
+	if len(sources) == 0 {
+		return
+	}
+
+	log := zerolog.Ctx(ctx)
+
+	sem := make(chan struct{}, d.PreloadConcurrency)
+	wg := sync.WaitGroup{}
+	for _, source := range sources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(source *Source) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// reads of the same source wait for the preloaded data, instead
+			// of reading it at the same time
+			unlock, err := d.lockSource(ctx, source)
+			if err != nil {
+				return
+			}
+			defer unlock()
+			_, err = d.readSource(ctx, source)
+			if err != nil {
+				log.Debug().Err(err).Str("alias", source.Alias).Msg("failed to preload datasource - it'll be read again when used")
+			}
+		}(source)
+	}
+	wg.Wait()
+}
+
+// preloadable - whether the source can be read without a subpath, and
+// without side-effects on other sources
+func preloadable(source *Source) bool {
+	u := source.URL
+	if u == nil {
+		return false
+	}
+	switch u.Scheme {
+	case "merge", "stdin":
+		return false
+	}
+	if u.Opaque != "" {
+		return !strings.HasSuffix(u.Opaque, "/")
+	}
+	return !strings.HasSuffix(u.Path, "/")
+}
@@ -0,0 +1,124 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreload(t *testing.T) {
+	mu := sync.Mutex{}
+	inflight, maxInflight := 0, 0
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+
+		if r.URL.Path == "/fail.json" {
+			http.Error(w, "oops", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", jsonMimetype)
+		w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	mustParse := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		require.NoError(t, err)
+		return u
+	}
+	d := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"a":    {Alias: "a", URL: mustParse(srv.URL + "/a.json")},
+			"b":    {Alias: "b", URL: mustParse(srv.URL + "/b.json")},
+			"c":    {Alias: "c", URL: mustParse(srv.URL + "/c.json")},
+			"d":    {Alias: "d", URL: mustParse(srv.URL + "/d.json")},
+			"fail": {Alias: "fail", URL: mustParse(srv.URL + "/fail.json")},
+			"dir":  {Alias: "dir", URL: mustParse(srv.URL + "/dir/")},
+		},
+		PreloadConcurrency: 3,
+	}
+
+	start := time.Now()
+	d.Preload(context.Background())
+	elapsed := time.Since(start)
+
+	mu.Lock()
+	assert.Equal(t, 3, maxInflight)
+	assert.Equal(t, map[string]int{"/a.json": 1, "/b.json": 1, "/c.json": 1, "/d.json": 1, "/fail.json": 1}, requests)
+	mu.Unlock()
+	// 5 requests, 3 at a time
+	assert.Less(t, elapsed, 200*time.Millisecond)
+
+	// preloaded data is used, so isn't requested again
+	out, err := d.Datasource("b")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"path": "/b.json"}, out)
+
+	// failures are read again when used, and reported then
+	_, err = d.Datasource("fail")
+	assert.Error(t, err)
+
+	// preloading again only reads what hasn't been read
+	d.Preload(context.Background())
+
+	mu.Lock()
+	assert.Equal(t, map[string]int{"/a.json": 1, "/b.json": 1, "/c.json": 1, "/d.json": 1, "/fail.json": 3}, requests)
+	mu.Unlock()
+}
+
+func TestPreload_Disabled(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/a.json")
+	d := &Data{Sources: map[string]*Source{"a": {Alias: "a", URL: u}}}
+	d.Preload(context.Background())
+	assert.Equal(t, 0, requests)
+
+	var nilData *Data
+	nilData.Preload(context.Background())
+}
+
+func TestPreloadable(t *testing.T) {
+	testdata := []struct {
+		u        string
+		expected bool
+	}{
+		{"https://example.com/a.json", true},
+		{"vault:///secret/foo", true},
+		{"env:FOO", true},
+		{"vault:///secret/", false},
+		{"file:///tmp/dir/", false},
+		{"merge:a|b", false},
+		{"stdin:", false},
+	}
+	for _, d := range testdata {
+		u, _ := url.Parse(d.u)
+		assert.Equal(t, d.expected, preloadable(&Source{URL: u}), d.u)
+	}
+}
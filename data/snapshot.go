@@ -0,0 +1,89 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Snapshot - the data read from a datasource, so that it can be used later
+// without reading the datasource again
+type Snapshot struct {
+	Alias     string   `json:"alias"`
+	URL       string   `json:"url"`
+	Args      []string `json:"args,omitempty"`
+	MediaType string   `json:"mediaType,omitempty"`
+	Data      []byte   `json:"data"`
+}
+
+// TakeSnapshot - read the datasource, and return a snapshot of its data
+func (d *Data) TakeSnapshot(alias string, args ...string) (Snapshot, error) {
+	b, mimeType, err := d.readDataSource(d.Ctx, alias, args...)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return Snapshot{
+		Alias:     alias,
+		URL:       d.Sources[alias].URL.String(),
+		Args:      args,
+		MediaType: mimeType,
+		Data:      []byte(b),
+	}, nil
+}
+
+// Parse - parse the snapshot's data according to its media type, as it would
+// be when the datasource is read
+func (s Snapshot) Parse() (interface{}, error) {
+	return parseData(s.MediaType, string(s.Data))
+}
+
+// UseSnapshots - read datasources only from the given snapshots, and never
+// from the datasources themselves. Reading a datasource that has no snapshot
+// (with the same args) is an error. Snapshotted datasources that aren't
+// defined yet are defined with the snapshot's URL.
+func (d *Data) UseSnapshots(snapshots []Snapshot) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.Sources == nil {
+		d.Sources = map[string]*Source{}
+	}
+	d.snapshots = make(map[string]Snapshot, len(snapshots))
+	for _, s := range snapshots {
+		d.snapshots[snapshotKey(s.Alias, s.Args)] = s
+
+		source, ok := d.Sources[s.Alias]
+		if !ok {
+			u, err := url.Parse(s.URL)
+			if err != nil {
+				// it's an error to read it, as it's undefined
+				continue
+			}
+			source = &Source{Alias: s.Alias, URL: u}
+			d.Sources[s.Alias] = source
+		}
+		if len(s.Args) == 0 {
+			source.mediaType = s.MediaType
+		}
+	}
+}
+
+// readSnapshot - must be called with d.mu held
+func (d *Data) readSnapshot(source *Source, args []string) ([]byte, error) {
+	s, ok := d.snapshots[snapshotKey(source.Alias, args)]
+	if !ok {
+		if len(args) > 0 {
+			return nil, errors.Errorf("no snapshot of datasource '%s' with args %q", source.Alias, args)
+		}
+		return nil, errors.Errorf("no snapshot of datasource '%s'", source.Alias)
+	}
+	return s.Data, nil
+}
+
+func snapshotKey(alias string, args []string) string {
+	return alias + "\x00" + strings.Join(args, "\x00")
+}
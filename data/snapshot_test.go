@@ -0,0 +1,69 @@
+package data
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/foo.json", []byte(`{"a": 1}`), 0600)
+
+	d := &Data{
+		Ctx: context.Background(),
+		Sources: map[string]*Source{
+			"foo": {
+				Alias: "foo",
+				URL:   &url.URL{Scheme: "file", Path: "/foo.json"},
+				fs:    fs,
+			},
+		},
+	}
+
+	s, err := d.TakeSnapshot("foo")
+	require.NoError(t, err)
+	assert.Equal(t, Snapshot{
+		Alias:     "foo",
+		URL:       "file:///foo.json",
+		MediaType: jsonMimetype,
+		Data:      []byte(`{"a": 1}`),
+	}, s)
+
+	_, err = d.TakeSnapshot("bogus")
+	assert.Error(t, err)
+
+	// a new Data reads only from the snapshots - even undefined datasources
+	// are defined
+	d = &Data{Ctx: context.Background()}
+	d.UseSnapshots([]Snapshot{
+		s,
+		{Alias: "bar", URL: "https://example.com/bar", MediaType: yamlMimetype, Data: []byte("b: 2")},
+		{Alias: "bar", URL: "https://example.com/bar", Args: []string{"sub"}, MediaType: jsonMimetype, Data: []byte(`{"c": 3}`)},
+	})
+
+	out, err := d.Datasource("foo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, out)
+
+	out, err = d.Datasource("bar")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"b": 2}, out)
+
+	out, err = d.Datasource("bar", "sub")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"c": 3}, out)
+
+	_, err = d.Datasource("bar", "other")
+	assert.ErrorContains(t, err, `no snapshot of datasource 'bar' with args ["other"]`)
+
+	_, err = d.DefineDatasource("baz", "https://example.com/baz")
+	require.NoError(t, err)
+	_, err = d.Datasource("baz")
+	assert.ErrorContains(t, err, "no snapshot of datasource 'baz'")
+	assert.False(t, d.DatasourceReachable("baz"))
+}
@@ -0,0 +1,402 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// streamBuffer - how many items are decoded ahead of the template
+const streamBuffer = 64
+
+// stream - a datasource being streamed. The producer goroutine decodes items
+// from the reader until it's done, or until the stream is closed.
+type stream struct {
+	done     chan struct{}
+	finished chan struct{}
+	closeMu  sync.Once
+	// err is set by the producer before finished is closed
+	err error
+}
+
+func (s *stream) close() error {
+	s.closeMu.Do(func() { close(s.done) })
+	<-s.finished
+	return s.err
+}
+
+// streamSet - streams in progress, which are closed together
+type streamSet struct {
+	mu      sync.Mutex
+	streams []*stream
+}
+
+func (ss *streamSet) add(s *stream) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.streams = append(ss.streams, s)
+}
+
+// close - stops the streams in the set, and returns the first error from any
+// of them
+func (ss *streamSet) close() error {
+	ss.mu.Lock()
+	streams := ss.streams
+	ss.streams = nil
+	ss.mu.Unlock()
+
+	var err error
+	for _, s := range streams {
+		if serr := s.close(); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+type streamsCtxKey struct{}
+
+// ContextWithStreams - a context in which streams opened with StreamContext
+// are tracked on their own, so that renders running at the same time (with the
+// same Data) don't close each other's streams. They're closed with
+// CloseStreamsContext.
+func ContextWithStreams(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamsCtxKey{}, &streamSet{})
+}
+
+// streamsFromContext - the streams tracked in ctx, or d's own when there are
+// none
+func (d *Data) streamsFromContext(ctx context.Context) *streamSet {
+	if ctx != nil {
+		if ss, ok := ctx.Value(streamsCtxKey{}).(*streamSet); ok {
+			return ss
+		}
+	}
+	return &d.streams
+}
+
+// Stream - reads the datasource, and returns a channel that yields its items
+// one at a time, so that very large datasources can be iterated over (with
+// range) without holding all of their data in memory at once.
+//
+// JSON arrays yield each element, NDJSON (or any sequence of JSON values)
+// yields each value, and CSV yields each row (after the header) as a map of
+// column names to values. Other types are read in full, and then yield each
+// element if they're arrays, or else the whole value.
+//
+// Only file and stdin datasources are streamed directly from their source -
+// other datasources are read (and cached) first.
+//
+// Errors partway through the data close the channel early, and are returned
+// by CloseStreams.
+func (d *Data) Stream(alias string, args ...string) (<-chan interface{}, error) {
+	return d.StreamContext(d.Ctx, alias, args...)
+}
+
+// StreamContext - like Stream, but when ctx has its own streams (see
+// ContextWithStreams), the stream is tracked there, and closed (and its errors
+// returned) by CloseStreamsContext instead of CloseStreams
+func (d *Data) StreamContext(ctx context.Context, alias string, args ...string) (<-chan interface{}, error) {
+	d.mu.Lock()
+	source, err := d.lookupSource(alias)
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := d.lockSource(d.Ctx, source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Couldn't read datasource '%s'", alias)
+	}
+	start := time.Now()
+	r, mimeType, err := d.openStream(d.Ctx, source, args...)
+	unlock()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Couldn't read datasource '%s'", alias)
+	}
+
+	// hash the data as it's read, so the read can be recorded once it's
+	// complete
+	h := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, h))
+
+	s := &stream{done: make(chan struct{}), finished: make(chan struct{})}
+	d.streamsFromContext(ctx).add(s)
+
+	out := make(chan interface{}, streamBuffer)
+	emit := func(v interface{}) bool {
+		select {
+		case out <- v:
+			return true
+		case <-s.done:
+			return false
+		}
+	}
+	go func() {
+		defer close(s.finished)
+		defer close(out)
+		defer r.Close()
+
+		complete, err := decodeStream(br, mimeType, emit)
+		if err != nil {
+			s.err = errors.Wrapf(err, "Couldn't stream datasource '%s'", alias)
+			return
+		}
+		if complete {
+			d.recordStreamRead(source, args, h, time.Since(start))
+		}
+	}()
+	return out, nil
+}
+
+// CloseStreams - stops any streams that are still in progress, and returns
+// the first error from any of the streams since the last call. Streams tracked
+// in a context (see ContextWithStreams) aren't closed.
+func (d *Data) CloseStreams() error {
+	return d.streams.close()
+}
+
+// CloseStreamsContext - like CloseStreams, but for the streams tracked in ctx
+// (see ContextWithStreams)
+func (d *Data) CloseStreamsContext(ctx context.Context) error {
+	return d.streamsFromContext(ctx).close()
+}
+
+func (d *Data) recordStreamRead(source *Source, args []string, h hash.Hash, dur time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recordDigest(source, args, "sha256:"+hex.EncodeToString(h.Sum(nil)), dur)
+}
+
+// openStream - must be called with the source locked (see lockSource), and
+// without d.mu held. File and stdin sources are opened directly, unless their
+// data needs to be checked (or replayed from a snapshot) first, in which case
+// (as with other sources) the data is read in full.
+func (d *Data) openStream(ctx context.Context, source *Source, args ...string) (io.ReadCloser, string, error) {
+	subpath := ""
+	if len(args) > 0 {
+		subpath = args[0]
+	}
+
+	// data that's already cached doesn't need to be read again
+	d.mu.Lock()
+	cached := false
+	if d.cache != nil {
+		_, cached = d.cache.get(source.Alias + strings.Join(args, ""))
+	}
+	snapshots := d.snapshots != nil
+	d.mu.Unlock()
+
+	if !cached && !snapshots && d.Verifier == nil && source.Integrity == "" {
+		if err := d.NetworkPolicy.CheckURL(source.URL); err != nil {
+			return nil, "", errors.Wrapf(err, "can't read datasource '%s'", source.Alias)
+		}
+		switch source.URL.Scheme {
+		case "file":
+			p, err := filePath(source, args...)
+			if err != nil {
+				return nil, "", err
+			}
+			if strings.HasSuffix(p, string(filepath.Separator)) {
+				break
+			}
+			f, err := source.fs.OpenFile(p, os.O_RDONLY, 0)
+			if err != nil {
+				return nil, "", errors.Wrapf(err, "Can't open %s", p)
+			}
+			mimeType, err := source.mimeType(subpath)
+			if err != nil {
+				f.Close()
+				return nil, "", err
+			}
+			return f, mimeType, nil
+		case "stdin":
+			mimeType, err := source.mimeType(subpath)
+			if err != nil {
+				return nil, "", err
+			}
+			return ioutil.NopCloser(stdinFromContext(ctx)), mimeType, nil
+		}
+	}
+
+	b, err := d.readSource(ctx, source, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	mimeType, err := source.mimeType(subpath)
+	if err != nil {
+		return nil, "", err
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), mimeType, nil
+}
+
+// decodeStream decodes items from the reader, calling emit for each, until
+// the data ends (complete is true) or emit returns false
+func decodeStream(r *bufio.Reader, mimeType string, emit func(interface{}) bool) (complete bool, err error) {
+	switch mimeAlias(mimeType) {
+	case jsonMimetype, jsonArrayMimetype, ndjsonMimetype:
+		return decodeJSONStream(r, emit)
+	case csvMimetype:
+		return decodeCSVStream(r, emit)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+	v, err := parseData(mimeType, string(b))
+	if err != nil {
+		return false, err
+	}
+	if l, ok := v.([]interface{}); ok {
+		for _, item := range l {
+			if !emit(item) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return emit(v), nil
+}
+
+// decodeJSONStream decodes the elements of a JSON array, or else a sequence
+// of JSON values (like NDJSON)
+func decodeJSONStream(r *bufio.Reader, emit func(interface{}) bool) (bool, error) {
+	dec := json.NewDecoder(r)
+	isArray, err := startsWith(r, '[')
+	if err != nil {
+		return false, err
+	}
+	if isArray {
+		// consume the opening bracket
+		if _, err := dec.Token(); err != nil {
+			return false, err
+		}
+	}
+
+	for {
+		if isArray && !dec.More() {
+			break
+		}
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF && !isArray {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		v, err := jsonValue(raw)
+		if err != nil {
+			return false, err
+		}
+		if !emit(v) {
+			return false, nil
+		}
+	}
+
+	if isArray {
+		// consume the closing bracket
+		if _, err := dec.Token(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// decodeCSVStream decodes each row after the header as a map of column names
+// to values
+func decodeCSVStream(r io.Reader, emit func(interface{}) bool) (bool, error) {
+	c := csv.NewReader(r)
+	hdr, err := c.Read()
+	if err == io.EOF {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for {
+		record, err := c.Read()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		row := make(map[string]string, len(record))
+		for i, v := range record {
+			row[hdr[i]] = v
+		}
+		if !emit(row) {
+			return false, nil
+		}
+	}
+}
+
+// ndJSON parses a sequence of JSON values (like NDJSON) into an array
+func ndJSON(r io.Reader) ([]interface{}, error) {
+	out := []interface{}{}
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := jsonValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
+
+// jsonValue parses a single JSON value the same way as JSON and JSONArray
+// do, so that numbers are handled consistently
+func jsonValue(raw json.RawMessage) (interface{}, error) {
+	switch bytes.TrimSpace(raw)[0] {
+	case '{':
+		return JSON(string(raw))
+	case '[':
+		return JSONArray(string(raw))
+	}
+	var v interface{}
+	err := yamlUnmarshal(raw, &v)
+	return v, err
+}
+
+// startsWith reports whether the first non-whitespace byte is c, discarding
+// any whitespace before it
+func startsWith(r *bufio.Reader, c byte) (bool, error) {
+	for {
+		b, err := r.Peek(1)
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.Discard(1)
+			continue
+		}
+		return b[0] == c, nil
+	}
+}
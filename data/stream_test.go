@@ -0,0 +1,158 @@
+package data
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(ch <-chan interface{}) []interface{} {
+	out := []interface{}{}
+	for v := range ch {
+		out = append(out, v)
+	}
+	return out
+}
+
+func streamTestData(t *testing.T, files map[string]string) *Data {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	d := &Data{Ctx: context.Background(), Sources: map[string]*Source{}}
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fs, "/"+name, []byte(content), 0o644))
+		alias := strings.TrimSuffix(name, "."+strings.SplitN(name, ".", 2)[1])
+		d.Sources[alias] = &Source{Alias: alias, URL: mustParseURL("file:///" + name), fs: fs}
+	}
+	return d
+}
+
+func TestStream(t *testing.T) {
+	d := streamTestData(t, map[string]string{
+		"arr.json":   ` [{"a": 1}, {"a": 2.5}, "three", [4]] `,
+		"obj.json":   `{"a": 1}`,
+		"rows.jsonl": "{\"n\": 1}\n{\"n\": 2}\n\n{\"n\": 3}\n",
+		"table.csv":  "name,size\nfoo,1\nbar,2\n",
+		"list.yaml":  "- one\n- two\n",
+		"bad.json":   `[{"a": 1}, {"a": `,
+	})
+
+	ch, err := d.Stream("arr")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": 1},
+		map[string]interface{}{"a": 2.5},
+		"three",
+		[]interface{}{4},
+	}, collect(ch))
+
+	ch, err = d.Stream("obj")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"a": 1}}, collect(ch))
+
+	ch, err = d.Stream("rows")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"n": 1},
+		map[string]interface{}{"n": 2},
+		map[string]interface{}{"n": 3},
+	}, collect(ch))
+
+	ch, err = d.Stream("table")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]string{"name": "foo", "size": "1"},
+		map[string]string{"name": "bar", "size": "2"},
+	}, collect(ch))
+
+	ch, err = d.Stream("list")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"one", "two"}, collect(ch))
+	assert.NoError(t, d.CloseStreams())
+
+	ch, err = d.Stream("bad")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"a": 1}}, collect(ch))
+	assert.Error(t, d.CloseStreams())
+	// errors are only reported once
+	assert.NoError(t, d.CloseStreams())
+
+	_, err = d.Stream("missing")
+	assert.Error(t, err)
+	d.Sources["nofile"] = &Source{Alias: "nofile", URL: mustParseURL("file:///nofile.json"), fs: d.Sources["arr"].fs}
+	_, err = d.Stream("nofile")
+	assert.Error(t, err)
+}
+
+func TestStreamClose(t *testing.T) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = "{}"
+	}
+	d := streamTestData(t, map[string]string{
+		"big.json": "[" + strings.Join(items, ",") + "]",
+	})
+
+	ch, err := d.Stream("big")
+	require.NoError(t, err)
+	<-ch
+
+	// closing an unfinished stream stops it without an error
+	assert.NoError(t, d.CloseStreams())
+	n := 0
+	for range ch {
+		n++
+	}
+	assert.Less(t, n, 999)
+}
+
+func TestStreamContext(t *testing.T) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = "{}"
+	}
+	d := streamTestData(t, map[string]string{
+		"big.json": "[" + strings.Join(items, ",") + "]",
+	})
+
+	// streams tracked in different contexts are closed separately
+	ctx1 := ContextWithStreams(context.Background())
+	ctx2 := ContextWithStreams(context.Background())
+	ch1, err := d.StreamContext(ctx1, "big")
+	require.NoError(t, err)
+	ch2, err := d.StreamContext(ctx2, "big")
+	require.NoError(t, err)
+
+	assert.NoError(t, d.CloseStreams())
+	assert.NoError(t, d.CloseStreamsContext(ctx1))
+	assert.Len(t, collect(ch2), 1000)
+	assert.Less(t, len(collect(ch1)), 1000)
+	assert.NoError(t, d.CloseStreamsContext(ctx2))
+}
+
+func TestStreamRecordsReads(t *testing.T) {
+	d := streamTestData(t, map[string]string{
+		"rows.jsonl": "{\"n\": 1}\n{\"n\": 2}\n",
+	})
+
+	stop := d.RecordReads()
+	ch, err := d.Stream("rows")
+	require.NoError(t, err)
+	collect(ch)
+	require.NoError(t, d.CloseStreams())
+	reads := stop()
+	require.Len(t, reads, 1)
+	assert.Equal(t, digest([]byte("{\"n\": 1}\n{\"n\": 2}\n")), reads[0].Digest)
+}
+
+func TestNDJSON(t *testing.T) {
+	out, err := parseData(ndjsonMimetype, "{\"a\": 1}\n[2]\n\"x\"\n")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"a": 1}, []interface{}{2}, "x"}, out)
+
+	_, err = parseData(ndjsonMimetype, "{\"a\": ")
+	assert.Error(t, err)
+}
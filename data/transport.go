@@ -0,0 +1,74 @@
+package data
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/credhelper"
+)
+
+type transportCtxKey struct{}
+
+// Transport returns the HTTP transport for datasources with the given scheme.
+// It's created once and shared by all reads, so that connections are kept
+// alive and reused - even when many templates are rendered at once - and so
+// that the network policy, connection limit, and rate limits apply to all of
+// them together.
+func (d *Data) Transport(scheme string) http.RoundTripper {
+	d.transportsMu.Lock()
+	defer d.transportsMu.Unlock()
+
+	if rt, ok := d.transports[scheme]; ok {
+		return rt
+	}
+
+	rt := d.NetworkPolicy.Transport(scheme)
+	if t, ok := rt.(*http.Transport); ok {
+		if t == http.DefaultTransport {
+			t = t.Clone()
+		}
+		// the default of 2 idle connections per host means that concurrent
+		// reads from the same host mostly open new connections
+		if t.MaxIdleConns > t.MaxIdleConnsPerHost {
+			t.MaxIdleConnsPerHost = t.MaxIdleConns
+		}
+		t.MaxConnsPerHost = d.MaxConnsPerHost
+		rt = t
+	}
+	rt = d.RateLimiter.Transport(rt)
+
+	if d.transports == nil {
+		d.transports = map[string]http.RoundTripper{}
+	}
+	d.transports[scheme] = rt
+	return rt
+}
+
+// contextWithTransports - so that datasource readers can use the shared
+// transports, and the connection and rate limits
+func contextWithTransports(ctx context.Context, d *Data) context.Context {
+	return context.WithValue(ctx, transportCtxKey{}, d)
+}
+
+func transportsFromContext(ctx context.Context) *Data {
+	d, _ := ctx.Value(transportCtxKey{}).(*Data)
+	return d
+}
+
+// credentials returns the credentials for the host from the credential
+// helpers in the context, or nil if there are none
+func credentials(ctx context.Context, protocol, host string) (*credhelper.Credentials, error) {
+	if d := transportsFromContext(ctx); d != nil {
+		return d.CredentialHelpers.Get(ctx, protocol, host)
+	}
+	return nil, nil
+}
+
+// transportFromContext returns the shared transport for the scheme, or
+// http.DefaultTransport when there's none in the context
+func transportFromContext(ctx context.Context, scheme string) http.RoundTripper {
+	if d := transportsFromContext(ctx); d != nil {
+		return d.Transport(scheme)
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	d := &Data{MaxConnsPerHost: 4}
+
+	rt := d.Transport("https")
+	assert.Same(t, rt, d.Transport("https"))
+	assert.NotSame(t, rt, d.Transport("http"))
+	assert.NotSame(t, http.DefaultTransport, rt)
+
+	tr, ok := rt.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 4, tr.MaxConnsPerHost)
+	assert.Equal(t, 100, tr.MaxIdleConnsPerHost)
+
+	assert.Equal(t, http.DefaultTransport, transportFromContext(context.Background(), "https"))
+	assert.Same(t, rt, transportFromContext(contextWithTransports(context.Background(), d), "https"))
+}
+
+func TestReadHTTPSharedConnections(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", jsonMimetype)
+		w.Write([]byte(`{}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/")
+	d := &Data{
+		Ctx:             context.Background(),
+		Sources:         map[string]*Source{"foo": {Alias: "foo", URL: u}},
+		MaxConnsPerHost: 2,
+		RateLimiter:     ratelimit.New([]config.RateLimit{{Host: "127.0.0.1", Rate: 1000, Burst: 10}}),
+	}
+
+	// concurrent reads of different paths, as in a matrix render
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := d.Datasource("foo", string(rune('a'+i))+".json")
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&conns), int32(2))
+}
@@ -0,0 +1,257 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+)
+
+// ToXLSX - produce an Office Open XML spreadsheet (.xlsx) with one worksheet
+// for each key in the given map, ordered by name. Each worksheet's value must
+// be a list of rows, where each row is either a list of cells, or a map (in
+// which case a header row is added, with one column per key). A plain list of
+// rows is also accepted, and produces a single worksheet named "Sheet1".
+//
+// The output is binary, and so should be written to a file.
+func ToXLSX(in interface{}) (string, error) {
+	sheets := map[string]interface{}{}
+	switch v := in.(type) {
+	case map[string]interface{}:
+		sheets = v
+	default:
+		sheets["Sheet1"] = v
+	}
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("at least one worksheet is required")
+	}
+
+	names := make([]string, 0, len(sheets))
+	for k := range sheets {
+		if err := validSheetName(k); err != nil {
+			return "", err
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	b := &bytes.Buffer{}
+	z := zip.NewWriter(b)
+
+	files := []struct {
+		name, content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes(len(names))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook(names)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(names))},
+	}
+	for _, f := range files {
+		if err := writeZipFile(z, f.name, strings.NewReader(f.content)); err != nil {
+			return "", err
+		}
+	}
+
+	for i, n := range names {
+		rows, err := xlsxRows(sheets[n])
+		if err != nil {
+			return "", fmt.Errorf("worksheet %q: %w", n, err)
+		}
+		err = writeZipFile(z, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), strings.NewReader(xlsxSheet(rows)))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func validSheetName(name string) error {
+	if name == "" || len([]rune(name)) > 31 {
+		return fmt.Errorf("invalid worksheet name %q: must be between 1 and 31 characters", name)
+	}
+	if strings.ContainsAny(name, `[]:*?/\`) {
+		return fmt.Errorf("invalid worksheet name %q: must not contain any of []:*?/\\", name)
+	}
+	return nil
+}
+
+// writeZipFile adds a file to the archive with a fixed modification time, so
+// that output is reproducible
+func writeZipFile(z *zip.Writer, name string, r io.Reader) error {
+	w, err := z.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// xlsxRows converts the worksheet value into rows of cells
+func xlsxRows(in interface{}) ([][]interface{}, error) {
+	l, err := iconv.InterfaceSlice(in)
+	if err != nil {
+		return nil, fmt.Errorf("must be a list of rows: %w", err)
+	}
+
+	// a list of maps gets a header row
+	if len(l) > 0 {
+		if _, ok := l[0].(map[string]interface{}); ok {
+			return xlsxMapRows(l)
+		}
+	}
+
+	rows := make([][]interface{}, len(l))
+	for i, r := range l {
+		rows[i], err = iconv.InterfaceSlice(r)
+		if err != nil {
+			return nil, fmt.Errorf("row %d must be a list of cells: %w", i, err)
+		}
+	}
+	return rows, nil
+}
+
+func xlsxMapRows(l []interface{}) ([][]interface{}, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+	for i, r := range l {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("row %d must be a map, like all other rows (got %T)", i, r)
+		}
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	rows := make([][]interface{}, 0, len(l)+1)
+	hdr := make([]interface{}, len(keys))
+	for i, k := range keys {
+		hdr[i] = k
+	}
+	rows = append(rows, hdr)
+	for _, r := range l {
+		m := r.(map[string]interface{})
+		row := make([]interface{}, len(keys))
+		for i, k := range keys {
+			row[i] = m[k]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// xlsxColumn returns the column name (A, B, ..., Z, AA, ...) for the given
+// zero-based index
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	b := &strings.Builder{}
+	_ = xml.EscapeText(b, []byte(s))
+	return b.String()
+}
+
+func xlsxSheet(rows [][]interface{}) string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(b, `<row r="%d">`, i+1)
+		for j, v := range row {
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			switch c := v.(type) {
+			case nil:
+				continue
+			case bool:
+				n := 0
+				if c {
+					n = 1
+				}
+				fmt.Fprintf(b, `<c r="%s" t="b"><v>%d</v></c>`, ref, n)
+			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+				// Excel can't represent NaN or infinity, and refuses to open
+				// workbooks with them as numbers, so they're written as text
+				if f := conv.ToFloat64(c); math.IsNaN(f) || math.IsInf(f, 0) {
+					xlsxInlineStr(b, ref, conv.ToString(c))
+					continue
+				}
+				fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, conv.ToString(c))
+			default:
+				xlsxInlineStr(b, ref, conv.ToString(c))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+func xlsxInlineStr(b *strings.Builder, ref, s string) {
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(s))
+}
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func xlsxContentTypes(n int) string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func xlsxWorkbook(names []string) string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, n := range names {
+		fmt.Fprintf(b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(n), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func xlsxWorkbookRels(n int) string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"archive/zip"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readZip(t *testing.T, s string) map[string]string {
+	t.Helper()
+	z, err := zip.NewReader(strings.NewReader(s), int64(len(s)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(b)
+	}
+	return files
+}
+
+func TestToXLSX(t *testing.T) {
+	out, err := ToXLSX(map[string]interface{}{
+		"people": []interface{}{
+			map[string]interface{}{"name": "Jo & Al", "age": 42},
+			map[string]interface{}{"name": "Sam", "active": true},
+		},
+		"grid": [][]interface{}{{"a", 1.5}, {nil, "b"}},
+	})
+	require.NoError(t, err)
+
+	files := readZip(t, out)
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "_rels/.rels")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="grid" sheetId="1" r:id="rId1"/><sheet name="people" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"],
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">a</t></is></c><c r="B1"><v>1.5</v></c></row>`+
+			`<row r="2"><c r="B2" t="inlineStr"><is><t xml:space="preserve">b</t></is></c></row>`)
+	assert.Contains(t, files["xl/worksheets/sheet2.xml"],
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">active</t></is></c>`)
+	assert.Contains(t, files["xl/worksheets/sheet2.xml"],
+		`<row r="2"><c r="B2"><v>42</v></c><c r="C2" t="inlineStr"><is><t xml:space="preserve">Jo &amp; Al</t></is></c></row>`+
+			`<row r="3"><c r="A3" t="b"><v>1</v></c>`)
+
+	// output is reproducible
+	out2, err := ToXLSX(map[string]interface{}{
+		"people": []interface{}{
+			map[string]interface{}{"name": "Jo & Al", "age": 42},
+			map[string]interface{}{"name": "Sam", "active": true},
+		},
+		"grid": [][]interface{}{{"a", 1.5}, {nil, "b"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, out, out2)
+
+	// a plain list of rows
+	out, err = ToXLSX([]interface{}{[]interface{}{"x"}})
+	require.NoError(t, err)
+	assert.Contains(t, readZip(t, out)["xl/workbook.xml"], `name="Sheet1"`)
+
+	// NaN and infinities aren't valid numbers in a workbook
+	out, err = ToXLSX([]interface{}{[]interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1))}})
+	require.NoError(t, err)
+	assert.Contains(t, readZip(t, out)["xl/worksheets/sheet1.xml"],
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">NaN</t></is></c>`+
+			`<c r="B1" t="inlineStr"><is><t xml:space="preserve">+Inf</t></is></c>`+
+			`<c r="C1" t="inlineStr"><is><t xml:space="preserve">-Inf</t></is></c></row>`)
+
+	_, err = ToXLSX(map[string]interface{}{})
+	assert.Error(t, err)
+	_, err = ToXLSX(map[string]interface{}{"a/b": []interface{}{}})
+	assert.Error(t, err)
+	_, err = ToXLSX(map[string]interface{}{"a": "not rows"})
+	assert.Error(t, err)
+	_, err = ToXLSX(map[string]interface{}{"a": []interface{}{map[string]interface{}{}, 1}})
+	assert.Error(t, err)
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, c := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, c, xlsxColumn(i))
+	}
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+)
+
+// Keys used for XML attributes and text, when an element is converted to a map
+const (
+	xmlAttrPrefix = "@"
+	xmlTextKey    = "#text"
+)
+
+// XML - Unmarshal an XML document into a map. The root element is the map's
+// only key. Each element becomes a map of its attributes (with keys prefixed
+// by "@") and child elements, with any text in the "#text" key - or just a
+// string, when it has only text. Repeated child elements become arrays.
+// Namespace prefixes are dropped, and all values are strings.
+func XML(in string) (map[string]interface{}, error) {
+	dec := xml.NewDecoder(strings.NewReader(in))
+	dec.Strict = true
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("unable to unmarshal XML: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal XML: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			v, err := xmlElement(dec, start)
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshal XML: %w", err)
+			}
+			return map[string]interface{}{start.Name.Local: v}, nil
+		}
+	}
+}
+
+// xmlElement converts the element, whose start tag has just been read
+func xmlElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	m := map[string]interface{}{}
+	for _, a := range start.Attr {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			continue
+		}
+		m[xmlAttrPrefix+a.Name.Local] = a.Value
+	}
+
+	text := &strings.Builder{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := xmlElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			k := t.Name.Local
+			switch existing := m[k].(type) {
+			case nil:
+				m[k] = v
+			case []interface{}:
+				m[k] = append(existing, v)
+			default:
+				m[k] = []interface{}{existing, v}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(m) == 0 {
+				return s, nil
+			}
+			if s != "" {
+				m[xmlTextKey] = s
+			}
+			return m, nil
+		}
+	}
+}
+
+// ToXML - marshal an object (in the form produced by XML) as an XML document.
+// The object must have a single key, which is the root element's name.
+func ToXML(in interface{}) (string, error) {
+	m, ok := in.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", fmt.Errorf("unable to marshal XML: input must be a map with a single key (the root element), got %T", in)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	for k, v := range m {
+		if err := xmlEncode(enc, k, v); err != nil {
+			return "", fmt.Errorf("unable to marshal XML: %w", err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", fmt.Errorf("unable to marshal XML: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.String(), nil
+}
+
+func xmlEncode(enc *xml.Encoder, name string, v interface{}) error {
+	if name == "" || strings.HasPrefix(name, xmlAttrPrefix) || name == xmlTextKey {
+		return fmt.Errorf("invalid element name %q", name)
+	}
+
+	// repeated elements
+	if a, ok := v.([]interface{}); ok {
+		for _, item := range a {
+			if err := xmlEncode(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if v != nil {
+			if err := enc.EncodeToken(xml.CharData(conv.ToString(v))); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	children := []string{}
+	for _, k := range keys {
+		if strings.HasPrefix(k, xmlAttrPrefix) {
+			if k == xmlAttrPrefix {
+				return fmt.Errorf("invalid attribute name %q in element %q", k, name)
+			}
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: strings.TrimPrefix(k, xmlAttrPrefix)}, Value: conv.ToString(m[k])})
+		} else if k != xmlTextKey {
+			children = append(children, k)
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if text, ok := m[xmlTextKey]; ok && text != nil {
+		if err := enc.EncodeToken(xml.CharData(conv.ToString(text))); err != nil {
+			return err
+		}
+	}
+	for _, k := range children {
+		if err := xmlEncode(enc, k, m[k]); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXML(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- servers -->
+<config xmlns="urn:example" xmlns:x="urn:x" version="2">
+  <name>app</name>
+  <server host="a.example.com" port="80"/>
+  <server host="b.example.com">primary</server>
+  <x:debug/>
+  <note lang="en">
+    hello <b>there</b>
+  </note>
+</config>
+`
+	out, err := XML(in)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"config": map[string]interface{}{
+			"@version": "2",
+			"name":     "app",
+			"server": []interface{}{
+				map[string]interface{}{"@host": "a.example.com", "@port": "80"},
+				map[string]interface{}{"@host": "b.example.com", "#text": "primary"},
+			},
+			"debug": "",
+			"note":  map[string]interface{}{"@lang": "en", "b": "there", "#text": "hello"},
+		},
+	}, out)
+
+	out, err = XML(`<a>b</a>`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "b"}, out)
+
+	_, err = XML(`<a><b></a>`)
+	assert.Error(t, err)
+
+	_, err = XML(`<!-- nothing -->`)
+	assert.ErrorContains(t, err, "no root element")
+}
+
+func TestToXML(t *testing.T) {
+	in := map[string]interface{}{
+		"config": map[string]interface{}{
+			"@version": 2,
+			"name":     "a & b",
+			"server": []interface{}{
+				map[string]interface{}{"@host": "a.example.com", "@port": 80},
+				map[string]interface{}{"@host": "b.example.com", "#text": "primary"},
+			},
+			"debug": nil,
+			"tls":   true,
+		},
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<config version="2">
+  <debug></debug>
+  <name>a &amp; b</name>
+  <server host="a.example.com" port="80"></server>
+  <server host="b.example.com">primary</server>
+  <tls>true</tls>
+</config>
+`
+	out, err := ToXML(in)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	// round-trips
+	parsed, err := XML(out)
+	require.NoError(t, err)
+	out2, err := ToXML(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out2)
+
+	_, err = ToXML(map[string]interface{}{"a": 1, "b": 2})
+	assert.ErrorContains(t, err, "single key")
+
+	_, err = ToXML([]interface{}{"a"})
+	assert.Error(t, err)
+
+	_, err = ToXML(map[string]interface{}{"a": map[string]interface{}{"#text": "y", "": "z"}})
+	assert.ErrorContains(t, err, `invalid element name ""`)
+
+	_, err = ToXML(map[string]interface{}{"a": map[string]interface{}{"@": "x"}})
+	assert.ErrorContains(t, err, `invalid attribute name "@"`)
+}
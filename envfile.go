@@ -0,0 +1,65 @@
+package gomplate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/afero"
+)
+
+// loadEnvFiles sets environment variables from the given dotenv files, so
+// they're visible to env.Getenv, the env datasource, and any commands run
+// by gomplate. When a variable is set in more than one file, the first file
+// wins. Variables already in the environment are overridden, unless
+// noOverride is set.
+//
+// The returned function restores the environment to its previous state.
+func loadEnvFiles(files []string, noOverride bool) (restore func(), err error) {
+	vars := map[string]string{}
+	order := []string{}
+	for _, f := range files {
+		b, err := afero.ReadFile(aferoFS, f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+		env, err := godotenv.Unmarshal(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file %s: %w", f, err)
+		}
+		for k, v := range env {
+			if _, ok := vars[k]; !ok {
+				vars[k] = v
+				order = append(order, k)
+			}
+		}
+	}
+
+	type prev struct {
+		value string
+		set   bool
+	}
+	saved := map[string]prev{}
+	restore = func() {
+		for k, p := range saved {
+			if p.set {
+				_ = os.Setenv(k, p.value)
+			} else {
+				_ = os.Unsetenv(k)
+			}
+		}
+	}
+
+	for _, k := range order {
+		old, set := os.LookupEnv(k)
+		if set && noOverride {
+			continue
+		}
+		saved[k] = prev{old, set}
+		if err := os.Setenv(k, vars[k]); err != nil {
+			restore()
+			return nil, fmt.Errorf("failed to set %s from env file: %w", k, err)
+		}
+	}
+	return restore, nil
+}
@@ -0,0 +1,48 @@
+package gomplate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvFiles(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+	_ = afero.WriteFile(aferoFS, ".env.local", []byte("GOMPLATE_TEST_A=local\n"), 0o600)
+	_ = afero.WriteFile(aferoFS, ".env", []byte("GOMPLATE_TEST_A=base\nGOMPLATE_TEST_B='b value'\nGOMPLATE_TEST_C=file\n"), 0o600)
+	_ = afero.WriteFile(aferoFS, "bad.env", []byte("'unterminated\n"), 0o600)
+
+	t.Setenv("GOMPLATE_TEST_C", "process")
+	os.Unsetenv("GOMPLATE_TEST_A")
+	os.Unsetenv("GOMPLATE_TEST_B")
+
+	restore, err := loadEnvFiles([]string{".env.local", ".env"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "local", os.Getenv("GOMPLATE_TEST_A"))
+	assert.Equal(t, "b value", os.Getenv("GOMPLATE_TEST_B"))
+	assert.Equal(t, "file", os.Getenv("GOMPLATE_TEST_C"))
+
+	restore()
+	_, set := os.LookupEnv("GOMPLATE_TEST_A")
+	assert.False(t, set)
+	_, set = os.LookupEnv("GOMPLATE_TEST_B")
+	assert.False(t, set)
+	assert.Equal(t, "process", os.Getenv("GOMPLATE_TEST_C"))
+
+	restore, err = loadEnvFiles([]string{".env"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, "base", os.Getenv("GOMPLATE_TEST_A"))
+	assert.Equal(t, "process", os.Getenv("GOMPLATE_TEST_C"))
+	restore()
+
+	_, err = loadEnvFiles([]string{"missing.env"}, false)
+	assert.Error(t, err)
+
+	_, err = loadEnvFiles([]string{"bad.env"}, false)
+	assert.ErrorContains(t, err, "failed to parse env file bad.env")
+}
@@ -0,0 +1,257 @@
+package gomplate
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// EventOptions - options for ServeEvents
+type EventOptions struct {
+	// Addr - the address to listen on. Defaults to ":8080".
+	Addr string
+	// Secret - when set, requests must be signed with it, in the
+	// X-Hub-Signature-256 header (as GitHub webhooks are)
+	Secret string
+	// Types - when set, only events of these types trigger renders
+	Types []string
+	// AfterRender - called after each successful render, with the config it
+	// was rendered with (for example, to run a post-exec command)
+	AfterRender func(ctx context.Context, cfg *config.Config) error
+}
+
+// event - a received CloudEvent or webhook, available to templates as .Event
+type event struct {
+	// ID, Type, Source, Subject, and Time - the CloudEvent's attributes. For
+	// GitHub and GitLab webhooks, Type is the event name, and ID the
+	// delivery ID.
+	ID      string
+	Type    string
+	Source  string
+	Subject string
+	Time    string
+	// Data - the event's payload, parsed when it's JSON
+	Data interface{}
+	// Headers - the request's HTTP headers
+	Headers http.Header
+}
+
+type eventCtxKey struct{}
+
+func contextWithEvent(ctx context.Context, ev *event) context.Context {
+	return context.WithValue(ctx, eventCtxKey{}, ev)
+}
+
+func eventFromContext(ctx context.Context) *event {
+	ev, _ := ctx.Value(eventCtxKey{}).(*event)
+	return ev
+}
+
+// ServeEvents listens for CloudEvents (in binary or structured JSON mode) and
+// generic webhooks, such as those sent by GitHub or Argo Events, and renders
+// the configured templates once for each, with the event available as
+// .Event. Renders happen one at a time, and the response reports whether the
+// render succeeded. It returns when the context is cancelled.
+func ServeEvents(ctx context.Context, cfg *config.Config, o EventOptions) error {
+	if o.Addr == "" {
+		o.Addr = ":8080"
+	}
+
+	srv := &http.Server{
+		Addr:              o.Addr,
+		Handler:           &eventHandler{ctx: ctx, cfg: cfg, opts: o},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+
+	zerolog.Ctx(ctx).Info().Str("addr", o.Addr).Msg("listening for events")
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+type eventHandler struct {
+	ctx  context.Context
+	cfg  *config.Config
+	opts EventOptions
+
+	// renders happen one at a time, so outputs aren't written concurrently
+	mu sync.Mutex
+}
+
+func (h *eventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.opts.Secret != "" && !validSignature(h.opts.Secret, r.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	ev, err := parseEvent(r.Header, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger := zerolog.Ctx(h.ctx).With().Str("eventType", ev.Type).Str("eventID", ev.ID).Logger()
+	if !h.wanted(ev) {
+		logger.Debug().Msg("ignoring event")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.mu.Lock()
+	err = h.render(ev)
+	h.mu.Unlock()
+	if err != nil {
+		logger.Error().Err(err).Msg("render failed")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info().Msg("rendered templates for event")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *eventHandler) wanted(ev *event) bool {
+	if len(h.opts.Types) == 0 {
+		return true
+	}
+	for _, t := range h.opts.Types {
+		if t == ev.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// render runs the templates with a copy of the config, since running
+// modifies it
+func (h *eventHandler) render(ev *event) error {
+	cfg := *h.cfg
+	ctx := contextWithEvent(h.ctx, ev)
+	if err := Run(ctx, &cfg); err != nil {
+		return err
+	}
+	if h.opts.AfterRender != nil {
+		return h.opts.AfterRender(ctx, &cfg)
+	}
+	return nil
+}
+
+// validSignature checks a GitHub-style "sha256=<hex HMAC>" signature
+func validSignature(secret, sig string, body []byte) bool {
+	want, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+	if err != nil || !strings.HasPrefix(sig, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// structuredEvent - a CloudEvent in structured JSON mode
+type structuredEvent struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Source      string          `json:"source"`
+	Subject     string          `json:"subject"`
+	Time        string          `json:"time"`
+	ContentType string          `json:"datacontenttype"`
+	Data        json.RawMessage `json:"data"`
+	DataBase64  string          `json:"data_base64"`
+}
+
+// parseEvent parses a CloudEvent (in binary or structured mode), or any other
+// webhook request
+func parseEvent(h http.Header, body []byte) (*event, error) {
+	ev := &event{Headers: h}
+	ctype := h.Get("Content-Type")
+	mt, _, _ := mime.ParseMediaType(ctype)
+
+	switch {
+	case mt == "application/cloudevents+json":
+		s := structuredEvent{}
+		if err := json.Unmarshal(body, &s); err != nil {
+			return nil, fmt.Errorf("invalid CloudEvent: %w", err)
+		}
+		ev.ID, ev.Type, ev.Source, ev.Subject, ev.Time = s.ID, s.Type, s.Source, s.Subject, s.Time
+
+		if s.DataBase64 != "" {
+			b, err := base64.StdEncoding.DecodeString(s.DataBase64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CloudEvent data_base64: %w", err)
+			}
+			ev.Data = parseEventData(s.ContentType, b)
+		} else if len(s.Data) > 0 {
+			// the data is embedded as JSON - non-JSON data is a JSON string
+			if err := json.Unmarshal(s.Data, &ev.Data); err != nil {
+				return nil, fmt.Errorf("invalid CloudEvent data: %w", err)
+			}
+		}
+	case h.Get("Ce-Specversion") != "":
+		ev.ID = h.Get("Ce-Id")
+		ev.Type = h.Get("Ce-Type")
+		ev.Source = h.Get("Ce-Source")
+		ev.Subject = h.Get("Ce-Subject")
+		ev.Time = h.Get("Ce-Time")
+		ev.Data = parseEventData(ctype, body)
+	default:
+		switch {
+		case h.Get("X-GitHub-Event") != "":
+			ev.Type = h.Get("X-GitHub-Event")
+			ev.ID = h.Get("X-GitHub-Delivery")
+		case h.Get("X-Gitlab-Event") != "":
+			ev.Type = h.Get("X-Gitlab-Event")
+			ev.ID = h.Get("X-Gitlab-Event-UUID")
+		}
+		ev.Data = parseEventData(ctype, body)
+	}
+
+	return ev, nil
+}
+
+// parseEventData parses JSON data, and returns anything else as a string
+func parseEventData(ctype string, data []byte) interface{} {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	mt, _, _ := mime.ParseMediaType(ctype)
+	if mt == "application/json" || strings.HasSuffix(mt, "+json") {
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err == nil {
+			return v
+		}
+	}
+	return string(data)
+}
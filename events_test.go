@@ -0,0 +1,140 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEvent(t *testing.T) {
+	// binary mode
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Ce-Specversion", "1.0")
+	h.Set("Ce-Id", "1")
+	h.Set("Ce-Type", "com.example.config.changed")
+	h.Set("Ce-Source", "/config")
+	ev, err := parseEvent(h, []byte(`{"app": "web"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "1", ev.ID)
+	assert.Equal(t, "com.example.config.changed", ev.Type)
+	assert.Equal(t, "/config", ev.Source)
+	assert.Equal(t, map[string]interface{}{"app": "web"}, ev.Data)
+
+	// structured mode
+	h = http.Header{}
+	h.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+	ev, err = parseEvent(h, []byte(`{"specversion": "1.0", "id": "2", "type": "t", "source": "s",
+		"subject": "sub", "time": "2022-01-01T00:00:00Z", "data": {"app": "api"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "2", ev.ID)
+	assert.Equal(t, "sub", ev.Subject)
+	assert.Equal(t, "2022-01-01T00:00:00Z", ev.Time)
+	assert.Equal(t, map[string]interface{}{"app": "api"}, ev.Data)
+
+	ev, err = parseEvent(h, []byte(`{"id": "3", "datacontenttype": "text/plain", "data": "hello"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", ev.Data)
+
+	ev, err = parseEvent(h, []byte(`{"id": "4", "datacontenttype": "application/json", "data_base64": "eyJhIjoxfQ=="}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1.0}, ev.Data)
+
+	_, err = parseEvent(h, []byte(`not json`))
+	assert.Error(t, err)
+
+	// GitHub webhook
+	h = http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("X-GitHub-Event", "push")
+	h.Set("X-GitHub-Delivery", "abc")
+	ev, err = parseEvent(h, []byte(`{"ref": "refs/heads/main"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "push", ev.Type)
+	assert.Equal(t, "abc", ev.ID)
+	assert.Equal(t, map[string]interface{}{"ref": "refs/heads/main"}, ev.Data)
+
+	// anything else
+	ev, err = parseEvent(http.Header{}, []byte(`plain`))
+	require.NoError(t, err)
+	assert.Equal(t, "", ev.Type)
+	assert.Equal(t, "plain", ev.Data)
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"a": 1}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.True(t, validSignature("s3cret", sig, body))
+	assert.False(t, validSignature("other", sig, body))
+	assert.False(t, validSignature("s3cret", strings.TrimPrefix(sig, "sha256="), body))
+	assert.False(t, validSignature("s3cret", "", body))
+}
+
+func TestEventHandler(t *testing.T) {
+	out := &bytes.Buffer{}
+	rendered := 0
+	h := &eventHandler{
+		ctx: context.Background(),
+		cfg: &config.Config{
+			Input:       `{{ .Event.Type }}: {{ .Event.Data.ref }}`,
+			OutputFiles: []string{"-"},
+			Stdout:      out,
+		},
+		opts: EventOptions{
+			Secret: "s3cret",
+			Types:  []string{"push"},
+			AfterRender: func(context.Context, *config.Config) error {
+				rendered++
+				return nil
+			},
+		},
+	}
+
+	post := func(eventType, body string, sign bool) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", eventType)
+		if sign {
+			mac := hmac.New(sha256.New, []byte("s3cret"))
+			mac.Write([]byte(body))
+			req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post("push", `{"ref": "refs/heads/main"}`, true))
+	assert.Equal(t, "push: refs/heads/main", out.String())
+	assert.Equal(t, 1, rendered)
+
+	out.Reset()
+	assert.Equal(t, http.StatusUnauthorized, post("push", `{"ref": "x"}`, false))
+	assert.Equal(t, http.StatusNoContent, post("issues", `{}`, true))
+	assert.Empty(t, out.String())
+	assert.Equal(t, 1, rendered)
+
+	// the config isn't modified by rendering
+	assert.Empty(t, h.cfg.LDelim)
+
+	h.cfg.Input = `{{ fail "nope" }}`
+	assert.Equal(t, http.StatusInternalServerError, post("push", `{}`, true))
+	assert.Equal(t, 1, rendered)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
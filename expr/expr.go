@@ -0,0 +1,150 @@
+// Package expr evaluates CEL (Common Expression Language) expressions, for the
+// expr namespace.
+package expr
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+)
+
+// MaxCost - the maximum cost of evaluating an expression, roughly a count of
+// the operations performed, so runaway comprehensions fail rather than hang
+const MaxCost = 10_000_000
+
+// identRE - valid variable names. Keys that aren't valid names aren't bound
+// as variables.
+var identRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved words can't be used as variable names
+var reserved = map[string]bool{
+	"true": true, "false": true, "null": true, "in": true, "as": true,
+	"break": true, "const": true, "continue": true, "else": true, "for": true,
+	"function": true, "if": true, "import": true, "let": true, "loop": true,
+	"package": true, "namespace": true, "return": true, "var": true,
+	"void": true, "while": true,
+}
+
+// Evaluator evaluates expressions, caching the compiled programs
+type Evaluator struct {
+	progs map[string]cel.Program
+	mu    sync.Mutex
+}
+
+// New creates an Evaluator
+func New() *Evaluator {
+	return &Evaluator{progs: map[string]cel.Program{}}
+}
+
+// ValidName - whether the name can be used as a variable
+func ValidName(name string) bool {
+	return identRE.MatchString(name) && !reserved[name]
+}
+
+// Eval evaluates the expression, with the variables (which can have any
+// type), and returns the result as a Go value. Variables with invalid names
+// are ignored.
+func (e *Evaluator) Eval(ctx context.Context, expression string, vars map[string]interface{}) (interface{}, error) {
+	names := make([]string, 0, len(vars))
+	for k := range vars {
+		if ValidName(k) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	prg, err := e.program(expression, names)
+	if err != nil {
+		return nil, err
+	}
+
+	activation := make(map[string]interface{}, len(names))
+	for _, n := range names {
+		activation[n] = vars[n]
+	}
+	out, _, err := prg.ContextEval(ctx, activation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", expression, err)
+	}
+	return toNative(out)
+}
+
+// program compiles the expression, with the variables declared, or returns it
+// from the cache
+func (e *Evaluator) program(expression string, names []string) (cel.Program, error) {
+	key := expression + "\x00" + strings.Join(names, ",")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if prg, ok := e.progs[key]; ok {
+		return prg, nil
+	}
+
+	opts := []cel.EnvOption{ext.Strings(), ext.Encoders()}
+	for _, n := range names {
+		opts = append(opts, cel.Variable(n, cel.DynType))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expression, iss.Err())
+	}
+	prg, err := env.Program(ast,
+		cel.CostLimit(MaxCost),
+		cel.InterruptCheckFrequency(100),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expression, err)
+	}
+
+	e.progs[key] = prg
+	return prg, nil
+}
+
+// toNative converts a CEL value to a Go value. Lists and maps are converted
+// to []interface{} and map[string]interface{}, so they can be used with the
+// coll functions.
+func toNative(v ref.Val) (interface{}, error) {
+	switch v := v.(type) {
+	case types.Null:
+		return nil, nil
+	case traits.Lister:
+		out := []interface{}{}
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			e, err := toNative(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, e)
+		}
+		return out, nil
+	case traits.Mapper:
+		out := map[string]interface{}{}
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			k := it.Next()
+			e, err := toNative(v.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(k.Value())] = e
+		}
+		return out, nil
+	}
+	if types.IsError(v) {
+		return nil, fmt.Errorf("%v", v)
+	}
+	return v.Value(), nil
+}
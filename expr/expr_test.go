@@ -0,0 +1,87 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	ctx := context.Background()
+	e := New()
+
+	vars := map[string]interface{}{
+		"a":     3,
+		"b":     4.5,
+		"name":  "world",
+		"items": []interface{}{map[string]interface{}{"n": 1}, map[string]interface{}{"n": 5}},
+		"tags":  map[string]string{"env": "prod"},
+		"when":  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"in":    "ignored - reserved",
+		"a-b":   "ignored - invalid name",
+	}
+
+	testdata := []struct {
+		expected interface{}
+		expr     string
+	}{
+		{int64(7), `a + 4`},
+		{7.5, `double(a) + b`},
+		{true, `a > 2 && name == "world"`},
+		{"hello, world", `"hello, " + name`},
+		{"WORLD", `name.upperAscii()`},
+		{[]interface{}{int64(5)}, `items.filter(i, i.n > 2).map(i, i.n)`},
+		{true, `items.exists(i, i.n == 1)`},
+		{int64(2), `size(items)`},
+		{"prod", `tags.env`},
+		{false, `has(tags.region)`},
+		{int64(2024), `when.getFullYear()`},
+		{map[string]interface{}{"x": int64(1)}, `{"x": 1}`},
+		{nil, `null`},
+	}
+	for _, d := range testdata {
+		d := d
+		t.Run(d.expr, func(t *testing.T) {
+			out, err := e.Eval(ctx, d.expr, vars)
+			require.NoError(t, err)
+			assert.Equal(t, d.expected, out)
+		})
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	ctx := context.Background()
+	e := New()
+
+	_, err := e.Eval(ctx, `a +`, nil)
+	assert.ErrorContains(t, err, "invalid expression")
+
+	_, err = e.Eval(ctx, `missing + 1`, nil)
+	assert.ErrorContains(t, err, "undeclared reference to 'missing'")
+
+	_, err = e.Eval(ctx, `a / 0`, map[string]interface{}{"a": 1})
+	assert.ErrorContains(t, err, "division by zero")
+
+	_, err = e.Eval(ctx, `[1,2,3,4,5,6,7,8,9,10].map(a, [1,2,3,4,5,6,7,8,9,10].map(b, [1,2,3,4,5,6,7,8,9,10].map(c, [1,2,3,4,5,6,7,8,9,10].map(d, [1,2,3,4,5,6,7,8,9,10].map(e, [1,2,3,4,5,6,7,8,9,10].map(f, [1,2,3,4,5,6,7,8,9,10].map(g, a+b+c+d+e+f+g)))))))`, nil)
+	assert.ErrorContains(t, err, "cost limit exceeded")
+}
+
+func TestEval_Cache(t *testing.T) {
+	ctx := context.Background()
+	e := New()
+
+	for i := 0; i < 3; i++ {
+		out, err := e.Eval(ctx, `x * 2`, map[string]interface{}{"x": i})
+		require.NoError(t, err)
+		assert.Equal(t, int64(i*2), out)
+	}
+	assert.Len(t, e.progs, 1)
+
+	// a different set of variables needs a different program
+	_, err := e.Eval(ctx, `x * 2`, map[string]interface{}{"x": 1, "y": 2})
+	require.NoError(t, err)
+	assert.Len(t, e.progs, 2)
+}
@@ -0,0 +1,208 @@
+// Package feed contains functions for parsing and generating RSS and Atom
+// feeds
+package feed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+)
+
+// Feed - a format-independent representation of an RSS or Atom feed
+type Feed struct {
+	Updated     time.Time
+	Title       string
+	Link        string
+	ID          string
+	Description string
+	Author      string
+	Language    string
+	Items       []Item
+}
+
+// Item - an entry in a feed
+type Item struct {
+	Published   time.Time
+	Updated     time.Time
+	Title       string
+	Link        string
+	ID          string
+	Description string
+	Content     string
+	Author      string
+	Categories  []string
+}
+
+// Map - convert the feed to a map, as returned by datasources. Unset fields
+// are omitted.
+func (f *Feed) Map() map[string]interface{} {
+	m := map[string]interface{}{}
+	setString(m, "title", f.Title)
+	setString(m, "link", f.Link)
+	setString(m, "id", f.ID)
+	setString(m, "description", f.Description)
+	setString(m, "author", f.Author)
+	setString(m, "language", f.Language)
+	setTime(m, "updated", f.Updated)
+
+	items := make([]interface{}, len(f.Items))
+	for i, item := range f.Items {
+		im := map[string]interface{}{}
+		setString(im, "title", item.Title)
+		setString(im, "link", item.Link)
+		setString(im, "id", item.ID)
+		setString(im, "description", item.Description)
+		setString(im, "content", item.Content)
+		setString(im, "author", item.Author)
+		setTime(im, "published", item.Published)
+		setTime(im, "updated", item.Updated)
+		if len(item.Categories) > 0 {
+			c := make([]interface{}, len(item.Categories))
+			for j, s := range item.Categories {
+				c[j] = s
+			}
+			im["categories"] = c
+		}
+		items[i] = im
+	}
+	m["items"] = items
+	return m
+}
+
+func setString(m map[string]interface{}, k, v string) {
+	if v != "" {
+		m[k] = v
+	}
+}
+
+func setTime(m map[string]interface{}, k string, v time.Time) {
+	if !v.IsZero() {
+		m[k] = v
+	}
+}
+
+// FromMap - convert a map (in the same form as returned by Map) to a Feed.
+// Times can be given as time.Time values or as strings in RFC 3339 or RFC 1123
+// formats.
+func FromMap(m map[string]interface{}) (*Feed, error) {
+	f := &Feed{}
+	for k, v := range m {
+		var err error
+		switch k {
+		case "title":
+			f.Title = conv.ToString(v)
+		case "link":
+			f.Link = conv.ToString(v)
+		case "id":
+			f.ID = conv.ToString(v)
+		case "description":
+			f.Description = conv.ToString(v)
+		case "author":
+			f.Author = conv.ToString(v)
+		case "language":
+			f.Language = conv.ToString(v)
+		case "updated":
+			f.Updated, err = toTime(v)
+		case "items":
+			f.Items, err = itemsFromList(v)
+		default:
+			return nil, fmt.Errorf("unknown feed property %q", k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", k, err)
+		}
+	}
+	return f, nil
+}
+
+func itemsFromList(v interface{}) ([]Item, error) {
+	l, err := iconv.InterfaceSlice(v)
+	if err != nil {
+		return nil, fmt.Errorf("must be a list: %w", err)
+	}
+	items := make([]Item, len(l))
+	for i, li := range l {
+		m, ok := li.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("item %d must be a map, got %T", i, li)
+		}
+		items[i], err = itemFromMap(m)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return items, nil
+}
+
+func itemFromMap(m map[string]interface{}) (Item, error) {
+	item := Item{}
+	for k, v := range m {
+		var err error
+		switch k {
+		case "title":
+			item.Title = conv.ToString(v)
+		case "link":
+			item.Link = conv.ToString(v)
+		case "id":
+			item.ID = conv.ToString(v)
+		case "description":
+			item.Description = conv.ToString(v)
+		case "content":
+			item.Content = conv.ToString(v)
+		case "author":
+			item.Author = conv.ToString(v)
+		case "published":
+			item.Published, err = toTime(v)
+		case "updated":
+			item.Updated, err = toTime(v)
+		case "categories":
+			var l []interface{}
+			l, err = iconv.InterfaceSlice(v)
+			item.Categories = conv.ToStrings(l...)
+		default:
+			return item, fmt.Errorf("unknown item property %q", k)
+		}
+		if err != nil {
+			return item, fmt.Errorf("invalid %s: %w", k, err)
+		}
+	}
+	return item, nil
+}
+
+// timeLayouts are the layouts that feed timestamps are parsed with - RSS uses
+// RFC 822 dates, though in practice many variations are seen
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, _2 Jan 2006 15:04:05 -0700",
+	"Mon, _2 Jan 2006 15:04:05 MST",
+	"_2 Jan 2006 15:04:05 -0700",
+	"_2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, _2 Jan 2006 15:04 -0700",
+	"Mon, _2 Jan 2006 15:04 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse %q as a time", s)
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+	return parseTime(conv.ToString(v))
+}
@@ -0,0 +1,230 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRSS(t *testing.T) {
+	in := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"
+  xmlns:content="http://purl.org/rss/1.0/modules/content/"
+  xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Example News</title>
+    <atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+    <link>https://example.com/</link>
+    <description>News from Example</description>
+    <language>en-us</language>
+    <lastBuildDate>Tue, 5 Mar 2024 09:30:00 GMT</lastBuildDate>
+    <item>
+      <title>Version 2 released</title>
+      <link>https://example.com/v2</link>
+      <guid isPermaLink="false">v2</guid>
+      <description><![CDATA[<p>It's <b>here</b></p>]]></description>
+      <content:encoded><![CDATA[<p>Full text</p>]]></content:encoded>
+      <dc:creator>Jo</dc:creator>
+      <pubDate>Mon, 04 Mar 2024 10:00:00 +0000</pubDate>
+      <category>release</category>
+      <category> news </category>
+    </item>
+    <item>
+      <title>Untimed</title>
+    </item>
+  </channel>
+</rss>`
+
+	f, err := Parse(in)
+	require.NoError(t, err)
+	assert.Equal(t, &Feed{
+		Title:       "Example News",
+		Link:        "https://example.com/",
+		Description: "News from Example",
+		Language:    "en-us",
+		Updated:     time.Date(2024, 3, 5, 9, 30, 0, 0, time.FixedZone("GMT", 0)),
+		Items: []Item{
+			{
+				Title:       "Version 2 released",
+				Link:        "https://example.com/v2",
+				ID:          "v2",
+				Description: "<p>It's <b>here</b></p>",
+				Content:     "<p>Full text</p>",
+				Author:      "Jo",
+				Published:   time.Date(2024, 3, 4, 10, 0, 0, 0, time.FixedZone("", 0)),
+				Categories:  []string{"release", "news"},
+			},
+			{Title: "Untimed"},
+		},
+	}, fixZones(f))
+}
+
+// fixZones normalizes time zones so that parsed times can be compared
+func fixZones(f *Feed) *Feed {
+	fix := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return t
+		}
+		name, off := t.Zone()
+		if name == "UTC" {
+			name = ""
+		}
+		return t.In(time.FixedZone(name, off))
+	}
+	f.Updated = fix(f.Updated)
+	for i := range f.Items {
+		f.Items[i].Published = fix(f.Items[i].Published)
+		f.Items[i].Updated = fix(f.Items[i].Updated)
+	}
+	return f
+}
+
+func TestParseRDF(t *testing.T) {
+	in := `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>Example</title>
+    <link>https://example.com/</link>
+    <description>RSS 1.0</description>
+    <dc:date>2024-03-05T09:30:00Z</dc:date>
+  </channel>
+  <item rdf:about="https://example.com/1">
+    <title>One</title>
+    <link>https://example.com/1</link>
+    <dc:creator>Jo</dc:creator>
+    <dc:subject>misc</dc:subject>
+    <dc:date>2024-03-04T10:00:00Z</dc:date>
+  </item>
+</rdf:RDF>`
+
+	f, err := Parse(in)
+	require.NoError(t, err)
+	assert.Equal(t, "Example", f.Title)
+	assert.Equal(t, "RSS 1.0", f.Description)
+	assert.Equal(t, time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC), f.Updated)
+	require.Len(t, f.Items, 1)
+	assert.Equal(t, Item{
+		Title:      "One",
+		Link:       "https://example.com/1",
+		Author:     "Jo",
+		Categories: []string{"misc"},
+		Published:  time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+	}, f.Items[0])
+}
+
+func TestParseAtom(t *testing.T) {
+	in := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+  <title type="text">Example Feed</title>
+  <subtitle>All the news</subtitle>
+  <link rel="self" href="https://example.com/feed.atom"/>
+  <link href="https://example.com/"/>
+  <id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+  <updated>2024-03-05T09:30:00Z</updated>
+  <author><name>Jo</name><email>jo@example.com</email></author>
+  <entry>
+    <title>Atom-Powered Robots Run Amok</title>
+    <link rel="alternate" href="https://example.com/2003/12/13/atom03"/>
+    <link rel="edit" href="https://example.com/edit"/>
+    <id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+    <published>2024-03-04T10:00:00+01:00</published>
+    <updated>2024-03-04T12:00:00+01:00</updated>
+    <summary type="html">&lt;p&gt;Some text.&lt;/p&gt;</summary>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Some <b>markup</b>.</p></div></content>
+    <author><name>Sam</name></author>
+    <author><name>Alex</name></author>
+    <category term="robots" label="Robots"/>
+  </entry>
+</feed>`
+
+	f, err := Parse(in)
+	require.NoError(t, err)
+	assert.Equal(t, "Example Feed", f.Title)
+	assert.Equal(t, "All the news", f.Description)
+	assert.Equal(t, "https://example.com/", f.Link)
+	assert.Equal(t, "urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6", f.ID)
+	assert.Equal(t, "jo@example.com (Jo)", f.Author)
+	assert.Equal(t, "en", f.Language)
+	require.Len(t, f.Items, 1)
+
+	item := f.Items[0]
+	assert.Equal(t, "Atom-Powered Robots Run Amok", item.Title)
+	assert.Equal(t, "https://example.com/2003/12/13/atom03", item.Link)
+	assert.Equal(t, "<p>Some text.</p>", item.Description)
+	assert.Equal(t, "<p>Some <b>markup</b>.</p>", item.Content)
+	assert.Equal(t, "Sam, Alex", item.Author)
+	assert.Equal(t, []string{"robots"}, item.Categories)
+	assert.True(t, item.Published.Equal(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, item.Updated.Equal(time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)))
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("")
+	assert.Error(t, err)
+
+	_, err = Parse("<html></html>")
+	assert.ErrorContains(t, err, "unexpected root element <html>")
+
+	_, err = Parse("<rss><channel><title>x</title>")
+	assert.Error(t, err)
+
+	_, err = Parse("<rss><channel><item><pubDate>someday</pubDate></item></channel></rss>")
+	assert.ErrorContains(t, err, `item 0: can not parse "someday" as a time`)
+}
+
+func TestMapRoundTrip(t *testing.T) {
+	f := &Feed{
+		Title:   "t",
+		Link:    "https://example.com/",
+		Updated: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Items: []Item{
+			{Title: "a", Published: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), Categories: []string{"x"}},
+			{Title: "b"},
+		},
+	}
+	m := f.Map()
+	assert.Equal(t, map[string]interface{}{
+		"title":   "t",
+		"link":    "https://example.com/",
+		"updated": time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		"items": []interface{}{
+			map[string]interface{}{
+				"title":      "a",
+				"published":  time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+				"categories": []interface{}{"x"},
+			},
+			map[string]interface{}{"title": "b"},
+		},
+	}, m)
+
+	out, err := FromMap(m)
+	require.NoError(t, err)
+	assert.Equal(t, f, out)
+}
+
+func TestFromMap(t *testing.T) {
+	f, err := FromMap(map[string]interface{}{
+		"title":   "t",
+		"updated": "Tue, 05 Mar 2024 09:30:00 +0000",
+		"items": []interface{}{
+			map[string]interface{}{"title": "a", "published": "2024-03-04"},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, f.Updated.Equal(time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), f.Items[0].Published)
+
+	_, err = FromMap(map[string]interface{}{"colour": "red"})
+	assert.ErrorContains(t, err, `unknown feed property "colour"`)
+
+	_, err = FromMap(map[string]interface{}{"items": []interface{}{map[string]interface{}{"colour": "red"}}})
+	assert.ErrorContains(t, err, `item 0: unknown item property "colour"`)
+
+	_, err = FromMap(map[string]interface{}{"items": []interface{}{"foo"}})
+	assert.ErrorContains(t, err, "item 0 must be a map")
+
+	_, err = FromMap(map[string]interface{}{"updated": "someday"})
+	assert.ErrorContains(t, err, "invalid updated")
+}
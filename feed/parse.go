@@ -0,0 +1,303 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+// Parse - parse an RSS (0.9x, 1.0, or 2.0) or Atom feed
+func Parse(in string) (*Feed, error) {
+	root, err := rootElement(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(in)
+	case "RDF":
+		return parseRDF(in)
+	case "feed":
+		return parseAtom(in)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: unexpected root element <%s>", root)
+	}
+}
+
+func rootElement(in string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader(in))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("failed to parse feed: no root element")
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse feed: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local, nil
+		}
+	}
+}
+
+type rssChannel struct {
+	Title          string    `xml:"title"`
+	Link           []rssLink `xml:"link"`
+	Description    string    `xml:"description"`
+	Language       string    `xml:"language"`
+	ManagingEditor string    `xml:"managingEditor"`
+	LastBuildDate  string    `xml:"lastBuildDate"`
+	PubDate        string    `xml:"pubDate"`
+	Date           string    `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Items          []rssItem `xml:"item"`
+}
+
+// rssLink is a channel's link - RSS feeds commonly include an atom:link to
+// themselves alongside the RSS link, which must be ignored
+type rssLink struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Categories  []string `xml:"category"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+func parseRSS(in string) (*Feed, error) {
+	doc := struct {
+		Channel rssChannel `xml:"channel"`
+	}{}
+	if err := xml.Unmarshal([]byte(in), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+	}
+	return doc.Channel.feed(doc.Channel.Items)
+}
+
+// parseRDF parses RSS 1.0 feeds, where items are siblings of the channel
+func parseRDF(in string) (*Feed, error) {
+	doc := struct {
+		Channel rssChannel `xml:"channel"`
+		Items   []rssItem  `xml:"item"`
+	}{}
+	if err := xml.Unmarshal([]byte(in), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+	}
+	return doc.Channel.feed(doc.Items)
+}
+
+func (c rssChannel) feed(items []rssItem) (*Feed, error) {
+	f := &Feed{
+		Title:       strings.TrimSpace(c.Title),
+		Description: strings.TrimSpace(c.Description),
+		Language:    strings.TrimSpace(c.Language),
+		Author:      strings.TrimSpace(c.ManagingEditor),
+	}
+	for _, l := range c.Link {
+		if l.XMLName.Space != atomNS {
+			f.Link = strings.TrimSpace(l.Text)
+			break
+		}
+	}
+
+	var err error
+	f.Updated, err = optionalTime(c.LastBuildDate, c.PubDate, c.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Items = make([]Item, len(items))
+	for i, ri := range items {
+		item := Item{
+			Title:       strings.TrimSpace(ri.Title),
+			Link:        strings.TrimSpace(ri.Link),
+			ID:          strings.TrimSpace(ri.GUID),
+			Description: strings.TrimSpace(ri.Description),
+			Content:     strings.TrimSpace(ri.Content),
+			Author:      strings.TrimSpace(firstOf(ri.Author, ri.Creator)),
+			Categories:  append(trimAll(ri.Categories), trimAll(ri.Subjects)...),
+		}
+		item.Published, err = optionalTime(ri.PubDate, ri.Date)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		if len(item.Categories) == 0 {
+			item.Categories = nil
+		}
+		f.Items[i] = item
+	}
+	return f, nil
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the text content - for XHTML, this is the markup inside the
+// wrapping div
+func (t atomText) String() string {
+	if t.Type != "xhtml" {
+		return strings.TrimSpace(t.Text)
+	}
+	s := strings.TrimSpace(t.Inner)
+	if strings.HasPrefix(s, "<div") && strings.HasSuffix(s, "</div>") {
+		s = s[strings.Index(s, ">")+1 : len(s)-len("</div>")]
+	}
+	return strings.TrimSpace(s)
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+func (p atomPerson) String() string {
+	name := strings.TrimSpace(p.Name)
+	email := strings.TrimSpace(p.Email)
+	if email != "" && name != "" {
+		return fmt.Sprintf("%s (%s)", email, name)
+	}
+	return firstOf(name, email)
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomEntry struct {
+	Title      atomText       `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Authors    []atomPerson   `xml:"author"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func parseAtom(in string) (*Feed, error) {
+	doc := struct {
+		Title    atomText     `xml:"title"`
+		Subtitle atomText     `xml:"subtitle"`
+		ID       string       `xml:"id"`
+		Links    []atomLink   `xml:"link"`
+		Authors  []atomPerson `xml:"author"`
+		Updated  string       `xml:"updated"`
+		Lang     string       `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Entries  []atomEntry  `xml:"entry"`
+	}{}
+	if err := xml.Unmarshal([]byte(in), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse Atom feed: %w", err)
+	}
+
+	f := &Feed{
+		Title:       doc.Title.String(),
+		Description: doc.Subtitle.String(),
+		ID:          strings.TrimSpace(doc.ID),
+		Link:        alternateLink(doc.Links),
+		Author:      authors(doc.Authors),
+		Language:    doc.Lang,
+	}
+	var err error
+	f.Updated, err = optionalTime(doc.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Items = make([]Item, len(doc.Entries))
+	for i, e := range doc.Entries {
+		item := Item{
+			Title:       e.Title.String(),
+			ID:          strings.TrimSpace(e.ID),
+			Link:        alternateLink(e.Links),
+			Description: e.Summary.String(),
+			Content:     e.Content.String(),
+			Author:      authors(e.Authors),
+		}
+		for _, c := range e.Categories {
+			item.Categories = append(item.Categories, firstOf(c.Term, c.Label))
+		}
+		item.Published, err = optionalTime(e.Published)
+		if err == nil {
+			item.Updated, err = optionalTime(e.Updated)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		f.Items[i] = item
+	}
+	return f, nil
+}
+
+// alternateLink returns the link to the HTML representation, which is the
+// link with no rel or rel="alternate"
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+func authors(p []atomPerson) string {
+	names := make([]string, 0, len(p))
+	for _, a := range p {
+		if s := a.String(); s != "" {
+			names = append(names, s)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// optionalTime parses the first non-empty value, returning the zero time if
+// all are empty
+func optionalTime(values ...string) (time.Time, error) {
+	s := firstOf(values...)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return parseTime(s)
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if s := strings.TrimSpace(v); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func trimAll(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,237 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type atomFeedOut struct {
+	XMLName  xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string         `xml:"xml:lang,attr,omitempty"`
+	Title    string         `xml:"title"`
+	Subtitle string         `xml:"subtitle,omitempty"`
+	ID       string         `xml:"id"`
+	Link     *atomLinkOut   `xml:"link"`
+	Updated  string         `xml:"updated"`
+	Author   *atomPerson    `xml:"author"`
+	Entries  []atomEntryOut `xml:"entry"`
+}
+
+type atomLinkOut struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomTextOut struct {
+	Type string `xml:"type,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type atomCategoryOut struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntryOut struct {
+	Title      string            `xml:"title"`
+	ID         string            `xml:"id"`
+	Link       *atomLinkOut      `xml:"link"`
+	Published  string            `xml:"published,omitempty"`
+	Updated    string            `xml:"updated"`
+	Author     *atomPerson       `xml:"author"`
+	Categories []atomCategoryOut `xml:"category"`
+	Summary    *atomTextOut      `xml:"summary"`
+	Content    *atomTextOut      `xml:"content"`
+}
+
+// ToAtom - render the feed as an Atom (RFC 4287) document.
+//
+// Atom requires IDs and update times, so the link is used as the ID when one
+// isn't set, and the feed's update time defaults to that of the most recently
+// updated item.
+func ToAtom(f *Feed) (string, error) {
+	if f.Title == "" {
+		return "", fmt.Errorf("feed title is required")
+	}
+	out := atomFeedOut{
+		Lang:     f.Language,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       firstOf(f.ID, f.Link),
+		Link:     atomLinkTo(f.Link),
+		Author:   atomAuthor(f.Author),
+	}
+	if out.ID == "" {
+		return "", fmt.Errorf("feed id or link is required")
+	}
+
+	updated := f.Updated
+	out.Entries = make([]atomEntryOut, len(f.Items))
+	for i, item := range f.Items {
+		e := atomEntryOut{
+			Title:   item.Title,
+			ID:      firstOf(item.ID, item.Link),
+			Link:    atomLinkTo(item.Link),
+			Author:  atomAuthor(item.Author),
+			Summary: atomTextTo(item.Description),
+			Content: atomTextTo(item.Content),
+		}
+		if e.ID == "" {
+			return "", fmt.Errorf("item %d: id or link is required", i)
+		}
+		for _, c := range item.Categories {
+			e.Categories = append(e.Categories, atomCategoryOut{c})
+		}
+
+		itemUpdated := item.Updated
+		if itemUpdated.IsZero() {
+			itemUpdated = item.Published
+		}
+		if itemUpdated.IsZero() {
+			return "", fmt.Errorf("item %d: updated or published time is required", i)
+		}
+		e.Updated = itemUpdated.Format(time.RFC3339)
+		if !item.Published.IsZero() {
+			e.Published = item.Published.Format(time.RFC3339)
+		}
+		if f.Updated.IsZero() && itemUpdated.After(updated) {
+			updated = itemUpdated
+		}
+		out.Entries[i] = e
+	}
+	if updated.IsZero() {
+		return "", fmt.Errorf("feed updated time is required when there are no items")
+	}
+	out.Updated = updated.Format(time.RFC3339)
+
+	return marshalFeed(out)
+}
+
+func atomLinkTo(href string) *atomLinkOut {
+	if href == "" {
+		return nil
+	}
+	return &atomLinkOut{Rel: "alternate", Href: href}
+}
+
+func atomAuthor(name string) *atomPerson {
+	if name == "" {
+		return nil
+	}
+	return &atomPerson{Name: name}
+}
+
+// atomTextTo returns a text construct, marking it as HTML if it appears to
+// contain markup
+func atomTextTo(s string) *atomTextOut {
+	if s == "" {
+		return nil
+	}
+	t := &atomTextOut{Text: s}
+	if strings.ContainsAny(s, "<&") {
+		t.Type = "html"
+	}
+	return t
+}
+
+type rssOut struct {
+	XMLName        xml.Name     `xml:"rss"`
+	Version        string       `xml:"version,attr"`
+	ContentNS      string       `xml:"xmlns:content,attr,omitempty"`
+	DCNS           string       `xml:"xmlns:dc,attr,omitempty"`
+	Title          string       `xml:"channel>title"`
+	Link           string       `xml:"channel>link"`
+	Description    string       `xml:"channel>description"`
+	Language       string       `xml:"channel>language,omitempty"`
+	ManagingEditor string       `xml:"channel>managingEditor,omitempty"`
+	LastBuildDate  string       `xml:"channel>lastBuildDate,omitempty"`
+	Items          []rssOutItem `xml:"channel>item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Text        string `xml:",chardata"`
+}
+
+type rssOutItem struct {
+	Title       string   `xml:"title,omitempty"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Content     string   `xml:"content:encoded,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	GUID        *rssGUID `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+// ToRSS - render the feed as an RSS 2.0 document.
+//
+// Item content is written as content:encoded, and authors that aren't email
+// addresses are written as dc:creator.
+func ToRSS(f *Feed) (string, error) {
+	if f.Title == "" {
+		return "", fmt.Errorf("feed title is required")
+	}
+	if f.Link == "" {
+		return "", fmt.Errorf("feed link is required")
+	}
+	out := rssOut{
+		Version:     "2.0",
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: firstOf(f.Description, f.Title),
+		Language:    f.Language,
+	}
+	if strings.Contains(f.Author, "@") {
+		out.ManagingEditor = f.Author
+	}
+	if !f.Updated.IsZero() {
+		out.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	out.Items = make([]rssOutItem, len(f.Items))
+	for i, item := range f.Items {
+		if item.Title == "" && item.Description == "" {
+			return "", fmt.Errorf("item %d: title or description is required", i)
+		}
+		ri := rssOutItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Content:     item.Content,
+			Categories:  item.Categories,
+		}
+		if ri.Content != "" {
+			out.ContentNS = "http://purl.org/rss/1.0/modules/content/"
+		}
+		if strings.Contains(item.Author, "@") {
+			ri.Author = item.Author
+		} else if item.Author != "" {
+			ri.Creator = item.Author
+			out.DCNS = "http://purl.org/dc/elements/1.1/"
+		}
+		if id := firstOf(item.ID, item.Link); id != "" {
+			ri.GUID = &rssGUID{IsPermaLink: id == item.Link, Text: id}
+		}
+		pub := item.Published
+		if pub.IsZero() {
+			pub = item.Updated
+		}
+		if !pub.IsZero() {
+			ri.PubDate = pub.Format(time.RFC1123Z)
+		}
+		out.Items[i] = ri
+	}
+
+	return marshalFeed(out)
+}
+
+func marshalFeed(v interface{}) (string, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal feed: %w", err)
+	}
+	return xml.Header + string(b) + "\n", nil
+}
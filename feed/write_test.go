@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() *Feed {
+	return &Feed{
+		Title:    "Changelog",
+		Link:     "https://example.com/",
+		Language: "en",
+		Items: []Item{
+			{
+				Title:       "v1.1",
+				Link:        "https://example.com/v1.1",
+				Description: "Fixes <b>bugs</b>",
+				Author:      "Jo",
+				Published:   time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+				Categories:  []string{"release"},
+			},
+			{
+				Title:     "v1.0",
+				ID:        "v1.0",
+				Content:   "First release",
+				Author:    "sam@example.com",
+				Published: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+				Updated:   time.Date(2024, 2, 2, 10, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+}
+
+func TestToAtom(t *testing.T) {
+	out, err := ToAtom(testFeed())
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+  <title>Changelog</title>
+  <id>https://example.com/</id>
+  <link rel="alternate" href="https://example.com/"></link>
+  <updated>2024-03-04T10:00:00Z</updated>
+  <entry>
+    <title>v1.1</title>
+    <id>https://example.com/v1.1</id>
+    <link rel="alternate" href="https://example.com/v1.1"></link>
+    <published>2024-03-04T10:00:00Z</published>
+    <updated>2024-03-04T10:00:00Z</updated>
+    <author>
+      <name>Jo</name>
+    </author>
+    <category term="release"></category>
+    <summary type="html">Fixes &lt;b&gt;bugs&lt;/b&gt;</summary>
+  </entry>
+  <entry>
+    <title>v1.0</title>
+    <id>v1.0</id>
+    <published>2024-02-01T10:00:00Z</published>
+    <updated>2024-02-02T10:00:00Z</updated>
+    <author>
+      <name>sam@example.com</name>
+    </author>
+    <content>First release</content>
+  </entry>
+</feed>
+`, out)
+
+	// round-trips through Parse
+	f, err := Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, "Fixes <b>bugs</b>", f.Items[0].Description)
+	assert.Equal(t, "First release", f.Items[1].Content)
+
+	_, err = ToAtom(&Feed{Link: "https://example.com/"})
+	assert.ErrorContains(t, err, "title is required")
+
+	_, err = ToAtom(&Feed{Title: "t"})
+	assert.ErrorContains(t, err, "id or link is required")
+
+	_, err = ToAtom(&Feed{Title: "t", ID: "x"})
+	assert.ErrorContains(t, err, "updated time is required")
+
+	_, err = ToAtom(&Feed{Title: "t", ID: "x", Items: []Item{{Title: "a"}}})
+	assert.ErrorContains(t, err, "item 0: id or link is required")
+
+	_, err = ToAtom(&Feed{Title: "t", ID: "x", Items: []Item{{ID: "a"}}})
+	assert.ErrorContains(t, err, "item 0: updated or published time is required")
+}
+
+func TestToRSS(t *testing.T) {
+	out, err := ToRSS(testFeed())
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Changelog</title>
+    <link>https://example.com/</link>
+    <description>Changelog</description>
+    <language>en</language>
+    <item>
+      <title>v1.1</title>
+      <link>https://example.com/v1.1</link>
+      <description>Fixes &lt;b&gt;bugs&lt;/b&gt;</description>
+      <dc:creator>Jo</dc:creator>
+      <category>release</category>
+      <guid isPermaLink="true">https://example.com/v1.1</guid>
+      <pubDate>Mon, 04 Mar 2024 10:00:00 +0000</pubDate>
+    </item>
+    <item>
+      <title>v1.0</title>
+      <content:encoded>First release</content:encoded>
+      <author>sam@example.com</author>
+      <guid isPermaLink="false">v1.0</guid>
+      <pubDate>Thu, 01 Feb 2024 10:00:00 +0000</pubDate>
+    </item>
+  </channel>
+</rss>
+`, out)
+
+	f, err := Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, "Jo", f.Items[0].Author)
+	assert.Equal(t, "First release", f.Items[1].Content)
+
+	_, err = ToRSS(&Feed{Link: "https://example.com/"})
+	assert.ErrorContains(t, err, "title is required")
+
+	_, err = ToRSS(&Feed{Title: "t"})
+	assert.ErrorContains(t, err, "link is required")
+
+	_, err = ToRSS(&Feed{Title: "t", Link: "https://example.com/", Items: []Item{{Link: "x"}}})
+	assert.ErrorContains(t, err, "item 0: title or description is required")
+}
@@ -38,6 +38,27 @@ func CreateFuncs(ctx context.Context, d *data.Data) template.FuncMap {
 	addToMap(f, funcs.CreateCollFuncs(ctx))
 	addToMap(f, funcs.CreateUUIDFuncs(ctx))
 	addToMap(f, funcs.CreateRandomFuncs(ctx))
+	addToMap(f, funcs.CreateGoTypeFuncs(ctx))
+	addToMap(f, funcs.CreateEscFuncs(ctx))
+	addToMap(f, funcs.CreateTextFuncs(ctx))
+	addToMap(f, funcs.CreatePlotFuncs(ctx))
+	addToMap(f, funcs.CreateDocFuncs(ctx))
+	addToMap(f, funcs.CreateMailFuncs(ctx))
+	addToMap(f, funcs.CreateFeedFuncs(ctx))
+	addToMap(f, funcs.CreateOpenAPIFuncs(ctx))
+	addToMap(f, funcs.CreateSchemaFuncs(ctx))
+	addToMap(f, funcs.CreatePromptFuncs(ctx))
+	addToMap(f, funcs.CreateK8sFuncs(ctx))
+	addToMap(f, funcs.CreateScriptFuncs(ctx))
+	addToMap(f, funcs.CreateExprFuncs(ctx))
+	addToMap(f, funcs.CreateFlagFuncs(ctx, d))
+	addToMap(f, funcs.CreateRolloutFuncs(ctx))
+	addToMap(f, funcs.CreateUnitsFuncs(ctx))
+	addToMap(f, funcs.CreateColorFuncs(ctx))
+	addToMap(f, funcs.CreateContactFuncs(ctx))
+	addToMap(f, funcs.CreateMoneyFuncs(ctx, d))
+	addToMap(f, funcs.CreateGeoFuncs(ctx))
+	addToMap(f, funcs.CreateGraphFuncs(ctx))
 	return f
 }
 
@@ -139,6 +139,42 @@ func (CollFuncs) Flatten(args ...interface{}) ([]interface{}, error) {
 	return coll.Flatten(list, depth)
 }
 
+// FlattenMap - flattens nested map keys into separator-joined keys
+func (CollFuncs) FlattenMap(args ...interface{}) (map[string]interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+
+	m, ok := args[len(args)-1].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("wrong map type: must be map[string]interface{}, got %T", args[len(args)-1])
+	}
+
+	sep := "."
+	if len(args) == 2 {
+		sep = conv.ToString(args[0])
+	}
+	return coll.FlattenMap(m, sep), nil
+}
+
+// Unflatten -
+func (CollFuncs) Unflatten(args ...interface{}) (map[string]interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+
+	m, ok := args[len(args)-1].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("wrong map type: must be map[string]interface{}, got %T", args[len(args)-1])
+	}
+
+	sep := "."
+	if len(args) == 2 {
+		sep = conv.ToString(args[0])
+	}
+	return coll.Unflatten(m, sep)
+}
+
 func pickOmitArgs(args ...interface{}) (map[string]interface{}, []string, error) {
 	if len(args) <= 1 {
 		return nil, nil, errors.Errorf("wrong number of args: wanted 2 or more, got %d", len(args))
@@ -45,6 +45,47 @@ func TestFlatten(t *testing.T) {
 	assert.EqualValues(t, []interface{}{1, []int{2}, 3}, out)
 }
 
+func TestFlattenMap(t *testing.T) {
+	t.Parallel()
+
+	c := CollFuncs{}
+
+	_, err := c.FlattenMap()
+	assert.Error(t, err)
+
+	_, err = c.FlattenMap([]interface{}{1, 2})
+	assert.Error(t, err)
+
+	in := map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2}
+	out, err := c.FlattenMap(in)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"a.b": 1, "c": 2}, out)
+
+	out, err = c.FlattenMap("/", in)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"a/b": 1, "c": 2}, out)
+}
+
+func TestUnflatten(t *testing.T) {
+	t.Parallel()
+
+	c := CollFuncs{}
+
+	_, err := c.Unflatten()
+	assert.Error(t, err)
+
+	_, err = c.Unflatten("foo")
+	assert.Error(t, err)
+
+	out, err := c.Unflatten(map[string]interface{}{"a.b": 1, "c": 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2}, out)
+
+	out, err = c.Unflatten("/", map[string]interface{}{"a/b": 1, "c": 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2}, out)
+}
+
 func TestPick(t *testing.T) {
 	t.Parallel()
 
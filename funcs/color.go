@@ -0,0 +1,126 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hairyhenderson/gomplate/v3/color"
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/env"
+	"golang.org/x/term"
+)
+
+// CreateColorFuncs -
+func CreateColorFuncs(ctx context.Context) map[string]interface{} {
+	ns := &ColorFuncs{ctx: ctx, enabled: colorEnabled}
+	return map[string]interface{}{
+		"color": func() interface{} { return ns },
+	}
+}
+
+// ColorFuncs -
+type ColorFuncs struct {
+	ctx context.Context
+	// enabled is overridden in tests
+	enabled func() bool
+}
+
+// colorEnabled - whether ANSI styling should be output. NO_COLOR disables
+// it, and FORCE_COLOR (or CLICOLOR_FORCE) enables it even when stdout isn't
+// a terminal (like when rendering to a file).
+func colorEnabled() bool {
+	if env.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	for _, k := range []string{"FORCE_COLOR", "CLICOLOR_FORCE"} {
+		if v := env.Getenv(k); v != "" && v != "0" {
+			return true
+		}
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Enabled - whether ANSI styling is enabled
+func (f *ColorFuncs) Enabled() bool {
+	return f.enabled()
+}
+
+// Style - styles the text (the last argument) with the given styles, like
+// "bold", "red", "bgBlue", or "#ff8800". When styling isn't enabled, the
+// text is returned unchanged.
+func (f *ColorFuncs) Style(args ...interface{}) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("wrong number of args: want at least 1, got 0")
+	}
+	text := conv.ToString(args[len(args)-1])
+	styles := conv.ToStrings(args[:len(args)-1]...)
+
+	out, err := color.Style(text, styles...)
+	if err != nil {
+		return "", err
+	}
+	if !f.enabled() {
+		return text, nil
+	}
+	return out, nil
+}
+
+// Strip - removes ANSI escape codes from the text
+func (ColorFuncs) Strip(text interface{}) string {
+	return color.Strip(conv.ToString(text))
+}
+
+// HexToRGB - converts a hex colour (like "#ff8800" or "#f80") to a list of
+// its red, green, and blue components
+func (ColorFuncs) HexToRGB(hex interface{}) ([]int, error) {
+	c, err := color.ParseHex(conv.ToString(hex))
+	if err != nil {
+		return nil, err
+	}
+	return []int{int(c.R), int(c.G), int(c.B)}, nil
+}
+
+// RGBToHex - converts red, green, and blue components (0-255) to a hex
+// colour, like "#ff8800"
+func (ColorFuncs) RGBToHex(r, g, b interface{}) (string, error) {
+	c := color.RGB{}
+	for i, v := range []interface{}{r, g, b} {
+		n := conv.ToInt(v)
+		if n < 0 || n > 255 {
+			return "", fmt.Errorf("colour components must be between 0 and 255, got %v", v)
+		}
+		switch i {
+		case 0:
+			c.R = uint8(n)
+		case 1:
+			c.G = uint8(n)
+		case 2:
+			c.B = uint8(n)
+		}
+	}
+	return c.Hex(), nil
+}
+
+// Luminance - the relative luminance of the hex colour, from 0 (black) to 1
+// (white)
+func (ColorFuncs) Luminance(hex interface{}) (float64, error) {
+	c, err := color.ParseHex(conv.ToString(hex))
+	if err != nil {
+		return 0, err
+	}
+	return c.Luminance(), nil
+}
+
+// Contrast - the WCAG 2 contrast ratio between two hex colours, from 1 to 21
+func (ColorFuncs) Contrast(a, b interface{}) (float64, error) {
+	ca, err := color.ParseHex(conv.ToString(a))
+	if err != nil {
+		return 0, err
+	}
+	cb, err := color.ParseHex(conv.ToString(b))
+	if err != nil {
+		return 0, err
+	}
+	return color.Contrast(ca, cb), nil
+}
@@ -0,0 +1,98 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateColorFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateColorFuncs(ctx)
+			actual := fmap["color"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*ColorFuncs).ctx)
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, colorEnabled())
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+	assert.True(t, colorEnabled())
+
+	t.Setenv("FORCE_COLOR", "0")
+	t.Setenv("CLICOLOR_FORCE", "1")
+	assert.True(t, colorEnabled())
+}
+
+func TestColorStyle(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+	f := &ColorFuncs{enabled: func() bool { return enabled }}
+
+	out, err := f.Style("bold", "red", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b[1;31mhello\x1b[0m", out)
+	assert.Equal(t, "hello", f.Strip(out))
+
+	enabled = false
+	out, err = f.Style("bold", "red", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", out)
+
+	// invalid styles are errors even when styling is disabled
+	_, err = f.Style("sparkly", "hello")
+	assert.Error(t, err)
+
+	_, err = f.Style()
+	assert.Error(t, err)
+}
+
+func TestColorConversions(t *testing.T) {
+	t.Parallel()
+
+	f := &ColorFuncs{}
+
+	rgb, err := f.HexToRGB("#ff8800")
+	require.NoError(t, err)
+	assert.Equal(t, []int{255, 136, 0}, rgb)
+
+	_, err = f.HexToRGB("orange")
+	assert.Error(t, err)
+
+	hex, err := f.RGBToHex(255, "136", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "#ff8800", hex)
+
+	_, err = f.RGBToHex(256, 0, 0)
+	assert.Error(t, err)
+
+	c, err := f.Contrast("#000", "#ffffff")
+	require.NoError(t, err)
+	assert.InDelta(t, 21, c, 0.0001)
+
+	_, err = f.Contrast("#000", "white")
+	assert.Error(t, err)
+
+	l, err := f.Luminance("#fff")
+	require.NoError(t, err)
+	assert.InDelta(t, 1, l, 0.0001)
+}
@@ -0,0 +1,138 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/contact"
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+)
+
+// CreateContactFuncs -
+func CreateContactFuncs(ctx context.Context) map[string]interface{} {
+	ns := &ContactFuncs{ctx}
+	return map[string]interface{}{
+		"contact": func() interface{} { return ns },
+	}
+}
+
+// ContactFuncs -
+type ContactFuncs struct {
+	ctx context.Context
+}
+
+// FormatPhone - formats the phone number, parsed as a number in the region
+// (when it has no international prefix). The optional format is one of
+// "international" (the default), "national", "e164", or "rfc3966".
+func (ContactFuncs) FormatPhone(number, region interface{}, format ...string) (string, error) {
+	f := "international"
+	switch len(format) {
+	case 0:
+	case 1:
+		f = format[0]
+	default:
+		return "", fmt.Errorf("wrong number of args: want 2 or 3, got %d", len(format)+2)
+	}
+	return contact.FormatPhone(conv.ToString(number), conv.ToString(region), f)
+}
+
+// ParsePhone - parses the phone number, returning a map of its details
+func (ContactFuncs) ParsePhone(number, region interface{}) (map[string]interface{}, error) {
+	p, err := contact.ParsePhone(conv.ToString(number), conv.ToString(region))
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{
+		"region":      p.Region,
+		"type":        p.Type,
+		"e164":        p.E164,
+		"countryCode": p.CountryCode,
+		"valid":       p.Valid,
+	}
+	if p.Extension != "" {
+		out["extension"] = p.Extension
+	}
+	return out, nil
+}
+
+// IsValidPhone - whether the phone number is valid
+func (ContactFuncs) IsValidPhone(number, region interface{}) bool {
+	p, err := contact.ParsePhone(conv.ToString(number), conv.ToString(region))
+	return err == nil && p.Valid
+}
+
+// addressKeys - the keys that address fields are read from (compared
+// case-insensitively, ignoring '_' and '-'), in order of preference
+var addressKeys = map[string][]string{
+	"name":         {"name", "fullname", "recipient"},
+	"organization": {"organization", "organisation", "company"},
+	"lines":        {"lines", "street", "streetaddress", "address", "addressline1", "address1", "line1", "addressline2", "address2", "line2"},
+	"city":         {"city", "locality", "town"},
+	"region":       {"region", "state", "province", "county"},
+	"postalCode":   {"postalcode", "postcode", "zip", "zipcode"},
+	"country":      {"country", "countrycode"},
+}
+
+// FormatAddress - formats a postal address (a map), following the
+// conventions of its country. The optional first argument is the country the
+// mail is sent from - the country line is left out for domestic addresses.
+func (ContactFuncs) FormatAddress(args ...interface{}) (string, error) {
+	var from string
+	var in interface{}
+	switch len(args) {
+	case 1:
+		in = args[0]
+	case 2:
+		from = conv.ToString(args[0])
+		in = args[1]
+	default:
+		return "", fmt.Errorf("wrong number of args: want 1 or 2, got %d", len(args))
+	}
+
+	m, ok := in.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("address must be a map, got %T", in)
+	}
+	norm := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		norm[strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(k))] = v
+	}
+
+	field := func(name string) string {
+		for _, k := range addressKeys[name] {
+			if v, ok := norm[k]; ok && v != nil {
+				return conv.ToString(v)
+			}
+		}
+		return ""
+	}
+
+	a := contact.Address{
+		Name:         field("name"),
+		Organization: field("organization"),
+		City:         field("city"),
+		Region:       field("region"),
+		PostalCode:   field("postalCode"),
+		Country:      field("country"),
+	}
+	// street lines can be given as a list, or in separate keys
+	for _, k := range addressKeys["lines"] {
+		v, ok := norm[k]
+		if !ok || v == nil {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			a.Lines = append(a.Lines, strings.Split(s, "\n")...)
+			continue
+		}
+		l, err := iconv.InterfaceSlice(v)
+		if err != nil {
+			return "", fmt.Errorf("address lines must be a string or a list: %w", err)
+		}
+		a.Lines = append(a.Lines, conv.ToStrings(l...)...)
+	}
+
+	return contact.FormatAddress(a, from), nil
+}
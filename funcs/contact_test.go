@@ -0,0 +1,98 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateContactFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateContactFuncs(ctx)
+			actual := fmap["contact"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*ContactFuncs).ctx)
+		})
+	}
+}
+
+func TestContactPhone(t *testing.T) {
+	t.Parallel()
+
+	c := ContactFuncs{}
+
+	out, err := c.FormatPhone("020 7946 0018", "GB")
+	require.NoError(t, err)
+	assert.Equal(t, "+44 20 7946 0018", out)
+
+	out, err = c.FormatPhone(4155552671, "US", "national")
+	require.NoError(t, err)
+	assert.Equal(t, "(415) 555-2671", out)
+
+	_, err = c.FormatPhone("020 7946 0018", "GB", "national", "extra")
+	assert.Error(t, err)
+
+	p, err := c.ParsePhone("07400 123456", "GB")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"region":      "GB",
+		"type":        "mobile",
+		"e164":        "+447400123456",
+		"countryCode": 44,
+		"valid":       true,
+	}, p)
+
+	assert.True(t, c.IsValidPhone("+1 415 555 2671", ""))
+	assert.False(t, c.IsValidPhone("12345", "US"))
+	assert.False(t, c.IsValidPhone("bogus", "US"))
+}
+
+func TestContactFormatAddress(t *testing.T) {
+	t.Parallel()
+
+	c := ContactFuncs{}
+
+	out, err := c.FormatAddress(map[string]interface{}{
+		"Name":        "Jane Doe",
+		"address_1":   "1600 Amphitheatre Pkwy",
+		"address_2":   "Suite 100",
+		"City":        "Mountain View",
+		"State":       "CA",
+		"Postal-Code": 94043,
+		"country":     "US",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe\n1600 Amphitheatre Pkwy\nSuite 100\nMountain View, CA 94043\nUNITED STATES", out)
+
+	out, err = c.FormatAddress("GB", map[string]interface{}{
+		"lines":    []interface{}{"10 Downing Street"},
+		"city":     "London",
+		"postcode": "SW1A 2AA",
+		"country":  "GB",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "10 Downing Street\nLondon\nSW1A 2AA", out)
+
+	out, err = c.FormatAddress(map[string]interface{}{"street": "Kalverstraat 1\n2nd floor", "zip": "1012 NX", "city": "Amsterdam", "country": "NL"})
+	require.NoError(t, err)
+	assert.Equal(t, "Kalverstraat 1\n2nd floor\n1012 NX Amsterdam\nNETHERLANDS", out)
+
+	_, err = c.FormatAddress("not a map")
+	assert.Error(t, err)
+
+	_, err = c.FormatAddress(map[string]interface{}{"lines": 42})
+	assert.Error(t, err)
+
+	_, err = c.FormatAddress()
+	assert.Error(t, err)
+}
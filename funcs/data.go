@@ -2,6 +2,7 @@ package funcs
 
 import (
 	"context"
+	"strings"
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
 	"github.com/hairyhenderson/gomplate/v3/data"
@@ -28,11 +29,14 @@ func CreateDataFuncs(ctx context.Context, d *data.Data) map[string]interface{} {
 	f["ds"] = d.Datasource
 	f["datasourceExists"] = d.DatasourceExists
 	f["datasourceReachable"] = d.DatasourceReachable
+	f["datasourceWrite"] = d.DatasourceWrite
 	f["defineDatasource"] = d.DefineDatasource
 	f["include"] = d.Include
 	f["listDatasources"] = d.ListDatasources
 
-	ns := &DataFuncs{ctx}
+	ns := &DataFuncs{ctx: ctx, d: d}
+
+	f["stream"] = ns.Stream
 
 	f["data"] = func() interface{} { return ns }
 
@@ -41,6 +45,8 @@ func CreateDataFuncs(ctx context.Context, d *data.Data) map[string]interface{} {
 	f["yaml"] = ns.YAML
 	f["yamlArray"] = ns.YAMLArray
 	f["toml"] = ns.TOML
+	f["xml"] = ns.XML
+	f["hcl"] = ns.HCL
 	f["csv"] = ns.CSV
 	f["csvByRow"] = ns.CSVByRow
 	f["csvByColumn"] = ns.CSVByColumn
@@ -48,13 +54,41 @@ func CreateDataFuncs(ctx context.Context, d *data.Data) map[string]interface{} {
 	f["toJSONPretty"] = ns.ToJSONPretty
 	f["toYAML"] = ns.ToYAML
 	f["toTOML"] = ns.ToTOML
+	f["toXML"] = ns.ToXML
 	f["toCSV"] = ns.ToCSV
 	return f
 }
 
+// DataContextFuncs - variants of the datasource-reading functions in f (as
+// created by CreateDataFuncs) that take a context as their first argument,
+// and stop reading when it's cancelled. Returns nil if f has no datasource
+// functions.
+func DataContextFuncs(f map[string]interface{}) map[string]interface{} {
+	nsFunc, ok := f["data"].(func() interface{})
+	if !ok {
+		return nil
+	}
+	ns, ok := nsFunc().(*DataFuncs)
+	if !ok || ns.d == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"datasource": ns.d.DatasourceContext,
+		"ds":         ns.d.DatasourceContext,
+		"include":    ns.d.IncludeContext,
+	}
+}
+
 // DataFuncs -
 type DataFuncs struct {
 	ctx context.Context
+	d   *data.Data
+}
+
+// Stream - streams are tracked in the context the functions were created
+// with, so they're only closed at the end of that render
+func (f *DataFuncs) Stream(alias string, args ...string) (<-chan interface{}, error) {
+	return f.d.StreamContext(f.ctx, alias, args...)
 }
 
 // JSON -
@@ -82,6 +116,16 @@ func (f *DataFuncs) TOML(in interface{}) (interface{}, error) {
 	return data.TOML(conv.ToString(in))
 }
 
+// XML -
+func (f *DataFuncs) XML(in interface{}) (map[string]interface{}, error) {
+	return data.XML(conv.ToString(in))
+}
+
+// HCL -
+func (f *DataFuncs) HCL(in interface{}) (map[string]interface{}, error) {
+	return data.HCL(conv.ToString(in))
+}
+
 // CSV -
 func (f *DataFuncs) CSV(args ...string) ([][]string, error) {
 	return data.CSV(args...)
@@ -97,11 +141,21 @@ func (f *DataFuncs) CSVByColumn(args ...string) (cols map[string][]string, err e
 	return data.CSVByColumn(args...)
 }
 
+// ToXML -
+func (f *DataFuncs) ToXML(in interface{}) (string, error) {
+	return data.ToXML(in)
+}
+
 // ToCSV -
 func (f *DataFuncs) ToCSV(args ...interface{}) (string, error) {
 	return data.ToCSV(args...)
 }
 
+// ToXLSX -
+func (f *DataFuncs) ToXLSX(in interface{}) (string, error) {
+	return data.ToXLSX(in)
+}
+
 // ToJSON -
 func (f *DataFuncs) ToJSON(in interface{}) (string, error) {
 	return data.ToJSON(in)
@@ -121,3 +175,28 @@ func (f *DataFuncs) ToYAML(in interface{}) (string, error) {
 func (f *DataFuncs) ToTOML(in interface{}) (string, error) {
 	return data.ToTOML(in)
 }
+
+// Diff -
+func (f *DataFuncs) Diff(a, b interface{}) []interface{} {
+	return data.Diff(a, b)
+}
+
+// Patch -
+func (f *DataFuncs) Patch(patch, doc interface{}) (interface{}, error) {
+	if s, ok := patch.(string); ok {
+		var err error
+		patch, err = parsePatch(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data.Patch(patch, doc)
+}
+
+// parsePatch - parse a JSON Patch (array) or JSON Merge Patch (object) string
+func parsePatch(s string) (interface{}, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), "[") {
+		return data.JSONArray(s)
+	}
+	return data.JSON(s)
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/hairyhenderson/gomplate/v3/data"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,3 +25,40 @@ func TestCreateDataFuncs(t *testing.T) {
 		})
 	}
 }
+
+func TestDataContextFuncs(t *testing.T) {
+	t.Parallel()
+
+	f := DataContextFuncs(CreateDataFuncs(context.Background(), &data.Data{}))
+	assert.Contains(t, f, "ds")
+	assert.Contains(t, f, "datasource")
+	assert.Contains(t, f, "include")
+
+	assert.Nil(t, DataContextFuncs(CreateDataFuncs(context.Background(), nil)))
+	assert.Nil(t, DataContextFuncs(map[string]interface{}{}))
+}
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	f := &DataFuncs{}
+	doc := map[string]interface{}{"a": 1.0, "b": 2.0}
+
+	out, err := f.Patch(`[{"op":"remove","path":"/a"}]`, doc)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"b": 2.0}, out)
+
+	out, err = f.Patch(`{"b":null,"c":3}`, doc)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"a": 1.0, "c": 3}, out)
+
+	out, err = f.Patch(f.Diff(doc, map[string]interface{}{"a": 1.0}), doc)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"a": 1.0}, out)
+
+	_, err = f.Patch(`[{"op":"remove","path":"/z"}]`, doc)
+	assert.Error(t, err)
+
+	_, err = f.Patch(`not json`, doc)
+	assert.Error(t, err)
+}
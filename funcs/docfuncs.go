@@ -0,0 +1,75 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/pdf"
+)
+
+// CreateDocFuncs -
+func CreateDocFuncs(ctx context.Context) map[string]interface{} {
+	ns := &DocFuncs{ctx}
+	return map[string]interface{}{
+		"doc": func() interface{} { return ns },
+	}
+}
+
+// DocFuncs -
+type DocFuncs struct {
+	ctx context.Context
+}
+
+// ToPDF - render a Markdown or HTML document as a PDF. The optional first
+// argument is a map of options (format, title, and pageSize).
+func (DocFuncs) ToPDF(args ...interface{}) (string, error) {
+	var in string
+	opts := pdf.Options{}
+	format := ""
+	switch len(args) {
+	case 1:
+		in = conv.ToString(args[0])
+	case 2:
+		o, ok := args[0].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("options must be a map, got %T", args[0])
+		}
+		for k, v := range o {
+			switch k {
+			case "format":
+				format = strings.ToLower(conv.ToString(v))
+			case "title":
+				opts.Title = conv.ToString(v)
+			case "pageSize":
+				opts.PageSize = conv.ToString(v)
+			default:
+				return "", fmt.Errorf("unknown PDF option %q", k)
+			}
+		}
+		in = conv.ToString(args[1])
+	default:
+		return "", fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+
+	// guess the format when not given - HTML documents start with a tag
+	if format == "" {
+		format = "markdown"
+		if strings.HasPrefix(strings.TrimSpace(in), "<") {
+			format = "html"
+		}
+	}
+
+	var b []byte
+	var err error
+	switch format {
+	case "markdown", "md":
+		b, err = pdf.FromMarkdown(in, opts)
+	case "html":
+		b, err = pdf.FromHTML(in, opts)
+	default:
+		return "", fmt.Errorf("unsupported input format %q (must be markdown or html)", format)
+	}
+	return string(b), err
+}
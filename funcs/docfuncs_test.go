@@ -0,0 +1,60 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDocFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateDocFuncs(ctx)
+			actual := fmap["doc"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*DocFuncs).ctx)
+		})
+	}
+}
+
+func TestToPDF(t *testing.T) {
+	t.Parallel()
+
+	d := DocFuncs{}
+
+	out, err := d.ToPDF("# hello")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "%PDF-1.4")
+	assert.Contains(t, out, "(hello) Tj")
+
+	// HTML is detected
+	out, err = d.ToPDF("<h1>hello</h1>")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "(hello) Tj")
+
+	out, err = d.ToPDF(map[string]interface{}{"format": "markdown", "title": "T", "pageSize": "Letter"}, "<b>hi</b>")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "(<b>hi</b>) Tj")
+	assert.Contains(t, out, "/Title (T)")
+	assert.Contains(t, out, "/MediaBox [0 0 612 792]")
+
+	_, err = d.ToPDF(map[string]interface{}{"format": "docx"}, "hi")
+	assert.Error(t, err)
+
+	_, err = d.ToPDF(map[string]interface{}{"bogus": true}, "hi")
+	assert.Error(t, err)
+
+	_, err = d.ToPDF("foo", "hi")
+	assert.Error(t, err)
+
+	_, err = d.ToPDF()
+	assert.Error(t, err)
+}
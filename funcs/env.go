@@ -5,6 +5,7 @@ import (
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
 	"github.com/hairyhenderson/gomplate/v3/env"
+	"github.com/hairyhenderson/gomplate/v3/internal/provenance"
 )
 
 // EnvNS - the Env namespace
@@ -37,8 +38,12 @@ type EnvFuncs struct {
 }
 
 // Getenv -
-func (EnvFuncs) Getenv(key interface{}, def ...string) string {
-	return env.Getenv(conv.ToString(key), def...)
+func (f EnvFuncs) Getenv(key interface{}, def ...string) string {
+	k := conv.ToString(key)
+	if v := env.Getenv(k); v != "" {
+		provenance.FromContext(f.ctx).Record("env "+k, v)
+	}
+	return env.Getenv(k, def...)
 }
 
 // ExpandEnv -
@@ -0,0 +1,57 @@
+package funcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"html/template"
+	"net/url"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	gompstrings "github.com/hairyhenderson/gomplate/v3/strings"
+)
+
+// CreateEscFuncs -
+func CreateEscFuncs(ctx context.Context) map[string]interface{} {
+	ns := &EscFuncs{ctx}
+	return map[string]interface{}{
+		"esc": func() interface{} { return ns },
+	}
+}
+
+// EscFuncs -
+type EscFuncs struct {
+	ctx context.Context
+}
+
+// HTML -
+func (EscFuncs) HTML(in interface{}) string {
+	return template.HTMLEscapeString(conv.ToString(in))
+}
+
+// JS -
+func (EscFuncs) JS(in interface{}) string {
+	return template.JSEscapeString(conv.ToString(in))
+}
+
+// CSS -
+func (EscFuncs) CSS(in interface{}) string {
+	return gompstrings.CSSEscape(conv.ToString(in))
+}
+
+// URLQuery -
+func (EscFuncs) URLQuery(in interface{}) string {
+	return url.QueryEscape(conv.ToString(in))
+}
+
+// URLPath -
+func (EscFuncs) URLPath(in interface{}) string {
+	return url.PathEscape(conv.ToString(in))
+}
+
+// XML -
+func (EscFuncs) XML(in interface{}) (string, error) {
+	out := &bytes.Buffer{}
+	err := xml.EscapeText(out, []byte(conv.ToString(in)))
+	return out.String(), err
+}
@@ -0,0 +1,43 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateEscFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateEscFuncs(ctx)
+			actual := fmap["esc"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*EscFuncs).ctx)
+		})
+	}
+}
+
+func TestEsc(t *testing.T) {
+	t.Parallel()
+
+	e := EscFuncs{}
+	assert.Equal(t, "&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;", e.HTML(`<a href="x">&</a>`))
+	assert.Equal(t, `it\'s \"quoted\"\u003C/script\u003E`, e.JS(`it's "quoted"</script>`))
+	assert.Equal(t, `\22 \20red\3b \20\22`, e.CSS(`" red; "`))
+	assert.Equal(t, "a+b%26c%3Dd", e.URLQuery("a b&c=d"))
+	assert.Equal(t, "a%20b%2Fc", e.URLPath("a b/c"))
+
+	out, err := e.XML(`<foo a="b">'&'</foo>`)
+	assert.NoError(t, err)
+	assert.Equal(t, "&lt;foo a=&#34;b&#34;&gt;&#39;&amp;&#39;&lt;/foo&gt;", out)
+
+	assert.Equal(t, "42", e.HTML(42))
+}
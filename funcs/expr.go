@@ -0,0 +1,70 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/expr"
+)
+
+// CreateExprFuncs -
+func CreateExprFuncs(ctx context.Context) map[string]interface{} {
+	ns := &ExprFuncs{ctx: ctx, eval: expr.New()}
+	return map[string]interface{}{
+		"expr": func() interface{} { return ns },
+	}
+}
+
+// ExprFuncs -
+type ExprFuncs struct {
+	ctx  context.Context
+	eval *expr.Evaluator
+}
+
+// Eval - evaluates a CEL expression. The optional context is available as the
+// ctx variable, and when it's a map, its keys are available as variables too.
+func (f *ExprFuncs) Eval(expression interface{}, tmplCtx ...interface{}) (interface{}, error) {
+	if len(tmplCtx) > 1 {
+		return nil, fmt.Errorf("wrong number of args: want 1 or 2, got %d", len(tmplCtx)+1)
+	}
+
+	vars := map[string]interface{}{}
+	if len(tmplCtx) == 1 {
+		c := contextMap(tmplCtx[0])
+		for k, v := range c {
+			vars[k] = v
+		}
+		if _, ok := vars["ctx"]; !ok {
+			vars["ctx"] = tmplCtx[0]
+			if c != nil {
+				vars["ctx"] = c
+			}
+		}
+	}
+
+	return f.eval.Eval(f.ctx, conv.ToString(expression), vars)
+}
+
+// contextMap - the context as a map[string]interface{}, when it's a map (or a
+// pointer to one) with string keys, or nil otherwise
+func contextMap(in interface{}) map[string]interface{} {
+	v := reflect.ValueOf(in)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+
+	out := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		out[iter.Key().String()] = iter.Value().Interface()
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExprFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateExprFuncs(ctx)
+			actual := fmap["expr"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*ExprFuncs).ctx)
+		})
+	}
+}
+
+type testTmplCtx map[string]interface{}
+
+func TestExprEval(t *testing.T) {
+	t.Parallel()
+
+	f := &ExprFuncs{ctx: context.Background(), eval: expr.New()}
+
+	out, err := f.Eval("1 + 2 * 3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), out)
+
+	// like gomplate's own context, a pointer to a named map type
+	c := &testTmplCtx{"Values": map[string]interface{}{"replicas": 3}}
+	out, err = f.Eval("Values.replicas > 2", c)
+	require.NoError(t, err)
+	assert.Equal(t, true, out)
+
+	out, err = f.Eval("ctx.Values.replicas", c)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), out)
+
+	out, err = f.Eval("ctx.map(x, x * 2)", []interface{}{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(2), int64(4)}, out)
+
+	_, err = f.Eval("1", 1, 2)
+	assert.Error(t, err)
+}
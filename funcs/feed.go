@@ -0,0 +1,57 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/feed"
+)
+
+// CreateFeedFuncs -
+func CreateFeedFuncs(ctx context.Context) map[string]interface{} {
+	ns := &FeedFuncs{ctx}
+	return map[string]interface{}{
+		"feed": func() interface{} { return ns },
+	}
+}
+
+// FeedFuncs -
+type FeedFuncs struct {
+	ctx context.Context
+}
+
+// Parse -
+func (FeedFuncs) Parse(in interface{}) (map[string]interface{}, error) {
+	f, err := feed.Parse(conv.ToString(in))
+	if err != nil {
+		return nil, err
+	}
+	return f.Map(), nil
+}
+
+// ToAtom -
+func (FeedFuncs) ToAtom(in interface{}) (string, error) {
+	f, err := toFeed(in)
+	if err != nil {
+		return "", err
+	}
+	return feed.ToAtom(f)
+}
+
+// ToRSS -
+func (FeedFuncs) ToRSS(in interface{}) (string, error) {
+	f, err := toFeed(in)
+	if err != nil {
+		return "", err
+	}
+	return feed.ToRSS(f)
+}
+
+func toFeed(in interface{}) (*feed.Feed, error) {
+	m, ok := in.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("feed must be a map, got %T", in)
+	}
+	return feed.FromMap(m)
+}
@@ -0,0 +1,69 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFeedFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateFeedFuncs(ctx)
+			actual := fmap["feed"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*FeedFuncs).ctx)
+		})
+	}
+}
+
+func TestFeedRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	f := FeedFuncs{}
+	in := map[string]interface{}{
+		"title": "News",
+		"link":  "https://example.com/",
+		"items": []interface{}{
+			map[string]interface{}{
+				"title":     "Hello",
+				"link":      "https://example.com/hello",
+				"published": "2024-03-04T10:00:00Z",
+			},
+		},
+	}
+
+	rss, err := f.ToRSS(in)
+	require.NoError(t, err)
+	assert.Contains(t, rss, "<title>Hello</title>")
+
+	atom, err := f.ToAtom(in)
+	require.NoError(t, err)
+	assert.Contains(t, atom, "<updated>2024-03-04T10:00:00Z</updated>")
+
+	for _, s := range []string{rss, atom} {
+		out, err := f.Parse(s)
+		require.NoError(t, err)
+		assert.Equal(t, "News", out["title"])
+		items := out["items"].([]interface{})
+		assert.Equal(t, "https://example.com/hello", items[0].(map[string]interface{})["link"])
+	}
+
+	_, err = f.ToRSS("foo")
+	assert.Error(t, err)
+
+	_, err = f.ToAtom(map[string]interface{}{"bogus": true})
+	assert.Error(t, err)
+
+	_, err = f.Parse("not a feed")
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package funcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/ofrep"
+	"github.com/rs/zerolog"
+)
+
+// CreateFlagFuncs -
+func CreateFlagFuncs(ctx context.Context, d *data.Data) map[string]interface{} {
+	ns := &FlagFuncs{ctx: ctx, data: d, cache: map[string]*ofrep.Evaluation{}}
+	return map[string]interface{}{
+		"flag": func() interface{} { return ns },
+	}
+}
+
+// FlagFuncs - evaluates feature flags with an OpenFeature (OFREP) service.
+// Like OpenFeature's SDKs, the default value is returned (and a warning is
+// logged) when a flag can't be evaluated, so rendering doesn't fail when the
+// service is down.
+type FlagFuncs struct {
+	ctx    context.Context
+	data   *data.Data
+	client *ofrep.Client
+	// each flag is only evaluated once per render (for each evaluation
+	// context), so its value is consistent
+	cache map[string]*ofrep.Evaluation
+	mu    sync.Mutex
+}
+
+// Bool - evaluates a boolean flag
+func (f *FlagFuncs) Bool(key string, def interface{}, evalCtx ...map[string]interface{}) (bool, error) {
+	d := conv.ToBool(def)
+	v, ok, err := f.evaluate(key, evalCtx)
+	if err != nil || !ok {
+		return d, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		f.mismatch(key, "bool", v)
+		return d, nil
+	}
+	return b, nil
+}
+
+// String - evaluates a string flag
+func (f *FlagFuncs) String(key string, def interface{}, evalCtx ...map[string]interface{}) (string, error) {
+	d := conv.ToString(def)
+	v, ok, err := f.evaluate(key, evalCtx)
+	if err != nil || !ok {
+		return d, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		f.mismatch(key, "string", v)
+		return d, nil
+	}
+	return s, nil
+}
+
+// Number - evaluates a numeric flag
+func (f *FlagFuncs) Number(key string, def interface{}, evalCtx ...map[string]interface{}) (float64, error) {
+	d := conv.ToFloat64(def)
+	v, ok, err := f.evaluate(key, evalCtx)
+	if err != nil || !ok {
+		return d, err
+	}
+	n, ok := v.(float64)
+	if !ok {
+		f.mismatch(key, "number", v)
+		return d, nil
+	}
+	return n, nil
+}
+
+// Object - evaluates a flag with any type of value (usually an object)
+func (f *FlagFuncs) Object(key string, def interface{}, evalCtx ...map[string]interface{}) (interface{}, error) {
+	v, ok, err := f.evaluate(key, evalCtx)
+	if err != nil || !ok {
+		return def, err
+	}
+	return v, nil
+}
+
+// evaluate evaluates the flag, returning false when the default should be
+// used instead. Only invalid arguments are returned as errors.
+func (f *FlagFuncs) evaluate(key string, evalCtx []map[string]interface{}) (interface{}, bool, error) {
+	if len(evalCtx) > 1 {
+		return nil, false, fmt.Errorf("wrong number of args: want 2 or 3, got %d", len(evalCtx)+2)
+	}
+	var ec map[string]interface{}
+	if len(evalCtx) == 1 {
+		ec = evalCtx[0]
+	}
+	ecJSON, err := json.Marshal(ec)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid evaluation context: %w", err)
+	}
+	cacheKey := key + "\x00" + string(ecJSON)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.cache[cacheKey]
+	if !ok {
+		e, err = f.eval(key, ec)
+		if err != nil {
+			e = &ofrep.Evaluation{Key: key, ErrorCode: "PROVIDER_NOT_READY", ErrorDetails: err.Error()}
+		}
+		f.cache[cacheKey] = e
+		// only warned about once, like the flag is only evaluated once
+		if err := e.Err(); err != nil {
+			zerolog.Ctx(f.ctx).Warn().Err(err).Msg("using the feature flag's default value")
+		}
+	}
+	if e.ErrorCode != "" {
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+// eval - must be called with f.mu held
+func (f *FlagFuncs) eval(key string, ec map[string]interface{}) (*ofrep.Evaluation, error) {
+	if f.client == nil {
+		// the same transport as the ofrep datasource, so the network policy
+		// and rate limits apply
+		rt := http.DefaultTransport
+		if f.data != nil {
+			rt = f.data.Transport("ofrep")
+		}
+		c, err := ofrep.New(&http.Client{Timeout: 10 * time.Second, Transport: rt}, "", "")
+		if err != nil {
+			return nil, err
+		}
+		f.client = c
+	}
+	return f.client.Evaluate(f.ctx, key, ec)
+}
+
+func (f *FlagFuncs) mismatch(key, want string, v interface{}) {
+	zerolog.Ctx(f.ctx).Warn().Str("flag", key).
+		Msgf("feature flag has type %T, not %s - using default value", v, want)
+}
@@ -0,0 +1,139 @@
+package funcs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFlagFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateFlagFuncs(ctx, nil)
+			actual := fmap["flag"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*FlagFuncs).ctx)
+		})
+	}
+}
+
+func TestFlagFuncs(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/ofrep/v1/evaluate/flags/new-ui":
+			_, _ = w.Write([]byte(`{"key": "new-ui", "value": true}`))
+		case "/ofrep/v1/evaluate/flags/banner":
+			_, _ = w.Write([]byte(`{"key": "banner", "value": "hello"}`))
+		case "/ofrep/v1/evaluate/flags/replicas":
+			_, _ = w.Write([]byte(`{"key": "replicas", "value": 3}`))
+		case "/ofrep/v1/evaluate/flags/limits":
+			_, _ = w.Write([]byte(`{"key": "limits", "value": {"max": 10}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errorCode": "FLAG_NOT_FOUND"}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("OFREP_URL", srv.URL)
+
+	f := CreateFlagFuncs(context.Background(), nil)["flag"].(func() interface{})().(*FlagFuncs)
+
+	b, err := f.Bool("new-ui", false)
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	s, err := f.String("banner", "none", map[string]interface{}{"env": "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", s)
+
+	n, err := f.Number("replicas", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, n)
+
+	o, err := f.Object("limits", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"max": 10.0}, o)
+
+	// missing flags, and the wrong type, get the default
+	b, err = f.Bool("missing", "true")
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	b, err = f.Bool("banner", false)
+	require.NoError(t, err)
+	assert.False(t, b)
+
+	// flags are only evaluated once
+	calls = 0
+	_, _ = f.Bool("new-ui", false)
+	_, _ = f.Bool("missing", false)
+	assert.Equal(t, 0, calls)
+
+	_, err = f.Bool("new-ui", false, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestFlagFuncs_NotConfigured(t *testing.T) {
+	t.Setenv("OFREP_URL", "")
+	f := CreateFlagFuncs(context.Background(), nil)["flag"].(func() interface{})().(*FlagFuncs)
+
+	s, err := f.String("banner", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "default", s)
+}
+
+func TestFlagFuncs_NetworkPolicy(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"key": "banner", "value": "hello"}`))
+	}))
+	defer srv.Close()
+	t.Setenv("OFREP_URL", srv.URL)
+
+	// flags are evaluated with the datasources' transport for the ofrep
+	// scheme, so the network policy applies
+	d := &data.Data{NetworkPolicy: netpolicy.New(&config.NetworkPolicy{Allow: []config.NetworkRule{
+		{Schemes: []string{"ofrep"}, Hosts: []string{"example.com"}},
+	}})}
+	f := CreateFlagFuncs(context.Background(), d)["flag"].(func() interface{})().(*FlagFuncs)
+
+	s, err := f.String("banner", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "default", s)
+	assert.Equal(t, 0, calls)
+
+	d = &data.Data{NetworkPolicy: netpolicy.Offline("testing")}
+	f = CreateFlagFuncs(context.Background(), d)["flag"].(func() interface{})().(*FlagFuncs)
+
+	s, err = f.String("banner", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "default", s)
+	assert.Equal(t, 0, calls)
+
+	d = &data.Data{NetworkPolicy: netpolicy.New(&config.NetworkPolicy{Allow: []config.NetworkRule{
+		{Schemes: []string{"ofrep"}, Hosts: []string{"127.0.0.1"}},
+	}})}
+	f = CreateFlagFuncs(context.Background(), d)["flag"].(func() interface{})().(*FlagFuncs)
+
+	s, err = f.String("banner", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", s)
+	assert.Equal(t, 1, calls)
+}
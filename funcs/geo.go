@@ -0,0 +1,219 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/geo"
+)
+
+// CreateGeoFuncs -
+func CreateGeoFuncs(ctx context.Context) map[string]interface{} {
+	ns := &GeoFuncs{ctx}
+	return map[string]interface{}{
+		"geo": func() interface{} { return ns },
+	}
+}
+
+// GeoFuncs -
+type GeoFuncs struct {
+	ctx context.Context
+}
+
+// Distance - returns the great-circle distance between two points, given
+// either as two positions or as four numbers (lat1, lon1, lat2, lon2),
+// optionally followed by the unit (default "km")
+func (GeoFuncs) Distance(args ...interface{}) (float64, error) {
+	var a, b geo.Point
+	var err error
+	unit := "km"
+	switch len(args) {
+	case 2, 3:
+		a, err = geo.ParsePoint(args[0])
+		if err != nil {
+			return 0, err
+		}
+		b, err = geo.ParsePoint(args[1])
+		if err != nil {
+			return 0, err
+		}
+		if len(args) == 3 {
+			unit = conv.ToString(args[2])
+		}
+	case 4, 5:
+		a, err = geo.ParsePoint(map[string]interface{}{"lat": args[0], "lon": args[1]})
+		if err != nil {
+			return 0, err
+		}
+		b, err = geo.ParsePoint(map[string]interface{}{"lat": args[2], "lon": args[3]})
+		if err != nil {
+			return 0, err
+		}
+		if len(args) == 5 {
+			unit = conv.ToString(args[4])
+		}
+	default:
+		return 0, fmt.Errorf("wrong number of args: want 2 to 5, got %d", len(args))
+	}
+
+	return geo.ConvertDistance(geo.Distance(a, b), unit)
+}
+
+// GeohashEncode - encodes a position (or a latitude and longitude) as a
+// geohash, with the optional precision (default 9 characters)
+func (GeoFuncs) GeohashEncode(args ...interface{}) (string, error) {
+	p, rest, err := pointArgs(args)
+	if err != nil {
+		return "", err
+	}
+	precision := 9
+	switch len(rest) {
+	case 0:
+	case 1:
+		precision = conv.ToInt(rest[0])
+	default:
+		return "", fmt.Errorf("wrong number of args: want 1 to 3, got %d", len(args))
+	}
+	return geo.EncodeGeohash(p, precision)
+}
+
+// GeohashDecode - decodes the geohash, returning a map with the "lat" and "lon"
+// of the center of its cell, and the cell's GeoJSON-style "bbox"
+func (GeoFuncs) GeohashDecode(hash interface{}) (map[string]interface{}, error) {
+	b, err := geo.DecodeGeohash(conv.ToString(hash))
+	if err != nil {
+		return nil, err
+	}
+	c := b.Center()
+	return map[string]interface{}{
+		"lat":  c.Lat,
+		"lon":  c.Lon,
+		"bbox": []interface{}{b.MinLon, b.MinLat, b.MaxLon, b.MaxLat},
+	}, nil
+}
+
+// PointInPolygon - returns true if the position (or latitude and longitude) is
+// inside any of the polygons in the GeoJSON geometry, Feature, or
+// FeatureCollection, or inside the ring of positions
+func (GeoFuncs) PointInPolygon(polygon interface{}, args ...interface{}) (bool, error) {
+	p, rest, err := pointArgs(args)
+	if err != nil {
+		return false, err
+	}
+	if len(rest) > 0 {
+		return false, fmt.Errorf("wrong number of args: want 2 or 3, got %d", len(args)+1)
+	}
+	polys, err := geo.ParsePolygons(polygon)
+	if err != nil {
+		return false, err
+	}
+	for _, poly := range polys {
+		if poly.Contains(p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Point - returns a GeoJSON Point geometry for the position (or latitude and
+// longitude)
+func (GeoFuncs) Point(args ...interface{}) (map[string]interface{}, error) {
+	p, rest, err := pointArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("wrong number of args: want 1 or 2, got %d", len(args))
+	}
+	return geo.PointGeometry(p), nil
+}
+
+// Polygon - returns a GeoJSON Polygon geometry for the array of positions (or
+// array of rings), closing any rings that aren't closed
+func (GeoFuncs) Polygon(coords interface{}) (map[string]interface{}, error) {
+	// an array of rings is an array of arrays of positions
+	if s, ok := coords.([]interface{}); ok && len(s) > 0 && isArrayOfPositions(s[0]) {
+		coords = map[string]interface{}{"type": "Polygon", "coordinates": s}
+	}
+	polys, err := geo.ParsePolygons(coords)
+	if err != nil {
+		return nil, err
+	}
+	if len(polys) != 1 {
+		return nil, fmt.Errorf("expected 1 polygon, got %d", len(polys))
+	}
+	return geo.PolygonGeometry(polys[0]), nil
+}
+
+// Feature - returns a GeoJSON Feature of the geometry, with the optional
+// properties
+func (GeoFuncs) Feature(geometry interface{}, properties ...interface{}) (map[string]interface{}, error) {
+	g, ok := geometry.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("geometry must be a GeoJSON geometry object, got %T", geometry)
+	}
+	var props map[string]interface{}
+	switch len(properties) {
+	case 0:
+	case 1:
+		props, ok = properties[0].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("properties must be a map, got %T", properties[0])
+		}
+	default:
+		return nil, fmt.Errorf("wrong number of args: want 1 or 2, got %d", len(properties)+1)
+	}
+	return geo.Feature(g, props)
+}
+
+// FeatureCollection - returns a GeoJSON FeatureCollection of the features,
+// given as separate arguments or as a single array
+func (GeoFuncs) FeatureCollection(features ...interface{}) (map[string]interface{}, error) {
+	if len(features) == 1 {
+		if s, ok := features[0].([]interface{}); ok {
+			features = s
+		}
+	}
+	fs := make([]map[string]interface{}, len(features))
+	for i, f := range features {
+		m, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("features must be GeoJSON Feature objects, got %T", f)
+		}
+		fs[i] = m
+	}
+	return geo.FeatureCollection(fs...)
+}
+
+// pointArgs parses a point from the start of the args - either a single
+// position (an array or map), or a latitude and longitude - and returns the
+// remaining args
+func pointArgs(args []interface{}) (geo.Point, []interface{}, error) {
+	if len(args) == 0 {
+		return geo.Point{}, nil, fmt.Errorf("missing position")
+	}
+	switch reflect.ValueOf(args[0]).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		p, err := geo.ParsePoint(args[0])
+		return p, args[1:], err
+	}
+	if len(args) < 2 {
+		return geo.Point{}, nil, fmt.Errorf("missing longitude")
+	}
+	p, err := geo.ParsePoint(map[string]interface{}{"lat": args[0], "lon": args[1]})
+	return p, args[2:], err
+}
+
+func isArrayOfPositions(v interface{}) bool {
+	s, ok := v.([]interface{})
+	if !ok || len(s) == 0 {
+		return false
+	}
+	switch s[0].(type) {
+	case []interface{}, []float64, map[string]interface{}:
+		return true
+	}
+	return false
+}
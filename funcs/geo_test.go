@@ -0,0 +1,162 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGeoFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateGeoFuncs(ctx)
+			actual := fmap["geo"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*GeoFuncs).ctx)
+		})
+	}
+}
+
+func TestGeoDistance(t *testing.T) {
+	t.Parallel()
+
+	g := GeoFuncs{}
+	london := map[string]interface{}{"lat": 51.5074, "lon": -0.1278}
+	paris := []interface{}{2.3522, 48.8566}
+
+	d, err := g.Distance(london, paris)
+	require.NoError(t, err)
+	assert.InDelta(t, 343.56, d, 0.1)
+
+	d, err = g.Distance(51.5074, -0.1278, "48.8566", "2.3522", "mi")
+	require.NoError(t, err)
+	assert.InDelta(t, 213.48, d, 0.1)
+
+	d, err = g.Distance(london, paris, "m")
+	require.NoError(t, err)
+	assert.InDelta(t, 343560, d, 100)
+
+	_, err = g.Distance(london)
+	assert.Error(t, err)
+	_, err = g.Distance(london, paris, "leagues")
+	assert.Error(t, err)
+	_, err = g.Distance(91, 0, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestGeohash(t *testing.T) {
+	t.Parallel()
+
+	g := GeoFuncs{}
+
+	h, err := g.GeohashEncode(57.64911, 10.40744)
+	require.NoError(t, err)
+	assert.Equal(t, "u4pruydqq", h)
+
+	h, err = g.GeohashEncode(57.64911, 10.40744, 11)
+	require.NoError(t, err)
+	assert.Equal(t, "u4pruydqqvj", h)
+
+	h, err = g.GeohashEncode(map[string]interface{}{"lat": 57.64911, "lon": 10.40744}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "u4pru", h)
+
+	_, err = g.GeohashEncode(57.64911, 10.40744, 5, 6)
+	assert.Error(t, err)
+	_, err = g.GeohashEncode(57.64911)
+	assert.Error(t, err)
+
+	out, err := g.GeohashDecode("u4pruydqqvj")
+	require.NoError(t, err)
+	assert.InDelta(t, 57.64911, out["lat"], 0.00001)
+	assert.InDelta(t, 10.40744, out["lon"], 0.00001)
+	assert.Len(t, out["bbox"], 4)
+
+	_, err = g.GeohashDecode("a")
+	assert.Error(t, err)
+}
+
+func TestPointInPolygon(t *testing.T) {
+	t.Parallel()
+
+	g := GeoFuncs{}
+	ring := []interface{}{
+		[]interface{}{0, 0}, []interface{}{10, 0}, []interface{}{10, 10}, []interface{}{0, 10},
+	}
+
+	in, err := g.PointInPolygon(ring, 5, 5)
+	require.NoError(t, err)
+	assert.True(t, in)
+
+	in, err = g.PointInPolygon(ring, map[string]interface{}{"lat": 5, "lon": 15})
+	require.NoError(t, err)
+	assert.False(t, in)
+
+	poly, err := g.Polygon(ring)
+	require.NoError(t, err)
+	feature, err := g.Feature(poly)
+	require.NoError(t, err)
+	in, err = g.PointInPolygon(feature, []interface{}{5, 5})
+	require.NoError(t, err)
+	assert.True(t, in)
+
+	_, err = g.PointInPolygon(ring, 5, 5, 5)
+	assert.Error(t, err)
+	_, err = g.PointInPolygon("nope", 5, 5)
+	assert.Error(t, err)
+}
+
+func TestGeoJSON(t *testing.T) {
+	t.Parallel()
+
+	g := GeoFuncs{}
+
+	p, err := g.Point(51.5, -0.12)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"type":        "Point",
+		"coordinates": []interface{}{-0.12, 51.5},
+	}, p)
+
+	_, err = g.Point(51.5, -0.12, 3)
+	assert.Error(t, err)
+
+	poly, err := g.Polygon([]interface{}{
+		[]interface{}{
+			[]interface{}{0, 0}, []interface{}{10, 0}, []interface{}{10, 10},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Polygon", poly["type"])
+	assert.Len(t, poly["coordinates"].([]interface{})[0], 4)
+
+	f, err := g.Feature(p, map[string]interface{}{"name": "London"})
+	require.NoError(t, err)
+	assert.Equal(t, "Feature", f["type"])
+	assert.Equal(t, map[string]interface{}{"name": "London"}, f["properties"])
+
+	_, err = g.Feature("point")
+	assert.Error(t, err)
+	_, err = g.Feature(p, "props")
+	assert.Error(t, err)
+
+	fc, err := g.FeatureCollection(f, f)
+	require.NoError(t, err)
+	assert.Len(t, fc["features"], 2)
+
+	fc, err = g.FeatureCollection([]interface{}{f})
+	require.NoError(t, err)
+	assert.Len(t, fc["features"], 1)
+
+	_, err = g.FeatureCollection("feature")
+	assert.Error(t, err)
+}
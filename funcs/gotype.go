@@ -0,0 +1,41 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/gotype"
+)
+
+// CreateGoTypeFuncs -
+func CreateGoTypeFuncs(ctx context.Context) map[string]interface{} {
+	ns := &GoTypeFuncs{ctx}
+	return map[string]interface{}{
+		"gotype": func() interface{} { return ns },
+	}
+}
+
+// GoTypeFuncs -
+type GoTypeFuncs struct {
+	ctx context.Context
+}
+
+// FromSample -
+func (GoTypeFuncs) FromSample(name interface{}, sample interface{}) (string, error) {
+	return gotype.FromSample(conv.ToString(name), sample)
+}
+
+// FromSchema -
+func (GoTypeFuncs) FromSchema(name interface{}, schema interface{}) (string, error) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("schema must be a map, got %T", schema)
+	}
+	return gotype.FromSchema(conv.ToString(name), s)
+}
+
+// Name -
+func (GoTypeFuncs) Name(in interface{}) string {
+	return gotype.GoName(conv.ToString(in))
+}
@@ -0,0 +1,41 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateGoTypeFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateGoTypeFuncs(ctx)
+			actual := fmap["gotype"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*GoTypeFuncs).ctx)
+		})
+	}
+}
+
+func TestGoTypeFromSchema(t *testing.T) {
+	t.Parallel()
+
+	g := GoTypeFuncs{}
+	_, err := g.FromSchema("foo", "not a map")
+	assert.Error(t, err)
+
+	out, err := g.FromSchema("foo", map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"bar": map[string]interface{}{"type": "string"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "type Foo struct {\n\tBar string `json:\"bar,omitempty\" yaml:\"bar,omitempty\"`\n}\n", out)
+}
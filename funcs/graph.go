@@ -0,0 +1,86 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3/graph"
+)
+
+// CreateGraphFuncs -
+func CreateGraphFuncs(ctx context.Context) map[string]interface{} {
+	ns := &GraphFuncs{ctx}
+	return map[string]interface{}{
+		"graph": func() interface{} { return ns },
+	}
+}
+
+// GraphFuncs -
+type GraphFuncs struct {
+	ctx context.Context
+}
+
+// TopoSort - returns the graph's nodes in topological order, so that for
+// every edge, the node it's from comes before the node it's to. Errors if the
+// graph has a cycle.
+func (GraphFuncs) TopoSort(edges interface{}) ([]string, error) {
+	g, err := graph.Parse(edges)
+	if err != nil {
+		return nil, err
+	}
+	return g.TopoSort()
+}
+
+// ShortestPath - returns the lowest-weight path of nodes between from and to,
+// or an empty list if there's no path
+func (GraphFuncs) ShortestPath(from, to string, edges interface{}) ([]string, error) {
+	g, err := graph.Parse(edges)
+	if err != nil {
+		return nil, err
+	}
+	path, _, err := g.ShortestPath(from, to)
+	if path == nil {
+		path = []string{}
+	}
+	return path, err
+}
+
+// PathWeight - returns the total weight of the lowest-weight path between
+// from and to. Errors if there's no path.
+func (GraphFuncs) PathWeight(from, to string, edges interface{}) (float64, error) {
+	g, err := graph.Parse(edges)
+	if err != nil {
+		return 0, err
+	}
+	path, weight, err := g.ShortestPath(from, to)
+	if err != nil {
+		return 0, err
+	}
+	if path == nil {
+		return 0, fmt.Errorf("no path from %q to %q", from, to)
+	}
+	return weight, nil
+}
+
+// FindCycle - returns a cycle in the graph (starting and ending with the same
+// node), or an empty list if the graph is acyclic
+func (GraphFuncs) FindCycle(edges interface{}) ([]string, error) {
+	g, err := graph.Parse(edges)
+	if err != nil {
+		return nil, err
+	}
+	cycle := g.FindCycle()
+	if cycle == nil {
+		cycle = []string{}
+	}
+	return cycle, nil
+}
+
+// IsAcyclic - returns true if the graph has no cycles
+func (GraphFuncs) IsAcyclic(edges interface{}) (bool, error) {
+	g, err := graph.Parse(edges)
+	if err != nil {
+		return false, err
+	}
+	return g.FindCycle() == nil, nil
+}
@@ -0,0 +1,105 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGraphFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateGraphFuncs(ctx)
+			actual := fmap["graph"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*GraphFuncs).ctx)
+		})
+	}
+}
+
+func TestGraphTopoSort(t *testing.T) {
+	t.Parallel()
+
+	g := GraphFuncs{}
+	out, err := g.TopoSort(map[string]interface{}{
+		"app": []interface{}{"db"},
+		"db":  []interface{}{"storage"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", "db", "storage"}, out)
+
+	_, err = g.TopoSort(map[string]interface{}{
+		"a": []interface{}{"b"},
+		"b": []interface{}{"a"},
+	})
+	assert.EqualError(t, err, "graph has a cycle: a -> b -> a")
+
+	_, err = g.TopoSort("nope")
+	assert.Error(t, err)
+}
+
+func TestGraphShortestPath(t *testing.T) {
+	t.Parallel()
+
+	g := GraphFuncs{}
+	edges := []interface{}{
+		map[string]interface{}{"from": "a", "to": "b", "weight": 5},
+		map[string]interface{}{"from": "a", "to": "c", "weight": 1},
+		map[string]interface{}{"from": "c", "to": "b", "weight": 1},
+		map[string]interface{}{"from": "d", "to": "a"},
+	}
+
+	path, err := g.ShortestPath("a", "b", edges)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b"}, path)
+
+	path, err = g.ShortestPath("b", "a", edges)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, path)
+
+	_, err = g.ShortestPath("a", "z", edges)
+	assert.Error(t, err)
+
+	w, err := g.PathWeight("d", "b", edges)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, w)
+
+	_, err = g.PathWeight("b", "a", edges)
+	assert.Error(t, err)
+}
+
+func TestGraphCycles(t *testing.T) {
+	t.Parallel()
+
+	g := GraphFuncs{}
+	cyclic := [][]string{{"a", "b"}, {"b", "c"}, {"c", "a"}}
+	acyclic := [][]string{{"a", "b"}, {"b", "c"}}
+
+	c, err := g.FindCycle(cyclic)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "a"}, c)
+
+	c, err = g.FindCycle(acyclic)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, c)
+
+	ok, err := g.IsAcyclic(acyclic)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = g.IsAcyclic(cyclic)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = g.IsAcyclic(42)
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+	"github.com/hairyhenderson/gomplate/v3/k8s"
+)
+
+// CreateK8sFuncs -
+func CreateK8sFuncs(ctx context.Context) map[string]interface{} {
+	ns := &K8sFuncs{ctx}
+	return map[string]interface{}{
+		"k8s": func() interface{} { return ns },
+	}
+}
+
+// K8sFuncs -
+type K8sFuncs struct {
+	ctx context.Context
+}
+
+// StrategicMergePatch -
+func (K8sFuncs) StrategicMergePatch(args ...interface{}) (map[string]interface{}, error) {
+	mergeKeys, rest, err := k8sArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 2 {
+		return nil, fmt.Errorf("wrong number of args: wanted 2 (not counting options), got %d", len(rest))
+	}
+	base, err := parseDocArg(rest[0], "base manifest")
+	if err != nil {
+		return nil, err
+	}
+	patch, err := parseDocArg(rest[1], "patch")
+	if err != nil {
+		return nil, err
+	}
+	return k8s.StrategicMergePatch(base, patch, mergeKeys)
+}
+
+// Overlay -
+func (K8sFuncs) Overlay(args ...interface{}) (interface{}, error) {
+	mergeKeys, rest, err := k8sArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < 2 {
+		return nil, fmt.Errorf("wrong number of args: wanted at least 2 (not counting options), got %d", len(rest))
+	}
+
+	single := false
+	var resources []interface{}
+	switch r := rest[0].(type) {
+	case map[string]interface{}, string:
+		m, err := parseDocArg(r, "resource")
+		if err != nil {
+			return nil, err
+		}
+		resources = []interface{}{m}
+		single = true
+	default:
+		resources, err = iconv.InterfaceSlice(r)
+		if err != nil {
+			return nil, fmt.Errorf("resources must be a map or a list: %w", err)
+		}
+	}
+
+	patches := []map[string]interface{}{}
+	for _, p := range rest[1:] {
+		l, err := iconv.InterfaceSlice(p)
+		if err != nil {
+			l = []interface{}{p}
+		}
+		for _, e := range l {
+			m, err := parseDocArg(e, "patch")
+			if err != nil {
+				return nil, err
+			}
+			patches = append(patches, m)
+		}
+	}
+
+	out, err := k8s.Overlay(resources, patches, mergeKeys)
+	if err != nil {
+		return nil, err
+	}
+	if single {
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return out[0], nil
+	}
+	return out, nil
+}
+
+// k8sArgs separates the optional leading options map from the rest of the
+// arguments. The only option is mergeKeys, a map of list field names to the
+// keys their items are merged by. Since manifests are maps too, a map is only
+// taken to be the options when it sets mergeKeys.
+func k8sArgs(args []interface{}) (map[string]string, []interface{}, error) {
+	if len(args) < 3 {
+		return nil, args, nil
+	}
+	opts, ok := args[0].(map[string]interface{})
+	if _, hasKeys := opts["mergeKeys"]; !ok || !hasKeys {
+		return nil, args, nil
+	}
+	mergeKeys := map[string]string{}
+	for k, v := range opts {
+		if k != "mergeKeys" {
+			return nil, nil, fmt.Errorf("unknown k8s option %q", k)
+		}
+		mk, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("mergeKeys must be a map, got %T", v)
+		}
+		for field, key := range mk {
+			mergeKeys[field] = conv.ToString(key)
+		}
+	}
+	return mergeKeys, args[1:], nil
+}
@@ -0,0 +1,94 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateK8sFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateK8sFuncs(ctx)
+			actual := fmap["k8s"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*K8sFuncs).ctx)
+		})
+	}
+}
+
+func TestStrategicMergePatch(t *testing.T) {
+	t.Parallel()
+
+	f := K8sFuncs{}
+	base := `{kind: Pod, metadata: {name: p}, spec: {containers: [{name: a, image: a:1}, {name: b, image: b:1}]}}`
+
+	out, err := f.StrategicMergePatch(base, `{spec: {containers: [{name: b, image: b:2}]}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "a", "image": "a:1"},
+		map[string]interface{}{"name": "b", "image": "b:2"},
+	}, out["spec"].(map[string]interface{})["containers"])
+
+	out, err = f.StrategicMergePatch(
+		map[string]interface{}{"mergeKeys": map[string]interface{}{"items": "id"}},
+		`{items: [{id: 1, v: a}]}`, `{items: [{id: 2, v: b}]}`)
+	require.NoError(t, err)
+	assert.Len(t, out["items"], 2)
+
+	_, err = f.StrategicMergePatch(map[string]interface{}{"mergeKeys": map[string]interface{}{}, "bogus": true}, base, base)
+	assert.Error(t, err)
+
+	_, err = f.StrategicMergePatch(base)
+	assert.Error(t, err)
+
+	_, err = f.StrategicMergePatch(base, 42)
+	assert.Error(t, err)
+}
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+
+	f := K8sFuncs{}
+	web := map[string]interface{}{
+		"kind":     "Deployment",
+		"metadata": map[string]interface{}{"name": "web"},
+		"spec":     map[string]interface{}{"replicas": 1},
+	}
+	api := map[string]interface{}{
+		"kind":     "Deployment",
+		"metadata": map[string]interface{}{"name": "api"},
+		"spec":     map[string]interface{}{"replicas": 1},
+	}
+
+	out, err := f.Overlay(web, `{kind: Deployment, metadata: {name: web}, spec: {replicas: 3}}`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, out.(map[string]interface{})["spec"].(map[string]interface{})["replicas"])
+
+	out, err = f.Overlay([]interface{}{web, api},
+		`{kind: Deployment, metadata: {name: web}, spec: {replicas: 3}}`,
+		[]interface{}{`{kind: Deployment, metadata: {name: api}, spec: {replicas: 5}}`})
+	require.NoError(t, err)
+	l := out.([]interface{})
+	assert.Len(t, l, 2)
+	assert.Equal(t, 5, l[1].(map[string]interface{})["spec"].(map[string]interface{})["replicas"])
+
+	out, err = f.Overlay(web, `{kind: Deployment, metadata: {name: web}, $patch: delete}`)
+	require.NoError(t, err)
+	assert.Nil(t, out)
+
+	_, err = f.Overlay(web)
+	assert.Error(t, err)
+
+	_, err = f.Overlay(42, web)
+	assert.Error(t, err)
+}
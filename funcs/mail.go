@@ -0,0 +1,124 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+	"github.com/hairyhenderson/gomplate/v3/mail"
+)
+
+// CreateMailFuncs -
+func CreateMailFuncs(ctx context.Context) map[string]interface{} {
+	ns := &MailFuncs{ctx}
+	return map[string]interface{}{
+		"mail": func() interface{} { return ns },
+	}
+}
+
+// MailFuncs -
+type MailFuncs struct {
+	ctx context.Context
+}
+
+// Message - compose a MIME email message from a map describing it
+func (MailFuncs) Message(in interface{}) (string, error) {
+	o, ok := in.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("message must be a map, got %T", in)
+	}
+
+	m := &mail.Message{}
+	for k, v := range o {
+		var err error
+		switch k {
+		case "from":
+			m.From = conv.ToString(v)
+		case "replyTo":
+			m.ReplyTo = conv.ToString(v)
+		case "subject":
+			m.Subject = conv.ToString(v)
+		case "text":
+			m.Text = conv.ToString(v)
+		case "html":
+			m.HTML = conv.ToString(v)
+		case "to":
+			m.To, err = addressList(v)
+		case "cc":
+			m.Cc, err = addressList(v)
+		case "bcc":
+			m.Bcc, err = addressList(v)
+		case "date":
+			m.Date, err = messageDate(v)
+		case "headers":
+			h, ok := v.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("headers must be a map, got %T", v)
+			}
+			m.Headers = make(map[string]string, len(h))
+			for hk, hv := range h {
+				m.Headers[hk] = conv.ToString(hv)
+			}
+		case "attachments":
+			m.Attachments, err = attachments(v)
+		default:
+			return "", fmt.Errorf("unknown message field %q", k)
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", k, err)
+		}
+	}
+
+	b, err := m.Bytes()
+	return string(b), err
+}
+
+func addressList(v interface{}) ([]string, error) {
+	if s, ok := v.(string); ok {
+		return []string{s}, nil
+	}
+	l, err := iconv.InterfaceSlice(v)
+	if err != nil {
+		return nil, err
+	}
+	return conv.ToStrings(l...), nil
+}
+
+func messageDate(v interface{}) (time.Time, error) {
+	switch d := v.(type) {
+	case time.Time:
+		return d, nil
+	case *time.Time:
+		return *d, nil
+	default:
+		return time.Parse(time.RFC3339, conv.ToString(v))
+	}
+}
+
+func attachments(v interface{}) ([]mail.Attachment, error) {
+	l, err := iconv.InterfaceSlice(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]mail.Attachment, len(l))
+	for i, a := range l {
+		am, ok := a.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("attachment %d must be a map, got %T", i, a)
+		}
+		out[i] = mail.Attachment{
+			Name:        conv.ToString(am["name"]),
+			ContentType: conv.ToString(am["contentType"]),
+		}
+		switch c := am["content"].(type) {
+		case []byte:
+			out[i].Content = c
+		case nil:
+		default:
+			out[i].Content = []byte(conv.ToString(c))
+		}
+	}
+	return out, nil
+}
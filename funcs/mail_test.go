@@ -0,0 +1,63 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMailFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateMailFuncs(ctx)
+			actual := fmap["mail"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*MailFuncs).ctx)
+		})
+	}
+}
+
+func TestMailMessage(t *testing.T) {
+	t.Parallel()
+
+	m := MailFuncs{}
+	out, err := m.Message(map[string]interface{}{
+		"from":    "a@example.com",
+		"to":      "b@example.com",
+		"cc":      []interface{}{"c@example.com", "d@example.com"},
+		"subject": "hi",
+		"text":    "hello",
+		"date":    "2022-06-01T12:00:00Z",
+		"headers": map[string]interface{}{"X-Count": 2},
+		"attachments": []interface{}{
+			map[string]interface{}{"name": "a.txt", "content": "abc"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Date: Wed, 01 Jun 2022 12:00:00 +0000\r\n")
+	assert.Contains(t, out, "Cc: <c@example.com>, <d@example.com>\r\n")
+	assert.Contains(t, out, "X-Count: 2\r\n")
+	assert.Contains(t, out, "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, out, "Content-Disposition: attachment; filename=a.txt\r\n")
+	assert.Contains(t, out, "YWJj\r\n")
+
+	_, err = m.Message("foo")
+	assert.Error(t, err)
+
+	_, err = m.Message(map[string]interface{}{"bogus": 1})
+	assert.Error(t, err)
+
+	_, err = m.Message(map[string]interface{}{"from": "a@example.com", "to": "b@example.com", "date": "yesterday"})
+	assert.Error(t, err)
+
+	_, err = m.Message(map[string]interface{}{"from": "a@example.com", "to": "b@example.com", "attachments": []interface{}{"x"}})
+	assert.Error(t, err)
+}
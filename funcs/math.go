@@ -2,6 +2,7 @@ package funcs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	gmath "math"
 	"strconv"
@@ -55,6 +56,8 @@ func (f MathFuncs) IsInt(n interface{}) bool {
 	case string:
 		_, err := strconv.ParseInt(i, 0, 64)
 		return err == nil
+	case json.Number:
+		return f.IsInt(string(i))
 	}
 	return false
 }
@@ -73,6 +76,8 @@ func (f MathFuncs) IsFloat(n interface{}) bool {
 			return false
 		}
 		return true
+	case json.Number:
+		return f.IsFloat(string(i))
 	}
 	return false
 }
@@ -2,6 +2,7 @@ package funcs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	gmath "math"
 	"strconv"
@@ -149,6 +150,8 @@ func TestIsIntFloatNum(t *testing.T) {
 		{"-42", true, false},
 		{"-0", true, false},
 		{"3.14", false, true},
+		{json.Number("42"), true, false},
+		{json.Number("18446744073709551617"), false, true},
 		{"-3.14", false, true},
 		{"0.00", false, true},
 		{"NaN", false, true},
@@ -0,0 +1,168 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/env"
+	"github.com/hairyhenderson/gomplate/v3/money"
+)
+
+// CreateMoneyFuncs -
+func CreateMoneyFuncs(ctx context.Context, d *data.Data) map[string]interface{} {
+	ns := &MoneyFuncs{ctx: ctx, data: d, rates: map[string]money.Rates{}}
+	return map[string]interface{}{
+		"money": func() interface{} { return ns },
+	}
+}
+
+// MoneyFuncs -
+type MoneyFuncs struct {
+	ctx  context.Context
+	data *data.Data
+	// rates read from datasources, by alias
+	rates map[string]money.Rates
+	mu    sync.Mutex
+}
+
+// Format - formats the amount in the currency, following the conventions of
+// the optional locale (default "en-US")
+func (f *MoneyFuncs) Format(amount interface{}, code string, locale ...string) (string, error) {
+	loc := "en-US"
+	switch len(locale) {
+	case 0:
+	case 1:
+		loc = locale[0]
+	default:
+		return "", fmt.Errorf("wrong number of args: want 2 or 3, got %d", len(locale)+2)
+	}
+
+	a, err := toRat(amount)
+	if err != nil {
+		return "", err
+	}
+	cur, err := money.ParseCurrency(code)
+	if err != nil {
+		return "", err
+	}
+	return money.Format(a, cur, loc)
+}
+
+// Round - rounds the amount to the currency's minor units (like cents)
+func (f *MoneyFuncs) Round(amount interface{}, code string) (float64, error) {
+	a, err := toRat(amount)
+	if err != nil {
+		return 0, err
+	}
+	cur, err := money.ParseCurrency(code)
+	if err != nil {
+		return 0, err
+	}
+	out, _ := money.Round(a, cur).Float64()
+	return out, nil
+}
+
+// ToMinor - converts the amount to a whole number of the currency's minor
+// units (like cents)
+func (f *MoneyFuncs) ToMinor(amount interface{}, code string) (int64, error) {
+	a, err := toRat(amount)
+	if err != nil {
+		return 0, err
+	}
+	cur, err := money.ParseCurrency(code)
+	if err != nil {
+		return 0, err
+	}
+	n := money.ToMinor(a, cur)
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("amount %s is too large", a.FloatString(money.MinorUnits(cur)))
+	}
+	return n.Int64(), nil
+}
+
+// FromMinor - converts a whole number of the currency's minor units (like
+// cents) to an amount
+func (f *MoneyFuncs) FromMinor(minor interface{}, code string) (float64, error) {
+	cur, err := money.ParseCurrency(code)
+	if err != nil {
+		return 0, err
+	}
+	if !(MathFuncs{}).IsInt(minor) {
+		return 0, fmt.Errorf("minor units must be a whole number, got %v", minor)
+	}
+	out, _ := money.FromMinor(big.NewInt(conv.ToInt64(minor)), cur).Float64()
+	return out, nil
+}
+
+// Convert - converts the amount between currencies, rounded to the minor
+// units of the target currency. Exchange rates are read from the datasource
+// named by GOMPLATE_MONEY_RATES (default "rates"), unless the optional
+// rates (a map, or a datasource alias) are given.
+func (f *MoneyFuncs) Convert(amount interface{}, from, to string, rates ...interface{}) (float64, error) {
+	if len(rates) > 1 {
+		return 0, fmt.Errorf("wrong number of args: want 3 or 4, got %d", len(rates)+3)
+	}
+	a, err := toRat(amount)
+	if err != nil {
+		return 0, err
+	}
+	fromCur, err := money.ParseCurrency(from)
+	if err != nil {
+		return 0, err
+	}
+	toCur, err := money.ParseCurrency(to)
+	if err != nil {
+		return 0, err
+	}
+
+	var r money.Rates
+	if len(rates) == 1 {
+		if m, ok := rates[0].(map[string]interface{}); ok {
+			r, err = money.ParseRates(m)
+		} else {
+			r, err = f.datasourceRates(conv.ToString(rates[0]))
+		}
+	} else {
+		r, err = f.datasourceRates(env.Getenv("GOMPLATE_MONEY_RATES", "rates"))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := r.Convert(a, fromCur, toCur)
+	if err != nil {
+		return 0, err
+	}
+	result, _ := out.Float64()
+	return result, nil
+}
+
+// datasourceRates reads (and caches) exchange rates from the datasource
+func (f *MoneyFuncs) datasourceRates(alias string) (money.Rates, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.rates[alias]; ok {
+		return r, nil
+	}
+	if f.data == nil {
+		return nil, fmt.Errorf("no exchange rates datasource %q is defined", alias)
+	}
+	if !f.data.DatasourceExists(alias) {
+		return nil, fmt.Errorf("no exchange rates datasource %q is defined - define it, or set GOMPLATE_MONEY_RATES to the name of another datasource", alias)
+	}
+
+	d, err := f.data.Datasource(alias)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates: %w", err)
+	}
+	r, err := money.ParseRates(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates from datasource %q: %w", alias, err)
+	}
+	f.rates[alias] = r
+	return r, nil
+}
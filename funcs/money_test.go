@@ -0,0 +1,124 @@
+package funcs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateMoneyFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateMoneyFuncs(ctx, nil)
+			actual := fmap["money"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*MoneyFuncs).ctx)
+		})
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	t.Parallel()
+
+	m := &MoneyFuncs{}
+
+	out, err := m.Format(1234.5, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "$1,234.50", out)
+
+	out, err = m.Format("1234.5", "eur", "de-DE")
+	require.NoError(t, err)
+	assert.Equal(t, "1.234,50 €", out)
+
+	_, err = m.Format(1, "USD", "en", "extra")
+	assert.Error(t, err)
+
+	_, err = m.Format(1, "DOLLARS")
+	assert.Error(t, err)
+
+	_, err = m.Format("lots", "USD")
+	assert.Error(t, err)
+}
+
+func TestMoneyMinorUnits(t *testing.T) {
+	t.Parallel()
+
+	m := &MoneyFuncs{}
+
+	r, err := m.Round(2.675, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 2.68, r)
+
+	n, err := m.ToMinor("19.99", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1999), n)
+
+	n, err = m.ToMinor(1999, "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1999), n)
+
+	f, err := m.FromMinor(1999, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 19.99, f)
+
+	_, err = m.FromMinor(19.99, "USD")
+	assert.Error(t, err)
+}
+
+func TestMoneyConvert(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "rates.json")
+	require.NoError(t, os.WriteFile(fname, []byte(`{"base": "USD", "rates": {"EUR": 0.92, "GBP": 0.79}}`), 0o600))
+	other := filepath.Join(dir, "other.json")
+	require.NoError(t, os.WriteFile(other, []byte(`{"EUR": 1, "USD": 2}`), 0o600))
+
+	d, err := data.NewData([]string{"rates=" + fname, "ecb=" + other}, nil)
+	require.NoError(t, err)
+	m := CreateMoneyFuncs(context.Background(), d)["money"].(func() interface{})().(*MoneyFuncs)
+
+	out, err := m.Convert(100, "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 92.0, out)
+
+	out, err = m.Convert(100, "EUR", "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, 85.87, out)
+
+	out, err = m.Convert(100, "EUR", "USD", "ecb")
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, out)
+
+	out, err = m.Convert(100, "EUR", "USD", map[string]interface{}{"EUR": 1, "USD": 1.09})
+	require.NoError(t, err)
+	assert.Equal(t, 109.0, out)
+
+	t.Setenv("GOMPLATE_MONEY_RATES", "ecb")
+	out, err = m.Convert(1, "EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, out)
+
+	_, err = m.Convert(1, "EUR", "CHF")
+	assert.Error(t, err)
+
+	_, err = m.Convert(1, "EUR", "USD", "missing")
+	assert.Error(t, err)
+
+	_, err = m.Convert(1, "EUR", "USD", "a", "b")
+	assert.Error(t, err)
+
+	_, err = (&MoneyFuncs{rates: map[string]money.Rates{}}).Convert(1, "EUR", "USD")
+	assert.Error(t, err)
+}
@@ -2,9 +2,13 @@ package funcs
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"math/big"
 	stdnet "net"
 	"net/netip"
+	"reflect"
+	"strings"
 
 	"github.com/apparentlymart/go-cidr/cidr"
 	"github.com/hairyhenderson/gomplate/v3/conv"
@@ -104,7 +108,6 @@ func (f NetFuncs) parseStdnetIPNet(prefix interface{}) (*stdnet.IPNet, error) {
 }
 
 // TODO: look at using this instead of parseStdnetIPNet
-//nolint:unused
 func (f NetFuncs) parseNetipPrefix(prefix interface{}) (netip.Prefix, error) {
 	switch p := prefix.(type) {
 	case *stdnet.IPNet:
@@ -125,6 +128,9 @@ func (f NetFuncs) ipFromNetIP(n stdnet.IP) netip.Addr {
 
 func (f NetFuncs) ipPrefixFromIPNet(n *stdnet.IPNet) netip.Prefix {
 	ip, _ := netip.AddrFromSlice(n.IP)
+	if len(n.Mask) == stdnet.IPv4len {
+		ip = ip.Unmap()
+	}
 	ones, _ := n.Mask.Size()
 	return netip.PrefixFrom(ip, ones)
 }
@@ -255,3 +261,177 @@ func (f NetFuncs) CIDRSubnetSizes(args ...interface{}) ([]netip.Prefix, error) {
 
 	return retValues, nil
 }
+
+// NextFreeIP - returns the first host address in the prefix that isn't in
+// the used list. Used entries can be addresses or prefixes (to reserve a
+// whole range). In IPv4 prefixes, the network and broadcast addresses are
+// never returned, and in IPv6 prefixes, the subnet-router anycast address
+// (the first address) is never returned.
+func (f NetFuncs) NextFreeIP(prefix interface{}, used interface{}) (netip.Addr, error) {
+	p, err := f.parseNetipPrefix(prefix)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	p = p.Masked()
+
+	reserved, err := f.usedPrefixes(used)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	first, last := p.Addr(), lastAddr(p)
+	if p.Bits() < p.Addr().BitLen()-1 {
+		first = first.Next()
+		if p.Addr().Is4() {
+			last = last.Prev()
+		}
+	}
+
+	for ip := first; ip.IsValid() && ip.Compare(last) <= 0; {
+		r, ok := containingPrefix(reserved, ip)
+		if !ok {
+			return ip, nil
+		}
+		// skip the rest of the reserved range
+		ip = lastAddr(r).Next()
+	}
+	return netip.Addr{}, errors.Errorf("no free addresses in %s", p)
+}
+
+// usedPrefixes converts the list of used addresses and prefixes to prefixes
+func (f NetFuncs) usedPrefixes(used interface{}) ([]netip.Prefix, error) {
+	if used == nil {
+		return nil, nil
+	}
+	v := reflect.ValueOf(used)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, errors.Errorf("used addresses must be a list, got %T", used)
+	}
+
+	out := make([]netip.Prefix, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		switch u := v.Index(i).Interface().(type) {
+		case netip.Addr:
+			u = u.Unmap()
+			out = append(out, netip.PrefixFrom(u, u.BitLen()))
+		case netaddr.IP:
+			a := netip.AddrFrom16(u.As16()).Unmap()
+			out = append(out, netip.PrefixFrom(a, a.BitLen()))
+		case netip.Prefix, netaddr.IPPrefix, *stdnet.IPNet:
+			pr, err := f.parseNetipPrefix(u)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, pr.Masked())
+		default:
+			s := strings.TrimSpace(conv.ToString(u))
+			if strings.Contains(s, "/") {
+				pr, err := netip.ParsePrefix(s)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, pr.Masked())
+				continue
+			}
+			a, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, err
+			}
+			a = a.Unmap()
+			out = append(out, netip.PrefixFrom(a, a.BitLen()))
+		}
+	}
+	return out, nil
+}
+
+func containingPrefix(prefixes []netip.Prefix, ip netip.Addr) (netip.Prefix, bool) {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return p, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// lastAddr - the last address in the prefix
+func lastAddr(p netip.Prefix) netip.Addr {
+	a := p.Masked().Addr().As16()
+	off := 0
+	if p.Addr().Is4() {
+		off = 96
+	}
+	for i := off + p.Bits(); i < 128; i++ {
+		a[i/8] |= 1 << (7 - uint(i%8))
+	}
+	ip := netip.AddrFrom16(a)
+	if p.Addr().Is4() {
+		return ip.Unmap()
+	}
+	return ip
+}
+
+// NormalizeMAC - returns the MAC address (EUI-48 or EUI-64) in lower case,
+// with colon separators. Addresses can be given with colons, hyphens, or dots
+// (like Cisco's "0123.4567.89ab"), or with no separators at all.
+func (f NetFuncs) NormalizeMAC(mac interface{}) (string, error) {
+	b, err := parseMAC(conv.ToString(mac))
+	if err != nil {
+		return "", err
+	}
+	if len(b) != 6 && len(b) != 8 {
+		return "", errors.Errorf("invalid MAC address %q: must have 6 or 8 bytes", conv.ToString(mac))
+	}
+	return stdnet.HardwareAddr(b).String(), nil
+}
+
+// GenerateMAC - returns a MAC address generated from the seed, so the same
+// seed always gives the same address. Without a prefix, the address is a
+// locally-administered unicast address. The optional prefix (like the
+// "52:54:00" OUI used by QEMU/KVM) is used for the first bytes instead.
+func (f NetFuncs) GenerateMAC(args ...interface{}) (string, error) {
+	var seed string
+	var prefix []byte
+	switch len(args) {
+	case 1:
+		seed = conv.ToString(args[0])
+	case 2:
+		p, err := parseMAC(conv.ToString(args[0]))
+		if err != nil {
+			return "", err
+		}
+		if len(p) > 5 {
+			return "", errors.Errorf("MAC address prefix %q is too long: must have at most 5 bytes", conv.ToString(args[0]))
+		}
+		prefix = p
+		seed = conv.ToString(args[1])
+	default:
+		return "", errors.Errorf("wrong number of args: want 1 or 2, got %d", len(args))
+	}
+
+	sum := sha256.Sum256([]byte(seed))
+	mac := make([]byte, 6)
+	copy(mac, sum[:])
+	if prefix == nil {
+		// set the locally-administered bit, and clear the multicast bit
+		mac[0] = (mac[0] | 0x02) &^ 0x01
+	} else {
+		copy(mac, prefix)
+	}
+	return stdnet.HardwareAddr(mac).String(), nil
+}
+
+// parseMAC parses a MAC address (or prefix) in any common notation
+func parseMAC(s string) ([]byte, error) {
+	h := strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) == 0 {
+		return nil, errors.Errorf("invalid MAC address %q", s)
+	}
+	return b, nil
+}
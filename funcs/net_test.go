@@ -5,6 +5,7 @@ import (
 	stdnet "net"
 	"net/netip"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
@@ -192,3 +193,94 @@ func TestCIDRSubnetSizes(t *testing.T) {
 	assert.Equal(t, "10.1.32.0/24", subnets[2].String())
 	assert.Equal(t, "10.1.48.0/20", subnets[3].String())
 }
+
+func TestNextFreeIP(t *testing.T) {
+	n := testNetNS()
+
+	testdata := []struct {
+		prefix   interface{}
+		used     interface{}
+		expected string
+	}{
+		{"10.0.0.0/24", nil, "10.0.0.1"},
+		{"10.0.0.0/24", []string{}, "10.0.0.1"},
+		{"10.0.0.7/24", []interface{}{"10.0.0.1", "10.0.0.2", "10.0.0.4"}, "10.0.0.3"},
+		{"10.0.0.0/24", []interface{}{"10.0.0.0/26", netip.MustParseAddr("10.0.0.64")}, "10.0.0.65"},
+		{netip.MustParsePrefix("10.0.0.0/24"), []interface{}{netaddr.MustParseIP("10.0.0.1")}, "10.0.0.2"},
+		{"10.0.0.0/30", []string{"10.0.0.1"}, "10.0.0.2"},
+		{"10.0.0.4/31", []string{"10.0.0.4"}, "10.0.0.5"},
+		{"10.0.0.9/32", nil, "10.0.0.9"},
+		{"fd00::/64", []string{"fd00::1"}, "fd00::2"},
+		{"fd00::/64", []string{"fd00::/120"}, "fd00::100"},
+	}
+	for _, d := range testdata {
+		ip, err := n.NextFreeIP(d.prefix, d.used)
+		assert.NoError(t, err)
+		assert.Equal(t, d.expected, ip.String())
+	}
+
+	_, err := n.NextFreeIP("10.0.0.0/30", []string{"10.0.0.1", "10.0.0.2"})
+	assert.Error(t, err)
+
+	_, err = n.NextFreeIP("10.0.0.0/24", "10.0.0.1")
+	assert.Error(t, err)
+
+	_, err = n.NextFreeIP("10.0.0.0/24", []string{"bogus"})
+	assert.Error(t, err)
+
+	_, err = n.NextFreeIP("bogus", nil)
+	assert.Error(t, err)
+}
+
+func TestNormalizeMAC(t *testing.T) {
+	n := testNetNS()
+
+	for _, in := range []string{
+		"00:1A:2b:3C:4d:5E", "00-1a-2b-3c-4d-5e", "001a.2b3c.4d5e", "001a2b3c4d5e", " 00:1a:2b:3c:4d:5e ",
+	} {
+		mac, err := n.NormalizeMAC(in)
+		assert.NoError(t, err)
+		assert.Equal(t, "00:1a:2b:3c:4d:5e", mac)
+	}
+
+	mac, err := n.NormalizeMAC("0011.2233.4455.6677")
+	assert.NoError(t, err)
+	assert.Equal(t, "00:11:22:33:44:55:66:77", mac)
+
+	for _, in := range []string{"", "00:1a:2b:3c:4d", "00:1a:2b:3c:4d:zz", "001a2b3c4d5"} {
+		_, err = n.NormalizeMAC(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestGenerateMAC(t *testing.T) {
+	n := testNetNS()
+
+	mac, err := n.GenerateMAC("web-01")
+	assert.NoError(t, err)
+	hw, err := stdnet.ParseMAC(mac)
+	assert.NoError(t, err)
+	assert.Len(t, hw, 6)
+	// locally-administered unicast
+	assert.Equal(t, byte(0x02), hw[0]&0x03)
+
+	again, _ := n.GenerateMAC("web-01")
+	assert.Equal(t, mac, again)
+
+	other, _ := n.GenerateMAC("web-02")
+	assert.NotEqual(t, mac, other)
+
+	mac, err = n.GenerateMAC("52-54-00", "web-01")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(mac, "52:54:00:"), mac)
+	assert.Len(t, mac, 17)
+
+	_, err = n.GenerateMAC("52:54:00:00:00:00", "web-01")
+	assert.Error(t, err)
+
+	_, err = n.GenerateMAC("xx", "web-01")
+	assert.Error(t, err)
+
+	_, err = n.GenerateMAC()
+	assert.Error(t, err)
+}
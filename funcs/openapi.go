@@ -0,0 +1,83 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/openapi"
+)
+
+// CreateOpenAPIFuncs -
+func CreateOpenAPIFuncs(ctx context.Context) map[string]interface{} {
+	ns := &OpenAPIFuncs{ctx}
+	return map[string]interface{}{
+		"openapi": func() interface{} { return ns },
+	}
+}
+
+// OpenAPIFuncs -
+type OpenAPIFuncs struct {
+	ctx context.Context
+}
+
+// Load - parse the document (if necessary) and resolve its references
+func (OpenAPIFuncs) Load(in interface{}) (map[string]interface{}, error) {
+	doc, err := parseDocArg(in, "OpenAPI document")
+	if err != nil {
+		return nil, err
+	}
+	return openapi.Resolve(doc)
+}
+
+// Operations -
+func (f OpenAPIFuncs) Operations(in interface{}) ([]interface{}, error) {
+	doc, err := f.Load(in)
+	if err != nil {
+		return nil, err
+	}
+	return openapi.Operations(doc)
+}
+
+// Schemas -
+func (f OpenAPIFuncs) Schemas(in interface{}) (map[string]interface{}, error) {
+	doc, err := f.Load(in)
+	if err != nil {
+		return nil, err
+	}
+	return openapi.Schemas(doc)
+}
+
+// Validate -
+func (OpenAPIFuncs) Validate(schema, value interface{}) ([]string, error) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema must be a map, got %T", schema)
+	}
+	return openapi.Validate(s, value), nil
+}
+
+// ValidateExamples -
+func (OpenAPIFuncs) ValidateExamples(in interface{}) ([]string, error) {
+	doc, err := parseDocArg(in, "OpenAPI document")
+	if err != nil {
+		return nil, err
+	}
+	return openapi.ValidateExamples(doc)
+}
+
+// parseDocArg accepts a parsed document, or parses a JSON or YAML document
+func parseDocArg(in interface{}, what string) (map[string]interface{}, error) {
+	switch t := in.(type) {
+	case map[string]interface{}:
+		return t, nil
+	case string:
+		doc, err := data.YAML(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", what, err)
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%s must be a map or a string, got %T", what, in)
+	}
+}
@@ -0,0 +1,89 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOpenAPIFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateOpenAPIFuncs(ctx)
+			actual := fmap["openapi"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*OpenAPIFuncs).ctx)
+		})
+	}
+}
+
+func TestOpenAPIFuncs(t *testing.T) {
+	t.Parallel()
+
+	spec := `openapi: 3.0.3
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+              example: [{name: 42}]
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+`
+	f := OpenAPIFuncs{}
+
+	doc, err := f.Load(spec)
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	ops, err := f.Operations(spec)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	op := ops[0].(map[string]interface{})
+	assert.Equal(t, "listPets", op["operationId"])
+	assert.Equal(t, "GET", op["method"])
+
+	schemas, err := f.Schemas(doc)
+	require.NoError(t, err)
+	assert.Contains(t, schemas, "Pet")
+
+	problems, err := f.Validate(schemas["Pet"], map[string]interface{}{"name": "Rex"})
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	problems, err = f.ValidateExamples(spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/paths/~1pets/get/responses/200/content/application~1json/example/0/name: expected string, got integer",
+	}, problems)
+
+	_, err = f.Validate("foo", 1)
+	assert.Error(t, err)
+
+	_, err = f.Load(42)
+	assert.Error(t, err)
+
+	_, err = f.Load("- not a map")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+	"github.com/hairyhenderson/gomplate/v3/plot"
+)
+
+// CreatePlotFuncs -
+func CreatePlotFuncs(ctx context.Context) map[string]interface{} {
+	ns := &PlotFuncs{ctx}
+	return map[string]interface{}{
+		"plot": func() interface{} { return ns },
+	}
+}
+
+// PlotFuncs -
+type PlotFuncs struct {
+	ctx context.Context
+}
+
+// Bar -
+func (PlotFuncs) Bar(args ...interface{}) (string, error) {
+	values, opts, err := plotArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return plot.Bar(values, opts)
+}
+
+// Line -
+func (PlotFuncs) Line(args ...interface{}) (string, error) {
+	values, opts, err := plotArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return plot.Line(values, opts)
+}
+
+// Pie -
+func (PlotFuncs) Pie(args ...interface{}) (string, error) {
+	values, opts, err := plotArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return plot.Pie(values, opts)
+}
+
+// plotArgs parses the optional options map and the values to plot. Values
+// can be a list of numbers, or a map of labels to numbers (in which case the
+// values are ordered by label).
+func plotArgs(args []interface{}) ([]float64, plot.Options, error) {
+	opts := plot.Options{}
+	var in interface{}
+	switch len(args) {
+	case 1:
+		in = args[0]
+	case 2:
+		o, ok := args[0].(map[string]interface{})
+		if !ok {
+			return nil, opts, fmt.Errorf("options must be a map, got %T", args[0])
+		}
+		var err error
+		opts, err = parsePlotOptions(o)
+		if err != nil {
+			return nil, opts, err
+		}
+		in = args[1]
+	default:
+		return nil, opts, fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+
+	if m, ok := in.(map[string]interface{}); ok {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]float64, len(keys))
+		for i, k := range keys {
+			values[i] = conv.ToFloat64(m[k])
+		}
+		if len(opts.Labels) == 0 {
+			opts.Labels = keys
+		}
+		return values, opts, nil
+	}
+
+	l, err := iconv.InterfaceSlice(in)
+	if err != nil {
+		return nil, opts, fmt.Errorf("values must be a list or a map of numbers: %w", err)
+	}
+	return conv.ToFloat64s(l...), opts, nil
+}
+
+func parsePlotOptions(o map[string]interface{}) (plot.Options, error) {
+	opts := plot.Options{}
+	for k, v := range o {
+		switch k {
+		case "width":
+			opts.Width = conv.ToInt(v)
+		case "height":
+			opts.Height = conv.ToInt(v)
+		case "title":
+			opts.Title = conv.ToString(v)
+		case "labels", "colors":
+			l, err := iconv.InterfaceSlice(v)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be a list: %w", k, err)
+			}
+			if k == "labels" {
+				opts.Labels = conv.ToStrings(l...)
+			} else {
+				opts.Colors = conv.ToStrings(l...)
+			}
+		default:
+			return opts, fmt.Errorf("unknown plot option %q", k)
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,69 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePlotFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreatePlotFuncs(ctx)
+			actual := fmap["plot"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*PlotFuncs).ctx)
+		})
+	}
+}
+
+func TestPlotArgs(t *testing.T) {
+	t.Parallel()
+
+	values, opts, err := plotArgs([]interface{}{[]interface{}{1, "2.5", 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2.5, 3}, values)
+	assert.Empty(t, opts.Labels)
+
+	values, opts, err = plotArgs([]interface{}{
+		map[string]interface{}{"title": "T", "width": "200", "colors": []interface{}{"red"}},
+		map[string]interface{}{"b": 2, "a": 1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, values)
+	assert.Equal(t, []string{"a", "b"}, opts.Labels)
+	assert.Equal(t, []string{"red"}, opts.Colors)
+	assert.Equal(t, "T", opts.Title)
+	assert.Equal(t, 200, opts.Width)
+
+	_, _, err = plotArgs([]interface{}{map[string]interface{}{"bogus": 1}, []int{1}})
+	assert.Error(t, err)
+
+	_, _, err = plotArgs([]interface{}{"foo", []int{1}})
+	assert.Error(t, err)
+
+	_, _, err = plotArgs([]interface{}{42})
+	assert.Error(t, err)
+
+	_, _, err = plotArgs(nil)
+	assert.Error(t, err)
+}
+
+func TestPlot(t *testing.T) {
+	t.Parallel()
+
+	p := PlotFuncs{}
+	for _, f := range []func(...interface{}) (string, error){p.Bar, p.Line, p.Pie} {
+		out, err := f([]interface{}{1, 2})
+		assert.NoError(t, err)
+		assert.Contains(t, out, "<svg ")
+	}
+}
@@ -0,0 +1,105 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+	"github.com/hairyhenderson/gomplate/v3/prompt"
+)
+
+// CreatePromptFuncs -
+func CreatePromptFuncs(ctx context.Context) map[string]interface{} {
+	ns := &PromptFuncs{ctx, prompt.Terminal()}
+	return map[string]interface{}{
+		"prompt": func() interface{} { return ns },
+	}
+}
+
+// PromptFuncs -
+type PromptFuncs struct {
+	ctx context.Context
+	p   *prompt.Prompter
+}
+
+// String -
+func (f *PromptFuncs) String(args ...interface{}) (string, error) {
+	def, rest, err := promptArgs(args, 1)
+	if err != nil {
+		return "", err
+	}
+	message := conv.ToString(rest[0])
+	if v, ok := suppliedValue(rest, 1); ok {
+		return v, nil
+	}
+	return f.p.String(message, def)
+}
+
+// Password -
+func (f *PromptFuncs) Password(message interface{}, value ...interface{}) (string, error) {
+	if len(value) > 1 {
+		return "", fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(value)+1)
+	}
+	if v, ok := suppliedValue(value, 0); ok {
+		return v, nil
+	}
+	return f.p.Password(conv.ToString(message))
+}
+
+// Select -
+func (f *PromptFuncs) Select(args ...interface{}) (string, error) {
+	def, rest, err := promptArgs(args, 2)
+	if err != nil {
+		return "", err
+	}
+	message := conv.ToString(rest[0])
+	list, err := iconv.InterfaceSlice(rest[1])
+	if err != nil {
+		return "", fmt.Errorf("choices must be a list: %w", err)
+	}
+	choices := make([]string, len(list))
+	for i, c := range list {
+		choices[i] = conv.ToString(c)
+	}
+	if v, ok := suppliedValue(rest, 2); ok {
+		for _, c := range choices {
+			if c == v {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of the choices for %q", v, message)
+	}
+	return f.p.Select(message, choices, def)
+}
+
+// promptArgs separates the optional leading options map (which may only
+// contain a default) from the rest of the arguments. There must be n
+// required arguments, optionally followed by a supplied value.
+func promptArgs(args []interface{}, n int) (def string, rest []interface{}, err error) {
+	rest = args
+	if len(rest) > 0 {
+		if opts, ok := rest[0].(map[string]interface{}); ok {
+			for k, v := range opts {
+				if k != "default" {
+					return "", nil, fmt.Errorf("unknown prompt option %q", k)
+				}
+				def = conv.ToString(v)
+			}
+			rest = rest[1:]
+		}
+	}
+	if len(rest) < n || len(rest) > n+1 {
+		return "", nil, fmt.Errorf("wrong number of args: wanted %d or %d (not counting options), got %d", n, n+1, len(rest))
+	}
+	return def, rest, nil
+}
+
+// suppliedValue returns the value at index i, if it's present and not empty
+func suppliedValue(args []interface{}, i int) (string, bool) {
+	if len(args) <= i || args[i] == nil {
+		return "", false
+	}
+	v := conv.ToString(args[i])
+	return v, v != ""
+}
@@ -0,0 +1,112 @@
+package funcs
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePromptFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreatePromptFuncs(ctx)
+			actual := fmap["prompt"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*PromptFuncs).ctx)
+		})
+	}
+}
+
+func testPromptFuncs(in string, interactive bool) *PromptFuncs {
+	return &PromptFuncs{p: &prompt.Prompter{
+		In:          strings.NewReader(in),
+		Out:         &bytes.Buffer{},
+		Interactive: interactive,
+	}}
+}
+
+func TestPromptString(t *testing.T) {
+	t.Parallel()
+
+	f := testPromptFuncs("typed\n\n", true)
+	s, err := f.String("Name")
+	require.NoError(t, err)
+	assert.Equal(t, "typed", s)
+
+	s, err = f.String(map[string]interface{}{"default": "dflt"}, "Name")
+	require.NoError(t, err)
+	assert.Equal(t, "dflt", s)
+
+	// supplied values aren't prompted for, even when not interactive
+	f = testPromptFuncs("", false)
+	s, err = f.String("Name", "given")
+	require.NoError(t, err)
+	assert.Equal(t, "given", s)
+
+	_, err = f.String("Name", "")
+	assert.Error(t, err)
+	_, err = f.String("Name", nil)
+	assert.Error(t, err)
+
+	_, err = f.String()
+	assert.Error(t, err)
+	_, err = f.String("Name", "a", "b")
+	assert.Error(t, err)
+	_, err = f.String(map[string]interface{}{"bogus": true}, "Name")
+	assert.Error(t, err)
+}
+
+func TestPromptPassword(t *testing.T) {
+	t.Parallel()
+
+	f := testPromptFuncs("s3cret\n", true)
+	s, err := f.Password("Token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", s)
+
+	f = testPromptFuncs("", false)
+	s, err = f.Password("Token", "given")
+	require.NoError(t, err)
+	assert.Equal(t, "given", s)
+
+	_, err = f.Password("Token")
+	assert.Error(t, err)
+}
+
+func TestPromptSelect(t *testing.T) {
+	t.Parallel()
+
+	choices := []interface{}{"MIT", "Apache-2.0"}
+	f := testPromptFuncs("2\n\n", true)
+	s, err := f.Select("License", choices)
+	require.NoError(t, err)
+	assert.Equal(t, "Apache-2.0", s)
+
+	s, err = f.Select(map[string]interface{}{"default": "MIT"}, "License", []string{"MIT", "Apache-2.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", s)
+
+	f = testPromptFuncs("", false)
+	s, err = f.Select("License", choices, "MIT")
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", s)
+
+	_, err = f.Select("License", choices, "GPL")
+	assert.Error(t, err)
+	_, err = f.Select("License", "MIT")
+	assert.Error(t, err)
+	_, err = f.Select("License")
+	assert.Error(t, err)
+}
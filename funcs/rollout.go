@@ -0,0 +1,135 @@
+package funcs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	gotime "time"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+)
+
+// rolloutBuckets - the number of buckets keys are hashed into, so percentages
+// can have up to 2 decimal places
+const rolloutBuckets = 10000
+
+// CreateRolloutFuncs -
+func CreateRolloutFuncs(ctx context.Context) map[string]interface{} {
+	ns := &RolloutFuncs{ctx: ctx, now: gotime.Now}
+	return map[string]interface{}{
+		"rollout": func() interface{} { return ns },
+	}
+}
+
+// RolloutFuncs - functions for gradual (canary) rollouts. Keys are hashed
+// into buckets, so the same key always gets the same result for the same
+// salt, and a key that's in the rollout at 10% is still in at 20%.
+type RolloutFuncs struct {
+	ctx context.Context
+	// now is overridden in tests
+	now func() gotime.Time
+}
+
+// Bucket - the key's bucket, from 0 up to (but not including) 100, with 2
+// decimal places. The optional salt varies the bucketing between rollouts.
+func (f *RolloutFuncs) Bucket(key interface{}, salt ...interface{}) (float64, error) {
+	s, err := rolloutSalt(salt)
+	if err != nil {
+		return 0, err
+	}
+	return float64(bucket(conv.ToString(key), s)) / (rolloutBuckets / 100), nil
+}
+
+// Percent - whether the key is in the given percentage (0-100) of the
+// rollout. The optional salt varies the bucketing between rollouts.
+func (f *RolloutFuncs) Percent(key interface{}, percent interface{}, salt ...interface{}) (bool, error) {
+	s, err := rolloutSalt(salt)
+	if err != nil {
+		return false, err
+	}
+	if !(MathFuncs{}).IsNum(percent) {
+		return false, fmt.Errorf("invalid percentage %q", conv.ToString(percent))
+	}
+	p := conv.ToFloat64(percent)
+	if p < 0 || p > 100 {
+		return false, fmt.Errorf("percentage must be between 0 and 100, got %v", percent)
+	}
+	b := bucket(conv.ToString(key), s)
+	return float64(b) < p*(rolloutBuckets/100), nil
+}
+
+// AfterTime - whether the current time (or the optional time now) is at or
+// after the time t. Times can be given as time.Time values, or as
+// RFC 3339 strings (or dates, for midnight local time).
+func (f *RolloutFuncs) AfterTime(t interface{}, now ...interface{}) (bool, error) {
+	at, err := rolloutTime(t)
+	if err != nil {
+		return false, err
+	}
+	n, err := f.currentTime(now)
+	if err != nil {
+		return false, err
+	}
+	return !n.Before(at), nil
+}
+
+// Ramp - the percentage of a rollout that ramps up linearly from 0 at the
+// start time to 100 at the end time, for use with Percent. The optional time
+// now is used instead of the current time.
+func (f *RolloutFuncs) Ramp(start, end interface{}, now ...interface{}) (float64, error) {
+	s, err := rolloutTime(start)
+	if err != nil {
+		return 0, err
+	}
+	e, err := rolloutTime(end)
+	if err != nil {
+		return 0, err
+	}
+	if !e.After(s) {
+		return 0, fmt.Errorf("ramp end time %s must be after start time %s", e.Format(gotime.RFC3339), s.Format(gotime.RFC3339))
+	}
+	n, err := f.currentTime(now)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case n.Before(s):
+		return 0, nil
+	case !n.Before(e):
+		return 100, nil
+	}
+	return 100 * float64(n.Sub(s)) / float64(e.Sub(s)), nil
+}
+
+func (f *RolloutFuncs) currentTime(now []interface{}) (gotime.Time, error) {
+	switch len(now) {
+	case 0:
+		return f.now(), nil
+	case 1:
+		return rolloutTime(now[0])
+	}
+	return gotime.Time{}, fmt.Errorf("wrong number of args: too many times given (%d)", len(now))
+}
+
+// bucket hashes the salt and key into one of rolloutBuckets buckets
+func bucket(key, salt string) uint64 {
+	sum := sha256.Sum256([]byte(salt + ":" + key))
+	return binary.BigEndian.Uint64(sum[:8]) % rolloutBuckets
+}
+
+func rolloutSalt(salt []interface{}) (string, error) {
+	switch len(salt) {
+	case 0:
+		return "", nil
+	case 1:
+		return conv.ToString(salt[0]), nil
+	}
+	return "", fmt.Errorf("wrong number of args: want at most 1 salt, got %d", len(salt))
+}
+
+func rolloutTime(v interface{}) (gotime.Time, error) {
+	t, _, err := parseEventTime(v, gotime.Local)
+	return t, err
+}
@@ -0,0 +1,152 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	gotime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRolloutFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateRolloutFuncs(ctx)
+			actual := fmap["rollout"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*RolloutFuncs).ctx)
+		})
+	}
+}
+
+func TestRolloutBucket(t *testing.T) {
+	t.Parallel()
+
+	f := &RolloutFuncs{}
+
+	b, err := f.Bucket("user-1")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, b, 0.0)
+	assert.Less(t, b, 100.0)
+
+	// stable
+	b2, err := f.Bucket("user-1")
+	require.NoError(t, err)
+	assert.Equal(t, b, b2)
+
+	// the salt changes the bucket
+	b3, err := f.Bucket("user-1", "checkout-v2")
+	require.NoError(t, err)
+	assert.NotEqual(t, b, b3)
+
+	_, err = f.Bucket("user-1", "a", "b")
+	assert.Error(t, err)
+}
+
+func TestRolloutPercent(t *testing.T) {
+	t.Parallel()
+
+	f := &RolloutFuncs{}
+
+	in := 0
+	for i := 0; i < 10000; i++ {
+		key := "host-" + strconv.Itoa(i)
+		ok, err := f.Percent(key, 25, "salt")
+		require.NoError(t, err)
+		if ok {
+			in++
+
+			// keys in the rollout stay in as it grows
+			ok, err = f.Percent(key, "50.5", "salt")
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+	}
+	assert.InDelta(t, 2500, in, 150)
+
+	ok, err := f.Percent("anything", 0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = f.Percent("anything", 100)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = f.Percent("x", 101)
+	assert.Error(t, err)
+
+	_, err = f.Percent("x", -1)
+	assert.Error(t, err)
+
+	_, err = f.Percent("x", "lots")
+	assert.Error(t, err)
+}
+
+func TestRolloutAfterTime(t *testing.T) {
+	t.Parallel()
+
+	now := gotime.Date(2021, 6, 1, 12, 0, 0, 0, gotime.UTC)
+	f := &RolloutFuncs{now: func() gotime.Time { return now }}
+
+	ok, err := f.AfterTime("2021-06-01T11:00:00Z")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = f.AfterTime(now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = f.AfterTime("2021-06-01T13:00:00Z")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = f.AfterTime("2021-06-01T13:00:00Z", "2021-07-01T00:00:00Z")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = f.AfterTime("tomorrow")
+	assert.Error(t, err)
+
+	_, err = f.AfterTime(now, now, now)
+	assert.Error(t, err)
+}
+
+func TestRolloutRamp(t *testing.T) {
+	t.Parallel()
+
+	f := &RolloutFuncs{now: gotime.Now}
+
+	start := "2021-06-01T00:00:00Z"
+	end := "2021-06-11T00:00:00Z"
+
+	testdata := []struct {
+		now      string
+		expected float64
+	}{
+		{"2021-05-01T00:00:00Z", 0},
+		{"2021-06-01T00:00:00Z", 0},
+		{"2021-06-02T00:00:00Z", 10},
+		{"2021-06-06T00:00:00Z", 50},
+		{"2021-06-11T00:00:00Z", 100},
+		{"2022-01-01T00:00:00Z", 100},
+	}
+	for _, d := range testdata {
+		p, err := f.Ramp(start, end, d.now)
+		require.NoError(t, err)
+		assert.InDelta(t, d.expected, p, 0.0001, d.now)
+	}
+
+	_, err := f.Ramp(end, start)
+	assert.Error(t, err)
+
+	_, err = f.Ramp("soon", end)
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package funcs
+
+import (
+	"context"
+
+	"github.com/hairyhenderson/gomplate/v3/schema"
+)
+
+// CreateSchemaFuncs -
+func CreateSchemaFuncs(ctx context.Context) map[string]interface{} {
+	ns := &SchemaFuncs{ctx}
+	return map[string]interface{}{
+		"schema": func() interface{} { return ns },
+	}
+}
+
+// SchemaFuncs -
+type SchemaFuncs struct {
+	ctx context.Context
+}
+
+// Defaults -
+func (SchemaFuncs) Defaults(in interface{}) (interface{}, error) {
+	s, err := parseDocArg(in, "schema")
+	if err != nil {
+		return nil, err
+	}
+	return schema.Defaults(s)
+}
+
+// Example -
+func (SchemaFuncs) Example(in interface{}) (interface{}, error) {
+	s, err := parseDocArg(in, "schema")
+	if err != nil {
+		return nil, err
+	}
+	return schema.Example(s)
+}
@@ -0,0 +1,51 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSchemaFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateSchemaFuncs(ctx)
+			actual := fmap["schema"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*SchemaFuncs).ctx)
+		})
+	}
+}
+
+func TestSchemaFuncs(t *testing.T) {
+	t.Parallel()
+
+	s := SchemaFuncs{}
+	in := `{"type": "object", "properties": {
+		"port": {"type": "integer", "default": 8080},
+		"host": {"type": "string", "format": "hostname"}
+	}}`
+
+	v, err := s.Defaults(in)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"port": 8080}, v)
+
+	v, err = s.Example(in)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"port": 8080, "host": "example.com"}, v)
+
+	_, err = s.Defaults(42)
+	assert.Error(t, err)
+
+	_, err = s.Example("[")
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package funcs
+
+import (
+	"context"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/file"
+	"github.com/hairyhenderson/gomplate/v3/script"
+)
+
+// CreateScriptFuncs -
+func CreateScriptFuncs(ctx context.Context) map[string]interface{} {
+	ns := &ScriptFuncs{ctx}
+	return map[string]interface{}{
+		"script": func() interface{} { return ns },
+	}
+}
+
+// ScriptFuncs - scripts are run with the limits in the context (see
+// script.ContextWithLimits)
+type ScriptFuncs struct {
+	ctx context.Context
+}
+
+// Run -
+func (f *ScriptFuncs) Run(code interface{}, args ...interface{}) (interface{}, error) {
+	return script.Run(f.ctx, "script", conv.ToString(code), args, script.LimitsFromContext(f.ctx))
+}
+
+// LoadFile -
+func (f *ScriptFuncs) LoadFile(path interface{}, args ...interface{}) (interface{}, error) {
+	p := conv.ToString(path)
+	code, err := file.Read(p)
+	if err != nil {
+		return nil, err
+	}
+	return script.Run(f.ctx, p, code, args, script.LimitsFromContext(f.ctx))
+}
@@ -0,0 +1,68 @@
+package funcs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/script"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScriptFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateScriptFuncs(ctx)
+			actual := fmap["script"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*ScriptFuncs).ctx)
+		})
+	}
+}
+
+func TestScriptRun(t *testing.T) {
+	t.Parallel()
+
+	f := &ScriptFuncs{ctx: context.Background()}
+	out, err := f.Run(`return args[1] * args[2]`, 6, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 42, out)
+}
+
+func TestScriptLoadFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "greet.lua")
+	require.NoError(t, os.WriteFile(p, []byte(`local name = ... return "hello, " .. name`), 0o600))
+
+	f := &ScriptFuncs{ctx: context.Background()}
+	out, err := f.LoadFile(p, "world")
+	require.NoError(t, err)
+	assert.Equal(t, "hello, world", out)
+
+	_, err = f.LoadFile(filepath.Join(dir, "missing.lua"))
+	assert.Error(t, err)
+}
+
+func TestScriptRun_Limits(t *testing.T) {
+	t.Parallel()
+
+	ctx := script.ContextWithLimits(context.Background(), script.Limits{Memory: 1024})
+	f := &ScriptFuncs{ctx: ctx}
+	_, err := f.Run(`return string.rep("x", 2048)`)
+	assert.EqualError(t, err, "script script exceeded its memory limit of 1024 bytes")
+
+	out, err := f.Run(`return string.rep("x", 512)`)
+	require.NoError(t, err)
+	assert.Len(t, out, 512)
+}
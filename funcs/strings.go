@@ -13,6 +13,7 @@ import (
 
 	"github.com/Masterminds/goutils"
 	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
 	"github.com/hairyhenderson/gomplate/v3/internal/deprecated"
 	"github.com/pkg/errors"
 	"golang.org/x/text/cases"
@@ -53,6 +54,7 @@ func CreateStringFuncs(ctx context.Context) map[string]interface{} {
 	f["indent"] = ns.Indent
 	f["quote"] = ns.Quote
 	f["shellQuote"] = ns.ShellQuote
+	f["shellJoin"] = ns.ShellJoin
 	f["squote"] = ns.Squote
 
 	// these are legacy aliases with non-pipelinable arg order
@@ -258,6 +260,56 @@ func (StringFuncs) ShellQuote(in interface{}) string {
 	return gompstrings.ShellQuote(conv.ToString(in))
 }
 
+// ShellJoin -
+func (StringFuncs) ShellJoin(in interface{}) (string, error) {
+	words, err := iconv.InterfaceSlice(in)
+	if err != nil {
+		return "", err
+	}
+	return gompstrings.ShellJoin(conv.ToStrings(words...)), nil
+}
+
+// SQLQuoteIdent -
+func (StringFuncs) SQLQuoteIdent(args ...interface{}) (string, error) {
+	dialect, in, err := sqlQuoteArgs(args...)
+	if err != nil {
+		return "", err
+	}
+	return gompstrings.SQLQuoteIdent(dialect, in)
+}
+
+// SQLQuoteLiteral -
+func (StringFuncs) SQLQuoteLiteral(args ...interface{}) (string, error) {
+	dialect, in, err := sqlQuoteArgs(args...)
+	if err != nil {
+		return "", err
+	}
+	return gompstrings.SQLQuoteLiteral(dialect, in)
+}
+
+func sqlQuoteArgs(args ...interface{}) (dialect, in string, err error) {
+	switch len(args) {
+	case 1:
+		in = conv.ToString(args[0])
+	case 2:
+		dialect = conv.ToString(args[0])
+		in = conv.ToString(args[1])
+	default:
+		return "", "", fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+	return dialect, in, nil
+}
+
+// SystemdEscape -
+func (StringFuncs) SystemdEscape(in interface{}) string {
+	return gompstrings.SystemdEscape(conv.ToString(in))
+}
+
+// SystemdEscapePath -
+func (StringFuncs) SystemdEscapePath(in interface{}) string {
+	return gompstrings.SystemdEscapePath(conv.ToString(in))
+}
+
 // Squote -
 func (StringFuncs) Squote(in interface{}) string {
 	s := conv.ToString(in)
@@ -253,3 +253,46 @@ func TestRuneCount(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 5, n)
 }
+
+func TestShellJoin(t *testing.T) {
+	t.Parallel()
+
+	sf := &StringFuncs{}
+	out, err := sf.ShellJoin([]interface{}{"echo", "hello world", 42})
+	assert.NoError(t, err)
+	assert.Equal(t, `echo 'hello world' 42`, out)
+
+	out, err = sf.ShellJoin([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, ``, out)
+
+	_, err = sf.ShellJoin("foo")
+	assert.Error(t, err)
+}
+
+func TestSQLQuote(t *testing.T) {
+	t.Parallel()
+
+	sf := &StringFuncs{}
+	out, err := sf.SQLQuoteIdent("users")
+	assert.NoError(t, err)
+	assert.Equal(t, `"users"`, out)
+
+	out, err = sf.SQLQuoteIdent("mysql", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, "`users`", out)
+
+	out, err = sf.SQLQuoteLiteral("it's")
+	assert.NoError(t, err)
+	assert.Equal(t, `'it''s'`, out)
+
+	out, err = sf.SQLQuoteLiteral("postgres", 42)
+	assert.NoError(t, err)
+	assert.Equal(t, `'42'`, out)
+
+	_, err = sf.SQLQuoteIdent()
+	assert.Error(t, err)
+
+	_, err = sf.SQLQuoteLiteral("a", "b", "c")
+	assert.Error(t, err)
+}
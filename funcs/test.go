@@ -3,8 +3,10 @@ package funcs
 import (
 	"context"
 	"reflect"
+	"strings"
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/internal/deprecated"
 	"github.com/pkg/errors"
 
 	"github.com/hairyhenderson/gomplate/v3/test"
@@ -37,6 +39,7 @@ func CreateTestFuncs(ctx context.Context) map[string]interface{} {
 	f["ternary"] = ns.Ternary
 	f["kind"] = ns.Kind
 	f["isKind"] = ns.IsKind
+	f["deprecated"] = ns.Deprecated
 	return f
 }
 
@@ -45,8 +48,12 @@ type TestFuncs struct {
 	ctx context.Context
 }
 
-// Assert -
-func (TestFuncs) Assert(args ...interface{}) (string, error) {
+// Assert - with no arguments, returns the assert namespace (so assert.That
+// works, even though assert is also a function)
+func (TestFuncs) Assert(args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return &AssertFuncs{}, nil
+	}
 	input := conv.ToBool(args[len(args)-1])
 	switch len(args) {
 	case 1:
@@ -62,6 +69,53 @@ func (TestFuncs) Assert(args ...interface{}) (string, error) {
 	}
 }
 
+// AssertFuncs - the assert namespace, for enforcing a template's input
+// contracts. Failures stop the template, with the message and location.
+type AssertFuncs struct{}
+
+// That - fails if the condition isn't true
+func (AssertFuncs) That(cond interface{}, message ...string) (string, error) {
+	if len(message) > 1 {
+		return "", errors.Errorf("wrong number of args: want 1 or 2, got %d", len(message)+1)
+	}
+	return test.Assert(conv.ToBool(cond), strings.Join(message, ""))
+}
+
+// NotEmpty - passes through the value if it's not nil, or an empty string,
+// slice, or map
+func (AssertFuncs) NotEmpty(args ...interface{}) (interface{}, error) {
+	message, value, err := messageAndValue(args)
+	if err != nil {
+		return nil, err
+	}
+	return test.NotEmpty(message, value)
+}
+
+// Matches - passes through the value if it matches the regular expression
+func (AssertFuncs) Matches(pattern string, args ...interface{}) (interface{}, error) {
+	message, value, err := messageAndValue(args)
+	if err != nil {
+		return nil, err
+	}
+	return test.Matches(pattern, message, value)
+}
+
+// messageAndValue - splits the args into an optional message and a value
+func messageAndValue(args []interface{}) (string, interface{}, error) {
+	switch len(args) {
+	case 1:
+		return "", args[0], nil
+	case 2:
+		message, ok := args[0].(string)
+		if !ok {
+			return "", nil, errors.Errorf("expected message to be a string; found %T", args[0])
+		}
+		return message, args[1], nil
+	default:
+		return "", nil, errors.Errorf("wrong number of args: want message (optional) and value, got %d args", len(args))
+	}
+}
+
 // Fail -
 func (TestFuncs) Fail(args ...interface{}) (string, error) {
 	switch len(args) {
@@ -90,6 +144,14 @@ func (TestFuncs) Required(args ...interface{}) (interface{}, error) {
 	}
 }
 
+// Deprecated - emits a deprecation warning, for marking parts of a template
+// library (like nested templates) as deprecated. Each warning is reported
+// once per render.
+func (f TestFuncs) Deprecated(message string) string {
+	deprecated.WarnDeprecated(f.ctx, message)
+	return ""
+}
+
 // Ternary -
 func (TestFuncs) Ternary(tval, fval, b interface{}) interface{} {
 	if conv.ToBool(b) {
@@ -42,6 +42,37 @@ func TestAssert(t *testing.T) {
 	assert.EqualError(t, err, "assertion failed: foo")
 }
 
+func TestAssertNS(t *testing.T) {
+	t.Parallel()
+
+	ns, err := TestNS().Assert()
+	assert.NoError(t, err)
+	a := ns.(*AssertFuncs)
+
+	_, err = a.That(true)
+	assert.NoError(t, err)
+	_, err = a.That("false", "must be true")
+	assert.EqualError(t, err, "assertion failed: must be true")
+	_, err = a.That(false, "a", "b")
+	assert.Error(t, err)
+
+	out, err := a.NotEmpty("need a value", "x")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", out)
+	_, err = a.NotEmpty("need a value", []interface{}{})
+	assert.EqualError(t, err, "assertion failed: need a value")
+	_, err = a.NotEmpty(42, "x")
+	assert.Error(t, err)
+	_, err = a.NotEmpty()
+	assert.Error(t, err)
+
+	out, err = a.Matches("^[a-z]+$", "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", out)
+	_, err = a.Matches("^[a-z]+$", "lowercase only", "ABC")
+	assert.EqualError(t, err, "assertion failed: lowercase only")
+}
+
 func TestRequired(t *testing.T) {
 	t.Parallel()
 
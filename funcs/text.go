@@ -0,0 +1,149 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+	"github.com/hairyhenderson/gomplate/v3/text"
+)
+
+// CreateTextFuncs -
+func CreateTextFuncs(ctx context.Context) map[string]interface{} {
+	ns := &TextFuncs{ctx}
+	return map[string]interface{}{
+		"text": func() interface{} { return ns },
+	}
+}
+
+// TextFuncs -
+type TextFuncs struct {
+	ctx context.Context
+}
+
+// Table - render a list of maps as a table. The optional first argument is a
+// map of options (format, columns, and sort).
+func (TextFuncs) Table(args ...interface{}) (string, error) {
+	var in interface{}
+	opts := text.TableOptions{}
+	switch len(args) {
+	case 1:
+		in = args[0]
+	case 2:
+		o, ok := args[0].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("options must be a map, got %T", args[0])
+		}
+		var err error
+		opts, err = parseTableOptions(o)
+		if err != nil {
+			return "", err
+		}
+		in = args[1]
+	default:
+		return "", fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+
+	rows, err := tableRows(in)
+	if err != nil {
+		return "", err
+	}
+	return text.Table(rows, opts)
+}
+
+// Diff - a unified diff between the old and new text. The optional first
+// argument is a map of options (context, from, and to).
+func (TextFuncs) Diff(args ...interface{}) (string, error) {
+	opts, oldText, newText, err := diffArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return text.Diff(oldText, newText, opts)
+}
+
+// DiffHTML - the diff between the old and new text, as an HTML table. The
+// optional first argument is a map of options (context, from, and to).
+func (TextFuncs) DiffHTML(args ...interface{}) (string, error) {
+	opts, oldText, newText, err := diffArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return text.DiffHTML(oldText, newText, opts), nil
+}
+
+func diffArgs(args []interface{}) (opts text.DiffOptions, oldText, newText string, err error) {
+	opts = text.DiffOptions{FromName: "old", ToName: "new", Context: 3}
+	switch len(args) {
+	case 2:
+	case 3:
+		o, ok := args[0].(map[string]interface{})
+		if !ok {
+			return opts, "", "", fmt.Errorf("options must be a map, got %T", args[0])
+		}
+		for k, v := range o {
+			switch k {
+			case "context":
+				opts.Context = conv.ToInt(v)
+				if opts.Context < 0 {
+					return opts, "", "", fmt.Errorf("context must not be negative, got %d", opts.Context)
+				}
+			case "from":
+				opts.FromName = conv.ToString(v)
+			case "to":
+				opts.ToName = conv.ToString(v)
+			default:
+				return opts, "", "", fmt.Errorf("unknown diff option %q", k)
+			}
+		}
+		args = args[1:]
+	default:
+		return opts, "", "", fmt.Errorf("wrong number of args: wanted 2 or 3, got %d", len(args))
+	}
+	return opts, conv.ToString(args[0]), conv.ToString(args[1]), nil
+}
+
+func parseTableOptions(o map[string]interface{}) (text.TableOptions, error) {
+	opts := text.TableOptions{}
+	for k, v := range o {
+		switch k {
+		case "format":
+			opts.Format = conv.ToString(v)
+		case "sort":
+			opts.Sort = conv.ToString(v)
+		case "columns":
+			if s, ok := v.(string); ok {
+				opts.Columns = strings.Split(s, ",")
+				continue
+			}
+			l, err := iconv.InterfaceSlice(v)
+			if err != nil {
+				return opts, fmt.Errorf("columns must be a list or a comma-separated string: %w", err)
+			}
+			opts.Columns = conv.ToStrings(l...)
+		default:
+			return opts, fmt.Errorf("unknown table option %q", k)
+		}
+	}
+	return opts, nil
+}
+
+func tableRows(in interface{}) ([]map[string]interface{}, error) {
+	if rows, ok := in.([]map[string]interface{}); ok {
+		return rows, nil
+	}
+	l, err := iconv.InterfaceSlice(in)
+	if err != nil {
+		return nil, fmt.Errorf("table input must be a list of maps: %w", err)
+	}
+	rows := make([]map[string]interface{}, len(l))
+	for i, r := range l {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("table input must be a list of maps, but element %d is a %T", i, r)
+		}
+		rows[i] = m
+	}
+	return rows, nil
+}
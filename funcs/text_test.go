@@ -0,0 +1,101 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTextFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateTextFuncs(ctx)
+			actual := fmap["text"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*TextFuncs).ctx)
+		})
+	}
+}
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+
+	tf := TextFuncs{}
+	rows := []interface{}{
+		map[string]interface{}{"name": "foo", "size": 2},
+		map[string]interface{}{"name": "bar", "size": 1},
+	}
+
+	out, err := tf.Table(rows)
+	assert.NoError(t, err)
+	assert.Equal(t, `+------+------+
+| name | size |
++------+------+
+| foo  | 2    |
+| bar  | 1    |
++------+------+
+`, out)
+
+	out, err = tf.Table(map[string]interface{}{
+		"format":  "csv",
+		"columns": []interface{}{"size", "name"},
+		"sort":    "size",
+	}, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, "size,name\r\n1,bar\r\n2,foo\r\n", out)
+
+	out, err = tf.Table(map[string]interface{}{"format": "csv", "columns": "name"}, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, "name\r\nfoo\r\nbar\r\n", out)
+
+	_, err = tf.Table(map[string]interface{}{"bogus": true}, rows)
+	assert.Error(t, err)
+
+	_, err = tf.Table("foo", rows)
+	assert.Error(t, err)
+
+	_, err = tf.Table([]interface{}{"not a map"})
+	assert.Error(t, err)
+
+	_, err = tf.Table()
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	tf := TextFuncs{}
+
+	out, err := tf.Diff("a\nb\n", "a\nc\n")
+	require.NoError(t, err)
+	assert.Equal(t, "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n", out)
+
+	out, err = tf.Diff(map[string]interface{}{"context": 0, "from": "a.txt", "to": "b.txt"}, "a\nb\n", "a\nc\n")
+	require.NoError(t, err)
+	assert.Equal(t, "--- a.txt\n+++ b.txt\n@@ -2 +2 @@\n-b\n+c\n", out)
+
+	out, err = tf.DiffHTML("a\n", "b\n")
+	require.NoError(t, err)
+	assert.Contains(t, out, `<tr class="diff-add"><td></td><td>1</td><td>+b</td></tr>`)
+
+	_, err = tf.Diff("a")
+	assert.Error(t, err)
+
+	_, err = tf.Diff("opts", "a", "b")
+	assert.Error(t, err)
+
+	_, err = tf.Diff(map[string]interface{}{"colour": true}, "a", "b")
+	assert.Error(t, err)
+
+	_, err = tf.DiffHTML(map[string]interface{}{"context": -1}, "a", "b")
+	assert.Error(t, err)
+}
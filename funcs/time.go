@@ -9,6 +9,7 @@ import (
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
 	"github.com/hairyhenderson/gomplate/v3/env"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
 	"github.com/hairyhenderson/gomplate/v3/time"
 )
 
@@ -98,6 +99,49 @@ func (TimeFuncs) ZoneOffset() int {
 	return time.ZoneOffset()
 }
 
+// ZoneInfo - return details of the named time zone (like "Europe/Paris") at
+// the given time (default now), including its DST transitions that year
+func (TimeFuncs) ZoneInfo(name string, t ...interface{}) (time.ZoneInfo, error) {
+	loc, at, err := zoneArgs(name, t)
+	if err != nil {
+		return time.ZoneInfo{}, err
+	}
+	return time.GetZoneInfo(loc, at), nil
+}
+
+// NextTransition - return the named time zone's next transition (like the
+// start or end of DST) after the given time (default now), or nil if it has
+// none in the next few years
+func (TimeFuncs) NextTransition(name string, t ...interface{}) (*time.Transition, error) {
+	loc, at, err := zoneArgs(name, t)
+	if err != nil {
+		return nil, err
+	}
+	return time.NextTransition(loc, at), nil
+}
+
+func zoneArgs(name string, t []interface{}) (*gotime.Location, gotime.Time, error) {
+	loc, err := gotime.LoadLocation(name)
+	if err != nil {
+		return nil, gotime.Time{}, err
+	}
+	switch len(t) {
+	case 0:
+		return loc, gotime.Now(), nil
+	case 1:
+		if at, ok := t[0].(gotime.Time); ok {
+			return loc, at, nil
+		}
+		at, err := gotime.Parse(gotime.RFC3339, conv.ToString(t[0]))
+		if err != nil {
+			return nil, gotime.Time{}, fmt.Errorf("time must be a time.Time or an RFC 3339 timestamp: %w", err)
+		}
+		return loc, at, nil
+	default:
+		return nil, gotime.Time{}, fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(t)+1)
+	}
+}
+
 // Parse -
 func (TimeFuncs) Parse(layout string, value interface{}) (gotime.Time, error) {
 	return gotime.Parse(layout, conv.ToString(value))
@@ -178,6 +222,67 @@ func (TimeFuncs) Until(n gotime.Time) gotime.Duration {
 	return gotime.Until(n)
 }
 
+// ToICS - render a list of events as an iCalendar document
+func (TimeFuncs) ToICS(args ...interface{}) (string, error) {
+	opts := time.CalendarOptions{}
+	loc := gotime.UTC
+	var in interface{}
+	switch len(args) {
+	case 1:
+		in = args[0]
+	case 2:
+		o, ok := args[0].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("options must be a map, got %T", args[0])
+		}
+		for k, v := range o {
+			switch k {
+			case "name":
+				opts.Name = conv.ToString(v)
+			case "prodID":
+				opts.ProdID = conv.ToString(v)
+			case "timezone":
+				var err error
+				loc, err = gotime.LoadLocation(conv.ToString(v))
+				if err != nil {
+					return "", err
+				}
+			default:
+				return "", fmt.Errorf("unknown calendar option %q", k)
+			}
+		}
+		in = args[1]
+	default:
+		return "", fmt.Errorf("wrong number of args: wanted 1 or 2, got %d", len(args))
+	}
+
+	var list []interface{}
+	if m, ok := in.(map[string]interface{}); ok {
+		list = []interface{}{m}
+	} else {
+		var err error
+		list, err = iconv.InterfaceSlice(in)
+		if err != nil {
+			return "", fmt.Errorf("events must be a list of maps: %w", err)
+		}
+	}
+
+	events := make([]time.Event, len(list))
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("event %d must be a map, got %T", i, item)
+		}
+		e, err := parseEvent(m, loc)
+		if err != nil {
+			return "", fmt.Errorf("event %d: %w", i, err)
+		}
+		events[i] = e
+	}
+
+	return time.ToICS(events, opts)
+}
+
 // convert a number input to a pair of int64s, representing the integer portion and the decimal remainder
 // this can handle a string as well as any integer or float type
 // precision is at the "nano" level (i.e. 1e+9)
@@ -225,3 +330,100 @@ func padRight(in, pad string, length int) string {
 		}
 	}
 }
+
+// parseEvent converts a map of event properties to a time.Event. Times
+// without an explicit offset are interpreted in the given location, and
+// date-only start strings make the event an all-day event.
+func parseEvent(m map[string]interface{}, loc *gotime.Location) (time.Event, error) {
+	e := time.Event{}
+	var dateOnly bool
+	var duration gotime.Duration
+	allDaySet := false
+	for k, v := range m {
+		var err error
+		switch k {
+		case "start":
+			e.Start, dateOnly, err = parseEventTime(v, loc)
+		case "end":
+			e.End, _, err = parseEventTime(v, loc)
+		case "stamp":
+			e.Stamp, _, err = parseEventTime(v, loc)
+		case "duration":
+			if d, ok := v.(gotime.Duration); ok {
+				duration = d
+			} else {
+				duration, err = gotime.ParseDuration(conv.ToString(v))
+			}
+		case "allDay":
+			e.AllDay = conv.ToBool(v)
+			allDaySet = true
+		case "uid":
+			e.UID = conv.ToString(v)
+		case "summary":
+			e.Summary = conv.ToString(v)
+		case "description":
+			e.Description = conv.ToString(v)
+		case "location":
+			e.Location = conv.ToString(v)
+		case "url":
+			e.URL = conv.ToString(v)
+		case "status":
+			e.Status = conv.ToString(v)
+		case "rrule":
+			e.RRule = conv.ToString(v)
+		case "organizer":
+			e.Organizer = conv.ToString(v)
+		case "attendees", "categories":
+			var l []interface{}
+			l, err = iconv.InterfaceSlice(v)
+			if err != nil {
+				return e, fmt.Errorf("%s must be a list: %w", k, err)
+			}
+			if k == "attendees" {
+				e.Attendees = conv.ToStrings(l...)
+			} else {
+				e.Categories = conv.ToStrings(l...)
+			}
+		default:
+			return e, fmt.Errorf("unknown event property %q", k)
+		}
+		if err != nil {
+			return e, fmt.Errorf("invalid %s: %w", k, err)
+		}
+	}
+
+	if !allDaySet {
+		e.AllDay = dateOnly
+	}
+	if duration != 0 {
+		if !e.End.IsZero() {
+			return e, fmt.Errorf("only one of end and duration can be set")
+		}
+		e.End = e.Start.Add(duration)
+	}
+	return e, nil
+}
+
+// parseEventTime parses a time.Time or a string in one of a few ISO 8601
+// forms, reporting whether the value was a string with no time part. A
+// time.Time at midnight UTC can't be told apart from a date (which is how
+// YAML datasources parse bare dates), so it's never treated as one.
+func parseEventTime(v interface{}, loc *gotime.Location) (gotime.Time, bool, error) {
+	if t, ok := v.(gotime.Time); ok {
+		return t, false, nil
+	}
+	s := conv.ToString(v)
+	if t, err := gotime.Parse(gotime.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := gotime.ParseInLocation(layout, s, loc); err == nil {
+			return t, false, nil
+		}
+	}
+	t, err := gotime.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return t, false, fmt.Errorf("can not parse %q as a date or time", s)
+	}
+	return t, true, nil
+}
@@ -6,6 +6,8 @@ import (
 	"math/big"
 	"strconv"
 	"testing"
+	gotime "time"
+	_ "time/tzdata"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -79,3 +81,112 @@ func TestParseNum(t *testing.T) {
 	assert.Zero(t, f)
 	assert.NoError(t, err)
 }
+
+func TestToICS(t *testing.T) {
+	t.Parallel()
+
+	tf := &TimeFuncs{}
+	out, err := tf.ToICS(map[string]interface{}{"name": "On-call", "timezone": "America/New_York"},
+		[]interface{}{
+			map[string]interface{}{
+				"uid": "1", "stamp": "2024-01-01T00:00:00Z",
+				"summary": "Alice", "start": "2024-03-04", "end": "2024-03-11",
+			},
+			map[string]interface{}{
+				"uid": "2", "stamp": "2024-01-01T00:00:00Z",
+				"summary": "Maintenance", "start": "2024-03-05 22:00", "duration": "2h",
+				"attendees": []interface{}{"ops@example.com"},
+			},
+		})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "X-WR-CALNAME:On-call\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20240304\r\nDTEND;VALUE=DATE:20240311\r\n")
+	assert.Contains(t, out, "DTSTART:20240306T030000Z\r\nDTEND:20240306T050000Z\r\n")
+	assert.Contains(t, out, "ATTENDEE:mailto:ops@example.com\r\n")
+
+	// a single event can be given as a map - times at midnight UTC aren't
+	// all-day events
+	out, err = tf.ToICS(map[string]interface{}{
+		"uid": "1", "stamp": "2024-01-01T00:00:00Z",
+		"start": gotime.Date(2024, 3, 4, 0, 0, 0, 0, gotime.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "DTSTART:20240304T000000Z\r\n")
+
+	out, err = tf.ToICS(map[string]interface{}{
+		"uid": "1", "stamp": "2024-01-01T00:00:00Z",
+		"start": "2024-03-04T00:00:00Z",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "DTSTART:20240304T000000Z\r\n")
+
+	// unless they're marked as such
+	out, err = tf.ToICS(map[string]interface{}{
+		"uid": "1", "stamp": "2024-01-01T00:00:00Z", "allDay": true,
+		"start": gotime.Date(2024, 3, 4, 0, 0, 0, 0, gotime.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20240304\r\n")
+
+	out, err = tf.ToICS(map[string]interface{}{
+		"uid": "1", "stamp": "2024-01-01T00:00:00Z", "allDay": false,
+		"start": "2024-03-04",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "DTSTART:20240304T000000Z\r\n")
+
+	_, err = tf.ToICS(map[string]interface{}{"start": "2024-03-04", "colour": "red"})
+	assert.ErrorContains(t, err, `unknown event property "colour"`)
+
+	_, err = tf.ToICS(map[string]interface{}{"start": "tomorrow"})
+	assert.ErrorContains(t, err, "invalid start")
+
+	_, err = tf.ToICS(map[string]interface{}{"start": "2024-03-04", "end": "2024-03-05", "duration": "1h"})
+	assert.ErrorContains(t, err, "only one of end and duration")
+
+	_, err = tf.ToICS(map[string]interface{}{"colour": "red"}, []interface{}{})
+	assert.ErrorContains(t, err, `unknown calendar option "colour"`)
+
+	_, err = tf.ToICS("foo", []interface{}{})
+	assert.Error(t, err)
+
+	_, err = tf.ToICS([]interface{}{"foo"})
+	assert.Error(t, err)
+}
+
+func TestZoneInfo(t *testing.T) {
+	t.Parallel()
+
+	tf := &TimeFuncs{}
+	info, err := tf.ZoneInfo("America/New_York", "2022-07-01T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, "EDT", info.Abbreviation)
+	assert.Equal(t, -4*3600, info.Offset)
+	assert.True(t, info.IsDST)
+	assert.Len(t, info.Transitions, 2)
+
+	info, err = tf.ZoneInfo("UTC", gotime.Date(2022, 7, 1, 0, 0, 0, 0, gotime.UTC))
+	assert.NoError(t, err)
+	assert.False(t, info.ObservesDST)
+
+	_, err = tf.ZoneInfo("Nowhere/Special")
+	assert.Error(t, err)
+	_, err = tf.ZoneInfo("UTC", "yesterday")
+	assert.Error(t, err)
+	_, err = tf.ZoneInfo("UTC", "2022-07-01T00:00:00Z", "extra")
+	assert.Error(t, err)
+}
+
+func TestNextTransition(t *testing.T) {
+	t.Parallel()
+
+	tf := &TimeFuncs{}
+	tr, err := tf.NextTransition("America/New_York", "2022-07-01T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, gotime.Date(2022, 11, 6, 6, 0, 0, 0, gotime.UTC), tr.Time.UTC())
+	assert.Equal(t, "EST", tr.After.Abbreviation)
+
+	tr, err = tf.NextTransition("UTC")
+	assert.NoError(t, err)
+	assert.Nil(t, tr)
+}
@@ -0,0 +1,97 @@
+package funcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	gotime "time"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/units"
+)
+
+// CreateUnitsFuncs -
+func CreateUnitsFuncs(ctx context.Context) map[string]interface{} {
+	ns := &UnitsFuncs{ctx}
+	return map[string]interface{}{
+		"units": func() interface{} { return ns },
+	}
+}
+
+// UnitsFuncs -
+type UnitsFuncs struct {
+	ctx context.Context
+}
+
+// Convert - converts the value from one unit to another. Whole-number results
+// are returned as integers, others as floating-point numbers.
+func (UnitsFuncs) Convert(value interface{}, from, to string) (interface{}, error) {
+	v, err := toRat(value)
+	if err != nil {
+		return nil, err
+	}
+	out, err := units.Convert(v, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if out.IsInt() && out.Num().IsInt64() {
+		return out.Num().Int64(), nil
+	}
+	f, _ := out.Float64()
+	return f, nil
+}
+
+// Duration - converts the value in the given unit (like "min" or "d") to a
+// time.Duration, rounded to the nearest nanosecond
+func (UnitsFuncs) Duration(value interface{}, unit string) (gotime.Duration, error) {
+	if dim, err := units.DimensionOf(unit); err != nil {
+		return 0, err
+	} else if dim != units.Duration {
+		return 0, fmt.Errorf("%s is a unit of %s, not duration", unit, dim)
+	}
+
+	v, err := toRat(value)
+	if err != nil {
+		return 0, err
+	}
+	ns, err := units.Convert(v, unit, "ns")
+	if err != nil {
+		return 0, err
+	}
+	// round half away from zero
+	half := big.NewRat(1, 2)
+	if ns.Sign() < 0 {
+		half.Neg(half)
+	}
+	ns.Add(ns, half)
+	n := new(big.Int).Quo(ns.Num(), ns.Denom())
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("%s%s is out of range for a duration", conv.ToString(value), unit)
+	}
+	return gotime.Duration(n.Int64()), nil
+}
+
+// toRat converts a number (or numeric string) to an exact rational number.
+// Floating-point numbers are converted from their shortest decimal form, so
+// 0.1 is exactly 1/10.
+func toRat(value interface{}) (*big.Rat, error) {
+	var s string
+	switch v := value.(type) {
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case json.Number:
+		s = v.String()
+	default:
+		s = strings.TrimSpace(conv.ToString(value))
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("can not convert %q to a number", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,84 @@
+package funcs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	gotime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateUnitsFuncs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 10; i++ {
+		// Run this a bunch to catch race conditions
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fmap := CreateUnitsFuncs(ctx)
+			actual := fmap["units"].(func() interface{})
+
+			assert.Same(t, ctx, actual().(*UnitsFuncs).ctx)
+		})
+	}
+}
+
+func TestUnitsConvert(t *testing.T) {
+	t.Parallel()
+
+	u := UnitsFuncs{}
+
+	out, err := u.Convert(512, "MiB", "KiB")
+	require.NoError(t, err)
+	assert.Equal(t, int64(524288), out)
+
+	out, err = u.Convert("1", "GiB", "GB")
+	require.NoError(t, err)
+	assert.Equal(t, 1.073741824, out)
+
+	// 0.1 is converted exactly
+	out, err = u.Convert(0.1, "s", "ms")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), out)
+
+	out, err = u.Convert(37, "C", "F")
+	require.NoError(t, err)
+	assert.Equal(t, 98.6, out)
+
+	_, err = u.Convert("lots", "MiB", "KiB")
+	assert.Error(t, err)
+
+	_, err = u.Convert(1, "MiB", "h")
+	assert.Error(t, err)
+}
+
+func TestUnitsDuration(t *testing.T) {
+	t.Parallel()
+
+	u := UnitsFuncs{}
+
+	d, err := u.Duration(90, "min")
+	require.NoError(t, err)
+	assert.Equal(t, 90*gotime.Minute, d)
+
+	d, err = u.Duration("1.5", "d")
+	require.NoError(t, err)
+	assert.Equal(t, 36*gotime.Hour, d)
+
+	d, err = u.Duration(-2.5, "ns")
+	require.NoError(t, err)
+	assert.Equal(t, gotime.Duration(-3), d)
+
+	_, err = u.Duration(1, "GiB")
+	assert.Error(t, err)
+
+	_, err = u.Duration(1, "fortnight")
+	assert.Error(t, err)
+
+	_, err = u.Duration(100000, "w")
+	assert.Error(t, err)
+}
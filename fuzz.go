@@ -0,0 +1,350 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/fuzz"
+	"github.com/hairyhenderson/gomplate/v3/openapi"
+	gtmpl "github.com/hairyhenderson/gomplate/v3/tmpl"
+	"github.com/hairyhenderson/yaml"
+)
+
+// Kinds of fuzzing failures
+const (
+	// FuzzPanic - the template (or a function it called) panicked
+	FuzzPanic = "panic"
+	// FuzzHang - the template didn't finish rendering within the timeout
+	FuzzHang = "hang"
+	// FuzzInvalid - the output didn't match the output schema
+	FuzzInvalid = "invalid"
+)
+
+// FuzzOptions - options for Fuzz
+//
+// Experimental: subject to breaking changes before the next major release
+type FuzzOptions struct {
+	// Schema - when set, each template's output is parsed as JSON or YAML, and
+	// must be valid according to this JSON Schema
+	Schema map[string]interface{}
+	// Timeout - how long a template can take to render before it's reported
+	// as hanging (default 5s)
+	Timeout time.Duration
+	// MaxMutations - the most mutations to try. When there are more, a random
+	// sample (chosen with Seed) is tried. (default 1000)
+	MaxMutations int
+	// Seed - the random seed for choosing mutations to try
+	Seed int64
+}
+
+// FuzzReport - the results of fuzzing
+//
+// Experimental: subject to breaking changes before the next major release
+type FuzzReport struct {
+	// Mutations - the number of mutations tried
+	Mutations int
+	// Errors - the number of mutations that made a template fail with an
+	// error (like a missing key, or a call to fail), which isn't a failure
+	Errors int
+	// Failures - the template executions that panicked, hung, or emitted
+	// invalid output
+	Failures []FuzzFailure
+}
+
+// FuzzFailure - a template that panicked, hung, or emitted invalid output
+// when a datasource was mutated
+//
+// Experimental: subject to breaking changes before the next major release
+type FuzzFailure struct {
+	// Kind - one of FuzzPanic, FuzzHang, or FuzzInvalid
+	Kind     string
+	Template string
+	// Datasource - the alias of the mutated datasource, or empty when the
+	// failure happens without any mutation
+	Datasource string
+	// Mutation - the change made to the datasource, like "null at .db.host"
+	Mutation string
+	Message  string
+}
+
+// fuzzError - an error from rendering a template that's reported as a
+// failure, rather than counted as an error
+type fuzzError struct {
+	kind, msg string
+}
+
+func (e *fuzzError) Error() string {
+	return e.kind + ": " + e.msg
+}
+
+// fuzzMutation - a mutation of one of the fixtures
+type fuzzMutation struct {
+	fixture int
+	m       fuzz.Mutation
+	value   interface{}
+}
+
+// Fuzz renders the configured templates many times, each time with one of the
+// datasources' data mutated - with a key removed, a value set to null or to
+// a value of the wrong type, or a string made very long - and reports
+// templates that panic, hang, or emit output that doesn't match the schema.
+//
+// The datasources are read once (or taken from the config's snapshot), and
+// the mutated data is rendered from snapshots, so datasources are never read
+// again. Templates are first rendered without mutations, and must succeed.
+//
+// Experimental: subject to breaking changes before the next major release
+func Fuzz(ctx context.Context, cfg *config.Config, fo FuzzOptions) (*FuzzReport, error) {
+	defer runCleanupHooks()
+
+	if cfg.Matrix != nil {
+		return nil, fmt.Errorf("fuzzing matrix renders is not supported")
+	}
+	if fo.Timeout <= 0 {
+		fo.Timeout = 5 * time.Second
+	}
+	if fo.MaxMutations <= 0 {
+		fo.MaxMutations = 1000
+	}
+
+	err := validateInputs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.EnvFiles) > 0 {
+		restoreEnv, err := loadEnvFiles(cfg.EnvFiles, cfg.EnvFileNoOverride)
+		if err != nil {
+			return nil, err
+		}
+		defer restoreEnv()
+	}
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := readInputTemplates(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather templates for rendering: %w", err)
+	}
+
+	fixtures := opts.Snapshots
+	if fixtures == nil {
+		fixtures, err = takeSnapshots(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &FuzzReport{}
+	failures, err := fuzzRender(ctx, opts, templates, fixtures, fo)
+	if err != nil {
+		return nil, fmt.Errorf("templates must render before mutating datasources: %w", err)
+	}
+	report.Failures = append(report.Failures, failures...)
+
+	mutations := fuzzMutations(fixtures)
+	if len(mutations) > fo.MaxMutations {
+		//nolint:gosec
+		idx := rand.New(rand.NewSource(fo.Seed)).Perm(len(mutations))[:fo.MaxMutations]
+		sort.Ints(idx)
+		sample := make([]fuzzMutation, len(idx))
+		for i, j := range idx {
+			sample[i] = mutations[j]
+		}
+		mutations = sample
+	}
+
+	for _, fm := range mutations {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		snapshots := make([]data.Snapshot, len(fixtures))
+		copy(snapshots, fixtures)
+		snapshots[fm.fixture], err = mutatedSnapshot(fixtures[fm.fixture], fm.m.Apply(fm.value))
+		if err != nil {
+			return nil, err
+		}
+
+		failures, err := fuzzRender(ctx, opts, templates, snapshots, fo)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			report.Errors++
+		}
+		for _, f := range failures {
+			f.Datasource = fixtures[fm.fixture].Alias
+			f.Mutation = fm.m.String()
+			report.Failures = append(report.Failures, f)
+		}
+	}
+	report.Mutations = len(mutations)
+
+	return report, nil
+}
+
+// fuzzMutations lists the mutations of all of the fixtures. Fixtures that
+// can't be parsed, and fixtures read with arguments, aren't mutated.
+func fuzzMutations(fixtures []data.Snapshot) []fuzzMutation {
+	out := []fuzzMutation{}
+	for i, s := range fixtures {
+		if len(s.Args) > 0 {
+			continue
+		}
+		v, err := s.Parse()
+		if err != nil {
+			continue
+		}
+		v = jsonValue(v)
+		for _, m := range fuzz.Mutations(v) {
+			out = append(out, fuzzMutation{fixture: i, m: m, value: v})
+		}
+	}
+	return out
+}
+
+// jsonValue converts the parsed data to the types that encoding/json would
+// produce, so that it can be mutated
+func jsonValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// mutatedSnapshot - a copy of the snapshot with the mutated value as its data.
+// Objects and arrays are encoded as JSON, and other values as plain text,
+// since datasources can't return scalars otherwise.
+func mutatedSnapshot(s data.Snapshot, v interface{}) (data.Snapshot, error) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return s, fmt.Errorf("failed to encode mutated datasource '%s': %w", s.Alias, err)
+		}
+		s.Data, s.MediaType = b, "application/json"
+	default:
+		s.Data, s.MediaType = []byte(fmt.Sprint(v)), "text/plain"
+	}
+	return s, nil
+}
+
+// fuzzRender renders the templates with data read from the snapshots. A
+// template that panics, hangs, or emits output that doesn't match the schema
+// is a failure - other errors stop a template from rendering, and the first
+// is returned.
+func fuzzRender(ctx context.Context, opts Options, templates []inputTemplate, snapshots []data.Snapshot, fo FuzzOptions) ([]FuzzFailure, error) {
+	opts.Snapshots = snapshots
+	tr := NewRenderer(opts)
+	tr.data.Ctx = ctx
+
+	tctx, err := createTmplContext(ctx, tr.tctxAliases, tr.tctxRoots, tr.data)
+	if err != nil {
+		return nil, err
+	}
+
+	f := tr.funcMap(ctx)
+	failures := []FuzzFailure{}
+	var firstErr error
+	for _, it := range templates {
+		out, err := tr.fuzzTemplate(ctx, it, f, tctx, fo.Timeout)
+		fe := &fuzzError{}
+		switch {
+		case errors.As(err, &fe):
+			failures = append(failures, FuzzFailure{Kind: fe.kind, Template: it.name, Message: fe.msg})
+		case err != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to render template %s: %w", it.name, err)
+			}
+		case fo.Schema != nil:
+			if problems := validateOutput(fo.Schema, out); len(problems) > 0 {
+				failures = append(failures, FuzzFailure{Kind: FuzzInvalid, Template: it.name, Message: strings.Join(problems, "; ")})
+			}
+		}
+	}
+	return failures, firstErr
+}
+
+// fuzzTemplate parses and executes the template, returning its output. Panics
+// (including runtime errors recovered by the template package), and executions
+// that take longer than the timeout, are returned as fuzzErrors. A hanging
+// execution can't be stopped, so it's left running in the background.
+func (t *Renderer) fuzzTemplate(ctx context.Context, it inputTemplate, f template.FuncMap, tctx interface{}, timeout time.Duration) (string, error) {
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: &fuzzError{kind: FuzzPanic, msg: fmt.Sprint(r)}}
+			}
+		}()
+
+		tmpl, err := t.parse(ctx, it.name, it.text, it.bundle, f, tctx)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+
+		buf := &bytes.Buffer{}
+		if t.htmlEscape {
+			err = executeHTML(tmpl, f, buf, tctx)
+		} else {
+			err = tmpl.Execute(buf, tctx)
+		}
+		if errors.Is(err, gtmpl.ErrSkip) {
+			err = nil
+		}
+		var re runtime.Error
+		if errors.As(err, &re) {
+			err = &fuzzError{kind: FuzzPanic, msg: err.Error()}
+		}
+		done <- result{out: buf.String(), err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.out, r.err
+	case <-timer.C:
+		return "", &fuzzError{kind: FuzzHang, msg: fmt.Sprintf("still rendering after %s", timeout)}
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// validateOutput parses the output as JSON or YAML, and validates it against
+// the schema, returning the problems found
+func validateOutput(schema map[string]interface{}, out string) []string {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(out), &v); err != nil {
+		return []string{fmt.Sprintf("output isn't valid JSON or YAML: %v", err)}
+	}
+	return openapi.Validate(schema, jsonValue(v))
+}
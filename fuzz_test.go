@@ -0,0 +1,101 @@
+package gomplate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzz(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	dsFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(dsFile, []byte("name: acme\nport: 8080\ntags: [web]\n"), 0o600))
+	u, err := config.ParseSourceURL(dsFile)
+	require.NoError(t, err)
+
+	newConfig := func(in string) *config.Config {
+		return &config.Config{
+			Input:   in,
+			Context: map[string]config.DataSource{"cfg": {URL: u}},
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"name"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "pattern": "^[a-z]+$", "maxLength": 64},
+		},
+	}
+
+	report, err := Fuzz(ctx, newConfig(`name: {{ .cfg.name }}`), FuzzOptions{Schema: schema})
+	require.NoError(t, err)
+	assert.Equal(t, 16, report.Mutations)
+	assert.Contains(t, report.Failures, FuzzFailure{
+		Kind: FuzzInvalid, Template: "<arg>", Datasource: "cfg", Mutation: "null at .name",
+		Message: `/name: "<no value>" does not match pattern "^[a-z]+$"`,
+	})
+	kinds := map[string]bool{}
+	for _, f := range report.Failures {
+		kinds[f.Kind+" "+f.Mutation] = true
+	}
+	assert.True(t, kinds["invalid huge at .name"])
+	assert.True(t, kinds["invalid type at .name"])
+	assert.False(t, kinds["invalid null at .port"])
+	// missing keys are errors, not failures
+	assert.False(t, kinds["invalid missing at .name"])
+	assert.Greater(t, report.Errors, 0)
+
+	// integer division by zero panics
+	report, err = Fuzz(ctx, newConfig(`{{ math.Rem 100 .cfg.port }}`), FuzzOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Failures)
+	assert.Equal(t, FuzzPanic, report.Failures[0].Kind)
+	assert.Equal(t, "null at .port", report.Failures[0].Mutation)
+	assert.Contains(t, report.Failures[0].Message, "integer divide by zero")
+
+	// without tags, the template retries for much longer than the timeout
+	report, err = Fuzz(ctx, newConfig(`{{ if not .cfg.tags }}{{ retry 3 "1s" "fail" }}{{ end }}`),
+		FuzzOptions{Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Failures)
+	assert.Equal(t, FuzzFailure{
+		Kind: FuzzHang, Template: "<arg>", Datasource: "cfg", Mutation: "null at .tags",
+		Message: "still rendering after 50ms",
+	}, report.Failures[0])
+
+	// errors aren't failures, but they're counted
+	report, err = Fuzz(ctx, newConfig(`{{ required "name is required" .cfg.name }}`), FuzzOptions{MaxMutations: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Mutations)
+	assert.Empty(t, report.Failures)
+
+	_, err = Fuzz(ctx, newConfig(`{{ fail "oops" }}`), FuzzOptions{})
+	assert.ErrorContains(t, err, "templates must render before mutating datasources")
+}
+
+func TestMutatedSnapshot(t *testing.T) {
+	s := data.Snapshot{Alias: "a", URL: "file:///a.yaml", MediaType: "application/yaml", Data: []byte("a: b\n")}
+
+	out, err := mutatedSnapshot(s, map[string]interface{}{"a": nil})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", out.MediaType)
+	assert.Equal(t, `{"a":null}`, string(out.Data))
+
+	out, err = mutatedSnapshot(s, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", out.MediaType)
+	assert.Equal(t, "0", string(out.Data))
+
+	// the original is unchanged
+	assert.Equal(t, "a: b\n", string(s.Data))
+}
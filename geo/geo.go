@@ -0,0 +1,60 @@
+// Package geo contains functions for working with geographic coordinates -
+// great-circle distances, geohashes, and point-in-polygon tests.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// EarthRadius - the mean radius of the Earth, in metres
+const EarthRadius = 6371008.8
+
+// Point - a position, in decimal degrees
+type Point struct {
+	Lat, Lon float64
+}
+
+// Validate returns an error if the latitude or longitude is out of range
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p.Lat)
+	}
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p.Lon)
+	}
+	return nil
+}
+
+// Distance returns the great-circle distance between the points in metres,
+// using the haversine formula
+func Distance(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// distance units, in metres
+var distanceUnits = map[string]float64{
+	"m":   1,
+	"km":  1000,
+	"mi":  1609.344,
+	"nmi": 1852,
+	"ft":  0.3048,
+}
+
+// ConvertDistance converts a distance in metres to the given unit - one of
+// "m", "km", "mi", "nmi", or "ft"
+func ConvertDistance(metres float64, unit string) (float64, error) {
+	f, ok := distanceUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("unknown distance unit %q (must be one of m, km, mi, nmi, or ft)", unit)
+	}
+	return metres / f, nil
+}
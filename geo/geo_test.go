@@ -0,0 +1,39 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	london := Point{Lat: 51.5074, Lon: -0.1278}
+	paris := Point{Lat: 48.8566, Lon: 2.3522}
+
+	assert.InDelta(t, 343560, Distance(london, paris), 100)
+	assert.InDelta(t, Distance(london, paris), Distance(paris, london), 0.001)
+	assert.Equal(t, 0.0, Distance(london, london))
+
+	// antipodes
+	assert.InDelta(t, 20015114, Distance(Point{0, 0}, Point{0, 180}), 1)
+}
+
+func TestConvertDistance(t *testing.T) {
+	d, err := ConvertDistance(1609.344, "mi")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, d)
+
+	d, err = ConvertDistance(1500, "KM")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, d)
+
+	_, err = ConvertDistance(1, "furlongs")
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Point{Lat: 90, Lon: -180}.Validate())
+	assert.Error(t, Point{Lat: 91}.Validate())
+	assert.Error(t, Point{Lon: -181}.Validate())
+}
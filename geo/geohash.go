@@ -0,0 +1,105 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeohashPrecision - the longest geohash that can be encoded. 12
+// characters is precise to a few centimetres.
+const MaxGeohashPrecision = 12
+
+// Bounds - a bounding box, in decimal degrees
+type Bounds struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// Center returns the point at the center of the bounding box
+func (b Bounds) Center() Point {
+	return Point{Lat: (b.MinLat + b.MaxLat) / 2, Lon: (b.MinLon + b.MaxLon) / 2}
+}
+
+// EncodeGeohash encodes the point as a geohash of the given precision (number
+// of characters)
+func EncodeGeohash(p Point, precision int) (string, error) {
+	if precision < 1 || precision > MaxGeohashPrecision {
+		return "", fmt.Errorf("geohash precision %d out of range [1, %d]", precision, MaxGeohashPrecision)
+	}
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	b := Bounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	out := make([]byte, 0, precision)
+	even := true
+	bit, ch := 0, 0
+	for len(out) < precision {
+		// bits alternate between longitude and latitude, starting with
+		// longitude
+		if even {
+			mid := (b.MinLon + b.MaxLon) / 2
+			if p.Lon >= mid {
+				ch = ch<<1 | 1
+				b.MinLon = mid
+			} else {
+				ch <<= 1
+				b.MaxLon = mid
+			}
+		} else {
+			mid := (b.MinLat + b.MaxLat) / 2
+			if p.Lat >= mid {
+				ch = ch<<1 | 1
+				b.MinLat = mid
+			} else {
+				ch <<= 1
+				b.MaxLat = mid
+			}
+		}
+		even = !even
+
+		bit++
+		if bit == 5 {
+			out = append(out, geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return string(out), nil
+}
+
+// DecodeGeohash returns the bounding box of the cell the geohash identifies
+func DecodeGeohash(hash string) (Bounds, error) {
+	b := Bounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	if hash == "" {
+		return b, fmt.Errorf("empty geohash")
+	}
+
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		n := strings.IndexRune(geohashAlphabet, c)
+		if n < 0 {
+			return b, fmt.Errorf("invalid geohash %q: unexpected character %q", hash, c)
+		}
+		for i := 4; i >= 0; i-- {
+			on := n>>i&1 == 1
+			if even {
+				mid := (b.MinLon + b.MaxLon) / 2
+				if on {
+					b.MinLon = mid
+				} else {
+					b.MaxLon = mid
+				}
+			} else {
+				mid := (b.MinLat + b.MaxLat) / 2
+				if on {
+					b.MinLat = mid
+				} else {
+					b.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	h, err := EncodeGeohash(Point{Lat: 57.64911, Lon: 10.40744}, 11)
+	require.NoError(t, err)
+	assert.Equal(t, "u4pruydqqvj", h)
+
+	h, err = EncodeGeohash(Point{Lat: 51.5074, Lon: -0.1278}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "gcpvj", h)
+
+	_, err = EncodeGeohash(Point{}, 0)
+	assert.Error(t, err)
+	_, err = EncodeGeohash(Point{}, 13)
+	assert.Error(t, err)
+	_, err = EncodeGeohash(Point{Lat: 100}, 5)
+	assert.Error(t, err)
+}
+
+func TestDecodeGeohash(t *testing.T) {
+	b, err := DecodeGeohash("u4pruydqqvj")
+	require.NoError(t, err)
+	c := b.Center()
+	assert.InDelta(t, 57.64911, c.Lat, 0.00001)
+	assert.InDelta(t, 10.40744, c.Lon, 0.00001)
+	assert.True(t, b.MinLat <= 57.64911 && 57.64911 <= b.MaxLat)
+	assert.True(t, b.MinLon <= 10.40744 && 10.40744 <= b.MaxLon)
+
+	upper, err := DecodeGeohash("GCPVJ")
+	require.NoError(t, err)
+	lower, err := DecodeGeohash("gcpvj")
+	require.NoError(t, err)
+	assert.Equal(t, lower, upper)
+
+	_, err = DecodeGeohash("")
+	assert.Error(t, err)
+	_, err = DecodeGeohash("abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package geo
+
+import (
+	"fmt"
+)
+
+// Position returns the point as a GeoJSON position ([longitude, latitude])
+func (p Point) Position() []interface{} {
+	return []interface{}{p.Lon, p.Lat}
+}
+
+// PointGeometry returns a GeoJSON Point geometry
+func PointGeometry(p Point) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "Point",
+		"coordinates": p.Position(),
+	}
+}
+
+// PolygonGeometry returns a GeoJSON Polygon geometry. Rings that aren't closed
+// (where the last position isn't the same as the first) are closed.
+func PolygonGeometry(p Polygon) map[string]interface{} {
+	rings := make([]interface{}, len(p))
+	for i, ring := range p {
+		positions := make([]interface{}, 0, len(ring)+1)
+		for _, pt := range ring {
+			positions = append(positions, pt.Position())
+		}
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			positions = append(positions, ring[0].Position())
+		}
+		rings[i] = positions
+	}
+	return map[string]interface{}{
+		"type":        "Polygon",
+		"coordinates": rings,
+	}
+}
+
+// Feature returns a GeoJSON Feature wrapping the geometry. The properties may
+// be nil.
+func Feature(geometry, properties map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := geometry["type"]; !ok {
+		return nil, fmt.Errorf("geometry has no GeoJSON type")
+	}
+	if geometry["type"] == "Feature" {
+		return nil, fmt.Errorf("can not nest a Feature inside another Feature")
+	}
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"type":       "Feature",
+		"geometry":   geometry,
+		"properties": properties,
+	}, nil
+}
+
+// FeatureCollection returns a GeoJSON FeatureCollection of the features
+func FeatureCollection(features ...map[string]interface{}) (map[string]interface{}, error) {
+	out := make([]interface{}, len(features))
+	for i, f := range features {
+		if f["type"] != "Feature" {
+			return nil, fmt.Errorf("FeatureCollection members must be Features, got %v", f["type"])
+		}
+		out[i] = f
+	}
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": out,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointGeometry(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"type":        "Point",
+		"coordinates": []interface{}{-0.12, 51.5},
+	}, PointGeometry(Point{Lat: 51.5, Lon: -0.12}))
+}
+
+func TestPolygonGeometry(t *testing.T) {
+	g := PolygonGeometry(Polygon{{{0, 0}, {0, 1}, {1, 1}}})
+	assert.Equal(t, "Polygon", g["type"])
+	assert.Equal(t, []interface{}{
+		[]interface{}{
+			[]interface{}{0.0, 0.0},
+			[]interface{}{1.0, 0.0},
+			[]interface{}{1.0, 1.0},
+			[]interface{}{0.0, 0.0},
+		},
+	}, g["coordinates"])
+
+	// already-closed rings aren't closed again
+	g = PolygonGeometry(Polygon{{{0, 0}, {0, 1}, {1, 1}, {0, 0}}})
+	assert.Len(t, g["coordinates"].([]interface{})[0], 4)
+}
+
+func TestFeature(t *testing.T) {
+	g := PointGeometry(Point{Lat: 1, Lon: 2})
+	f, err := Feature(g, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"type":       "Feature",
+		"geometry":   g,
+		"properties": map[string]interface{}{},
+	}, f)
+
+	_, err = Feature(map[string]interface{}{}, nil)
+	assert.Error(t, err)
+	_, err = Feature(f, nil)
+	assert.Error(t, err)
+
+	fc, err := FeatureCollection(f, f)
+	require.NoError(t, err)
+	assert.Equal(t, "FeatureCollection", fc["type"])
+	assert.Len(t, fc["features"], 2)
+
+	_, err = FeatureCollection(g)
+	assert.Error(t, err)
+}
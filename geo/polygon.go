@@ -0,0 +1,266 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+)
+
+// Polygon - a polygon, made of an exterior ring, followed by any number of
+// interior rings (holes)
+type Polygon [][]Point
+
+// Contains returns true if the point is inside the polygon (and not inside any
+// of its holes). Edges are treated as straight lines in latitude/longitude
+// space, which is accurate enough for the region-sized polygons used for
+// geo-fencing.
+func (p Polygon) Contains(pt Point) bool {
+	if len(p) == 0 || !ringContains(p[0], pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains tests whether the point is inside the ring, by counting how
+// many edges a ray cast east from the point crosses
+func ringContains(ring []Point, pt Point) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lon < (b.Lon-a.Lon)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			in = !in
+		}
+	}
+	return in
+}
+
+// ParsePoint parses a position - either a GeoJSON-style [longitude, latitude]
+// array, a map with "lat" and "lon" keys (or "latitude"/"longitude", or
+// "lng"), or a GeoJSON Point geometry or Feature
+func ParsePoint(v interface{}) (Point, error) {
+	switch p := v.(type) {
+	case Point:
+		return p, p.Validate()
+	case map[string]interface{}:
+		if t, ok := p["type"]; ok {
+			return parseGeoJSONPoint(conv.ToString(t), p)
+		}
+
+		lat, ok := lookup(p, "lat", "latitude")
+		if !ok {
+			return Point{}, fmt.Errorf("position is missing a latitude (lat) key")
+		}
+		lon, ok := lookup(p, "lon", "lng", "longitude")
+		if !ok {
+			return Point{}, fmt.Errorf("position is missing a longitude (lon) key")
+		}
+		return newPoint(lat, lon)
+	}
+
+	coords, err := toSlice(v)
+	if err != nil {
+		return Point{}, fmt.Errorf("can not parse position from %T", v)
+	}
+	if len(coords) < 2 {
+		return Point{}, fmt.Errorf("position must have at least 2 coordinates, got %d", len(coords))
+	}
+	return newPoint(coords[1], coords[0])
+}
+
+func parseGeoJSONPoint(t string, m map[string]interface{}) (Point, error) {
+	switch t {
+	case "Point":
+		return ParsePoint(m["coordinates"])
+	case "Feature":
+		g, ok := m["geometry"].(map[string]interface{})
+		if !ok {
+			return Point{}, fmt.Errorf("feature has no geometry")
+		}
+		return ParsePoint(g)
+	default:
+		return Point{}, fmt.Errorf("can not parse position from GeoJSON %s", t)
+	}
+}
+
+// ParsePolygons parses one or more polygons from a GeoJSON Polygon or
+// MultiPolygon geometry, a Feature or FeatureCollection containing them, or a
+// bare array of positions (a single ring)
+func ParsePolygons(v interface{}) ([]Polygon, error) {
+	switch p := v.(type) {
+	case Polygon:
+		return []Polygon{p}, nil
+	case []Polygon:
+		return p, nil
+	case map[string]interface{}:
+		return parseGeoJSONPolygons(p)
+	}
+
+	ring, err := parseRing(v)
+	if err != nil {
+		return nil, err
+	}
+	return []Polygon{{ring}}, nil
+}
+
+func parseGeoJSONPolygons(m map[string]interface{}) ([]Polygon, error) {
+	t := conv.ToString(m["type"])
+	switch t {
+	case "Polygon":
+		poly, err := parsePolygon(m["coordinates"])
+		if err != nil {
+			return nil, err
+		}
+		return []Polygon{poly}, nil
+	case "MultiPolygon":
+		polys, err := toSlice(m["coordinates"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+		out := make([]Polygon, len(polys))
+		for i, p := range polys {
+			out[i], err = parsePolygon(p)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case "Feature":
+		g, ok := m["geometry"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		return parseGeoJSONPolygons(g)
+	case "FeatureCollection", "GeometryCollection":
+		key := "features"
+		if t == "GeometryCollection" {
+			key = "geometries"
+		}
+		items, err := toSlice(m[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", t, key, err)
+		}
+		out := []Polygon{}
+		for _, item := range items {
+			im, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: %s must be objects", t, key)
+			}
+			polys, err := parseGeoJSONPolygons(im)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, polys...)
+		}
+		return out, nil
+	case "":
+		return nil, fmt.Errorf("can not parse polygon from object with no GeoJSON type")
+	default:
+		return nil, fmt.Errorf("can not parse polygon from GeoJSON %s", t)
+	}
+}
+
+func parsePolygon(v interface{}) (Polygon, error) {
+	rings, err := toSlice(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+	}
+	if len(rings) == 0 {
+		return nil, fmt.Errorf("polygon has no rings")
+	}
+	poly := make(Polygon, len(rings))
+	for i, r := range rings {
+		poly[i], err = parseRing(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return poly, nil
+}
+
+func parseRing(v interface{}) ([]Point, error) {
+	positions, err := toSlice(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ring: %w", err)
+	}
+	if len(positions) < 3 {
+		return nil, fmt.Errorf("a ring must have at least 3 positions, got %d", len(positions))
+	}
+	ring := make([]Point, len(positions))
+	for i, pos := range positions {
+		ring[i], err = ParsePoint(pos)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ring, nil
+}
+
+func newPoint(lat, lon interface{}) (Point, error) {
+	la, err := toFloat(lat)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid latitude: %w", err)
+	}
+	lo, err := toFloat(lon)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid longitude: %w", err)
+	}
+	p := Point{Lat: la, Lon: lo}
+	return p, p.Validate()
+}
+
+// toFloat converts a number (or numeric string) to a float64, unlike
+// conv.ToFloat64 returning an error for non-numeric values
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	}
+	s := strings.TrimSpace(conv.ToString(v))
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can not convert %q to a number", s)
+	}
+	return f, nil
+}
+
+func lookup(m map[string]interface{}, keys ...string) (interface{}, bool) {
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func toSlice(v interface{}) ([]interface{}, error) {
+	switch s := v.(type) {
+	case []interface{}:
+		return s, nil
+	case []float64:
+		out := make([]interface{}, len(s))
+		for i, f := range s {
+			out[i] = f
+		}
+		return out, nil
+	case [][]float64:
+		out := make([]interface{}, len(s))
+		for i, f := range s {
+			out[i] = f
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+}
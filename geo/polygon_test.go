@@ -0,0 +1,107 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolygonContains(t *testing.T) {
+	square := []Point{{0, 0}, {0, 10}, {10, 10}, {10, 0}}
+	hole := []Point{{4, 4}, {4, 6}, {6, 6}, {6, 4}}
+
+	p := Polygon{square}
+	assert.True(t, p.Contains(Point{5, 5}))
+	assert.False(t, p.Contains(Point{11, 5}))
+	assert.False(t, p.Contains(Point{5, -1}))
+
+	p = Polygon{square, hole}
+	assert.False(t, p.Contains(Point{5, 5}))
+	assert.True(t, p.Contains(Point{2, 2}))
+
+	assert.False(t, Polygon{}.Contains(Point{5, 5}))
+}
+
+func TestParsePoint(t *testing.T) {
+	expected := Point{Lat: 51.5, Lon: -0.12}
+
+	testdata := []interface{}{
+		[]interface{}{-0.12, 51.5},
+		[]interface{}{"-0.12", "51.5", 0},
+		[]float64{-0.12, 51.5},
+		map[string]interface{}{"lat": 51.5, "lon": -0.12},
+		map[string]interface{}{"latitude": "51.5", "longitude": "-0.12"},
+		map[string]interface{}{"lat": 51.5, "lng": -0.12},
+		map[string]interface{}{"type": "Point", "coordinates": []interface{}{-0.12, 51.5}},
+		map[string]interface{}{"type": "Feature", "geometry": map[string]interface{}{
+			"type": "Point", "coordinates": []interface{}{-0.12, 51.5},
+		}},
+	}
+	for _, d := range testdata {
+		p, err := ParsePoint(d)
+		require.NoError(t, err, d)
+		assert.Equal(t, expected, p, d)
+	}
+
+	for _, d := range []interface{}{
+		"london",
+		[]interface{}{1.0},
+		[]interface{}{"a", "b"},
+		[]interface{}{0, 91},
+		map[string]interface{}{"lat": 1},
+		map[string]interface{}{"lon": 1},
+		map[string]interface{}{"type": "Polygon"},
+		map[string]interface{}{"type": "Feature"},
+	} {
+		_, err := ParsePoint(d)
+		assert.Error(t, err, d)
+	}
+}
+
+func TestParsePolygons(t *testing.T) {
+	ring := []interface{}{
+		[]interface{}{0, 0}, []interface{}{10, 0}, []interface{}{10, 10}, []interface{}{0, 10}, []interface{}{0, 0},
+	}
+	polygon := map[string]interface{}{"type": "Polygon", "coordinates": []interface{}{ring}}
+
+	polys, err := ParsePolygons(ring)
+	require.NoError(t, err)
+	require.Len(t, polys, 1)
+	assert.True(t, polys[0].Contains(Point{5, 5}))
+
+	polys, err = ParsePolygons(polygon)
+	require.NoError(t, err)
+	require.Len(t, polys, 1)
+	assert.Len(t, polys[0][0], 5)
+
+	polys, err = ParsePolygons(map[string]interface{}{
+		"type":        "MultiPolygon",
+		"coordinates": []interface{}{[]interface{}{ring}, []interface{}{ring}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, polys, 2)
+
+	polys, err = ParsePolygons(map[string]interface{}{
+		"type": "FeatureCollection",
+		"features": []interface{}{
+			map[string]interface{}{"type": "Feature", "geometry": polygon},
+			map[string]interface{}{"type": "Feature", "geometry": polygon},
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, polys, 2)
+
+	for _, d := range []interface{}{
+		"nope",
+		[]interface{}{[]interface{}{0, 0}, []interface{}{1, 1}},
+		map[string]interface{}{"coordinates": []interface{}{ring}},
+		map[string]interface{}{"type": "Point", "coordinates": []interface{}{0, 0}},
+		map[string]interface{}{"type": "Polygon", "coordinates": []interface{}{}},
+		map[string]interface{}{"type": "Feature"},
+		map[string]interface{}{"type": "FeatureCollection", "features": []interface{}{"x"}},
+	} {
+		_, err := ParsePolygons(d)
+		assert.Error(t, err, d)
+	}
+}
@@ -12,10 +12,23 @@ import (
 	"time"
 
 	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/credhelper"
+	"github.com/hairyhenderson/gomplate/v3/internal/encrypt"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/hairyhenderson/gomplate/v3/internal/notify"
+	"github.com/hairyhenderson/gomplate/v3/internal/ratelimit"
+	"github.com/hairyhenderson/gomplate/v3/internal/verify"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 )
 
+// ErrConflict - wrapped by errors from conditional writes to remote outputs,
+// when the output was changed by another writer after it was opened. Check
+// for it with errors.Is, and render again to retry.
+var ErrConflict = cas.ErrConflict
+
 // RunTemplates - run all gomplate templates specified by the given configuration
 //
 // Deprecated: use the Renderer interface instead
@@ -28,31 +41,70 @@ func RunTemplates(o *Config) error {
 }
 
 // Run all gomplate templates specified by the given configuration
-func Run(ctx context.Context, cfg *config.Config) error {
+func Run(ctx context.Context, cfg *config.Config) (err error) {
 	Metrics = newMetrics()
 	defer runCleanupHooks()
 
 	// apply defaults before validation
 	cfg.ApplyDefaults()
 
-	err := cfg.Validate()
+	err = cfg.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate config: %w\n%+v", err, cfg)
 	}
 
-	funcMap := template.FuncMap{}
-	err = bindPlugins(ctx, cfg, funcMap)
-	if err != nil {
-		return err
+	if len(cfg.EnvFiles) > 0 {
+		restoreEnv, err := loadEnvFiles(cfg.EnvFiles, cfg.EnvFileNoOverride)
+		if err != nil {
+			return err
+		}
+		defer restoreEnv()
+	}
+
+	if len(cfg.Notify) > 0 {
+		runStart := time.Now()
+		defer func() {
+			sendNotifications(ctx, cfg.Notify, err, time.Since(runStart))
+		}()
+	}
+
+	var lock *lockState
+	if cfg.Lock != nil {
+		var release func()
+		lock, release, err = acquireLock(ctx, cfg.Lock)
+		if err != nil {
+			return err
+		}
+		if lock == nil {
+			zerolog.Ctx(ctx).Info().Str("lock", cfg.Lock.URL).Msg("lock is held elsewhere - not rendering")
+			return nil
+		}
+		defer release()
+
+		var cancel context.CancelFunc
+		ctx, cancel = contextWithLock(ctx, lock)
+		defer cancel()
+		defer func() {
+			if err == nil && !lock.Held() {
+				err = fmt.Errorf("lock on %s was lost while rendering", lock.Key)
+			}
+		}()
 	}
 
 	// if a custom Stdin is set in the config, inject it into the context now
 	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
 
-	opts := optionsFromConfig(cfg)
-	opts.Funcs = funcMap
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	opts.lock = lock
 	tr := NewRenderer(opts)
 
+	if cfg.Matrix != nil {
+		return runMatrix(ctx, cfg, tr)
+	}
+
 	start := time.Now()
 
 	namer := chooseNamer(cfg, tr)
@@ -72,6 +124,85 @@ func Run(ctx context.Context, cfg *config.Config) error {
 	return nil
 }
 
+// runOptions creates the renderer options for the config, with plugins bound
+// and values loaded
+func runOptions(ctx context.Context, cfg *config.Config) (Options, error) {
+	funcMap := template.FuncMap{}
+	err := bindPlugins(ctx, cfg, funcMap)
+	if err != nil {
+		return Options{}, err
+	}
+
+	opts := optionsFromConfig(cfg)
+	opts.Funcs = funcMap
+	if len(cfg.Signatures) > 0 || cfg.Verify {
+		opts.verifier, err = verify.New(cfg.Signatures, cfg.Verify)
+		if err != nil {
+			return Options{}, err
+		}
+	}
+	opts.netPolicy = networkPolicy(cfg)
+	if len(cfg.Encrypt) > 0 {
+		opts.encrypter, err = encrypt.New(cfg.Encrypt)
+		if err != nil {
+			return Options{}, err
+		}
+	}
+	opts.rateLimiter = ratelimit.New(cfg.RateLimits)
+	opts.credHelpers = credhelper.New(cfg.CredentialHelpers)
+	opts.event = eventFromContext(ctx)
+	opts.request = requestFromContext(ctx)
+	if cfg.Snapshot != "" {
+		opts.Snapshots, err = loadSnapshots(cfg.Snapshot)
+		if err != nil {
+			return Options{}, err
+		}
+	}
+	if len(cfg.Values) > 0 || len(cfg.ValuesFiles) > 0 || len(cfg.SetValues) > 0 {
+		opts.Values, err = loadValues(cfg.Values, cfg.ValuesFiles, cfg.SetValues)
+		if err != nil {
+			return Options{}, err
+		}
+	}
+	return opts, nil
+}
+
+// networkPolicy - the policy that datasources, templates, and outputs must be
+// allowed by. Rendering from a snapshot is offline, so nothing is allowed.
+func networkPolicy(cfg *config.Config) *netpolicy.Policy {
+	if cfg.Snapshot != "" {
+		return netpolicy.Offline("rendering from a snapshot")
+	}
+	return netpolicy.New(cfg.NetworkPolicy)
+}
+
+// runMatrix gathers the templates and renders them once for each matrix item
+func runMatrix(ctx context.Context, cfg *config.Config, tr *Renderer) error {
+	start := time.Now()
+	tmpl, err := readInputTemplates(cfg)
+	Metrics.GatherDuration = time.Since(start)
+	if err != nil {
+		Metrics.Errors++
+		return fmt.Errorf("failed to gather templates for rendering: %w", err)
+	}
+
+	return tr.renderMatrix(ctx, cfg, tmpl)
+}
+
+// sendNotifications - notify the configured webhooks of the outcome of the
+// run. Failures are logged, but don't affect the result of the run.
+func sendNotifications(ctx context.Context, hooks []config.NotifyConfig, err error, d time.Duration) {
+	nerr := notify.Send(ctx, hooks, notify.Summary{
+		Err:          err,
+		Templates:    Metrics.TemplatesProcessed,
+		ChangedFiles: Metrics.ChangedFiles,
+		Duration:     d,
+	})
+	if nerr != nil {
+		zerolog.Ctx(ctx).Warn().Err(nerr).Msg("failed to send notification")
+	}
+}
+
 func chooseNamer(cfg *config.Config, tr *Renderer) func(context.Context, string) (string, error) {
 	if cfg.OutputMap == "" {
 		return simpleNamer(cfg.OutputDir)
@@ -89,33 +220,48 @@ func simpleNamer(outDir string) func(ctx context.Context, inPath string) (string
 func mappingNamer(outMap string, tr *Renderer) func(context.Context, string) (string, error) {
 	return func(ctx context.Context, inPath string) (string, error) {
 		tr.data.Ctx = ctx
-		tcontext, err := createTmplContext(ctx, tr.tctxAliases, tr.data)
+		tcontext, err := createTmplContext(ctx, tr.tctxAliases, tr.tctxRoots, tr.data)
 		if err != nil {
 			return "", err
 		}
 
-		// add '.in' to the template context and preserve the original context
-		// in '.ctx'
-		tctx := &tmplctx{}
-		// nolint: gocritic
-		switch c := tcontext.(type) {
-		case *tmplctx:
-			for k, v := range *c {
-				if k != "in" && k != "ctx" {
-					(*tctx)[k] = v
-				}
+		return tr.renderOutputPath(ctx, "<OutputMap>", outMap, tcontext, inPath)
+	}
+}
+
+// renderOutputPath renders an output path template (like --output-map) for
+// the input path, with the input path available as '.in', and the template
+// context as '.ctx'
+func (t *Renderer) renderOutputPath(ctx context.Context, name, text string, tcontext interface{}, inPath string) (string, error) {
+	// add '.in' to the template context and preserve the original context
+	// in '.ctx'
+	tctx := &tmplctx{}
+	// nolint: gocritic
+	switch c := tcontext.(type) {
+	case *tmplctx:
+		for k, v := range *c {
+			if k != "in" && k != "ctx" {
+				(*tctx)[k] = v
 			}
 		}
-		(*tctx)["ctx"] = tcontext
-		(*tctx)["in"] = inPath
+	}
+	(*tctx)["ctx"] = tcontext
+	(*tctx)["in"] = inPath
 
-		out := &bytes.Buffer{}
-		err = tr.renderTemplatesWithData(ctx,
-			[]Template{{Name: "<OutputMap>", Text: outMap, Writer: out}}, tctx)
-		if err != nil {
-			return "", errors.Wrapf(err, "failed to render outputMap with ctx %+v and inPath %s", tctx, inPath)
-		}
+	// output paths must never be HTML-escaped
+	nr := *t
+	nr.htmlEscape = false
+
+	out := &bytes.Buffer{}
+	err := nr.renderTemplatesWithData(ctx,
+		[]Template{{Name: name, Text: text, Writer: out}}, tctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render %s with ctx %+v and inPath %s", strings.Trim(name, "<>"), tctx, inPath)
+	}
 
-		return filepath.Clean(strings.TrimSpace(out.String())), nil
+	outPath := strings.TrimSpace(out.String())
+	if data.IsOutputURL(outPath) {
+		return outPath, nil
 	}
+	return filepath.Clean(outPath), nil
 }
@@ -186,4 +186,10 @@ func TestMappingNamer(t *testing.T) {
 	assert.NoError(t, err)
 	expected = filepath.FromSlash("out/foofile")
 	assert.Equal(t, expected, out)
+
+	// remote output URLs aren't cleaned like paths
+	n = mappingNamer("s3://bucket/out//{{ .in }}", tr)
+	out, err = n(ctx, "file")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://bucket/out//file", out)
 }
@@ -0,0 +1,181 @@
+// Package gotype contains functions for generating Go type definitions from
+// sample data or JSON Schema documents.
+package gotype
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// commonInitialisms are rendered in all-caps in field and type names, as
+// recommended by the Go style guide (and as golint does)
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true,
+	"QPS": true, "RAM": true, "RHS": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true,
+	"XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// GoName converts an arbitrary string (such as a JSON object key) into an
+// exported Go identifier.
+func GoName(s string) string {
+	words := splitWords(s)
+	out := &strings.Builder{}
+	for _, w := range words {
+		u := strings.ToUpper(w)
+		if commonInitialisms[u] {
+			out.WriteString(u)
+			continue
+		}
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		out.WriteString(string(r))
+	}
+
+	name := out.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// splitWords splits a string on non-alphanumeric characters and on
+// lower-to-upper case transitions
+func splitWords(s string) []string {
+	words := []string{}
+	cur := []rune{}
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = []rune{}
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(cur) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+// field is a single struct field
+type field struct {
+	Name    string
+	Key     string
+	Type    string
+	Comment string
+	Omit    bool
+}
+
+// structType is a named struct type
+type structType struct {
+	Name    string
+	Comment string
+	Fields  []field
+}
+
+// generator accumulates the struct types needed to describe a value
+type generator struct {
+	defs  map[string]interface{}
+	types []*structType
+	names map[string]bool
+}
+
+func newGenerator() *generator {
+	return &generator{names: map[string]bool{}}
+}
+
+// uniqueName returns the given type name, or a numbered variant if the name
+// is already taken
+func (g *generator) uniqueName(name string) string {
+	n := name
+	for i := 2; g.names[n]; i++ {
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[n] = true
+	return n
+}
+
+// source renders all accumulated types as gofmt-ed Go source
+func (g *generator) source() (string, error) {
+	out := &strings.Builder{}
+	for i, t := range g.types {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		if t.Comment != "" {
+			writeComment(out, "", t.Comment)
+		}
+		fmt.Fprintf(out, "type %s struct {\n", t.Name)
+		for _, f := range t.Fields {
+			if f.Comment != "" {
+				writeComment(out, "\t", f.Comment)
+			}
+			omit := ""
+			if f.Omit {
+				omit = ",omitempty"
+			}
+			fmt.Fprintf(out, "\t%s %s `json:\"%s%s\" yaml:\"%s%s\"`\n",
+				f.Name, f.Type, f.Key, omit, f.Key, omit)
+		}
+		out.WriteString("}\n")
+	}
+
+	b, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(b), nil
+}
+
+func writeComment(out *strings.Builder, indent, comment string) {
+	for _, l := range strings.Split(strings.TrimSpace(comment), "\n") {
+		fmt.Fprintf(out, "%s// %s\n", indent, strings.TrimSpace(l))
+	}
+}
+
+// fieldNames returns unique Go field names for the given (sorted) keys
+func fieldNames(keys []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		n := GoName(k)
+		base := n
+		for j := 2; seen[n]; j++ {
+			n = fmt.Sprintf("%s%d", base, j)
+		}
+		seen[n] = true
+		out[i] = n
+	}
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,183 @@
+package gotype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoName(t *testing.T) {
+	data := map[string]string{
+		"foo":          "Foo",
+		"foo_bar":      "FooBar",
+		"foo-bar baz":  "FooBarBaz",
+		"fooBar":       "FooBar",
+		"userId":       "UserID",
+		"api_url":      "APIURL",
+		"HTTPServer":   "HTTPServer",
+		"2fa":          "X2fa",
+		"":             "Field",
+		"$ref":         "Ref",
+		"already_JSON": "AlreadyJSON",
+	}
+	for in, expected := range data {
+		assert.Equal(t, expected, GoName(in), in)
+	}
+}
+
+func TestFromSample(t *testing.T) {
+	sample := map[string]interface{}{
+		"id":      1,
+		"name":    "foo",
+		"score":   1.5,
+		"enabled": true,
+		"tags":    []interface{}{"a", "b"},
+		"owner":   map[string]interface{}{"login": "bar"},
+		"items": []interface{}{
+			map[string]interface{}{"sku": "x"},
+			map[string]interface{}{"qty": 2},
+		},
+		"extra": nil,
+	}
+	expected := "type Repo struct {\n" +
+		"\tEnabled bool        `json:\"enabled\" yaml:\"enabled\"`\n" +
+		"\tExtra   interface{} `json:\"extra,omitempty\" yaml:\"extra,omitempty\"`\n" +
+		"\tID      int64       `json:\"id\" yaml:\"id\"`\n" +
+		"\tItems   []RepoItems `json:\"items\" yaml:\"items\"`\n" +
+		"\tName    string      `json:\"name\" yaml:\"name\"`\n" +
+		"\tOwner   *RepoOwner  `json:\"owner\" yaml:\"owner\"`\n" +
+		"\tScore   float64     `json:\"score\" yaml:\"score\"`\n" +
+		"\tTags    []string    `json:\"tags\" yaml:\"tags\"`\n" +
+		"}\n\n" +
+		"type RepoItems struct {\n" +
+		"\tQty int64  `json:\"qty\" yaml:\"qty\"`\n" +
+		"\tSku string `json:\"sku\" yaml:\"sku\"`\n" +
+		"}\n\n" +
+		"type RepoOwner struct {\n" +
+		"\tLogin string `json:\"login\" yaml:\"login\"`\n" +
+		"}\n"
+	out, err := FromSample("repo", sample)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	out, err = FromSample("list", []interface{}{
+		map[string]interface{}{"a": 1},
+		map[string]interface{}{"b": "x"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out, "type List struct {")
+	assert.Contains(t, out, "A int64")
+	assert.Contains(t, out, "B string")
+
+	_, err = FromSample("foo", "bar")
+	assert.Error(t, err)
+
+	_, err = FromSample("foo", []interface{}{1, 2})
+	assert.Error(t, err)
+}
+
+func TestFromSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":        "object",
+		"description": "a person",
+		"required":    []interface{}{"name"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "description": "the name"},
+			"age":  map[string]interface{}{"type": []interface{}{"integer", "null"}},
+			"address": map[string]interface{}{
+				"$ref": "#/definitions/address",
+			},
+			"friends": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/definitions/person"},
+			},
+			"labels": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"address": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"street": map[string]interface{}{"type": "string"},
+				},
+			},
+			"person": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":    map[string]interface{}{"type": "string"},
+					"friends": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/definitions/person"}},
+				},
+			},
+		},
+	}
+
+	expected := "// Person - a person\n" +
+		"type Person struct {\n" +
+		"\tAddress *Address          `json:\"address,omitempty\" yaml:\"address,omitempty\"`\n" +
+		"\tAge     int64             `json:\"age,omitempty\" yaml:\"age,omitempty\"`\n" +
+		"\tFriends []*Person2        `json:\"friends,omitempty\" yaml:\"friends,omitempty\"`\n" +
+		"\tLabels  map[string]string `json:\"labels,omitempty\" yaml:\"labels,omitempty\"`\n" +
+		"\t// the name\n" +
+		"\tName string `json:\"name\" yaml:\"name\"`\n" +
+		"}\n\n" +
+		"type Address struct {\n" +
+		"\tStreet string `json:\"street,omitempty\" yaml:\"street,omitempty\"`\n" +
+		"}\n\n" +
+		"type Person2 struct {\n" +
+		"\tFriends []*Person2 `json:\"friends,omitempty\" yaml:\"friends,omitempty\"`\n" +
+		"\tName    string     `json:\"name,omitempty\" yaml:\"name,omitempty\"`\n" +
+		"}\n"
+
+	out, err := FromSchema("person", schema)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	_, err = FromSchema("foo", map[string]interface{}{"type": "string"})
+	assert.Error(t, err)
+
+	_, err = FromSchema("foo", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"a": map[string]interface{}{"$ref": "http://example.com/schema.json"},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestFromSchema_RecursiveRef(t *testing.T) {
+	// references back to non-object definitions would otherwise recurse
+	// forever
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tree":  map[string]interface{}{"$ref": "#/$defs/tree"},
+			"loop":  map[string]interface{}{"$ref": "#/$defs/a"},
+			"index": map[string]interface{}{"$ref": "#/$defs/index"},
+		},
+		"$defs": map[string]interface{}{
+			"tree": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/$defs/tree"},
+			},
+			"a": map[string]interface{}{"$ref": "#/$defs/b"},
+			"b": map[string]interface{}{"$ref": "#/$defs/a"},
+			"index": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"$ref": "#/$defs/index"},
+			},
+		},
+	}
+
+	expected := "type Doc struct {\n" +
+		"\tIndex map[string]interface{} `json:\"index,omitempty\" yaml:\"index,omitempty\"`\n" +
+		"\tLoop  interface{}            `json:\"loop,omitempty\" yaml:\"loop,omitempty\"`\n" +
+		"\tTree  []interface{}          `json:\"tree,omitempty\" yaml:\"tree,omitempty\"`\n" +
+		"}\n"
+
+	out, err := FromSchema("doc", schema)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
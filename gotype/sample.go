@@ -0,0 +1,124 @@
+package gotype
+
+import "fmt"
+
+// FromSample generates Go struct definitions describing the given sample
+// value (usually parsed from JSON or YAML). The top-level value must be a map
+// (or a list of maps). Nested maps produce additional named struct types,
+// and lists of maps are merged so that every key seen in any element becomes
+// a field.
+func FromSample(name string, sample interface{}) (string, error) {
+	g := newGenerator()
+
+	switch v := sample.(type) {
+	case map[string]interface{}:
+		g.sampleStruct(GoName(name), v)
+	case []interface{}:
+		m, ok := mergeMaps(v)
+		if !ok {
+			return "", fmt.Errorf("sample must be a map or a list of maps, got a list of mixed values")
+		}
+		g.sampleStruct(GoName(name), m)
+	default:
+		return "", fmt.Errorf("sample must be a map or a list of maps, got %T", sample)
+	}
+
+	return g.source()
+}
+
+func (g *generator) sampleStruct(name string, m map[string]interface{}) string {
+	t := &structType{Name: g.uniqueName(name)}
+	g.types = append(g.types, t)
+
+	keys := sortedKeys(m)
+	names := fieldNames(keys)
+	for i, k := range keys {
+		t.Fields = append(t.Fields, field{
+			Name: names[i],
+			Key:  k,
+			Type: g.sampleType(t.Name+names[i], m[k]),
+			Omit: m[k] == nil,
+		})
+	}
+	return t.Name
+}
+
+// sampleType infers the Go type for the given sample value, generating a new
+// named struct type for maps
+func (g *generator) sampleType(name string, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "interface{}"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "int64"
+	case float32, float64:
+		return "float64"
+	case map[string]interface{}:
+		return "*" + g.sampleStruct(name, v)
+	case []interface{}:
+		return "[]" + g.sliceElemType(name, v)
+	default:
+		return "interface{}"
+	}
+}
+
+func (g *generator) sliceElemType(name string, l []interface{}) string {
+	if len(l) == 0 {
+		return "interface{}"
+	}
+	if m, ok := mergeMaps(l); ok {
+		return g.sampleStruct(name, m)
+	}
+
+	elemType := ""
+	for _, e := range l {
+		t := g.sampleType(name, e)
+		switch {
+		case elemType == "" || elemType == t:
+			elemType = t
+		case isNumeric(elemType) && isNumeric(t):
+			elemType = "float64"
+		default:
+			return "interface{}"
+		}
+	}
+	return elemType
+}
+
+func isNumeric(t string) bool {
+	return t == "int64" || t == "float64"
+}
+
+// mergeMaps merges a list of maps into one map containing every key, for
+// inferring a single struct type from all the list's elements. Returns false
+// if the list contains non-map elements.
+func mergeMaps(l []interface{}) (map[string]interface{}, bool) {
+	if len(l) == 0 {
+		return nil, false
+	}
+	out := map[string]interface{}{}
+	for _, e := range l {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for k, v := range m {
+			if existing, ok := out[k]; ok && existing != nil {
+				// merge nested maps too, so that all keys are represented
+				em, eok := existing.(map[string]interface{})
+				vm, vok := v.(map[string]interface{})
+				if eok && vok {
+					merged, _ := mergeMaps([]interface{}{em, vm})
+					out[k] = merged
+				}
+				continue
+			}
+			out[k] = v
+		}
+	}
+	return out, true
+}
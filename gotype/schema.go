@@ -0,0 +1,176 @@
+package gotype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FromSchema generates Go struct definitions from the given JSON Schema
+// document. The schema's root must describe an object. Local references (to
+// "#/definitions/..." or "#/$defs/...") produce named types, and properties not
+// listed as "required" are tagged with "omitempty".
+func FromSchema(name string, schema map[string]interface{}) (string, error) {
+	g := newGenerator()
+	g.defs = map[string]interface{}{}
+	for _, k := range []string{"definitions", "$defs"} {
+		if d, ok := schema[k].(map[string]interface{}); ok {
+			for n, v := range d {
+				g.defs["#/"+k+"/"+n] = v
+			}
+		}
+	}
+
+	if t, _ := schema["type"].(string); t != "object" && schema["properties"] == nil {
+		return "", fmt.Errorf("schema root must be an object, got type %q", t)
+	}
+
+	refs := map[string]string{}
+	_, err := g.schemaStruct(GoName(name), schema, refs)
+	if err != nil {
+		return "", err
+	}
+
+	return g.source()
+}
+
+func (g *generator) schemaStruct(name string, schema map[string]interface{}, refs map[string]string) (string, error) {
+	return g.namedSchemaStruct(g.uniqueName(name), schema, refs)
+}
+
+// namedSchemaStruct generates a struct type with an already-reserved name
+func (g *generator) namedSchemaStruct(name string, schema map[string]interface{}, refs map[string]string) (string, error) {
+	t := &structType{Name: name}
+	t.Comment, _ = schema["description"].(string)
+	if t.Comment != "" {
+		t.Comment = t.Name + " - " + t.Comment
+	}
+	g.types = append(g.types, t)
+
+	required := map[string]bool{}
+	if r, ok := schema["required"].([]interface{}); ok {
+		for _, k := range r {
+			required[fmt.Sprint(k)] = true
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	keys := sortedKeys(props)
+	names := fieldNames(keys)
+	for i, k := range keys {
+		p, ok := props[k].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("property %q must be a schema object, got %T", k, props[k])
+		}
+		typ, err := g.schemaType(t.Name+names[i], p, refs)
+		if err != nil {
+			return "", fmt.Errorf("property %q: %w", k, err)
+		}
+		desc, _ := p["description"].(string)
+		t.Fields = append(t.Fields, field{
+			Name:    names[i],
+			Key:     k,
+			Type:    typ,
+			Comment: desc,
+			Omit:    !required[k],
+		})
+	}
+	return t.Name, nil
+}
+
+// schemaType determines the Go type for the given schema, generating named
+// struct types for nested objects
+func (g *generator) schemaType(name string, schema map[string]interface{}, refs map[string]string) (string, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		return g.refType(ref, refs)
+	}
+
+	typ := schema["type"]
+	// a list of types like ["string", "null"] - use the first non-null type
+	if l, ok := typ.([]interface{}); ok {
+		typ = nil
+		for _, t := range l {
+			if t != "null" {
+				typ = t
+				break
+			}
+		}
+	}
+	if typ == nil && schema["properties"] != nil {
+		typ = "object"
+	}
+
+	switch typ {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			return "[]interface{}", nil
+		}
+		et, err := g.schemaType(name, items, refs)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + et, nil
+	case "object":
+		props, _ := schema["properties"].(map[string]interface{})
+		if len(props) == 0 {
+			if ap, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				vt, err := g.schemaType(name, ap, refs)
+				if err != nil {
+					return "", err
+				}
+				return "map[string]" + vt, nil
+			}
+			return "map[string]interface{}", nil
+		}
+		n, err := g.schemaStruct(name, schema, refs)
+		if err != nil {
+			return "", err
+		}
+		return "*" + n, nil
+	default:
+		return "interface{}", nil
+	}
+}
+
+// refType resolves a local $ref, generating the referenced type only once
+func (g *generator) refType(ref string, refs map[string]string) (string, error) {
+	if t, ok := refs[ref]; ok {
+		return t, nil
+	}
+	def, ok := g.defs[ref].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unresolvable $ref %q (only local definitions are supported)", ref)
+	}
+
+	parts := strings.Split(ref, "/")
+	name := GoName(parts[len(parts)-1])
+
+	// objects are referenced by pointer, and registered before generating so
+	// that recursive definitions terminate
+	if props, ok := def["properties"].(map[string]interface{}); ok && len(props) > 0 {
+		name = g.uniqueName(name)
+		refs[ref] = "*" + name
+		if _, err := g.namedSchemaStruct(name, def, refs); err != nil {
+			return "", err
+		}
+		return refs[ref], nil
+	}
+
+	// other types can't refer to themselves in Go without being named, so a
+	// recursive reference (like an array of itself) is an interface{}
+	refs[ref] = "interface{}"
+	t, err := g.schemaType(name, def, refs)
+	if err != nil {
+		return "", err
+	}
+	refs[ref] = t
+	return t, nil
+}
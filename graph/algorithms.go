@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CycleError - returned when an operation needs an acyclic graph
+type CycleError struct {
+	// Cycle - the nodes in the cycle, starting and ending with the same node
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("graph has a cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// TopoSort returns the nodes in topological order - for every edge, the node
+// it's from comes before the node it's to. Ties are broken alphabetically, so
+// the order is stable. A *CycleError is returned if the graph has a cycle.
+func (g *Graph) TopoSort() ([]string, error) {
+	indegree := map[string]int{}
+	for _, n := range g.Nodes() {
+		for _, to := range g.Neighbours(n) {
+			indegree[to]++
+		}
+	}
+
+	ready := []string{}
+	for _, n := range g.Nodes() {
+		if indegree[n] == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	out := make([]string, 0, len(g.edges))
+	for len(ready) > 0 {
+		n := ready[0]
+		ready = ready[1:]
+		out = append(out, n)
+
+		added := false
+		for _, to := range g.Neighbours(n) {
+			indegree[to]--
+			if indegree[to] == 0 {
+				ready = append(ready, to)
+				added = true
+			}
+		}
+		if added {
+			sort.Strings(ready)
+		}
+	}
+
+	if len(out) < len(g.edges) {
+		return nil, &CycleError{Cycle: g.FindCycle()}
+	}
+	return out, nil
+}
+
+// FindCycle returns a cycle in the graph, starting and ending with the same
+// node, or nil if the graph is acyclic
+func (g *Graph) FindCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	stack := []string{}
+
+	var visit func(n string) []string
+	visit = func(n string) []string {
+		state[n] = visiting
+		stack = append(stack, n)
+		for _, to := range g.Neighbours(n) {
+			switch state[to] {
+			case visiting:
+				// the cycle is the part of the stack from 'to' onwards
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == to {
+						cycle := append([]string{}, stack[i:]...)
+						return append(cycle, to)
+					}
+				}
+			case unvisited:
+				if c := visit(to); c != nil {
+					return c
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = done
+		return nil
+	}
+
+	for _, n := range g.Nodes() {
+		if state[n] == unvisited {
+			if c := visit(n); c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// ShortestPath returns the lowest-weight path between the nodes (including
+// both), and its total weight, using Dijkstra's algorithm. The path is nil if
+// there's no path between the nodes.
+func (g *Graph) ShortestPath(from, to string) ([]string, float64, error) {
+	for _, n := range []string{from, to} {
+		if !g.HasNode(n) {
+			return nil, 0, fmt.Errorf("node %q is not in the graph", n)
+		}
+	}
+	for _, n := range g.Nodes() {
+		for _, w := range g.edges[n] {
+			if w < 0 {
+				return nil, 0, fmt.Errorf("can not find shortest paths with negative edge weights")
+			}
+		}
+	}
+
+	dist := map[string]float64{from: 0}
+	prev := map[string]string{}
+	visited := map[string]bool{}
+	q := &queue{{node: from}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(queueItem)
+		if visited[item.node] {
+			continue
+		}
+		visited[item.node] = true
+		if item.node == to {
+			break
+		}
+		for _, next := range g.Neighbours(item.node) {
+			d := item.dist + g.edges[item.node][next]
+			if cur, ok := dist[next]; !ok || d < cur {
+				dist[next] = d
+				prev[next] = item.node
+				heap.Push(q, queueItem{node: next, dist: d})
+			}
+		}
+	}
+
+	if !visited[to] {
+		return nil, 0, nil
+	}
+	path := []string{to}
+	for n := to; n != from; {
+		n = prev[n]
+		path = append([]string{n}, path...)
+	}
+	return path, dist[to], nil
+}
+
+type queueItem struct {
+	node string
+	dist float64
+}
+
+// queue - a priority queue of nodes, by distance (then name, for stability)
+type queue []queueItem
+
+func (q queue) Len() int { return len(q) }
+func (q queue) Less(i, j int) bool {
+	if q[i].dist == q[j].dist {
+		return q[i].node < q[j].node
+	}
+	return q[i].dist < q[j].dist
+}
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
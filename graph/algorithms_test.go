@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, in interface{}) *Graph {
+	t.Helper()
+	g, err := Parse(in)
+	require.NoError(t, err)
+	return g
+}
+
+func TestTopoSort(t *testing.T) {
+	g := mustParse(t, map[string]interface{}{
+		"app":    []interface{}{"db", "cache"},
+		"cache":  []interface{}{"network"},
+		"db":     []interface{}{"network", "storage"},
+		"worker": []interface{}{"db"},
+	})
+	out, err := g.TopoSort()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", "cache", "worker", "db", "network", "storage"}, out)
+
+	out, err = New().TopoSort()
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	g = mustParse(t, map[string]interface{}{
+		"a": []interface{}{"b"},
+		"b": []interface{}{"c"},
+		"c": []interface{}{"a"},
+		"d": []interface{}{"a"},
+	})
+	_, err = g.TopoSort()
+	require.Error(t, err)
+	assert.EqualError(t, err, "graph has a cycle: a -> b -> c -> a")
+	var cerr *CycleError
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, []string{"a", "b", "c", "a"}, cerr.Cycle)
+}
+
+func TestFindCycle(t *testing.T) {
+	assert.Nil(t, mustParse(t, [][]string{{"a", "b"}, {"b", "c"}, {"a", "c"}}).FindCycle())
+	assert.Equal(t, []string{"b", "c", "b"},
+		mustParse(t, [][]string{{"a", "b"}, {"b", "c"}, {"c", "b"}}).FindCycle())
+	assert.Equal(t, []string{"a", "a"}, mustParse(t, [][]string{{"a", "a"}}).FindCycle())
+}
+
+func TestShortestPath(t *testing.T) {
+	g := mustParse(t, []interface{}{
+		[]interface{}{"a", "b", 1},
+		[]interface{}{"b", "d", 5},
+		[]interface{}{"a", "c", 2},
+		[]interface{}{"c", "d", 1},
+		[]interface{}{"d", "e"},
+		[]interface{}{"f", "a"},
+	})
+
+	path, dist, err := g.ShortestPath("a", "e")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "d", "e"}, path)
+	assert.Equal(t, 4.0, dist)
+
+	path, dist, err = g.ShortestPath("a", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, path)
+	assert.Equal(t, 0.0, dist)
+
+	path, _, err = g.ShortestPath("e", "a")
+	require.NoError(t, err)
+	assert.Nil(t, path)
+
+	_, _, err = g.ShortestPath("a", "z")
+	assert.Error(t, err)
+
+	g.AddEdge("a", "e", -1)
+	_, _, err = g.ShortestPath("a", "e")
+	assert.Error(t, err)
+}
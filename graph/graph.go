@@ -0,0 +1,162 @@
+// Package graph contains functions for working with directed graphs, like
+// dependency graphs read from datasources.
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+)
+
+// Graph - a directed graph with weighted edges
+type Graph struct {
+	// edges, by the node they're from, then the node they're to
+	edges map[string]map[string]float64
+}
+
+// New creates an empty graph
+func New() *Graph {
+	return &Graph{edges: map[string]map[string]float64{}}
+}
+
+// AddNode adds a node, if it isn't already in the graph
+func (g *Graph) AddNode(n string) {
+	if _, ok := g.edges[n]; !ok {
+		g.edges[n] = map[string]float64{}
+	}
+}
+
+// AddEdge adds an edge between the nodes (adding them if necessary),
+// replacing any existing edge between them
+func (g *Graph) AddEdge(from, to string, weight float64) {
+	g.AddNode(from)
+	g.AddNode(to)
+	g.edges[from][to] = weight
+}
+
+// Nodes returns all of the graph's nodes, sorted
+func (g *Graph) Nodes() []string {
+	out := make([]string, 0, len(g.edges))
+	for n := range g.edges {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Neighbours returns the nodes the node has edges to, sorted
+func (g *Graph) Neighbours(n string) []string {
+	out := make([]string, 0, len(g.edges[n]))
+	for to := range g.edges[n] {
+		out = append(out, to)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// HasNode returns true if the node is in the graph
+func (g *Graph) HasNode(n string) bool {
+	_, ok := g.edges[n]
+	return ok
+}
+
+// Parse builds a graph from either an adjacency map (from each node to a list
+// of the nodes it has edges to, or to a map of those nodes to edge weights),
+// or a list of edges - each either a [from, to] or [from, to, weight] array,
+// or a map with "from", "to", and (optionally) "weight" keys. Edges without a
+// weight have a weight of 1.
+func Parse(in interface{}) (*Graph, error) {
+	g := New()
+	if m, ok := in.(map[string]interface{}); ok {
+		for from, v := range m {
+			g.AddNode(from)
+			if err := g.addAdjacent(from, v); err != nil {
+				return nil, err
+			}
+		}
+		return g, nil
+	}
+
+	edges, err := iconv.InterfaceSlice(in)
+	if err != nil {
+		return nil, fmt.Errorf("graph must be an adjacency map or a list of edges, got %T", in)
+	}
+	for i, e := range edges {
+		from, to, weight, err := parseEdge(e)
+		if err != nil {
+			return nil, fmt.Errorf("edge %d: %w", i, err)
+		}
+		g.AddEdge(from, to, weight)
+	}
+	return g, nil
+}
+
+func (g *Graph) addAdjacent(from string, v interface{}) error {
+	switch a := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for to, w := range a {
+			weight, err := parseWeight(w)
+			if err != nil {
+				return fmt.Errorf("edge %s -> %s: %w", from, to, err)
+			}
+			g.AddEdge(from, to, weight)
+		}
+		return nil
+	case string:
+		g.AddEdge(from, a, 1)
+		return nil
+	}
+
+	l, err := iconv.InterfaceSlice(v)
+	if err != nil {
+		return fmt.Errorf("neighbours of %s must be a list or a map, got %T", from, v)
+	}
+	for _, to := range l {
+		g.AddEdge(from, conv.ToString(to), 1)
+	}
+	return nil
+}
+
+func parseEdge(e interface{}) (from, to string, weight float64, err error) {
+	weight = 1
+	if m, ok := e.(map[string]interface{}); ok {
+		f, fok := m["from"]
+		t, tok := m["to"]
+		if !fok || !tok {
+			return "", "", 0, fmt.Errorf("edges must have from and to keys")
+		}
+		if w, ok := m["weight"]; ok {
+			weight, err = parseWeight(w)
+		}
+		return conv.ToString(f), conv.ToString(t), weight, err
+	}
+
+	l, err := iconv.InterfaceSlice(e)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("edges must be arrays or maps, got %T", e)
+	}
+	switch len(l) {
+	case 3:
+		weight, err = parseWeight(l[2])
+		fallthrough
+	case 2:
+		return conv.ToString(l[0]), conv.ToString(l[1]), weight, err
+	default:
+		return "", "", 0, fmt.Errorf("edges must have 2 or 3 elements, got %d", len(l))
+	}
+}
+
+func parseWeight(w interface{}) (float64, error) {
+	s := strings.TrimSpace(conv.ToString(w))
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid weight %q", s)
+	}
+	return f, nil
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	expected := New()
+	expected.AddEdge("a", "b", 1)
+	expected.AddEdge("a", "c", 2)
+	expected.AddNode("d")
+
+	testdata := []interface{}{
+		map[string]interface{}{
+			"a": map[string]interface{}{"b": 1, "c": "2"},
+			"d": nil,
+		},
+		[]interface{}{
+			[]interface{}{"a", "b"},
+			[]interface{}{"a", "c", 2},
+			map[string]interface{}{"from": "d", "to": "d"},
+		},
+	}
+	g, err := Parse(testdata[0])
+	require.NoError(t, err)
+	assert.Equal(t, expected, g)
+
+	g, err = Parse(testdata[1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, g.Nodes())
+	assert.Equal(t, []string{"b", "c"}, g.Neighbours("a"))
+	assert.Equal(t, []string{"d"}, g.Neighbours("d"))
+
+	g, err = Parse(map[string]interface{}{"a": []string{"b", "c"}, "b": "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, g.Neighbours("a"))
+	assert.Equal(t, []string{"c"}, g.Neighbours("b"))
+
+	for _, d := range []interface{}{
+		"a",
+		map[string]interface{}{"a": 1},
+		map[string]interface{}{"a": map[string]interface{}{"b": "heavy"}},
+		[]interface{}{[]interface{}{"a"}},
+		[]interface{}{map[string]interface{}{"from": "a"}},
+		[]interface{}{"a"},
+	} {
+		_, err := Parse(d)
+		assert.Error(t, err, d)
+	}
+}
@@ -0,0 +1,225 @@
+package gomplate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/migrate"
+	"github.com/spf13/afero"
+)
+
+// ImportOptions - options for Import
+//
+// Experimental: subject to breaking changes before the next major release
+type ImportOptions struct {
+	// From - the kind of templates to import: "helm" or "consul-template"
+	From string
+	// Source - the Helm chart directory, or the consul-template template file
+	// or directory
+	Source string
+	// Dest - the directory to write the converted templates to
+	Dest string
+	// Force - overwrite existing files
+	Force bool
+}
+
+// ImportReport - the results of an import
+//
+// Experimental: subject to breaking changes before the next major release
+type ImportReport struct {
+	// Files - the files written, relative to Dest
+	Files []string
+	// Findings - constructs that couldn't be converted, or that may behave
+	// differently in gomplate
+	Findings []ImportFinding
+	// Datasources - aliases of the datasources that the converted templates
+	// read, which must be defined when rendering
+	Datasources []string
+}
+
+// ImportFinding - a construct that couldn't be converted, or that may behave
+// differently in gomplate
+//
+// Experimental: subject to breaking changes before the next major release
+type ImportFinding struct {
+	File      string
+	Line, Col int
+	Message   string
+}
+
+func (f ImportFinding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", f.File, f.Line, f.Col, f.Message)
+}
+
+// importFile - a file to be written
+type importFile struct {
+	rel     string
+	mode    os.FileMode
+	content []byte
+}
+
+// Import converts a Helm chart's templates, or consul-template templates, to
+// gomplate templates. Functions with gomplate equivalents are replaced, and
+// everything else is copied unchanged and reported, so that the converted
+// templates can be finished by hand.
+//
+// Experimental: subject to breaking changes before the next major release
+func Import(ctx context.Context, opts ImportOptions) (*ImportReport, error) {
+	d, ok := migrate.Dialects[opts.From]
+	if !ok {
+		return nil, fmt.Errorf("can't import from %q - must be \"helm\" or \"consul-template\"", opts.From)
+	}
+
+	funcMap := NewRenderer(Options{}).funcMap(ctx)
+	known := func(name string) bool {
+		_, ok := funcMap[name]
+		return ok || name == "tmpl" || name == "tpl"
+	}
+
+	report := &ImportReport{}
+	var files []importFile
+	var err error
+	if d == migrate.Helm {
+		files, err = planHelmImport(opts.Source, d, known, report)
+	} else {
+		files, err = planImport(opts.Source, "", d, known, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Force {
+		for _, f := range files {
+			out := filepath.Join(opts.Dest, f.rel)
+			if _, err := aferoFS.Stat(out); err == nil {
+				return nil, fmt.Errorf("%s already exists (use --force to overwrite)", out)
+			}
+		}
+	}
+
+	for _, f := range files {
+		out := filepath.Join(opts.Dest, f.rel)
+		if err := aferoFS.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+			return nil, err
+		}
+		if err := afero.WriteFile(aferoFS, out, f.content, f.mode); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", out, err)
+		}
+		report.Files = append(report.Files, f.rel)
+	}
+	sort.Strings(report.Datasources)
+	return report, nil
+}
+
+// planHelmImport converts the chart's templates into a templates directory,
+// and copies its default values
+func planHelmImport(src string, d *migrate.Dialect, known func(string) bool, report *ImportReport) ([]importFile, error) {
+	if _, err := aferoFS.Stat(filepath.Join(src, "Chart.yaml")); err != nil {
+		return nil, fmt.Errorf("%s is not a Helm chart (no Chart.yaml): %w", src, err)
+	}
+
+	files, err := planImport(filepath.Join(src, "templates"), "templates", d, known, report)
+	if err != nil {
+		return nil, err
+	}
+
+	values := filepath.Join(src, "values.yaml")
+	if fi, err := aferoFS.Stat(values); err == nil {
+		b, err := afero.ReadFile(aferoFS, values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", values, err)
+		}
+		files = append(files, importFile{rel: "values.yaml", mode: fi.Mode().Perm(), content: b})
+	}
+
+	if fi, err := aferoFS.Stat(filepath.Join(src, "charts")); err == nil && fi.IsDir() {
+		report.Findings = append(report.Findings, ImportFinding{
+			File:    "charts",
+			Message: "subcharts can't be converted - import each one separately",
+		})
+	}
+	return files, nil
+}
+
+// planImport converts every file in src (a file or a directory), without
+// writing anything, so that nothing is written if a file can't be read
+func planImport(src, destRel string, d *migrate.Dialect, known func(string) bool, report *ImportReport) ([]importFile, error) {
+	fi, err := aferoFS.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't stat %s: %w", src, err)
+	}
+
+	paths := []string{}
+	if fi.IsDir() {
+		err = afero.Walk(aferoFS, src, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() {
+				rel, err := filepath.Rel(src, path)
+				if err != nil {
+					return err
+				}
+				paths = append(paths, rel)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		paths = append(paths, filepath.Base(src))
+		src = filepath.Dir(src)
+	}
+	sort.Strings(paths)
+
+	datasources := map[string]bool{}
+	for _, ds := range report.Datasources {
+		datasources[ds] = true
+	}
+
+	files := []importFile{}
+	for _, rel := range paths {
+		in := filepath.Join(src, rel)
+		fi, err := aferoFS.Stat(in)
+		if err != nil {
+			return nil, err
+		}
+		b, err := afero.ReadFile(aferoFS, in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", in, err)
+		}
+		rel = filepath.Join(destRel, rel)
+
+		if !isBinary(b) {
+			r, err := migrate.Convert(rel, string(b), d, known)
+			if err != nil {
+				// copy it unchanged, so it can be fixed by hand
+				report.Findings = append(report.Findings, ImportFinding{
+					File: rel, Message: fmt.Sprintf("couldn't parse template: %v", err),
+				})
+			} else {
+				b = []byte(r.Text)
+				for _, f := range r.Findings {
+					report.Findings = append(report.Findings, ImportFinding{
+						File: rel, Line: f.Line, Col: f.Col, Message: f.Message,
+					})
+				}
+				for _, ds := range r.Datasources {
+					datasources[ds] = true
+				}
+			}
+		}
+
+		files = append(files, importFile{rel: rel, mode: fi.Mode().Perm(), content: b})
+	}
+
+	report.Datasources = make([]string, 0, len(datasources))
+	for ds := range datasources {
+		report.Datasources = append(report.Datasources, ds)
+	}
+	return files, nil
+}
@@ -0,0 +1,69 @@
+package gomplate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = afero.WriteFile(aferoFS, "/chart/Chart.yaml", []byte("name: app\n"), 0o644)
+	_ = afero.WriteFile(aferoFS, "/chart/values.yaml", []byte("name: app\n"), 0o644)
+	_ = afero.WriteFile(aferoFS, "/chart/templates/_helpers.tpl", []byte(`{{ define "app.name" }}{{ .Values.name | upper }}{{ end }}`), 0o644)
+	_ = afero.WriteFile(aferoFS, "/chart/templates/cm.yaml", []byte(`name: {{ include "app.name" . }}
+ns: {{ .Release.Namespace }}
+`), 0o644)
+	_ = afero.WriteFile(aferoFS, "/chart/templates/broken.yaml", []byte(`{{ if }}`), 0o644)
+	_ = aferoFS.MkdirAll("/chart/charts/sub", 0o755)
+
+	_, err := Import(ctx, ImportOptions{From: "jinja", Source: "/chart", Dest: "/out"})
+	assert.ErrorContains(t, err, `can't import from "jinja"`)
+
+	_, err = Import(ctx, ImportOptions{From: "helm", Source: "/chart/templates", Dest: "/out"})
+	assert.ErrorContains(t, err, "is not a Helm chart")
+
+	report, err := Import(ctx, ImportOptions{From: "helm", Source: "/chart", Dest: "/out"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"templates/_helpers.tpl", "templates/broken.yaml", "templates/cm.yaml", "values.yaml"}, report.Files)
+	assert.Empty(t, report.Datasources)
+	require.Len(t, report.Findings, 3)
+	assert.Equal(t, "templates/broken.yaml", report.Findings[0].File)
+	assert.Contains(t, report.Findings[0].Message, "couldn't parse template")
+	assert.Equal(t, "templates/cm.yaml:2:8: Helm field .Release can't be converted: define the release's name and namespace in values.yaml, or in a datasource",
+		report.Findings[1].String())
+	assert.Equal(t, "charts", report.Findings[2].File)
+
+	b, err := afero.ReadFile(aferoFS, "/out/templates/_helpers.tpl")
+	require.NoError(t, err)
+	assert.Equal(t, `{{ define "app.name" }}{{ .Values.name | strings.ToUpper }}{{ end }}`, string(b))
+	b, err = afero.ReadFile(aferoFS, "/out/templates/cm.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "name: {{ tmpl.Exec \"app.name\" . }}\nns: {{ .Release.Namespace }}\n", string(b))
+	b, err = afero.ReadFile(aferoFS, "/out/templates/broken.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "{{ if }}", string(b))
+	b, err = afero.ReadFile(aferoFS, "/out/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "name: app\n", string(b))
+
+	_, err = Import(ctx, ImportOptions{From: "helm", Source: "/chart", Dest: "/out"})
+	assert.ErrorContains(t, err, "already exists")
+
+	_ = afero.WriteFile(aferoFS, "/ct/app.ctmpl", []byte(`{{ key "app/host" }} {{ env "HOME" }}`), 0o644)
+	report, err = Import(ctx, ImportOptions{From: "consul-template", Source: "/ct/app.ctmpl", Dest: "/ctout"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.ctmpl"}, report.Files)
+	assert.Equal(t, []string{"consul"}, report.Datasources)
+	assert.Empty(t, report.Findings)
+	b, err = afero.ReadFile(aferoFS, "/ctout/app.ctmpl")
+	require.NoError(t, err)
+	assert.Equal(t, `{{ ds "consul" "app/host" }} {{ env.Getenv "HOME" }}`, string(b))
+}
@@ -0,0 +1,175 @@
+// Package akeyless is a minimal client for the Akeyless Vault Platform's API,
+// for the akeyless: datasource.
+package akeyless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBase - the Akeyless SaaS API's URL
+const DefaultBase = "https://api.akeyless.io"
+
+// Client - an Akeyless API client
+type Client struct {
+	// Base - the API's URL - either DefaultBase, or a gateway's API URL
+	Base string
+
+	hc *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// New creates a client for the API at base (DefaultBase when empty). The
+// token is used to authenticate, or when it's empty, AKEYLESS_TOKEN. When
+// there's no token, one is requested with the access ID and key in
+// AKEYLESS_ACCESS_ID and AKEYLESS_ACCESS_KEY.
+func New(hc *http.Client, base, token string) *Client {
+	if base == "" {
+		base = DefaultBase
+	}
+	if token == "" {
+		token = os.Getenv("AKEYLESS_TOKEN")
+	}
+	return &Client{Base: strings.TrimSuffix(base, "/"), hc: hc, token: token}
+}
+
+// call POSTs the request body to the API command, and decodes the response
+func (c *Client) call(ctx context.Context, command string, in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Base+"/"+command, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err = io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		e := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(b, &e) == nil && e.Error != "" {
+			return fmt.Errorf("akeyless API error (HTTP %d): %s", res.StatusCode, e.Error)
+		}
+		return fmt.Errorf("unexpected HTTP status %d from Akeyless API: %s", res.StatusCode, bytes.TrimSpace(b))
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (c *Client) authenticate(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	id, key := os.Getenv("AKEYLESS_ACCESS_ID"), os.Getenv("AKEYLESS_ACCESS_KEY")
+	if id == "" || key == "" {
+		return "", errors.New("an Akeyless token must be set in AKEYLESS_TOKEN (or with an Authorization header), or an access ID and key in AKEYLESS_ACCESS_ID and AKEYLESS_ACCESS_KEY")
+	}
+
+	resp := struct {
+		Token string `json:"token"`
+	}{}
+	err := c.call(ctx, "auth", map[string]string{
+		"access-id":   id,
+		"access-key":  key,
+		"access-type": "access_key",
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("akeyless authentication failed: %w", err)
+	}
+	c.token = resp.Token
+	return c.token, nil
+}
+
+// Secret - the value of the static secret
+func (c *Client) Secret(ctx context.Context, name string) ([]byte, error) {
+	token, err := c.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// values are returned keyed by name
+	resp := map[string]interface{}{}
+	err = c.call(ctx, "get-secret-value", map[string]interface{}{
+		"names": []string{name},
+		"token": token,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+
+	switch v := resp[name].(type) {
+	case string:
+		return []byte(v), nil
+	case nil:
+		return nil, fmt.Errorf("secret %s not found in response", name)
+	default:
+		// structured values are returned as JSON
+		return json.Marshal(v)
+	}
+}
+
+// List - the names of the items and folders in the folder, relative to it.
+// Folders have a trailing '/'.
+func (c *Client) List(ctx context.Context, folder string) ([]string, error) {
+	token, err := c.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	folder = "/" + strings.Trim(folder, "/")
+	resp := struct {
+		Items []struct {
+			Name string `json:"item_name"`
+		} `json:"items"`
+		Folders []string `json:"folders"`
+	}{}
+	err = c.call(ctx, "list-items", map[string]interface{}{
+		"path":  folder,
+		"token": token,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", folder, err)
+	}
+
+	prefix := strings.TrimSuffix(folder, "/") + "/"
+	names := []string{}
+	for _, item := range resp.Items {
+		if path.Dir(item.Name) == path.Clean(folder) {
+			names = append(names, strings.TrimPrefix(item.Name, prefix))
+		}
+	}
+	for _, f := range resp.Folders {
+		f = strings.TrimSuffix(f, "/")
+		if path.Dir(f) == path.Clean(folder) {
+			names = append(names, strings.TrimPrefix(f, prefix)+"/")
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
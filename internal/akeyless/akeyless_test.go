@@ -0,0 +1,110 @@
+package akeyless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFake(t *testing.T) (*Client, *int) {
+	auths := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		in := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+
+		if r.URL.Path == "/auth" {
+			if in["access-id"] != "p-123" || in["access-key"] != "key" || in["access-type"] != "access_key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error": "access denied"}`))
+				return
+			}
+			auths++
+			_, _ = w.Write([]byte(`{"token": "t-abc"}`))
+			return
+		}
+		if in["token"] != "t-abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid token"}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/get-secret-value":
+			out := map[string]interface{}{}
+			for _, n := range in["names"].([]interface{}) {
+				switch n {
+				case "/prod/db/password":
+					out["/prod/db/password"] = "s3cr3t"
+				case "/prod/db/config":
+					out["/prod/db/config"] = map[string]interface{}{"port": 5432}
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"error": "item not found"}`))
+					return
+				}
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case "/list-items":
+			_, _ = w.Write([]byte(`{
+				"items": [{"item_name": "/prod/db/password"}, {"item_name": "/prod/db/config"}, {"item_name": "/prod/db/tls/cert"}],
+				"folders": ["/prod/db/tls"]
+			}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return New(srv.Client(), srv.URL+"/", ""), &auths
+}
+
+func TestAkeyless(t *testing.T) {
+	t.Setenv("AKEYLESS_TOKEN", "")
+	t.Setenv("AKEYLESS_ACCESS_ID", "p-123")
+	t.Setenv("AKEYLESS_ACCESS_KEY", "key")
+	c, auths := setupFake(t)
+	ctx := context.Background()
+
+	b, err := c.Secret(ctx, "/prod/db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(b))
+
+	b, err = c.Secret(ctx, "/prod/db/config")
+	require.NoError(t, err)
+	assert.Equal(t, `{"port":5432}`, string(b))
+
+	_, err = c.Secret(ctx, "/prod/db/missing")
+	assert.EqualError(t, err, "failed to read secret /prod/db/missing: akeyless API error (HTTP 404): item not found")
+
+	names, err := c.List(ctx, "/prod/db/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config", "password", "tls/"}, names)
+
+	// the token is reused
+	assert.Equal(t, 1, *auths)
+}
+
+func TestAkeylessAuth(t *testing.T) {
+	t.Setenv("AKEYLESS_TOKEN", "")
+	t.Setenv("AKEYLESS_ACCESS_ID", "")
+	t.Setenv("AKEYLESS_ACCESS_KEY", "")
+	c, _ := setupFake(t)
+	_, err := c.Secret(context.Background(), "/prod/db/password")
+	assert.Error(t, err)
+
+	t.Setenv("AKEYLESS_ACCESS_ID", "p-123")
+	t.Setenv("AKEYLESS_ACCESS_KEY", "wrong")
+	_, err = c.Secret(context.Background(), "/prod/db/password")
+	assert.EqualError(t, err, "akeyless authentication failed: akeyless API error (HTTP 401): access denied")
+
+	t.Setenv("AKEYLESS_TOKEN", "t-abc")
+	c, auths := setupFake(t)
+	_, err = c.Secret(context.Background(), "/prod/db/password")
+	require.NoError(t, err)
+	assert.Equal(t, 0, *auths)
+
+	assert.Equal(t, DefaultBase, New(http.DefaultClient, "", "").Base)
+}
@@ -0,0 +1,136 @@
+package artifactrepo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Artifactory - a JFrog Artifactory API client
+type Artifactory struct {
+	// Base - Artifactory's URL, like https://example.jfrog.io/artifactory
+	Base string
+
+	auth http.Header
+	hc   *http.Client
+}
+
+var _ Repository = (*Artifactory)(nil)
+
+// NewArtifactory creates a client for the Artifactory at base. It
+// authenticates with the access token in ARTIFACTORY_ACCESS_TOKEN, the API key
+// in ARTIFACTORY_API_KEY, or the username and password in user (or
+// ARTIFACTORY_USER and ARTIFACTORY_PASSWORD), in that order of preference.
+func NewArtifactory(hc *http.Client, base string, user *url.Userinfo) *Artifactory {
+	auth := http.Header{}
+	if token := os.Getenv("ARTIFACTORY_ACCESS_TOKEN"); token != "" {
+		auth.Set("Authorization", "Bearer "+token)
+	} else if key := os.Getenv("ARTIFACTORY_API_KEY"); key != "" {
+		auth.Set("X-JFrog-Art-Api", key)
+	} else {
+		setBasicAuth(auth, user, "ARTIFACTORY_USER", "ARTIFACTORY_PASSWORD")
+	}
+	return &Artifactory{Base: strings.TrimSuffix(base, "/"), auth: auth, hc: hc}
+}
+
+// setBasicAuth sets the Authorization header from the userinfo, or from the
+// environment variables when there's no userinfo
+func setBasicAuth(h http.Header, user *url.Userinfo, userEnv, passEnv string) {
+	name, pass := os.Getenv(userEnv), os.Getenv(passEnv)
+	if user != nil {
+		name = user.Username()
+		pass, _ = user.Password()
+	}
+	if name == "" {
+		return
+	}
+	h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(name+":"+pass)))
+}
+
+// Artifact - the artifact's content, validated against the checksums in the
+// X-Checksum-* response headers
+func (a *Artifactory) Artifact(ctx context.Context, repo, p string) ([]byte, error) {
+	b, h, err := get(ctx, a.hc, a.Base+"/"+url.PathEscape(repo)+"/"+escapePath(p), a.auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s/%s: %w", repo, p, err)
+	}
+
+	sums := Checksums{
+		"sha256": h.Get("X-Checksum-Sha256"),
+		"sha1":   h.Get("X-Checksum-Sha1"),
+		"md5":    h.Get("X-Checksum-Md5"),
+	}
+	if err := sums.Verify(b); err != nil {
+		return nil, fmt.Errorf("checksum validation of %s/%s failed: %w", repo, p, err)
+	}
+	return b, nil
+}
+
+// storage - reads from the storage API, which has the metadata of artifacts
+// and folders
+func (a *Artifactory) storage(ctx context.Context, repo, p, query string) ([]byte, error) {
+	u := a.Base + "/api/storage/" + url.PathEscape(repo)
+	if p = escapePath(p); p != "" {
+		u += "/" + p
+	}
+	if query != "" {
+		u += "?" + query
+	}
+	b, _, err := get(ctx, a.hc, u, a.auth)
+	return b, err
+}
+
+// Properties - the artifact's properties, as an object of property names to
+// arrays of values. Artifacts without properties have an empty object.
+func (a *Artifactory) Properties(ctx context.Context, repo, p string) ([]byte, error) {
+	b, err := a.storage(ctx, repo, p, "properties")
+	if err != nil {
+		// the API responds 404 when there are no properties
+		if _, serr := a.storage(ctx, repo, p, ""); serr == nil {
+			return []byte("{}"), nil
+		}
+		return nil, fmt.Errorf("failed to read properties of %s/%s: %w", repo, p, err)
+	}
+
+	resp := struct {
+		Properties json.RawMessage `json:"properties"`
+	}{}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("invalid Artifactory API response: %w", err)
+	}
+	return resp.Properties, nil
+}
+
+// List - the entries in the folder
+func (a *Artifactory) List(ctx context.Context, repo, p string) ([]string, error) {
+	b, err := a.storage(ctx, repo, p, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s/%s: %w", repo, p, err)
+	}
+
+	resp := struct {
+		Children []struct {
+			URI    string `json:"uri"`
+			Folder bool   `json:"folder"`
+		} `json:"children"`
+	}{}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("invalid Artifactory API response: %w", err)
+	}
+
+	names := make([]string, len(resp.Children))
+	for i, c := range resp.Children {
+		names[i] = strings.TrimPrefix(c.URI, "/")
+		if c.Folder {
+			names[i] += "/"
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
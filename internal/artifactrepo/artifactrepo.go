@@ -0,0 +1,120 @@
+// Package artifactrepo reads artifacts, their metadata, and directory listings
+// from Artifactory and Nexus repositories, validating downloaded artifacts
+// against the checksums the server has for them. It's used by the
+// artifactory: and nexus: datasources.
+package artifactrepo
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"  //nolint:gosec
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/integrity"
+)
+
+// ErrNotFound - the repository or artifact doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// Repository - an artifact repository manager's API
+type Repository interface {
+	// Artifact - the artifact's content, after it's been validated against
+	// the server's checksums
+	Artifact(ctx context.Context, repo, path string) ([]byte, error)
+	// Properties - the artifact's metadata, as JSON
+	Properties(ctx context.Context, repo, path string) ([]byte, error)
+	// List - the sorted names of the entries in the directory. Directories
+	// have a trailing '/'.
+	List(ctx context.Context, repo, path string) ([]string, error)
+}
+
+// Checksums - hex-encoded digests of an artifact, keyed by algorithm ("sha256",
+// "sha1", or "md5")
+type Checksums map[string]string
+
+// Verify checks the content against the strongest of the checksums. It's an
+// error when there are no checksums to verify against.
+func (c Checksums) Verify(content []byte) error {
+	if s := c["sha256"]; s != "" {
+		return integrity.Check("sha256-"+strings.ToLower(s), content)
+	}
+	for _, alg := range []struct {
+		name string
+		h    hash.Hash
+	}{
+		{"sha1", sha1.New()}, //nolint:gosec
+		{"md5", md5.New()},   //nolint:gosec
+	} {
+		expected := strings.ToLower(c[alg.name])
+		if expected == "" {
+			continue
+		}
+		alg.h.Write(content)
+		if actual := hex.EncodeToString(alg.h.Sum(nil)); actual != expected {
+			return fmt.Errorf("content has %s checksum %s, not %s", alg.name, actual, expected)
+		}
+		return nil
+	}
+	return errors.New("the server provided no checksums")
+}
+
+// escapePath escapes each segment of the path, keeping the '/'s
+func escapePath(p string) string {
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// get makes a GET request with the authentication header, returning the body
+// and the response's headers when the status is 200, and ErrNotFound on a 404
+func get(ctx context.Context, hc *http.Client, u string, auth http.Header) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range auth {
+		req.Header[k] = v
+	}
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return b, res.Header, nil
+	case http.StatusNotFound:
+		return nil, nil, ErrNotFound
+	}
+
+	// Artifactory's errors are {"errors": [{"status": 401, "message": "..."}]}
+	e := struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && len(e.Errors) > 0 {
+		return nil, nil, fmt.Errorf("API error (HTTP %d): %s", res.StatusCode, e.Errors[0].Message)
+	}
+	if b = bytes.TrimSpace(b); len(b) == 0 {
+		return nil, nil, fmt.Errorf("unexpected HTTP status %d", res.StatusCode)
+	}
+	return nil, nil, fmt.Errorf("unexpected HTTP status %d: %s", res.StatusCode, b)
+}
@@ -0,0 +1,196 @@
+package artifactrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumsVerify(t *testing.T) {
+	content := []byte("hello world")
+	assert.NoError(t, Checksums{"sha256": "B94D27B9934D3E08A52E52D7DA7DABFAC484EFE37A5380EE9088F7ACE2EFCDE9"}.Verify(content))
+	assert.NoError(t, Checksums{"sha1": "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed"}.Verify(content))
+	assert.NoError(t, Checksums{"md5": "5eb63bbbe01eeed093cb22bb8f5acdc3", "sha1": ""}.Verify(content))
+
+	// the strongest checksum wins
+	assert.Error(t, Checksums{"sha1": "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", "sha256": strings.Repeat("0", 64)}.Verify(content))
+
+	err := Checksums{"sha1": strings.Repeat("0", 40)}.Verify(content)
+	assert.EqualError(t, err, "content has sha1 checksum 2aae6c35c94fcfb415dbe95f408b9ce91ee846ed, not "+strings.Repeat("0", 40))
+
+	assert.EqualError(t, Checksums{}.Verify(content), "the server provided no checksums")
+}
+
+func sha256Hex(s string) string {
+	d := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(d[:])
+}
+
+func TestArtifactory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-JFrog-Art-Api") != "apikey" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors": [{"status": 401, "message": "Bad credentials"}]}`))
+			return
+		}
+		_, props := r.URL.Query()["properties"]
+		switch r.URL.Path {
+		case "/artifactory/libs/app/build.json":
+			w.Header().Set("X-Checksum-Sha256", sha256Hex(`{"build": 42}`))
+			_, _ = w.Write([]byte(`{"build": 42}`))
+		case "/artifactory/libs/app/tampered.json":
+			w.Header().Set("X-Checksum-Sha256", sha256Hex(`{"build": 42}`))
+			_, _ = w.Write([]byte(`{"build": 666}`))
+		case "/artifactory/api/storage/libs/app/build.json":
+			if props {
+				_, _ = w.Write([]byte(`{"uri": "x", "properties": {"vcs.revision": ["abc123"]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"uri": "x"}`))
+		case "/artifactory/api/storage/libs/app/bare.json":
+			if props {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"uri": "x"}`))
+		case "/artifactory/api/storage/libs/app":
+			_, _ = w.Write([]byte(`{"children": [{"uri": "/build.json", "folder": false}, {"uri": "/sub", "folder": true}, {"uri": "/bare.json", "folder": false}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("ARTIFACTORY_ACCESS_TOKEN", "")
+	t.Setenv("ARTIFACTORY_API_KEY", "apikey")
+	a := NewArtifactory(srv.Client(), srv.URL+"/artifactory/", nil)
+	ctx := context.Background()
+
+	b, err := a.Artifact(ctx, "libs", "app/build.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"build": 42}`, string(b))
+
+	_, err = a.Artifact(ctx, "libs", "app/tampered.json")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum validation of libs/app/tampered.json failed")
+
+	_, err = a.Artifact(ctx, "libs", "app/missing.json")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	b, err = a.Properties(ctx, "libs", "app/build.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"vcs.revision": ["abc123"]}`, string(b))
+
+	b, err = a.Properties(ctx, "libs", "app/bare.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(b))
+
+	_, err = a.Properties(ctx, "libs", "app/missing.json")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	names, err := a.List(ctx, "libs", "app/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bare.json", "build.json", "sub/"}, names)
+
+	t.Setenv("ARTIFACTORY_API_KEY", "")
+	a = NewArtifactory(srv.Client(), srv.URL+"/artifactory", url.UserPassword("u", "p"))
+	assert.Equal(t, "Basic dTpw", a.auth.Get("Authorization"))
+	_, err = a.Artifact(ctx, "libs", "app/build.json")
+	assert.EqualError(t, err, "failed to download libs/app/build.json: API error (HTTP 401): Bad credentials")
+
+	t.Setenv("ARTIFACTORY_ACCESS_TOKEN", "tok")
+	a = NewArtifactory(srv.Client(), srv.URL, nil)
+	assert.Equal(t, "Bearer tok", a.auth.Get("Authorization"))
+}
+
+func TestNexus(t *testing.T) {
+	assets := map[string]string{
+		"app/build.json":      `{"build": 42}`,
+		"app/sub/other.json":  `{}`,
+		"app/tampered.json":   `{"build": 666}`,
+		"docs/readme.txt":     "hi",
+		"app/sub/deeper.json": `{}`,
+	}
+	asset := func(p string) string {
+		sum := sha256Hex(assets[p])
+		if p == "app/tampered.json" {
+			sum = sha256Hex("nope")
+		}
+		return `{"path": "` + p + `", "contentType": "application/json", "checksum": {"sha256": "` + sum + `"}}`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != "nx" || p != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("repository") != "" && q.Get("repository") != "raw" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.URL.Path == "/service/rest/v1/search/assets":
+			if _, ok := assets[q.Get("name")]; !ok {
+				_, _ = w.Write([]byte(`{"items": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"items": [` + asset(q.Get("name")) + `]}`))
+		case r.URL.Path == "/service/rest/v1/assets":
+			// two pages, to exercise the continuation token
+			if q.Get("continuationToken") == "" {
+				_, _ = w.Write([]byte(`{"items": [` + asset("app/build.json") + `,` + asset("app/sub/other.json") + `], "continuationToken": "next"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"items": [` + asset("app/sub/deeper.json") + `,` + asset("docs/readme.txt") + `]}`))
+		case strings.HasPrefix(r.URL.Path, "/repository/raw/"):
+			_, _ = w.Write([]byte(assets[strings.TrimPrefix(r.URL.Path, "/repository/raw/")]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("NEXUS_USER", "nx")
+	t.Setenv("NEXUS_PASSWORD", "pw")
+	n := NewNexus(srv.Client(), srv.URL, nil)
+	ctx := context.Background()
+
+	b, err := n.Artifact(ctx, "raw", "/app/build.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"build": 42}`, string(b))
+
+	_, err = n.Artifact(ctx, "raw", "app/tampered.json")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum validation of raw/app/tampered.json failed")
+
+	_, err = n.Artifact(ctx, "raw", "app/missing.json")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	b, err = n.Properties(ctx, "raw", "app/build.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, asset("app/build.json"), string(b))
+
+	names, err := n.List(ctx, "raw", "app/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build.json", "sub/"}, names)
+
+	names, err = n.List(ctx, "raw", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/", "docs/"}, names)
+
+	_, err = n.List(ctx, "raw", "nope/")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	n = NewNexus(srv.Client(), srv.URL, url.UserPassword("bad", "creds"))
+	_, err = n.Artifact(ctx, "raw", "app/build.json")
+	assert.EqualError(t, err, "failed to find raw/app/build.json: unexpected HTTP status 401")
+}
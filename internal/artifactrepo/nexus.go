@@ -0,0 +1,162 @@
+package artifactrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Nexus - a Sonatype Nexus Repository 3 API client
+type Nexus struct {
+	// Base - Nexus's URL, like https://nexus.example.com
+	Base string
+
+	auth http.Header
+	hc   *http.Client
+}
+
+var _ Repository = (*Nexus)(nil)
+
+// NewNexus creates a client for the Nexus at base. It authenticates with the
+// username and password (or user token) in user, or NEXUS_USER and
+// NEXUS_PASSWORD.
+func NewNexus(hc *http.Client, base string, user *url.Userinfo) *Nexus {
+	auth := http.Header{}
+	setBasicAuth(auth, user, "NEXUS_USER", "NEXUS_PASSWORD")
+	return &Nexus{Base: strings.TrimSuffix(base, "/"), auth: auth, hc: hc}
+}
+
+// nexusAsset - an asset, as described by the REST API
+type nexusAsset struct {
+	Path     string            `json:"path"`
+	Checksum map[string]string `json:"checksum"`
+}
+
+// assets - the assets in the repository, read with the REST API. When name is
+// set, only the assets with that path are searched for. All pages of results
+// are read.
+func (n *Nexus) assets(ctx context.Context, repo, name string) ([]json.RawMessage, error) {
+	items := []json.RawMessage{}
+	token := ""
+	for {
+		q := url.Values{"repository": []string{repo}}
+		p := "/service/rest/v1/assets"
+		if name != "" {
+			q.Set("name", name)
+			p = "/service/rest/v1/search/assets"
+		}
+		if token != "" {
+			q.Set("continuationToken", token)
+		}
+		b, _, err := get(ctx, n.hc, n.Base+p+"?"+q.Encode(), n.auth)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := struct {
+			Items             []json.RawMessage `json:"items"`
+			ContinuationToken string            `json:"continuationToken"`
+		}{}
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return nil, fmt.Errorf("invalid Nexus API response: %w", err)
+		}
+		items = append(items, resp.Items...)
+
+		token = resp.ContinuationToken
+		if token == "" || len(resp.Items) == 0 {
+			return items, nil
+		}
+	}
+}
+
+// asset - the metadata of the asset at the path
+func (n *Nexus) asset(ctx context.Context, repo, p string) (json.RawMessage, *nexusAsset, error) {
+	p = strings.Trim(p, "/")
+	items, err := n.assets(ctx, repo, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, item := range items {
+		a := nexusAsset{}
+		if err := json.Unmarshal(item, &a); err != nil {
+			return nil, nil, fmt.Errorf("invalid Nexus API response: %w", err)
+		}
+		if strings.TrimPrefix(a.Path, "/") == p {
+			return item, &a, nil
+		}
+	}
+	return nil, nil, ErrNotFound
+}
+
+// Artifact - the asset's content, validated against the checksums in its
+// metadata
+func (n *Nexus) Artifact(ctx context.Context, repo, p string) ([]byte, error) {
+	_, a, err := n.asset(ctx, repo, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s/%s: %w", repo, p, err)
+	}
+
+	b, _, err := get(ctx, n.hc, n.Base+"/repository/"+url.PathEscape(repo)+"/"+escapePath(p), n.auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s/%s: %w", repo, p, err)
+	}
+
+	if err := Checksums(a.Checksum).Verify(b); err != nil {
+		return nil, fmt.Errorf("checksum validation of %s/%s failed: %w", repo, p, err)
+	}
+	return b, nil
+}
+
+// Properties - the asset's metadata (its checksums, content type, last
+// modified time, etc), as returned by the API
+func (n *Nexus) Properties(ctx context.Context, repo, p string) ([]byte, error) {
+	item, _, err := n.asset(ctx, repo, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s/%s: %w", repo, p, err)
+	}
+	return item, nil
+}
+
+// List - the entries in the directory. Nexus has no directories as such, so
+// they're derived from the paths of all the repository's assets.
+func (n *Nexus) List(ctx context.Context, repo, p string) ([]string, error) {
+	items, err := n.assets(ctx, repo, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s/%s: %w", repo, p, err)
+	}
+
+	prefix := strings.Trim(p, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, item := range items {
+		a := nexusAsset{}
+		if err := json.Unmarshal(item, &a); err != nil {
+			return nil, fmt.Errorf("invalid Nexus API response: %w", err)
+		}
+		rel := strings.TrimPrefix(a.Path, "/")
+		if !strings.HasPrefix(rel, prefix) {
+			continue
+		}
+		rel = rel[len(prefix):]
+		if i := strings.Index(rel, "/"); i >= 0 {
+			rel = rel[:i+1]
+		}
+		if !seen[rel] {
+			seen[rel] = true
+			names = append(names, rel)
+		}
+	}
+
+	if len(names) == 0 && prefix != "" {
+		return nil, fmt.Errorf("failed to list %s/%s: %w", repo, p, ErrNotFound)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,100 @@
+// Package cas provides compare-and-swap (conditional) writes to remote
+// outputs, so that concurrent renderers don't clobber each other's writes.
+//
+// The version of the output is read when it's opened, before rendering, and
+// the rendered content is only written if the output still has that version
+// when it's closed. Otherwise, a *ConflictError is returned, and the caller
+// can render again and retry.
+package cas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrConflict - matches all *ConflictErrors with errors.Is
+var ErrConflict = errors.New("conflicting write")
+
+// ConflictError - the output was changed (or created, or deleted) by someone
+// else since its version was read
+type ConflictError struct {
+	// Target - the output, usually a URL
+	Target string
+	// Version - the version the write expected. Empty when the output wasn't
+	// expected to exist.
+	Version string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("%s: %s was created by another writer", ErrConflict, e.Target)
+	}
+	return fmt.Sprintf("%s: %s was modified by another writer (expected version %s)", ErrConflict, e.Target, e.Version)
+}
+
+// Is - so that errors.Is(err, ErrConflict) is true for all ConflictErrors
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// IsConflict - whether the error is (or wraps) a *ConflictError, in which
+// case the write can be retried
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// Store - an output that supports conditional writes, like an HTTP resource
+// (with ETags) or a Consul key (with its modify index)
+type Store interface {
+	// Version returns the current version of the output, or "" when it
+	// doesn't exist
+	Version(ctx context.Context) (string, error)
+	// Write writes the content only if the output still has the version
+	// ("" meaning it must not exist), and returns the new version. When it
+	// doesn't, a *ConflictError is returned.
+	Write(ctx context.Context, content []byte, version string) (string, error)
+}
+
+// Writer - buffers content, and writes it to the Store when closed, only if
+// the Store's version hasn't changed since the Writer was opened
+type Writer struct {
+	ctx     context.Context
+	store   Store
+	version string
+	buf     bytes.Buffer
+	closed  bool
+}
+
+// NewWriter reads the Store's current version, which it must still have when
+// the Writer is closed
+func NewWriter(ctx context.Context, s Store) (*Writer, error) {
+	v, err := s.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{ctx: ctx, store: s, version: v}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close writes the buffered content to the Store. It's only written once,
+// even if Close is called again.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	_, err := w.store.Write(w.ctx, w.buf.Bytes(), w.version)
+	return err
+}
+
+// Discard drops the buffered content without writing it
+func (w *Writer) Discard() error {
+	w.closed = true
+	w.buf.Reset()
+	return nil
+}
@@ -0,0 +1,153 @@
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// etagServer - an HTTP server with one resource, which supports conditional
+// PUTs
+func etagServer() *httptest.Server {
+	var (
+		mu      sync.Mutex
+		content []byte
+		version int
+	)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		etag := fmt.Sprintf(`"v%d"`, version)
+		switch r.Method {
+		case http.MethodHead:
+			if content == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", etag)
+		case http.MethodPut:
+			if m := r.Header.Get("If-Match"); m != "" && (content == nil || m != etag) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			if r.Header.Get("If-None-Match") == "*" && content != nil {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			content, _ = io.ReadAll(r.Body)
+			version++
+			w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+func TestHTTPStore(t *testing.T) {
+	srv := etagServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	s := &HTTPStore{URL: srv.URL + "/out.txt"}
+
+	v, err := s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", v)
+
+	v, err = s.Write(ctx, []byte("hello"), "")
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, v)
+
+	// it exists now, so can't be created again
+	_, err = s.Write(ctx, []byte("hello"), "")
+	assert.True(t, IsConflict(err))
+	assert.EqualError(t, err, "conflicting write: "+s.URL+" was created by another writer")
+
+	v, err = s.Write(ctx, []byte("world"), `"v1"`)
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, v)
+
+	_, err = s.Write(ctx, []byte("stale"), `"v1"`)
+	var cerr *ConflictError
+	require.True(t, errors.As(err, &cerr))
+	assert.Equal(t, `"v1"`, cerr.Version)
+	assert.EqualError(t, err, "conflicting write: "+s.URL+` was modified by another writer (expected version "v1")`)
+}
+
+func TestWriter(t *testing.T) {
+	srv := etagServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	s := &HTTPStore{URL: srv.URL + "/out.txt"}
+
+	w1, err := NewWriter(ctx, s)
+	require.NoError(t, err)
+	w2, err := NewWriter(ctx, s)
+	require.NoError(t, err)
+
+	_, err = w1.Write([]byte("first"))
+	require.NoError(t, err)
+	_, err = w2.Write([]byte("second"))
+	require.NoError(t, err)
+
+	assert.NoError(t, w1.Close())
+	// w2 was opened before w1 wrote, so its write conflicts
+	err = w2.Close()
+	assert.True(t, IsConflict(err))
+	assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", err), ErrConflict))
+
+	// retrying with a new writer succeeds
+	w2, err = NewWriter(ctx, s)
+	require.NoError(t, err)
+	_, err = w2.Write([]byte("second"))
+	require.NoError(t, err)
+	assert.NoError(t, w2.Close())
+
+	assert.False(t, IsConflict(errors.New("other")))
+
+	// discarded content isn't written
+	w3, err := NewWriter(ctx, s)
+	require.NoError(t, err)
+	_, err = w3.Write([]byte("third"))
+	require.NoError(t, err)
+	assert.NoError(t, w3.Discard())
+	assert.NoError(t, w3.Close())
+	v, err := s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, v)
+}
+
+func TestHTTPStoreUnversioned(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	s := &HTTPStore{URL: srv.URL + "/out.txt"}
+	_, err := s.Version(ctx)
+	assert.Error(t, err)
+
+	s.AllowUnversioned = true
+	v, err := s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Unversioned, v)
+
+	_, err = s.Write(ctx, []byte("hello"), v)
+	require.NoError(t, err)
+	assert.Empty(t, got.Get("If-Match"))
+	assert.Empty(t, got.Get("If-None-Match"))
+}
@@ -0,0 +1,109 @@
+package cas
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPStore - an HTTP resource, written with PUT, and versioned by its ETag.
+// Writes are made conditional with If-Match (or If-None-Match: * when it
+// doesn't exist yet), so the server must support these to detect conflicts.
+type HTTPStore struct {
+	// Client - defaults to http.DefaultClient
+	Client *http.Client
+	URL    string
+	// Header - extra headers to send with each request
+	Header http.Header
+	// AllowUnversioned - when the resource exists but has no ETag, it's
+	// written unconditionally instead of failing. Its version is Unversioned.
+	AllowUnversioned bool
+}
+
+// Unversioned - the version of an HTTP resource that exists, but has no ETag
+// (see HTTPStore.AllowUnversioned)
+const Unversioned = "*"
+
+var _ Store = (*HTTPStore)(nil)
+
+func (s *HTTPStore) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+func (s *HTTPStore) request(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = append([]string{}, v...)
+	}
+	return req, nil
+}
+
+// Version - the resource's ETag, from a HEAD request
+func (s *HTTPStore) Version(ctx context.Context) (string, error) {
+	req, err := s.request(ctx, http.MethodHead, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := s.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return "", nil
+	case res.StatusCode >= 300:
+		return "", fmt.Errorf("unexpected HTTP status %d on HEAD from %s", res.StatusCode, s.URL)
+	}
+
+	etag := res.Header.Get("ETag")
+	if etag == "" && s.AllowUnversioned {
+		return Unversioned, nil
+	}
+	if etag == "" {
+		return "", fmt.Errorf("%s has no ETag, so can't be written conditionally", s.URL)
+	}
+	return etag, nil
+}
+
+// Write - PUT the content, if the resource still has the ETag (or
+// unconditionally, if it's Unversioned). A 412 (Precondition Failed) response
+// is a conflict.
+func (s *HTTPStore) Write(ctx context.Context, content []byte, version string) (string, error) {
+	req, err := s.request(ctx, http.MethodPut, content)
+	if err != nil {
+		return "", err
+	}
+	switch version {
+	case "":
+		req.Header.Set("If-None-Match", "*")
+	case Unversioned:
+	default:
+		req.Header.Set("If-Match", version)
+	}
+
+	res, err := s.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusPreconditionFailed:
+		return "", &ConflictError{Target: s.URL, Version: version}
+	case res.StatusCode >= 300:
+		body, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("unexpected HTTP status %d on PUT to %s: %s", res.StatusCode, s.URL, body)
+	}
+
+	return res.Header.Get("ETag"), nil
+}
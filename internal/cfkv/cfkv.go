@@ -0,0 +1,143 @@
+// Package cfkv is a minimal client for Cloudflare Workers KV, for the cfkv:
+// datasource.
+package cfkv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound - the key doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// DefaultBase - the Cloudflare API's URL
+const DefaultBase = "https://api.cloudflare.com/client/v4"
+
+// Client - a Workers KV client for one namespace
+type Client struct {
+	Base      string
+	Account   string
+	Namespace string
+
+	token string
+	hc    *http.Client
+}
+
+// New creates a client for the account's namespace, authenticating with the
+// API token in CLOUDFLARE_API_TOKEN (or CF_API_TOKEN)
+func New(hc *http.Client, account, namespace string) (*Client, error) {
+	token := os.Getenv("CLOUDFLARE_API_TOKEN")
+	if token == "" {
+		token = os.Getenv("CF_API_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("a Cloudflare API token must be set in CLOUDFLARE_API_TOKEN")
+	}
+	return &Client{
+		Base:      DefaultBase,
+		Account:   account,
+		Namespace: namespace,
+		token:     token,
+		hc:        hc,
+	}, nil
+}
+
+func (c *Client) get(ctx context.Context, p string, q url.Values) ([]byte, error) {
+	u := c.Base + "/accounts/" + url.PathEscape(c.Account) + "/storage/kv/namespaces/" + url.PathEscape(c.Namespace) + p
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusOK {
+		return b, nil
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	e := struct {
+		Errors []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && len(e.Errors) > 0 {
+		return nil, fmt.Errorf("cloudflare API error %d (HTTP %d): %s", e.Errors[0].Code, res.StatusCode, e.Errors[0].Message)
+	}
+	return nil, fmt.Errorf("unexpected HTTP status %d from Cloudflare API: %s", res.StatusCode, bytes.TrimSpace(b))
+}
+
+// Get - the value of the key
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	// keys can contain '/', which must be escaped
+	b, err := c.get(ctx, "/values/"+strings.ReplaceAll(url.PathEscape(key), "/", "%2F"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// List - the sorted names of the keys starting with the prefix, relative to
+// it. All pages of results are read.
+func (c *Client) List(ctx context.Context, prefix string) ([]string, error) {
+	names := []string{}
+	cursor := ""
+	for {
+		q := url.Values{"limit": []string{"1000"}}
+		if prefix != "" {
+			q.Set("prefix", prefix)
+		}
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		b, err := c.get(ctx, "/keys", q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys: %w", err)
+		}
+
+		resp := struct {
+			Result []struct {
+				Name string `json:"name"`
+			} `json:"result"`
+			ResultInfo struct {
+				Cursor string `json:"cursor"`
+			} `json:"result_info"`
+		}{}
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return nil, fmt.Errorf("invalid Cloudflare API response: %w", err)
+		}
+		for _, k := range resp.Result {
+			names = append(names, strings.TrimPrefix(k.Name, prefix))
+		}
+
+		cursor = resp.ResultInfo.Cursor
+		if cursor == "" || len(resp.Result) == 0 {
+			break
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,105 @@
+package cfkv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFake(t *testing.T, keys map[string]string) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer cftoken" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"success": false, "errors": [{"code": 10000, "message": "Authentication error"}]}`))
+			return
+		}
+		base := "/accounts/acct/storage/kv/namespaces/ns"
+		p := r.URL.EscapedPath()
+		switch {
+		case strings.HasPrefix(p, base+"/values/"):
+			v, ok := keys[r.URL.Path[len(base+"/values/"):]]
+			if !ok || strings.Contains(p[len(base+"/values/"):], "/") {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"success": false, "errors": [{"code": 10009, "message": "get: 'key not found'"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(v))
+		case p == base+"/keys":
+			// one key per page, to exercise the cursor
+			names := []string{}
+			for k := range keys {
+				if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+					names = append(names, k)
+				}
+			}
+			sort.Strings(names)
+			i, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+			if i >= len(names) {
+				_, _ = w.Write([]byte(`{"result": [], "result_info": {"cursor": ""}}`))
+				return
+			}
+			cursor := ""
+			if i+1 < len(names) {
+				cursor = strconv.Itoa(i + 1)
+			}
+			_, _ = w.Write([]byte(`{"result": [{"name": "` + names[i] + `"}], "result_info": {"cursor": "` + cursor + `"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("CLOUDFLARE_API_TOKEN", "cftoken")
+	c, err := New(srv.Client(), "acct", "ns")
+	require.NoError(t, err)
+	c.Base = srv.URL
+	return c
+}
+
+func TestCFKV(t *testing.T) {
+	c := setupFake(t, map[string]string{
+		"site/config.json": `{"a": 1}`,
+		"site/banner":      "hello",
+		"other":            "x",
+	})
+	ctx := context.Background()
+
+	b, err := c.Get(ctx, "site/config.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, string(b))
+
+	_, err = c.Get(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	names, err := c.List(ctx, "site/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"banner", "config.json"}, names)
+
+	names, err = c.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other", "site/banner", "site/config.json"}, names)
+
+	c.token = "wrong"
+	_, err = c.Get(ctx, "other")
+	assert.EqualError(t, err, "failed to read key other: cloudflare API error 10000 (HTTP 403): Authentication error")
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("CLOUDFLARE_API_TOKEN", "")
+	t.Setenv("CF_API_TOKEN", "")
+	_, err := New(http.DefaultClient, "a", "n")
+	assert.Error(t, err)
+
+	t.Setenv("CF_API_TOKEN", "x")
+	c, err := New(http.DefaultClient, "a", "n")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBase, c.Base)
+}
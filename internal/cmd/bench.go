@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newBenchCmd - the 'bench' subcommand, which repeatedly renders the
+// configured templates and reports timings
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench [flags]",
+		Short: "Render templates repeatedly, reporting how long each phase takes",
+		Long: `Render the configured templates repeatedly (discarding the output), and report
+the median (p50) and 95th percentile (p95) time each template spends being
+parsed, reading datasources, and executing.
+
+Templates and datasources are configured with the same flags (and config file)
+as the main gomplate command. Output flags are ignored.`,
+		Args: optionalExecArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			iterations, err := cmd.Flags().GetInt("iterations")
+			if err != nil {
+				return err
+			}
+			cpuProfile, err := getString(cmd, "cpuprofile")
+			if err != nil {
+				return err
+			}
+			memProfile, err := getString(cmd, "memprofile")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			if cpuProfile != "" {
+				f, err := os.Create(cpuProfile)
+				if err != nil {
+					return fmt.Errorf("failed to create CPU profile: %w", err)
+				}
+				defer f.Close()
+				if err := pprof.StartCPUProfile(f); err != nil {
+					return fmt.Errorf("failed to start CPU profile: %w", err)
+				}
+			}
+
+			results, err := gomplate.Bench(ctx, cfg, iterations)
+			if cpuProfile != "" {
+				pprof.StopCPUProfile()
+			}
+			if err != nil {
+				return err
+			}
+
+			if memProfile != "" {
+				if err := writeMemProfile(memProfile); err != nil {
+					return err
+				}
+			}
+
+			return printBenchResults(cmd.OutOrStdout(), iterations, results)
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().IntP("iterations", "n", 10, "number of times to render each template")
+	cmd.Flags().String("cpuprofile", "", "write a CPU profile to `file`, for use with 'go tool pprof'")
+	cmd.Flags().String("memprofile", "", "write a memory (heap) profile to `file`, for use with 'go tool pprof'")
+
+	return cmd
+}
+
+func writeMemProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %w", err)
+	}
+	defer f.Close()
+
+	// get up-to-date statistics
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+	return nil
+}
+
+func printBenchResults(out io.Writer, iterations int, results []gomplate.BenchResult) error {
+	fmt.Fprintf(out, "%d iterations\n\n", iterations)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tPARSE P50\tPARSE P95\tDATASOURCE P50\tDATASOURCE P95\tEXECUTE P50\tEXECUTE P95")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Name,
+			r.Parse.P50, r.Parse.P95,
+			r.Datasource.P50, r.Datasource.P95,
+			r.Execute.P50, r.Execute.P95)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newCompileCmd - the 'compile' subcommand, which writes the configured
+// templates to a bundle
+func newCompileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compile BUNDLE [flags]",
+		Short: "Validate templates and compile them into a bundle, to render later with --bundle",
+		Long: `Read and validate the configured templates (and nested templates), and write
+them to a single BUNDLE file. The bundle can be rendered later with
+'gomplate --bundle BUNDLE', without reading or validating the templates again.
+
+Templates are configured with the same flags (and config file) as the main
+gomplate command. Output flags are ignored.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, nil)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			cmd.SilenceUsage = true
+
+			// only write the bundle if all templates are valid
+			buf := &bytes.Buffer{}
+			err = gomplate.Compile(ctx, cfg, buf)
+			if err != nil {
+				return err
+			}
+
+			err = os.WriteFile(args[0], buf.Bytes(), 0o644)
+			if err != nil {
+				return fmt.Errorf("failed to write bundle: %w", err)
+			}
+
+			zerolog.Ctx(ctx).Debug().Str("bundle", args[0]).Msg("compiled templates")
+			return nil
+		},
+	}
+
+	InitFlags(cmd)
+
+	return cmd
+}
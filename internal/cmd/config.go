@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
@@ -36,6 +37,15 @@ func loadConfig(cmd *cobra.Command, args []string) (*config.Config, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	// a packed executable's config is overridden by the config file
+	if packCfg := packConfigFromContext(ctx); packCfg != nil {
+		if cfg != nil {
+			packCfg = packCfg.MergeFrom(cfg)
+		}
+		cfg = packCfg
+	}
+
 	if cfg == nil {
 		cfg = flagConfig
 	} else {
@@ -110,6 +120,10 @@ func cobraConfig(cmd *cobra.Command, args []string) (cfg *config.Config, err err
 	if err != nil {
 		return nil, err
 	}
+	cfg.Bundle, err = getString(cmd, "bundle")
+	if err != nil {
+		return nil, err
+	}
 
 	cfg.ExcludeGlob, err = getStringSlice(cmd, "exclude")
 	if err != nil {
@@ -143,6 +157,25 @@ func cobraConfig(cmd *cobra.Command, args []string) (cfg *config.Config, err err
 		cfg.PostExec = args
 	}
 
+	cfg.Args, err = getStringArray(cmd, "args")
+	if err != nil {
+		return nil, err
+	}
+	namedArgs, err := getStringArray(cmd, "arg")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range namedArgs {
+		parts := strings.SplitN(a, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid argument %q: must be in the form name=value", a)
+		}
+		if cfg.NamedArgs == nil {
+			cfg.NamedArgs = map[string]string{}
+		}
+		cfg.NamedArgs[parts[0]] = parts[1]
+	}
+
 	cfg.ExecPipe, err = getBool(cmd, "exec-pipe")
 	if err != nil {
 		return nil, err
@@ -151,6 +184,113 @@ func cobraConfig(cmd *cobra.Command, args []string) (cfg *config.Config, err err
 	if err != nil {
 		return nil, err
 	}
+	cfg.HTMLEscape, err = getBool(cmd, "html-escape")
+	if err != nil {
+		return nil, err
+	}
+	cfg.StrictDeprecations, err = getBool(cmd, "strict-deprecations")
+	if err != nil {
+		return nil, err
+	}
+	cfg.OrderedMaps, err = getBool(cmd, "ordered-maps")
+	if err != nil {
+		return nil, err
+	}
+	cfg.PreserveComments, err = getBool(cmd, "preserve-comments")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.RenderCache, err = getString(cmd, "render-cache")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Checksums, err = getString(cmd, "checksums")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ContentAddressed, err = getBool(cmd, "content-addressed")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ProvenanceReport, err = getString(cmd, "provenance-report")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ProvenanceComment, err = getString(cmd, "provenance-comment")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Preview, err = getBool(cmd, "preview")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Watch, err = getBool(cmd, "watch")
+	if err != nil {
+		return nil, err
+	}
+	cfg.DatasourceCacheLimit, err = getString(cmd, "datasource-cache-limit")
+	if err != nil {
+		return nil, err
+	}
+	cfg.DatasourceSpillThreshold, err = getString(cmd, "datasource-spill-threshold")
+	if err != nil {
+		return nil, err
+	}
+	cfg.DatasourceConcurrency, err = getInt(cmd, "datasource-concurrency")
+	if err != nil {
+		return nil, err
+	}
+	cfg.DatasourceCacheDir, err = getString(cmd, "datasource-cache-dir")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.EnvFiles, err = getStringSlice(cmd, "env-file")
+	if err != nil {
+		return nil, err
+	}
+	cfg.EnvFileNoOverride, err = getBool(cmd, "env-file-no-override")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ValuesFiles, err = getStringSlice(cmd, "values")
+	if err != nil {
+		return nil, err
+	}
+	cfg.SetValues, err = getStringArray(cmd, "set")
+	if err != nil {
+		return nil, err
+	}
+
+	notify, err := getStringSlice(cmd, "notify")
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range notify {
+		cfg.Notify = append(cfg.Notify, config.NotifyConfig{URL: u})
+	}
+
+	cfg.Verify, err = getBool(cmd, "verify")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Matrix, err = matrixConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Lock, err = lockConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ScriptLimits, err = scriptLimits(cmd)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg.LDelim, err = getString(cmd, "left-delim")
 	if err != nil {
@@ -200,6 +340,13 @@ func getStringSlice(cmd *cobra.Command, flag string) (s []string, err error) {
 	return s, err
 }
 
+func getStringArray(cmd *cobra.Command, flag string) (s []string, err error) {
+	if cmd.Flag(flag) != nil && cmd.Flag(flag).Changed {
+		s, err = cmd.Flags().GetStringArray(flag)
+	}
+	return s, err
+}
+
 func getString(cmd *cobra.Command, flag string) (s string, err error) {
 	if cmd.Flag(flag) != nil && cmd.Flag(flag).Changed {
 		s, err = cmd.Flags().GetString(flag)
@@ -207,6 +354,89 @@ func getString(cmd *cobra.Command, flag string) (s string, err error) {
 	return s, err
 }
 
+func getInt(cmd *cobra.Command, flag string) (i int, err error) {
+	if cmd.Flag(flag) != nil && cmd.Flag(flag).Changed {
+		i, err = cmd.Flags().GetInt(flag)
+	}
+	return i, err
+}
+
+// matrixConfig - the matrix config from the --matrix flags, or nil if none
+// were given
+func matrixConfig(cmd *cobra.Command) (*config.MatrixConfig, error) {
+	m := &config.MatrixConfig{}
+	var err error
+	m.Datasource, err = getString(cmd, "matrix")
+	if err != nil {
+		return nil, err
+	}
+	m.OutputPath, err = getString(cmd, "matrix-output")
+	if err != nil {
+		return nil, err
+	}
+	m.Parallelism, err = getInt(cmd, "matrix-parallelism")
+	if err != nil {
+		return nil, err
+	}
+	if *m == (config.MatrixConfig{}) {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// lockConfig - the lock config from the --lock flags, or nil if none were
+// given
+func lockConfig(cmd *cobra.Command) (*config.LockConfig, error) {
+	l := &config.LockConfig{}
+	var err error
+	l.URL, err = getString(cmd, "lock")
+	if err != nil {
+		return nil, err
+	}
+	l.Wait, err = getDuration(cmd, "lock-wait")
+	if err != nil {
+		return nil, err
+	}
+	if *l == (config.LockConfig{}) {
+		return nil, nil
+	}
+	return l, nil
+}
+
+// scriptLimits - the script limits from the --script flags, or nil if none
+// were given
+func scriptLimits(cmd *cobra.Command) (*config.ScriptLimits, error) {
+	l := &config.ScriptLimits{}
+	var err error
+	l.Timeout, err = getDuration(cmd, "script-timeout")
+	if err != nil {
+		return nil, err
+	}
+	l.CallDepth, err = getInt(cmd, "script-call-depth")
+	if err != nil {
+		return nil, err
+	}
+	l.StackSize, err = getInt(cmd, "script-stack-size")
+	if err != nil {
+		return nil, err
+	}
+	l.Memory, err = getString(cmd, "script-memory")
+	if err != nil {
+		return nil, err
+	}
+	if *l == (config.ScriptLimits{}) {
+		return nil, nil
+	}
+	return l, nil
+}
+
+func getDuration(cmd *cobra.Command, flag string) (d time.Duration, err error) {
+	if cmd.Flag(flag) != nil && cmd.Flag(flag).Changed {
+		d, err = cmd.Flags().GetDuration(flag)
+	}
+	return d, err
+}
+
 func getBool(cmd *cobra.Command, flag string) (b bool, err error) {
 	if cmd.Flag(flag) != nil && cmd.Flag(flag).Changed {
 		b, err = cmd.Flags().GetBool(flag)
@@ -252,6 +482,10 @@ func applyEnvVars(ctx context.Context, cfg *config.Config) (*config.Config, erro
 		cfg.Experimental = true
 	}
 
+	if !cfg.HTMLEscape && conv.ToBool(env.Getenv("GOMPLATE_HTML_ESCAPE", "false")) {
+		cfg.HTMLEscape = true
+	}
+
 	if cfg.LDelim == "" {
 		cfg.LDelim = env.Getenv("GOMPLATE_LEFT_DELIM")
 	}
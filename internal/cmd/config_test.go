@@ -137,6 +137,60 @@ func TestCobraConfig(t *testing.T) {
 		InputFiles: []string{"in"},
 		PostExec:   []string{"echo", "foo"},
 	}, cfg)
+
+	cmd = &cobra.Command{}
+	cmd.Flags().StringArray("args", []string{}, "...")
+	cmd.Flags().StringArray("arg", []string{}, "...")
+	cmd.ParseFlags([]string{"--arg", "name=world", "--arg", "greeting=hi=there", "--args", "a", "--args", "b,c", "--", "echo", "foo"})
+
+	cfg, err = cobraConfig(cmd, cmd.Flags().Args())
+	assert.NoError(t, err)
+	assert.EqualValues(t, &config.Config{
+		Args:      []string{"a", "b,c"},
+		NamedArgs: map[string]string{"name": "world", "greeting": "hi=there"},
+		PostExec:  []string{"echo", "foo"},
+	}, cfg)
+
+	cmd = &cobra.Command{}
+	cmd.Flags().String("matrix", "", "...")
+	cmd.Flags().String("matrix-output", "", "...")
+	cmd.Flags().Int("matrix-parallelism", 0, "...")
+	cmd.ParseFlags([]string{"--matrix", "tenants", "--matrix-parallelism", "4"})
+
+	cfg, err = cobraConfig(cmd, cmd.Flags().Args())
+	assert.NoError(t, err)
+	assert.EqualValues(t, &config.Config{
+		Matrix: &config.MatrixConfig{Datasource: "tenants", Parallelism: 4},
+	}, cfg)
+
+	cmd = &cobra.Command{}
+	cmd.Flags().String("lock", "", "...")
+	cmd.Flags().Duration("lock-wait", 0, "...")
+	cmd.ParseFlags([]string{"--lock", "consul:///locks/app", "--lock-wait", "30s"})
+
+	cfg, err = cobraConfig(cmd, cmd.Flags().Args())
+	assert.NoError(t, err)
+	assert.EqualValues(t, &config.Config{
+		Lock: &config.LockConfig{URL: "consul:///locks/app", Wait: 30 * time.Second},
+	}, cfg)
+
+	cmd = &cobra.Command{}
+	cmd.Flags().Duration("script-timeout", 0, "...")
+	cmd.Flags().String("script-memory", "", "...")
+	cmd.ParseFlags([]string{"--script-timeout", "10s", "--script-memory", "64MiB"})
+
+	cfg, err = cobraConfig(cmd, cmd.Flags().Args())
+	assert.NoError(t, err)
+	assert.EqualValues(t, &config.Config{
+		ScriptLimits: &config.ScriptLimits{Timeout: 10 * time.Second, Memory: "64MiB"},
+	}, cfg)
+
+	cmd = &cobra.Command{}
+	cmd.Flags().StringArray("arg", []string{}, "...")
+	cmd.ParseFlags([]string{"--arg", "bogus"})
+
+	_, err = cobraConfig(cmd, cmd.Flags().Args())
+	assert.Error(t, err)
 }
 
 func TestProcessIncludes(t *testing.T) {
@@ -237,6 +291,16 @@ func TestApplyEnvVars(t *testing.T) {
 			&config.Config{Experimental: true},
 			"GOMPLATE_EXPERIMENTAL", "false",
 		},
+		{
+			&config.Config{},
+			&config.Config{HTMLEscape: true},
+			"GOMPLATE_HTML_ESCAPE", "true",
+		},
+		{
+			&config.Config{HTMLEscape: true},
+			&config.Config{HTMLEscape: true},
+			"GOMPLATE_HTML_ESCAPE", "false",
+		},
 		{
 			&config.Config{},
 			&config.Config{LDelim: "--"},
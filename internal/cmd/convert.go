@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newConvertCmd - the 'convert' subcommand, which converts between data
+// formats
+func newConvertCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert [-i INPUT] [-o OUTPUT] [--from FORMAT] [--to FORMAT]",
+		Short: "Convert a file from one data format to another (like YAML to JSON)",
+		Long: `Convert a file from one data format to another, with the same parsers used for
+datasources. The formats are taken from the files' extensions, unless --from
+and --to are given (which they must be for standard input and output).
+
+Formats that can be read: csv, env, hcl, ini, json, msgpack, ndjson, toml, xml,
+and yaml. Formats that can be written: csv, env, ini, json, msgpack, toml, xml,
+and yaml.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+
+			opts := gomplate.ConvertOptions{
+				Stdin:  cmd.InOrStdin(),
+				Stdout: cmd.OutOrStdout(),
+			}
+
+			var err error
+			opts.Input, err = getString(cmd, "in")
+			if err != nil {
+				return err
+			}
+			opts.Output, err = getString(cmd, "out")
+			if err != nil {
+				return err
+			}
+			opts.From, err = getString(cmd, "from")
+			if err != nil {
+				return err
+			}
+			opts.To, err = getString(cmd, "to")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+			return gomplate.Convert(opts)
+		},
+	}
+
+	cmd.Flags().StringP("in", "i", "-", "input `file`. Omit to read standard input")
+	cmd.Flags().StringP("out", "o", "-", "output `file`. Omit to write to standard output")
+	cmd.Flags().String("from", "", "input `format` (defaults to the input file's extension)")
+	cmd.Flags().String("to", "", "output `format` (defaults to the output file's extension)")
+	cmd.Flags().BoolP("verbose", "V", false, "output extra information about what gomplate is doing")
+
+	return cmd
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hairyhenderson/gomplate/v3"
+	"github.com/hairyhenderson/yaml"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// maxFuzzMessage - messages longer than this are truncated in the report, so
+// that huge strings don't flood the terminal
+const maxFuzzMessage = 200
+
+// newFuzzCmd - the 'fuzz' subcommand, which renders the configured templates
+// with mutated datasource data, and reports templates that misbehave
+func newFuzzCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fuzz [flags]",
+		Short: "Render templates with mutated datasources, reporting panics, hangs, and invalid output",
+		Long: `Read the configured datasources once, and render the templates many times,
+each time with one datasource's data mutated: a key removed, a value set to
+null or to a value of the wrong type, or a string made very long.
+
+Template executions that panic, take longer than --timeout, or (with --schema)
+emit output that isn't valid according to the JSON Schema are reported, and
+the command fails. Other errors (like missing keys) are only counted.
+
+Templates and datasources are configured with the same flags (and config file)
+as the main gomplate command. Output flags are ignored. With --snapshot, the
+datasources are read from a snapshot directory instead.`,
+		Args: optionalExecArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			fo := gomplate.FuzzOptions{}
+			fo.Timeout, err = cmd.Flags().GetDuration("timeout")
+			if err != nil {
+				return err
+			}
+			fo.MaxMutations, err = cmd.Flags().GetInt("max-mutations")
+			if err != nil {
+				return err
+			}
+			fo.Seed, err = cmd.Flags().GetInt64("seed")
+			if err != nil {
+				return err
+			}
+			cfg.Snapshot, err = getString(cmd, "snapshot")
+			if err != nil {
+				return err
+			}
+			schemaFile, err := getString(cmd, "schema")
+			if err != nil {
+				return err
+			}
+			if schemaFile != "" {
+				fo.Schema, err = readSchema(schemaFile)
+				if err != nil {
+					return err
+				}
+			}
+
+			cmd.SilenceUsage = true
+
+			report, err := gomplate.Fuzz(ctx, cfg, fo)
+			if err != nil {
+				return err
+			}
+
+			if err := printFuzzReport(cmd.OutOrStdout(), report); err != nil {
+				return err
+			}
+			if len(report.Failures) > 0 {
+				return fmt.Errorf("found %d failure(s)", len(report.Failures))
+			}
+			return nil
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("schema", "", "JSON Schema `file` (JSON or YAML) that each template's output must be valid according to")
+	cmd.Flags().String("snapshot", "", "read datasources from the snapshot `directory` (created with 'gomplate snapshot create')")
+	cmd.Flags().Duration("timeout", 0, "how long a template can render before it's reported as hanging (default 5s)")
+	cmd.Flags().Int("max-mutations", 0, "the most mutations to try - a random sample is tried when there are more (default 1000)")
+	cmd.Flags().Int64("seed", 0, "random seed for sampling mutations")
+
+	return cmd
+}
+
+func readSchema(path string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	schema := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+func printFuzzReport(out io.Writer, report *gomplate.FuzzReport) error {
+	fmt.Fprintf(out, "%d mutations, %d failed with errors, %d failure(s)\n", report.Mutations, report.Errors, len(report.Failures))
+	if len(report.Failures) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tTEMPLATE\tDATASOURCE\tMUTATION\tMESSAGE")
+	for _, f := range report.Failures {
+		ds, mutation := f.Datasource, f.Mutation
+		if ds == "" {
+			ds, mutation = "-", "(none)"
+		}
+		msg := f.Message
+		if len(msg) > maxFuzzMessage {
+			msg = msg[:maxFuzzMessage] + "..."
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Kind, f.Template, ds, mutation, msg)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newImportCmd - the 'import' subcommand, which converts Helm charts and
+// consul-template templates to gomplate templates
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import --from helm|consul-template SOURCE DEST_DIR",
+		Short: "Convert a Helm chart's templates, or consul-template templates, to gomplate templates",
+		Long: `Convert a Helm chart's templates, or consul-template templates, to gomplate
+templates, and write them to DEST_DIR.
+
+Functions with a gomplate equivalent are replaced in place. Everything else is
+copied unchanged, and reported with its file, line, and column, so that the
+converted templates can be finished by hand. Templates that can't be parsed
+are copied unchanged too.
+
+For a Helm chart, SOURCE is the chart's directory. Its templates are written to
+DEST_DIR/templates, and its values.yaml to DEST_DIR/values.yaml. For
+consul-template, SOURCE is a template file or a directory of templates.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			opts := gomplate.ImportOptions{
+				Source: args[0],
+				Dest:   args[1],
+			}
+
+			var err error
+			opts.From, err = getString(cmd, "from")
+			if err != nil {
+				return err
+			}
+			opts.Force, err = getBool(cmd, "force")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			report, err := gomplate.Import(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			printImportReport(cmd.OutOrStdout(), opts, report)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("from", "", "the kind of templates to import: helm or consul-template")
+	_ = cmd.MarkFlagRequired("from")
+	cmd.Flags().Bool("force", false, "overwrite existing files")
+	cmd.Flags().BoolP("verbose", "V", false, "output extra information about what gomplate is doing")
+
+	return cmd
+}
+
+func printImportReport(out io.Writer, opts gomplate.ImportOptions, report *gomplate.ImportReport) {
+	for _, f := range report.Findings {
+		fmt.Fprintln(out, f)
+	}
+	fmt.Fprintf(out, "converted %d file(s), with %d finding(s) to review\n", len(report.Files), len(report.Findings))
+
+	var args string
+	if opts.From == "helm" {
+		// helpers are loaded as nested templates, rather than rendered
+		tmpl := filepath.Join(opts.Dest, "templates")
+		args = fmt.Sprintf(" --input-dir %s --exclude '_*.tpl'", tmpl)
+		for _, f := range report.Files {
+			if filepath.Dir(f) == "templates" && filepath.Ext(f) == ".tpl" && filepath.Base(f)[0] == '_' {
+				args += fmt.Sprintf(" -t %s", filepath.Join(opts.Dest, f))
+			}
+		}
+		args += " --values " + filepath.Join(opts.Dest, "values.yaml")
+	} else {
+		args = " --input-dir " + opts.Dest
+	}
+	for _, ds := range report.Datasources {
+		args += fmt.Sprintf(" -d %s=%s://", ds, ds)
+	}
+	fmt.Fprintf(out, "\nrender with:\n  gomplate%s --output-dir OUT_DIR\n", args)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/keyring"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// newKeyringCmd - the 'keyring' subcommand, for storing datasource tokens in
+// the OS keychain
+func newKeyringCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keyring",
+		Short: "Store datasource tokens in the OS keychain, to reference as keyring://ITEM",
+		Long: `Store datasource tokens in the OS keychain (the macOS Keychain, Windows
+Credential Manager, or a Secret Service provider like GNOME Keyring), so they
+don't need to be kept in environment variables.
+
+Stored tokens can be referenced as keyring://ITEM in datasource headers (like
+'Authorization: Bearer keyring://ITEM') and in HTTP datasource URLs' query
+parameters.`,
+		Args: cobra.NoArgs,
+	}
+	cmd.AddCommand(newKeyringSetCmd())
+	cmd.AddCommand(newKeyringDeleteCmd())
+	return cmd
+}
+
+func newKeyringSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set ITEM",
+		Short: "Store a token in the keychain, read from standard input",
+		Long: `Store a token in the keychain as ITEM, replacing any already stored. The token
+is read from standard input - when it's a terminal, it's prompted for without
+being echoed. Trailing newlines are removed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			secret, err := readSecret(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			if secret == "" {
+				return fmt.Errorf("no token given for %s", args[0])
+			}
+			return keyring.Set(args[0], secret)
+		},
+	}
+}
+
+func newKeyringDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ITEM",
+		Short: "Remove a token from the keychain",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return keyring.Delete(args[0])
+		},
+	}
+}
+
+// readSecret - read the secret from stdin, prompting for it without echoing
+// when stdin is a terminal
+func readSecret(cmd *cobra.Command, item string) (string, error) {
+	in := cmd.InOrStdin()
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Token for %s: ", item)
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/hairyhenderson/gomplate/v3"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newListenCmd - the 'listen' subcommand, which renders templates when
+// events are received
+func newListenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "listen [flags] [-- command]",
+		Short: "Render templates each time a CloudEvent or webhook is received",
+		Long: `Listen for CloudEvents (in binary or structured JSON mode) and generic webhooks
+(such as those sent by GitHub, GitLab, or Argo Events), and render the
+templates once for each, with the event available as .Event.
+
+Templates, datasources, and outputs are configured with the same flags (and
+config file) as the main gomplate command, and any post-exec command is run
+after each render. Renders happen one at a time, and the response's status
+reports whether the render succeeded.`,
+		Args: optionalExecArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			o := gomplate.EventOptions{
+				AfterRender: func(ctx context.Context, cfg *config.Config) error {
+					return postRunExec(ctx, cfg.PostExec, cfg.PostExecInput, cmd.OutOrStdout(), cmd.ErrOrStderr())
+				},
+			}
+			o.Addr, err = getString(cmd, "addr")
+			if err != nil {
+				return err
+			}
+			o.Secret, err = getString(cmd, "secret")
+			if err != nil {
+				return err
+			}
+			if o.Secret == "" {
+				o.Secret = os.Getenv("GOMPLATE_LISTEN_SECRET")
+			}
+			o.Types, err = cmd.Flags().GetStringSlice("event-type")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return gomplate.ServeEvents(ctx, cfg, o)
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("addr", ":8080", "`address` to listen on")
+	cmd.Flags().String("secret", "", "require requests to be signed with this secret, in the X-Hub-Signature-256 header (as GitHub webhooks are). Can also be set with GOMPLATE_LISTEN_SECRET")
+	cmd.Flags().StringSlice("event-type", nil, "only render for events of these `types` (CloudEvent types, or GitHub/GitLab event names)")
+
+	return cmd
+}
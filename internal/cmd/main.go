@@ -6,10 +6,12 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"syscall"
 
 	"github.com/hairyhenderson/go-fsimpl/filefs"
 	"github.com/hairyhenderson/gomplate/v3"
 	"github.com/hairyhenderson/gomplate/v3/env"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
 	"github.com/hairyhenderson/gomplate/v3/version"
 
 	"github.com/rs/zerolog"
@@ -37,6 +39,7 @@ func postRunExec(ctx context.Context, args []string, stdin io.Reader, stdout, st
 		// make sure all signals are propagated
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs)
+		defer signal.Stop(sigs)
 
 		err := c.Start()
 		if err != nil {
@@ -79,46 +82,89 @@ func NewGomplateCmd() *cobra.Command {
 			if v, _ := cmd.Flags().GetBool("verbose"); v {
 				zerolog.SetGlobalLevel(zerolog.DebugLevel)
 			}
-			ctx := cmd.Context()
-			log := zerolog.Ctx(ctx)
 
 			cfg, err := loadConfig(cmd, args)
 			if err != nil {
 				return err
 			}
 
-			if cfg.Experimental {
-				log.UpdateContext(func(c zerolog.Context) zerolog.Context {
-					return c.Bool("experimental", true)
-				})
-				log.Info().Msg("experimental functions and features enabled!")
+			return render(cmd, cfg)
+		},
+		Args: optionalExecArgs,
+	}
+	// avoid adding a 'completion' subcommand, which could be mistaken for a
+	// template
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(newNewCmd())
+	rootCmd.AddCommand(newBenchCmd())
+	rootCmd.AddCommand(newFuzzCmd())
+	rootCmd.AddCommand(newTestCmd())
+	rootCmd.AddCommand(newImportCmd())
+	rootCmd.AddCommand(newConvertCmd())
+	rootCmd.AddCommand(newQueryCmd())
+	rootCmd.AddCommand(newCompileCmd())
+	rootCmd.AddCommand(newPackCmd())
+	rootCmd.AddCommand(newSnapshotCmd())
+	rootCmd.AddCommand(newOperatorCmd())
+	rootCmd.AddCommand(newWebhookCmd())
+	rootCmd.AddCommand(newListenCmd())
+	rootCmd.AddCommand(newServeCmd())
+	rootCmd.AddCommand(newKeyringCmd())
+	return rootCmd
+}
 
-				ctx = gomplate.SetExperimental(ctx)
-			}
+// render - render the templates in the config, and run the post-exec command
+func render(cmd *cobra.Command, cfg *config.Config) error {
+	ctx := cmd.Context()
+	log := zerolog.Ctx(ctx)
 
-			log.Debug().Msgf("starting %s", cmd.Name())
-			log.Debug().
-				Str("version", version.Version).
-				Str("build", version.GitCommit).
-				Msgf("config is:\n%v", cfg)
+	if cfg.Experimental {
+		log.UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Bool("experimental", true)
+		})
+		log.Info().Msg("experimental functions and features enabled!")
 
-			err = gomplate.Run(ctx, cfg)
-			cmd.SilenceErrors = true
-			cmd.SilenceUsage = true
+		ctx = gomplate.SetExperimental(ctx)
+	}
 
-			log.Debug().Int("templatesRendered", gomplate.Metrics.TemplatesProcessed).
-				Int("errors", gomplate.Metrics.Errors).
-				Dur("duration", gomplate.Metrics.TotalRenderDuration).
-				Msg("completed rendering")
+	log.Debug().Msgf("starting %s", cmd.Name())
+	log.Debug().
+		Str("version", version.Version).
+		Str("build", version.GitCommit).
+		Msgf("config is:\n%v", cfg)
 
-			if err != nil {
-				return err
-			}
-			return postRunExec(ctx, cfg.PostExec, cfg.PostExecInput, cmd.OutOrStdout(), cmd.ErrOrStderr())
-		},
-		Args: optionalExecArgs,
+	if cfg.Watch {
+		cmd.SilenceUsage = true
+
+		// stop watching cleanly when interrupted
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return gomplate.Watch(ctx, cfg, gomplate.WatchOptions{
+			AfterRender: func(ctx context.Context, cfg *config.Config) error {
+				if cfg.Preview {
+					return nil
+				}
+				return postRunExec(ctx, cfg.PostExec, cfg.PostExecInput, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			},
+		})
 	}
-	return rootCmd
+
+	err := gomplate.Run(ctx, cfg)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	log.Debug().Int("templatesRendered", gomplate.Metrics.TemplatesProcessed).
+		Int("templatesCached", gomplate.Metrics.TemplatesCached).
+		Int("errors", gomplate.Metrics.Errors).
+		Dur("duration", gomplate.Metrics.TotalRenderDuration).
+		Msg("completed rendering")
+
+	// previews aren't real outputs, so there's nothing to run the command on
+	if err != nil || cfg.Preview {
+		return err
+	}
+	return postRunExec(ctx, cfg.PostExec, cfg.PostExecInput, cmd.OutOrStdout(), cmd.ErrOrStderr())
 }
 
 // InitFlags - initialize the various flags and help strings on the command.
@@ -138,14 +184,18 @@ func InitFlags(command *cobra.Command) {
 	command.Flags().StringSliceP("file", "f", []string{"-"}, "Template `file` to process. Omit to use standard input, or use --in or --input-dir")
 	command.Flags().StringP("in", "i", "", "Template `string` to process (alternative to --file and --input-dir)")
 	command.Flags().String("input-dir", "", "`directory` which is examined recursively for templates (alternative to --file and --in)")
+	command.Flags().String("bundle", "", "render the templates in a `bundle` compiled with 'gomplate compile' (alternative to --file, --in, and --input-dir)")
 
 	command.Flags().StringSlice("exclude", []string{}, "glob of files to not parse")
 	command.Flags().StringSlice("include", []string{}, "glob of files to parse")
 
 	command.Flags().StringSliceP("out", "o", []string{"-"}, "output `file` name. Omit to use standard output.")
 	command.Flags().StringSliceP("template", "t", []string{}, "Additional template file(s)")
-	command.Flags().String("output-dir", ".", "`directory` to store the processed templates. Only used for --input-dir")
+	command.Flags().String("output-dir", ".", "`directory` to store the processed templates. Only used for --input-dir or --bundle")
 	command.Flags().String("output-map", "", "Template `string` to map the input file to an output path")
+	command.Flags().String("matrix", "", "`datasource` alias of a list - all templates are rendered once for each item, which is available as .Item")
+	command.Flags().String("matrix-output", "", "template `string` for each output path in --matrix mode, with .Item and .in (the input path) available")
+	command.Flags().Int("matrix-parallelism", 0, "number of --matrix items to render at once. Defaults to the number of CPUs")
 	command.Flags().String("chmod", "", "set the mode for output file(s). Omit to inherit from input file(s)")
 
 	command.Flags().Bool("exec-pipe", false, "pipe the output to the post-run exec command")
@@ -156,6 +206,48 @@ func InitFlags(command *cobra.Command) {
 	command.Flags().String("left-delim", ldDefault, "override the default left-`delimiter` [$GOMPLATE_LEFT_DELIM]")
 	command.Flags().String("right-delim", rdDefault, "override the default right-`delimiter` [$GOMPLATE_RIGHT_DELIM]")
 
+	command.Flags().StringSlice("env-file", []string{}, "dotenv `file` to load into the environment before rendering. Variables in earlier files take precedence")
+	command.Flags().Bool("env-file-no-override", false, "don't override variables already set in the environment with values from --env-file")
+
+	command.Flags().StringSlice("values", []string{}, "YAML or JSON `file` of values to make available to templates as .Values. Values in later files take precedence")
+	command.Flags().StringArray("args", []string{}, "template `argument`, available to templates in the list .Args. Can be specified multiple times")
+	command.Flags().StringArray("arg", []string{}, "named template argument in `name=value` form, available to templates as .Arg.name. Can be specified multiple times")
+	command.Flags().StringArray("set", []string{}, "set a value in .Values, with a dotted `key=value` path (e.g. --set image.tag=1.2). Overrides --values")
+
+	command.Flags().StringSlice("notify", []string{}, "webhook `URL` to POST a summary to when rendering completes (Slack and Teams webhooks are detected)")
+
+	command.Flags().String("lock", "", "Consul key `URL` (like consul:///locks/myapp) to lock while rendering, so only one of several replicas renders at a time")
+	command.Flags().Duration("lock-wait", 0, "how long to wait for the --lock when it's held elsewhere, before giving up without rendering")
+
+	command.Flags().Duration("script-timeout", 0, "maximum time each Lua script run by the script functions can run for (default 5s)")
+	command.Flags().Int("script-call-depth", 0, "maximum depth of nested function calls in Lua scripts (default 200)")
+	command.Flags().Int("script-stack-size", 0, "maximum number of values on a Lua script's data stack (default 262144)")
+	command.Flags().String("script-memory", "", "maximum `size` of the strings and tables each Lua script can allocate (e.g. 64MiB, default 256MiB)")
+
+	command.Flags().Bool("verify", false, "refuse to use remote templates and datasources that don't have a signature configured (see the 'signatures' config option)")
+
+	command.Flags().Bool("html-escape", false, "contextually auto-escape template output as HTML (with html/template) [$GOMPLATE_HTML_ESCAPE]")
+
+	command.Flags().Bool("strict-deprecations", false, "fail when deprecated template functions are used, instead of warning")
+
+	command.Flags().Bool("ordered-maps", false, "preserve the key order of JSON, YAML, and TOML datasources when they're output with toJSON or toYAML")
+	command.Flags().Bool("preserve-comments", false, "preserve the comments and formatting of YAML datasources when they're output with toYAML (implies --ordered-maps)")
+
+	command.Flags().String("datasource-cache-limit", "", "maximum `size` of datasource data to hold in memory (e.g. 512MiB) - the least-recently used data is evicted and read again when needed")
+	command.Flags().String("datasource-spill-threshold", "", "datasource data larger than this `size` (e.g. 64MiB) is held in temporary files instead of in memory")
+	command.Flags().String("datasource-cache-dir", "", "`directory` to cache the data from datasources with a 'ttl' query parameter in, so later runs can reuse it")
+	command.Flags().Int("datasource-concurrency", 0, "read datasources before rendering, `number` at a time, instead of one at a time as they're used")
+	command.Flags().String("checksums", "", "`file` to write the SHA-256 digests of all outputs to, in the format of sha256sum (like SHA256SUMS)")
+	command.Flags().Bool("content-addressed", false, "rename output files to include their digests (like app.<digest>.js) - requires --checksums")
+	command.Flags().String("render-cache", "", "`file` to record rendered templates' fingerprints in, so that templates unchanged since the last run (including the datasources they read) aren't rendered again")
+
+	command.Flags().String("provenance-report", "", "`file` to write a JSON report to, tracing each line of output to the datasources and environment variables its values came from")
+	command.Flags().String("provenance-comment", "", "annotate lines of output with where their values came from, in a comment starting with this `string` (like '#' or '//')")
+
+	command.Flags().Bool("preview", false, "render all outputs to stdout instead, with values from secret datasources (like vault) masked with '****'")
+
+	command.Flags().Bool("watch", false, "keep running, and render again each time a template or file-based datasource changes")
+
 	command.Flags().Bool("experimental", false, "enable experimental features [$GOMPLATE_EXPERIMENTAL]")
 
 	command.Flags().BoolP("verbose", "V", false, "output extra information about what gomplate is doing")
@@ -174,6 +266,13 @@ func Main(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io
 		ctx = gomplate.ContextWithFSProvider(ctx, filefs.FS)
 	}
 
+	ctx, cleanup, err := contextWithPack(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Send()
+		return err
+	}
+	defer cleanup()
+
 	command := NewGomplateCmd()
 	InitFlags(command)
 	command.SetArgs(args)
@@ -181,7 +280,7 @@ func Main(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io
 	command.SetOut(stdout)
 	command.SetErr(stderr)
 
-	err := command.ExecuteContext(ctx)
+	err = command.ExecuteContext(ctx)
 	if err != nil {
 		log := zerolog.Ctx(ctx)
 		log.Error().Err(err).Send()
@@ -50,12 +50,53 @@ func TestRunMain(t *testing.T) {
 	err = Main(ctx, []string{"--bogus"}, nil, nil, nil)
 	assert.Error(t, err)
 
+	// the 'new' subcommand requires a scaffold directory
+	err = Main(ctx, []string{"new"}, nil, nil, nil)
+	assert.Error(t, err)
+
+	err = Main(ctx, []string{"bench", "-n", "0", "-i", "hello"}, nil, nil, nil)
+	assert.Error(t, err)
+
+	// the 'compile' subcommand requires a bundle path
+	err = Main(ctx, []string{"compile", "-i", "hello"}, nil, nil, nil)
+	assert.Error(t, err)
+
+	// the 'pack' subcommand requires an output path
+	err = Main(ctx, []string{"pack", "-i", "hello"}, nil, nil, nil)
+	assert.Error(t, err)
+
+	// the 'snapshot use' subcommand requires a snapshot directory
+	err = Main(ctx, []string{"snapshot", "use", "-i", "hello"}, nil, nil, nil)
+	assert.Error(t, err)
+
 	stdin := &bytes.Buffer{}
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
 	err = Main(ctx, []string{"-i", "hello"}, stdin, stdout, stderr)
 	assert.NoError(t, err)
 	assert.Equal(t, "hello", stdout.String())
+
+	stdout.Reset()
+	err = Main(ctx, []string{"bench", "-n", "2", "-i", "hello"}, stdin, stdout, stderr)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "2 iterations")
+	assert.Contains(t, stdout.String(), "<arg>")
+
+	dir := t.TempDir()
+	stdout.Reset()
+	err = Main(ctx, []string{"snapshot", "create", dir, "-d", "env=env:///HOME"}, stdin, stdout, stderr)
+	assert.NoError(t, err)
+	err = Main(ctx, []string{"snapshot", "use", dir, "-i", `{{ .Args }} {{ ds "env" | len | lt 0 }}`, "--args", "a", "--args", "b"}, stdin, stdout, stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, "[a b] true", stdout.String())
+
+	// only the directory can be given before the '--'
+	err = Main(ctx, []string{"snapshot", "use", dir, "-i", "hello", "a"}, stdin, stdout, stderr)
+	assert.ErrorContains(t, err, `unexpected argument "a"`)
+
+	// rendering from a snapshot is offline, so remote outputs can't be used
+	err = Main(ctx, []string{"snapshot", "use", dir, "-i", "hello", "-o", "s3://bucket/out.txt"}, stdin, stdout, stderr)
+	assert.ErrorContains(t, err, "network access is disabled when rendering from a snapshot")
 }
 
 func TestPostRunExec(t *testing.T) {
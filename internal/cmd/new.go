@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newNewCmd - the 'new' subcommand, which renders a scaffold directory
+func newNewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "new SCAFFOLD_DIR [OUTPUT_DIR]",
+		Short: "Render a directory tree of templates (a scaffold), prompting for values",
+		Long: `Render a directory tree of templates (a scaffold) into OUTPUT_DIR (or the
+current directory). File and directory names are templates too, and are skipped
+when they render to an empty string.
+
+Values listed in the scaffold's .gomplate-new.yaml file are prompted for, unless
+they're supplied with --values or --set.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			opts := gomplate.ScaffoldOptions{
+				Source: args[0],
+				Dest:   ".",
+			}
+			if len(args) > 1 {
+				opts.Dest = args[1]
+			}
+
+			var err error
+			opts.ValuesFiles, err = getStringSlice(cmd, "values")
+			if err != nil {
+				return err
+			}
+			opts.SetValues, err = getStringArray(cmd, "set")
+			if err != nil {
+				return err
+			}
+			opts.Force, err = getBool(cmd, "force")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+			err = gomplate.Scaffold(ctx, opts)
+			cmd.SilenceErrors = true
+			if err != nil {
+				return err
+			}
+
+			zerolog.Ctx(ctx).Debug().Strs("files", gomplate.Metrics.ChangedFiles).Msg("rendered scaffold")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("values", []string{}, "YAML or JSON `file` of values (answers), available to templates as .Values. Values in later files take precedence")
+	cmd.Flags().StringArray("set", []string{}, "set a value in .Values, with a dotted `key=value` path. Overrides --values")
+	cmd.Flags().Bool("force", false, "overwrite existing files")
+	cmd.Flags().BoolP("verbose", "V", false, "output extra information about what gomplate is doing")
+
+	return cmd
+}
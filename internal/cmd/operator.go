@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newOperatorCmd - the 'operator' subcommand, which renders labelled
+// ConfigMaps in a Kubernetes cluster
+func newOperatorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "operator [flags]",
+		Short: "Render ConfigMaps in a Kubernetes cluster into other ConfigMaps or Secrets",
+		Long: `Run as a lightweight Kubernetes operator: every interval, render the data of each
+ConfigMap labelled ` + gomplate.OperatorLabel + `=true as templates, and write
+the results to the ConfigMap or Secret named by its
+` + gomplate.OperatorTargetAnnotation + ` annotation (configmap/NAME or
+secret/NAME, in the same namespace).
+
+Datasources (and other options) are configured with the same flags (and config
+file) as the main gomplate command. Input and output flags are ignored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, nil)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			o := gomplate.OperatorOptions{}
+			o.Server, err = getString(cmd, "kube-server")
+			if err != nil {
+				return err
+			}
+			o.Namespace, err = getString(cmd, "namespace")
+			if err != nil {
+				return err
+			}
+			o.Interval, err = cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return err
+			}
+			o.Once, err = getBool(cmd, "once")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return gomplate.RunOperator(ctx, cfg, o)
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("kube-server", "", "Kubernetes API server `URL` (like one served by 'kubectl proxy'). Defaults to the cluster gomplate is running in")
+	cmd.Flags().String("namespace", "", "only render ConfigMaps in this namespace (defaults to all namespaces)")
+	cmd.Flags().Duration("interval", 30*time.Second, "how often to render all ConfigMaps")
+	cmd.Flags().Bool("once", false, "render all ConfigMaps once, and exit")
+
+	return cmd
+}
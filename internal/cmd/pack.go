@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hairyhenderson/gomplate/v3"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newPackCmd - the 'pack' subcommand, which writes a standalone executable
+func newPackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack OUTPUT [flags]",
+		Short: "Write a standalone executable that renders the templates with snapshots of the datasources",
+		Long: `Compile the configured templates into a bundle, read all configured
+datasources, and write a standalone executable to OUTPUT, which renders the
+templates without reading any template files or datasources.
+
+OUTPUT is a copy of this gomplate executable (or the --base executable, for
+other platforms) with the bundle, datasource snapshots, and rendering options
+appended. Run it with output flags (like --out or --output-dir) to render.
+
+Templates and datasources are configured with the same flags (and config file)
+as the main gomplate command.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, nil)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			base, err := getString(cmd, "base")
+			if err != nil {
+				return err
+			}
+			if base == "" {
+				base, err = os.Executable()
+				if err != nil {
+					return fmt.Errorf("failed to find gomplate executable: %w", err)
+				}
+			}
+
+			cmd.SilenceUsage = true
+
+			f, err := os.Open(base)
+			if err != nil {
+				return fmt.Errorf("failed to open base executable: %w", err)
+			}
+			defer f.Close()
+			fi, err := f.Stat()
+			if err != nil {
+				return err
+			}
+
+			// only write the executable if packing succeeds
+			buf := &bytes.Buffer{}
+			err = gomplate.Pack(ctx, cfg, f, fi.Size(), buf)
+			if err != nil {
+				return err
+			}
+
+			err = os.WriteFile(args[0], buf.Bytes(), 0o755)
+			if err != nil {
+				return fmt.Errorf("failed to write packed executable: %w", err)
+			}
+
+			zerolog.Ctx(ctx).Debug().Str("output", args[0]).Str("base", base).Msg("packed templates")
+			return nil
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("base", "", "gomplate `executable` to pack into, such as one built for another platform. Defaults to this executable")
+
+	return cmd
+}
+
+type packConfigKey struct{}
+
+// contextWithPack - extract the pack appended to the running executable, if
+// any, and add its config to the context, to be used as the base config
+func contextWithPack(ctx context.Context) (context.Context, func(), error) {
+	exe, err := os.Executable()
+	if err != nil {
+		// not fatal - just assume there's no pack
+		return ctx, func() {}, nil
+	}
+
+	cfg, cleanup, err := gomplate.ExtractPack(exe)
+	if err != nil || cfg == nil {
+		return ctx, cleanup, err
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("executable", exe).Msg("rendering packed templates")
+	return context.WithValue(ctx, packConfigKey{}, cfg), cleanup, nil
+}
+
+func packConfigFromContext(ctx context.Context) *config.Config {
+	if ctx == nil {
+		return nil
+	}
+	cfg, _ := ctx.Value(packConfigKey{}).(*config.Config)
+	return cfg
+}
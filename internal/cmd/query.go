@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newQueryCmd - the 'query' subcommand, which prints the result of querying
+// a datasource
+func newQueryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query [flags] EXPRESSION DATASOURCE",
+		Short: "Query a datasource with a jq (or JMESPath or JSONPath) expression, and print the result",
+		Long: `Read a datasource, query it, and print the result, without writing a template.
+DATASOURCE is the alias of a datasource defined with --datasource (or in the
+config file), or a datasource URL or file path ("-" reads standard input).
+
+The expression is a jq expression, unless --lang selects JMESPath or JSONPath.
+Each result is printed on its own line - strings as-is (unless --json is given),
+and other values as JSON.
+
+Datasources are read as they are for templates, so the main gomplate command's
+datasource flags (like --datasource-header) apply.`,
+		Example: `  gomplate query '.version' package.json
+  gomplate query -d api=https://example.com/items.json -H 'api=Authorization: Bearer abc' '.items[].name' api
+  kubectl get pods -o yaml | gomplate query --from yaml '.items[].metadata.name' -`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, nil)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			o := gomplate.QueryOptions{
+				Expr:   args[0],
+				Source: args[1],
+				Stdout: cmd.OutOrStdout(),
+			}
+			o.Lang, err = getString(cmd, "lang")
+			if err != nil {
+				return err
+			}
+			o.From, err = getString(cmd, "from")
+			if err != nil {
+				return err
+			}
+			o.JSON, err = getBool(cmd, "json")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return gomplate.Query(ctx, cfg, o)
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("lang", "jq", "query `language` - jq, jmespath, or jsonpath")
+	cmd.Flags().String("from", "", "the datasource's `format` (like json or yaml), when it can't be told from the URL")
+	cmd.Flags().Bool("json", false, "print strings as JSON, rather than as-is")
+
+	return cmd
+}
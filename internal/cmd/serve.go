@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newServeCmd - the 'serve' subcommand, which renders templates over HTTP
+func newServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve [flags]",
+		Short: "Serve templates over HTTP, rendering them for each request",
+		Long: `Serve templates over HTTP. Each request renders a template, with the request
+available as .Request (with .Request.Method, .Request.Path, .Request.Query, and
+.Request.Headers), and responds with the output. Templates and datasources are
+read again for each request, so changes are seen without restarting.
+
+With a single template (--file or --in), every path renders it. With an input
+directory, the request's path selects the template, relative to the directory,
+and directory paths render their index.html. Requests for other paths get a 404
+response, and render errors a 500 response with the error.
+
+The templates and their datasources are configured with the same flags (and
+config file) as the main gomplate command. Output flags are ignored.`,
+		Args: optionalExecArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			o := gomplate.ServeOptions{}
+			o.Addr, err = getString(cmd, "addr")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return gomplate.Serve(ctx, cfg, o)
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("addr", ":8080", "`address` to listen on")
+
+	return cmd
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newSnapshotCmd - the 'snapshot' subcommand, for rendering from local
+// snapshots of datasources
+func newSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Snapshot datasources, and render from snapshots without network access",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newSnapshotCreateCmd())
+	cmd.AddCommand(newSnapshotUseCmd())
+	return cmd
+}
+
+func newSnapshotCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create DIR [flags]",
+		Short: "Read all configured datasources into a snapshot directory",
+		Long: `Read all configured datasources (and context datasources), and write their data
+to the snapshot directory DIR, to render from later with 'gomplate snapshot use'.
+
+Data is stored in files named by its SHA-256 digest, listed in DIR/index.json.
+Datasources are configured with the same flags (and config file) as the main
+gomplate command.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, nil)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			cmd.SilenceUsage = true
+
+			err = gomplate.CreateSnapshot(ctx, cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			zerolog.Ctx(ctx).Debug().Str("dir", args[0]).Msg("created snapshot")
+			return nil
+		},
+	}
+
+	InitFlags(cmd)
+
+	return cmd
+}
+
+func newSnapshotUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use DIR [flags] [-- command]",
+		Short: "Render templates with datasources read only from a snapshot directory",
+		Long: `Render templates exactly as the main gomplate command does, except that
+datasources are read only from the snapshot directory DIR (created with
+'gomplate snapshot create'), and nothing is read from or written to the network.
+
+Reading a datasource that isn't in the snapshot is an error, as is using a
+remote template or output (like an S3 or HTTP URL), or the lock and notify
+options.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() == 0 {
+				return fmt.Errorf("the snapshot directory must be given before '--'")
+			}
+			if dash := cmd.ArgsLenAtDash(); dash > 1 || (dash < 0 && len(args) > 1) {
+				return fmt.Errorf("unexpected argument %q - only the snapshot directory can be given before '--'", args[1])
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+
+			// the first positional arg is the directory, and the rest are the
+			// post-exec command
+			cfg, err := loadConfig(cmd, args[1:])
+			if err != nil {
+				return err
+			}
+			cfg.Snapshot = args[0]
+
+			return render(cmd, cfg)
+		},
+	}
+
+	InitFlags(cmd)
+
+	return cmd
+}
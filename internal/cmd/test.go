@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hairyhenderson/gomplate/v3"
+	"github.com/hairyhenderson/yaml"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// maxTestOutput - failing outputs longer than this are truncated in the
+// report
+const maxTestOutput = 200
+
+// testSpecFile - a file of template tests
+type testSpecFile struct {
+	Tests []testSpec `yaml:"tests"`
+}
+
+// testSpec - a template test, which can read its template from a file
+// (relative to the spec file) instead of giving it inline
+type testSpec struct {
+	gomplate.TemplateTest `yaml:",inline"`
+	TemplateFile          string `yaml:"templateFile,omitempty"`
+}
+
+// newTestCmd - the 'test' subcommand, which runs property-based tests of
+// templates
+func newTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test SPEC...",
+		Short: "Run property-based tests of templates",
+		Long: `Render templates many times with generated inputs, and check that each
+output satisfies the tests' assertions.
+
+Each spec file is a YAML file with a list of tests. The inputs are numbers in
+ranges, values from lists, or strings matching regular expressions, and are
+generated from a seed, so failing cases can be reproduced.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			tests := []gomplate.TemplateTest{}
+			for _, path := range args {
+				t, err := readTestSpecs(path)
+				if err != nil {
+					return err
+				}
+				tests = append(tests, t...)
+			}
+
+			cmd.SilenceUsage = true
+
+			results, err := gomplate.RunTemplateTests(ctx, tests)
+			if err != nil {
+				return err
+			}
+
+			failed := printTestResults(cmd.OutOrStdout(), tests, results)
+			if failed > 0 {
+				return fmt.Errorf("%d of %d test(s) failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("verbose", "V", false, "output extra information about what gomplate is doing")
+
+	return cmd
+}
+
+func readTestSpecs(path string) ([]gomplate.TemplateTest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test spec: %w", err)
+	}
+	f := testSpecFile{}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse test spec %s: %w", path, err)
+	}
+
+	tests := make([]gomplate.TemplateTest, len(f.Tests))
+	for i, s := range f.Tests {
+		if s.TemplateFile != "" {
+			if s.Template != "" {
+				return nil, fmt.Errorf("test %q in %s: only one of template and templateFile can be set", s.Name, path)
+			}
+			tb, err := os.ReadFile(filepath.Join(filepath.Dir(path), s.TemplateFile))
+			if err != nil {
+				return nil, fmt.Errorf("test %q in %s: failed to read template: %w", s.Name, path, err)
+			}
+			s.Template = string(tb)
+		}
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("%s #%d", path, i+1)
+		}
+		tests[i] = s.TemplateTest
+	}
+	return tests, nil
+}
+
+// printTestResults prints each test's result, and the first failing case of
+// each failed test, returning the number of tests that failed
+func printTestResults(out io.Writer, tests []gomplate.TemplateTest, results []gomplate.TemplateTestResult) int {
+	failed := 0
+	for i, r := range results {
+		if r.Failure == nil {
+			fmt.Fprintf(out, "PASS  %s (%d cases)\n", r.Name, r.Cases)
+			continue
+		}
+		failed++
+		fmt.Fprintf(out, "FAIL  %s (%d of %d cases failed)\n", r.Name, r.Failed, r.Cases)
+
+		f := r.Failure
+		inputs, _ := json.Marshal(f.Inputs)
+		output := f.Output
+		if len(output) > maxTestOutput {
+			output = output[:maxTestOutput] + "..."
+		}
+		fmt.Fprintf(out, "      case:   %d (seed %d)\n", f.Case, tests[i].Seed)
+		fmt.Fprintf(out, "      inputs: %s\n", inputs)
+		fmt.Fprintf(out, "      output: %q\n", output)
+		fmt.Fprintf(out, "      error:  %s\n", f.Message)
+	}
+	return failed
+}
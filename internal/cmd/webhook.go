@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/hairyhenderson/gomplate/v3"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// newWebhookCmd - the 'webhook' subcommand, which serves a Kubernetes
+// mutating admission webhook
+func newWebhookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook [flags]",
+		Short: "Serve a Kubernetes mutating admission webhook, with patches rendered by a template",
+		Long: `Serve a Kubernetes mutating admission webhook. For each AdmissionReview received,
+the template is rendered with the admission request available as .Request (and
+the object being admitted as .Object and .OldObject), and must output a JSON
+patch (an array of operations) to apply to the object, or nothing to admit it
+unchanged. If the template fails to render (for example because it calls
+'fail'), the request is denied with the error.
+
+The template (exactly one) and its datasources are configured with the same
+flags (and config file) as the main gomplate command. Output flags are ignored.`,
+		Args: optionalExecArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+			ctx := cmd.Context()
+
+			cfg, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			if cfg.Experimental {
+				ctx = gomplate.SetExperimental(ctx)
+			}
+
+			o := gomplate.WebhookOptions{}
+			o.Addr, err = getString(cmd, "addr")
+			if err != nil {
+				return err
+			}
+			o.TLSCertFile, err = getString(cmd, "tls-cert")
+			if err != nil {
+				return err
+			}
+			o.TLSKeyFile, err = getString(cmd, "tls-key")
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return gomplate.ServeWebhook(ctx, cfg, o)
+		},
+	}
+
+	InitFlags(cmd)
+	cmd.Flags().String("addr", ":8443", "`address` to listen on")
+	cmd.Flags().String("tls-cert", "", "TLS certificate `file` (PEM). Without a certificate and key, plain HTTP is served")
+	cmd.Flags().String("tls-key", "", "TLS private key `file` (PEM)")
+
+	return cmd
+}
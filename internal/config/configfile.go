@@ -10,21 +10,35 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/hairyhenderson/gomplate/v3/internal/integrity"
 	"github.com/hairyhenderson/gomplate/v3/internal/iohelpers"
 	"github.com/hairyhenderson/yaml"
 	"github.com/pkg/errors"
 )
 
-// Parse a config file
+// Parse a config file. Environment variables referenced in values (like
+// "${VAR}") are interpolated first - see Interpolate.
 func Parse(in io.Reader) (*Config, error) {
 	out := &Config{}
+	node := &yaml.Node{}
 	dec := yaml.NewDecoder(in)
-	err := dec.Decode(out)
-	if err != nil && err != io.EOF {
+	err := dec.Decode(node)
+	if err == io.EOF {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+	if err = interpolateNode(node); err != nil {
+		return out, fmt.Errorf("failed to interpolate environment variables: %w", err)
+	}
+	err = node.Decode(out)
+	if err != nil {
 		return out, err
 	}
 	return out, nil
@@ -53,6 +67,13 @@ type Config struct {
 	InputFiles  []string `yaml:"inputFiles,omitempty,flow"`
 	ExcludeGlob []string `yaml:"excludes,omitempty"`
 
+	// Bundle is the path of a bundle compiled with 'gomplate compile', to
+	// render instead of Input/InputDir/InputFiles
+	Bundle string `yaml:"bundle,omitempty"`
+	// Snapshot is the path of a file of datasource snapshots, which are
+	// read instead of the datasources. Set for packed executables.
+	Snapshot string `yaml:"-"`
+
 	OutputDir   string   `yaml:"outputDir,omitempty"`
 	OutputMap   string   `yaml:"outputMap,omitempty"`
 	OutputFiles []string `yaml:"outputFiles,omitempty,flow"`
@@ -68,6 +89,106 @@ type Config struct {
 	ExecPipe      bool `yaml:"execPipe,omitempty"`
 	SuppressEmpty bool `yaml:"suppressEmpty,omitempty"`
 	Experimental  bool `yaml:"experimental,omitempty"`
+	HTMLEscape    bool `yaml:"htmlEscape,omitempty"`
+	OrderedMaps   bool `yaml:"orderedMaps,omitempty"`
+	// PreserveComments implies OrderedMaps
+	PreserveComments bool `yaml:"preserveComments,omitempty"`
+	// StrictDeprecations fails renders that use deprecated functions
+	StrictDeprecations bool `yaml:"strictDeprecations,omitempty"`
+
+	// DatasourceCacheLimit and DatasourceSpillThreshold are sizes, like
+	// "512MiB" - see GetCacheLimits
+	DatasourceCacheLimit     string `yaml:"datasourceCacheLimit,omitempty"`
+	DatasourceSpillThreshold string `yaml:"datasourceSpillThreshold,omitempty"`
+	// DatasourceConcurrency is the number of datasources to read at once
+	// before rendering. 0 means datasources are only read when they're used.
+	DatasourceConcurrency int `yaml:"datasourceConcurrency,omitempty"`
+	// DatasourceCacheDir is a directory to cache the data from datasources
+	// with a 'ttl' query parameter in, so later runs can use it
+	DatasourceCacheDir string `yaml:"datasourceCacheDir,omitempty"`
+
+	// ProvenanceReport is the path of a file to write a provenance report to
+	ProvenanceReport string `yaml:"provenanceReport,omitempty"`
+	// ProvenanceComment starts the comments that annotate output with where
+	// its values came from
+	ProvenanceComment string `yaml:"provenanceComment,omitempty"`
+	// Preview renders all outputs to Stdout instead, with values from secret
+	// datasources masked. It can only be set on the commandline.
+	Preview bool `yaml:"-"`
+
+	// Watch renders again each time a template or file-based datasource
+	// changes, instead of exiting after rendering
+	Watch bool `yaml:"watch,omitempty"`
+
+	// RenderCache is the path of the file to record rendered templates'
+	// fingerprints in, so unchanged templates can be skipped
+	RenderCache string `yaml:"renderCache,omitempty"`
+
+	// Checksums is the path of a file to write the SHA-256 digests of all
+	// outputs to, in the same format as sha256sum
+	Checksums string `yaml:"checksums,omitempty"`
+	// ContentAddressed renames output files to include their digests
+	ContentAddressed bool `yaml:"contentAddressed,omitempty"`
+
+	// EnvFiles are dotenv files to load into the environment before
+	// rendering. Variables in earlier files take precedence.
+	EnvFiles          []string `yaml:"envFiles,omitempty,flow"`
+	EnvFileNoOverride bool     `yaml:"envFileNoOverride,omitempty"`
+
+	// Values are made available to templates as .Values. They're overridden
+	// by values read from ValuesFiles (in order), which are in turn
+	// overridden by SetValues (Helm-style key=value assignments).
+	Values      map[string]interface{} `yaml:"values,omitempty"`
+	ValuesFiles []string               `yaml:"valuesFiles,omitempty,flow"`
+	SetValues   []string               `yaml:"-"`
+
+	// Args and NamedArgs are made available to templates as .Args and .Arg.
+	// They can only be given on the command line.
+	Args      []string          `yaml:"-"`
+	NamedArgs map[string]string `yaml:"-"`
+
+	Notify []NotifyConfig `yaml:"notify,omitempty"`
+
+	// Signatures configures the verification of detached signatures of
+	// templates and datasources, by URL prefix. When Verify is set, content
+	// from remote URLs without a signature configured is refused.
+	Signatures []SignatureConfig `yaml:"signatures,omitempty"`
+	Verify     bool              `yaml:"verify,omitempty"`
+
+	// Encrypt encrypts the outputs that match, so that secrets generated by
+	// templates can be committed safely
+	Encrypt []EncryptConfig `yaml:"encrypt,omitempty"`
+
+	// NetworkPolicy restricts the hosts that datasources and templates can
+	// be read from - when it's set, everything not allowed is denied
+	NetworkPolicy *NetworkPolicy `yaml:"networkPolicy,omitempty"`
+
+	// RateLimits limits the rate of requests that datasources make to
+	// matching hosts
+	RateLimits []RateLimit `yaml:"rateLimits,omitempty"`
+	// MaxConnsPerHost limits the number of connections that datasources open
+	// to each host. 0 means no limit.
+	MaxConnsPerHost int `yaml:"maxConnsPerHost,omitempty"`
+
+	// CredentialHelpers are external commands that supply the credentials
+	// for datasources on matching hosts - see CredentialHelper
+	CredentialHelpers []CredentialHelper `yaml:"credentialHelpers,omitempty"`
+
+	// Schemes are URL scheme shortcuts for datasources and nested templates,
+	// mapping each shortcut scheme to the URL it expands to - see
+	// ExpandScheme
+	Schemes map[string]string `yaml:"schemes,omitempty"`
+
+	// Matrix renders every template once for each item in a datasource
+	Matrix *MatrixConfig `yaml:"matrix,omitempty"`
+
+	// Lock is acquired before rendering, so that only one of several
+	// replicas renders (and writes outputs) at a time
+	Lock *LockConfig `yaml:"lock,omitempty"`
+
+	// ScriptLimits overrides the limits on the resources that scripts run by
+	// the script functions can use
+	ScriptLimits *ScriptLimits `yaml:"scriptLimits,omitempty"`
 }
 
 var experimentalCtxKey = struct{}{}
@@ -98,14 +219,18 @@ func mergeDataSources(d, o map[string]DataSource) map[string]DataSource {
 type DataSource struct {
 	URL    *url.URL    `yaml:"-"`
 	Header http.Header `yaml:"header,omitempty,flow"`
+	// Integrity pins the SHA-256 digest of the content, like
+	// "sha256-<base64 digest>"
+	Integrity string `yaml:"integrity,omitempty"`
 }
 
 // UnmarshalYAML - satisfy the yaml.Umarshaler interface - URLs aren't
 // well supported, and anyway we need to do some extra parsing
 func (d *DataSource) UnmarshalYAML(value *yaml.Node) error {
 	type raw struct {
-		Header http.Header
-		URL    string
+		Header    http.Header
+		URL       string
+		Integrity string
 	}
 	r := raw{}
 	err := value.Decode(&r)
@@ -116,9 +241,15 @@ func (d *DataSource) UnmarshalYAML(value *yaml.Node) error {
 	if err != nil {
 		return fmt.Errorf("could not parse datasource URL %q: %w", r.URL, err)
 	}
+	if r.Integrity != "" {
+		if _, err := integrity.Parse(r.Integrity); err != nil {
+			return fmt.Errorf("datasource %q: %w", r.URL, err)
+		}
+	}
 	*d = DataSource{
-		URL:    u,
-		Header: r.Header,
+		URL:       u,
+		Header:    r.Header,
+		Integrity: r.Integrity,
 	}
 	return nil
 }
@@ -127,12 +258,14 @@ func (d *DataSource) UnmarshalYAML(value *yaml.Node) error {
 // well supported, and anyway we need to do some extra parsing
 func (d DataSource) MarshalYAML() (interface{}, error) {
 	type raw struct {
-		Header http.Header
-		URL    string
+		Header    http.Header
+		URL       string
+		Integrity string `yaml:",omitempty"`
 	}
 	r := raw{
-		URL:    d.URL.String(),
-		Header: d.Header,
+		URL:       d.URL.String(),
+		Header:    d.Header,
+		Integrity: d.Integrity,
 	}
 	return r, nil
 }
@@ -140,8 +273,15 @@ func (d DataSource) MarshalYAML() (interface{}, error) {
 // mergeFrom - use this as default, and override with values from o
 func (d DataSource) mergeFrom(o DataSource) DataSource {
 	if o.URL != nil {
+		// a pinned digest is only for the URL it was pinned with
+		if d.URL == nil || d.URL.String() != o.URL.String() {
+			d.Integrity = ""
+		}
 		d.URL = o.URL
 	}
+	if o.Integrity != "" {
+		d.Integrity = o.Integrity
+	}
 	if d.Header == nil {
 		d.Header = o.Header
 	} else {
@@ -152,10 +292,399 @@ func (d DataSource) mergeFrom(o DataSource) DataSource {
 	return d
 }
 
+// NotifyConfig - configures a webhook to notify when rendering completes
+type NotifyConfig struct {
+	URL     string            `yaml:"url"`
+	Format  string            `yaml:"format,omitempty"`
+	On      []string          `yaml:"on,omitempty,flow"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// SignatureConfig - configures how to verify the detached signatures of
+// templates and datasources whose URLs start with URL. The signature is read
+// from the same URL, with Suffix appended.
+type SignatureConfig struct {
+	URL string `yaml:"url"`
+	// Type is one of cosign, minisign, or gpg
+	Type string `yaml:"type"`
+	// Key is the path of the public key (or GPG keyring) file
+	Key    string `yaml:"key"`
+	Suffix string `yaml:"suffix,omitempty"`
+}
+
+// EncryptConfig - configures how to encrypt the outputs matching any of the
+// globs - either as an age-encrypted file (the default), or as a
+// SOPS-encrypted YAML or JSON document
+type EncryptConfig struct {
+	Outputs []string `yaml:"outputs,flow"`
+	// Format is one of age or sops
+	Format string `yaml:"format,omitempty"`
+	// Recipients are age recipients (and, for sops, AWS KMS key ARNs) - by
+	// default, the SOPS_AGE_RECIPIENTS and SOPS_KMS_ARN environment variables
+	// are used
+	Recipients []string `yaml:"recipients,omitempty,flow"`
+}
+
+// NetworkPolicy - the schemes and hosts that datasources and templates can be
+// read from
+type NetworkPolicy struct {
+	Allow []NetworkRule `yaml:"allow"`
+}
+
+// NetworkRule - allows URLs with any of the schemes, to any of the hosts.
+// Hosts are globs (like "*.example.com"), optionally with a port. When there
+// are no hosts, any host is allowed.
+type NetworkRule struct {
+	Schemes []string `yaml:"schemes,flow"`
+	Hosts   []string `yaml:"hosts,omitempty,flow"`
+}
+
+func (p NetworkPolicy) validate() error {
+	for _, r := range p.Allow {
+		if len(r.Schemes) == 0 {
+			return fmt.Errorf("networkPolicy: schemes are required for each allow rule")
+		}
+		for _, h := range r.Hosts {
+			if _, err := path.Match(h, ""); err != nil {
+				return fmt.Errorf("networkPolicy: invalid host pattern %q: %w", h, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validSchemeName - the syntax of URL schemes, from RFC 3986
+var validSchemeName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+func validateSchemes(schemes map[string]string) error {
+	for name, target := range schemes {
+		if !validSchemeName.MatchString(name) {
+			return fmt.Errorf("schemes: invalid scheme name %q", name)
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("schemes: %s: invalid URL %q: %w", name, target, err)
+		}
+		if u.Scheme == "" {
+			return fmt.Errorf("schemes: %s: %q must be an absolute URL", name, target)
+		}
+		if _, ok := schemes[u.Scheme]; ok {
+			return fmt.Errorf("schemes: %s: can't expand to another shortcut (%s)", name, u.Scheme)
+		}
+	}
+	return nil
+}
+
+// ExpandScheme - when the URL's scheme is one of the shortcuts in schemes,
+// returns the URL it expands to, or otherwise the URL unchanged. The rest of
+// the URL (after "scheme://") is appended to the shortcut's URL, so with the
+// shortcut "secrets: vault:///kv/data/team/", "secrets://db" expands to
+// "vault:///kv/data/team/db". Query parameters are combined, with the URL's
+// taking precedence.
+func ExpandScheme(schemes map[string]string, u *url.URL) *url.URL {
+	if u == nil {
+		return u
+	}
+	target, ok := schemes[u.Scheme]
+	if !ok {
+		return u
+	}
+
+	rest := u.Opaque
+	if rest == "" {
+		rest = strings.TrimPrefix(u.Host+u.EscapedPath(), "/")
+	}
+	base, baseQuery, _ := strings.Cut(target, "?")
+	out, err := url.Parse(base + rest)
+	if err != nil {
+		// the shortcut was validated, so this shouldn't happen
+		return u
+	}
+
+	switch {
+	case baseQuery == "":
+		out.RawQuery = u.RawQuery
+	case u.RawQuery == "":
+		out.RawQuery = baseQuery
+	default:
+		q, _ := url.ParseQuery(baseQuery)
+		for k, v := range u.Query() {
+			q[k] = v
+		}
+		out.RawQuery = q.Encode()
+	}
+	out.Fragment = u.Fragment
+	return out
+}
+
+// RateLimit - limits the rate of requests to each host matching Host, which
+// is a glob (like "*.example.com")
+type RateLimit struct {
+	Host string `yaml:"host"`
+	// Rate is the number of requests per second
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of requests that can be made at once, before
+	// they're limited to Rate. Defaults to 1.
+	Burst int `yaml:"burst,omitempty"`
+}
+
+func (r RateLimit) validate() error {
+	if r.Host == "" {
+		return fmt.Errorf("rateLimits: host is required")
+	}
+	if _, err := path.Match(r.Host, ""); err != nil {
+		return fmt.Errorf("rateLimits: invalid host pattern %q: %w", r.Host, err)
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("rateLimits: rate for %s must be greater than 0", r.Host)
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("rateLimits: burst for %s must not be negative", r.Host)
+	}
+	return nil
+}
+
+// CredentialHelper - an external command that supplies the credentials for
+// requests to hosts matching Host, which is a glob (like "*.example.com").
+// Helpers use git's credential helper protocol, so helpers written for git can
+// be used.
+type CredentialHelper struct {
+	Host    string   `yaml:"host"`
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty,flow"`
+}
+
+func (h CredentialHelper) validate() error {
+	if h.Host == "" {
+		return fmt.Errorf("credentialHelpers: host is required")
+	}
+	if _, err := path.Match(h.Host, ""); err != nil {
+		return fmt.Errorf("credentialHelpers: invalid host pattern %q: %w", h.Host, err)
+	}
+	if h.Command == "" {
+		return fmt.Errorf("credentialHelpers: command for %s is required", h.Host)
+	}
+	return nil
+}
+
+// LockConfig - a Consul lock to hold while rendering
+type LockConfig struct {
+	// URL is the Consul key to lock, like consul:///locks/myapp
+	URL string `yaml:"url"`
+	// Wait is how long to wait for the lock when it's held elsewhere, before
+	// giving up and not rendering. 0 means don't wait.
+	Wait time.Duration `yaml:"wait,omitempty"`
+	// TTL is the lock session's TTL - if gomplate dies while holding the
+	// lock, it's released after this long. Defaults to 15s.
+	TTL time.Duration `yaml:"ttl,omitempty"`
+}
+
+// mergeFrom - use l as the defaults, and override with non-zero values from o
+func (l *LockConfig) mergeFrom(o *LockConfig) *LockConfig {
+	out := &LockConfig{}
+	if l != nil {
+		*out = *l
+	}
+	if o.URL != "" {
+		out.URL = o.URL
+	}
+	if o.Wait != 0 {
+		out.Wait = o.Wait
+	}
+	if o.TTL != 0 {
+		out.TTL = o.TTL
+	}
+	return out
+}
+
+func (l LockConfig) validate() error {
+	u, err := ParseSourceURL(l.URL)
+	if err != nil {
+		return fmt.Errorf("lock: invalid url %q: %w", l.URL, err)
+	}
+	switch u.Scheme {
+	case "consul", "consul+http", "consul+https":
+	default:
+		return fmt.Errorf("lock: url must be a consul URL, like consul:///locks/myapp (got %q)", l.URL)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("lock: url must have the key to lock as its path")
+	}
+	if l.Wait < 0 {
+		return fmt.Errorf("lock: wait must not be negative")
+	}
+	// Consul's limits for session TTLs
+	if l.TTL != 0 && (l.TTL < 10*time.Second || l.TTL > 24*time.Hour) {
+		return fmt.Errorf("lock: ttl must be between 10s and 24h")
+	}
+	return nil
+}
+
+// ScriptLimits - the limits on the resources that Lua scripts can use. Zero
+// values use the defaults.
+type ScriptLimits struct {
+	// Timeout is the maximum time a script can run for
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// CallDepth is the maximum depth of nested function calls
+	CallDepth int `yaml:"callDepth,omitempty"`
+	// StackSize is the maximum number of values on the data stack
+	StackSize int `yaml:"stackSize,omitempty"`
+	// Memory is the maximum size of the strings and tables that a script can
+	// allocate, like "64MiB" - see GetMemory
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// mergeFrom - use l as the defaults, and override with non-zero values from o
+func (l *ScriptLimits) mergeFrom(o *ScriptLimits) *ScriptLimits {
+	out := &ScriptLimits{}
+	if l != nil {
+		*out = *l
+	}
+	if o.Timeout != 0 {
+		out.Timeout = o.Timeout
+	}
+	if o.CallDepth != 0 {
+		out.CallDepth = o.CallDepth
+	}
+	if o.StackSize != 0 {
+		out.StackSize = o.StackSize
+	}
+	if o.Memory != "" {
+		out.Memory = o.Memory
+	}
+	return out
+}
+
+func (l ScriptLimits) validate() error {
+	if l.Timeout < 0 {
+		return fmt.Errorf("scriptLimits: timeout must not be negative")
+	}
+	if l.CallDepth < 0 {
+		return fmt.Errorf("scriptLimits: callDepth must not be negative")
+	}
+	if l.StackSize < 0 {
+		return fmt.Errorf("scriptLimits: stackSize must not be negative")
+	}
+	_, err := l.GetMemory()
+	return err
+}
+
+// GetMemory - parse the memory limit, in bytes. An unset limit is 0.
+func (l ScriptLimits) GetMemory() (int64, error) {
+	n, err := parseByteSize(l.Memory)
+	if err != nil {
+		return 0, fmt.Errorf("scriptLimits: invalid memory: %w", err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("scriptLimits: memory must not be negative")
+	}
+	return n, nil
+}
+
+// MatrixConfig - configures matrix rendering, where the templates are rendered
+// once for each item in a list
+type MatrixConfig struct {
+	// Datasource is the alias of the datasource that provides the list
+	Datasource string `yaml:"datasource"`
+	// OutputPath is a template for each output file's path
+	OutputPath string `yaml:"outputPath"`
+	// Parallelism is the number of items to render at once. Defaults to the
+	// number of CPUs.
+	Parallelism int `yaml:"parallelism,omitempty"`
+}
+
+// mergeFrom - use m as the defaults, and override with non-zero values from o
+func (m *MatrixConfig) mergeFrom(o *MatrixConfig) *MatrixConfig {
+	out := &MatrixConfig{}
+	if m != nil {
+		*out = *m
+	}
+	if o.Datasource != "" {
+		out.Datasource = o.Datasource
+	}
+	if o.OutputPath != "" {
+		out.OutputPath = o.OutputPath
+	}
+	if o.Parallelism != 0 {
+		out.Parallelism = o.Parallelism
+	}
+	return out
+}
+
+func (m MatrixConfig) validate() error {
+	if m.Datasource == "" {
+		return fmt.Errorf("matrix: datasource is required")
+	}
+	if m.OutputPath == "" {
+		return fmt.Errorf("matrix: outputPath is required")
+	}
+	if m.Parallelism < 0 {
+		return fmt.Errorf("matrix: parallelism must not be negative")
+	}
+	return nil
+}
+
+func (n NotifyConfig) validate() error {
+	if n.URL == "" {
+		return fmt.Errorf("notify: url is required")
+	}
+	switch n.Format {
+	case "", "json", "slack", "teams":
+	default:
+		return fmt.Errorf("notify: invalid format %q (must be one of json, slack, or teams)", n.Format)
+	}
+	for _, e := range n.On {
+		switch e {
+		case "success", "failure", "change":
+		default:
+			return fmt.Errorf("notify: invalid event %q (must be one of success, failure, or change)", e)
+		}
+	}
+	return nil
+}
+
+func (s SignatureConfig) validate() error {
+	if s.URL == "" {
+		return fmt.Errorf("signatures: url is required")
+	}
+	switch s.Type {
+	case "cosign", "minisign", "gpg":
+	default:
+		return fmt.Errorf("signatures: invalid type %q for %s (must be one of cosign, minisign, or gpg)", s.Type, s.URL)
+	}
+	if s.Key == "" {
+		return fmt.Errorf("signatures: key is required for %s", s.URL)
+	}
+	return nil
+}
+
+func (e EncryptConfig) validate() error {
+	if len(e.Outputs) == 0 {
+		return fmt.Errorf("encrypt: outputs are required")
+	}
+	for _, o := range e.Outputs {
+		if _, err := path.Match(o, ""); err != nil {
+			return fmt.Errorf("encrypt: invalid output pattern %q: %w", o, err)
+		}
+	}
+	switch e.Format {
+	case "", "age", "sops":
+	default:
+		return fmt.Errorf("encrypt: invalid format %q (must be one of age or sops)", e.Format)
+	}
+	return nil
+}
+
 type PluginConfig struct {
 	Cmd     string
 	Timeout time.Duration
 	Pipe    bool
+	// Protocol - how gomplate talks to the plugin: "exec" (the default) runs
+	// Cmd for every call, and "grpc" starts it once as a gRPC plugin
+	Protocol string `yaml:",omitempty"`
+	// Persistent - whether an exec plugin is started once and called over
+	// stdin and stdout, rather than run for every call
+	Persistent bool `yaml:",omitempty"`
 }
 
 // UnmarshalYAML - satisfy the yaml.Umarshaler interface - plugin configs can
@@ -178,9 +707,11 @@ func (p *PluginConfig) UnmarshalYAML(value *yaml.Node) error {
 	}
 
 	type raw struct {
-		Cmd     string
-		Timeout time.Duration
-		Pipe    bool
+		Cmd        string
+		Timeout    time.Duration
+		Pipe       bool
+		Protocol   string
+		Persistent bool
 	}
 	r := raw{}
 	err := value.Decode(&r)
@@ -188,6 +719,15 @@ func (p *PluginConfig) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
+	switch r.Protocol {
+	case "", "exec", "grpc":
+	default:
+		return fmt.Errorf("plugins: invalid protocol %q for %s (must be exec or grpc)", r.Protocol, r.Cmd)
+	}
+	if r.Persistent && r.Protocol == "grpc" {
+		return fmt.Errorf("plugins: persistent can't be set for gRPC plugins (%s), which are always persistent", r.Cmd)
+	}
+
 	*p = PluginConfig(r)
 
 	return nil
@@ -204,18 +744,26 @@ func (c *Config) MergeFrom(o *Config) *Config {
 		c.Input = o.Input
 		c.InputDir = ""
 		c.InputFiles = nil
+		c.Bundle = ""
 		c.OutputDir = ""
 	case !isZero(o.InputDir):
 		c.Input = ""
 		c.InputDir = o.InputDir
 		c.InputFiles = nil
+		c.Bundle = ""
 	case !isZero(o.InputFiles):
 		if !(len(o.InputFiles) == 1 && o.InputFiles[0] == "-") {
 			c.Input = ""
 			c.InputFiles = o.InputFiles
 			c.InputDir = ""
+			c.Bundle = ""
 			c.OutputDir = ""
 		}
+	case !isZero(o.Bundle):
+		c.Input = ""
+		c.InputDir = ""
+		c.InputFiles = nil
+		c.Bundle = o.Bundle
 	}
 
 	if !isZero(o.OutputMap) {
@@ -250,6 +798,113 @@ func (c *Config) MergeFrom(o *Config) *Config {
 	if !isZero(o.RDelim) {
 		c.RDelim = o.RDelim
 	}
+	if !isZero(o.HTMLEscape) {
+		c.HTMLEscape = o.HTMLEscape
+	}
+	if !isZero(o.StrictDeprecations) {
+		c.StrictDeprecations = o.StrictDeprecations
+	}
+	if !isZero(o.OrderedMaps) {
+		c.OrderedMaps = o.OrderedMaps
+	}
+	if !isZero(o.PreserveComments) {
+		c.PreserveComments = o.PreserveComments
+	}
+	if !isZero(o.RenderCache) {
+		c.RenderCache = o.RenderCache
+	}
+	if !isZero(o.Checksums) {
+		c.Checksums = o.Checksums
+	}
+	if !isZero(o.ContentAddressed) {
+		c.ContentAddressed = o.ContentAddressed
+	}
+	if !isZero(o.ProvenanceReport) {
+		c.ProvenanceReport = o.ProvenanceReport
+	}
+	if !isZero(o.ProvenanceComment) {
+		c.ProvenanceComment = o.ProvenanceComment
+	}
+	if !isZero(o.Preview) {
+		c.Preview = o.Preview
+	}
+	if !isZero(o.Watch) {
+		c.Watch = o.Watch
+	}
+	if !isZero(o.DatasourceCacheLimit) {
+		c.DatasourceCacheLimit = o.DatasourceCacheLimit
+	}
+	if !isZero(o.DatasourceSpillThreshold) {
+		c.DatasourceSpillThreshold = o.DatasourceSpillThreshold
+	}
+	if !isZero(o.DatasourceConcurrency) {
+		c.DatasourceConcurrency = o.DatasourceConcurrency
+	}
+	if !isZero(o.DatasourceCacheDir) {
+		c.DatasourceCacheDir = o.DatasourceCacheDir
+	}
+	if !isZero(o.EnvFiles) {
+		c.EnvFiles = o.EnvFiles
+	}
+	if !isZero(o.EnvFileNoOverride) {
+		c.EnvFileNoOverride = o.EnvFileNoOverride
+	}
+	if len(o.Values) > 0 {
+		c.Values = o.Values
+	}
+	if !isZero(o.ValuesFiles) {
+		c.ValuesFiles = o.ValuesFiles
+	}
+	if !isZero(o.SetValues) {
+		c.SetValues = o.SetValues
+	}
+	if !isZero(o.Args) {
+		c.Args = o.Args
+	}
+	if len(o.NamedArgs) > 0 {
+		c.NamedArgs = o.NamedArgs
+	}
+	if len(o.Notify) > 0 {
+		c.Notify = o.Notify
+	}
+	if len(o.Signatures) > 0 {
+		c.Signatures = o.Signatures
+	}
+	if len(o.Encrypt) > 0 {
+		c.Encrypt = o.Encrypt
+	}
+	if o.NetworkPolicy != nil {
+		c.NetworkPolicy = o.NetworkPolicy
+	}
+	if len(o.RateLimits) > 0 {
+		c.RateLimits = o.RateLimits
+	}
+	if !isZero(o.MaxConnsPerHost) {
+		c.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	if len(o.CredentialHelpers) > 0 {
+		c.CredentialHelpers = o.CredentialHelpers
+	}
+	if len(o.Schemes) > 0 {
+		if c.Schemes == nil {
+			c.Schemes = map[string]string{}
+		}
+		for k, v := range o.Schemes {
+			c.Schemes[k] = v
+		}
+	}
+	if !isZero(o.Verify) {
+		c.Verify = o.Verify
+	}
+	if o.Lock != nil {
+		c.Lock = c.Lock.mergeFrom(o.Lock)
+	}
+	if o.Matrix != nil {
+		c.Matrix = c.Matrix.mergeFrom(o.Matrix)
+	}
+	if o.ScriptLimits != nil {
+		c.ScriptLimits = c.ScriptLimits.mergeFrom(o.ScriptLimits)
+	}
 	if c.Templates == nil {
 		c.Templates = o.Templates
 	} else {
@@ -418,8 +1073,8 @@ func splitHeader(header string) (name, value string, err error) {
 // Validate the Config
 func (c Config) Validate() (err error) {
 	err = notTogether(
-		[]string{"in", "inputFiles", "inputDir"},
-		c.Input, c.InputFiles, c.InputDir)
+		[]string{"in", "inputFiles", "inputDir", "bundle"},
+		c.Input, c.InputFiles, c.InputDir, c.Bundle)
 	if err == nil {
 		err = notTogether(
 			[]string{"outputFiles", "outputDir", "outputMap"},
@@ -432,11 +1087,50 @@ func (c Config) Validate() (err error) {
 	}
 
 	if err == nil {
+		err = notTogether(
+			[]string{"preview", "execPipe"},
+			c.Preview, c.ExecPipe)
+	}
+
+	if err == nil {
+		err = mustTogether("contentAddressed", "checksums",
+			c.ContentAddressed, c.Checksums)
+	}
+
+	if err == nil && c.ScriptLimits != nil {
+		err = c.ScriptLimits.validate()
+	}
+
+	if err == nil && c.Lock != nil {
+		err = c.Lock.validate()
+	}
+
+	// these always need the network, which is disabled when rendering from a
+	// snapshot
+	if err == nil && c.Snapshot != "" && (c.Lock != nil || len(c.Notify) > 0) {
+		err = fmt.Errorf("lock and notify can't be used when rendering from a snapshot, since they need network access")
+	}
+
+	if err == nil && c.Matrix != nil {
+		err = c.Matrix.validate()
+		if err == nil {
+			err = notTogether(
+				[]string{"matrix", "outputFiles", "outputDir", "outputMap", "execPipe", "renderCache"},
+				"set", c.OutputFiles, c.OutputDir, c.OutputMap, c.ExecPipe, c.RenderCache)
+		}
+	}
+
+	if err == nil && c.Bundle != "" {
+		err = c.validateBundle()
+	}
+
+	// templates from a bundle can be output to a dir too
+	if err == nil && c.Bundle == "" {
 		err = mustTogether("outputDir", "inputDir",
 			c.OutputDir, c.InputDir)
 	}
 
-	if err == nil {
+	if err == nil && c.Bundle == "" {
 		err = mustTogether("outputMap", "inputDir",
 			c.OutputMap, c.InputDir)
 	}
@@ -447,7 +1141,8 @@ func (c Config) Validate() (err error) {
 			f = 1
 		}
 		o := len(c.OutputFiles)
-		if f != o && !c.ExecPipe {
+		// the number of templates in a bundle is only known when it's read
+		if f != o && !c.ExecPipe && c.Matrix == nil && c.Bundle == "" {
 			err = fmt.Errorf("must provide same number of 'outputFiles' (%d) as 'in' or 'inputFiles' (%d) options", o, f)
 		}
 	}
@@ -464,9 +1159,61 @@ func (c Config) Validate() (err error) {
 		}
 	}
 
+	if err == nil {
+		_, _, err = c.GetCacheLimits()
+	}
+
+	for i := 0; err == nil && i < len(c.Notify); i++ {
+		err = c.Notify[i].validate()
+	}
+
+	for i := 0; err == nil && i < len(c.Signatures); i++ {
+		err = c.Signatures[i].validate()
+	}
+
+	for i := 0; err == nil && i < len(c.Encrypt); i++ {
+		err = c.Encrypt[i].validate()
+	}
+
+	if err == nil && c.NetworkPolicy != nil {
+		err = c.NetworkPolicy.validate()
+	}
+
+	for i := 0; err == nil && i < len(c.RateLimits); i++ {
+		err = c.RateLimits[i].validate()
+	}
+
+	if err == nil && c.MaxConnsPerHost < 0 {
+		err = fmt.Errorf("maxConnsPerHost must not be negative")
+	}
+
+	for i := 0; err == nil && i < len(c.CredentialHelpers); i++ {
+		err = c.CredentialHelpers[i].validate()
+	}
+
+	if err == nil && c.DatasourceConcurrency < 0 {
+		err = fmt.Errorf("datasourceConcurrency must not be negative")
+	}
+
+	if err == nil {
+		err = validateSchemes(c.Schemes)
+	}
+
 	return err
 }
 
+// validateBundle - nested templates are compiled into bundles, so can't be
+// given separately, and bundles can't be rendered with a matrix
+func (c Config) validateBundle() error {
+	if len(c.Templates) > 0 {
+		return fmt.Errorf("nested templates can't be used with a bundle - compile them into the bundle instead")
+	}
+	if c.Matrix != nil {
+		return fmt.Errorf("only one of these options is supported at a time: 'bundle', 'matrix'")
+	}
+	return nil
+}
+
 func notTogether(names []string, values ...interface{}) error {
 	found := ""
 	for i, value := range values {
@@ -517,13 +1264,15 @@ func (c *Config) ApplyDefaults() {
 		c.Stdin = os.Stdin
 	}
 
-	if c.InputDir != "" && c.OutputDir == "" && c.OutputMap == "" {
+	// in matrix mode, output paths come from the matrix's outputPath
+	matrix := c.Matrix != nil
+	if c.InputDir != "" && c.OutputDir == "" && c.OutputMap == "" && !matrix {
 		c.OutputDir = "."
 	}
-	if c.Input == "" && c.InputDir == "" && len(c.InputFiles) == 0 {
+	if c.Input == "" && c.InputDir == "" && len(c.InputFiles) == 0 && c.Bundle == "" {
 		c.InputFiles = []string{"-"}
 	}
-	if c.OutputDir == "" && c.OutputMap == "" && len(c.OutputFiles) == 0 && !c.ExecPipe {
+	if c.OutputDir == "" && c.OutputMap == "" && len(c.OutputFiles) == 0 && !c.ExecPipe && !matrix {
 		c.OutputFiles = []string{"-"}
 	}
 	if c.LDelim == "" {
@@ -563,6 +1312,58 @@ func (c *Config) GetMode() (os.FileMode, bool, error) {
 	return mode, modeOverride, nil
 }
 
+// GetCacheLimits - parse the datasource cache limit and spill threshold, in
+// bytes. Unset sizes are 0.
+func (c *Config) GetCacheLimits() (limit, spill int64, err error) {
+	limit, err = parseByteSize(c.DatasourceCacheLimit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid datasourceCacheLimit: %w", err)
+	}
+	spill, err = parseByteSize(c.DatasourceSpillThreshold)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid datasourceSpillThreshold: %w", err)
+	}
+	return limit, spill, nil
+}
+
+// byteSizeUnits - decimal and binary units, by lower-case suffix
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1000,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1000 * 1000,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1 << 30,
+}
+
+// parseByteSize parses a size like "512MiB", "1.5GB", or "1024"
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, s[i:])
+	}
+	return int64(n * float64(unit)), nil
+}
+
 // String -
 func (c *Config) String() string {
 	out := &strings.Builder{}
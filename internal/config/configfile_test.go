@@ -14,6 +14,7 @@ import (
 	"github.com/hairyhenderson/gomplate/v3/internal/iohelpers"
 	"github.com/hairyhenderson/yaml"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseConfigFile(t *testing.T) {
@@ -37,6 +38,7 @@ datasources:
     url: https://example.com/more.json
     header:
       Authorization: ["Bearer abcd1234"]
+    integrity: sha256-uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=
 
 context:
   .:
@@ -61,6 +63,7 @@ pluginTimeout: 2s
 				Header: map[string][]string{
 					"Authorization": {"Bearer abcd1234"},
 				},
+				Integrity: "sha256-uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=",
 			},
 		},
 		Context: map[string]DataSource{
@@ -78,6 +81,29 @@ pluginTimeout: 2s
 	cf, err = Parse(strings.NewReader(in))
 	assert.NoError(t, err)
 	assert.EqualValues(t, expected, cf)
+
+	_, err = Parse(strings.NewReader(`datasources:
+  data:
+    url: https://example.com/data.json
+    integrity: md5-abcd
+`))
+	assert.Error(t, err)
+}
+
+func TestDataSourceMergeFromIntegrity(t *testing.T) {
+	t.Parallel()
+	pinned := DataSource{URL: mustURL("https://example.com/a.json"), Integrity: "sha256-abc"}
+
+	// the pin is kept when the URL is the same
+	out := pinned.mergeFrom(DataSource{URL: mustURL("https://example.com/a.json")})
+	assert.Equal(t, "sha256-abc", out.Integrity)
+
+	// and dropped when it's changed
+	out = pinned.mergeFrom(DataSource{URL: mustURL("https://example.com/b.json")})
+	assert.Equal(t, "", out.Integrity)
+
+	out = pinned.mergeFrom(DataSource{Integrity: "sha256-def"})
+	assert.Equal(t, "sha256-def", out.Integrity)
 }
 
 func mustURL(s string) *url.URL {
@@ -170,6 +196,247 @@ postExec: [echo]
 execPipe: true
 outputMap: foo
 postExec: [echo]
+`))
+
+	// previews are only ever written to stdout
+	assert.Error(t, Config{Input: "foo", ExecPipe: true, PostExec: []string{"echo"}, Preview: true}.Validate())
+	assert.NoError(t, Config{InputDir: "foo", OutputDir: "bar", Preview: true}.Validate())
+
+	assert.NoError(t, validateConfig(`notify:
+  - url: https://example.com/hook
+    format: slack
+    on: [failure, change]
+`))
+
+	assert.Error(t, validateConfig(`notify:
+  - format: slack
+`))
+
+	assert.Error(t, validateConfig(`notify:
+  - url: https://example.com/hook
+    format: xml
+`))
+
+	assert.Error(t, validateConfig(`notify:
+  - url: https://example.com/hook
+    on: [sometimes]
+`))
+
+	assert.NoError(t, validateConfig(`verify: true
+signatures:
+  - url: https://example.com/
+    type: cosign
+    key: cosign.pub
+`))
+
+	assert.Error(t, validateConfig(`signatures:
+  - type: cosign
+    key: cosign.pub
+`))
+
+	assert.Error(t, validateConfig(`signatures:
+  - url: https://example.com/
+    type: x509
+    key: cosign.pub
+`))
+
+	assert.Error(t, validateConfig(`signatures:
+  - url: https://example.com/
+    type: gpg
+`))
+
+	assert.NoError(t, validateConfig(`encrypt:
+  - outputs: ["secrets/*.yaml"]
+    format: sops
+    recipients: [age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p]
+  - outputs: [token.txt]
+`))
+
+	assert.Error(t, validateConfig(`encrypt:
+  - format: age
+`))
+
+	assert.Error(t, validateConfig(`encrypt:
+  - outputs: ["[secrets"]
+`))
+
+	assert.Error(t, validateConfig(`encrypt:
+  - outputs: [token.txt]
+    format: gpg
+`))
+
+	assert.NoError(t, validateConfig(`networkPolicy:
+  allow:
+    - schemes: [https]
+      hosts: [example.com, "*.example.org"]
+    - schemes: [vault]
+`))
+
+	assert.Error(t, validateConfig(`networkPolicy:
+  allow:
+    - hosts: [example.com]
+`))
+
+	assert.Error(t, validateConfig(`networkPolicy:
+  allow:
+    - schemes: [https]
+      hosts: ["[example.com"]
+`))
+
+	assert.NoError(t, validateConfig(`maxConnsPerHost: 8
+rateLimits:
+  - host: api.github.com
+    rate: 10
+    burst: 20
+  - host: "*.example.com"
+    rate: 0.5
+`))
+
+	assert.Error(t, validateConfig(`rateLimits:
+  - rate: 10
+`))
+
+	assert.Error(t, validateConfig(`rateLimits:
+  - host: api.github.com
+`))
+
+	assert.Error(t, validateConfig(`rateLimits:
+  - host: api.github.com
+    rate: 10
+    burst: -1
+`))
+
+	assert.Error(t, validateConfig(`maxConnsPerHost: -1`))
+
+	assert.NoError(t, validateConfig(`credentialHelpers:
+  - host: github.com
+    command: gh
+    args: [auth, git-credential]
+  - host: "*.vault.internal"
+    command: /usr/local/bin/vault-credentials
+`))
+
+	assert.Error(t, validateConfig(`credentialHelpers:
+  - command: gh
+`))
+
+	assert.Error(t, validateConfig(`credentialHelpers:
+  - host: github.com
+`))
+
+	assert.NoError(t, validateConfig(`lock:
+  url: consul+https://consul.example.com/locks/app
+  wait: 30s
+  ttl: 1m
+`))
+
+	assert.Error(t, validateConfig(`lock:
+  url: https://example.com/locks/app
+`))
+
+	assert.Error(t, validateConfig(`lock:
+  url: consul:///
+`))
+
+	assert.Error(t, validateConfig(`lock:
+  url: consul:///locks/app
+  ttl: 1s
+`))
+
+	assert.Error(t, validateConfig(`lock:
+  url: consul:///locks/app
+  wait: -1s
+`))
+
+	assert.NoError(t, validateConfig(`inputDir: in
+matrix:
+  datasource: tenants
+  outputPath: out/{{ .Item.name }}/{{ .in }}
+`))
+
+	assert.Error(t, validateConfig(`matrix:
+  outputPath: out
+`))
+
+	assert.Error(t, validateConfig(`matrix:
+  datasource: tenants
+`))
+
+	assert.Error(t, validateConfig(`inputDir: in
+renderCache: cache.json
+matrix:
+  datasource: tenants
+  outputPath: out
+`))
+
+	assert.Error(t, validateConfig(`inputDir: in
+outputDir: out
+matrix:
+  datasource: tenants
+  outputPath: out
+`))
+
+	assert.NoError(t, validateConfig(`bundle: app.bundle
+outputDir: out
+`))
+
+	assert.NoError(t, validateConfig(`checksums: SHA256SUMS
+contentAddressed: true
+`))
+
+	assert.Error(t, validateConfig(`contentAddressed: true
+`))
+
+	assert.NoError(t, validateConfig(`bundle: app.bundle
+outputFiles: [a, b, c]
+`))
+
+	assert.Error(t, validateConfig(`bundle: app.bundle
+inputDir: in
+`))
+
+	assert.Error(t, validateConfig(`bundle: app.bundle
+templates:
+  - foo.t
+`))
+
+	assert.NoError(t, validateConfig(`schemes:
+  secrets: vault:///kv/data/team/
+  cfg: https://config.example.com/v1/?type=application/json
+`))
+
+	assert.Error(t, validateConfig(`schemes:
+  secrets: kv/data/team/
+`))
+
+	assert.Error(t, validateConfig(`schemes:
+  "not a scheme": vault:///kv/
+`))
+
+	assert.Error(t, validateConfig(`schemes:
+  secrets: vault:///kv/data/team/
+  s: secrets://
+`))
+
+	// snapshots are rendered offline
+	cfg := &Config{Snapshot: "snap/", Notify: []NotifyConfig{{URL: "https://example.com/hook"}}}
+	assert.Error(t, cfg.Validate())
+	cfg = &Config{Snapshot: "snap/", Lock: &LockConfig{URL: "consul:///locks/app"}}
+	assert.Error(t, cfg.Validate())
+	cfg = &Config{Lock: &LockConfig{URL: "consul:///locks/app"}, Notify: []NotifyConfig{{URL: "https://example.com/hook"}}}
+	assert.NoError(t, cfg.Validate())
+
+	assert.NoError(t, validateConfig(`scriptLimits:
+  timeout: 10s
+  memory: 64MiB
+`))
+
+	assert.Error(t, validateConfig(`scriptLimits:
+  memory: lots
+`))
+
+	assert.Error(t, validateConfig(`scriptLimits:
+  callDepth: -1
 `))
 }
 
@@ -384,6 +651,45 @@ func TestMergeFrom(t *testing.T) {
 
 	assert.EqualValues(t, expected, cfg.MergeFrom(other))
 
+	// webhooks from the config file aren't dropped when none are given as flags
+	cfg = &Config{
+		Input:  "hello world",
+		Notify: []NotifyConfig{{URL: "https://example.com/hook"}},
+	}
+	other = &Config{OutputFiles: []string{"out.txt"}}
+	expected = &Config{
+		Input:       "hello world",
+		OutputFiles: []string{"out.txt"},
+		Notify:      []NotifyConfig{{URL: "https://example.com/hook"}},
+	}
+
+	assert.EqualValues(t, expected, cfg.MergeFrom(other))
+
+	// a bundle overrides the config file's inputs
+	cfg = &Config{InputDir: "in", OutputDir: "out"}
+	other = &Config{Bundle: "app.bundle"}
+	expected = &Config{Bundle: "app.bundle", OutputDir: "out"}
+
+	assert.EqualValues(t, expected, cfg.MergeFrom(other))
+
+	// matrix options from flags override the config file's one at a time
+	cfg = &Config{
+		Matrix: &MatrixConfig{Datasource: "tenants", OutputPath: "out/{{ .in }}"},
+	}
+	other = &Config{Matrix: &MatrixConfig{Parallelism: 2}}
+	expected = &Config{
+		Matrix: &MatrixConfig{Datasource: "tenants", OutputPath: "out/{{ .in }}", Parallelism: 2},
+	}
+
+	assert.EqualValues(t, expected, cfg.MergeFrom(other))
+
+	// so do script limits
+	cfg = &Config{ScriptLimits: &ScriptLimits{Timeout: 10 * time.Second, Memory: "64MiB"}}
+	other = &Config{ScriptLimits: &ScriptLimits{Memory: "1GiB"}}
+	expected = &Config{ScriptLimits: &ScriptLimits{Timeout: 10 * time.Second, Memory: "1GiB"}}
+
+	assert.EqualValues(t, expected, cfg.MergeFrom(other))
+
 	// test template merging & a few other things
 	cfg = &Config{
 		InputDir:    "indir/",
@@ -659,6 +965,21 @@ func TestApplyDefaults(t *testing.T) {
 	assert.Equal(t, "{{", cfg.LDelim)
 	assert.Equal(t, "}}", cfg.RDelim)
 
+	cfg = &Config{
+		InputDir: "in",
+		Matrix:   &MatrixConfig{Datasource: "tenants", OutputPath: "out"},
+	}
+
+	cfg.ApplyDefaults()
+	assert.Empty(t, cfg.OutputFiles)
+	assert.Empty(t, cfg.OutputDir)
+
+	cfg = &Config{Bundle: "app.bundle"}
+
+	cfg.ApplyDefaults()
+	assert.Empty(t, cfg.InputFiles)
+	assert.EqualValues(t, []string{"-"}, cfg.OutputFiles)
+
 	cfg = &Config{
 		Input:  "foo",
 		LDelim: "<",
@@ -697,6 +1018,56 @@ func TestApplyDefaults(t *testing.T) {
 	assert.Equal(t, "bar", cfg.OutputMap)
 }
 
+func TestGetCacheLimits(t *testing.T) {
+	c := &Config{}
+	limit, spill, err := c.GetCacheLimits()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), limit)
+	assert.Equal(t, int64(0), spill)
+
+	c = &Config{DatasourceCacheLimit: "512MiB", DatasourceSpillThreshold: "1.5 kb"}
+	limit, spill, err = c.GetCacheLimits()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(512<<20), limit)
+	assert.Equal(t, int64(1500), spill)
+
+	c = &Config{DatasourceCacheLimit: "lots"}
+	_, _, err = c.GetCacheLimits()
+	assert.Error(t, err)
+
+	c = &Config{DatasourceSpillThreshold: "10 parsecs"}
+	_, _, err = c.GetCacheLimits()
+	assert.Error(t, err)
+}
+
+func TestParseByteSize(t *testing.T) {
+	testdata := []struct {
+		in       string
+		expected int64
+	}{
+		{"", 0},
+		{"1024", 1024},
+		{"10b", 10},
+		{"2K", 2048},
+		{"2KB", 2000},
+		{"2KiB", 2048},
+		{"3M", 3 << 20},
+		{"3 MB", 3000000},
+		{"0.5GiB", 1 << 29},
+		{"1G", 1 << 30},
+	}
+	for _, d := range testdata {
+		actual, err := parseByteSize(d.in)
+		assert.NoError(t, err, d.in)
+		assert.Equal(t, d.expected, actual, d.in)
+	}
+
+	for _, in := range []string{"MB", "1..2MB", "-1", "1TB"} {
+		_, err := parseByteSize(in)
+		assert.Error(t, err, in)
+	}
+}
+
 func TestGetMode(t *testing.T) {
 	c := &Config{}
 	m, o, err := c.GetMode()
@@ -792,6 +1163,37 @@ func TestParseSourceURL(t *testing.T) {
 	assert.EqualValues(t, expected, u)
 }
 
+func TestExpandScheme(t *testing.T) {
+	schemes := map[string]string{
+		"secrets": "vault:///kv/data/team/",
+		"cfg":     "https://config.example.com/v1/?type=application/json",
+		"local":   "file:///etc/app/",
+	}
+
+	testdata := []struct {
+		in, expected string
+	}{
+		{"secrets://db", "vault:///kv/data/team/db"},
+		{"secrets:///db/password", "vault:///kv/data/team/db/password"},
+		{"secrets:db", "vault:///kv/data/team/db"},
+		{"secrets://", "vault:///kv/data/team/"},
+		{"cfg://apps/web", "https://config.example.com/v1/apps/web?type=application/json"},
+		{"cfg://apps/web?type=text/plain&env=prod", "https://config.example.com/v1/apps/web?env=prod&type=text%2Fplain"},
+		{"local:///app.yaml#frag", "file:///etc/app/app.yaml#frag"},
+		{"vault:///kv/other", "vault:///kv/other"},
+		{"file:///tmp/foo.json", "file:///tmp/foo.json"},
+	}
+	for _, d := range testdata {
+		u, err := url.Parse(d.in)
+		require.NoError(t, err)
+		assert.Equal(t, d.expected, ExpandScheme(schemes, u).String(), d.in)
+	}
+
+	assert.Nil(t, ExpandScheme(schemes, nil))
+	u, _ := url.Parse("secrets://db")
+	assert.Equal(t, u, ExpandScheme(nil, u))
+}
+
 func TestAbsFileURL(t *testing.T) {
 	cwd, _ := os.Getwd()
 	// make this pass on Windows
@@ -927,4 +1329,35 @@ pipe: true
 		Timeout: time.Duration(10) * time.Millisecond,
 		Pipe:    true,
 	}, out)
+	in = `cmd: foo
+protocol: grpc
+`
+	out = PluginConfig{}
+	err = yaml.Unmarshal([]byte(in), &out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, PluginConfig{Cmd: "foo", Protocol: "grpc"}, out)
+
+	in = `cmd: foo
+protocol: http
+`
+	out = PluginConfig{}
+	err = yaml.Unmarshal([]byte(in), &out)
+	assert.ErrorContains(t, err, "invalid protocol")
+
+	in = `cmd: foo
+persistent: true
+pipe: true
+`
+	out = PluginConfig{}
+	err = yaml.Unmarshal([]byte(in), &out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, PluginConfig{Cmd: "foo", Persistent: true, Pipe: true}, out)
+
+	in = `cmd: foo
+persistent: true
+protocol: grpc
+`
+	out = PluginConfig{}
+	err = yaml.Unmarshal([]byte(in), &out)
+	assert.ErrorContains(t, err, "always persistent")
 }
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/hairyhenderson/gomplate/v3/env"
+	"github.com/hairyhenderson/yaml"
+)
+
+// interpolation - "$$" (an escaped "$"), or a "${VAR}" reference, optionally
+// with a default ("${VAR:-default}", "${VAR-default}") or a required marker
+// ("${VAR:?message}", "${VAR?message}")
+var interpolation = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?])([^}]*))?\}`)
+
+// Interpolate replaces "${VAR}" references in s with the values of the
+// environment variables (or the contents of the files named by VAR_FILE, as
+// with the getenv function). These forms are supported:
+//
+//   - ${VAR} - the value, or "" when VAR is unset
+//   - ${VAR:-default} - the default when VAR is unset or empty
+//   - ${VAR-default} - the default when VAR is unset
+//   - ${VAR:?message} - an error (with the message) when VAR is unset or empty
+//   - ${VAR?message} - an error (with the message) when VAR is unset
+//
+// "$$" is a literal "$", so "$${VAR}" isn't replaced. Other uses of "$" are
+// left as they are.
+func Interpolate(s string) (string, error) {
+	var err error
+	out := interpolation.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" || err != nil {
+			return "$"
+		}
+		sub := interpolation.FindStringSubmatch(m)
+		name, op, arg := sub[1], sub[2], sub[3]
+
+		v, set := lookupEnv(name)
+		empty := !set || (v == "" && op != "" && op[0] == ':')
+		switch {
+		case !empty:
+			return v
+		case op == ":-" || op == "-":
+			return arg
+		case op == ":?" || op == "?":
+			if arg == "" {
+				arg = "required variable is not set"
+				if set {
+					arg = "required variable is empty"
+				}
+			}
+			err = fmt.Errorf("%s: %s", name, arg)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// lookupEnv - like os.LookupEnv, but with support for VAR_FILE
+func lookupEnv(name string) (string, bool) {
+	if v := env.Getenv(name); v != "" {
+		return v, true
+	}
+	_, ok := os.LookupEnv(name)
+	return "", ok
+}
+
+// interpolateNode interpolates environment variables into all of the scalar
+// values in the node (but not map keys)
+func interpolateNode(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := interpolateNode(c); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := interpolateNode(n.Content[i]); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		v, err := Interpolate(n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		if v != n.Value {
+			n.Value = v
+			// unquoted values are resolved again, so that "${PORT}" can be
+			// an int (for example)
+			if n.Style == 0 {
+				n.Tag = ""
+			}
+		}
+	}
+	return nil
+}
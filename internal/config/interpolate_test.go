@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("INTERP_SET", "foo")
+	t.Setenv("INTERP_EMPTY", "")
+	os.Unsetenv("INTERP_UNSET")
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("hunter2\n"), 0o600))
+	t.Setenv("INTERP_FROMFILE_FILE", secret)
+
+	testdata := []struct {
+		in, expected string
+	}{
+		{"", ""},
+		{"no vars", "no vars"},
+		{"${INTERP_SET}", "foo"},
+		{"https://${INTERP_SET}.example.com/${INTERP_SET}.json", "https://foo.example.com/foo.json"},
+		{"${INTERP_UNSET}", ""},
+		{"${INTERP_UNSET:-bar}", "bar"},
+		{"${INTERP_EMPTY:-bar}", "bar"},
+		{"${INTERP_SET:-bar}", "foo"},
+		{"${INTERP_UNSET-bar}", "bar"},
+		{"${INTERP_EMPTY-bar}", ""},
+		{"${INTERP_UNSET:-}", ""},
+		{"${INTERP_UNSET:-vault:///a/b}", "vault:///a/b"},
+		{"${INTERP_EMPTY?}", ""},
+		{"${INTERP_FROMFILE}", "hunter2"},
+		{"$${INTERP_SET}", "${INTERP_SET}"},
+		{"$$", "$"},
+		{"$INTERP_SET and ${not a var} and ${INTERP_SET", "$INTERP_SET and ${not a var} and ${INTERP_SET"},
+	}
+	for _, d := range testdata {
+		out, err := Interpolate(d.in)
+		require.NoError(t, err, d.in)
+		assert.Equal(t, d.expected, out, d.in)
+	}
+
+	_, err := Interpolate("${INTERP_UNSET:?must be set to the environment name}")
+	assert.EqualError(t, err, "INTERP_UNSET: must be set to the environment name")
+
+	_, err = Interpolate("${INTERP_EMPTY:?}")
+	assert.EqualError(t, err, "INTERP_EMPTY: required variable is empty")
+
+	_, err = Interpolate("${INTERP_UNSET?}")
+	assert.EqualError(t, err, "INTERP_UNSET: required variable is not set")
+}
+
+func TestParse_Interpolation(t *testing.T) {
+	t.Setenv("INTERP_ENV", "staging")
+	t.Setenv("INTERP_TOKEN", "abc123")
+	t.Setenv("INTERP_CONNS", "4")
+	os.Unsetenv("INTERP_UNSET")
+
+	in := `datasources:
+  config:
+    url: https://config.${INTERP_ENV}.example.com/app.json
+    header:
+      Authorization: ["Bearer ${INTERP_TOKEN}"]
+maxConnsPerHost: ${INTERP_CONNS}
+outputFiles: ["${INTERP_UNSET:-out}.txt"]
+postExec: [sh, -c, "echo $${HOME}"]
+`
+	cfg, err := Parse(strings.NewReader(in))
+	require.NoError(t, err)
+	assert.Equal(t, "https://config.staging.example.com/app.json", cfg.DataSources["config"].URL.String())
+	assert.Equal(t, http.Header{"Authorization": {"Bearer abc123"}}, cfg.DataSources["config"].Header)
+	assert.Equal(t, 4, cfg.MaxConnsPerHost)
+	assert.Equal(t, []string{"out.txt"}, cfg.OutputFiles)
+	assert.Equal(t, []string{"sh", "-c", "echo ${HOME}"}, cfg.PostExec)
+
+	_, err = Parse(strings.NewReader("in: hello\noutputDir: ${INTERP_UNSET:?set the output dir}\n"))
+	assert.EqualError(t, err, "failed to interpolate environment variables: line 2: INTERP_UNSET: set the output dir")
+}
@@ -0,0 +1,309 @@
+// Package conjur is a minimal client for CyberArk Conjur's REST API, for the
+// conjur: datasource.
+package conjur
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+)
+
+// ErrNotFound - the variable doesn't exist, or has no value
+var ErrNotFound = errors.New("not found")
+
+// default path of the Kubernetes service account token, used as the JWT when
+// none is given
+const serviceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// tokens are valid for 8 minutes - refresh them well before then
+const tokenLifetime = 5 * time.Minute
+
+// Client - a Conjur client, authenticated with the first of these that's
+// configured:
+//
+//   - an access token in the file named by CONJUR_AUTHN_TOKEN_FILE (as written by
+//     Conjur's Kubernetes authenticator)
+//   - the JWT authenticator named by CONJUR_AUTHN_JWT_SERVICE_ID, with the JWT
+//     in CONJUR_AUTHN_JWT_TOKEN, or in the file named by JWT_TOKEN_PATH
+//     (defaulting to the Kubernetes service account token). The host to
+//     authenticate as can be set with CONJUR_AUTHN_JWT_HOST_ID.
+//   - the AWS IAM authenticator named by CONJUR_AUTHN_IAM_SERVICE_ID, as the
+//     host in CONJUR_AUTHN_LOGIN, with the AWS credentials found by the AWS SDK
+//   - the API key in CONJUR_AUTHN_API_KEY, with CONJUR_AUTHN_LOGIN
+type Client struct {
+	// Base - the Conjur appliance URL
+	Base    *url.URL
+	Account string
+
+	hc *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// New creates a client for the Conjur server in the URL's host (over HTTPS),
+// or in CONJUR_APPLIANCE_URL. The account is read from CONJUR_ACCOUNT, and
+// CONJUR_CERT_FILE can name a CA certificate for verifying the server.
+func New(ctx context.Context, u *url.URL) (*Client, error) {
+	base := &url.URL{Scheme: "https", Host: u.Host}
+	if u.Host == "" {
+		addr := os.Getenv("CONJUR_APPLIANCE_URL")
+		if addr == "" {
+			return nil, errors.New("no Conjur server given in the URL or CONJUR_APPLIANCE_URL")
+		}
+		var err error
+		base, err = url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CONJUR_APPLIANCE_URL: %w", err)
+		}
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+
+	account := os.Getenv("CONJUR_ACCOUNT")
+	if account == "" {
+		return nil, errors.New("CONJUR_ACCOUNT must be set")
+	}
+
+	rt := netpolicy.FromContext(ctx).Transport(u.Scheme)
+	if cert := os.Getenv("CONJUR_CERT_FILE"); cert != "" {
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, errors.New("CONJUR_CERT_FILE can't be used with this HTTP transport")
+		}
+		b, err := os.ReadFile(cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CONJUR_CERT_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", cert)
+		}
+		t = t.Clone()
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		rt = t
+	}
+
+	return &Client{
+		Base:    base,
+		Account: account,
+		hc:      &http.Client{Timeout: 30 * time.Second, Transport: rt},
+		now:     time.Now,
+	}, nil
+}
+
+// escape a path segment, including any '/' characters (as in host IDs)
+func escape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "/", "%2F")
+}
+
+func (c *Client) url(p string) string {
+	return c.Base.String() + p
+}
+
+// authenticate returns a (base64-encoded) access token, from the cache when
+// it's fresh enough
+func (c *Client) authenticate(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f := os.Getenv("CONJUR_AUTHN_TOKEN_FILE"); f != "" {
+		// the file is kept fresh by something else, so it's never cached
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return "", fmt.Errorf("failed to read CONJUR_AUTHN_TOKEN_FILE: %w", err)
+		}
+		return encodeToken(b), nil
+	}
+
+	if c.token != "" && c.now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	req, err := c.authnRequest(ctx)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept-Encoding", "base64")
+
+	b, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("conjur authentication failed: %w", err)
+	}
+	c.token = string(b)
+	c.expires = c.now().Add(tokenLifetime)
+	return c.token, nil
+}
+
+// encodeToken base64-encodes a raw JSON access token - tokens that are
+// already encoded are returned as-is
+func encodeToken(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return string(b)
+}
+
+func (c *Client) authnRequest(ctx context.Context) (*http.Request, error) {
+	if svc := os.Getenv("CONJUR_AUTHN_JWT_SERVICE_ID"); svc != "" {
+		jwt := os.Getenv("CONJUR_AUTHN_JWT_TOKEN")
+		if jwt == "" {
+			p := os.Getenv("JWT_TOKEN_PATH")
+			if p == "" {
+				p = serviceAccountToken
+			}
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read JWT: %w", err)
+			}
+			jwt = strings.TrimSpace(string(b))
+		}
+
+		p := "/authn-jwt/" + escape(svc) + "/" + escape(c.Account)
+		if host := os.Getenv("CONJUR_AUTHN_JWT_HOST_ID"); host != "" {
+			p += "/" + escape(host)
+		}
+		body := url.Values{"jwt": []string{jwt}}.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(p+"/authenticate"), strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}
+
+	login := os.Getenv("CONJUR_AUTHN_LOGIN")
+	if login == "" {
+		return nil, errors.New("no Conjur credentials found - set CONJUR_AUTHN_LOGIN and CONJUR_AUTHN_API_KEY, or configure a JWT or IAM authenticator")
+	}
+
+	if svc := os.Getenv("CONJUR_AUTHN_IAM_SERVICE_ID"); svc != "" {
+		body, err := signedIdentityRequest(c.now())
+		if err != nil {
+			return nil, err
+		}
+		p := "/authn-iam/" + escape(svc) + "/" + escape(c.Account) + "/" + escape(login) + "/authenticate"
+		return http.NewRequestWithContext(ctx, http.MethodPost, c.url(p), bytes.NewReader(body))
+	}
+
+	key := os.Getenv("CONJUR_AUTHN_API_KEY")
+	if key == "" {
+		return nil, errors.New("CONJUR_AUTHN_API_KEY must be set when authenticating with an API key")
+	}
+	p := "/authn/" + escape(c.Account) + "/" + escape(login) + "/authenticate"
+	return http.NewRequestWithContext(ctx, http.MethodPost, c.url(p), strings.NewReader(key))
+}
+
+// signedIdentityRequest - the headers of an STS GetCallerIdentity request,
+// signed with the AWS SDK's credentials, which the IAM authenticator uses to
+// verify the caller's identity
+func signedIdentityRequest(now time.Time) ([]byte, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://sts.amazonaws.com/?Action=GetCallerIdentity&Version=2011-06-15", nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := v4.NewSigner(sess.Config.Credentials).Sign(req, nil, "sts", "us-east-1", now); err != nil {
+		return nil, fmt.Errorf("failed to sign AWS identity request: %w", err)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k := range req.Header {
+		headers[strings.ToLower(k)] = req.Header.Get(k)
+	}
+	return json.Marshal(headers)
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %d from %s %s: %s", res.StatusCode, req.Method, req.URL.Path, bytes.TrimSpace(b))
+	}
+	return b, nil
+}
+
+func (c *Client) get(ctx context.Context, p string) ([]byte, error) {
+	token, err := c.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(p), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Token token=%q", token))
+	return c.do(req)
+}
+
+// Secret - the value of the variable
+func (c *Client) Secret(ctx context.Context, id string) ([]byte, error) {
+	b, err := c.get(ctx, "/secrets/"+escape(c.Account)+"/variable/"+url.PathEscape(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variable %s: %w", id, err)
+	}
+	return b, nil
+}
+
+// List - the IDs of the variables starting with the prefix, relative to it
+func (c *Client) List(ctx context.Context, prefix string) ([]string, error) {
+	q := url.Values{"search": []string{prefix}}
+	b, err := c.get(ctx, "/resources/"+escape(c.Account)+"/variable?"+q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variables in %s: %w", prefix, err)
+	}
+
+	resources := []struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal(b, &resources); err != nil {
+		return nil, fmt.Errorf("invalid Conjur resource list: %w", err)
+	}
+
+	// IDs are fully-qualified, like "account:variable:prod/db/password"
+	qualified := c.Account + ":variable:" + prefix
+	ids := []string{}
+	for _, r := range resources {
+		if strings.HasPrefix(r.ID, qualified) {
+			ids = append(ids, strings.TrimPrefix(r.ID, qualified))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,189 @@
+package conjur
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConjur - authenticates with any of the supported authenticators, and
+// serves variables in the "myorg" account
+type fakeConjur struct {
+	t      *testing.T
+	authns []string
+	vars   map[string]string
+}
+
+const fakeToken = `{"protected":"x","payload":"y","signature":"z"}`
+
+func (f *fakeConjur) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.EscapedPath()
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(p, "/authenticate"):
+		body, _ := io.ReadAll(r.Body)
+		ok := false
+		switch {
+		case p == "/authn/myorg/host%2Fapp/authenticate":
+			ok = string(body) == "apikey"
+		case p == "/authn-jwt/k8s/myorg/authenticate":
+			form, _ := url.ParseQuery(string(body))
+			ok = form.Get("jwt") == "a.jwt.token" && r.Header.Get("Content-Type") == "application/x-www-form-urlencoded"
+		case strings.HasPrefix(p, "/authn-iam/prod/myorg/host%2Fapp"):
+			headers := map[string]string{}
+			_ = json.Unmarshal(body, &headers)
+			ok = headers["host"] == "sts.amazonaws.com" && strings.Contains(headers["authorization"], "AKIDEXAMPLE")
+		}
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.authns = append(f.authns, p)
+		assert.Equal(f.t, "base64", r.Header.Get("Accept-Encoding"))
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString([]byte(fakeToken))))
+	case r.Header.Get("Authorization") != `Token token="`+base64.StdEncoding.EncodeToString([]byte(fakeToken))+`"`:
+		w.WriteHeader(http.StatusUnauthorized)
+	case strings.HasPrefix(p, "/secrets/myorg/variable/"):
+		v, ok := f.vars[strings.TrimPrefix(r.URL.Path, "/secrets/myorg/variable/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(v))
+	case p == "/resources/myorg/variable":
+		out := []map[string]string{}
+		for k := range f.vars {
+			if strings.Contains(k, r.URL.Query().Get("search")) {
+				out = append(out, map[string]string{"id": "myorg:variable:" + k})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupFake(t *testing.T) (*fakeConjur, *Client) {
+	f := &fakeConjur{t: t, vars: map[string]string{
+		"prod/db/password": "s3cr3t",
+		"prod/db/username": "app",
+		"staging/prod/db":  "nope",
+	}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	for k, v := range map[string]string{
+		"CONJUR_APPLIANCE_URL": srv.URL + "/",
+		"CONJUR_ACCOUNT":       "myorg",
+	} {
+		t.Setenv(k, v)
+	}
+
+	c, err := New(context.Background(), &url.URL{Scheme: "conjur", Path: "/prod/db/password"})
+	require.NoError(t, err)
+	return f, c
+}
+
+func TestAPIKey(t *testing.T) {
+	t.Setenv("CONJUR_AUTHN_LOGIN", "host/app")
+	t.Setenv("CONJUR_AUTHN_API_KEY", "apikey")
+	f, c := setupFake(t)
+	ctx := context.Background()
+
+	v, err := c.Secret(ctx, "prod/db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(v))
+
+	_, err = c.Secret(ctx, "prod/db/missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	ids, err := c.List(ctx, "prod/db/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "username"}, ids)
+
+	// the token is cached until it's close to expiring
+	assert.Len(t, f.authns, 1)
+	now := time.Now()
+	c.now = func() time.Time { return now.Add(6 * time.Minute) }
+	_, err = c.Secret(ctx, "prod/db/password")
+	require.NoError(t, err)
+	assert.Len(t, f.authns, 2)
+}
+
+func TestBadCredentials(t *testing.T) {
+	t.Setenv("CONJUR_AUTHN_LOGIN", "host/app")
+	t.Setenv("CONJUR_AUTHN_API_KEY", "wrong")
+	_, c := setupFake(t)
+
+	_, err := c.Secret(context.Background(), "prod/db/password")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication failed")
+}
+
+func TestJWT(t *testing.T) {
+	jwt := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(jwt, []byte("a.jwt.token\n"), 0o600))
+	t.Setenv("CONJUR_AUTHN_JWT_SERVICE_ID", "k8s")
+	t.Setenv("JWT_TOKEN_PATH", jwt)
+	f, c := setupFake(t)
+
+	v, err := c.Secret(context.Background(), "prod/db/username")
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(v))
+	assert.Equal(t, []string{"/authn-jwt/k8s/myorg/authenticate"}, f.authns)
+}
+
+func TestIAM(t *testing.T) {
+	t.Setenv("CONJUR_AUTHN_IAM_SERVICE_ID", "prod")
+	t.Setenv("CONJUR_AUTHN_LOGIN", "host/app")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	f, c := setupFake(t)
+
+	v, err := c.Secret(context.Background(), "prod/db/username")
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(v))
+	assert.Len(t, f.authns, 1)
+}
+
+func TestTokenFile(t *testing.T) {
+	tok := filepath.Join(t.TempDir(), "access-token")
+	require.NoError(t, os.WriteFile(tok, []byte(fakeToken), 0o600))
+	t.Setenv("CONJUR_AUTHN_TOKEN_FILE", tok)
+	f, c := setupFake(t)
+
+	v, err := c.Secret(context.Background(), "prod/db/username")
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(v))
+	assert.Empty(t, f.authns)
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("CONJUR_APPLIANCE_URL", "")
+	t.Setenv("CONJUR_ACCOUNT", "myorg")
+
+	_, err := New(context.Background(), &url.URL{Scheme: "conjur", Path: "/a"})
+	assert.Error(t, err)
+
+	c, err := New(context.Background(), &url.URL{Scheme: "conjur", Host: "conjur.example.com", Path: "/a"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://conjur.example.com", c.Base.String())
+
+	t.Setenv("CONJUR_ACCOUNT", "")
+	_, err = New(context.Background(), &url.URL{Scheme: "conjur", Host: "conjur.example.com", Path: "/a"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+// Package credhelper gets the credentials for datasources from external
+// commands, so that long-lived secrets can be kept in a keychain or password
+// manager, rather than in environment variables or config files. Helpers use
+// git's credential helper protocol (see git-credential(1)), so the many helpers
+// written for git can be used.
+package credhelper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+)
+
+// Credentials - the credentials a helper supplied. When Username is empty,
+// Password is a token.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Helpers - runs the helper configured for each host. Credentials are only
+// fetched once for each protocol and host, since they're only needed for as
+// long as gomplate runs.
+type Helpers struct {
+	helpers []config.CredentialHelper
+
+	mu    sync.Mutex
+	creds map[string]*Credentials
+}
+
+// New creates Helpers from the config - when there are none, it returns nil,
+// which never supplies credentials
+func New(helpers []config.CredentialHelper) *Helpers {
+	if len(helpers) == 0 {
+		return nil
+	}
+	return &Helpers{helpers: helpers, creds: map[string]*Credentials{}}
+}
+
+// Get returns the credentials for the protocol (like "https") and host (which
+// may have a port), or nil if no helper is configured for the host, or the
+// helper had no credentials. When more than one helper matches the host, the
+// first is used.
+func (h *Helpers) Get(ctx context.Context, protocol, host string) (*Credentials, error) {
+	if h == nil || host == "" {
+		return nil, nil
+	}
+
+	name := strings.ToLower(host)
+	if n, _, err := net.SplitHostPort(name); err == nil {
+		name = n
+	}
+
+	var helper *config.CredentialHelper
+	for i := range h.helpers {
+		if netpolicy.MatchHost(h.helpers[i].Host, name) {
+			helper = &h.helpers[i]
+			break
+		}
+	}
+	if helper == nil {
+		return nil, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := protocol + "://" + host
+	if c, ok := h.creds[key]; ok {
+		return c, nil
+	}
+	c, err := run(ctx, helper, protocol, host)
+	if err != nil {
+		return nil, err
+	}
+	h.creds[key] = c
+	return c, nil
+}
+
+// run runs the helper's 'get' action, which reads the protocol and host from
+// stdin, and prints the credentials as 'key=value' lines
+func run(ctx context.Context, helper *config.CredentialHelper, protocol, host string) (*Credentials, error) {
+	args := append(append([]string{}, helper.Args...), "get")
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, helper.Command, args...)
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\n\n", protocol, host))
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s for %s failed: %w: %s", helper.Command, host, err, strings.TrimSpace(stderr.String()))
+	}
+
+	c := &Credentials{}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "username":
+			c.Username = v
+		case "password":
+			c.Password = v
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("invalid output from credential helper %s: %w", helper.Command, err)
+	}
+	if c.Password == "" {
+		return nil, nil
+	}
+	return c, nil
+}
@@ -0,0 +1,81 @@
+package credhelper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helpers are shell scripts")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+
+	// the helper records its args and input, and supplies credentials for
+	// the host
+	helper := filepath.Join(dir, "helper")
+	require.NoError(t, os.WriteFile(helper, []byte(`#!/bin/sh
+input=$(cat)
+echo "$* $input" | tr '\n' ' ' >> `+calls+`
+echo >> `+calls+`
+case "$input" in
+  *host=git.example.com*) echo "username=alice"; echo "password=s3cret" ;;
+  *host=vault.example.com*) echo "password=hvs.token" ;;
+  *host=fail.example.com*) echo "oops" >&2; exit 1 ;;
+esac
+`), 0o755))
+
+	assert.Nil(t, New(nil))
+	var h *Helpers
+	c, err := h.Get(ctx, "https", "git.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	h = New([]config.CredentialHelper{
+		{Host: "*.example.com", Command: helper, Args: []string{"--store", "test"}},
+	})
+
+	c, err = h.Get(ctx, "https", "git.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Username: "alice", Password: "s3cret"}, c)
+
+	// credentials are only fetched once
+	c, err = h.Get(ctx, "https", "git.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Username: "alice", Password: "s3cret"}, c)
+
+	c, err = h.Get(ctx, "https", "vault.example.com:8200")
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Password: "hvs.token"}, c)
+
+	// no credentials from the helper
+	c, err = h.Get(ctx, "https", "other.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	// no helper for the host
+	c, err = h.Get(ctx, "https", "example.org")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	_, err = h.Get(ctx, "https", "fail.example.com")
+	assert.ErrorContains(t, err, "oops")
+
+	b, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "--store test get protocol=https host=git.example.com ", lines[0])
+	assert.Equal(t, "--store test get protocol=https host=vault.example.com:8200 ", lines[1])
+}
@@ -2,13 +2,79 @@ package deprecated
 
 import (
 	"context"
+	"sync"
 
 	"github.com/rs/zerolog"
 )
 
 // WarnDeprecated - use this to warn about deprecated template functions or
-// datasources
+// datasources. When the context has a Collector, the warning is collected
+// (to be reported once, after rendering), otherwise it's logged immediately.
 func WarnDeprecated(ctx context.Context, msg string) {
+	if c := CollectorFromContext(ctx); c != nil {
+		c.add(msg)
+		return
+	}
 	logger := zerolog.Ctx(ctx)
 	logger.Warn().Msgf("Deprecated: %s", msg)
 }
+
+// Warning - a deprecation warning, and the number of times it was emitted
+type Warning struct {
+	Message string
+	Count   int
+}
+
+// Collector collects deprecation warnings, so each can be reported once
+type Collector struct {
+	counts map[string]int
+	order  []string
+	mu     sync.Mutex
+}
+
+// NewCollector -
+func NewCollector() *Collector {
+	return &Collector{counts: map[string]int{}}
+}
+
+func (c *Collector) add(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[msg] == 0 {
+		c.order = append(c.order, msg)
+	}
+	c.counts[msg]++
+}
+
+// Warnings - the collected warnings, in the order they were first emitted
+func (c *Collector) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Warning, len(c.order))
+	for i, msg := range c.order {
+		out[i] = Warning{Message: msg, Count: c.counts[msg]}
+	}
+	return out
+}
+
+// Log - logs each collected warning once
+func (c *Collector) Log(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+	for _, w := range c.Warnings() {
+		logger.Warn().Int("count", w.Count).Msgf("Deprecated: %s", w.Message)
+	}
+}
+
+type collectorCtxKey struct{}
+
+// ContextWithCollector returns a context with the collector, so deprecation
+// warnings are collected instead of logged
+func ContextWithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorCtxKey{}, c)
+}
+
+// CollectorFromContext returns the collector from the context, if any
+func CollectorFromContext(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorCtxKey{}).(*Collector)
+	return c
+}
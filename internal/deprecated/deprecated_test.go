@@ -0,0 +1,33 @@
+package deprecated
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarnDeprecated(t *testing.T) {
+	logs := &bytes.Buffer{}
+	ctx := zerolog.New(logs).WithContext(context.Background())
+
+	WarnDeprecated(ctx, "foo is deprecated")
+	assert.Contains(t, logs.String(), "Deprecated: foo is deprecated")
+
+	logs.Reset()
+	c := NewCollector()
+	cctx := ContextWithCollector(ctx, c)
+	WarnDeprecated(cctx, "foo is deprecated")
+	WarnDeprecated(cctx, "bar is deprecated")
+	WarnDeprecated(cctx, "foo is deprecated")
+	assert.Empty(t, logs.String())
+	assert.Equal(t, []Warning{
+		{Message: "foo is deprecated", Count: 2},
+		{Message: "bar is deprecated", Count: 1},
+	}, c.Warnings())
+
+	c.Log(ctx)
+	assert.Equal(t, 2, bytes.Count(logs.Bytes(), []byte("Deprecated:")))
+}
@@ -0,0 +1,129 @@
+// Package doppler is a minimal client for the Doppler secrets manager's API,
+// for the doppler: datasource.
+package doppler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// ErrNotFound - the project, config, or secret doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// DefaultBase - the Doppler API's URL
+const DefaultBase = "https://api.doppler.com"
+
+// Client - a Doppler API client
+type Client struct {
+	// Base - the API's URL, DefaultBase unless DOPPLER_API_HOST is set
+	Base  string
+	Token string
+
+	hc *http.Client
+}
+
+// New creates a client, authenticating with the token, or with DOPPLER_TOKEN
+// when it's empty
+func New(hc *http.Client, token string) (*Client, error) {
+	if token == "" {
+		token = os.Getenv("DOPPLER_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("a Doppler token must be set in DOPPLER_TOKEN, or with an Authorization header")
+	}
+
+	base := os.Getenv("DOPPLER_API_HOST")
+	if base == "" {
+		base = DefaultBase
+	}
+	return &Client{Base: base, Token: token, hc: hc}, nil
+}
+
+func (c *Client) get(ctx context.Context, p string, q url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Base+p+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return b, nil
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+
+	// errors are described in a "messages" array
+	e := struct {
+		Messages []string `json:"messages"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && len(e.Messages) > 0 {
+		return nil, fmt.Errorf("doppler API error (HTTP %d): %s", res.StatusCode, e.Messages[0])
+	}
+	return nil, fmt.Errorf("unexpected HTTP status %d from Doppler API: %s", res.StatusCode, bytes.TrimSpace(b))
+}
+
+// scope adds the project and config to the query, when they're set (service
+// tokens are already scoped to a config)
+func scope(project, config string) url.Values {
+	q := url.Values{}
+	if project != "" {
+		q.Set("project", project)
+	}
+	if config != "" {
+		q.Set("config", config)
+	}
+	return q
+}
+
+// Download all of the config's secrets, in "json" or "env" format
+func (c *Client) Download(ctx context.Context, project, config, format string) ([]byte, error) {
+	q := scope(project, config)
+	q.Set("format", format)
+	b, err := c.get(ctx, "/v3/configs/config/secrets/download", q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download secrets: %w", err)
+	}
+	return b, nil
+}
+
+// Secret - the (computed) value of one of the config's secrets
+func (c *Client) Secret(ctx context.Context, project, config, name string) ([]byte, error) {
+	q := scope(project, config)
+	q.Set("name", name)
+	b, err := c.get(ctx, "/v3/configs/config/secret", q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+
+	resp := struct {
+		Value struct {
+			Computed *string `json:"computed"`
+		} `json:"value"`
+	}{}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("invalid Doppler API response: %w", err)
+	}
+	if resp.Value.Computed == nil {
+		return nil, fmt.Errorf("secret %s has no value: %w", name, ErrNotFound)
+	}
+	return []byte(*resp.Value.Computed), nil
+}
@@ -0,0 +1,89 @@
+package doppler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFake(t *testing.T) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dp.st.test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"messages": ["Invalid Auth token"], "success": false}`))
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/v3/configs/config/secrets/download":
+			if q.Get("project") != "app" || q.Get("config") != "prd" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if q.Get("format") == "env" {
+				_, _ = w.Write([]byte("DB_PASSWORD=\"s3cr3t\"\n"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"DB_PASSWORD": "s3cr3t"}`))
+		case "/v3/configs/config/secret":
+			switch q.Get("name") {
+			case "DB_PASSWORD":
+				_, _ = w.Write([]byte(`{"name": "DB_PASSWORD", "value": {"raw": "${OTHER}", "computed": "s3cr3t"}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"messages": ["Could not find requested secret"]}`))
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("DOPPLER_API_HOST", srv.URL)
+	t.Setenv("DOPPLER_TOKEN", "dp.st.test")
+
+	c, err := New(srv.Client(), "")
+	require.NoError(t, err)
+	return c
+}
+
+func TestDoppler(t *testing.T) {
+	c := setupFake(t)
+	ctx := context.Background()
+
+	b, err := c.Download(ctx, "app", "prd", "json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"DB_PASSWORD": "s3cr3t"}`, string(b))
+
+	b, err = c.Download(ctx, "app", "prd", "env")
+	require.NoError(t, err)
+	assert.Equal(t, "DB_PASSWORD=\"s3cr3t\"\n", string(b))
+
+	_, err = c.Download(ctx, "app", "dev", "json")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	b, err = c.Secret(ctx, "app", "prd", "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(b))
+
+	_, err = c.Secret(ctx, "app", "prd", "MISSING")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	c.Token = "wrong"
+	_, err = c.Secret(ctx, "app", "prd", "DB_PASSWORD")
+	assert.EqualError(t, err, "failed to read secret DB_PASSWORD: doppler API error (HTTP 401): Invalid Auth token")
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("DOPPLER_TOKEN", "")
+	t.Setenv("DOPPLER_API_HOST", "")
+	_, err := New(http.DefaultClient, "")
+	assert.Error(t, err)
+
+	c, err := New(http.DefaultClient, "dp.pt.abc")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBase, c.Base)
+	assert.Equal(t, "dp.pt.abc", c.Token)
+}
@@ -0,0 +1,165 @@
+// Package elastic is a minimal client for searching Elasticsearch and
+// OpenSearch, for the es: datasource.
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+)
+
+// Client - an Elasticsearch (or OpenSearch) client
+type Client struct {
+	// Base - the cluster's URL, like https://localhost:9200
+	Base string
+
+	auth http.Header
+	hc   *http.Client
+}
+
+// New creates a client for the cluster in the URL's host (over HTTPS, or HTTP
+// with the es+http scheme), or in ES_URL. It authenticates with the API key in
+// ES_API_KEY, or the username and password in the URL (or ES_USERNAME and
+// ES_PASSWORD). ES_CA_CERT can name a CA certificate for verifying the server.
+func New(ctx context.Context, u *url.URL) (*Client, error) {
+	base := os.Getenv("ES_URL")
+	if u.Host != "" {
+		scheme := "https"
+		if u.Scheme == "es+http" {
+			scheme = "http"
+		}
+		base = scheme + "://" + u.Host
+	}
+	if base == "" {
+		return nil, errors.New("no Elasticsearch host given in the URL or ES_URL")
+	}
+
+	auth := http.Header{}
+	if key := os.Getenv("ES_API_KEY"); key != "" {
+		auth.Set("Authorization", "ApiKey "+key)
+	} else {
+		user, pass := os.Getenv("ES_USERNAME"), os.Getenv("ES_PASSWORD")
+		if u.User != nil {
+			user = u.User.Username()
+			pass, _ = u.User.Password()
+		}
+		if user != "" {
+			auth.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+		}
+	}
+
+	rt := netpolicy.FromContext(ctx).Transport(u.Scheme)
+	if cert := os.Getenv("ES_CA_CERT"); cert != "" {
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, errors.New("ES_CA_CERT can't be used with this HTTP transport")
+		}
+		b, err := os.ReadFile(cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ES_CA_CERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", cert)
+		}
+		t = t.Clone()
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		rt = t
+	}
+
+	return &Client{
+		Base: strings.TrimSuffix(base, "/"),
+		auth: auth,
+		hc:   &http.Client{Timeout: 30 * time.Second, Transport: rt},
+	}, nil
+}
+
+// Search runs the query (in the query DSL, as JSON) against the index (which
+// can be a comma-separated list, and contain wildcards), returning the hits.
+// With full, the hits are returned as-is, with their metadata (_index, _id,
+// _score, etc) and the document in _source. Otherwise, only the documents are
+// returned.
+func (c *Client) Search(ctx context.Context, index string, query []byte, full bool) ([]json.RawMessage, error) {
+	if len(bytes.TrimSpace(query)) == 0 {
+		query = []byte(`{"query": {"match_all": {}}}`)
+	}
+	if !json.Valid(query) {
+		return nil, errors.New("the query must be valid JSON, in the query DSL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Base+"/"+url.PathEscape(index)+"/_search", bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.auth {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		e := struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}{}
+		if json.Unmarshal(b, &e) == nil && e.Error.Reason != "" {
+			return nil, fmt.Errorf("search of %s failed (HTTP %d): %s: %s", index, res.StatusCode, e.Error.Type, e.Error.Reason)
+		}
+		return nil, fmt.Errorf("search of %s failed with unexpected HTTP status %d: %s", index, res.StatusCode, bytes.TrimSpace(b))
+	}
+
+	resp := struct {
+		Hits struct {
+			Hits []json.RawMessage `json:"hits"`
+		} `json:"hits"`
+	}{}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("invalid search response: %w", err)
+	}
+
+	hits := resp.Hits.Hits
+	if hits == nil {
+		hits = []json.RawMessage{}
+	}
+	if full {
+		return hits, nil
+	}
+	for i, h := range hits {
+		doc := struct {
+			Source json.RawMessage `json:"_source"`
+		}{}
+		if err := json.Unmarshal(h, &doc); err != nil {
+			return nil, fmt.Errorf("invalid search response: %w", err)
+		}
+		// documents can be excluded from hits with "_source": false
+		if doc.Source == nil {
+			doc.Source = json.RawMessage(`{}`)
+		}
+		hits[i] = doc.Source
+	}
+	return hits, nil
+}
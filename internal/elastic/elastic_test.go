@@ -0,0 +1,124 @@
+package elastic
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logs-*/_search":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			b, _ := io.ReadAll(r.Body)
+			if string(b) == `{"query": {"match_all": {}}}` {
+				_, _ = w.Write([]byte(`{"hits": {"total": {"value": 0}, "hits": []}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"hits": {"total": {"value": 2}, "hits": [
+				{"_index": "logs-1", "_id": "a", "_score": 1.5, "_source": {"level": "error", "n": 1}},
+				{"_index": "logs-2", "_id": "b", "_score": 1.2}
+			]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"type": "index_not_found_exception", "reason": "no such index [nope]"}, "status": 404}`))
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv := httptest.NewServer(searchHandler(t))
+	defer srv.Close()
+
+	t.Setenv("ES_API_KEY", "")
+	t.Setenv("ES_USERNAME", "")
+	c, err := New(context.Background(), &url.URL{Scheme: "es+http", Host: srv.Listener.Addr().String()})
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL, c.Base)
+	ctx := context.Background()
+
+	hits, err := c.Search(ctx, "logs-*", []byte(`{"query": {"term": {"level": "error"}}}`), false)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.JSONEq(t, `{"level": "error", "n": 1}`, string(hits[0]))
+	assert.JSONEq(t, `{}`, string(hits[1]))
+
+	hits, err = c.Search(ctx, "logs-*", []byte(`{"query": {"term": {"level": "error"}}}`), true)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"_index": "logs-1", "_id": "a", "_score": 1.5, "_source": {"level": "error", "n": 1}}`, string(hits[0]))
+
+	hits, err = c.Search(ctx, "logs-*", nil, false)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	assert.NotNil(t, hits)
+
+	_, err = c.Search(ctx, "logs-*", []byte(`{"query":`), false)
+	assert.Error(t, err)
+
+	_, err = c.Search(ctx, "nope", nil, false)
+	assert.EqualError(t, err, "search of nope failed (HTTP 404): index_not_found_exception: no such index [nope]")
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("ES_URL", "")
+	_, err := New(context.Background(), &url.URL{Scheme: "es"})
+	assert.Error(t, err)
+
+	t.Setenv("ES_URL", "https://es.example.com:9200/")
+	c, err := New(context.Background(), &url.URL{Scheme: "es"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://es.example.com:9200", c.Base)
+
+	t.Setenv("ES_API_KEY", "")
+	t.Setenv("ES_USERNAME", "elastic")
+	t.Setenv("ES_PASSWORD", "changeme")
+	c, err = New(context.Background(), &url.URL{Scheme: "es", Host: "localhost:9200"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://localhost:9200", c.Base)
+	assert.Equal(t, "Basic ZWxhc3RpYzpjaGFuZ2VtZQ==", c.auth.Get("Authorization"))
+
+	c, err = New(context.Background(), &url.URL{Scheme: "es", Host: "localhost:9200", User: url.UserPassword("u", "p")})
+	require.NoError(t, err)
+	assert.Equal(t, "Basic dTpw", c.auth.Get("Authorization"))
+
+	t.Setenv("ES_API_KEY", "a2V5")
+	c, err = New(context.Background(), &url.URL{Scheme: "es", Host: "localhost:9200"})
+	require.NoError(t, err)
+	assert.Equal(t, "ApiKey a2V5", c.auth.Get("Authorization"))
+}
+
+func TestCACert(t *testing.T) {
+	srv := httptest.NewTLSServer(searchHandler(t))
+	defer srv.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+	u := &url.URL{Scheme: "es", Host: srv.Listener.Addr().String()}
+
+	// the test server's certificate isn't trusted by default
+	c, err := New(context.Background(), u)
+	require.NoError(t, err)
+	_, err = c.Search(context.Background(), "logs-*", nil, false)
+	assert.Error(t, err)
+
+	t.Setenv("ES_CA_CERT", ca)
+	c, err = New(context.Background(), u)
+	require.NoError(t, err)
+	_, err = c.Search(context.Background(), "logs-*", nil, false)
+	assert.NoError(t, err)
+
+	t.Setenv("ES_CA_CERT", filepath.Join(t.TempDir(), "missing.pem"))
+	_, err = New(context.Background(), u)
+	assert.Error(t, err)
+}
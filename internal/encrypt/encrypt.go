@@ -0,0 +1,172 @@
+// Package encrypt encrypts rendered outputs before they're written, either as
+// age-encrypted files, or as SOPS-encrypted YAML or JSON documents, so that
+// secrets generated by templates can be committed safely.
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/hairyhenderson/gomplate/v3/aws"
+	"github.com/hairyhenderson/gomplate/v3/env"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+)
+
+// Formats
+const (
+	FormatAge  = "age"
+	FormatSOPS = "sops"
+)
+
+// Encrypter encrypts outputs with the first rule that matches them
+type Encrypter struct {
+	rules []rule
+	// kms - the client for encrypting SOPS data keys with AWS KMS keys, if
+	// any are configured
+	kms *aws.KMS
+}
+
+type rule struct {
+	outputs []string
+	format  string
+	age     []string
+	kmsARNs []string
+}
+
+// New creates an Encrypter from the encrypt configs, parsing the recipients.
+// Configs without recipients use the same environment variables as sops:
+// SOPS_AGE_RECIPIENTS and SOPS_KMS_ARN (both comma-separated).
+func New(cfgs []config.EncryptConfig) (*Encrypter, error) {
+	e := &Encrypter{}
+	for _, c := range cfgs {
+		r := rule{outputs: c.Outputs, format: c.Format}
+		if r.format == "" {
+			r.format = FormatAge
+		}
+
+		recipients := c.Recipients
+		if len(recipients) == 0 {
+			recipients = append(splitList(env.Getenv("SOPS_AGE_RECIPIENTS")), splitList(env.Getenv("SOPS_KMS_ARN"))...)
+		}
+		for _, s := range recipients {
+			switch {
+			case strings.HasPrefix(s, "arn:aws:kms:"):
+				if r.format != FormatSOPS {
+					return nil, fmt.Errorf("AWS KMS keys can only be used with the sops format (for %s)", strings.Join(c.Outputs, ", "))
+				}
+				r.kmsARNs = append(r.kmsARNs, s)
+			default:
+				if _, err := age.ParseX25519Recipient(s); err != nil {
+					return nil, fmt.Errorf("invalid recipient for %s: %w", strings.Join(c.Outputs, ", "), err)
+				}
+				r.age = append(r.age, s)
+			}
+		}
+		if len(r.age) == 0 && len(r.kmsARNs) == 0 {
+			return nil, fmt.Errorf("no recipients to encrypt %s for (set recipients, or SOPS_AGE_RECIPIENTS)", strings.Join(c.Outputs, ", "))
+		}
+
+		if len(r.kmsARNs) > 0 && e.kms == nil {
+			e.kms = aws.NewKMS(aws.ClientOptions{})
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+func splitList(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Encrypt encrypts the content of the output at target, if it matches any of
+// the rules. Otherwise the content is returned as-is.
+func (e *Encrypter) Encrypt(target string, content []byte) ([]byte, error) {
+	if e == nil {
+		return content, nil
+	}
+	r, ok := e.match(target)
+	if !ok {
+		return content, nil
+	}
+
+	var out []byte
+	var err error
+	switch r.format {
+	case FormatSOPS:
+		out, err = e.encryptSOPS(r, content, isJSON(target))
+	default:
+		out, err = encryptAge(r.age, content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s with %s: %w", target, r.format, err)
+	}
+	return out, nil
+}
+
+// match finds the first rule with a glob matching the output's path (or, for
+// globs without a '/', its file name)
+func (e *Encrypter) match(target string) (rule, bool) {
+	p := filepath.ToSlash(target)
+	for _, r := range e.rules {
+		for _, o := range r.outputs {
+			if ok, _ := path.Match(o, p); ok {
+				return r, true
+			}
+			if !strings.Contains(o, "/") {
+				if ok, _ := path.Match(o, path.Base(p)); ok {
+					return r, true
+				}
+			}
+		}
+	}
+	return rule{}, false
+}
+
+// isJSON - SOPS documents are written as JSON for .json outputs, and YAML
+// otherwise
+func isJSON(target string) bool {
+	return strings.EqualFold(path.Ext(filepath.ToSlash(target)), ".json")
+}
+
+// encryptAge encrypts the content to the recipients, as an armored (PEM-like)
+// age file, so it can be committed as text. It can be decrypted with
+// 'age -d -i key.txt'.
+func encryptAge(recipients []string, content []byte) ([]byte, error) {
+	rs := make([]age.Recipient, len(recipients))
+	for i, s := range recipients {
+		r, err := age.ParseX25519Recipient(s)
+		if err != nil {
+			return nil, err
+		}
+		rs[i] = r
+	}
+
+	buf := &bytes.Buffer{}
+	aw := armor.NewWriter(buf)
+	w, err := age.Encrypt(aw, rs...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(w, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,271 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/hairyhenderson/gomplate/v3/aws"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	return id
+}
+
+func decryptAge(t *testing.T, id age.Identity, in []byte) []byte {
+	t.Helper()
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(in)), id)
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out
+}
+
+func TestNew(t *testing.T) {
+	id := newIdentity(t)
+	r := id.Recipient().String()
+
+	_, err := New([]config.EncryptConfig{{Outputs: []string{"*"}, Recipients: []string{"bogus"}}})
+	assert.Error(t, err)
+
+	_, err = New([]config.EncryptConfig{{Outputs: []string{"*"}, Recipients: []string{"arn:aws:kms:us-east-1:123456789012:key/abc"}}})
+	assert.ErrorContains(t, err, "can only be used with the sops format")
+
+	t.Setenv("SOPS_AGE_RECIPIENTS", "")
+	_, err = New([]config.EncryptConfig{{Outputs: []string{"*"}}})
+	assert.ErrorContains(t, err, "no recipients")
+
+	t.Setenv("SOPS_AGE_RECIPIENTS", r+", "+r)
+	e, err := New([]config.EncryptConfig{{Outputs: []string{"*"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{r, r}, e.rules[0].age)
+	assert.Equal(t, FormatAge, e.rules[0].format)
+}
+
+func TestMatch(t *testing.T) {
+	e := &Encrypter{rules: []rule{
+		{outputs: []string{"secrets/*.yaml"}, format: FormatSOPS},
+		{outputs: []string{"*.key", "token"}, format: FormatAge},
+	}}
+
+	r, ok := e.match("secrets/db.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, FormatSOPS, r.format)
+
+	r, ok = e.match("out/tls/server.key")
+	assert.True(t, ok)
+	assert.Equal(t, FormatAge, r.format)
+
+	_, ok = e.match("out/token")
+	assert.True(t, ok)
+
+	for _, p := range []string{"out/secrets/db.yaml", "secrets/db.json", "-"} {
+		_, ok = e.match(p)
+		assert.False(t, ok, p)
+	}
+
+	// outputs that don't match are left alone
+	out, err := e.Encrypt("config.yaml", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(out))
+
+	out, err = (*Encrypter)(nil).Encrypt("config.yaml", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(out))
+}
+
+func TestEncryptAge(t *testing.T) {
+	id1, id2 := newIdentity(t), newIdentity(t)
+	e, err := New([]config.EncryptConfig{{
+		Outputs:    []string{"*.txt"},
+		Recipients: []string{id1.Recipient().String(), id2.Recipient().String()},
+	}})
+	require.NoError(t, err)
+
+	out, err := e.Encrypt("token.txt", []byte("s3cr3t"))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n")))
+	assert.NotContains(t, string(out), "s3cr3t")
+
+	assert.Equal(t, "s3cr3t", string(decryptAge(t, id1, out)))
+	assert.Equal(t, "s3cr3t", string(decryptAge(t, id2, out)))
+}
+
+var encRe = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// sopsDecrypt decrypts a value the same way as sops does
+func sopsDecrypt(t *testing.T, key []byte, value, additionalData string) (string, string) {
+	t.Helper()
+	m := encRe.FindStringSubmatch(value)
+	require.NotNil(t, m, value)
+	data, _ := base64.StdEncoding.DecodeString(m[1])
+	iv, _ := base64.StdEncoding.DecodeString(m[2])
+	tag, _ := base64.StdEncoding.DecodeString(m[3])
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	require.NoError(t, err)
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	require.NoError(t, err, additionalData)
+	return string(plain), m[4]
+}
+
+func TestEncryptSOPS_YAML(t *testing.T) {
+	id := newIdentity(t)
+	e, err := New([]config.EncryptConfig{{
+		Outputs:    []string{"*.yaml", "*.json"},
+		Format:     FormatSOPS,
+		Recipients: []string{id.Recipient().String()},
+	}})
+	require.NoError(t, err)
+
+	in := `# the database credentials
+db:
+  user: admin
+  password: hunter2 # shh
+  port: 5432
+  ratio: 1.5
+  enabled: true
+  empty: ""
+  nothing: null
+hosts: [a, b]
+note_unencrypted: visible
+`
+	out, err := e.Encrypt("secrets.yaml", []byte(in))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "hunter2")
+	assert.NotContains(t, string(out), "shh")
+	assert.NotContains(t, string(out), "database")
+
+	doc := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(out, &doc))
+
+	meta := doc["sops"].(map[string]interface{})
+	assert.Equal(t, "_unencrypted", meta["unencrypted_suffix"])
+	ageKeys := meta["age"].([]interface{})
+	require.Len(t, ageKeys, 1)
+	ak := ageKeys[0].(map[string]interface{})
+	assert.Equal(t, id.Recipient().String(), ak["recipient"])
+	key := decryptAge(t, id, []byte(ak["enc"].(string)))
+	require.Len(t, key, 32)
+
+	db := doc["db"].(map[string]interface{})
+	mac := sha512.New()
+	for _, c := range []struct{ k, v, typ string }{
+		{"user", "admin", "str"},
+		{"password", "hunter2", "str"},
+		{"port", "5432", "int"},
+		{"ratio", "1.5", "float"},
+		{"enabled", "True", "bool"},
+	} {
+		v, typ := sopsDecrypt(t, key, db[c.k].(string), "db:"+c.k+":")
+		assert.Equal(t, c.v, v)
+		assert.Equal(t, c.typ, typ)
+		mac.Write([]byte(v))
+	}
+	assert.Equal(t, "", db["empty"])
+	assert.Nil(t, db["nothing"])
+
+	hosts := doc["hosts"].([]interface{})
+	for i, h := range []string{"a", "b"} {
+		v, _ := sopsDecrypt(t, key, hosts[i].(string), "hosts:")
+		assert.Equal(t, h, v)
+		mac.Write([]byte(v))
+	}
+	assert.Equal(t, "visible", doc["note_unencrypted"])
+	mac.Write([]byte("visible"))
+
+	// the MAC covers all of the values, and is authenticated with the
+	// modification time
+	v, _ := sopsDecrypt(t, key, meta["mac"].(string), meta["lastmodified"].(string))
+	assert.Equal(t, fmt.Sprintf("%X", mac.Sum(nil)), v)
+
+	// keys keep their order
+	assert.Less(t, strings.Index(string(out), "password:"), strings.Index(string(out), "port:"))
+	assert.Less(t, strings.Index(string(out), "hosts:"), strings.Index(string(out), "sops:"))
+
+	for _, bad := range []string{"[1, 2]", "a: [", "sops: {}", "a: &x 1\nb: *x"} {
+		_, err = e.Encrypt("bad.yaml", []byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEncryptSOPS_JSON(t *testing.T) {
+	id := newIdentity(t)
+	e, err := New([]config.EncryptConfig{{
+		Outputs:    []string{"*.json"},
+		Format:     FormatSOPS,
+		Recipients: []string{id.Recipient().String()},
+	}})
+	require.NoError(t, err)
+
+	out, err := e.Encrypt("out/secrets.json", []byte(`{"b": {"token": "abc"}, "a": 1}`))
+	require.NoError(t, err)
+	assert.Less(t, strings.Index(string(out), `"b"`), strings.Index(string(out), `"a"`))
+
+	doc := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(out, &doc))
+	meta := doc["sops"].(map[string]interface{})
+	key := decryptAge(t, id, []byte(meta["age"].([]interface{})[0].(map[string]interface{})["enc"].(string)))
+
+	v, typ := sopsDecrypt(t, key, doc["b"].(map[string]interface{})["token"].(string), "b:token:")
+	assert.Equal(t, "abc", v)
+	assert.Equal(t, "str", typ)
+	v, typ = sopsDecrypt(t, key, doc["a"].(string), "a:")
+	assert.Equal(t, "1", v)
+	assert.Equal(t, "int", typ)
+}
+
+type fakeKMS struct {
+	aws.KMSAPI
+	keyID string
+}
+
+func (k *fakeKMS) Encrypt(in *kms.EncryptInput) (*kms.EncryptOutput, error) {
+	k.keyID = *in.KeyId
+	return &kms.EncryptOutput{CiphertextBlob: append([]byte("wrapped:"), in.Plaintext...)}, nil
+}
+
+func TestEncryptSOPS_KMS(t *testing.T) {
+	arn := "arn:aws:kms:us-east-1:123456789012:key/abc"
+	t.Setenv("SOPS_AGE_RECIPIENTS", "")
+	t.Setenv("SOPS_KMS_ARN", arn)
+	e, err := New([]config.EncryptConfig{{Outputs: []string{"*"}, Format: FormatSOPS}})
+	require.NoError(t, err)
+	client := &fakeKMS{}
+	e.kms = &aws.KMS{Client: client}
+
+	out, err := e.Encrypt("secrets.yaml", []byte("password: hunter2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, arn, client.keyID)
+
+	doc := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(out, &doc))
+	k := doc["sops"].(map[string]interface{})["kms"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, arn, k["arn"])
+	wrapped, err := base64.StdEncoding.DecodeString(k["enc"].(string))
+	require.NoError(t, err)
+	key := bytes.TrimPrefix(wrapped, []byte("wrapped:"))
+
+	v, _ := sopsDecrypt(t, key, doc["password"].(string), "password:")
+	assert.Equal(t, "hunter2", v)
+}
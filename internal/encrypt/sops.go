@@ -0,0 +1,295 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/yaml"
+)
+
+const (
+	// sopsVersion - the version of sops whose file format is written
+	sopsVersion = "3.7.3"
+	// unencryptedSuffix - values under keys with this suffix are left in
+	// plain text (sops' default)
+	unencryptedSuffix = "_unencrypted"
+	// sopsNonceSize - sops uses 256-bit IVs with AES-GCM
+	sopsNonceSize = 32
+)
+
+// sopsMetadata - the 'sops' key that's added to encrypted documents, with the
+// data key encrypted for each of the recipients
+type sopsMetadata struct {
+	KMS               []sopsKMSKey `yaml:"kms,omitempty"`
+	Age               []sopsAgeKey `yaml:"age,omitempty"`
+	LastModified      string       `yaml:"lastmodified"`
+	MAC               string       `yaml:"mac"`
+	UnencryptedSuffix string       `yaml:"unencrypted_suffix"`
+	Version           string       `yaml:"version"`
+}
+
+type sopsKMSKey struct {
+	ARN        string `yaml:"arn"`
+	CreatedAt  string `yaml:"created_at"`
+	Enc        string `yaml:"enc"`
+	AWSProfile string `yaml:"aws_profile"`
+}
+
+type sopsAgeKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+// encryptSOPS encrypts each of the values in the YAML or JSON document with a
+// new data key, like 'sops -e' does, so that the output can be decrypted (or
+// edited) with sops. Key order is preserved, but comments are removed, since
+// they may contain secrets too.
+func (e *Encrypter) encryptSOPS(r rule, content []byte, asJSON bool) ([]byte, error) {
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("output isn't a YAML or JSON document: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("output must be a YAML or JSON object")
+	}
+	root := doc.Content[0]
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	s := &sopsEncrypter{key: key, mac: sha512.New()}
+	if err := s.walk(root, nil, true); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	mac, err := s.encryptValue([]byte(fmt.Sprintf("%X", s.mac.Sum(nil))), "str", now)
+	if err != nil {
+		return nil, err
+	}
+	meta := sopsMetadata{
+		LastModified:      now,
+		MAC:               mac,
+		UnencryptedSuffix: unencryptedSuffix,
+		Version:           sopsVersion,
+	}
+	for _, a := range r.age {
+		enc, err := encryptAge([]string{a}, key)
+		if err != nil {
+			return nil, err
+		}
+		meta.Age = append(meta.Age, sopsAgeKey{Recipient: a, Enc: string(enc)})
+	}
+	for _, arn := range r.kmsARNs {
+		enc, err := e.kms.Encrypt(arn, string(key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt data key with %s: %w", arn, err)
+		}
+		meta.KMS = append(meta.KMS, sopsKMSKey{ARN: arn, CreatedAt: now, Enc: enc})
+	}
+
+	metaNode := &yaml.Node{}
+	if err := metaNode.Encode(meta); err != nil {
+		return nil, err
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sops"}, metaNode)
+
+	if asJSON {
+		return nodeJSON(root)
+	}
+	return yaml.Marshal(&doc)
+}
+
+type sopsEncrypter struct {
+	key []byte
+	// mac - the hash of all of the (plain text) values, in document order
+	mac hash.Hash
+}
+
+// walk encrypts the node's values. The path of keys to each value is
+// authenticated along with it, so values can't be moved around.
+func (s *sopsEncrypter) walk(n *yaml.Node, p []string, encrypt bool) error {
+	n.HeadComment, n.LineComment, n.FootComment = "", "", ""
+	n.Anchor = ""
+	if n.Kind != yaml.ScalarNode {
+		// the encrypted values are long, so flow style isn't readable
+		n.Style = 0
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			k.HeadComment, k.LineComment, k.FootComment = "", "", ""
+			if k.ShortTag() == "!!merge" {
+				return fmt.Errorf("merge keys aren't supported")
+			}
+			if len(p) == 0 && k.Value == "sops" {
+				return fmt.Errorf("output already has a 'sops' key")
+			}
+			kp := append(append([]string{}, p...), k.Value)
+			if err := s.walk(v, kp, encrypt && !strings.HasSuffix(k.Value, unencryptedSuffix)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		// like sops, list items have the path of the list
+		for _, c := range n.Content {
+			if err := s.walk(c, p, encrypt); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		return fmt.Errorf("aliases aren't supported")
+	case yaml.ScalarNode:
+		return s.leaf(n, p, encrypt)
+	}
+	return nil
+}
+
+func (s *sopsEncrypter) leaf(n *yaml.Node, p []string, encrypt bool) error {
+	plain, typ, err := scalarBytes(n)
+	if err != nil {
+		return fmt.Errorf("invalid value at %s: %w", strings.Join(p, "."), err)
+	}
+	if typ == "" {
+		// nulls aren't encrypted (or included in the MAC)
+		return nil
+	}
+	s.mac.Write(plain)
+	if !encrypt || (typ == "str" && len(plain) == 0) {
+		// sops leaves empty strings as they are
+		return nil
+	}
+
+	v, err := s.encryptValue(plain, typ, strings.Join(p, ":")+":")
+	if err != nil {
+		return err
+	}
+	n.Kind, n.Tag, n.Style, n.Value = yaml.ScalarNode, "!!str", 0, v
+	return nil
+}
+
+// scalarBytes - the value's bytes and type, formatted the same way as sops
+// does. The type is empty for nulls.
+func scalarBytes(n *yaml.Node) ([]byte, string, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, "", nil
+	case "!!int":
+		var i int
+		if err := n.Decode(&i); err != nil {
+			return nil, "", err
+		}
+		return []byte(strconv.Itoa(i)), "int", nil
+	case "!!float":
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return nil, "", err
+		}
+		return []byte(strconv.FormatFloat(f, 'f', -1, 64)), "float", nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, "", err
+		}
+		if b {
+			return []byte("True"), "bool", nil
+		}
+		return []byte("False"), "bool", nil
+	default:
+		return []byte(n.Value), "str", nil
+	}
+}
+
+// encryptValue encrypts the value with AES-GCM, in sops' format
+func (s *sopsEncrypter) encryptValue(plain []byte, typ, additionalData string) (string, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, sopsNonceSize)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, sopsNonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	out := gcm.Seal(nil, iv, plain, []byte(additionalData))
+	tag := len(out) - gcm.Overhead()
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", enc(out[:tag]), enc(iv), enc(out[tag:]), typ), nil
+}
+
+// nodeJSON marshals the node as indented JSON, preserving the key order
+func nodeJSON(n *yaml.Node) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeJSON(buf, n); err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, buf.Bytes(), "", "\t"); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(n.Content[i].Value)
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var v interface{} = n.Value
+		switch n.ShortTag() {
+		case "!!null", "!!bool", "!!int", "!!float":
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	default:
+		return fmt.Errorf("unsupported YAML node kind %d", n.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+// Package fuzz generates mutations of structured data (like a datasource's
+// parsed JSON or YAML) - missing keys, nulls, values of the wrong type, and
+// huge strings - for finding templates that don't cope with unexpected input.
+package fuzz
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HugeStringSize - the length of the strings used by Huge mutations
+const HugeStringSize = 1 << 20
+
+// Kind - the kind of change a mutation makes
+type Kind string
+
+const (
+	// Missing - the key is removed from its object (or the item from its array)
+	Missing Kind = "missing"
+	// Null - the value is replaced with null
+	Null Kind = "null"
+	// WrongType - the value is replaced with a value of a different type
+	WrongType Kind = "type"
+	// Huge - the string is replaced with a very long one
+	Huge Kind = "huge"
+)
+
+// Mutation - a change to the value at a path. Path elements are object keys
+// (strings) or array indexes (ints), and an empty path is the whole value.
+type Mutation struct {
+	Kind Kind
+	Path []interface{}
+}
+
+// String describes the mutation, like "null at .db.hosts[0]"
+func (m Mutation) String() string {
+	return string(m.Kind) + " at " + FormatPath(m.Path)
+}
+
+// FormatPath formats the path like a template field reference, with array
+// indexes in brackets, and keys that aren't identifiers quoted
+func FormatPath(p []interface{}) string {
+	if len(p) == 0 {
+		return "."
+	}
+	sb := &strings.Builder{}
+	for _, e := range p {
+		switch e := e.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(e) + "]")
+		case string:
+			if isIdent(e) {
+				sb.WriteString("." + e)
+			} else {
+				sb.WriteString("[" + strconv.Quote(e) + "]")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Mutations lists the mutations of the value, in a stable order. Every key of
+// every object is mutated, but only the first item of each array is, since
+// templates usually treat all of an array's items alike.
+func Mutations(v interface{}) []Mutation {
+	out := []Mutation{}
+	walk(v, nil, func(p []interface{}, v interface{}) {
+		if len(p) > 0 {
+			out = append(out, Mutation{Kind: Missing, Path: p})
+		}
+		if v != nil {
+			out = append(out, Mutation{Kind: Null, Path: p})
+		}
+		out = append(out, Mutation{Kind: WrongType, Path: p})
+		if _, ok := v.(string); ok {
+			out = append(out, Mutation{Kind: Huge, Path: p})
+		}
+	})
+	return out
+}
+
+func walk(v interface{}, p []interface{}, f func([]interface{}, interface{})) {
+	f(p, v)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walk(v[k], appendPath(p, k), f)
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			walk(v[0], appendPath(p, 0), f)
+		}
+	}
+}
+
+// appendPath appends to a copy of the path, so that paths don't share memory
+func appendPath(p []interface{}, e interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(p)+1), p...), e)
+}
+
+// Apply returns a copy of the value with the mutation applied. The value
+// itself isn't modified. Paths that aren't in the value are ignored.
+func (m Mutation) Apply(v interface{}) interface{} {
+	v = deepCopy(v)
+	if len(m.Path) == 0 {
+		return m.replace(v)
+	}
+
+	parent := v
+	for _, e := range m.Path[:len(m.Path)-1] {
+		var ok bool
+		parent, ok = child(parent, e)
+		if !ok {
+			return v
+		}
+	}
+
+	last := m.Path[len(m.Path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		k, ok := last.(string)
+		if !ok {
+			return v
+		}
+		if _, ok := p[k]; !ok {
+			return v
+		}
+		if m.Kind == Missing {
+			delete(p, k)
+			return v
+		}
+		p[k] = m.replace(p[k])
+	case []interface{}:
+		i, ok := last.(int)
+		if !ok || i < 0 || i >= len(p) {
+			return v
+		}
+		if m.Kind == Missing {
+			// the array's parent must refer to the shorter array
+			return (Mutation{Kind: m.Kind, Path: m.Path[:len(m.Path)-1]}).setValue(v, append(p[:i], p[i+1:]...))
+		}
+		p[i] = m.replace(p[i])
+	}
+	return v
+}
+
+// setValue sets the value at the mutation's path, in a value that has
+// already been copied
+func (m Mutation) setValue(v, nv interface{}) interface{} {
+	if len(m.Path) == 0 {
+		return nv
+	}
+	parent := v
+	for _, e := range m.Path[:len(m.Path)-1] {
+		parent, _ = child(parent, e)
+	}
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[m.Path[len(m.Path)-1].(string)] = nv
+	case []interface{}:
+		p[m.Path[len(m.Path)-1].(int)] = nv
+	}
+	return v
+}
+
+func child(v, e interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		k, ok := e.(string)
+		if !ok {
+			return nil, false
+		}
+		c, ok := v[k]
+		return c, ok
+	case []interface{}:
+		i, ok := e.(int)
+		if !ok || i < 0 || i >= len(v) {
+			return nil, false
+		}
+		return v[i], true
+	}
+	return nil, false
+}
+
+// replace returns the replacement value for Null, WrongType, and Huge
+// mutations
+func (m Mutation) replace(v interface{}) interface{} {
+	switch m.Kind {
+	case Null:
+		return nil
+	case WrongType:
+		return wrongType(v)
+	case Huge:
+		return strings.Repeat("x", HugeStringSize)
+	}
+	return v
+}
+
+// wrongType - a value of a different type than v, chosen to be plausible
+// enough to get past simple checks
+func wrongType(v interface{}) interface{} {
+	switch v.(type) {
+	case string:
+		return 0
+	case bool:
+		return "true"
+	case map[string]interface{}:
+		return []interface{}{}
+	case []interface{}:
+		return map[string]interface{}{}
+	case nil:
+		return ""
+	default:
+		// numbers
+		return "NaN"
+	}
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, c := range v {
+			out[k] = deepCopy(c)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, c := range v {
+			out[i] = deepCopy(c)
+		}
+		return out
+	}
+	return v
+}
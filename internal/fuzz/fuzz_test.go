@@ -0,0 +1,76 @@
+package fuzz
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutations(t *testing.T) {
+	v := map[string]interface{}{
+		"name":  "app",
+		"port":  8080,
+		"hosts": []interface{}{"a", "b"},
+	}
+
+	descs := []string{}
+	for _, m := range Mutations(v) {
+		descs = append(descs, m.String())
+	}
+	assert.Equal(t, []string{
+		"null at .", "type at .",
+		"missing at .hosts", "null at .hosts", "type at .hosts",
+		"missing at .hosts[0]", "null at .hosts[0]", "type at .hosts[0]", "huge at .hosts[0]",
+		"missing at .name", "null at .name", "type at .name", "huge at .name",
+		"missing at .port", "null at .port", "type at .port",
+	}, descs)
+
+	assert.Equal(t, []Mutation{{Kind: WrongType}}, Mutations(nil))
+}
+
+func TestApply(t *testing.T) {
+	v := map[string]interface{}{
+		"db": map[string]interface{}{
+			"hosts": []interface{}{"a", "b"},
+			"tls":   true,
+		},
+	}
+	orig := deepCopy(v)
+
+	assert.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+	}, Mutation{Kind: Missing, Path: []interface{}{"db", "tls"}}.Apply(v))
+
+	assert.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{"hosts": []interface{}{"b"}, "tls": true},
+	}, Mutation{Kind: Missing, Path: []interface{}{"db", "hosts", 0}}.Apply(v))
+
+	assert.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{"hosts": []interface{}{nil, "b"}, "tls": true},
+	}, Mutation{Kind: Null, Path: []interface{}{"db", "hosts", 0}}.Apply(v))
+
+	assert.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{"hosts": map[string]interface{}{}, "tls": "true"},
+	}, Mutation{Kind: WrongType, Path: []interface{}{"db", "tls"}}.Apply(
+		Mutation{Kind: WrongType, Path: []interface{}{"db", "hosts"}}.Apply(v)))
+
+	out := Mutation{Kind: Huge, Path: []interface{}{"db", "hosts", 1}}.Apply(v)
+	assert.Len(t, out.(map[string]interface{})["db"].(map[string]interface{})["hosts"].([]interface{})[1], HugeStringSize)
+
+	assert.Nil(t, Mutation{Kind: Null}.Apply(v))
+
+	// paths that don't exist are ignored
+	assert.Equal(t, v, Mutation{Kind: Null, Path: []interface{}{"db", "port"}}.Apply(v))
+	assert.Equal(t, v, Mutation{Kind: Null, Path: []interface{}{"nope", "port"}}.Apply(v))
+
+	// the original is never modified
+	assert.Equal(t, orig, v)
+}
+
+func TestFormatPath(t *testing.T) {
+	assert.Equal(t, ".", FormatPath(nil))
+	assert.Equal(t, `.a[0]["b-c"].d_2`, FormatPath([]interface{}{"a", 0, "b-c", "d_2"}))
+	assert.Equal(t, `["2x"]`, FormatPath([]interface{}{"2x"}))
+	assert.True(t, strings.HasPrefix(Mutation{Kind: Huge, Path: []interface{}{"a"}}.String(), "huge at .a"))
+}
@@ -0,0 +1,205 @@
+// Package gen generates template inputs for property-based tests - numbers in
+// ranges, values from lists, and strings matching regular expressions - from
+// a seed, so that failing cases can be reproduced.
+package gen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+)
+
+// MaxRepeat - the most times an unbounded repetition in a pattern (like `*`
+// or `+`) is repeated
+const MaxRepeat = 10
+
+// Generator - generates the input for the i'th case
+type Generator interface {
+	Generate(r *rand.Rand, i int) interface{}
+}
+
+// Range - generates numbers between min and max (inclusive). The first two
+// cases are min and max, since edge cases are often at the bounds. Integers
+// are generated when both bounds are integers, and floats otherwise.
+func Range(min, max interface{}) (Generator, error) {
+	lo, lok := toInt(min)
+	hi, hok := toInt(max)
+	if lok && hok {
+		if lo > hi {
+			return nil, fmt.Errorf("range minimum %d is larger than maximum %d", lo, hi)
+		}
+		return intRange{lo, hi}, nil
+	}
+
+	flo, lok := toFloat(min)
+	fhi, hok := toFloat(max)
+	if !lok || !hok {
+		return nil, fmt.Errorf("range bounds must be numbers, got %T and %T", min, max)
+	}
+	if flo > fhi {
+		return nil, fmt.Errorf("range minimum %g is larger than maximum %g", flo, fhi)
+	}
+	return floatRange{flo, fhi}, nil
+}
+
+type intRange struct {
+	min, max int64
+}
+
+func (g intRange) Generate(r *rand.Rand, i int) interface{} {
+	switch {
+	case i == 0:
+		return g.min
+	case i == 1:
+		return g.max
+	}
+	span := uint64(g.max - g.min)
+	if span == math.MaxUint64 {
+		return int64(r.Uint64())
+	}
+	//nolint:gosec
+	return g.min + int64(r.Uint64()%(span+1))
+}
+
+type floatRange struct {
+	min, max float64
+}
+
+func (g floatRange) Generate(r *rand.Rand, i int) interface{} {
+	switch {
+	case i == 0:
+		return g.min
+	case i == 1:
+		return g.max
+	}
+	return g.min + r.Float64()*(g.max-g.min)
+}
+
+// Enum - generates values from the list. Each value is used once (in order)
+// before values are chosen at random.
+func Enum(values []interface{}) (Generator, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("enum must have at least one value")
+	}
+	return enum(values), nil
+}
+
+type enum []interface{}
+
+func (g enum) Generate(r *rand.Rand, i int) interface{} {
+	if i < len(g) {
+		return g[i]
+	}
+	return g[r.Intn(len(g))]
+}
+
+// Pattern - generates strings that match the regular expression (in the same
+// syntax as the regexp package). Anchors and word boundaries are ignored, as
+// the whole string is generated to match, and unbounded repetitions are
+// repeated at most MaxRepeat times.
+func Pattern(pattern string) (Generator, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return patternGen{re.Simplify()}, nil
+}
+
+type patternGen struct {
+	re *syntax.Regexp
+}
+
+func (g patternGen) Generate(r *rand.Rand, _ int) interface{} {
+	sb := &strings.Builder{}
+	generate(r, g.re, sb)
+	return sb.String()
+}
+
+func generate(r *rand.Rand, re *syntax.Regexp, sb *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, c := range re.Rune {
+			sb.WriteRune(c)
+		}
+	case syntax.OpCharClass:
+		sb.WriteRune(charClassRune(r, re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		// printable ASCII
+		sb.WriteRune(rune(' ' + r.Intn('~'-' '+1)))
+	case syntax.OpCapture:
+		generate(r, re.Sub[0], sb)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			generate(r, sub, sb)
+		}
+	case syntax.OpAlternate:
+		generate(r, re.Sub[r.Intn(len(re.Sub))], sb)
+	case syntax.OpStar:
+		repeat(r, re.Sub[0], 0, MaxRepeat, sb)
+	case syntax.OpPlus:
+		repeat(r, re.Sub[0], 1, MaxRepeat, sb)
+	case syntax.OpQuest:
+		repeat(r, re.Sub[0], 0, 1, sb)
+	case syntax.OpRepeat:
+		max := re.Max
+		if max < 0 {
+			max = re.Min + MaxRepeat
+		}
+		repeat(r, re.Sub[0], re.Min, max, sb)
+	}
+	// other ops (anchors, word boundaries, and empty matches) match the
+	// empty string
+}
+
+func repeat(r *rand.Rand, re *syntax.Regexp, min, max int, sb *strings.Builder) {
+	n := min + r.Intn(max-min+1)
+	for i := 0; i < n; i++ {
+		generate(r, re, sb)
+	}
+}
+
+// charClassRune picks a rune from the class's ranges, which are pairs of
+// (inclusive) bounds
+func charClassRune(r *rand.Rand, ranges []rune) rune {
+	size := 0
+	for i := 0; i < len(ranges); i += 2 {
+		size += int(ranges[i+1]-ranges[i]) + 1
+	}
+	if size == 0 {
+		return 0
+	}
+	n := r.Intn(size)
+	for i := 0; i < len(ranges); i += 2 {
+		w := int(ranges[i+1]-ranges[i]) + 1
+		if n < w {
+			return ranges[i] + rune(n)
+		}
+		n -= w
+	}
+	return ranges[0]
+}
+
+func toInt(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	if i, ok := toInt(v); ok {
+		return float64(i), true
+	}
+	f, ok := v.(float64)
+	return f, ok
+}
@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	g, err := Range(1, 65535)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), g.Generate(r, 0))
+	assert.Equal(t, int64(65535), g.Generate(r, 1))
+	for i := 2; i < 100; i++ {
+		v := g.Generate(r, i).(int64)
+		assert.GreaterOrEqual(t, v, int64(1))
+		assert.LessOrEqual(t, v, int64(65535))
+	}
+
+	g, err = Range(-1, 0.5)
+	require.NoError(t, err)
+	assert.Equal(t, -1.0, g.Generate(r, 0))
+	assert.Equal(t, 0.5, g.Generate(r, 1))
+	for i := 2; i < 100; i++ {
+		v := g.Generate(r, i).(float64)
+		assert.GreaterOrEqual(t, v, -1.0)
+		assert.LessOrEqual(t, v, 0.5)
+	}
+
+	_, err = Range(2, 1)
+	assert.EqualError(t, err, "range minimum 2 is larger than maximum 1")
+
+	_, err = Range("a", 1)
+	assert.EqualError(t, err, "range bounds must be numbers, got string and int")
+}
+
+func TestEnum(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	g, err := Enum([]interface{}{"dev", "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "dev", g.Generate(r, 0))
+	assert.Equal(t, "prod", g.Generate(r, 1))
+	for i := 2; i < 20; i++ {
+		assert.Contains(t, []interface{}{"dev", "prod"}, g.Generate(r, i))
+	}
+
+	_, err = Enum(nil)
+	assert.EqualError(t, err, "enum must have at least one value")
+}
+
+func TestPattern(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	for _, p := range []string{
+		`[a-z]{1,8}\.example\.com`,
+		`^(dev|staging|prod)-\d+$`,
+		`v\d+\.\d+(\.\d+)?(-rc\d)?`,
+		`[^\n]*`,
+		`\w+@\w+\.(com|org)`,
+	} {
+		g, err := Pattern(p)
+		require.NoError(t, err)
+		re := regexp.MustCompile(`^(?:` + p + `)$`)
+		for i := 0; i < 100; i++ {
+			s := g.Generate(r, i).(string)
+			assert.Regexp(t, re, s, "pattern %s", p)
+		}
+	}
+
+	_, err := Pattern(`[a-`)
+	assert.ErrorContains(t, err, `invalid pattern "[a-"`)
+}
+
+func TestGenerateSeeded(t *testing.T) {
+	g, err := Pattern(`[a-z]{5}`)
+	require.NoError(t, err)
+
+	a := g.Generate(rand.New(rand.NewSource(42)), 0)
+	b := g.Generate(rand.New(rand.NewSource(42)), 0)
+	assert.Equal(t, a, b)
+}
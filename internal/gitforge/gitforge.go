@@ -0,0 +1,76 @@
+// Package gitforge reads files, directory listings, and release metadata from
+// the GitHub and GitLab REST APIs, for the github: and gitlab: datasources.
+package gitforge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound - the repository, file, ref, or release doesn't exist (or isn't
+// visible with the token)
+var ErrNotFound = errors.New("not found")
+
+// Forge - a git hosting service's API
+type Forge interface {
+	// File - the contents of the file at the ref (the default branch when
+	// empty)
+	File(ctx context.Context, repo, path, ref string) ([]byte, error)
+	// List - the sorted names of the entries in the directory at the ref.
+	// Directories have a trailing '/'.
+	List(ctx context.Context, repo, path, ref string) ([]string, error)
+	// Release - the metadata of the release with the tag, or the latest
+	// release when tag is "latest", as JSON
+	Release(ctx context.Context, repo, tag string) ([]byte, error)
+}
+
+// SplitRef splits a "path@ref" string into the path and the ref
+func SplitRef(s string) (path, ref string) {
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// do makes a GET request, returning the body when the status is 200, and
+// ErrNotFound on a 404. The response is returned too, for its headers.
+func do(ctx context.Context, hc *http.Client, u string, header http.Header) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return b, res, nil
+	case http.StatusNotFound:
+		return nil, res, ErrNotFound
+	}
+
+	e := struct {
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && e.Message != "" {
+		return nil, res, fmt.Errorf("API error (HTTP %d): %s", res.StatusCode, e.Message)
+	}
+	return nil, res, fmt.Errorf("unexpected HTTP status %d: %s", res.StatusCode, bytes.TrimSpace(b))
+}
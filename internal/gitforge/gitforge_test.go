@@ -0,0 +1,164 @@
+package gitforge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRef(t *testing.T) {
+	data := []struct {
+		in, path, ref string
+	}{
+		{"", "", ""},
+		{"a/b.yaml", "a/b.yaml", ""},
+		{"a/b.yaml@main", "a/b.yaml", "main"},
+		{"a/@feature/x", "a/", "feature/x"},
+		{"@v1.0", "", "v1.0"},
+	}
+	for _, d := range data {
+		p, ref := SplitRef(d.in)
+		assert.Equal(t, d.path, p, d.in)
+		assert.Equal(t, d.ref, ref, d.in)
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghtoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/o/r/contents/configs/app.yaml":
+			assert.Equal(t, "application/vnd.github.raw", r.Header.Get("Accept"))
+			if r.URL.Query().Get("ref") == "v1" {
+				_, _ = w.Write([]byte("version: 1\n"))
+				return
+			}
+			_, _ = w.Write([]byte("version: 2\n"))
+		case "/repos/o/r/contents/configs":
+			_, _ = w.Write([]byte(`[{"name": "b.json", "type": "file"}, {"name": "app.yaml", "type": "file"}, {"name": "sub", "type": "dir"}]`))
+		case "/repos/o/r/releases/latest":
+			_, _ = w.Write([]byte(`{"tag_name": "v2"}`))
+		case "/repos/o/r/releases/tags/v1":
+			_, _ = w.Write([]byte(`{"tag_name": "v1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITHUB_API_URL", srv.URL+"/")
+	t.Setenv("GITHUB_TOKEN", "ghtoken")
+	g := NewGitHub(srv.Client())
+	assert.Equal(t, srv.URL, g.Base)
+	ctx := context.Background()
+
+	b, err := g.File(ctx, "o/r", "configs/app.yaml", "")
+	require.NoError(t, err)
+	assert.Equal(t, "version: 2\n", string(b))
+
+	b, err = g.File(ctx, "o/r", "/configs/app.yaml", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "version: 1\n", string(b))
+
+	_, err = g.File(ctx, "o/r", "missing", "")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	names, err := g.List(ctx, "o/r", "configs/", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.yaml", "b.json", "sub/"}, names)
+
+	b, err = g.Release(ctx, "o/r", "latest")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tag_name": "v2"}`, string(b))
+
+	b, err = g.Release(ctx, "o/r", "v1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tag_name": "v1"}`, string(b))
+
+	g.token = "wrong"
+	_, err = g.File(ctx, "o/r", "configs/app.yaml", "")
+	assert.EqualError(t, err, "failed to read configs/app.yaml from o/r: API error (HTTP 401): Bad credentials")
+}
+
+func TestGitLab(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "gltoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "401 Unauthorized"}`))
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/g%2Fsub%2Fp/repository/files/configs%2Fapp.yaml/raw":
+			_, _ = w.Write([]byte("ref: " + q.Get("ref")))
+		case "/api/v4/projects/g%2Fsub%2Fp/repository/tree":
+			if q.Get("path") != "configs" {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			// one entry per page, to exercise the pagination
+			switch q.Get("page") {
+			case "1":
+				w.Header().Set("X-Next-Page", "2")
+				_, _ = w.Write([]byte(`[{"name": "sub", "type": "tree"}]`))
+			case "2":
+				_, _ = w.Write([]byte(`[{"name": "app.yaml", "type": "blob"}]`))
+			}
+		case "/api/v4/projects/g%2Fsub%2Fp/releases":
+			_, _ = w.Write([]byte(`[{"tag_name": "v2"}, {"tag_name": "v1"}]`))
+		case "/api/v4/projects/g%2Fsub%2Fp/releases/v1":
+			_, _ = w.Write([]byte(`{"tag_name": "v1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "404 Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITLAB_TOKEN", "gltoken")
+	g := NewGitLab(srv.Client(), srv.URL+"/api/v4/")
+	ctx := context.Background()
+
+	b, err := g.File(ctx, "g/sub/p", "configs/app.yaml", "")
+	require.NoError(t, err)
+	assert.Equal(t, "ref: HEAD", string(b))
+
+	b, err = g.File(ctx, "g/sub/p", "configs/app.yaml", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "ref: v1", string(b))
+
+	_, err = g.File(ctx, "g/sub/p", "missing", "")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	names, err := g.List(ctx, "g/sub/p", "configs/", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.yaml", "sub/"}, names)
+
+	_, err = g.List(ctx, "g/sub/p", "missing/", "")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	b, err = g.Release(ctx, "g/sub/p", "latest")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tag_name": "v2"}`, string(b))
+
+	b, err = g.Release(ctx, "g/sub/p", "v1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tag_name": "v1"}`, string(b))
+
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv("CI_JOB_TOKEN", "job")
+	g = NewGitLab(srv.Client(), srv.URL+"/api/v4")
+	assert.Equal(t, "job", g.header.Get("JOB-TOKEN"))
+	_, err = g.File(ctx, "g/sub/p", "configs/app.yaml", "")
+	assert.EqualError(t, err, "failed to read configs/app.yaml from g/sub/p: API error (HTTP 401): 401 Unauthorized")
+}
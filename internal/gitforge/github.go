@@ -0,0 +1,124 @@
+package gitforge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultGitHubAPI - the github.com API's URL
+const DefaultGitHubAPI = "https://api.github.com"
+
+// GitHub - a GitHub (or GitHub Enterprise Server) API client
+type GitHub struct {
+	// Base - the API's URL, from GITHUB_API_URL, or DefaultGitHubAPI
+	Base string
+
+	token string
+	hc    *http.Client
+}
+
+var _ Forge = (*GitHub)(nil)
+
+// NewGitHub creates a GitHub client, authenticating with the token in
+// GITHUB_TOKEN (or GH_TOKEN), when set
+func NewGitHub(hc *http.Client) *GitHub {
+	base := os.Getenv("GITHUB_API_URL")
+	if base == "" {
+		base = DefaultGitHubAPI
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return &GitHub{Base: strings.TrimSuffix(base, "/"), token: token, hc: hc}
+}
+
+func (g *GitHub) get(ctx context.Context, p string, q url.Values, accept string) ([]byte, error) {
+	u := g.Base + p
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	h := http.Header{}
+	h.Set("Accept", accept)
+	h.Set("X-GitHub-Api-Version", "2022-11-28")
+	if g.token != "" {
+		h.Set("Authorization", "Bearer "+g.token)
+	}
+	b, _, err := do(ctx, g.hc, u, h)
+	return b, err
+}
+
+func contentsPath(repo, p string) string {
+	return "/repos/" + repo + "/contents/" + escapePath(strings.Trim(p, "/"))
+}
+
+// escapePath escapes each segment of the path, keeping the '/'s
+func escapePath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+func refQuery(ref string) url.Values {
+	q := url.Values{}
+	if ref != "" {
+		q.Set("ref", ref)
+	}
+	return q
+}
+
+// File - the contents of the file in the "owner/repo" repository
+func (g *GitHub) File(ctx context.Context, repo, p, ref string) ([]byte, error) {
+	b, err := g.get(ctx, contentsPath(repo, p), refQuery(ref), "application/vnd.github.raw")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from %s: %w", p, repo, err)
+	}
+	return b, nil
+}
+
+// List - the entries in the directory in the "owner/repo" repository
+func (g *GitHub) List(ctx context.Context, repo, p, ref string) ([]string, error) {
+	b, err := g.get(ctx, contentsPath(repo, p), refQuery(ref), "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s in %s: %w", p, repo, err)
+	}
+
+	entries := []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}{}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("%s in %s is not a directory", p, repo)
+	}
+
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name
+		if e.Type == "dir" {
+			names[i] += "/"
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Release - the release's metadata, as returned by the API
+func (g *GitHub) Release(ctx context.Context, repo, tag string) ([]byte, error) {
+	p := "/repos/" + repo + "/releases/latest"
+	if tag != "latest" {
+		p = "/repos/" + repo + "/releases/tags/" + url.PathEscape(tag)
+	}
+	b, err := g.get(ctx, p, nil, "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release %s of %s: %w", tag, repo, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,132 @@
+package gitforge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GitLab - a GitLab API client
+type GitLab struct {
+	// Base - the API's URL, like https://gitlab.com/api/v4
+	Base string
+
+	header http.Header
+	hc     *http.Client
+}
+
+var _ Forge = (*GitLab)(nil)
+
+// NewGitLab creates a client for the GitLab API at base, authenticating with
+// the token in GITLAB_TOKEN, or a CI job token in CI_JOB_TOKEN, when set
+func NewGitLab(hc *http.Client, base string) *GitLab {
+	h := http.Header{}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		h.Set("PRIVATE-TOKEN", token)
+	} else if token := os.Getenv("CI_JOB_TOKEN"); token != "" {
+		h.Set("JOB-TOKEN", token)
+	}
+	return &GitLab{Base: strings.TrimSuffix(base, "/"), header: h, hc: hc}
+}
+
+// projectPath - the API path of the project, by its full path ("group/project")
+func projectPath(repo string) string {
+	return "/projects/" + url.PathEscape(repo)
+}
+
+func (g *GitLab) get(ctx context.Context, p string, q url.Values) ([]byte, *http.Response, error) {
+	u := g.Base + p
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return do(ctx, g.hc, u, g.header)
+}
+
+// File - the contents of the file in the "group/project" project. The default
+// branch is read when the ref is empty.
+func (g *GitLab) File(ctx context.Context, repo, p, ref string) ([]byte, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	p = strings.Trim(p, "/")
+	b, _, err := g.get(ctx, projectPath(repo)+"/repository/files/"+url.PathEscape(p)+"/raw", url.Values{"ref": []string{ref}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from %s: %w", p, repo, err)
+	}
+	return b, nil
+}
+
+// List - the entries in the directory in the "group/project" project. All
+// pages of results are read.
+func (g *GitLab) List(ctx context.Context, repo, p, ref string) ([]string, error) {
+	p = strings.Trim(p, "/")
+	q := url.Values{"per_page": []string{"100"}}
+	if p != "" {
+		q.Set("path", p)
+	}
+	if ref != "" {
+		q.Set("ref", ref)
+	}
+
+	names := []string{}
+	for page := "1"; page != ""; {
+		q.Set("page", page)
+		b, res, err := g.get(ctx, projectPath(repo)+"/repository/tree", q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s in %s: %w", p, repo, err)
+		}
+
+		entries := []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}{}
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, fmt.Errorf("invalid GitLab API response: %w", err)
+		}
+		for _, e := range entries {
+			if e.Type == "tree" {
+				e.Name += "/"
+			}
+			names = append(names, e.Name)
+		}
+
+		page = res.Header.Get("X-Next-Page")
+	}
+
+	// an empty listing is what GitLab returns for paths that don't exist
+	if len(names) == 0 && p != "" {
+		return nil, fmt.Errorf("failed to list %s in %s: %w", p, repo, ErrNotFound)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Release - the release's metadata, as returned by the API
+func (g *GitLab) Release(ctx context.Context, repo, tag string) ([]byte, error) {
+	if tag != "latest" {
+		b, _, err := g.get(ctx, projectPath(repo)+"/releases/"+url.PathEscape(tag), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release %s of %s: %w", tag, repo, err)
+		}
+		return b, nil
+	}
+
+	// releases are listed newest first
+	b, _, err := g.get(ctx, projectPath(repo)+"/releases", url.Values{"per_page": []string{"1"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latest release of %s: %w", repo, err)
+	}
+	releases := []json.RawMessage{}
+	if err := json.Unmarshal(b, &releases); err != nil {
+		return nil, fmt.Errorf("invalid GitLab API response: %w", err)
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("failed to read latest release of %s: %w", repo, ErrNotFound)
+	}
+	return releases[0], nil
+}
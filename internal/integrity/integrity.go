@@ -0,0 +1,52 @@
+// Package integrity checks content against pinned SHA-256 digests, given in
+// the style of Subresource Integrity (e.g. "sha256-<base64 digest>").
+package integrity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const prefix = "sha256-"
+
+// Parse returns the digest in the integrity value, which is "sha256-"
+// followed by the base64 (or hex) encoded SHA-256 digest
+func Parse(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, prefix) {
+		return nil, fmt.Errorf("invalid integrity %q: must start with %q", s, prefix)
+	}
+	enc := strings.TrimPrefix(s, prefix)
+
+	digest, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil || len(digest) != sha256.Size {
+		digest, err = hex.DecodeString(enc)
+	}
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("invalid integrity %q: must be a base64 or hex encoded SHA-256 digest", s)
+	}
+	return digest, nil
+}
+
+// Of returns the integrity value of the content
+func Of(content []byte) string {
+	digest := sha256.Sum256(content)
+	return prefix + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// Check returns an error when the content's digest isn't the one in the
+// integrity value
+func Check(s string, content []byte) error {
+	expected, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(content)
+	if !bytes.Equal(digest[:], expected) {
+		return fmt.Errorf("content has integrity %s, not %s", Of(content), s)
+	}
+	return nil
+}
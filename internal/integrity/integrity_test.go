@@ -0,0 +1,40 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	// printf 'hello world' | sha256sum
+	hexDigest := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	b64Digest := "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="
+
+	d1, err := Parse("sha256-" + b64Digest)
+	require.NoError(t, err)
+	d2, err := Parse("sha256-" + hexDigest)
+	require.NoError(t, err)
+	assert.Equal(t, d1, d2)
+
+	for _, s := range []string{
+		"", b64Digest, "sha512-" + b64Digest, "sha256-", "sha256-abc", "sha256-" + hexDigest[2:],
+	} {
+		_, err = Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	content := []byte("hello world")
+	assert.Equal(t, "sha256-uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=", Of(content))
+
+	assert.NoError(t, Check(Of(content), content))
+	assert.NoError(t, Check("sha256-b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", content))
+
+	err := Check(Of(content), []byte("goodbye world"))
+	assert.EqualError(t, err, "content has integrity "+Of([]byte("goodbye world"))+", not "+Of(content))
+
+	assert.Error(t, Check("bogus", content))
+}
@@ -64,6 +64,84 @@ func (f *emptySkipper) Close() error {
 	return nil
 }
 
+// Discard - implements Discarder
+func (f *emptySkipper) Discard() error {
+	f.buf.Reset()
+	if d, ok := f.w.(Discarder); ok {
+		return d.Discard()
+	}
+	return f.Close()
+}
+
+// Skipped - implements Skipper
+func (f *emptySkipper) Skipped() bool {
+	return !f.nw
+}
+
+// Discarder is implemented by writers that can be abandoned without producing
+// any output - for example, files that are only created on the first write.
+type Discarder interface {
+	// Discard closes the writer, without creating or modifying the output
+	// if it hasn't been written to yet.
+	Discard() error
+}
+
+// SpillWriter buffers what's written, until the buffer would grow larger than
+// a limit - from then on, the buffer and all later writes are written through
+// to the wrapped writer. Small outputs can be discarded (or processed as a
+// whole) before they're written, and large ones aren't held in memory.
+type SpillWriter struct {
+	w       io.Writer
+	buf     *bytes.Buffer
+	limit   int
+	spilled bool
+}
+
+// NewSpillWriter creates a SpillWriter that buffers up to limit bytes in buf,
+// before writing through to w
+func NewSpillWriter(w io.Writer, buf *bytes.Buffer, limit int) *SpillWriter {
+	return &SpillWriter{w: w, buf: buf, limit: limit}
+}
+
+func (s *SpillWriter) Write(p []byte) (int, error) {
+	if !s.spilled {
+		if s.buf.Len()+len(p) <= s.limit {
+			return s.buf.Write(p)
+		}
+		s.spilled = true
+		if _, err := s.buf.WriteTo(s.w); err != nil {
+			return 0, err
+		}
+	}
+	return s.w.Write(p)
+}
+
+// Spilled returns true once the writes are no longer buffered
+func (s *SpillWriter) Spilled() bool {
+	return s.spilled
+}
+
+// Discard closes w - with its Discard method, if it's a Discarder
+func Discard(w io.Closer) error {
+	if d, ok := w.(Discarder); ok {
+		return d.Discard()
+	}
+	return w.Close()
+}
+
+// Skipper is implemented by writers that may not produce any output at all -
+// for example, when only whitespace has been written to an empty-skipper.
+type Skipper interface {
+	// Skipped returns true if nothing has been output (yet)
+	Skipped() bool
+}
+
+// Skipped returns true if w is a Skipper that hasn't produced any output
+func Skipped(w io.Writer) bool {
+	s, ok := w.(Skipper)
+	return ok && s.Skipped()
+}
+
 func allWhitespace(p []byte) bool {
 	for _, b := range p {
 		if b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' {
@@ -89,6 +167,10 @@ var (
 	_ io.WriteCloser = (*NopCloser)(nil)
 	_ io.WriteCloser = (*emptySkipper)(nil)
 	_ io.WriteCloser = (*sameSkipper)(nil)
+	_ Discarder      = (*emptySkipper)(nil)
+	_ Discarder      = (*sameSkipper)(nil)
+	_ Discarder      = (*lazyWriteCloser)(nil)
+	_ Skipper        = (*emptySkipper)(nil)
 )
 
 type sameSkipper struct {
@@ -171,6 +253,16 @@ func (f *sameSkipper) Close() error {
 	return nil
 }
 
+// Discard - implements Discarder. The existing output is left alone, unless
+// a difference has already been written.
+func (f *sameSkipper) Discard() error {
+	f.buf.Reset()
+	if f.w != nil {
+		return Discard(f.w)
+	}
+	return nil
+}
+
 // LazyWriteCloser provides an interface to a WriteCloser that will open on the
 // first access. The wrapped io.WriteCloser must be provided by 'open'.
 func LazyWriteCloser(open func() (io.WriteCloser, error)) io.WriteCloser {
@@ -205,6 +297,17 @@ func (l *lazyWriteCloser) Close() error {
 	return w.Close()
 }
 
+// Discard - implements Discarder. The wrapped writer is only closed if it
+// was already opened, and otherwise won't be opened.
+func (l *lazyWriteCloser) Discard() error {
+	opened := true
+	l.opened.Do(func() { opened = false })
+	if !opened || l.w == nil {
+		return nil
+	}
+	return Discard(l.w)
+}
+
 func (l *lazyWriteCloser) Write(p []byte) (n int, err error) {
 	w, err := l.openWriter()
 	if err != nil {
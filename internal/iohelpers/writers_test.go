@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,6 +52,7 @@ func TestEmptySkipper(t *testing.T) {
 		assert.Equal(t, len(d.in), n)
 		err = f.Close()
 		assert.NoError(t, err)
+		assert.Equal(t, d.empty, Skipped(f))
 		if d.empty {
 			assert.Nil(t, f.w)
 			assert.False(t, opened)
@@ -62,6 +64,11 @@ func TestEmptySkipper(t *testing.T) {
 	}
 }
 
+func TestSkipped(t *testing.T) {
+	assert.False(t, Skipped(&bytes.Buffer{}))
+	assert.True(t, Skipped(NewEmptySkipper(nil)))
+}
+
 func newBufferCloser(b *bytes.Buffer) *bufferCloser {
 	return &bufferCloser{b, false}
 }
@@ -158,3 +165,68 @@ func TestLazyWriteCloser(t *testing.T) {
 	err = l.Close()
 	assert.Error(t, err)
 }
+
+func TestDiscard(t *testing.T) {
+	// a lazy writer that was never opened stays unopened
+	w := newBufferCloser(&bytes.Buffer{})
+	opened := false
+	l := LazyWriteCloser(func() (io.WriteCloser, error) {
+		opened = true
+		return w, nil
+	})
+	assert.NoError(t, Discard(l))
+	assert.False(t, opened)
+	assert.False(t, w.closed)
+
+	// an opened writer is closed
+	l = LazyWriteCloser(func() (io.WriteCloser, error) {
+		return w, nil
+	})
+	_, _ = l.Write([]byte("hi"))
+	assert.NoError(t, Discard(l))
+	assert.True(t, w.closed)
+
+	// the same for writers wrapped in skippers
+	opened = false
+	e := NewEmptySkipper(func() (io.Writer, error) {
+		opened = true
+		return w, nil
+	})
+	_, _ = e.Write([]byte("  "))
+	assert.NoError(t, Discard(e))
+	assert.False(t, opened)
+
+	s := SameSkipper(strings.NewReader("existing"), func() (io.WriteCloser, error) {
+		opened = true
+		return w, nil
+	})
+	assert.NoError(t, Discard(s))
+	assert.False(t, opened)
+
+	// other closers are just closed
+	w = newBufferCloser(&bytes.Buffer{})
+	assert.NoError(t, Discard(w))
+	assert.True(t, w.closed)
+}
+
+func TestSpillWriter(t *testing.T) {
+	out, buf := &bytes.Buffer{}, &bytes.Buffer{}
+	s := NewSpillWriter(out, buf, 8)
+
+	_, err := s.Write([]byte("hello"))
+	assert.NoError(t, err)
+	assert.False(t, s.Spilled())
+	assert.Empty(t, out.String())
+	assert.Equal(t, "hello", buf.String())
+
+	_, err = s.Write([]byte(" world"))
+	assert.NoError(t, err)
+	assert.True(t, s.Spilled())
+	assert.Equal(t, "hello world", out.String())
+	assert.Empty(t, buf.String())
+
+	_, err = s.Write([]byte("!"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world!", out.String())
+	assert.Empty(t, buf.String())
+}
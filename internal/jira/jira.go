@@ -0,0 +1,136 @@
+// Package jira is a minimal, read-only client for the Jira REST API, for the
+// jira: datasource.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound - the issue doesn't exist (or isn't visible to the user)
+var ErrNotFound = errors.New("not found")
+
+// pageSize - the number of issues to request at once
+const pageSize = 100
+
+// Client - a Jira API client
+type Client struct {
+	// Base - Jira's URL, like https://example.atlassian.net
+	Base string
+
+	auth http.Header
+	hc   *http.Client
+}
+
+// New creates a client for the Jira at base. With Jira Cloud, it
+// authenticates with the email address in JIRA_USER and the API token in
+// JIRA_API_TOKEN. When JIRA_USER isn't set, the token is used as a personal
+// access token, as with Jira Server and Data Center.
+func New(hc *http.Client, base string) *Client {
+	auth := http.Header{}
+	user, token := os.Getenv("JIRA_USER"), os.Getenv("JIRA_API_TOKEN")
+	switch {
+	case user != "":
+		auth.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+token)))
+	case token != "":
+		auth.Set("Authorization", "Bearer "+token)
+	}
+	return &Client{Base: strings.TrimSuffix(base, "/"), auth: auth, hc: hc}
+}
+
+func (c *Client) get(ctx context.Context, p string, q url.Values, out interface{}) error {
+	u := c.Base + "/rest/api/2" + p
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.auth {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return json.Unmarshal(b, out)
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	e := struct {
+		ErrorMessages []string `json:"errorMessages"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && len(e.ErrorMessages) > 0 {
+		return fmt.Errorf("jira API error (HTTP %d): %s", res.StatusCode, strings.Join(e.ErrorMessages, "; "))
+	}
+	return fmt.Errorf("unexpected HTTP status %d from Jira API: %s", res.StatusCode, bytes.TrimSpace(b))
+}
+
+func fieldsQuery(fields []string) url.Values {
+	q := url.Values{}
+	if len(fields) > 0 {
+		q.Set("fields", strings.Join(fields, ","))
+	}
+	return q
+}
+
+// Issue - the issue with the key (e.g. "OPS-123"), with only the fields when
+// any are given
+func (c *Client) Issue(ctx context.Context, key string, fields []string) (json.RawMessage, error) {
+	issue := json.RawMessage{}
+	if err := c.get(ctx, "/issue/"+url.PathEscape(key), fieldsQuery(fields), &issue); err != nil {
+		return nil, fmt.Errorf("failed to read issue %s: %w", key, err)
+	}
+	return issue, nil
+}
+
+// Search - the issues matching the JQL query, with only the fields when any
+// are given. All pages of results are read, up to limit issues (0 for no
+// limit).
+func (c *Client) Search(ctx context.Context, jql string, fields []string, limit int) ([]json.RawMessage, error) {
+	issues := []json.RawMessage{}
+	q := fieldsQuery(fields)
+	q.Set("jql", jql)
+	for {
+		n := pageSize
+		if limit > 0 && limit-len(issues) < n {
+			n = limit - len(issues)
+		}
+		q.Set("startAt", strconv.Itoa(len(issues)))
+		q.Set("maxResults", strconv.Itoa(n))
+
+		resp := struct {
+			Issues []json.RawMessage `json:"issues"`
+			Total  int               `json:"total"`
+		}{}
+		if err := c.get(ctx, "/search", q, &resp); err != nil {
+			return nil, fmt.Errorf("jira search failed: %w", err)
+		}
+		issues = append(issues, resp.Issues...)
+
+		if len(resp.Issues) == 0 || len(issues) >= resp.Total || (limit > 0 && len(issues) >= limit) {
+			return issues, nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJira(t *testing.T) {
+	// 5 issues, served at most 2 at a time like a server with a low maxResults cap
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != "me@example.com" || p != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errorMessages": ["You are not authenticated."]}`))
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/rest/api/2/issue/OPS-1":
+			assert.Equal(t, "summary,status", q.Get("fields"))
+			_, _ = w.Write([]byte(`{"key": "OPS-1", "fields": {"summary": "one"}}`))
+		case "/rest/api/2/search":
+			if q.Get("jql") != "project = OPS" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errorMessages": ["Error in the JQL Query"]}`))
+				return
+			}
+			start, _ := strconv.Atoi(q.Get("startAt"))
+			n, _ := strconv.Atoi(q.Get("maxResults"))
+			if n > 2 {
+				n = 2
+			}
+			issues := []json.RawMessage{}
+			for i := start; i < start+n && i < 5; i++ {
+				issues = append(issues, json.RawMessage(fmt.Sprintf(`{"key": "OPS-%d"}`, i+1)))
+			}
+			b, _ := json.Marshal(map[string]interface{}{"startAt": start, "total": 5, "issues": issues})
+			_, _ = w.Write(b)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errorMessages": ["Issue does not exist"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("JIRA_USER", "me@example.com")
+	t.Setenv("JIRA_API_TOKEN", "tok")
+	c := New(srv.Client(), srv.URL+"/")
+	ctx := context.Background()
+
+	b, err := c.Issue(ctx, "OPS-1", []string{"summary", "status"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key": "OPS-1", "fields": {"summary": "one"}}`, string(b))
+
+	_, err = c.Issue(ctx, "OPS-99", nil)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	issues, err := c.Search(ctx, "project = OPS", nil, 0)
+	require.NoError(t, err)
+	assert.Len(t, issues, 5)
+	assert.JSONEq(t, `{"key": "OPS-5"}`, string(issues[4]))
+
+	issues, err = c.Search(ctx, "project = OPS", nil, 3)
+	require.NoError(t, err)
+	assert.Len(t, issues, 3)
+
+	_, err = c.Search(ctx, "bogus", nil, 0)
+	assert.EqualError(t, err, "jira search failed: jira API error (HTTP 400): Error in the JQL Query")
+
+	t.Setenv("JIRA_USER", "")
+	c = New(srv.Client(), srv.URL)
+	assert.Equal(t, "Bearer tok", c.auth.Get("Authorization"))
+}
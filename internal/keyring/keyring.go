@@ -0,0 +1,157 @@
+// Package keyring stores datasource tokens in the OS keychain (the macOS
+// Keychain, Windows Credential Manager, or a Secret Service provider like GNOME
+// Keyring), so they can be referenced as 'keyring://item' instead of being
+// kept in environment variables, where they tend to leak into shell history.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// service - the name items are stored under in the keychain
+const service = "gomplate"
+
+var (
+	// reference - a 'keyring://item' reference to a stored secret
+	reference = regexp.MustCompile(`keyring://([A-Za-z0-9._@-]+)`)
+	validItem = regexp.MustCompile(`^[A-Za-z0-9._@-]+$`)
+)
+
+var (
+	mu sync.Mutex
+	// secrets that have been read, so the keychain (which may prompt the
+	// user) is only asked once for each item
+	secrets = map[string]string{}
+)
+
+// Get - the secret stored for the item
+func Get(item string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if s, ok := secrets[item]; ok {
+		return s, nil
+	}
+	s, err := keyring.Get(service, item)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("no secret for %q in the keyring - store one with 'gomplate keyring set %s'", item, item)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q from the keyring: %w", item, err)
+	}
+	secrets[item] = s
+	return s, nil
+}
+
+// Set - store the secret for the item, replacing any that's already stored
+func Set(item, secret string) error {
+	if !validItem.MatchString(item) {
+		return fmt.Errorf("invalid keyring item %q - names can only have letters, digits, '.', '_', '@', and '-'", item)
+	}
+	if err := keyring.Set(service, item, secret); err != nil {
+		return fmt.Errorf("failed to store %q in the keyring: %w", item, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	delete(secrets, item)
+	return nil
+}
+
+// Delete - remove the item's secret from the keyring
+func Delete(item string) error {
+	err := keyring.Delete(service, item)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("no secret for %q in the keyring", item)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %q from the keyring: %w", item, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	delete(secrets, item)
+	return nil
+}
+
+// Expand replaces the 'keyring://item' references in s with the items'
+// secrets
+func Expand(s string) (string, error) {
+	if !strings.Contains(s, "keyring://") {
+		return s, nil
+	}
+	var err error
+	out := reference.ReplaceAllStringFunc(s, func(m string) string {
+		if err != nil {
+			return m
+		}
+		var secret string
+		secret, err = Get(reference.FindStringSubmatch(m)[1])
+		return secret
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// ExpandHeader - h, with references in its values expanded. h is returned
+// as-is when there are none, and copied otherwise.
+func ExpandHeader(h http.Header) (http.Header, error) {
+	var out http.Header
+	for k, vs := range h {
+		for i, v := range vs {
+			e, err := Expand(v)
+			if err != nil {
+				return nil, fmt.Errorf("header %s: %w", k, err)
+			}
+			if e == v {
+				continue
+			}
+			if out == nil {
+				out = h.Clone()
+			}
+			out[k][i] = e
+		}
+	}
+	if out == nil {
+		return h, nil
+	}
+	return out, nil
+}
+
+// ExpandURL - u, with references in its query parameter values expanded. u is
+// returned as-is when there are none, and copied otherwise.
+func ExpandURL(u *url.URL) (*url.URL, error) {
+	if !strings.Contains(u.RawQuery, "keyring") {
+		return u, nil
+	}
+	q := u.Query()
+	changed := false
+	for k, vs := range q {
+		for i, v := range vs {
+			e, err := Expand(v)
+			if err != nil {
+				return nil, fmt.Errorf("query parameter %s: %w", k, err)
+			}
+			if e != v {
+				vs[i] = e
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return u, nil
+	}
+	out := *u
+	out.RawQuery = q.Encode()
+	return &out, nil
+}
@@ -0,0 +1,85 @@
+package keyring
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestKeyring(t *testing.T) {
+	keyring.MockInit()
+
+	_, err := Get("gh-token")
+	assert.ErrorContains(t, err, "gomplate keyring set gh-token")
+
+	require.NoError(t, Set("gh-token", "s3cret"))
+	s, err := Get("gh-token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", s)
+
+	// setting again replaces the secret, even once it's been read
+	require.NoError(t, Set("gh-token", "n3w"))
+	s, err = Get("gh-token")
+	require.NoError(t, err)
+	assert.Equal(t, "n3w", s)
+
+	assert.Error(t, Set("no spaces", "x"))
+
+	require.NoError(t, Delete("gh-token"))
+	_, err = Get("gh-token")
+	assert.Error(t, err)
+	assert.Error(t, Delete("gh-token"))
+}
+
+func TestExpand(t *testing.T) {
+	keyring.MockInit()
+	require.NoError(t, Set("api.token", "s3cret"))
+	require.NoError(t, Set("user", "alice"))
+
+	s, err := Expand("Bearer keyring://api.token")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer s3cret", s)
+
+	s, err = Expand("keyring://user:keyring://api.token")
+	require.NoError(t, err)
+	assert.Equal(t, "alice:s3cret", s)
+
+	s, err = Expand("no references")
+	require.NoError(t, err)
+	assert.Equal(t, "no references", s)
+
+	_, err = Expand("Bearer keyring://missing")
+	assert.ErrorContains(t, err, `"missing"`)
+
+	h := http.Header{
+		"Authorization": {"Bearer keyring://api.token"},
+		"Accept":        {"application/json"},
+	}
+	out, err := ExpandHeader(h)
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{
+		"Authorization": {"Bearer s3cret"},
+		"Accept":        {"application/json"},
+	}, out)
+	// the original isn't modified
+	assert.Equal(t, "Bearer keyring://api.token", h.Get("Authorization"))
+
+	out, err = ExpandHeader(nil)
+	require.NoError(t, err)
+	assert.Nil(t, out)
+
+	u, _ := url.Parse("https://example.com/api?token=keyring://api.token&x=1")
+	eu, err := ExpandURL(u)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/api?token=s3cret&x=1", eu.String())
+	assert.Equal(t, "https://example.com/api?token=keyring://api.token&x=1", u.String())
+
+	u, _ = url.Parse("https://example.com/api?x=1")
+	eu, err = ExpandURL(u)
+	require.NoError(t, err)
+	assert.Same(t, u, eu)
+}
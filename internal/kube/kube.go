@@ -0,0 +1,286 @@
+// Package kube is a minimal client for the Kubernetes API, for reading and
+// writing core objects like ConfigMaps and Secrets. It only supports what
+// gomplate needs, so that client-go (and its many dependencies) isn't needed.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir - where the pod's service account credentials are
+// mounted when running in a cluster
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ErrNotFound - returned (wrapped) when an object doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict - returned (wrapped) when an object was modified since it was
+// read, or already exists
+var ErrConflict = errors.New("conflict")
+
+// Client - a Kubernetes API client
+type Client struct {
+	// Server - the API server's URL
+	Server string
+	// Token - the bearer token to authenticate with, if any
+	Token string
+	// HTTPClient - defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// Namespace - the default namespace (the pod's namespace in a cluster,
+	// or the kubeconfig context's namespace), if any
+	Namespace string
+}
+
+// New creates a client for the given API server URL (such as one served by
+// 'kubectl proxy'), or, when server is empty, for the cluster gomplate is
+// running in, authenticated with the pod's service account.
+func New(server string) (*Client, error) {
+	if server != "" {
+		return &Client{Server: strings.TrimSuffix(server, "/")}, nil
+	}
+	return inCluster()
+}
+
+func inCluster() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster (KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set)")
+	}
+
+	token, err := os.ReadFile(path.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(path.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in service account CA certificate")
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	return &Client{
+		Server:     "https://" + net.JoinHostPort(host, port),
+		Token:      strings.TrimSpace(string(token)),
+		HTTPClient: &http.Client{Transport: tr, Timeout: 30 * time.Second},
+		Namespace:  CurrentNamespace(),
+	}, nil
+}
+
+// CurrentNamespace - the namespace of the pod gomplate is running in, or ""
+// when not running in a cluster
+func CurrentNamespace() string {
+	b, err := os.ReadFile(path.Join(serviceAccountDir, "namespace"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// ObjectMeta - the metadata common to all objects
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	UID             string            `json:"uid,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty"`
+}
+
+// OwnerReference - identifies the object that owns another, so the owned
+// object is deleted along with its owner
+type OwnerReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	Controller bool   `json:"controller,omitempty"`
+}
+
+// Object - a core/v1 object with string data, like a ConfigMap or Secret
+type Object struct {
+	APIVersion string     `json:"apiVersion,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Metadata   ObjectMeta `json:"metadata"`
+	// Type - the Secret's type
+	Type string `json:"type,omitempty"`
+	// Data - the ConfigMap's data, or the Secret's base64-encoded data
+	Data map[string]string `json:"data,omitempty"`
+	// BinaryData - the ConfigMap's base64-encoded binary data
+	BinaryData map[string]string `json:"binaryData,omitempty"`
+	// StringData - the Secret's data, to be written unencoded
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+type objectList struct {
+	Items []Object `json:"items"`
+}
+
+// resourcePath - the API path for a core/v1 resource (like "configmaps"),
+// optionally namespaced and named
+func resourcePath(resource, namespace, name string) string {
+	p := "/api/v1"
+	if namespace != "" {
+		p += "/namespaces/" + url.PathEscape(namespace)
+	}
+	p += "/" + resource
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+// List the objects of a core/v1 resource (like "configmaps") matching the
+// label selector. When namespace is empty, all namespaces are listed.
+func (c *Client) List(ctx context.Context, resource, namespace, labelSelector string) ([]Object, error) {
+	p := resourcePath(resource, namespace, "")
+	if labelSelector != "" {
+		p += "?labelSelector=" + url.QueryEscape(labelSelector)
+	}
+	l := objectList{}
+	if err := c.do(ctx, http.MethodGet, p, nil, &l); err != nil {
+		return nil, err
+	}
+	return l.Items, nil
+}
+
+// Get an object of a core/v1 resource
+func (c *Client) Get(ctx context.Context, resource, namespace, name string) (*Object, error) {
+	o := &Object{}
+	if err := c.do(ctx, http.MethodGet, resourcePath(resource, namespace, name), nil, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Create an object of a core/v1 resource, in the object's namespace
+func (c *Client) Create(ctx context.Context, resource string, obj *Object) (*Object, error) {
+	o := &Object{}
+	err := c.do(ctx, http.MethodPost, resourcePath(resource, obj.Metadata.Namespace, ""), obj, o)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Update (replace) an object of a core/v1 resource. The object's
+// resourceVersion must be current, or an error wrapping ErrConflict is
+// returned.
+func (c *Client) Update(ctx context.Context, resource string, obj *Object) (*Object, error) {
+	o := &Object{}
+	err := c.do(ctx, http.MethodPut, resourcePath(resource, obj.Metadata.Namespace, obj.Metadata.Name), obj, o)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Apply creates or updates an object of a core/v1 resource with server-side
+// apply, as the given field manager. Only the fields set in obj are applied,
+// and fields the field manager applied before (but aren't in obj) are
+// removed. If obj has a resourceVersion, it must be current. Unless force is
+// set, applying fields owned by other field managers is a conflict.
+func (c *Client) Apply(ctx context.Context, resource string, obj *Object, fieldManager string, force bool) (*Object, error) {
+	q := url.Values{"fieldManager": []string{fieldManager}}
+	if force {
+		q.Set("force", "true")
+	}
+	p := resourcePath(resource, obj.Metadata.Namespace, obj.Metadata.Name) + "?" + q.Encode()
+
+	o := &Object{}
+	if err := c.doRequest(ctx, http.MethodPatch, p, applyPatchType, obj, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// applyPatchType - the content type of server-side apply patches. JSON is
+// valid YAML, so objects can be sent as JSON.
+const applyPatchType = "application/apply-patch+yaml"
+
+// status - the API's error response
+type status struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+func (c *Client) do(ctx context.Context, method, p string, body, out interface{}) error {
+	return c.doRequest(ctx, method, p, "application/json", body, out)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, p, contentType string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.Server+p, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes API request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read kubernetes API response: %w", err)
+	}
+
+	if res.StatusCode >= 300 {
+		s := status{}
+		_ = json.Unmarshal(b, &s)
+		if s.Message == "" {
+			s.Message = http.StatusText(res.StatusCode)
+		}
+		switch res.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%s %s: %w: %s", method, p, ErrNotFound, s.Message)
+		case http.StatusConflict:
+			return fmt.Errorf("%s %s: %w: %s", method, p, ErrConflict, s.Message)
+		}
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, p, res.StatusCode, s.Message)
+	}
+
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("failed to parse kubernetes API response: %w", err)
+	}
+	return nil
+}
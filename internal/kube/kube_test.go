@@ -0,0 +1,189 @@
+package kube_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube/kubetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	srv := kubetest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := kube.New(srv.URL + "/")
+	require.NoError(t, err)
+
+	srv.Put("configmaps", kube.Object{Metadata: kube.ObjectMeta{Name: "a", Namespace: "ns1", Labels: map[string]string{"app": "x"}}})
+	srv.Put("configmaps", kube.Object{Metadata: kube.ObjectMeta{Name: "b", Namespace: "ns2", Labels: map[string]string{"app": "x"}}})
+	srv.Put("configmaps", kube.Object{Metadata: kube.ObjectMeta{Name: "c", Namespace: "ns1"}})
+
+	l, err := c.List(ctx, "configmaps", "", "app=x")
+	require.NoError(t, err)
+	require.Len(t, l, 2)
+	assert.Equal(t, "a", l[0].Metadata.Name)
+	assert.Equal(t, "b", l[1].Metadata.Name)
+
+	l, err = c.List(ctx, "configmaps", "ns1", "")
+	require.NoError(t, err)
+	assert.Len(t, l, 2)
+
+	_, err = c.Get(ctx, "secrets", "ns1", "missing")
+	assert.True(t, errors.Is(err, kube.ErrNotFound))
+
+	o, err := c.Create(ctx, "secrets", &kube.Object{
+		Metadata:   kube.ObjectMeta{Name: "s", Namespace: "ns1"},
+		StringData: map[string]string{"k": "v"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "dg==", o.Data["k"])
+
+	_, err = c.Create(ctx, "secrets", &kube.Object{Metadata: kube.ObjectMeta{Name: "s", Namespace: "ns1"}})
+	assert.True(t, errors.Is(err, kube.ErrConflict))
+
+	stale := *o
+	o.StringData = map[string]string{"k": "w"}
+	o.Data = nil
+	o, err = c.Update(ctx, "secrets", o)
+	require.NoError(t, err)
+	assert.Equal(t, "dw==", o.Data["k"])
+
+	_, err = c.Update(ctx, "secrets", &stale)
+	assert.True(t, errors.Is(err, kube.ErrConflict))
+
+	// apply creates, and then merges
+	o, err = c.Apply(ctx, "configmaps", &kube.Object{
+		APIVersion: "v1", Kind: "ConfigMap",
+		Metadata: kube.ObjectMeta{Name: "applied", Namespace: "ns1", Labels: map[string]string{"app": "y"}},
+		Data:     map[string]string{"a": "1"},
+	}, "test", false)
+	require.NoError(t, err)
+	assert.Equal(t, "1", o.Metadata.ResourceVersion)
+
+	o, err = c.Apply(ctx, "configmaps", &kube.Object{
+		Metadata: kube.ObjectMeta{Name: "applied", Namespace: "ns1", ResourceVersion: "1"},
+		Data:     map[string]string{"b": "2"},
+	}, "test", false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, o.Data)
+	assert.Equal(t, "y", o.Metadata.Labels["app"])
+
+	_, err = c.Apply(ctx, "configmaps", &kube.Object{
+		Metadata: kube.ObjectMeta{Name: "applied", Namespace: "ns1", ResourceVersion: "1"},
+	}, "test", true)
+	assert.True(t, errors.Is(err, kube.ErrConflict))
+}
+
+func TestNewInCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err := kube.New("")
+	assert.Error(t, err)
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	_, err = kube.New("")
+	// there's no service account outside of a pod
+	if _, serr := os.Stat(filepath.Join("/var/run/secrets/kubernetes.io/serviceaccount", "token")); serr != nil {
+		assert.Error(t, err)
+	}
+}
+
+func TestFromKubeconfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"metadata": {"name": "cm", "namespace": "team"}, "data": {"k": "v"}}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("s3cr3t\n"), 0o600))
+
+	// the first file to set the current context (or define a name) wins, and
+	// relative paths are relative to the file
+	first := filepath.Join(dir, "first")
+	require.NoError(t, os.WriteFile(first, []byte(`current-context: dev
+contexts:
+  - name: dev
+    context: {cluster: dev, user: dev, namespace: team}
+users:
+  - name: dev
+    user:
+      tokenFile: token
+`), 0o600))
+	second := filepath.Join(dir, "second")
+	require.NoError(t, os.WriteFile(second, []byte(`current-context: prod
+clusters:
+  - name: dev
+    cluster:
+      server: `+srv.URL+`
+      certificate-authority: ca.crt
+users:
+  - name: dev
+    user:
+      token: wrong
+`), 0o600))
+
+	c, err := kube.FromKubeconfig(first, filepath.Join(dir, "missing"), second)
+	require.NoError(t, err)
+	assert.Equal(t, "team", c.Namespace)
+	o, err := c.Get(context.Background(), "configmaps", "team", "cm")
+	require.NoError(t, err)
+	assert.Equal(t, "v", o.Data["k"])
+
+	_, err = kube.FromKubeconfig(second)
+	assert.ErrorContains(t, err, `context "prod" not found`)
+
+	_, err = kube.FromKubeconfig(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "no current context")
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBECONFIG", first+string(filepath.ListSeparator)+second)
+	c, err = kube.Default()
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL, c.Server)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// credential plugins are run to get a token
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "exec"), []byte(`current-context: dev
+contexts:
+  - name: dev
+    context: {cluster: dev, user: plugin}
+clusters:
+  - name: dev
+    cluster:
+      server: `+srv.URL+`
+      certificate-authority-data: `+base64.StdEncoding.EncodeToString(ca)+`
+users:
+  - name: plugin
+    user:
+      exec:
+        command: sh
+        args: [-c, 'echo "{\"status\": {\"token\": \"$TOKEN\"}}"']
+        env:
+          - {name: TOKEN, value: s3cr3t}
+`), 0o600))
+	c, err = kube.FromKubeconfig(filepath.Join(dir, "exec"))
+	require.NoError(t, err)
+	_, err = c.Get(context.Background(), "configmaps", "team", "cm")
+	require.NoError(t, err)
+}
@@ -0,0 +1,296 @@
+package kube
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/yaml"
+)
+
+// kubeconfig - the parts of a kubeconfig file that are used
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string  `yaml:"name"`
+		Cluster cluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string      `yaml:"name"`
+		Context kubeContext `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User user   `yaml:"user"`
+	} `yaml:"users"`
+}
+
+type cluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+	TLSServerName            string `yaml:"tls-server-name"`
+}
+
+type kubeContext struct {
+	Cluster   string `yaml:"cluster"`
+	User      string `yaml:"user"`
+	Namespace string `yaml:"namespace"`
+}
+
+type user struct {
+	Token                 string      `yaml:"token"`
+	TokenFile             string      `yaml:"tokenFile"`
+	ClientCertificate     string      `yaml:"client-certificate"`
+	ClientCertificateData string      `yaml:"client-certificate-data"`
+	ClientKey             string      `yaml:"client-key"`
+	ClientKeyData         string      `yaml:"client-key-data"`
+	Exec                  *execConfig `yaml:"exec"`
+}
+
+// execConfig - a credential plugin, like 'aws eks get-token', which prints
+// an ExecCredential with a token or client certificate
+type execConfig struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Env        []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// Default creates a client for the cluster gomplate is running in, or,
+// outside of a cluster, for the current context of the kubeconfig files in
+// KUBECONFIG (or ~/.kube/config)
+func Default() (*Client, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return inCluster()
+	}
+
+	paths := filepath.SplitList(os.Getenv("KUBECONFIG"))
+	if len(paths) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find kubeconfig file: %w", err)
+		}
+		paths = []string{filepath.Join(home, ".kube", "config")}
+	}
+	return FromKubeconfig(paths...)
+}
+
+// FromKubeconfig creates a client for the current context of the kubeconfig
+// files. Like kubectl, when several files are given, the first to set a value
+// (like a cluster or user with a particular name) wins. Files that don't
+// exist are ignored.
+func FromKubeconfig(paths ...string) (*Client, error) {
+	var ctx *kubeContext
+	var cl *cluster
+	var u *user
+	var cur string
+	// file paths in kubeconfigs are relative to the file they're in
+	var clDir, uDir string
+
+	configs := []kubeconfig{}
+	dirs := []string{}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+		}
+		kc := kubeconfig{}
+		if err := yaml.Unmarshal(b, &kc); err != nil {
+			return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", p, err)
+		}
+		if cur == "" {
+			cur = kc.CurrentContext
+		}
+		configs = append(configs, kc)
+		dirs = append(dirs, filepath.Dir(p))
+	}
+	if cur == "" {
+		return nil, fmt.Errorf("no current context set in kubeconfig (%s)", strings.Join(paths, string(filepath.ListSeparator)))
+	}
+
+	for _, kc := range configs {
+		for _, c := range kc.Contexts {
+			if ctx == nil && c.Name == cur {
+				c := c.Context
+				ctx = &c
+			}
+		}
+	}
+	if ctx == nil {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", cur)
+	}
+	for i, kc := range configs {
+		for _, c := range kc.Clusters {
+			if cl == nil && c.Name == ctx.Cluster {
+				c := c.Cluster
+				cl, clDir = &c, dirs[i]
+			}
+		}
+		for _, c := range kc.Users {
+			if u == nil && c.Name == ctx.User {
+				c := c.User
+				u, uDir = &c, dirs[i]
+			}
+		}
+	}
+	if cl == nil || cl.Server == "" {
+		return nil, fmt.Errorf("cluster %q for context %q not found in kubeconfig", ctx.Cluster, cur)
+	}
+	if u == nil {
+		u = &user{}
+	}
+
+	c, err := newClient(cl, clDir, u, uDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig context %q: %w", cur, err)
+	}
+	c.Namespace = ctx.Namespace
+	return c, nil
+}
+
+func newClient(cl *cluster, clDir string, u *user, uDir string) (*Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cl.TLSServerName,
+		InsecureSkipVerify: cl.InsecureSkipTLSVerify, //nolint:gosec
+	}
+
+	ca, err := fileOrData(cl.CertificateAuthority, cl.CertificateAuthorityData, clDir)
+	if err != nil {
+		return nil, fmt.Errorf("certificate authority: %w", err)
+	}
+	if len(ca) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in the certificate authority")
+		}
+	}
+
+	c := &Client{Server: strings.TrimSuffix(cl.Server, "/"), Token: u.Token}
+	if c.Token == "" && u.TokenFile != "" {
+		b, err := os.ReadFile(resolve(u.TokenFile, uDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		c.Token = strings.TrimSpace(string(b))
+	}
+
+	cert, err := fileOrData(u.ClientCertificate, u.ClientCertificateData, uDir)
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+	key, err := fileOrData(u.ClientKey, u.ClientKeyData, uDir)
+	if err != nil {
+		return nil, fmt.Errorf("client key: %w", err)
+	}
+
+	if u.Exec != nil {
+		cred, err := runExec(u.Exec)
+		if err != nil {
+			return nil, err
+		}
+		if cred.Token != "" {
+			c.Token = cred.Token
+		}
+		if cred.ClientCertificateData != "" {
+			cert, key = []byte(cred.ClientCertificateData), []byte(cred.ClientKeyData)
+		}
+	}
+
+	if len(cert) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = tlsConfig
+	c.HTTPClient = &http.Client{Transport: tr, Timeout: 30 * time.Second}
+	return c, nil
+}
+
+// fileOrData - the base64-encoded data, or else the content of the file
+func fileOrData(file, data, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(resolve(file, dir))
+	}
+	return nil, nil
+}
+
+func resolve(p, dir string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// execCredential - the status of the ExecCredential printed by credential
+// plugins
+type execCredential struct {
+	Token                 string `json:"token"`
+	ClientCertificateData string `json:"clientCertificateData"`
+	ClientKeyData         string `json:"clientKeyData"`
+}
+
+// runExec runs the credential plugin. The credentials are only fetched once,
+// since they're only needed for as long as gomplate runs.
+func runExec(e *execConfig) (*execCredential, error) {
+	apiVersion := e.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1"
+	}
+	info, err := json.Marshal(map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]interface{}{"interactive": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec
+	cmd := exec.Command(e.Command, e.Args...)
+	cmd.Env = append(os.Environ(), "KUBERNETES_EXEC_INFO="+string(info))
+	for _, v := range e.Env {
+		cmd.Env = append(cmd.Env, v.Name+"="+v.Value)
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential plugin %s failed: %w: %s", e.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	ec := struct {
+		Status *execCredential `json:"status"`
+	}{}
+	if err := json.Unmarshal(out, &ec); err != nil {
+		return nil, fmt.Errorf("invalid output from credential plugin %s: %w", e.Command, err)
+	}
+	if ec.Status == nil {
+		return nil, fmt.Errorf("credential plugin %s returned no credentials", e.Command)
+	}
+	return ec.Status, nil
+}
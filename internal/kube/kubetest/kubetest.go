@@ -0,0 +1,227 @@
+// Package kubetest provides an in-memory fake of the parts of the Kubernetes
+// API used by the kube package, for tests.
+package kubetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+)
+
+// Server - a fake API server, storing core/v1 objects in memory
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]kube.Object
+	nextUID int
+}
+
+// NewServer starts a fake API server - call Close when done
+func NewServer() *Server {
+	s := &Server{objects: map[string]kube.Object{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func key(resource, namespace, name string) string {
+	return resource + "/" + namespace + "/" + name
+}
+
+// Put stores an object directly, assigning a UID and resourceVersion
+func (s *Server) Put(resource string, obj kube.Object) kube.Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(resource, obj)
+}
+
+func (s *Server) put(resource string, obj kube.Object) kube.Object {
+	k := key(resource, obj.Metadata.Namespace, obj.Metadata.Name)
+	prev, ok := s.objects[k]
+	switch {
+	case ok:
+		obj.Metadata.UID = prev.Metadata.UID
+	case obj.Metadata.UID == "":
+		s.nextUID++
+		obj.Metadata.UID = fmt.Sprintf("uid-%d", s.nextUID)
+	}
+	v, _ := strconv.Atoi(prev.Metadata.ResourceVersion)
+	obj.Metadata.ResourceVersion = strconv.Itoa(v + 1)
+
+	// like the real API, Secrets' stringData is merged into data
+	if len(obj.StringData) > 0 {
+		if obj.Data == nil {
+			obj.Data = map[string]string{}
+		}
+		for dk, dv := range obj.StringData {
+			obj.Data[dk] = base64.StdEncoding.EncodeToString([]byte(dv))
+		}
+		obj.StringData = nil
+	}
+
+	s.objects[k] = obj
+	return obj
+}
+
+// Get returns a stored object, if it exists
+func (s *Server) Get(resource, namespace, name string) (kube.Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[key(resource, namespace, name)]
+	return o, ok
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// /api/v1[/namespaces/NS]/RESOURCE[/NAME]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	namespace := ""
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	resource, name := parts[0], ""
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		s.list(w, resource, namespace, r.URL.Query().Get("labelSelector"))
+	case r.Method == http.MethodGet:
+		o, ok := s.objects[key(resource, namespace, name)]
+		if !ok {
+			writeStatus(w, http.StatusNotFound, "NotFound", fmt.Sprintf("%s %q not found", resource, name))
+			return
+		}
+		writeJSON(w, http.StatusOK, o)
+	case r.Method == http.MethodPost || r.Method == http.MethodPut:
+		o := kube.Object{}
+		if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+			writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		if o.Metadata.Namespace == "" {
+			o.Metadata.Namespace = namespace
+		}
+		prev, exists := s.objects[key(resource, namespace, o.Metadata.Name)]
+		switch {
+		case r.Method == http.MethodPost && exists:
+			writeStatus(w, http.StatusConflict, "AlreadyExists", fmt.Sprintf("%s %q already exists", resource, o.Metadata.Name))
+			return
+		case r.Method == http.MethodPut && !exists:
+			writeStatus(w, http.StatusNotFound, "NotFound", fmt.Sprintf("%s %q not found", resource, o.Metadata.Name))
+			return
+		case r.Method == http.MethodPut && o.Metadata.ResourceVersion != prev.Metadata.ResourceVersion:
+			writeStatus(w, http.StatusConflict, "Conflict", "the object has been modified")
+			return
+		}
+		status := http.StatusOK
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, s.put(resource, o))
+	case r.Method == http.MethodPatch && r.Header.Get("Content-Type") == "application/apply-patch+yaml":
+		s.apply(w, r, resource, namespace, name)
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// apply - a simplified server-side apply: labels and data are merged into
+// the existing object (if any), without tracking field managers
+func (s *Server) apply(w http.ResponseWriter, r *http.Request, resource, namespace, name string) {
+	if r.URL.Query().Get("fieldManager") == "" {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "fieldManager is required for apply requests")
+		return
+	}
+	o := kube.Object{}
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	prev, exists := s.objects[key(resource, namespace, name)]
+	if v := o.Metadata.ResourceVersion; v != "" && (!exists || v != prev.Metadata.ResourceVersion) {
+		writeStatus(w, http.StatusConflict, "Conflict", "the object has been modified")
+		return
+	}
+	if !exists {
+		prev = kube.Object{Metadata: kube.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	if len(o.Metadata.Labels) > 0 && prev.Metadata.Labels == nil {
+		prev.Metadata.Labels = map[string]string{}
+	}
+	for k, v := range o.Metadata.Labels {
+		prev.Metadata.Labels[k] = v
+	}
+	if len(o.Data) > 0 && prev.Data == nil {
+		prev.Data = map[string]string{}
+	}
+	for k, v := range o.Data {
+		prev.Data[k] = v
+	}
+	if o.Type != "" {
+		prev.Type = o.Type
+	}
+	prev.APIVersion, prev.Kind = o.APIVersion, o.Kind
+	writeJSON(w, http.StatusOK, s.put(resource, prev))
+}
+
+func (s *Server) list(w http.ResponseWriter, resource, namespace, selector string) {
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	items := []kube.Object{}
+	for _, k := range keys {
+		o := s.objects[k]
+		if !strings.HasPrefix(k, resource+"/") {
+			continue
+		}
+		if namespace != "" && o.Metadata.Namespace != namespace {
+			continue
+		}
+		if !matchesSelector(o.Metadata.Labels, selector) {
+			continue
+		}
+		items = append(items, o)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+// matchesSelector supports equality-based selectors only (like "a=b,c=d")
+func matchesSelector(labels map[string]string, selector string) bool {
+	if selector == "" {
+		return true
+	}
+	for _, req := range strings.Split(selector, ",") {
+		k, v, _ := strings.Cut(req, "=")
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeStatus(w http.ResponseWriter, status int, reason, message string) {
+	writeJSON(w, status, map[string]string{"kind": "Status", "reason": reason, "message": message})
+}
@@ -0,0 +1,65 @@
+// Package mapmeta records information about maps parsed from documents -
+// the order of their keys, and the YAML nodes they were parsed from - so that
+// it can be used when the maps are output again. The information is kept
+// out-of-band so that the maps are still plain maps that can be used in
+// templates as usual.
+package mapmeta
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/hairyhenderson/yaml"
+)
+
+// Meta - information about a parsed map
+type Meta struct {
+	// Keys - the map's keys, in the order they appeared in the document
+	Keys []string
+	// Node - the YAML mapping node the map was parsed from, if comments are
+	// being preserved
+	Node *yaml.Node
+	// Document - the YAML document node, for the document's top-level map,
+	// if comments are being preserved
+	Document *yaml.Node
+}
+
+// entries are keyed by the map's address. The map is held in the entry, so
+// its address can't be reused while the entry exists.
+type entry struct {
+	m    map[string]interface{}
+	meta Meta
+}
+
+var (
+	mu      sync.RWMutex
+	entries = map[uintptr]entry{}
+)
+
+// Record - record information about the map
+func Record(m map[string]interface{}, meta Meta) {
+	mu.Lock()
+	defer mu.Unlock()
+	entries[reflect.ValueOf(m).Pointer()] = entry{m: m, meta: meta}
+}
+
+// Get - get the information recorded about the map, if there is any
+func Get(m map[string]interface{}) (Meta, bool) {
+	if m == nil {
+		return Meta{}, false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := entries[reflect.ValueOf(m).Pointer()]
+	return e.meta, ok
+}
+
+// Inherit - record src's information for dst, unless dst already has some.
+// This is used when maps are copied, so that copies are output the same way.
+func Inherit(dst, src map[string]interface{}) {
+	if meta, ok := Get(src); ok {
+		if _, ok := Get(dst); !ok {
+			Record(dst, meta)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package mapmeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	m := map[string]interface{}{"b": 1, "a": 2}
+	_, ok := Get(m)
+	assert.False(t, ok)
+
+	Record(m, Meta{Keys: []string{"b", "a"}})
+	meta, ok := Get(m)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"b", "a"}, meta.Keys)
+
+	_, ok = Get(nil)
+	assert.False(t, ok)
+}
+
+func TestInherit(t *testing.T) {
+	src := map[string]interface{}{"b": 1, "a": 2}
+	Record(src, Meta{Keys: []string{"b", "a"}})
+
+	dst := map[string]interface{}{}
+	Inherit(dst, src)
+	meta, ok := Get(dst)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"b", "a"}, meta.Keys)
+
+	// existing information isn't replaced
+	other := map[string]interface{}{"z": 1}
+	Record(other, Meta{Keys: []string{"z"}})
+	Inherit(other, src)
+	meta, _ = Get(other)
+	assert.Equal(t, []string{"z"}, meta.Keys)
+
+	// nothing is recorded when src has no information
+	none := map[string]interface{}{}
+	Inherit(none, map[string]interface{}{})
+	_, ok = Get(none)
+	assert.False(t, ok)
+}
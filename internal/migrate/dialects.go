@@ -0,0 +1,143 @@
+package migrate
+
+// Helm - the functions and built-in objects of Helm chart templates (mostly
+// from the Sprig library)
+var Helm = &Dialect{
+	Name: "Helm",
+	Funcs: map[string]string{
+		"toYaml":       "data.ToYAML",
+		"toJson":       "data.ToJSON",
+		"toPrettyJson": `data.ToJSONPretty "  "`,
+		"fromYaml":     "data.YAML",
+		"fromJson":     "data.JSON",
+		"upper":        "strings.ToUpper",
+		"lower":        "strings.ToLower",
+		"trim":         "strings.TrimSpace",
+		"trimAll":      "strings.Trim",
+		"trimPrefix":   "strings.TrimPrefix",
+		"trimSuffix":   "strings.TrimSuffix",
+		"trunc":        "strings.Trunc",
+		"replace":      "strings.ReplaceAll",
+		"repeat":       "strings.Repeat",
+		"splitList":    "strings.Split",
+		"empty":        "not",
+		"b64enc":       "base64.Encode",
+		"b64dec":       "base64.Decode",
+		"sha256sum":    "crypto.SHA256",
+		"sha1sum":      "crypto.SHA1",
+		"list":         "coll.Slice",
+		"hasKey":       "coll.Has",
+		"toString":     "conv.ToString",
+		"int":          "conv.ToInt",
+		"int64":        "conv.ToInt64",
+		"float64":      "conv.ToFloat64",
+		"atoi":         "conv.Atoi",
+		"include":      "tmpl.Exec",
+		"uuidv4":       "uuid.V4",
+		"randAlphaNum": "random.AlphaNum",
+		"now":          "time.Now",
+		"regexMatch":   "regexp.Match",
+		"kindIs":       "test.IsKind",
+		"base":         "path.Base",
+		"dir":          "path.Dir",
+		"ext":          "path.Ext",
+		"max":          "math.Max",
+		"min":          "math.Min",
+		"ceil":         "math.Ceil",
+		"floor":        "math.Floor",
+	},
+	Warnings: map[string]string{
+		"toYaml":  "adds a trailing newline in gomplate - use '| trimSpace' if it matters",
+		"empty":   "was converted to 'not', which treats empty maps and lists as true, but not zero-valued structs",
+		"default": "doesn't treat empty maps and lists as unset in gomplate - check the values",
+	},
+	Unsupported: map[string]string{
+		"nindent":           `use 'indent' with a leading newline, like '{{ "\n" }}{{ ... | indent 4 }}'`,
+		"join":              "gomplate's join takes the list first - use 'conv.Join LIST SEP'",
+		"split":             "Sprig's split returns a map - use 'strings.Split SEP STRING', which returns a list",
+		"append":            "gomplate's append takes the value first - use 'coll.Append VALUE LIST'",
+		"pick":              "use 'coll.Pick KEYS... MAP', which takes the map last",
+		"omit":              "use 'coll.Omit KEYS... MAP', which takes the map last",
+		"regexReplaceAll":   "use 'regexp.Replace REGEX REPL INPUT', which takes the input last",
+		"div":               "gomplate's div returns a float - use 'math.Div' and 'conv.ToInt'",
+		"lookup":            "gomplate can't query the Kubernetes API - use the k8s datasource",
+		"semverCompare":     "gomplate has no semver functions",
+		"set":               "gomplate maps are read-only - build a new map with 'coll.Merge'",
+		"unset":             "gomplate maps are read-only - build a new map with 'coll.Omit'",
+		"genCA":             "gomplate has no certificate functions",
+		"genSignedCert":     "gomplate has no certificate functions",
+		"genSelfSignedCert": "gomplate has no certificate functions",
+	},
+	Fields: map[string]string{
+		"Release":      "define the release's name and namespace in values.yaml, or in a datasource",
+		"Chart":        "read Chart.yaml as a datasource, or copy the needed fields into values.yaml",
+		"Capabilities": "gomplate has no equivalent of the cluster's capabilities",
+		"Files":        "use 'file.Read', or define the files as datasources",
+		"Template":     "gomplate has no equivalent - use the template name directly",
+	},
+}
+
+// ConsulTemplate - the functions of consul-template templates
+var ConsulTemplate = &Dialect{
+	Name: "consul-template",
+	Funcs: map[string]string{
+		"key":             `ds "consul"`,
+		"secret":          `ds "vault"`,
+		"env":             "env.Getenv",
+		"envOrDefault":    "env.Getenv",
+		"file":            "file.Read",
+		"parseJSON":       "data.JSON",
+		"parseYAML":       "data.YAML",
+		"parseBool":       "conv.ToBool",
+		"parseInt":        "conv.ToInt64",
+		"parseFloat":      "conv.ToFloat64",
+		"toTitle":         "strings.Title",
+		"split":           "strings.Split",
+		"toJSONPretty":    `toJSONPretty "  "`,
+		"regexMatch":      "regexp.Match",
+		"regexReplaceAll": "regexp.Replace",
+		"base64Encode":    "base64.Encode",
+		"base64Decode":    "base64.Decode",
+		"sha256Hex":       "crypto.SHA256",
+		"add":             "math.Add",
+		"multiply":        "math.Mul",
+		"executeTemplate": "tmpl.Exec",
+		"indent":          "strings.Indent",
+	},
+	Datasources: map[string]string{
+		"key":    "consul",
+		"secret": "vault",
+	},
+	Warnings: map[string]string{
+		"secret": "was converted to 'ds \"vault\"', which doesn't wrap the secret in .Data - use the secret's fields directly",
+	},
+	Unsupported: map[string]string{
+		"keyOrDefault": "gomplate fails when a key is missing - list the parent's keys with a consul datasource URL ending in '/', and check with 'coll.Has'",
+		"keyExists":    "list the parent's keys with a consul datasource URL ending in '/', and check with 'coll.Has'",
+		"ls":           "list the keys with a consul datasource URL ending in '/'",
+		"tree":         "list the keys with a consul datasource URL ending in '/'",
+		"secrets":      "list the secrets with a vault datasource URL ending in '/'",
+		"service":      "gomplate can't query the Consul catalog",
+		"services":     "gomplate can't query the Consul catalog",
+		"nodes":        "gomplate can't query the Consul catalog",
+		"node":         "gomplate can't query the Consul catalog",
+		"connect":      "gomplate can't query the Consul catalog",
+		"datacenters":  "gomplate can't query the Consul catalog",
+		"scratch":      "gomplate has no scratch space - use variables",
+		"loop":         "use 'math.Seq'",
+		"subtract":     "consul-template takes the operands in reverse order - use 'math.Sub'",
+		"divide":       "consul-template takes the operands in reverse order - use 'math.Div'",
+		"modulo":       "consul-template takes the operands in reverse order - use 'math.Rem'",
+		"join":         "gomplate's join takes the list first - use 'conv.Join LIST SEP'",
+		"timestamp":    "use 'time.Now' and its 'Format' method",
+		"md5sum":       "gomplate has no MD5 function",
+		"plugin":       "define the plugin with the --plugin flag, and call it by name",
+		"contains":     "consul-template's contains checks lists - use 'coll.Has LIST VALUE'",
+	},
+}
+
+// Dialects - the supported dialects, by name
+var Dialects = map[string]*Dialect{
+	"helm":            Helm,
+	"consul-template": ConsulTemplate,
+}
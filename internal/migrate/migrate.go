@@ -0,0 +1,211 @@
+// Package migrate converts templates written for other Go template-based
+// tools (like Helm and consul-template) to gomplate, replacing their functions
+// with gomplate's equivalents, and reporting constructs that can't be
+// converted.
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Dialect - the functions and fields of another tool's templates, and how to
+// convert them
+type Dialect struct {
+	Name string
+	// Funcs - functions with an equivalent in gomplate, and the text that
+	// replaces the function's name. The replacement can include leading
+	// arguments, like `ds "consul"`.
+	Funcs map[string]string
+	// Warnings - functions that are converted (or left alone), but which
+	// behave differently, and how
+	Warnings map[string]string
+	// Unsupported - functions with no equivalent in gomplate, and what to do
+	// instead
+	Unsupported map[string]string
+	// Fields - top-level fields (like .Release) that have no equivalent in
+	// gomplate, and what to do instead
+	Fields map[string]string
+	// Datasources - the datasources read by converted functions, by function
+	// name, which must be defined when rendering
+	Datasources map[string]string
+}
+
+// Finding - a construct that couldn't be converted, or was converted but may
+// behave differently
+type Finding struct {
+	Line, Col int
+	Message   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s", f.Line, f.Col, f.Message)
+}
+
+// Result - a converted template
+type Result struct {
+	Text     string
+	Findings []Finding
+	// Datasources - the aliases of the datasources the template now reads
+	Datasources []string
+}
+
+// builtins - the functions built in to Go templates
+var builtins = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// Convert converts the template. Functions are replaced in place, so the
+// template's formatting (and comments) are preserved. known reports whether
+// a function exists in gomplate - other functions that the dialect doesn't
+// know about are reported.
+func Convert(name, text string, d *Dialect, known func(string) bool) (*Result, error) {
+	t := parse.New(name)
+	t.Mode = parse.SkipFuncCheck | parse.ParseComments
+	trees := map[string]*parse.Tree{}
+	if _, err := t.Parse(text, "", "", trees); err != nil {
+		return nil, err
+	}
+
+	c := &converter{d: d, known: known, datasources: map[string]bool{}}
+	names := make([]string, 0, len(trees))
+	for n := range trees {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		c.walk(trees[n].Root)
+	}
+
+	// apply the edits from the end, so earlier positions are still valid
+	sort.Slice(c.edits, func(i, j int) bool { return c.edits[i].pos > c.edits[j].pos })
+	out := text
+	for _, e := range c.edits {
+		out = out[:e.pos] + e.text + out[e.pos+e.len:]
+	}
+
+	r := &Result{Text: out, Datasources: []string{}}
+	for ds := range c.datasources {
+		r.Datasources = append(r.Datasources, ds)
+	}
+	sort.Strings(r.Datasources)
+
+	sort.SliceStable(c.findings, func(i, j int) bool { return c.findings[i].pos < c.findings[j].pos })
+	for _, f := range c.findings {
+		line, col := position(text, f.pos)
+		r.Findings = append(r.Findings, Finding{Line: line, Col: col, Message: f.msg})
+	}
+	return r, nil
+}
+
+type edit struct {
+	pos, len int
+	text     string
+}
+
+type finding struct {
+	pos int
+	msg string
+}
+
+type converter struct {
+	d           *Dialect
+	known       func(string) bool
+	edits       []edit
+	findings    []finding
+	datasources map[string]bool
+}
+
+func (c *converter) addf(pos parse.Pos, format string, args ...interface{}) {
+	c.findings = append(c.findings, finding{pos: int(pos), msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *converter) walk(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child)
+		}
+	case *parse.ActionNode:
+		c.walk(n.Pipe)
+	case *parse.IfNode:
+		c.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		c.branch(&n.BranchNode)
+	case *parse.WithNode:
+		c.branch(&n.BranchNode)
+	case *parse.TemplateNode:
+		c.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			c.walk(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			c.walk(arg)
+		}
+	case *parse.ChainNode:
+		c.walk(n.Node)
+	case *parse.IdentifierNode:
+		c.ident(n)
+	case *parse.FieldNode:
+		c.field(n)
+	}
+}
+
+func (c *converter) branch(n *parse.BranchNode) {
+	c.walk(n.Pipe)
+	c.walk(n.List)
+	c.walk(n.ElseList)
+}
+
+func (c *converter) ident(n *parse.IdentifierNode) {
+	name := n.Ident
+	if msg, ok := c.d.Unsupported[name]; ok {
+		c.addf(n.Pos, "%s function %q can't be converted: %s", c.d.Name, name, msg)
+		return
+	}
+	if msg, ok := c.d.Warnings[name]; ok {
+		c.addf(n.Pos, "%s function %q %s", c.d.Name, name, msg)
+	}
+	if repl, ok := c.d.Funcs[name]; ok {
+		c.edits = append(c.edits, edit{pos: int(n.Pos), len: len(name), text: repl})
+		if ds, ok := c.d.Datasources[name]; ok {
+			c.datasources[ds] = true
+		}
+		return
+	}
+	if !builtins[name] && !c.known(name) {
+		c.addf(n.Pos, "unknown function %q", name)
+	}
+}
+
+func (c *converter) field(n *parse.FieldNode) {
+	msg, ok := c.d.Fields[n.Ident[0]]
+	if !ok {
+		return
+	}
+	// the position of a multi-part field (like .Release.Name) is that of its
+	// last part
+	pos := n.Pos - parse.Pos(len(n.String())-len(n.Ident[len(n.Ident)-1])-1)
+	c.addf(pos, "%s field .%s can't be converted: %s", c.d.Name, n.Ident[0], msg)
+}
+
+// position - the line and column (both starting at 1) of the byte offset
+func position(text string, pos int) (line, col int) {
+	before := text[:pos]
+	line = strings.Count(before, "\n") + 1
+	col = pos - strings.LastIndex(before, "\n")
+	return line, col
+}
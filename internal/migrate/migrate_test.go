@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func known(name string) bool {
+	return map[string]bool{"strings": true, "data": true, "indent": true, "quote": true, "ds": true}[name]
+}
+
+func TestConvert(t *testing.T) {
+	in := `{{/* labels */}}
+{{- define "app.labels" -}}
+app: {{ .Values.name | lower | quote }}
+{{- end }}
+{{ if empty .Values.tags }}{{ toYaml .Values.tags | indent 2 }}{{ else }}{{ bogus }}{{ end }}
+release: {{ .Release.Name }}
+{{ range $k, $v := .Values.env }}{{ $k | upper }}: {{ nindent 2 $v }}{{ end }}
+`
+	r, err := Convert("deploy.yaml", in, Helm, known)
+	require.NoError(t, err)
+	assert.Equal(t, `{{/* labels */}}
+{{- define "app.labels" -}}
+app: {{ .Values.name | strings.ToLower | quote }}
+{{- end }}
+{{ if not .Values.tags }}{{ data.ToYAML .Values.tags | indent 2 }}{{ else }}{{ bogus }}{{ end }}
+release: {{ .Release.Name }}
+{{ range $k, $v := .Values.env }}{{ $k | strings.ToUpper }}: {{ nindent 2 $v }}{{ end }}
+`, r.Text)
+	assert.Empty(t, r.Datasources)
+
+	msgs := []string{}
+	for _, f := range r.Findings {
+		msgs = append(msgs, f.String())
+	}
+	assert.Equal(t, []string{
+		`5:7: Helm function "empty" was converted to 'not', which treats empty maps and lists as true, but not zero-valued structs`,
+		`5:31: Helm function "toYaml" adds a trailing newline in gomplate - use '| trimSpace' if it matters`,
+		`5:77: unknown function "bogus"`,
+		`6:13: Helm field .Release can't be converted: define the release's name and namespace in values.yaml, or in a datasource`,
+		`7:55: Helm function "nindent" can't be converted: use 'indent' with a leading newline, like '{{ "\n" }}{{ ... | indent 4 }}'`,
+	}, msgs)
+}
+
+func TestConvert_ConsulTemplate(t *testing.T) {
+	in := `{{ with secret "secret/db" }}{{ .Data.password }}{{ end }}
+host={{ key "app/db/host" }}
+{{ range ls "app/flags" }}{{ .Key }}{{ end }}
+`
+	r, err := Convert("app.conf.ctmpl", in, ConsulTemplate, known)
+	require.NoError(t, err)
+	assert.Equal(t, `{{ with ds "vault" "secret/db" }}{{ .Data.password }}{{ end }}
+host={{ ds "consul" "app/db/host" }}
+{{ range ls "app/flags" }}{{ .Key }}{{ end }}
+`, r.Text)
+	assert.Equal(t, []string{"consul", "vault"}, r.Datasources)
+	require.Len(t, r.Findings, 2)
+	assert.Equal(t, Finding{Line: 1, Col: 9, Message: `consul-template function "secret" was converted to 'ds "vault"', which doesn't wrap the secret in .Data - use the secret's fields directly`}, r.Findings[0])
+	assert.Equal(t, 3, r.Findings[1].Line)
+	assert.Contains(t, r.Findings[1].Message, `"ls" can't be converted`)
+
+	_, err = Convert("bad", "{{ if }}", ConsulTemplate, known)
+	assert.Error(t, err)
+}
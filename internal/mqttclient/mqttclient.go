@@ -0,0 +1,221 @@
+// Package mqttclient reads retained messages from an MQTT broker, for the
+// mqtt: and mqtts: datasources.
+package mqttclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+)
+
+// Schemes
+const (
+	Scheme    = "mqtt"
+	TLSScheme = "mqtts"
+)
+
+// ErrNotFound - the topic has no retained message
+var ErrNotFound = errors.New("no retained message")
+
+// Quiet - how long to wait for (more) retained messages after subscribing.
+// Brokers send retained messages immediately after acknowledging a
+// subscription, so this only needs to cover network latency.
+var Quiet = time.Second
+
+const timeout = 10 * time.Second
+
+// Connect to the broker in the URL (localhost when there's no host), with TLS
+// for mqtts: URLs. The username and password are taken from the URL's user
+// info, or from MQTT_USERNAME and MQTT_PASSWORD. When MQTT_CA_CERT names a CA
+// certificate file, it's used to verify the broker.
+func Connect(ctx context.Context, u *url.URL) (mqtt.Client, error) {
+	broker := &url.URL{Scheme: "tcp", Host: u.Host}
+	port := "1883"
+	if u.Scheme == TLSScheme {
+		broker.Scheme = "ssl"
+		port = "8883"
+	}
+	if broker.Host == "" {
+		broker.Host = "localhost"
+	}
+	if u.Port() == "" {
+		broker.Host = net.JoinHostPort(broker.Hostname(), port)
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker.String()).
+		SetClientID(clientID()).
+		SetCleanSession(true).
+		SetAutoReconnect(false).
+		SetConnectTimeout(timeout).
+		SetWriteTimeout(timeout)
+
+	user, pass := os.Getenv("MQTT_USERNAME"), os.Getenv("MQTT_PASSWORD")
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			pass = p
+		}
+	}
+	if user != "" {
+		opts.SetUsername(user).SetPassword(pass)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if ca := os.Getenv("MQTT_CA_CERT"); ca != "" {
+		b, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MQTT_CA_CERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", ca)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	opts.SetTLSConfig(tlsConfig)
+
+	if p := netpolicy.FromContext(ctx); p != nil {
+		dial := p.DialContext(u.Scheme, (&net.Dialer{Timeout: timeout}).DialContext)
+		opts.SetCustomOpenConnectionFn(func(uri *url.URL, o mqtt.ClientOptions) (net.Conn, error) {
+			conn, err := dial(ctx, "tcp", uri.Host)
+			if err != nil || uri.Scheme != "ssl" {
+				return conn, err
+			}
+			cfg := o.TLSConfig.Clone()
+			if cfg.ServerName == "" {
+				cfg.ServerName = uri.Hostname()
+			}
+			return tls.Client(conn, cfg), nil
+		})
+	}
+
+	c := mqtt.NewClient(opts)
+	if err := wait(ctx, c.Connect()); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", broker.Host, err)
+	}
+	return c, nil
+}
+
+func clientID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return "gomplate-" + hex.EncodeToString(b)
+}
+
+// wait for the token to complete, or for the context to be done
+func wait(ctx context.Context, t mqtt.Token) error {
+	select {
+	case <-t.Done():
+		return t.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for broker")
+	}
+}
+
+// IsWildcard - whether the topic filter contains wildcards
+func IsWildcard(filter string) bool {
+	for _, level := range strings.Split(filter, "/") {
+		if level == "+" || level == "#" {
+			return true
+		}
+	}
+	return false
+}
+
+// Retained subscribes to the topic filter, and returns the retained messages
+// the broker sends, keyed by topic. When the filter has no wildcards, this
+// returns as soon as the message arrives.
+func Retained(ctx context.Context, c mqtt.Client, filter string) (map[string][]byte, error) {
+	var mu sync.Mutex
+	msgs := map[string][]byte{}
+	arrived := make(chan struct{}, 1)
+
+	handler := func(_ mqtt.Client, m mqtt.Message) {
+		// only retained messages are wanted, not ones published while
+		// subscribed
+		if !m.Retained() {
+			return
+		}
+		mu.Lock()
+		msgs[m.Topic()] = m.Payload()
+		mu.Unlock()
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+	}
+
+	if err := wait(ctx, c.Subscribe(filter, 0, handler)); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", filter, err)
+	}
+	defer c.Unsubscribe(filter)
+
+	quiet := time.NewTimer(Quiet)
+	defer quiet.Stop()
+	for done := false; !done; {
+		select {
+		case <-arrived:
+			if !IsWildcard(filter) {
+				done = true
+				break
+			}
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+			quiet.Reset(Quiet)
+		case <-quiet.C:
+			done = true
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return msgs, nil
+}
+
+// Get - the retained message on the topic
+func Get(ctx context.Context, c mqtt.Client, topic string) ([]byte, error) {
+	msgs, err := Retained(ctx, c, topic)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := msgs[topic]
+	if !ok {
+		return nil, fmt.Errorf("topic %s: %w", topic, ErrNotFound)
+	}
+	return b, nil
+}
+
+// List - the sorted names of the topics matching the filter which have
+// retained messages
+func List(ctx context.Context, c mqtt.Client, filter string) ([]string, error) {
+	msgs, err := Retained(ctx, c, filter)
+	if err != nil {
+		return nil, err
+	}
+	topics := make([]string, 0, len(msgs))
+	for t := range msgs {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
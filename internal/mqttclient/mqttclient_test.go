@@ -0,0 +1,145 @@
+package mqttclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker - speaks just enough MQTT 3.1.1 to accept connections and send
+// retained messages matching subscriptions
+type fakeBroker struct {
+	ln       net.Listener
+	retained map[string]string
+}
+
+func newFakeBroker(t *testing.T, retained map[string]string) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &fakeBroker{ln: ln, retained: retained}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(c)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return b
+}
+
+func (b *fakeBroker) URL(path string) *url.URL {
+	return &url.URL{Scheme: Scheme, Host: b.ln.Addr().String(), Path: path}
+}
+
+func matchTopic(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) || (level != "+" && level != t[i]) {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+func (b *fakeBroker) serve(c net.Conn) {
+	defer c.Close()
+	for {
+		p, err := packets.ReadPacket(c)
+		if err != nil {
+			return
+		}
+		switch p := p.(type) {
+		case *packets.ConnectPacket:
+			ack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+			_ = ack.Write(c)
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = p.Qoss
+			_ = ack.Write(c)
+			for _, filter := range p.Topics {
+				for topic, payload := range b.retained {
+					if !matchTopic(filter, topic) {
+						continue
+					}
+					pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+					pub.Retain = true
+					pub.TopicName = topic
+					pub.Payload = []byte(payload)
+					_ = pub.Write(c)
+				}
+				// messages published while subscribed aren't retained
+				pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+				pub.TopicName = strings.ReplaceAll(strings.ReplaceAll(filter, "#", "live"), "+", "live")
+				pub.Payload = []byte("live")
+				_ = pub.Write(c)
+			}
+		case *packets.UnsubscribePacket:
+			ack := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			ack.MessageID = p.MessageID
+			_ = ack.Write(c)
+		case *packets.PingreqPacket:
+			_ = packets.NewControlPacket(packets.Pingresp).Write(c)
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+func TestGetAndList(t *testing.T) {
+	Quiet = 100 * time.Millisecond
+	defer func() { Quiet = time.Second }()
+
+	b := newFakeBroker(t, map[string]string{
+		"devices/a/config": `{"interval": 5}`,
+		"devices/b/config": `{"interval": 10}`,
+		"devices/b/state":  "on",
+		"other":            "x",
+	})
+
+	ctx := context.Background()
+	c, err := Connect(ctx, b.URL("/devices"))
+	require.NoError(t, err)
+	defer c.Disconnect(0)
+
+	v, err := Get(ctx, c, "devices/a/config")
+	require.NoError(t, err)
+	assert.Equal(t, `{"interval": 5}`, string(v))
+
+	_, err = Get(ctx, c, "devices/c/config")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	topics, err := List(ctx, c, "devices/+/config")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devices/a/config", "devices/b/config"}, topics)
+
+	topics, err = List(ctx, c, "devices/#")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devices/a/config", "devices/b/config", "devices/b/state"}, topics)
+
+	topics, err = List(ctx, c, "nothing/#")
+	require.NoError(t, err)
+	assert.Empty(t, topics)
+}
+
+func TestIsWildcard(t *testing.T) {
+	assert.True(t, IsWildcard("a/+/c"))
+	assert.True(t, IsWildcard("a/#"))
+	assert.True(t, IsWildcard("#"))
+	assert.False(t, IsWildcard("a/b+c"))
+	assert.False(t, IsWildcard("a/b"))
+}
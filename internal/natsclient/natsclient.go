@@ -0,0 +1,258 @@
+// Package natsclient reads messages from NATS JetStream streams and key/value
+// buckets, and publishes messages, for the nats: and nats+kv: datasources and
+// outputs.
+//
+// JetStream is used through its request/reply API, so only a core NATS
+// client is needed.
+package natsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/nats-io/nats.go"
+)
+
+// Schemes
+const (
+	Scheme   = "nats"
+	KVScheme = "nats+kv"
+)
+
+// ErrNotFound - the subject has no messages, or the key doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// IsNATSURL - whether the string is a nats: or nats+kv: URL
+func IsNATSURL(s string) bool {
+	return strings.HasPrefix(s, Scheme+"://") || strings.HasPrefix(s, KVScheme+"://")
+}
+
+// Connect to the NATS server in the URL (nats.DefaultURL when there's no
+// host), authenticating with the URL's user info, or with a token in
+// NATS_TOKEN, or a credentials file named by NATS_CREDS. When NATS_CA_CERT
+// names a CA certificate file, TLS is required.
+func Connect(ctx context.Context, u *url.URL) (*nats.Conn, error) {
+	server := nats.DefaultURL
+	if u.Host != "" {
+		server = "nats://" + u.Host
+	}
+
+	opts := []nats.Option{
+		nats.Name("gomplate"),
+		nats.Timeout(10 * time.Second),
+		nats.NoReconnect(),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		opts = append(opts, nats.UserInfo(u.User.Username(), pass))
+	}
+	if token := os.Getenv("NATS_TOKEN"); token != "" {
+		opts = append(opts, nats.Token(token))
+	}
+	if creds := os.Getenv("NATS_CREDS"); creds != "" {
+		opts = append(opts, nats.UserCredentials(creds))
+	}
+	if ca := os.Getenv("NATS_CA_CERT"); ca != "" {
+		opts = append(opts, nats.RootCAs(ca))
+	}
+	if p := netpolicy.FromContext(ctx); p != nil {
+		dial := p.DialContext(u.Scheme, (&net.Dialer{}).DialContext)
+		opts = append(opts, nats.SetCustomDialer(dialer{ctx: ctx, dial: dial}))
+	}
+
+	nc, err := nats.Connect(server, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS server %s: %w", server, err)
+	}
+	return nc, nil
+}
+
+// dialer adapts a netpolicy.DialFunc to nats.CustomDialer
+type dialer struct {
+	ctx  context.Context
+	dial netpolicy.DialFunc
+}
+
+func (d dialer) Dial(network, address string) (net.Conn, error) {
+	return d.dial(d.ctx, network, address)
+}
+
+// apiError - the error in a JetStream API response
+type apiError struct {
+	Code        int    `json:"code"`
+	ErrCode     int    `json:"err_code"`
+	Description string `json:"description"`
+}
+
+// noMessage - whether the error means there's no message for the subject
+// (older servers don't send error codes)
+func (e *apiError) noMessage() bool {
+	return e.ErrCode == 10037 || (e.ErrCode == 0 && e.Description == "no message found")
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("JetStream API error %d: %s", e.Code, e.Description)
+}
+
+// request makes a JetStream API request, and parses the response into out
+func request(ctx context.Context, nc *nats.Conn, subject string, req, out interface{}) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+	}
+	msg, err := nc.RequestWithContext(ctx, subject, b)
+	if errors.Is(err, nats.ErrNoResponders) {
+		return fmt.Errorf("JetStream isn't enabled on the NATS server: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Error *apiError `json:"error"`
+	}{}
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return fmt.Errorf("invalid JetStream API response: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	return json.Unmarshal(msg.Data, out)
+}
+
+// streamFor finds the stream that stores messages on the subject
+func streamFor(ctx context.Context, nc *nats.Conn, subject string) (string, error) {
+	resp := struct {
+		Streams []string `json:"streams"`
+	}{}
+	err := request(ctx, nc, "$JS.API.STREAM.NAMES", map[string]string{"subject": subject}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to find stream for subject %s: %w", subject, err)
+	}
+	if len(resp.Streams) == 0 {
+		return "", fmt.Errorf("no JetStream stream stores subject %s", subject)
+	}
+	return resp.Streams[0], nil
+}
+
+// storedMsg - a message stored in a stream
+type storedMsg struct {
+	Subject string `json:"subject"`
+	Seq     uint64 `json:"seq"`
+	Header  []byte `json:"hdrs"`
+	Data    []byte `json:"data"`
+}
+
+// lastMsg gets the last message on the subject from the stream
+func lastMsg(ctx context.Context, nc *nats.Conn, stream, subject string) (*storedMsg, error) {
+	resp := struct {
+		Message *storedMsg `json:"message"`
+	}{}
+	err := request(ctx, nc, "$JS.API.STREAM.MSG.GET."+stream, map[string]string{"last_by_subj": subject}, &resp)
+	var aerr *apiError
+	if errors.As(err, &aerr) && aerr.noMessage() {
+		return nil, fmt.Errorf("no messages on subject %s: %w", subject, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last message on subject %s: %w", subject, err)
+	}
+	if resp.Message == nil {
+		return nil, fmt.Errorf("no messages on subject %s: %w", subject, ErrNotFound)
+	}
+	return resp.Message, nil
+}
+
+// Latest - the data of the latest message on the subject, which must be
+// stored in a JetStream stream
+func Latest(ctx context.Context, nc *nats.Conn, subject string) ([]byte, error) {
+	stream, err := streamFor(ctx, nc, subject)
+	if err != nil {
+		return nil, err
+	}
+	m, err := lastMsg(ctx, nc, stream, subject)
+	if err != nil {
+		return nil, err
+	}
+	return m.Data, nil
+}
+
+// KVGet - the value of the key in the JetStream key/value bucket
+func KVGet(ctx context.Context, nc *nats.Conn, bucket, key string) ([]byte, error) {
+	m, err := lastMsg(ctx, nc, "KV_"+bucket, kvSubject(bucket, key))
+	if err != nil {
+		return nil, err
+	}
+	// deleted and purged keys are marked with a header
+	if bytes.Contains(m.Header, []byte("KV-Operation: DEL")) || bytes.Contains(m.Header, []byte("KV-Operation: PURGE")) {
+		return nil, fmt.Errorf("key %s in bucket %s: %w", key, bucket, ErrNotFound)
+	}
+	return m.Data, nil
+}
+
+// KVPut sets the value of the key in the JetStream key/value bucket
+func KVPut(ctx context.Context, nc *nats.Conn, bucket, key string, value []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+	}
+	msg, err := nc.RequestWithContext(ctx, kvSubject(bucket, key), value)
+	if errors.Is(err, nats.ErrNoResponders) {
+		return fmt.Errorf("key/value bucket %s doesn't exist: %w", bucket, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put key %s in bucket %s: %w", key, bucket, err)
+	}
+
+	ack := struct {
+		Error *apiError `json:"error"`
+	}{}
+	if err := json.Unmarshal(msg.Data, &ack); err != nil {
+		return fmt.Errorf("invalid JetStream publish acknowledgement: %w", err)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("failed to put key %s in bucket %s: %w", key, bucket, ack.Error)
+	}
+	return nil
+}
+
+// Publish a message on the subject, waiting until the server has received it
+func Publish(nc *nats.Conn, subject string, data []byte) error {
+	if err := nc.Publish(subject, data); err != nil {
+		return fmt.Errorf("failed to publish to subject %s: %w", subject, err)
+	}
+	if err := nc.FlushTimeout(10 * time.Second); err != nil {
+		return fmt.Errorf("failed to publish to subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+func kvSubject(bucket, key string) string {
+	return "$KV." + bucket + "." + key
+}
+
+// ParseKV splits a nats+kv: URL's path into the bucket and key
+func ParseKV(p string) (bucket, key string) {
+	bucket, key, _ = strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	return bucket, key
+}
+
+// ParseSubject - the subject in a nats: URL's path
+func ParseSubject(p string) string {
+	return strings.TrimPrefix(p, "/")
+}
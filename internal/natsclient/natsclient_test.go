@@ -0,0 +1,233 @@
+package natsclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer - speaks just enough of the NATS protocol to handle requests and
+// publishes. Requests are answered by the responder, if it returns non-nil.
+type fakeServer struct {
+	ln        net.Listener
+	responder func(subject string, data []byte) []byte
+
+	mu        sync.Mutex
+	published map[string][]byte
+}
+
+func newFakeServer(t *testing.T, responder func(subject string, data []byte) []byte) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{ln: ln, responder: responder, published: map[string][]byte{}}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeServer) URL(scheme, path string) *url.URL {
+	return &url.URL{Scheme: scheme, Host: s.ln.Addr().String(), Path: path}
+}
+
+func matchSubject(pattern, subject string) bool {
+	p, sub := strings.Split(pattern, "."), strings.Split(subject, ".")
+	for i, tok := range p {
+		if tok == ">" {
+			return len(sub) > i
+		}
+		if i >= len(sub) || (tok != "*" && tok != sub[i]) {
+			return false
+		}
+	}
+	return len(p) == len(sub)
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	fmt.Fprintf(c, "INFO {\"server_id\":\"fake\",\"version\":\"2.2.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+
+	var wmu sync.Mutex
+	subs := map[string]string{}
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		switch strings.ToUpper(f[0]) {
+		case "PING":
+			wmu.Lock()
+			fmt.Fprint(c, "PONG\r\n")
+			wmu.Unlock()
+		case "SUB":
+			subs[f[len(f)-1]] = f[1]
+		case "UNSUB":
+			delete(subs, f[1])
+		case "PUB":
+			n, _ := strconv.Atoi(f[len(f)-1])
+			data := make([]byte, n+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			data = data[:n]
+
+			subject, reply := f[1], ""
+			if len(f) == 4 {
+				reply = f[2]
+			}
+			s.mu.Lock()
+			s.published[subject] = data
+			s.mu.Unlock()
+
+			if reply == "" {
+				continue
+			}
+			resp := s.responder(subject, data)
+			if resp == nil {
+				continue
+			}
+			for sid, pattern := range subs {
+				if matchSubject(pattern, reply) {
+					wmu.Lock()
+					fmt.Fprintf(c, "MSG %s %s %d\r\n%s\r\n", reply, sid, len(resp), resp)
+					wmu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+// jetStream - a fake JetStream API, with one stream holding messages by
+// subject
+func jetStream(stream string, msgs map[string]storedMsg) func(string, []byte) []byte {
+	return func(subject string, data []byte) []byte {
+		req := map[string]string{}
+		_ = json.Unmarshal(data, &req)
+
+		var resp interface{}
+		switch {
+		case subject == "$JS.API.STREAM.NAMES":
+			streams := []string{}
+			for s := range msgs {
+				if s == req["subject"] {
+					streams = append(streams, stream)
+				}
+			}
+			resp = map[string]interface{}{"streams": streams}
+		case subject == "$JS.API.STREAM.MSG.GET."+stream:
+			m, ok := msgs[req["last_by_subj"]]
+			if !ok {
+				resp = map[string]interface{}{"error": map[string]interface{}{"code": 404, "description": "no message found"}}
+			} else {
+				resp = map[string]interface{}{"message": m}
+			}
+		case strings.HasPrefix(subject, "$JS.API."):
+			resp = map[string]interface{}{"error": map[string]interface{}{"code": 404, "description": "stream not found"}}
+		case strings.HasPrefix(subject, "$KV."):
+			resp = map[string]interface{}{"stream": stream, "seq": 1}
+		default:
+			return nil
+		}
+		b, _ := json.Marshal(resp)
+		return b
+	}
+}
+
+func TestLatestAndKV(t *testing.T) {
+	srv := newFakeServer(t, jetStream("KV_config", map[string]storedMsg{
+		"config.app":          {Subject: "config.app", Seq: 3, Data: []byte(`{"a": 1}`)},
+		"$KV.config.app/db":   {Subject: "$KV.config.app/db", Seq: 4, Data: []byte("postgres")},
+		"$KV.config.app/gone": {Subject: "$KV.config.app/gone", Seq: 5, Header: []byte("NATS/1.0\r\nKV-Operation: DEL\r\n\r\n")},
+	}))
+
+	ctx := context.Background()
+	nc, err := Connect(ctx, srv.URL(Scheme, "/config.app"))
+	require.NoError(t, err)
+	defer nc.Close()
+
+	b, err := Latest(ctx, nc, "config.app")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, string(b))
+
+	_, err = Latest(ctx, nc, "config.other")
+	assert.Error(t, err)
+
+	b, err = KVGet(ctx, nc, "config", "app/db")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", string(b))
+
+	_, err = KVGet(ctx, nc, "config", "app/gone")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = KVGet(ctx, nc, "config", "missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = KVGet(ctx, nc, "other", "key")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotFound))
+}
+
+func TestWriter(t *testing.T) {
+	srv := newFakeServer(t, jetStream("KV_config", nil))
+
+	w := NewWriter(srv.URL(Scheme, "/config.rendered"))
+	_, err := w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	// only published once
+	require.NoError(t, w.Close())
+
+	w = NewWriter(srv.URL(KVScheme, "/config/app/rendered"))
+	_, err = w.Write([]byte("world"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, "hello", string(srv.published["config.rendered"]))
+	assert.Equal(t, "world", string(srv.published["$KV.config.app/rendered"]))
+
+	w = NewWriter(srv.URL(KVScheme, "/config"))
+	assert.Error(t, w.Close())
+}
+
+func TestParse(t *testing.T) {
+	b, k := ParseKV("/bucket/a/b")
+	assert.Equal(t, "bucket", b)
+	assert.Equal(t, "a/b", k)
+
+	b, k = ParseKV("/bucket")
+	assert.Equal(t, "bucket", b)
+	assert.Equal(t, "", k)
+
+	assert.Equal(t, "a.b", ParseSubject("/a.b"))
+
+	assert.True(t, IsNATSURL("nats://localhost/foo"))
+	assert.True(t, IsNATSURL("nats+kv://localhost/b/k"))
+	assert.False(t, IsNATSURL("natsx://localhost/foo"))
+	assert.False(t, IsNATSURL("out.txt"))
+}
@@ -0,0 +1,61 @@
+package natsclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+type writer struct {
+	u    *url.URL
+	buf  bytes.Buffer
+	sent bool
+}
+
+// NewWriter returns a writer that publishes the written content as a message
+// when it's closed - on the subject of a nats: URL, or as the value of the key
+// of a nats+kv: URL. It's only published once, even if Close is called again.
+func NewWriter(u *url.URL) io.WriteCloser {
+	return &writer{u: u}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *writer) Close() error {
+	if w.sent {
+		return nil
+	}
+	w.sent = true
+
+	ctx := context.Background()
+	nc, err := Connect(ctx, w.u)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	if w.u.Scheme == KVScheme {
+		bucket, key := ParseKV(w.u.Path)
+		if bucket == "" || key == "" {
+			return fmt.Errorf("a bucket and key must be given in %s", w.u)
+		}
+		return KVPut(ctx, nc, bucket, key, w.buf.Bytes())
+	}
+
+	subject := ParseSubject(w.u.Path)
+	if subject == "" {
+		return fmt.Errorf("a subject must be given in %s", w.u)
+	}
+	return Publish(nc, subject, w.buf.Bytes())
+}
+
+// Discard - drops the content without publishing it
+func (w *writer) Discard() error {
+	w.sent = true
+	w.buf.Reset()
+	return nil
+}
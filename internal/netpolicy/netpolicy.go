@@ -0,0 +1,194 @@
+// Package netpolicy restricts the hosts that datasources and templates can be
+// read from, by scheme. When a policy is configured, everything that isn't
+// explicitly allowed is denied.
+package netpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+)
+
+// DialFunc - the signature of net.Dialer's DialContext method
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Policy - the schemes and hosts that are allowed
+type Policy struct {
+	rules []config.NetworkRule
+	// offline - when set, the reason nothing is allowed
+	offline string
+}
+
+// New creates a policy from the config. A nil config allows everything, so
+// returns a nil policy.
+func New(cfg *config.NetworkPolicy) *Policy {
+	if cfg == nil {
+		return nil
+	}
+	return &Policy{rules: cfg.Allow}
+}
+
+// Offline creates a policy that allows no network access at all, for the
+// given reason (like "rendering from a snapshot"), which is given in errors
+func Offline(reason string) *Policy {
+	return &Policy{offline: reason}
+}
+
+type policyCtxKey struct{}
+
+// ContextWithPolicy returns a context with the policy, for datasource readers
+// to enforce
+func ContextWithPolicy(ctx context.Context, p *Policy) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, policyCtxKey{}, p)
+}
+
+// FromContext returns the policy in the context, or nil if there's none
+func FromContext(ctx context.Context) *Policy {
+	p, _ := ctx.Value(policyCtxKey{}).(*Policy)
+	return p
+}
+
+// isLocal - whether the scheme never needs the network
+func isLocal(scheme string) bool {
+	switch scheme {
+	case "", "file", "stdin", "env", "merge", "boltdb", "git+file":
+		return true
+	}
+	return false
+}
+
+// CheckURL returns an error when the URL's scheme isn't allowed, or when it
+// has a host that isn't allowed for the scheme
+func (p *Policy) CheckURL(u *url.URL) error {
+	if p == nil || isLocal(u.Scheme) {
+		return nil
+	}
+	if !p.allowed(u.Scheme, u.Host) {
+		if p.offline != "" {
+			return fmt.Errorf("network access is disabled when %s, so %s can't be used", p.offline, u.Redacted())
+		}
+		if u.Host == "" {
+			return fmt.Errorf("network policy doesn't allow %s URLs (%s)", u.Scheme, u)
+		}
+		return fmt.Errorf("network policy doesn't allow %s URLs to host %s (%s)", u.Scheme, u.Host, u)
+	}
+	return nil
+}
+
+// allowed - whether a rule allows the scheme, and the host (which may be
+// empty, in which case any rule for the scheme allows it)
+func (p *Policy) allowed(scheme, host string) bool {
+	for _, r := range p.rules {
+		if !hasScheme(r, scheme) {
+			continue
+		}
+		if host == "" || len(r.Hosts) == 0 {
+			return true
+		}
+		for _, pattern := range r.Hosts {
+			if MatchHost(pattern, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasScheme(r config.NetworkRule, scheme string) bool {
+	for _, s := range r.Schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchHost - whether the host (with an optional port) matches the pattern,
+// which is a glob, optionally with a port. A '*' matches across dots, so
+// "*.example.com" matches subdomains at any depth. When the pattern has no
+// port, any port matches.
+func MatchHost(pattern, host string) bool {
+	pHost, pPort := splitHostPort(strings.ToLower(pattern))
+	hHost, hPort := splitHostPort(strings.ToLower(host))
+	if pPort != "" && hPort != "" && pPort != hPort {
+		return false
+	}
+	ok, _ := path.Match(pHost, hHost)
+	return ok
+}
+
+func splitHostPort(s string) (host, port string) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		// no port
+		return strings.Trim(s, "[]"), ""
+	}
+	return host, port
+}
+
+// DialContext wraps dial, so that only connections to hosts that are allowed
+// for the scheme can be made. This catches redirects, proxies, and addresses
+// that don't come from the URL (like $VAULT_ADDR).
+func (p *Policy) DialContext(scheme string, dial DialFunc) DialFunc {
+	if p == nil {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err := p.CheckHost(scheme, addr); err != nil {
+			return nil, err
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// CheckHost returns an error when connections to the host (with an optional
+// port) aren't allowed for the scheme. It's for clients that can't be given a
+// dialer, and so must check the address they're about to connect to.
+func (p *Policy) CheckHost(scheme, host string) error {
+	if p == nil || p.allowed(scheme, host) {
+		return nil
+	}
+	if p.offline != "" {
+		return fmt.Errorf("network access is disabled when %s, so can't connect to %s", p.offline, host)
+	}
+	return fmt.Errorf("network policy doesn't allow %s connections to %s", scheme, host)
+}
+
+// Transport returns a copy of http.DefaultTransport that only connects to
+// hosts that are allowed for the scheme, or http.DefaultTransport itself when
+// p is nil
+func (p *Policy) Transport(scheme string) http.RoundTripper {
+	if p == nil {
+		return http.DefaultTransport
+	}
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		// the default transport was replaced - it can't be restricted, so
+		// nothing is allowed
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("network policy doesn't allow %s connections to %s", scheme, r.URL.Host)
+		})
+	}
+	t := base.Clone()
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t.DialContext = p.DialContext(scheme, dial)
+	return t
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
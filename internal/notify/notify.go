@@ -0,0 +1,198 @@
+// Package notify sends summaries of gomplate runs to webhooks, such as Slack
+// or Microsoft Teams incoming webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+)
+
+// Summary describes the outcome of a gomplate run
+type Summary struct {
+	Err          error
+	ChangedFiles []string
+	Duration     time.Duration
+	Templates    int
+}
+
+// Webhook formats
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+// Events which can trigger notifications
+const (
+	OnSuccess = "success"
+	OnFailure = "failure"
+	OnChange  = "change"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Send posts the summary to each of the configured webhooks whose events
+// match the outcome. All webhooks are attempted, and the first error is
+// returned.
+func Send(ctx context.Context, hooks []config.NotifyConfig, s Summary) error {
+	var firstErr error
+	for _, h := range hooks {
+		if !shouldNotify(h, s) {
+			continue
+		}
+		if err := send(ctx, h, s); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func shouldNotify(h config.NotifyConfig, s Summary) bool {
+	on := h.On
+	if len(on) == 0 {
+		on = []string{OnSuccess, OnFailure}
+	}
+	for _, e := range on {
+		switch {
+		case e == OnFailure && s.Err != nil,
+			e == OnSuccess && s.Err == nil,
+			e == OnChange && s.Err == nil && len(s.ChangedFiles) > 0:
+			return true
+		}
+	}
+	return false
+}
+
+// Format returns the webhook's format, guessing from the URL if not set
+func Format(h config.NotifyConfig) string {
+	if h.Format != "" {
+		return h.Format
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return FormatJSON
+	}
+	switch {
+	case u.Hostname() == "hooks.slack.com":
+		return FormatSlack
+	case strings.HasSuffix(u.Hostname(), ".webhook.office.com"),
+		u.Hostname() == "outlook.office.com":
+		return FormatTeams
+	default:
+		return FormatJSON
+	}
+}
+
+func send(ctx context.Context, h config.NotifyConfig, s Summary) error {
+	body, err := Payload(Format(h), s)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid notification URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification to %s: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification to %s failed: %s", req.URL.Redacted(), resp.Status)
+	}
+	return nil
+}
+
+// Payload renders the summary as a JSON request body in the given format
+func Payload(format string, s Summary) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		p := map[string]interface{}{
+			"status":          OnSuccess,
+			"host":            hostname(),
+			"templates":       s.Templates,
+			"changedFiles":    changedFiles(s),
+			"duration":        s.Duration.String(),
+			"durationSeconds": s.Duration.Seconds(),
+		}
+		if s.Err != nil {
+			p["status"] = OnFailure
+			p["error"] = s.Err.Error()
+		}
+		return json.Marshal(p)
+	case FormatSlack:
+		return json.Marshal(map[string]interface{}{"text": "*" + title(s) + "*\n" + details(s, "`")})
+	case FormatTeams:
+		color := "2EB886"
+		if s.Err != nil {
+			color = "D00000"
+		}
+		return json.Marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    title(s),
+			"themeColor": color,
+			"title":      title(s),
+			"text":       strings.ReplaceAll(details(s, "`"), "\n", "\n\n"),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported notification format %q (must be one of json, slack, or teams)", format)
+	}
+}
+
+func changedFiles(s Summary) []string {
+	if s.ChangedFiles == nil {
+		return []string{}
+	}
+	return s.ChangedFiles
+}
+
+func title(s Summary) string {
+	if s.Err != nil {
+		return fmt.Sprintf("gomplate failed on %s", hostname())
+	}
+	return fmt.Sprintf("gomplate succeeded on %s", hostname())
+}
+
+func details(s Summary, quote string) string {
+	b := &strings.Builder{}
+	if s.Err != nil {
+		fmt.Fprintf(b, "Error: %s%s%s\n", quote, s.Err, quote)
+	}
+	fmt.Fprintf(b, "Rendered %d template(s) in %s", s.Templates, s.Duration.Round(time.Millisecond))
+	if len(s.ChangedFiles) == 0 {
+		b.WriteString(" - no files changed")
+		return b.String()
+	}
+	fmt.Fprintf(b, " - %d file(s) changed:", len(s.ChangedFiles))
+	for _, f := range s.ChangedFiles {
+		fmt.Fprintf(b, "\n- %s%s%s", quote, f, quote)
+	}
+	return b.String()
+}
+
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "unknown host"
+	}
+	return h
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, FormatSlack, Format(config.NotifyConfig{URL: "https://hooks.slack.com/services/T0/B0/X"}))
+	assert.Equal(t, FormatTeams, Format(config.NotifyConfig{URL: "https://example.webhook.office.com/webhookb2/x"}))
+	assert.Equal(t, FormatJSON, Format(config.NotifyConfig{URL: "https://example.com/hook"}))
+	assert.Equal(t, FormatSlack, Format(config.NotifyConfig{URL: "https://example.com/hook", Format: "slack"}))
+}
+
+func TestPayload(t *testing.T) {
+	s := Summary{Templates: 2, ChangedFiles: []string{"a.txt"}, Duration: 1500 * time.Millisecond}
+
+	b, err := Payload(FormatJSON, s)
+	require.NoError(t, err)
+	p := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(b, &p))
+	assert.Equal(t, "success", p["status"])
+	assert.Equal(t, 2.0, p["templates"])
+	assert.Equal(t, []interface{}{"a.txt"}, p["changedFiles"])
+	assert.Equal(t, "1.5s", p["duration"])
+	assert.Equal(t, 1.5, p["durationSeconds"])
+	assert.NotContains(t, p, "error")
+
+	s.Err = fmt.Errorf("boom")
+	b, err = Payload(FormatSlack, s)
+	require.NoError(t, err)
+	p = map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(b, &p))
+	assert.Contains(t, p["text"], "gomplate failed on ")
+	assert.Contains(t, p["text"], "Error: `boom`\nRendered 2 template(s) in 1.5s - 1 file(s) changed:\n- `a.txt`")
+
+	b, err = Payload(FormatTeams, Summary{})
+	require.NoError(t, err)
+	p = map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(b, &p))
+	assert.Equal(t, "MessageCard", p["@type"])
+	assert.Equal(t, "2EB886", p["themeColor"])
+	assert.Equal(t, "Rendered 0 template(s) in 0s - no files changed", p["text"])
+
+	_, err = Payload("xml", s)
+	assert.Error(t, err)
+}
+
+func TestSend(t *testing.T) {
+	received := []map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer x", r.Header.Get("Authorization"))
+		b, _ := io.ReadAll(r.Body)
+		p := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(b, &p))
+		received = append(received, p)
+	}))
+	defer srv.Close()
+
+	hooks := []config.NotifyConfig{
+		{URL: srv.URL + "/all", Headers: map[string]string{"Authorization": "Bearer x"}},
+		{URL: srv.URL + "/failures", On: []string{"failure"}, Headers: map[string]string{"Authorization": "Bearer x"}},
+		{URL: srv.URL + "/changes", On: []string{"change"}, Headers: map[string]string{"Authorization": "Bearer x"}},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, Send(ctx, hooks, Summary{}))
+	assert.Len(t, received, 1)
+
+	require.NoError(t, Send(ctx, hooks, Summary{ChangedFiles: []string{"a"}}))
+	assert.Len(t, received, 3)
+
+	require.NoError(t, Send(ctx, hooks, Summary{Err: fmt.Errorf("boom")}))
+	assert.Len(t, received, 5)
+	assert.Equal(t, "failure", received[4]["status"])
+
+	err := Send(ctx, []config.NotifyConfig{{URL: srv.URL + "/fail"}}, Summary{})
+	assert.ErrorContains(t, err, "500 Internal Server Error")
+}
@@ -0,0 +1,156 @@
+// Package ofrep is a minimal client for the OpenFeature Remote Evaluation
+// Protocol (OFREP), for evaluating feature flags with the flag functions and
+// the ofrep: datasource.
+//
+// OFREP is served by flagd, go-feature-flag, and other OpenFeature-compatible
+// flag services (including relays in front of LaunchDarkly and others).
+package ofrep
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrNotConfigured - no OFREP URL was given, and OFREP_URL isn't set
+var ErrNotConfigured = errors.New("no feature flag service configured - set OFREP_URL")
+
+// Client - an OFREP client
+type Client struct {
+	hc *http.Client
+	// Base - the flag service's URL, without the /ofrep/v1 path
+	Base string
+	// Token - sent as a bearer token, when set
+	Token string
+}
+
+// New creates a client for the flag service at base, or at OFREP_URL when
+// it's empty. The token defaults to OFREP_TOKEN.
+func New(hc *http.Client, base, token string) (*Client, error) {
+	if base == "" {
+		base = os.Getenv("OFREP_URL")
+	}
+	if base == "" {
+		return nil, ErrNotConfigured
+	}
+	if token == "" {
+		token = os.Getenv("OFREP_TOKEN")
+	}
+	return &Client{hc: hc, Base: strings.TrimSuffix(base, "/"), Token: token}, nil
+}
+
+// Evaluation - the result of evaluating a flag. When the flag couldn't be
+// evaluated, ErrorCode is set (like "FLAG_NOT_FOUND"), and Value is nil.
+type Evaluation struct {
+	Value        interface{} `json:"value"`
+	Key          string      `json:"key"`
+	Reason       string      `json:"reason,omitempty"`
+	Variant      string      `json:"variant,omitempty"`
+	ErrorCode    string      `json:"errorCode,omitempty"`
+	ErrorDetails string      `json:"errorDetails,omitempty"`
+}
+
+// Err - the evaluation's error, if any
+func (e *Evaluation) Err() error {
+	if e.ErrorCode == "" {
+		return nil
+	}
+	if e.ErrorDetails != "" {
+		return fmt.Errorf("failed to evaluate flag %s: %s: %s", e.Key, e.ErrorCode, e.ErrorDetails)
+	}
+	return fmt.Errorf("failed to evaluate flag %s: %s", e.Key, e.ErrorCode)
+}
+
+// Evaluate evaluates a single flag, with the evaluation context (which can
+// be nil). Errors evaluating the flag (like an unknown flag) are returned in
+// the Evaluation - the error is only set when the service couldn't be used.
+func (c *Client) Evaluate(ctx context.Context, key string, evalCtx map[string]interface{}) (*Evaluation, error) {
+	b, status, err := c.post(ctx, "/ofrep/v1/evaluate/flags/"+url.PathEscape(key), evalCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case http.StatusOK, http.StatusBadRequest, http.StatusNotFound:
+		e := &Evaluation{}
+		if err := json.Unmarshal(b, e); err != nil {
+			return nil, fmt.Errorf("invalid response evaluating flag %s (HTTP %d): %w", key, status, err)
+		}
+		if e.Key == "" {
+			e.Key = key
+		}
+		if status != http.StatusOK && e.ErrorCode == "" {
+			e.ErrorCode = "GENERAL"
+		}
+		return e, nil
+	}
+	return nil, statusError(status, b)
+}
+
+// EvaluateAll evaluates every flag, with the evaluation context (which can
+// be nil)
+func (c *Client) EvaluateAll(ctx context.Context, evalCtx map[string]interface{}) ([]Evaluation, error) {
+	b, status, err := c.post(ctx, "/ofrep/v1/evaluate/flags", evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, b)
+	}
+
+	resp := struct {
+		Flags []Evaluation `json:"flags"`
+	}{}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response evaluating flags: %w", err)
+	}
+	return resp.Flags, nil
+}
+
+func (c *Client) post(ctx context.Context, p string, evalCtx map[string]interface{}) ([]byte, int, error) {
+	if evalCtx == nil {
+		evalCtx = map[string]interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{"context": evalCtx})
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid evaluation context: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Base+p, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return b, res.StatusCode, nil
+}
+
+func statusError(status int, b []byte) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("not authorized by the feature flag service (HTTP %d) - check OFREP_TOKEN", status)
+	case http.StatusTooManyRequests:
+		return errors.New("rate limited by the feature flag service (HTTP 429)")
+	}
+	return fmt.Errorf("unexpected HTTP status %d from the feature flag service: %s", status, bytes.TrimSpace(b))
+}
@@ -0,0 +1,96 @@
+package ofrep
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFake(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		req := struct {
+			Context map[string]interface{} `json:"context"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Context == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errorCode": "INVALID_CONTEXT"}`))
+			return
+		}
+		env, _ := req.Context["env"].(string)
+
+		switch key := strings.TrimPrefix(r.URL.Path, "/ofrep/v1/evaluate/flags"); key {
+		case "":
+			_, _ = w.Write([]byte(`{"flags": [
+				{"key": "new-ui", "value": true, "reason": "STATIC"},
+				{"key": "broken", "errorCode": "PARSE_ERROR"}
+			]}`))
+		case "/new-ui":
+			_, _ = w.Write([]byte(`{"key": "new-ui", "value": ` + map[bool]string{true: "true", false: "false"}[env == "prod"] + `, "reason": "TARGETING_MATCH", "variant": "on"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"key": "` + key[1:] + `", "errorCode": "FLAG_NOT_FOUND", "errorDetails": "flag not found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("OFREP_URL", "")
+	_, err := New(http.DefaultClient, "", "")
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	t.Setenv("OFREP_URL", "https://flags.example.com/")
+	t.Setenv("OFREP_TOKEN", "tok")
+	c, err := New(http.DefaultClient, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://flags.example.com", c.Base)
+	assert.Equal(t, "tok", c.Token)
+}
+
+func TestEvaluate(t *testing.T) {
+	srv := setupFake(t)
+	ctx := context.Background()
+	c, err := New(srv.Client(), srv.URL, "test-token")
+	require.NoError(t, err)
+
+	e, err := c.Evaluate(ctx, "new-ui", map[string]interface{}{"env": "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, &Evaluation{Key: "new-ui", Value: true, Reason: "TARGETING_MATCH", Variant: "on"}, e)
+	assert.NoError(t, e.Err())
+
+	e, err = c.Evaluate(ctx, "new-ui", nil)
+	require.NoError(t, err)
+	assert.Equal(t, false, e.Value)
+
+	e, err = c.Evaluate(ctx, "missing", nil)
+	require.NoError(t, err)
+	assert.EqualError(t, e.Err(), "failed to evaluate flag missing: FLAG_NOT_FOUND: flag not found")
+
+	c.Token = "wrong"
+	_, err = c.Evaluate(ctx, "new-ui", nil)
+	assert.EqualError(t, err, "not authorized by the feature flag service (HTTP 401) - check OFREP_TOKEN")
+}
+
+func TestEvaluateAll(t *testing.T) {
+	srv := setupFake(t)
+	c, err := New(srv.Client(), srv.URL, "test-token")
+	require.NoError(t, err)
+
+	flags, err := c.EvaluateAll(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []Evaluation{
+		{Key: "new-ui", Value: true, Reason: "STATIC"},
+		{Key: "broken", ErrorCode: "PARSE_ERROR"},
+	}, flags)
+}
@@ -0,0 +1,341 @@
+// Package provenance tracks where the values in rendered output came from.
+//
+// Values read from datasources and environment variables are recorded as
+// they're read, and each line of output is then traced back to the recorded
+// values it contains. Values are matched by content, so very short values
+// (and values like "true") are ignored, to avoid spurious matches. Lines that
+// don't contain any recorded values are literal template text (or computed).
+//
+// Values from secret stores (like Vault) are recorded as secret, so that they
+// can be masked in output that's shown for review, and in the report.
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MinValueLength - values shorter than this aren't tracked
+const MinValueLength = 3
+
+// Masked - what secret values are replaced with
+const Masked = "****"
+
+// ignored - common values that would match too much output to be useful
+var ignored = map[string]bool{"true": true, "false": true, "null": true, "nil": true}
+
+// Origin - where a value came from
+type Origin struct {
+	// Source - the datasource or environment variable, like "datasource
+	// config" or "env HOME"
+	Source string `json:"source"`
+	// Path - the path to the value in the source's data, like ".db.host"
+	Path string `json:"path,omitempty"`
+	// Value - the value
+	Value string `json:"value"`
+	// Secret - whether the value came from a secret store
+	Secret bool `json:"secret,omitempty"`
+}
+
+// String - the origin, as shown in annotations
+func (o Origin) String() string {
+	if o.Path == "" {
+		return o.Source
+	}
+	return o.Source + " " + o.Path
+}
+
+// Line - a line of output, and where its values came from
+type Line struct {
+	Text    string   `json:"text"`
+	Origins []Origin `json:"origins,omitempty"`
+	Number  int      `json:"line"`
+	// Literal - whether the line contains no recorded values
+	Literal bool `json:"literal,omitempty"`
+}
+
+// Tracker records values and where they came from. A nil Tracker records
+// nothing, so callers don't need to check whether tracking is enabled.
+type Tracker struct {
+	seen      map[Origin]bool
+	templates map[string][]Line
+	origins   []Origin
+	mu        sync.Mutex
+}
+
+// New creates a Tracker
+func New() *Tracker {
+	return &Tracker{seen: map[Origin]bool{}, templates: map[string][]Line{}}
+}
+
+type trackerCtxKey struct{}
+
+// ContextWithTracker returns a context with the tracker
+func ContextWithTracker(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, trackerCtxKey{}, t)
+}
+
+// FromContext returns the tracker from the context, or nil if provenance
+// isn't being tracked
+func FromContext(ctx context.Context) *Tracker {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(trackerCtxKey{}).(*Tracker)
+	return t
+}
+
+// Record records the values in the data (which can be nested maps and
+// slices) as coming from the source
+func (t *Tracker) Record(source string, data interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record(source, "", reflect.ValueOf(data), false)
+}
+
+// RecordSecret records the values in the data like Record, but as secret, so
+// they're masked by Mask. Each line of multi-line values (like certificates)
+// is recorded too, so that they're masked even when they're indented.
+func (t *Tracker) RecordSecret(source string, data interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record(source, "", reflect.ValueOf(data), true)
+}
+
+func (t *Tracker) record(source, path string, v reflect.Value, secret bool) {
+	if !v.IsValid() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			t.record(source, path, v.Elem(), secret)
+		}
+		return
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			t.record(source, fmt.Sprintf("%s.%v", path, iter.Key()), iter.Value(), secret)
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		for i := 0; i < v.Len(); i++ {
+			t.record(source, fmt.Sprintf("%s[%d]", path, i), v.Index(i), secret)
+		}
+		return
+	}
+
+	value := strings.TrimSpace(fmt.Sprint(v.Interface()))
+	if v.Kind() == reflect.Slice {
+		value = strings.TrimSpace(string(v.Bytes()))
+	}
+	if strings.Contains(value, "\n") {
+		if !secret {
+			return
+		}
+		for _, line := range strings.Split(value, "\n") {
+			t.add(Origin{Source: source, Path: path, Value: strings.TrimSpace(line), Secret: true})
+		}
+	}
+	t.add(Origin{Source: source, Path: path, Value: value, Secret: secret})
+}
+
+func (t *Tracker) add(o Origin) {
+	if len(o.Value) < MinValueLength || ignored[strings.ToLower(o.Value)] {
+		return
+	}
+	if !t.seen[o] {
+		t.seen[o] = true
+		t.origins = append(t.origins, o)
+	}
+}
+
+// Mask replaces all of the recorded secret values in the output with Masked,
+// matching the longest values first
+func (t *Tracker) Mask(output string) string {
+	if t == nil {
+		return output
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.mask(output)
+}
+
+func (t *Tracker) mask(output string) string {
+	values := []string{}
+	for _, o := range t.origins {
+		if o.Secret {
+			values = append(values, o.Value)
+		}
+	}
+	if len(values) == 0 {
+		return output
+	}
+	sort.SliceStable(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, Masked)
+	}
+	return strings.NewReplacer(pairs...).Replace(output)
+}
+
+// Trace traces each line of the output back to the recorded values it
+// contains, and keeps the result for the report. Blank lines are skipped.
+// Secret values are masked in the report.
+func (t *Tracker) Trace(name, output string) []Line {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := []Line{}
+	for i, text := range strings.Split(output, "\n") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		l := Line{Number: i + 1, Text: text, Origins: t.match(text)}
+		l.Literal = len(l.Origins) == 0
+		lines = append(lines, l)
+	}
+	t.templates[name] = maskLines(lines, t.mask)
+	return lines
+}
+
+// maskLines - a copy of the lines, with secret values masked
+func maskLines(lines []Line, mask func(string) string) []Line {
+	out := make([]Line, len(lines))
+	for i, l := range lines {
+		l.Text = mask(l.Text)
+		origins := make([]Origin, len(l.Origins))
+		for j, o := range l.Origins {
+			if o.Secret {
+				o.Value = Masked
+			}
+			origins[j] = o
+		}
+		if len(origins) > 0 {
+			l.Origins = origins
+		}
+		out[i] = l
+	}
+	return out
+}
+
+type span struct {
+	origin     Origin
+	start, end int
+}
+
+// match finds the recorded values in the text. Values found only inside a
+// longer value's match are dropped, so "prod" doesn't match in
+// "prod.example.com" when that was also recorded.
+func (t *Tracker) match(text string) []Origin {
+	spans := []span{}
+	for _, o := range t.origins {
+		for off := 0; ; {
+			i := strings.Index(text[off:], o.Value)
+			if i < 0 {
+				break
+			}
+			start := off + i
+			end := start + len(o.Value)
+			if boundary(text, start, end) {
+				spans = append(spans, span{o, start, end})
+				break
+			}
+			off = start + 1
+		}
+	}
+
+	var out []Origin
+	for _, s := range spans {
+		contained := false
+		for _, other := range spans {
+			if len(other.origin.Value) > len(s.origin.Value) && other.start <= s.start && other.end >= s.end {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			out = append(out, s.origin)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
+
+// boundary - whether the match isn't part of a longer word
+func boundary(text string, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	if start > 0 && isWord(rune(text[start-1])) && isWord(rune(text[start])) {
+		return false
+	}
+	if end < len(text) && isWord(rune(text[end])) && isWord(rune(text[end-1])) {
+		return false
+	}
+	return true
+}
+
+// Annotate appends a comment to each line that contains recorded values,
+// listing where they came from. The comment string starts the comment, like
+// "#" or "//".
+func Annotate(output string, lines []Line, comment string) string {
+	byNumber := make(map[int]Line, len(lines))
+	for _, l := range lines {
+		byNumber[l.Number] = l
+	}
+
+	out := strings.Split(output, "\n")
+	for i, text := range out {
+		l, ok := byNumber[i+1]
+		if !ok || l.Literal {
+			continue
+		}
+		from := make([]string, len(l.Origins))
+		for j, o := range l.Origins {
+			from[j] = o.String()
+		}
+		out[i] = fmt.Sprintf("%s %s from: %s", text, comment, strings.Join(from, ", "))
+	}
+	return strings.Join(out, "\n")
+}
+
+// WriteReport writes the traced lines of every template, as JSON
+func (t *Tracker) WriteReport(path string) error {
+	if t == nil {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	t.mu.Lock()
+	err := enc.Encode(map[string]interface{}{"templates": t.templates})
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	//nolint:gosec
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write provenance report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package provenance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	tr := New()
+	tr.Record("datasource config", map[string]interface{}{
+		"db": map[string]interface{}{
+			"host":  "db.example.com",
+			"port":  5432,
+			"debug": true,
+		},
+		"hosts": []interface{}{"web1", "web2"},
+		"env":   "db",
+	})
+	tr.Record("env USER", "admin")
+
+	output := "host: db.example.com:5432\n\nuser: admin\nhosts: web1,web2\n# a comment\nadministrator: true"
+	lines := tr.Trace("test", output)
+	assert.Equal(t, []Line{
+		{Number: 1, Text: "host: db.example.com:5432", Origins: []Origin{
+			{Source: "datasource config", Path: ".db.host", Value: "db.example.com"},
+			{Source: "datasource config", Path: ".db.port", Value: "5432"},
+		}},
+		{Number: 3, Text: "user: admin", Origins: []Origin{
+			{Source: "env USER", Value: "admin"},
+		}},
+		{Number: 4, Text: "hosts: web1,web2", Origins: []Origin{
+			{Source: "datasource config", Path: ".hosts[0]", Value: "web1"},
+			{Source: "datasource config", Path: ".hosts[1]", Value: "web2"},
+		}},
+		{Number: 5, Text: "# a comment", Literal: true},
+		{Number: 6, Text: "administrator: true", Literal: true},
+	}, lines)
+
+	assert.Equal(t, "host: db.example.com:5432 # from: datasource config .db.host, datasource config .db.port\n\n"+
+		"user: admin # from: env USER\n"+
+		"hosts: web1,web2 # from: datasource config .hosts[0], datasource config .hosts[1]\n"+
+		"# a comment\nadministrator: true",
+		Annotate(output, lines, "#"))
+}
+
+func TestMask(t *testing.T) {
+	tr := New()
+	tr.Record("env USER", "admin")
+	tr.RecordSecret("datasource vault", map[string]interface{}{
+		"password": "hunter2",
+		"pass":     "hunter",
+		"cert":     "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIUQ\n-----END CERTIFICATE-----",
+	})
+
+	out := "user: admin\npassword: hunter2\npass: hunter\ncert: |\n  -----BEGIN CERTIFICATE-----\n  MIIBszCCAVmgAwIBAgIUQ\n  -----END CERTIFICATE-----"
+	assert.Equal(t, "user: admin\npassword: ****\npass: ****\ncert: |\n  ****\n  ****\n  ****", tr.Mask(out))
+
+	lines := tr.Trace("test", "user: admin\npassword: hunter2")
+	assert.Equal(t, "password: hunter2", lines[1].Text)
+	assert.Equal(t, []Line{
+		{Number: 1, Text: "user: admin", Origins: []Origin{{Source: "env USER", Value: "admin"}}},
+		{Number: 2, Text: "password: ****", Origins: []Origin{{Source: "datasource vault", Path: ".password", Value: "****", Secret: true}}},
+	}, tr.templates["test"])
+
+	assert.Equal(t, "hunter2", (*Tracker)(nil).Mask("hunter2"))
+}
+
+func TestNilTracker(t *testing.T) {
+	tr := FromContext(context.Background())
+	assert.Nil(t, tr)
+	tr.Record("env FOO", "bar")
+	assert.Nil(t, tr.Trace("test", "bar"))
+	assert.NoError(t, tr.WriteReport("/nonexistent/report.json"))
+
+	tr = New()
+	assert.Same(t, tr, FromContext(ContextWithTracker(context.Background(), tr)))
+}
+
+func TestWriteReport(t *testing.T) {
+	tr := New()
+	tr.Record("env USER", "admin")
+	tr.Trace("<arg>", "user: admin")
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, tr.WriteReport(path))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"templates": {"<arg>": [
+		{"line": 1, "text": "user: admin", "origins": [{"source": "env USER", "value": "admin"}]}
+	]}}`, string(b))
+	assert.Contains(t, string(b), `"<arg>"`)
+}
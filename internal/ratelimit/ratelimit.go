@@ -0,0 +1,99 @@
+// Package ratelimit limits the rate of requests that datasources make, per
+// host, so that rendering many templates at once doesn't trip the rate limits
+// of APIs like Vault's or GitHub's.
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"golang.org/x/time/rate"
+)
+
+// Limiter - limits the rate of requests to each host that matches one of the
+// configured limits. Each host has its own limit, shared by all requests made
+// to it in the process.
+type Limiter struct {
+	limits []config.RateLimit
+
+	mu    sync.Mutex
+	hosts map[string]*rate.Limiter
+}
+
+// New creates a Limiter with the given limits - when there are none, it
+// returns nil, which doesn't limit anything
+func New(limits []config.RateLimit) *Limiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	return &Limiter{limits: limits, hosts: map[string]*rate.Limiter{}}
+}
+
+// ForHost returns the rate limiter for the host (which may have a port), or
+// nil if the host isn't limited. When more than one limit matches the host,
+// the first is used.
+func (l *Limiter) ForHost(host string) *rate.Limiter {
+	if l == nil {
+		return nil
+	}
+
+	name := strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(name); err == nil {
+		name = h
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rl, ok := l.hosts[name]; ok {
+		return rl
+	}
+
+	var rl *rate.Limiter
+	for _, lim := range l.limits {
+		if netpolicy.MatchHost(lim.Host, name) {
+			burst := lim.Burst
+			if burst == 0 {
+				burst = 1
+			}
+			rl = rate.NewLimiter(rate.Limit(lim.Rate), burst)
+			break
+		}
+	}
+	l.hosts[name] = rl
+	return rl
+}
+
+// Wait blocks until a request can be made to the host, or the context is done
+func (l *Limiter) Wait(ctx context.Context, host string) error {
+	rl := l.ForHost(host)
+	if rl == nil {
+		return nil
+	}
+	return rl.Wait(ctx)
+}
+
+// Transport wraps next, so that each request waits for the limit of the
+// request's host. When l is nil, next is returned as-is.
+func (l *Limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return next
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if err := l.Wait(r.Context(), r.URL.Host); err != nil {
+			return nil, err
+		}
+		return next.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestForHost(t *testing.T) {
+	var l *Limiter
+	assert.Nil(t, l.ForHost("example.com"))
+	assert.Nil(t, New(nil))
+
+	l = New([]config.RateLimit{
+		{Host: "api.github.com", Rate: 10, Burst: 5},
+		{Host: "*.example.com", Rate: 2},
+	})
+
+	rl := l.ForHost("api.github.com")
+	require.NotNil(t, rl)
+	assert.Equal(t, rate.Limit(10), rl.Limit())
+	assert.Equal(t, 5, rl.Burst())
+
+	// the same limiter is shared by all requests to the host, on any port
+	assert.Same(t, rl, l.ForHost("API.github.com:443"))
+
+	// each matching host has its own limiter
+	foo := l.ForHost("foo.example.com")
+	require.NotNil(t, foo)
+	assert.Equal(t, 1, foo.Burst())
+	assert.NotSame(t, foo, l.ForHost("bar.example.com"))
+
+	assert.Nil(t, l.ForHost("example.com"))
+	assert.NoError(t, l.Wait(context.Background(), "example.com"))
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var l *Limiter
+	assert.Equal(t, http.DefaultTransport, l.Transport(http.DefaultTransport))
+
+	l = New([]config.RateLimit{{Host: "127.0.0.1", Rate: 20}})
+	client := &http.Client{Transport: l.Transport(http.DefaultTransport)}
+
+	get := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, get(context.Background()))
+	}
+	// the first request is immediate, the next two wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// a request that can't be made before the deadline fails right away
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, get(ctx))
+}
@@ -0,0 +1,143 @@
+// Package servicenow is a minimal, read-only client for the ServiceNow Table
+// API, for the servicenow: datasource.
+package servicenow
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound - the table or record doesn't exist (or isn't visible to the
+// user)
+var ErrNotFound = errors.New("not found")
+
+// pageSize - the number of records to request at once
+const pageSize = 100
+
+// Client - a ServiceNow Table API client
+type Client struct {
+	// Base - the instance's URL, like https://example.service-now.com
+	Base string
+
+	auth http.Header
+	hc   *http.Client
+}
+
+// New creates a client for the ServiceNow instance at base. It authenticates
+// with the OAuth access token in SERVICENOW_TOKEN, or the username and
+// password in SERVICENOW_USER and SERVICENOW_PASSWORD.
+func New(hc *http.Client, base string) *Client {
+	auth := http.Header{}
+	if token := os.Getenv("SERVICENOW_TOKEN"); token != "" {
+		auth.Set("Authorization", "Bearer "+token)
+	} else if user := os.Getenv("SERVICENOW_USER"); user != "" {
+		auth.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+os.Getenv("SERVICENOW_PASSWORD"))))
+	}
+	return &Client{Base: strings.TrimSuffix(base, "/"), auth: auth, hc: hc}
+}
+
+// get reads from the Table API, decoding the response's "result"
+func (c *Client) get(ctx context.Context, p string, q url.Values, out interface{}) error {
+	u := c.Base + "/api/now/table/" + p
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.auth {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		resp := struct {
+			Result json.RawMessage `json:"result"`
+		}{}
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return fmt.Errorf("invalid ServiceNow API response: %w", err)
+		}
+		return json.Unmarshal(resp.Result, out)
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	e := struct {
+		Error struct {
+			Message string `json:"message"`
+			Detail  string `json:"detail"`
+		} `json:"error"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && e.Error.Message != "" {
+		msg := e.Error.Message
+		if e.Error.Detail != "" {
+			msg += ": " + e.Error.Detail
+		}
+		return fmt.Errorf("ServiceNow API error (HTTP %d): %s", res.StatusCode, msg)
+	}
+	return fmt.Errorf("unexpected HTTP status %d from ServiceNow API: %s", res.StatusCode, bytes.TrimSpace(b))
+}
+
+// Record - the record in the table with the sys_id. The params are passed
+// through to the API (e.g. sysparm_fields, sysparm_display_value).
+func (c *Client) Record(ctx context.Context, table, sysID string, params url.Values) (json.RawMessage, error) {
+	record := json.RawMessage{}
+	if err := c.get(ctx, url.PathEscape(table)+"/"+url.PathEscape(sysID), params, &record); err != nil {
+		return nil, fmt.Errorf("failed to read %s record %s: %w", table, sysID, err)
+	}
+	return record, nil
+}
+
+// Query - the records in the table matching the encoded query (all records
+// when it's empty). The params are passed through to the API. All pages of
+// results are read, up to limit records (0 for no limit).
+func (c *Client) Query(ctx context.Context, table, query string, params url.Values, limit int) ([]json.RawMessage, error) {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	if query != "" {
+		q.Set("sysparm_query", query)
+	}
+
+	records := []json.RawMessage{}
+	for {
+		n := pageSize
+		if limit > 0 && limit-len(records) < n {
+			n = limit - len(records)
+		}
+		q.Set("sysparm_offset", strconv.Itoa(len(records)))
+		q.Set("sysparm_limit", strconv.Itoa(n))
+
+		page := []json.RawMessage{}
+		if err := c.get(ctx, url.PathEscape(table), q, &page); err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", table, err)
+		}
+		records = append(records, page...)
+
+		if len(page) < n || (limit > 0 && len(records) >= limit) {
+			return records, nil
+		}
+	}
+}
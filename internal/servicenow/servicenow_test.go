@@ -0,0 +1,77 @@
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceNow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != "admin" || p != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": {"message": "User Not Authenticated", "detail": "Required to provide Auth information"}, "status": "failure"}`))
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/now/table/change_request/abc":
+			assert.Equal(t, "number,state", q.Get("sysparm_fields"))
+			_, _ = w.Write([]byte(`{"result": {"number": "CHG0001", "state": "new"}}`))
+		case "/api/now/table/change_request":
+			assert.Equal(t, "state=new", q.Get("sysparm_query"))
+			offset, _ := strconv.Atoi(q.Get("sysparm_offset"))
+			limit, _ := strconv.Atoi(q.Get("sysparm_limit"))
+			records := []json.RawMessage{}
+			for i := offset; i < offset+limit && i < 150; i++ {
+				records = append(records, json.RawMessage(fmt.Sprintf(`{"number": "CHG%04d"}`, i)))
+			}
+			b, _ := json.Marshal(map[string]interface{}{"result": records})
+			_, _ = w.Write(b)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"message": "No Record found"}, "status": "failure"}`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("SERVICENOW_TOKEN", "")
+	t.Setenv("SERVICENOW_USER", "admin")
+	t.Setenv("SERVICENOW_PASSWORD", "pw")
+	c := New(srv.Client(), srv.URL)
+	ctx := context.Background()
+
+	b, err := c.Record(ctx, "change_request", "abc", url.Values{"sysparm_fields": []string{"number,state"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"number": "CHG0001", "state": "new"}`, string(b))
+
+	_, err = c.Record(ctx, "change_request", "nope", nil)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	records, err := c.Query(ctx, "change_request", "state=new", nil, 0)
+	require.NoError(t, err)
+	assert.Len(t, records, 150)
+	assert.JSONEq(t, `{"number": "CHG0149"}`, string(records[149]))
+
+	records, err = c.Query(ctx, "change_request", "state=new", nil, 120)
+	require.NoError(t, err)
+	assert.Len(t, records, 120)
+
+	t.Setenv("SERVICENOW_PASSWORD", "wrong")
+	c = New(srv.Client(), srv.URL)
+	_, err = c.Record(ctx, "change_request", "abc", nil)
+	assert.EqualError(t, err, "failed to read change_request record abc: ServiceNow API error (HTTP 401): User Not Authenticated: Required to provide Auth information")
+
+	t.Setenv("SERVICENOW_TOKEN", "oauth")
+	c = New(srv.Client(), srv.URL)
+	assert.Equal(t, "Bearer oauth", c.auth.Get("Authorization"))
+}
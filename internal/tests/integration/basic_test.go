@@ -172,6 +172,21 @@ func TestBasic_PostRunExecPipe(t *testing.T) {
 	assertSuccess(t, o, e, err, "HELLO WORLD")
 }
 
+func TestBasic_TemplateArgs(t *testing.T) {
+	o, e, err := cmd(t,
+		"-i", `{{ .Arg.greeting }}, {{ index .Args 0 }}! ({{ len .Args }} args)`,
+		"--arg", "greeting=Hello",
+		"--args", "world", "--args", "again").run()
+	assertSuccess(t, o, e, err, "Hello, world! (2 args)")
+
+	o, e, err = cmd(t,
+		"-i", `{{ index .Args 0 }}`,
+		"--exec-pipe",
+		"--args", "hello",
+		"--", "tr", "a-z", "A-Z").run()
+	assertSuccess(t, o, e, err, "HELLO")
+}
+
 func TestBasic_EmptyOutputSuppression(t *testing.T) {
 	tmpDir := setupBasicTest(t)
 	out := tmpDir.Join("out")
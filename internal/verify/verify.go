@@ -0,0 +1,305 @@
+// Package verify checks the detached signatures of templates and datasources
+// before they're used, with cosign, minisign, or GPG public keys.
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signature types
+const (
+	TypeCosign   = "cosign"
+	TypeMinisign = "minisign"
+	TypeGPG      = "gpg"
+)
+
+// DefaultSuffix returns the suffix that's added to a URL to find its detached
+// signature, when none is configured
+func DefaultSuffix(typ string) string {
+	switch typ {
+	case TypeMinisign:
+		return ".minisig"
+	case TypeGPG:
+		return ".asc"
+	default:
+		return ".sig"
+	}
+}
+
+// Verifier verifies content read from URLs against the signature configured
+// for the longest matching URL prefix
+type Verifier struct {
+	rules []rule
+	// required - refuse to use content from remote URLs with no signature
+	// configured
+	required bool
+}
+
+type rule struct {
+	prefix string
+	suffix string
+	check  func(content, sig []byte) error
+}
+
+// New creates a Verifier from the signature configs, reading the public keys.
+// When required is set, content from remote URLs that don't match any of the
+// configs is refused.
+func New(sigs []config.SignatureConfig, required bool) (*Verifier, error) {
+	v := &Verifier{required: required}
+	for _, s := range sigs {
+		key, err := os.ReadFile(s.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s key for %s: %w", s.Type, s.URL, err)
+		}
+
+		var check func(content, sig []byte) error
+		switch s.Type {
+		case TypeCosign:
+			check, err = cosignChecker(key)
+		case TypeMinisign:
+			check, err = minisignChecker(key)
+		case TypeGPG:
+			check, err = gpgChecker(key)
+		default:
+			err = fmt.Errorf("unsupported signature type %q", s.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s key %s for %s: %w", s.Type, s.Key, s.URL, err)
+		}
+
+		suffix := s.Suffix
+		if suffix == "" {
+			suffix = DefaultSuffix(s.Type)
+		}
+		v.rules = append(v.rules, rule{prefix: s.URL, suffix: suffix, check: check})
+	}
+	return v, nil
+}
+
+// Verify checks the content read from u. When a signature is configured for
+// the URL, it's read with readSignature (given the signature's suffix), and
+// must be valid. Otherwise the content is only refused when signatures are
+// required and the URL is remote.
+func (v *Verifier) Verify(u *url.URL, content []byte, readSignature func(suffix string) ([]byte, error)) error {
+	if v == nil {
+		return nil
+	}
+
+	r, ok := v.match(u.String())
+	if !ok {
+		if v.required && isRemote(u) {
+			return fmt.Errorf("refusing to use unsigned content from %s (signatures are required)", u)
+		}
+		return nil
+	}
+
+	sig, err := readSignature(r.suffix)
+	if err != nil {
+		return fmt.Errorf("failed to read signature for %s: %w", u, err)
+	}
+	if err := r.check(content, sig); err != nil {
+		return fmt.Errorf("signature verification failed for %s: %w", u, err)
+	}
+	return nil
+}
+
+// IsSignature returns whether u is the URL of a detached signature, rather
+// than of content to verify - signatures are found alongside the content, so
+// may be read when a whole directory is
+func (v *Verifier) IsSignature(u *url.URL) bool {
+	if v == nil {
+		return false
+	}
+	s := u.String()
+	for _, r := range v.rules {
+		if strings.HasSuffix(s, r.suffix) {
+			if m, ok := v.match(strings.TrimSuffix(s, r.suffix)); ok && m.suffix == r.suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match finds the rule with the longest prefix of s
+func (v *Verifier) match(s string) (rule, bool) {
+	found := -1
+	for i, r := range v.rules {
+		if strings.HasPrefix(s, r.prefix) && (found < 0 || len(r.prefix) > len(v.rules[found].prefix)) {
+			found = i
+		}
+	}
+	if found < 0 {
+		return rule{}, false
+	}
+	return v.rules[found], true
+}
+
+// isRemote - whether content from the URL comes from outside this machine
+func isRemote(u *url.URL) bool {
+	switch u.Scheme {
+	case "", "file", "stdin", "env", "merge":
+		return false
+	}
+	return true
+}
+
+// cosignChecker verifies signatures made with 'cosign sign-blob' and a key
+// pair (keyless signatures aren't supported). Signatures are base64-encoded.
+func cosignChecker(key []byte) (func(content, sig []byte) error, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM-encoded public key found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(content, sig []byte) error {
+		raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil {
+			// the signature may not be encoded
+			raw = sig
+		}
+
+		digest := sha256.Sum256(content)
+		switch k := pub.(type) {
+		case *ecdsa.PublicKey:
+			if !ecdsa.VerifyASN1(k, digest[:], raw) {
+				return fmt.Errorf("invalid signature")
+			}
+		case *rsa.PublicKey:
+			return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], raw)
+		case ed25519.PublicKey:
+			if !ed25519.Verify(k, content, raw) {
+				return fmt.Errorf("invalid signature")
+			}
+		default:
+			return fmt.Errorf("unsupported public key type %T", pub)
+		}
+		return nil
+	}, nil
+}
+
+// minisign key and signature algorithms
+var (
+	minisignEd        = []byte("Ed")
+	minisignPrehashed = []byte("ED")
+)
+
+// minisignChecker verifies minisign signatures, including the signature of
+// the trusted comment
+func minisignChecker(key []byte) (func(content, sig []byte) error, error) {
+	b, err := minisignLine(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 2+8+ed25519.PublicKeySize || !bytes.Equal(b[:2], minisignEd) {
+		return nil, fmt.Errorf("not a minisign public key")
+	}
+	keyID := b[2:10]
+	pub := ed25519.PublicKey(b[10:])
+
+	return func(content, sig []byte) error {
+		b, err := minisignLine(sig)
+		if err != nil {
+			return err
+		}
+		if len(b) != 2+8+ed25519.SignatureSize {
+			return fmt.Errorf("malformed minisign signature")
+		}
+		if !bytes.Equal(b[2:10], keyID) {
+			return fmt.Errorf("signed with key %X, not %X",
+				binary.LittleEndian.Uint64(b[2:10]), binary.LittleEndian.Uint64(keyID))
+		}
+
+		msg := content
+		switch {
+		case bytes.Equal(b[:2], minisignPrehashed):
+			h := blake2b.Sum512(content)
+			msg = h[:]
+		case !bytes.Equal(b[:2], minisignEd):
+			return fmt.Errorf("unsupported minisign signature algorithm %q", b[:2])
+		}
+		if !ed25519.Verify(pub, msg, b[10:]) {
+			return fmt.Errorf("invalid signature")
+		}
+
+		return checkTrustedComment(pub, sig, b[10:])
+	}, nil
+}
+
+// checkTrustedComment verifies the global signature, which covers the
+// signature and the trusted comment
+func checkTrustedComment(pub ed25519.PublicKey, sigFile, sig []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(sigFile)), "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("malformed minisign signature: trusted comment is missing")
+	}
+	comment := strings.TrimSpace(lines[2])
+	if !strings.HasPrefix(comment, "trusted comment: ") {
+		return fmt.Errorf("malformed minisign signature: trusted comment is missing")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return fmt.Errorf("malformed minisign signature: %w", err)
+	}
+
+	msg := append(append([]byte{}, sig...), strings.TrimPrefix(comment, "trusted comment: ")...)
+	if !ed25519.Verify(pub, msg, global) {
+		return fmt.Errorf("invalid signature of trusted comment")
+	}
+	return nil
+}
+
+// minisignLine decodes the line after the untrusted comment
+func minisignLine(b []byte) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if strings.HasPrefix(lines[0], "untrusted comment:") {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("malformed minisign file")
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(lines[0]))
+}
+
+// gpgChecker verifies detached GPG signatures (armored or binary), made by
+// any key in the keyring (which may also be armored or binary)
+func gpgChecker(key []byte) (func(content, sig []byte) error, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func(content, sig []byte) error {
+		var err error
+		if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN")) {
+			_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(content), bytes.NewReader(sig), nil)
+		} else {
+			_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(content), bytes.NewReader(sig), nil)
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,209 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+var content = []byte("hello world\n")
+
+func mustParseURL(in string) *url.URL {
+	u, _ := url.Parse(in)
+	return u
+}
+
+func writeKey(t *testing.T, b []byte) string {
+	t.Helper()
+	f := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(f, b, 0o600))
+	return f
+}
+
+func sigReader(sigs map[string][]byte) func(string) ([]byte, error) {
+	return func(suffix string) ([]byte, error) {
+		b, ok := sigs[suffix]
+		if !ok {
+			return nil, fmt.Errorf("not found")
+		}
+		return b, nil
+	}
+}
+
+func TestCosign(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	key := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	digest := sha256.Sum256(content)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	require.NoError(t, err)
+	encoded := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	v, err := New([]config.SignatureConfig{
+		{URL: "https://example.com/", Type: TypeCosign, Key: writeKey(t, key)},
+	}, false)
+	require.NoError(t, err)
+
+	u := mustParseURL("https://example.com/foo.json")
+	err = v.Verify(u, content, sigReader(map[string][]byte{".sig": encoded}))
+	assert.NoError(t, err)
+
+	// raw (unencoded) signatures are supported too
+	err = v.Verify(u, content, sigReader(map[string][]byte{".sig": sig}))
+	assert.NoError(t, err)
+
+	err = v.Verify(u, []byte("tampered"), sigReader(map[string][]byte{".sig": encoded}))
+	assert.EqualError(t, err, "signature verification failed for https://example.com/foo.json: invalid signature")
+
+	err = v.Verify(u, content, sigReader(nil))
+	assert.EqualError(t, err, "failed to read signature for https://example.com/foo.json: not found")
+
+	_, err = New([]config.SignatureConfig{
+		{URL: "https://example.com/", Type: TypeCosign, Key: writeKey(t, []byte("not a key"))},
+	}, false)
+	assert.Error(t, err)
+}
+
+func minisignFiles(t *testing.T, prehash bool) (key, sig []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	k := append(append([]byte("Ed"), keyID...), pub...)
+	key = []byte("untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(k) + "\n")
+
+	alg, msg := []byte("Ed"), content
+	if prehash {
+		h := blake2b.Sum512(content)
+		alg, msg = []byte("ED"), h[:]
+	}
+	s := ed25519.Sign(priv, msg)
+	comment := "timestamp:1234567890\tfile:foo.json"
+	global := ed25519.Sign(priv, append(append([]byte{}, s...), comment...))
+
+	sigLine := append(append(alg, keyID...), s...)
+	sig = []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(sigLine) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+	return key, sig
+}
+
+func TestMinisign(t *testing.T) {
+	for _, prehash := range []bool{false, true} {
+		key, sig := minisignFiles(t, prehash)
+
+		v, err := New([]config.SignatureConfig{
+			{URL: "s3://bucket/", Type: TypeMinisign, Key: writeKey(t, key)},
+		}, false)
+		require.NoError(t, err)
+
+		u := mustParseURL("s3://bucket/foo.json")
+		err = v.Verify(u, content, sigReader(map[string][]byte{".minisig": sig}))
+		assert.NoError(t, err)
+
+		err = v.Verify(u, []byte("tampered"), sigReader(map[string][]byte{".minisig": sig}))
+		assert.Error(t, err)
+
+		tampered := bytes.Replace(sig, []byte("file:foo.json"), []byte("file:bar.json"), 1)
+		err = v.Verify(u, content, sigReader(map[string][]byte{".minisig": tampered}))
+		assert.EqualError(t, err, "signature verification failed for s3://bucket/foo.json: invalid signature of trusted comment")
+	}
+
+	// signed by a different key
+	key, _ := minisignFiles(t, true)
+	_, sig := minisignFiles(t, true)
+	v, err := New([]config.SignatureConfig{
+		{URL: "s3://bucket/", Type: TypeMinisign, Key: writeKey(t, key)},
+	}, false)
+	require.NoError(t, err)
+	err = v.Verify(mustParseURL("s3://bucket/foo.json"), content, sigReader(map[string][]byte{".minisig": sig}))
+	assert.Error(t, err)
+}
+
+func TestGPG(t *testing.T) {
+	e, err := openpgp.NewEntity("Test", "", "test@example.com", nil)
+	require.NoError(t, err)
+
+	keyring := &bytes.Buffer{}
+	require.NoError(t, e.Serialize(keyring))
+
+	armored := &bytes.Buffer{}
+	require.NoError(t, openpgp.ArmoredDetachSign(armored, e, bytes.NewReader(content), nil))
+	binary := &bytes.Buffer{}
+	require.NoError(t, openpgp.DetachSign(binary, e, bytes.NewReader(content), nil))
+
+	v, err := New([]config.SignatureConfig{
+		{URL: "https://example.com/", Type: TypeGPG, Key: writeKey(t, keyring.Bytes())},
+		{URL: "https://example.com/bin/", Type: TypeGPG, Key: writeKey(t, keyring.Bytes()), Suffix: ".gpg"},
+	}, false)
+	require.NoError(t, err)
+
+	err = v.Verify(mustParseURL("https://example.com/foo.json"), content,
+		sigReader(map[string][]byte{".asc": armored.Bytes()}))
+	assert.NoError(t, err)
+
+	// the longest prefix wins
+	err = v.Verify(mustParseURL("https://example.com/bin/foo.json"), content,
+		sigReader(map[string][]byte{".gpg": binary.Bytes()}))
+	assert.NoError(t, err)
+
+	err = v.Verify(mustParseURL("https://example.com/foo.json"), []byte("tampered"),
+		sigReader(map[string][]byte{".asc": armored.Bytes()}))
+	assert.Error(t, err)
+}
+
+func TestVerifyRequired(t *testing.T) {
+	var v *Verifier
+	assert.NoError(t, v.Verify(mustParseURL("https://example.com/foo"), content, sigReader(nil)))
+
+	v, err := New(nil, false)
+	require.NoError(t, err)
+	assert.NoError(t, v.Verify(mustParseURL("https://example.com/foo"), content, sigReader(nil)))
+
+	v, err = New(nil, true)
+	require.NoError(t, err)
+	err = v.Verify(mustParseURL("https://example.com/foo"), content, sigReader(nil))
+	assert.EqualError(t, err, "refusing to use unsigned content from https://example.com/foo (signatures are required)")
+
+	// local content isn't refused
+	for _, u := range []string{"file:///tmp/foo.json", "env:FOO", "stdin:///foo.json", "foo.json"} {
+		assert.NoError(t, v.Verify(mustParseURL(u), content, sigReader(nil)), u)
+	}
+}
+
+func TestIsSignature(t *testing.T) {
+	var v *Verifier
+	assert.False(t, v.IsSignature(mustParseURL("https://example.com/foo.sig")))
+
+	v = &Verifier{rules: []rule{
+		{prefix: "https://example.com/", suffix: ".sig"},
+		{prefix: "https://example.com/gpg/", suffix: ".asc"},
+	}}
+	assert.True(t, v.IsSignature(mustParseURL("https://example.com/foo.json.sig")))
+	assert.True(t, v.IsSignature(mustParseURL("https://example.com/gpg/foo.json.asc")))
+	assert.False(t, v.IsSignature(mustParseURL("https://example.com/foo.json")))
+	assert.False(t, v.IsSignature(mustParseURL("https://example.com/gpg/foo.json.sig")))
+	assert.False(t, v.IsSignature(mustParseURL("https://example.org/foo.json.sig")))
+}
@@ -0,0 +1,265 @@
+// Package zkclient reads nodes from Apache ZooKeeper, for the zk: and zk+tls:
+// datasources.
+package zkclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+)
+
+// Schemes
+const (
+	Scheme    = "zk"
+	TLSScheme = "zk+tls"
+)
+
+// ErrNotFound - the node doesn't exist
+var ErrNotFound = zk.ErrNoNode
+
+// Conn - the parts of a ZooKeeper connection used here
+type Conn interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Close()
+}
+
+const timeout = 10 * time.Second
+
+// Servers - the ZooKeeper servers named in the URL's host, which may be a
+// comma-separated list (e.g. zk://zk1:2181,zk2:2181/path), or in the
+// ZK_SERVERS environment variable. Ports default to 2181, or 2281 with TLS.
+func Servers(u *url.URL) []string {
+	hosts := u.Host
+	if hosts == "" {
+		hosts = os.Getenv("ZK_SERVERS")
+	}
+	if hosts == "" {
+		hosts = "localhost"
+	}
+
+	port := "2181"
+	if u.Scheme == TLSScheme {
+		port = "2281"
+	}
+
+	servers := []string{}
+	for _, h := range strings.Split(hosts, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(h); err != nil {
+			h = net.JoinHostPort(strings.Trim(h, "[]"), port)
+		}
+		servers = append(servers, h)
+	}
+	return servers
+}
+
+// Connect to the ZooKeeper ensemble in the URL, with TLS for zk+tls: URLs,
+// waiting until a session is established.
+//
+// Digest authentication is used with the URL's user info, or with the
+// credentials in ZK_AUTH (in "user:password" form). With TLS, the server is
+// verified with the CA certificate file named by ZK_CA_CERT (when set), and
+// a client certificate is presented when ZK_CLIENT_CERT and ZK_CLIENT_KEY are
+// set.
+func Connect(ctx context.Context, u *url.URL) (Conn, error) {
+	dial := (&net.Dialer{}).DialContext
+	if p := netpolicy.FromContext(ctx); p != nil {
+		dial = p.DialContext(u.Scheme, dial)
+	}
+
+	var tlsConfig *tls.Config
+	if u.Scheme == TLSScheme {
+		var err error
+		tlsConfig, err = clientTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		conn, err := dial(dctx, network, address)
+		if err != nil || tlsConfig == nil {
+			return conn, err
+		}
+		cfg := tlsConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(address)
+		}
+		tc := tls.Client(conn, cfg)
+		if err := tc.HandshakeContext(dctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tc, nil
+	}
+
+	servers := Servers(u)
+	c, events, err := zk.Connect(servers, timeout,
+		zk.WithDialer(dialer),
+		zk.WithLogger(nopLogger{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ZooKeeper: %w", err)
+	}
+
+	if err := waitForSession(ctx, events); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to connect to ZooKeeper at %s: %w", strings.Join(servers, ","), err)
+	}
+
+	auth := os.Getenv("ZK_AUTH")
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		auth = u.User.Username() + ":" + pass
+	}
+	if auth != "" {
+		if err := c.AddAuth("digest", []byte(auth)); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("ZooKeeper authentication failed: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+func waitForSession(ctx context.Context, events <-chan zk.Event) error {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return errors.New("connection closed")
+			}
+			switch ev.State {
+			case zk.StateHasSession:
+				return nil
+			case zk.StateAuthFailed:
+				return zk.ErrAuthFailed
+			}
+		case <-t.C:
+			return errors.New("timed out waiting for a session")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func clientTLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if ca := os.Getenv("ZK_CA_CERT"); ca != "" {
+		b, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ZK_CA_CERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", ca)
+		}
+		cfg.RootCAs = pool
+	}
+
+	certFile, keyFile := os.Getenv("ZK_CLIENT_CERT"), os.Getenv("ZK_CLIENT_KEY")
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ZooKeeper client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// nopLogger silences the client's logging, which goes to stdout by default
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// Get - the data in the node
+func Get(c Conn, p string) ([]byte, error) {
+	b, _, err := c.Get(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", p, err)
+	}
+	return b, nil
+}
+
+// Children - the sorted names of the node's children
+func Children(c Conn, p string) ([]string, error) {
+	children, _, err := c.Children(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", p, err)
+	}
+	sort.Strings(children)
+	return children, nil
+}
+
+// ChildrenData - the data in each of the node's children, keyed by name.
+// Children deleted while they're being read are skipped.
+func ChildrenData(c Conn, p string) (map[string]string, error) {
+	children, err := Children(c, p)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(children))
+	for _, name := range children {
+		b, _, err := c.Get(path.Join(p, name))
+		if errors.Is(err, zk.ErrNoNode) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", path.Join(p, name), err)
+		}
+		out[name] = string(b)
+	}
+	return out, nil
+}
+
+// Watch returns a channel which is closed when the node's data or children
+// next change (or it's created or deleted), or when the context is done.
+func Watch(ctx context.Context, c Conn, p string) (<-chan struct{}, error) {
+	exists, _, dataCh, err := c.ExistsW(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", p, err)
+	}
+
+	// children can only be watched on nodes that exist
+	var childCh <-chan zk.Event
+	if exists {
+		_, _, childCh, err = c.ChildrenW(p)
+		if err != nil && !errors.Is(err, zk.ErrNoNode) {
+			return nil, fmt.Errorf("failed to watch %s: %w", p, err)
+		}
+	}
+
+	changed := make(chan struct{})
+	go func() {
+		defer close(changed)
+		select {
+		case <-dataCh:
+		case <-childCh:
+		case <-ctx.Done():
+		}
+	}()
+	return changed, nil
+}
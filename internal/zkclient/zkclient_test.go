@@ -0,0 +1,174 @@
+package zkclient
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn - a tree of nodes in memory, with one watch channel per path
+type fakeConn struct {
+	nodes   map[string]string
+	watches map[string]chan zk.Event
+}
+
+func (c *fakeConn) Get(p string) ([]byte, *zk.Stat, error) {
+	v, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return []byte(v), &zk.Stat{}, nil
+}
+
+func (c *fakeConn) watch(p string) chan zk.Event {
+	if c.watches[p] == nil {
+		c.watches[p] = make(chan zk.Event, 1)
+	}
+	return c.watches[p]
+}
+
+func (c *fakeConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	_, ok := c.nodes[p]
+	return ok, &zk.Stat{}, c.watch(p), nil
+}
+
+func (c *fakeConn) Children(p string) ([]string, *zk.Stat, error) {
+	if _, ok := c.nodes[p]; !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	children := []string{}
+	for k := range c.nodes {
+		if k != p && path.Dir(k) == p {
+			children = append(children, path.Base(k))
+		}
+	}
+	return children, &zk.Stat{}, nil
+}
+
+func (c *fakeConn) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	children, stat, err := c.Children(p)
+	return children, stat, c.watch(p + "/"), err
+}
+
+func (c *fakeConn) Close() {}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		nodes: map[string]string{
+			"/":                 "",
+			"/app":              "",
+			"/app/config":       `{"replicas": 3}`,
+			"/app/db":           "postgres",
+			"/app/db/primary":   "db1",
+			"/app/feature-flag": "on",
+		},
+		watches: map[string]chan zk.Event{},
+	}
+}
+
+func TestServers(t *testing.T) {
+	u, err := url.Parse("zk://zk1,zk2:2182/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zk1:2181", "zk2:2182"}, Servers(u))
+
+	u, err = url.Parse("zk://zk1:2181,zk2:2181/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zk1:2181", "zk2:2181"}, Servers(u))
+
+	u, _ = url.Parse("zk+tls://zk1/app")
+	assert.Equal(t, []string{"zk1:2281"}, Servers(u))
+
+	u, _ = url.Parse("zk:///app")
+	assert.Equal(t, []string{"localhost:2181"}, Servers(u))
+
+	os.Setenv("ZK_SERVERS", "a:1, b:2")
+	defer os.Unsetenv("ZK_SERVERS")
+	assert.Equal(t, []string{"a:1", "b:2"}, Servers(u))
+}
+
+func TestGetAndChildren(t *testing.T) {
+	c := newFakeConn()
+
+	b, err := Get(c, "/app/db")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", string(b))
+
+	_, err = Get(c, "/app/missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	children, err := Children(c, "/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config", "db", "feature-flag"}, children)
+
+	data, err := ChildrenData(c, "/app")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"config":       `{"replicas": 3}`,
+		"db":           "postgres",
+		"feature-flag": "on",
+	}, data)
+
+	_, err = ChildrenData(c, "/missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestWatch(t *testing.T) {
+	c := newFakeConn()
+	ctx := context.Background()
+
+	// data changes
+	ch, err := Watch(ctx, c, "/app/db")
+	require.NoError(t, err)
+	c.watches["/app/db"] <- zk.Event{Type: zk.EventNodeDataChanged}
+	assertClosed(t, ch)
+
+	// children change
+	ch, err = Watch(ctx, c, "/app")
+	require.NoError(t, err)
+	c.watches["/app/"] <- zk.Event{Type: zk.EventNodeChildrenChanged}
+	assertClosed(t, ch)
+
+	// nodes that don't exist yet can be watched for creation
+	ch, err = Watch(ctx, c, "/app/new")
+	require.NoError(t, err)
+	assert.Nil(t, c.watches["/app/new/"])
+	c.watches["/app/new"] <- zk.Event{Type: zk.EventNodeCreated}
+	assertClosed(t, ch)
+
+	// the context being cancelled stops the watch
+	cctx, cancel := context.WithCancel(ctx)
+	ch, err = Watch(cctx, c, "/app/config")
+	require.NoError(t, err)
+	cancel()
+	assertClosed(t, ch)
+}
+
+func assertClosed(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("watch channel wasn't closed")
+	}
+}
+
+func TestClientTLSConfig(t *testing.T) {
+	cfg, err := clientTLSConfig()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.RootCAs)
+	assert.Empty(t, cfg.Certificates)
+
+	os.Setenv("ZK_CA_CERT", "/does/not/exist")
+	defer os.Unsetenv("ZK_CA_CERT")
+	_, err = clientTLSConfig()
+	assert.True(t, strings.Contains(err.Error(), "ZK_CA_CERT"))
+}
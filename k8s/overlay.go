@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Overlay - apply strategic merge patches to the matching resources, as
+// Kustomize does with patchesStrategicMerge. Each patch must identify its
+// target with kind and metadata.name, and may also give apiVersion and
+// metadata.namespace to narrow it down. Every patch must match at least one
+// resource. A patch with `$patch: delete` removes the resources it matches.
+//
+// Patches are applied in order, and the resources aren't modified.
+func Overlay(resources []interface{}, patches []map[string]interface{}, mergeKeys map[string]string) ([]interface{}, error) {
+	out := append([]interface{}{}, resources...)
+	for i, patch := range patches {
+		target, err := targetOf(patch)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i, err)
+		}
+
+		matched := false
+		kept := make([]interface{}, 0, len(out))
+		for _, r := range out {
+			rm, ok := r.(map[string]interface{})
+			if !ok || !target.matches(rm) {
+				kept = append(kept, r)
+				continue
+			}
+			matched = true
+
+			if patch[directiveKey] == "delete" {
+				continue
+			}
+			// apiVersion is only compared by group, so the resource's version
+			// is kept
+			p := patch
+			if _, ok := patch["apiVersion"]; ok {
+				p = copyMap(patch)
+				delete(p, "apiVersion")
+			}
+			patched, err := StrategicMergePatch(rm, p, mergeKeys)
+			if err != nil {
+				return nil, fmt.Errorf("patch %d (%s): %w", i, target, err)
+			}
+			kept = append(kept, patched)
+		}
+		if !matched {
+			return nil, fmt.Errorf("patch %d (%s) matched no resources", i, target)
+		}
+		out = kept
+	}
+	return out, nil
+}
+
+// target identifies the resources a patch applies to
+type target struct {
+	group, kind, name, namespace string
+}
+
+func targetOf(patch map[string]interface{}) (target, error) {
+	t := target{}
+	t.kind, _ = patch["kind"].(string)
+	meta, _ := patch["metadata"].(map[string]interface{})
+	t.name, _ = meta["name"].(string)
+	if t.kind == "" || t.name == "" {
+		return t, fmt.Errorf("kind and metadata.name must be set")
+	}
+	t.namespace, _ = meta["namespace"].(string)
+	if av, ok := patch["apiVersion"].(string); ok {
+		t.group = groupOf(av)
+	}
+	return t, nil
+}
+
+func (t target) matches(r map[string]interface{}) bool {
+	if kind, _ := r["kind"].(string); kind != t.kind {
+		return false
+	}
+	meta, _ := r["metadata"].(map[string]interface{})
+	if name, _ := meta["name"].(string); name != t.name {
+		return false
+	}
+	if ns, _ := meta["namespace"].(string); t.namespace != "" && ns != t.namespace {
+		return false
+	}
+	if av, ok := r["apiVersion"].(string); ok && t.group != "" && groupOf(av) != t.group {
+		return false
+	}
+	return true
+}
+
+func (t target) String() string {
+	s := t.kind + "/" + t.name
+	if t.namespace != "" {
+		s = t.namespace + "/" + s
+	}
+	return s
+}
+
+// groupOf returns the API group of an apiVersion, like apps for apps/v1. The
+// core group has no name, so it's returned as "core".
+func groupOf(apiVersion string) string {
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return apiVersion[:i]
+	}
+	return "core"
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
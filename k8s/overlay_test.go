@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+
+	resources := []interface{}{
+		parse(t, `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}, spec: {replicas: 1}}`),
+		parse(t, `{apiVersion: apps/v1, kind: Deployment, metadata: {name: api, namespace: prod}, spec: {replicas: 1}}`),
+		parse(t, `{apiVersion: v1, kind: Service, metadata: {name: web}, spec: {type: ClusterIP}}`),
+	}
+
+	out, err := Overlay(resources, []map[string]interface{}{
+		parse(t, `{kind: Deployment, metadata: {name: web}, spec: {replicas: 3}}`),
+		parse(t, `{apiVersion: apps/v1beta1, kind: Deployment, metadata: {name: api, namespace: prod}, spec: {replicas: 5}}`),
+		parse(t, `{kind: Service, metadata: {name: web}, $patch: delete}`),
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		parse(t, `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}, spec: {replicas: 3}}`),
+		parse(t, `{apiVersion: apps/v1, kind: Deployment, metadata: {name: api, namespace: prod}, spec: {replicas: 5}}`),
+	}, out)
+
+	// the resources aren't modified
+	assert.Equal(t, 1, resources[0].(map[string]interface{})["spec"].(map[string]interface{})["replicas"])
+
+	_, err = Overlay(resources, []map[string]interface{}{
+		parse(t, `{kind: Deployment, metadata: {name: missing}}`),
+	}, nil)
+	assert.EqualError(t, err, "patch 0 (Deployment/missing) matched no resources")
+
+	_, err = Overlay(resources, []map[string]interface{}{
+		parse(t, `{kind: Deployment, metadata: {name: api, namespace: dev}}`),
+	}, nil)
+	assert.Error(t, err)
+
+	_, err = Overlay(resources, []map[string]interface{}{
+		parse(t, `{apiVersion: v1, kind: Deployment, metadata: {name: web}}`),
+	}, nil)
+	assert.Error(t, err)
+
+	_, err = Overlay(resources, []map[string]interface{}{
+		parse(t, `{metadata: {name: web}}`),
+	}, nil)
+	assert.Error(t, err)
+}
+
+func TestGroupOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "core", groupOf("v1"))
+	assert.Equal(t, "apps", groupOf("apps/v1"))
+	assert.Equal(t, "networking.k8s.io", groupOf("networking.k8s.io/v1"))
+}
@@ -0,0 +1,372 @@
+// Package k8s contains functions for working with Kubernetes manifests
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/mapmeta"
+)
+
+// MergeKeys are the merge keys of the lists in the Kubernetes API that
+// strategic merge patches merge, rather than replace, by field name. The API
+// declares these per type, but in practice the field names are unambiguous -
+// except for ports, which are merged by containerPort in containers, and by
+// port elsewhere.
+var MergeKeys = map[string]string{
+	"conditions":                "type",
+	"containers":                "name",
+	"env":                       "name",
+	"ephemeralContainers":       "name",
+	"hostAliases":               "ip",
+	"imagePullSecrets":          "name",
+	"initContainers":            "name",
+	"ownerReferences":           "uid",
+	"ports":                     "port",
+	"resourceClaims":            "name",
+	"topologySpreadConstraints": "topologyKey",
+	"volumeDevices":             "devicePath",
+	"volumeMounts":              "mountPath",
+	"volumes":                   "name",
+}
+
+// mergedScalarLists are lists of scalars that are merged as sets, rather than
+// replaced
+var mergedScalarLists = map[string]bool{
+	"finalizers": true,
+}
+
+const (
+	directiveKey        = "$patch"
+	retainKeysKey       = "$retainKeys"
+	deleteFromPrimitive = "$deleteFromPrimitiveList/"
+	setElementOrder     = "$setElementOrder/"
+)
+
+// StrategicMergePatch - apply a strategic merge patch to a Kubernetes
+// manifest, with the same semantics as kubectl. Maps are merged recursively,
+// and null values delete keys. Lists are replaced, except for the lists in
+// MergeKeys (and in mergeKeys, which adds to or overrides them), whose items
+// are merged by the value of their merge key.
+//
+// The $patch (merge, replace, or delete), $retainKeys, and
+// $deleteFromPrimitiveList directives are supported. $setElementOrder is
+// accepted, but ignored.
+//
+// The base manifest isn't modified.
+func StrategicMergePatch(base, patch map[string]interface{}, mergeKeys map[string]string) (map[string]interface{}, error) {
+	p := &patcher{mergeKeys: mergeKeys}
+	out, deleted, err := p.mergeMap(base, patch)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return map[string]interface{}{}, nil
+	}
+	return out, nil
+}
+
+type patcher struct {
+	mergeKeys map[string]string
+}
+
+// mergeMap merges the patch into the base map. The returned bool is true when
+// the patch deletes the map.
+func (p *patcher) mergeMap(base, patch map[string]interface{}) (map[string]interface{}, bool, error) {
+	switch d := patch[directiveKey]; d {
+	case nil, "merge":
+	case "replace":
+		v, _, err := p.clean(patch)
+		if err != nil {
+			return nil, false, err
+		}
+		out, _ := v.(map[string]interface{})
+		return out, false, nil
+	case "delete":
+		return nil, true, nil
+	default:
+		return nil, false, fmt.Errorf("unknown %s directive %v", directiveKey, d)
+	}
+
+	out := make(map[string]interface{}, len(base)+len(patch))
+	for k, v := range base {
+		out[k] = v
+	}
+	// keep the base's key order and formatting, if they were recorded
+	mapmeta.Inherit(out, base)
+
+	keys := sortedKeys(patch)
+
+	// deletions from scalar lists apply to the base list, before the list is
+	// merged
+	for _, k := range keys {
+		if !strings.HasPrefix(k, deleteFromPrimitive) {
+			continue
+		}
+		field := strings.TrimPrefix(k, deleteFromPrimitive)
+		del, ok := patch[k].([]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%s must be a list, got %T", k, patch[k])
+		}
+		if l, ok := out[field].([]interface{}); ok {
+			out[field] = removeValues(l, del)
+		}
+	}
+
+	for _, k := range keys {
+		if strings.HasPrefix(k, "$") {
+			continue
+		}
+		pv := patch[k]
+		if pv == nil {
+			delete(out, k)
+			continue
+		}
+
+		var err error
+		bv, exists := out[k]
+		switch pt := pv.(type) {
+		case map[string]interface{}:
+			if bm, ok := bv.(map[string]interface{}); ok {
+				m, deleted, err := p.mergeMap(bm, pt)
+				if err != nil {
+					return nil, false, fmt.Errorf("%s: %w", k, err)
+				}
+				if deleted {
+					delete(out, k)
+				} else {
+					out[k] = m
+				}
+				continue
+			}
+		case []interface{}:
+			if bl, ok := bv.([]interface{}); ok && exists {
+				out[k], err = p.mergeList(k, bl, pt)
+				if err != nil {
+					return nil, false, fmt.Errorf("%s: %w", k, err)
+				}
+				continue
+			}
+		}
+
+		v, keep, err := p.clean(pv)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", k, err)
+		}
+		if keep {
+			out[k] = v
+		} else {
+			delete(out, k)
+		}
+	}
+
+	if rk, ok := patch[retainKeysKey]; ok {
+		retain, ok := rk.([]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%s must be a list, got %T", retainKeysKey, rk)
+		}
+		for k := range out {
+			if !containsValue(retain, k) {
+				delete(out, k)
+			}
+		}
+	}
+
+	return out, false, nil
+}
+
+// mergeList merges the patch list into the base list, by merge key if the
+// field has one. Otherwise, the list is replaced.
+func (p *patcher) mergeList(field string, base, patch []interface{}) ([]interface{}, error) {
+	for _, item := range patch {
+		if m, ok := item.(map[string]interface{}); ok && m[directiveKey] == "replace" {
+			return p.cleanList(removeDirectiveItems(patch))
+		}
+	}
+
+	key := p.mergeKey(field, base, patch)
+	if key == "" {
+		if mergedScalarLists[field] {
+			out := append([]interface{}{}, base...)
+			for _, v := range patch {
+				if !containsValue(out, v) {
+					out = append(out, v)
+				}
+			}
+			return out, nil
+		}
+		return p.cleanList(patch)
+	}
+
+	out := append([]interface{}{}, base...)
+	for _, item := range patch {
+		pm, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("items must be maps to be merged by %q, got %T", key, item)
+		}
+		kv, ok := pm[key]
+		if !ok {
+			return nil, fmt.Errorf("item has no value for merge key %q", key)
+		}
+
+		i := indexOf(out, key, kv)
+		if pm[directiveKey] == "delete" {
+			if i >= 0 {
+				out = append(out[:i], out[i+1:]...)
+			}
+			continue
+		}
+		if i < 0 {
+			v, _, err := p.clean(pm)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+
+		bm, _ := out[i].(map[string]interface{})
+		m, deleted, err := p.mergeMap(bm, pm)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%v: %w", key, kv, err)
+		}
+		if deleted {
+			out = append(out[:i], out[i+1:]...)
+		} else {
+			out[i] = m
+		}
+	}
+	return out, nil
+}
+
+// mergeKey returns the field's merge key, or "" if its items aren't maps, or
+// it isn't merged
+func (p *patcher) mergeKey(field string, base, patch []interface{}) string {
+	for _, l := range [][]interface{}{base, patch} {
+		for _, item := range l {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return ""
+			}
+		}
+	}
+	if k, ok := p.mergeKeys[field]; ok {
+		return k
+	}
+	if field == "ports" {
+		for _, l := range [][]interface{}{base, patch} {
+			for _, item := range l {
+				if _, ok := item.(map[string]interface{})["containerPort"]; ok {
+					return "containerPort"
+				}
+			}
+		}
+	}
+	return MergeKeys[field]
+}
+
+// clean removes directives from a value that's being added, rather than
+// merged. The returned bool is false when the value is deleted.
+func (p *patcher) clean(v interface{}) (interface{}, bool, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		switch d := t[directiveKey]; d {
+		case nil, "merge", "replace":
+		case "delete":
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("unknown %s directive %v", directiveKey, d)
+		}
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			if strings.HasPrefix(k, "$") {
+				continue
+			}
+			c, keep, err := p.clean(e)
+			if err != nil {
+				return nil, false, err
+			}
+			if keep {
+				out[k] = c
+			}
+		}
+		return out, true, nil
+	case []interface{}:
+		out, err := p.cleanList(t)
+		return out, true, err
+	default:
+		return v, true, nil
+	}
+}
+
+func (p *patcher) cleanList(l []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(l))
+	for _, e := range l {
+		c, keep, err := p.clean(e)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// removeDirectiveItems removes items that only hold a $patch directive
+func removeDirectiveItems(l []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(l))
+	for _, e := range l {
+		if m, ok := e.(map[string]interface{}); ok && len(m) == 1 && m[directiveKey] != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func indexOf(l []interface{}, key string, value interface{}) int {
+	for i, e := range l {
+		if m, ok := e.(map[string]interface{}); ok && sameValue(m[key], value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeValues(l, del []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(l))
+	for _, v := range l {
+		if !containsValue(del, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsValue(l []interface{}, v interface{}) bool {
+	for _, e := range l {
+		if sameValue(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue compares scalars loosely, so that (for example) a port parsed as
+// an int matches one given as an int64
+func sameValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
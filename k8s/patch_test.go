@@ -0,0 +1,220 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	m := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(s), &m))
+	return m
+}
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+  finalizers: [a, b]
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: web
+          image: web:1.0
+          ports:
+            - containerPort: 80
+              protocol: TCP
+          env:
+            - name: A
+              value: "1"
+            - name: B
+              value: "2"
+        - name: sidecar
+          image: sidecar:1.0
+      tolerations:
+        - key: x
+`
+
+// get returns the value at the dotted path
+func get(m map[string]interface{}, path string) interface{} {
+	var v interface{} = m
+	for _, k := range strings.Split(path, ".") {
+		mv, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = mv[k]
+	}
+	return v
+}
+
+func TestStrategicMergePatch(t *testing.T) {
+	t.Parallel()
+
+	testdata := []struct {
+		name, patch, path, expected string
+	}{
+		{
+			"maps are merged, and null deletes",
+			`metadata: {labels: {tier: frontend, app: null}}`,
+			"metadata.labels", `{tier: frontend}`,
+		},
+		{
+			"lists with merge keys are merged",
+			`spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: web:2.0
+          env:
+            - name: B
+              value: "3"
+            - name: C
+              value: "4"
+          ports:
+            - containerPort: 80
+              name: http
+        - name: new
+          image: new:1.0`,
+			"spec.template.spec.containers",
+			`- name: web
+  image: web:2.0
+  ports:
+    - containerPort: 80
+      protocol: TCP
+      name: http
+  env:
+    - name: A
+      value: "1"
+    - name: B
+      value: "3"
+    - name: C
+      value: "4"
+- name: sidecar
+  image: sidecar:1.0
+- name: new
+  image: new:1.0`,
+		},
+		{
+			"lists without merge keys are replaced",
+			`spec: {template: {spec: {tolerations: [{key: y}]}}}`,
+			"spec.template.spec.tolerations", `[{key: y}]`,
+		},
+		{
+			"list items can be deleted",
+			`spec:
+  template:
+    spec:
+      containers:
+        - name: sidecar
+          $patch: delete`,
+			"spec.template.spec.containers",
+			`- name: web
+  image: web:1.0
+  ports:
+    - containerPort: 80
+      protocol: TCP
+  env:
+    - name: A
+      value: "1"
+    - name: B
+      value: "2"`,
+		},
+		{
+			"lists can be replaced",
+			`spec:
+  template:
+    spec:
+      containers:
+        - name: only
+          image: only:1.0
+        - $patch: replace`,
+			"spec.template.spec.containers", `[{name: only, image: only:1.0}]`,
+		},
+		{
+			"maps can be replaced",
+			`metadata: {labels: {$patch: replace, tier: frontend}}`,
+			"metadata.labels", `{tier: frontend}`,
+		},
+		{
+			"scalar lists can be merged, and have values deleted",
+			`metadata: {finalizers: [c, a], $deleteFromPrimitiveList/finalizers: [b]}`,
+			"metadata.finalizers", `[a, c]`,
+		},
+		{
+			"$retainKeys removes other keys",
+			`spec: {$retainKeys: [replicas]}`,
+			"spec", `{replicas: 1}`,
+		},
+		{
+			"$patch: delete removes the map",
+			`spec: {$patch: delete}`,
+			"spec", ``,
+		},
+	}
+
+	for _, d := range testdata {
+		d := d
+		t.Run(d.name, func(t *testing.T) {
+			t.Parallel()
+			base := parse(t, deployment)
+			out, err := StrategicMergePatch(base, parse(t, d.patch), nil)
+			require.NoError(t, err)
+
+			var expected interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(d.expected), &expected))
+			assert.Equal(t, expected, get(out, d.path))
+
+			// the base isn't modified
+			assert.Equal(t, parse(t, deployment), base)
+		})
+	}
+}
+
+func TestStrategicMergePatchMergeKeys(t *testing.T) {
+	t.Parallel()
+
+	base := parse(t, `items: [{id: 1, v: a}, {id: 2, v: b}]`)
+	patch := parse(t, `items: [{id: 2, v: c}]`)
+
+	out, err := StrategicMergePatch(base, patch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, parse(t, `items: [{id: 2, v: c}]`), out)
+
+	out, err = StrategicMergePatch(base, patch, map[string]string{"items": "id"})
+	require.NoError(t, err)
+	assert.Equal(t, parse(t, `items: [{id: 1, v: a}, {id: 2, v: c}]`), out)
+
+	_, err = StrategicMergePatch(base, parse(t, `items: [{v: c}]`), map[string]string{"items": "id"})
+	assert.Error(t, err)
+
+	// service ports are merged by port
+	out, err = StrategicMergePatch(
+		parse(t, `ports: [{port: 80, name: http}, {port: 443, name: https}]`),
+		parse(t, `ports: [{port: 443, targetPort: 8443}]`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, parse(t, `ports: [{port: 80, name: http}, {port: 443, name: https, targetPort: 8443}]`), out)
+}
+
+func TestStrategicMergePatchErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := StrategicMergePatch(map[string]interface{}{}, parse(t, `$patch: bogus`), nil)
+	assert.Error(t, err)
+
+	_, err = StrategicMergePatch(map[string]interface{}{}, parse(t, `a: {$patch: bogus}`), nil)
+	assert.Error(t, err)
+
+	_, err = StrategicMergePatch(map[string]interface{}{}, parse(t, `$retainKeys: a`), nil)
+	assert.Error(t, err)
+}
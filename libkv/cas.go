@@ -0,0 +1,53 @@
+package libkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/docker/libkv/store"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+)
+
+// Store returns the key at path as a cas.Store, versioned by its modify index,
+// so that it can be written with compare-and-swap semantics
+func (kv *LibKV) Store(path string) cas.Store {
+	return &kvStore{store: kv.store, key: path}
+}
+
+type kvStore struct {
+	store store.Store
+	key   string
+}
+
+func (s *kvStore) Version(_ context.Context) (string, error) {
+	pair, err := s.store.Get(s.key)
+	if errors.Is(err, store.ErrKeyNotFound) || (err == nil && pair == nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(pair.LastIndex, 10), nil
+}
+
+func (s *kvStore) Write(_ context.Context, content []byte, version string) (string, error) {
+	var previous *store.KVPair
+	if version != "" {
+		index, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q for key %s: %w", version, s.key, err)
+		}
+		previous = &store.KVPair{Key: s.key, LastIndex: index}
+	}
+
+	ok, pair, err := s.store.AtomicPut(s.key, content, previous, nil)
+	switch {
+	case errors.Is(err, store.ErrKeyModified), errors.Is(err, store.ErrKeyExists), err == nil && !ok:
+		return "", &cas.ConflictError{Target: s.key, Version: version}
+	case err != nil:
+		return "", err
+	}
+	return strconv.FormatUint(pair.LastIndex, 10), nil
+}
@@ -0,0 +1,53 @@
+package libkv
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/libkv/store"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	kv := &LibKV{&FakeStore{}}
+	s := kv.Store("foo")
+
+	v, err := s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", v)
+
+	v, err = s.Write(ctx, []byte("bar"), "")
+	require.NoError(t, err)
+	assert.Equal(t, "1", v)
+
+	_, err = s.Write(ctx, []byte("bar"), "")
+	assert.True(t, cas.IsConflict(err))
+
+	v, err = s.Write(ctx, []byte("baz"), "1")
+	require.NoError(t, err)
+	assert.Equal(t, "2", v)
+
+	v, err = s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2", v)
+
+	_, err = s.Write(ctx, []byte("stale"), "1")
+	assert.EqualError(t, err, "conflicting write: foo was modified by another writer (expected version 1)")
+
+	_, err = s.Write(ctx, []byte("bogus"), "not-a-number")
+	assert.Error(t, err)
+	assert.False(t, cas.IsConflict(err))
+
+	kv = &LibKV{&FakeStore{err: errors.New("fail")}}
+	_, err = kv.Store("foo").Version(ctx)
+	assert.EqualError(t, err, "fail")
+
+	kv = &LibKV{&FakeStore{err: store.ErrKeyNotFound}}
+	v, err = kv.Store("foo").Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "", v)
+}
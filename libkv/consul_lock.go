@@ -0,0 +1,103 @@
+package libkv
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// ConsulLock - a lock on a Consul key, held with a session, for coordinating
+// work between processes
+type ConsulLock struct {
+	// Key - the locked key
+	Key string
+
+	lock *consulapi.Lock
+	lost <-chan struct{}
+}
+
+// NewConsulLock - a lock on the key in the URL's path (which is configured
+// like a Consul datasource's URL), set to value while it's held. The lock is
+// released ttl after the process holding it dies. Acquiring the lock waits
+// for up to wait when it's held elsewhere.
+func NewConsulLock(u *url.URL, value []byte, ttl, wait time.Duration) (*ConsulLock, error) {
+	c, err := consulURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	config := consulapi.DefaultConfig()
+	config.Address = c.Host
+	config.Scheme = c.Scheme
+	if c.Scheme == https {
+		config.TLSConfig = *setupTLS()
+	}
+
+	token, err := consulTokenFromVault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set Consul Vault token: %w", err)
+	}
+	if token != "" {
+		config.Token = token
+	}
+
+	client, err := consulapi.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("consul setup failed: %w", err)
+	}
+
+	if ttl == 0 {
+		ttl = 15 * time.Second
+	}
+	if wait == 0 {
+		// the Consul client treats 0 as its default (15s)
+		wait = time.Millisecond
+	}
+
+	key := strings.Trim(u.Path, "/")
+	lock, err := client.LockOpts(&consulapi.LockOptions{
+		Key:          key,
+		Value:        value,
+		SessionName:  "gomplate",
+		SessionTTL:   ttl.String(),
+		LockTryOnce:  true,
+		LockWaitTime: wait,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock on %s: %w", key, err)
+	}
+
+	return &ConsulLock{Key: key, lock: lock}, nil
+}
+
+// Acquire the lock, returning false if it's held elsewhere and wasn't
+// released in time
+func (l *ConsulLock) Acquire() (bool, error) {
+	lost, err := l.lock.Lock(nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock on %s: %w", l.Key, err)
+	}
+	if lost == nil {
+		return false, nil
+	}
+	l.lost = lost
+	return true, nil
+}
+
+// Lost - closed when the lock is lost after being acquired (for example when
+// the session can't be renewed), or nil when it wasn't acquired
+func (l *ConsulLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release the lock
+func (l *ConsulLock) Release() error {
+	err := l.lock.Unlock()
+	if err != nil && err != consulapi.ErrLockNotHeld {
+		return fmt.Errorf("failed to release lock on %s: %w", l.Key, err)
+	}
+	return nil
+}
@@ -75,7 +75,30 @@ func (s *FakeStore) DeleteTree(directory string) error {
 }
 
 func (s *FakeStore) AtomicPut(key string, value []byte, previous *store.KVPair, options *store.WriteOptions) (bool, *store.KVPair, error) {
-	return false, nil, nil
+	if s.err != nil {
+		return false, nil, s.err
+	}
+
+	for _, v := range s.data {
+		if v.Key != key {
+			continue
+		}
+		if previous == nil {
+			return false, nil, store.ErrKeyExists
+		}
+		if previous.LastIndex != v.LastIndex {
+			return false, nil, store.ErrKeyModified
+		}
+		v.Value = value
+		v.LastIndex++
+		return true, v, nil
+	}
+	if previous != nil {
+		return false, nil, store.ErrKeyNotFound
+	}
+	pair := &store.KVPair{Key: key, Value: value, LastIndex: 1}
+	s.data = append(s.data, pair)
+	return true, pair, nil
 }
 
 func (s *FakeStore) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
@@ -0,0 +1,103 @@
+package gomplate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/libkv"
+	"github.com/rs/zerolog"
+)
+
+// locker - a distributed lock, like a *libkv.ConsulLock
+type locker interface {
+	Acquire() (bool, error)
+	Lost() <-chan struct{}
+	Release() error
+}
+
+// newLocker creates the lock for the config - it's a variable so it can be
+// replaced in tests
+var newLocker = func(u *url.URL, cfg *config.LockConfig, value []byte) (locker, error) {
+	return libkv.NewConsulLock(u, value, cfg.TTL, cfg.Wait)
+}
+
+// lockState - the state of the lock held while rendering, available to
+// templates as .Lock
+type lockState struct {
+	// Key - the locked key
+	Key string
+	// Value - the value of the locked key, identifying this process
+	Value string
+
+	lost <-chan struct{}
+}
+
+// Held - whether the lock is still held. When it's lost, rendering is
+// cancelled, but a template may still want to check before doing something
+// expensive.
+func (l *lockState) Held() bool {
+	select {
+	case <-l.lost:
+		return false
+	default:
+		return true
+	}
+}
+
+// acquireLock acquires the configured lock. The returned state is nil when
+// the lock is held elsewhere. Otherwise, the returned function must be called
+// to release the lock.
+func acquireLock(ctx context.Context, cfg *config.LockConfig) (*lockState, func(), error) {
+	hostname, _ := os.Hostname()
+	value := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+
+	u, err := config.ParseSourceURL(cfg.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := newLocker(u, cfg, []byte(value))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ok, err := l.Acquire()
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+
+	state := &lockState{
+		Key:   strings.Trim(u.Path, "/"),
+		Value: value,
+		lost:  l.Lost(),
+	}
+
+	release := func() {
+		if err := l.Release(); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("failed to release lock")
+		}
+	}
+	return state, release, nil
+}
+
+// contextWithLock returns a context that's cancelled when the lock is lost,
+// so that rendering stops as soon as possible
+func contextWithLock(ctx context.Context, l *lockState) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if !l.Held() {
+		cancel()
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-l.lost:
+			zerolog.Ctx(ctx).Warn().Str("key", l.Key).Msg("lock lost - cancelling render")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
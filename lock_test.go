@@ -0,0 +1,105 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLocker struct {
+	held     bool
+	acquired bool
+	released bool
+	lost     chan struct{}
+}
+
+func (l *fakeLocker) Acquire() (bool, error) {
+	if l.held {
+		return false, nil
+	}
+	l.acquired = true
+	return true, nil
+}
+
+func (l *fakeLocker) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *fakeLocker) Release() error {
+	l.released = true
+	return nil
+}
+
+func withFakeLocker(t *testing.T, l *fakeLocker) {
+	orig := newLocker
+	t.Cleanup(func() { newLocker = orig })
+	newLocker = func(_ *url.URL, _ *config.LockConfig, _ []byte) (locker, error) {
+		return l, nil
+	}
+}
+
+func TestRunWithLock(t *testing.T) {
+	l := &fakeLocker{lost: make(chan struct{})}
+	withFakeLocker(t, l)
+
+	out := &bytes.Buffer{}
+	cfg := &config.Config{
+		Input:       `{{ .Lock.Key }} {{ .Lock.Held }}`,
+		OutputFiles: []string{"-"},
+		Stdout:      out,
+		Lock:        &config.LockConfig{URL: "consul:///locks/app"},
+	}
+	err := Run(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "locks/app true", out.String())
+	assert.True(t, l.acquired)
+	assert.True(t, l.released)
+}
+
+func TestRunWithLockHeldElsewhere(t *testing.T) {
+	l := &fakeLocker{held: true}
+	withFakeLocker(t, l)
+
+	out := &bytes.Buffer{}
+	cfg := &config.Config{
+		Input:       "hello",
+		OutputFiles: []string{"-"},
+		Stdout:      out,
+		Lock:        &config.LockConfig{URL: "consul:///locks/app"},
+	}
+	err := Run(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+	assert.False(t, l.released)
+}
+
+func TestRunWithLockLost(t *testing.T) {
+	l := &fakeLocker{lost: make(chan struct{})}
+	close(l.lost)
+	withFakeLocker(t, l)
+
+	out := &bytes.Buffer{}
+	cfg := &config.Config{
+		Input:       "hello",
+		OutputFiles: []string{"-"},
+		Stdout:      out,
+		Lock:        &config.LockConfig{URL: "consul:///locks/app"},
+	}
+	err := Run(context.Background(), cfg)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, out.String())
+	assert.True(t, l.released)
+}
+
+func TestLockStateHeld(t *testing.T) {
+	lost := make(chan struct{})
+	l := &lockState{Key: "locks/app", lost: lost}
+	assert.True(t, l.Held())
+	close(lost)
+	assert.False(t, l.Held())
+}
@@ -0,0 +1,272 @@
+// Package mail contains functions for composing email messages, and for
+// delivering them with SMTP.
+package mail
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is an email message
+type Message struct {
+	Date        time.Time
+	Headers     map[string]string
+	From        string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Name string
+	// ContentType defaults to a type based on the name's extension
+	ContentType string
+	Content     []byte
+}
+
+// Bytes composes the message in the Internet Message Format (RFC 5322), with
+// a MIME (RFC 2045) body. When both text and HTML bodies are given, they're
+// sent as alternatives. Attachments are base64-encoded.
+//
+// The Bcc header is included, so that the message can be delivered to those
+// recipients - it is removed by Send.
+func (m *Message) Bytes() ([]byte, error) {
+	if m.From == "" {
+		return nil, fmt.Errorf("a From address is required")
+	}
+	if len(m.To)+len(m.Cc)+len(m.Bcc) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+
+	hdr := textproto.MIMEHeader{}
+	if err := setAddresses(hdr, "From", []string{m.From}); err != nil {
+		return nil, err
+	}
+	for k, v := range map[string][]string{"To": m.To, "Cc": m.Cc, "Bcc": m.Bcc} {
+		if err := setAddresses(hdr, k, v); err != nil {
+			return nil, err
+		}
+	}
+	if m.ReplyTo != "" {
+		if err := setAddresses(hdr, "Reply-To", []string{m.ReplyTo}); err != nil {
+			return nil, err
+		}
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	hdr.Set("Date", date.Format(time.RFC1123Z))
+	hdr.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	hdr.Set("MIME-Version", "1.0")
+	for k, v := range m.Headers {
+		if strings.ContainsAny(k+v, "\r\n") {
+			return nil, fmt.Errorf("header %q must not contain line breaks", k)
+		}
+		hdr.Set(k, mime.QEncoding.Encode("utf-8", v))
+	}
+
+	body := &bytes.Buffer{}
+	bodyHdr, err := m.writeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range bodyHdr {
+		hdr[k] = v
+	}
+
+	out := &bytes.Buffer{}
+	writeHeader(out, hdr)
+	out.WriteString("\r\n")
+	_, err = body.WriteTo(out)
+	return out.Bytes(), err
+}
+
+func setAddresses(hdr textproto.MIMEHeader, key string, addrs []string) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return fmt.Errorf("invalid %s address %q: %w", key, a, err)
+		}
+		out[i] = addr.String()
+	}
+	hdr.Set(key, strings.Join(out, ", "))
+	return nil
+}
+
+// headerOrder is the order in which well-known headers are written - others
+// follow in alphabetical order
+var headerOrder = []string{"Date", "From", "Reply-To", "To", "Cc", "Bcc", "Subject", "Mime-Version"}
+
+func writeHeader(w io.Writer, hdr textproto.MIMEHeader) {
+	keys := make([]string, 0, len(hdr))
+	rank := map[string]int{}
+	for i, k := range headerOrder {
+		rank[k] = i - len(headerOrder)
+	}
+	for k := range hdr {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if rank[keys[i]] != rank[keys[j]] {
+			return rank[keys[i]] < rank[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		for _, v := range hdr[k] {
+			fmt.Fprintf(w, "%s: %s\r\n", k, v)
+		}
+	}
+}
+
+// boundary returns a multipart boundary derived from the message content, so
+// that output is reproducible
+func (m *Message) boundary(kind string) string {
+	h := sha256.New()
+	fmt.Fprint(h, kind, m.Text, m.HTML, len(m.Attachments))
+	for _, a := range m.Attachments {
+		h.Write(a.Content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:32]
+}
+
+// writeBody writes the MIME body, returning the headers describing it
+func (m *Message) writeBody(w io.Writer) (textproto.MIMEHeader, error) {
+	if len(m.Attachments) == 0 {
+		return m.writeContent(w)
+	}
+
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(m.boundary("mixed")); err != nil {
+		return nil, err
+	}
+
+	content := &bytes.Buffer{}
+	hdr, err := m.writeContent(content)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := mw.CreatePart(hdr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = content.WriteTo(pw); err != nil {
+		return nil, err
+	}
+
+	for _, a := range m.Attachments {
+		if err := writeAttachment(mw, a); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()})},
+	}, nil
+}
+
+// writeContent writes the text and/or HTML parts of the message
+func (m *Message) writeContent(w io.Writer) (textproto.MIMEHeader, error) {
+	if m.HTML == "" || m.Text == "" {
+		ct, s := "text/plain", m.Text
+		if m.HTML != "" {
+			ct, s = "text/html", m.HTML
+		}
+		return textHeader(ct), writeQP(w, s)
+	}
+
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(m.boundary("alternative")); err != nil {
+		return nil, err
+	}
+	for _, p := range []struct{ ct, s string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		pw, err := mw.CreatePart(textHeader(p.ct))
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQP(pw, p.s); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})},
+	}, nil
+}
+
+func textHeader(ct string) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":              {ct + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+}
+
+func writeQP(w io.Writer, s string) error {
+	qw := quotedprintable.NewWriter(w)
+	// line breaks must be CRLF
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := io.WriteString(qw, s); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func writeAttachment(mw *multipart.Writer, a Attachment) error {
+	if a.Name == "" {
+		return fmt.Errorf("attachments must have a name")
+	}
+	ct := a.ContentType
+	if ct == "" {
+		ct = mime.TypeByExtension(path.Ext(a.Name))
+	}
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	pw, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {ct},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+	})
+	if err != nil {
+		return err
+	}
+
+	// base64 lines must be no longer than 76 characters
+	enc := base64.StdEncoding.EncodeToString(a.Content)
+	for len(enc) > 76 {
+		if _, err := io.WriteString(pw, enc[:76]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err = io.WriteString(pw, enc+"\r\n")
+	return err
+}
@@ -0,0 +1,119 @@
+package mail
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestMessageBytes_TextOnly(t *testing.T) {
+	m := &Message{
+		Date:    testDate,
+		From:    "Alerts <alerts@example.com>",
+		To:      []string{"ops@example.com", "Zoë <zoe@example.com>"},
+		Subject: "Daily digest ✓",
+		Text:    "hello\nworld",
+		Headers: map[string]string{"X-Priority": "1"},
+	}
+	b, err := m.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, "Date: Wed, 01 Jun 2022 12:00:00 +0000\r\n"+
+		"From: \"Alerts\" <alerts@example.com>\r\n"+
+		"To: <ops@example.com>, =?utf-8?q?Zo=C3=AB?= <zoe@example.com>\r\n"+
+		"Subject: =?utf-8?q?Daily_digest_=E2=9C=93?=\r\n"+
+		"Mime-Version: 1.0\r\n"+
+		"Content-Transfer-Encoding: quoted-printable\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"X-Priority: 1\r\n"+
+		"\r\n"+
+		"hello\r\nworld", string(b))
+}
+
+func TestMessageBytes_Multipart(t *testing.T) {
+	m := &Message{
+		Date:    testDate,
+		From:    "alerts@example.com",
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Report",
+		Text:    "see attached",
+		HTML:    "<p>see attached</p>",
+		Attachments: []Attachment{
+			{Name: "report.csv", Content: []byte("a,b\r\n1,2\r\n")},
+			{Name: "data", Content: []byte(strings.Repeat("x", 100))},
+		},
+	}
+	b, err := m.Bytes()
+	require.NoError(t, err)
+
+	// the output is reproducible
+	b2, err := m.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, b, b2)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+	require.NoError(t, err)
+	assert.Equal(t, "<audit@example.com>", msg.Header.Get("Bcc"))
+
+	mt, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mt)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	p, err := mr.NextPart()
+	require.NoError(t, err)
+	mt, params, err = mime.ParseMediaType(p.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mt)
+
+	alt := multipart.NewReader(p, params["boundary"])
+	for _, expected := range []struct{ ct, body string }{
+		{"text/plain; charset=utf-8", "see attached"},
+		{"text/html; charset=utf-8", "<p>see attached</p>"},
+	} {
+		ap, err := alt.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, expected.ct, ap.Header.Get("Content-Type"))
+		// multipart.Reader decodes quoted-printable transparently
+		body, _ := io.ReadAll(ap)
+		assert.Equal(t, expected.body, string(body))
+	}
+
+	p, err = mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "report.csv", p.FileName())
+	assert.Equal(t, "text/csv; charset=utf-8", p.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(p)
+	assert.Equal(t, "YSxiDQoxLDINCg==\r\n", string(body))
+
+	p, err = mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream", p.Header.Get("Content-Type"))
+	body, _ = io.ReadAll(p)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\r\n")
+	assert.Len(t, lines, 2)
+	assert.Len(t, lines[0], 76)
+}
+
+func TestMessageBytes_Errors(t *testing.T) {
+	testdata := []*Message{
+		{To: []string{"a@example.com"}},
+		{From: "a@example.com"},
+		{From: "not an address", To: []string{"a@example.com"}},
+		{From: "a@example.com", To: []string{"@"}},
+		{From: "a@example.com", To: []string{"b@example.com"}, Headers: map[string]string{"X-Foo": "a\r\nBcc: evil@example.com"}},
+		{From: "a@example.com", To: []string{"b@example.com"}, Attachments: []Attachment{{Content: []byte("x")}}},
+	}
+	for _, m := range testdata {
+		_, err := m.Bytes()
+		assert.Error(t, err, "%+v", m)
+	}
+}
@@ -0,0 +1,204 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/env"
+)
+
+// IsSMTPURL returns true when the given string is an smtp:// or smtps:// URL
+func IsSMTPURL(s string) bool {
+	return strings.HasPrefix(s, "smtp://") || strings.HasPrefix(s, "smtps://")
+}
+
+// Send delivers the message (in RFC 5322 format) with the SMTP server at the
+// given URL, which has the form smtp[s]://[user[:password]@]host[:port].
+//
+// With the smtp scheme, STARTTLS is used when the server supports it, and
+// with smtps the connection uses TLS from the start. When a user is given but
+// no password, the password is read from the SMTP_PASSWORD environment
+// variable (or the file named by SMTP_PASSWORD_FILE).
+//
+// The envelope sender and recipients are taken from the message's From, To,
+// Cc, and Bcc headers, and can be overridden with the "from" and "to" query
+// parameters. The Bcc header is removed before delivery.
+func Send(u *url.URL, msg []byte) error {
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	from, rcpts, err := envelope(u, m.Header)
+	if err != nil {
+		return err
+	}
+
+	c, err := dial(u)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if u.User != nil {
+		pass, ok := u.User.Password()
+		if !ok {
+			pass = env.Getenv("SMTP_PASSWORD")
+		}
+		if err = c.Auth(smtp.PlainAuth("", u.User.Username(), pass, u.Hostname())); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err = c.Mail(from); err != nil {
+		return fmt.Errorf("SMTP MAIL command failed: %w", err)
+	}
+	for _, r := range rcpts {
+		if err = c.Rcpt(r); err != nil {
+			return fmt.Errorf("SMTP RCPT command failed for %s: %w", r, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA command failed: %w", err)
+	}
+	if _, err = w.Write(stripBcc(msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return c.Quit()
+}
+
+func dial(u *url.URL) (*smtp.Client, error) {
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "25"
+		if u.Scheme == "smtps" {
+			port = "465"
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+
+	if u.Scheme == "smtps" {
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+		}
+		return smtp.NewClient(conn, host)
+	}
+
+	c, err := smtp.Dial(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// envelope determines the envelope sender and recipients
+func envelope(u *url.URL, hdr mail.Header) (from string, rcpts []string, err error) {
+	q := u.Query()
+	from = q.Get("from")
+	if from == "" {
+		a, err := mail.ParseAddress(hdr.Get("From"))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid From address %q: %w", hdr.Get("From"), err)
+		}
+		from = a.Address
+	}
+
+	if to := q["to"]; len(to) > 0 {
+		return from, to, nil
+	}
+	for _, k := range []string{"To", "Cc", "Bcc"} {
+		if hdr.Get(k) == "" {
+			continue
+		}
+		l, err := hdr.AddressList(k)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid %s addresses: %w", k, err)
+		}
+		for _, a := range l {
+			rcpts = append(rcpts, a.Address)
+		}
+	}
+	if len(rcpts) == 0 {
+		return "", nil, fmt.Errorf("message has no recipients")
+	}
+	return from, rcpts, nil
+}
+
+// stripBcc removes the Bcc header (and any continuation lines) from the
+// message's header section
+func stripBcc(msg []byte) []byte {
+	out := &bytes.Buffer{}
+	r := bufio.NewReader(bytes.NewReader(msg))
+	skipping := false
+	for {
+		line, err := r.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") == "" {
+			// end of headers - copy the rest verbatim
+			out.WriteString(line)
+			_, _ = io.Copy(out, r)
+			return out.Bytes()
+		}
+		if skipping && (line[0] == ' ' || line[0] == '\t') {
+			continue
+		}
+		skipping = len(line) > 4 && strings.EqualFold(line[:4], "bcc:")
+		if !skipping {
+			out.WriteString(line)
+		}
+		if err != nil {
+			return out.Bytes()
+		}
+	}
+}
+
+// writer buffers a message, and sends it when closed
+type writer struct {
+	u    *url.URL
+	buf  bytes.Buffer
+	sent bool
+}
+
+// NewWriter returns a writer that sends the written message with Send when
+// it is closed. The message is only sent once, even if Close is called again.
+func NewWriter(u *url.URL) io.WriteCloser {
+	return &writer{u: u}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *writer) Close() error {
+	if w.sent {
+		return nil
+	}
+	w.sent = true
+	return Send(w.u, w.buf.Bytes())
+}
+
+// Discard - drops the message without sending it
+func (w *writer) Discard() error {
+	w.sent = true
+	w.buf.Reset()
+	return nil
+}
@@ -0,0 +1,147 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single connection, and records the commands and
+// message data it receives
+func fakeSMTPServer(t *testing.T) (addr string, received chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	received = make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		cmds := []string{}
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmds = append(cmds, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(line, "AUTH"):
+				reply("235 ok")
+			case line == "DATA":
+				reply("354 go ahead")
+				data := []string{}
+				for {
+					l, _ := r.ReadString('\n')
+					l = strings.TrimRight(l, "\r\n")
+					if l == "." {
+						break
+					}
+					data = append(data, l)
+				}
+				cmds = append(cmds, strings.Join(data, "\n"))
+				reply("250 queued")
+			case line == "QUIT":
+				reply("221 bye")
+				received <- cmds
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+		received <- cmds
+	}()
+	return l.Addr().String(), received
+}
+
+func TestSend(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	u, _ := url.Parse("smtp://user:secret@" + addr)
+
+	m := &Message{
+		Date:    testDate,
+		From:    "Alerts <alerts@example.com>",
+		To:      []string{"ops@example.com"},
+		Cc:      []string{"dev@example.com"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "hi",
+		Text:    "hello",
+	}
+	b, err := m.Bytes()
+	require.NoError(t, err)
+
+	w := NewWriter(u)
+	_, err = w.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	// a second close does nothing
+	require.NoError(t, w.Close())
+
+	cmds := <-received
+	assert.Equal(t, "AUTH PLAIN AHVzZXIAc2VjcmV0", cmds[1])
+	assert.Equal(t, []string{
+		"MAIL FROM:<alerts@example.com>",
+		"RCPT TO:<ops@example.com>",
+		"RCPT TO:<dev@example.com>",
+		"RCPT TO:<audit@example.com>",
+		"DATA",
+	}, cmds[2:7])
+	assert.NotContains(t, cmds[7], "Bcc")
+	assert.Contains(t, cmds[7], "Cc: <dev@example.com>")
+	assert.Contains(t, cmds[7], "\n\nhello")
+}
+
+func TestWriterDiscard(t *testing.T) {
+	// nothing is listening here, so sending would fail
+	u, _ := url.Parse("smtp://127.0.0.1:1")
+	w := NewWriter(u)
+	_, err := w.Write([]byte("Subject: hi\n\nhello"))
+	require.NoError(t, err)
+
+	d, ok := w.(interface{ Discard() error })
+	require.True(t, ok)
+	require.NoError(t, d.Discard())
+	assert.NoError(t, w.Close())
+}
+
+func TestEnvelope(t *testing.T) {
+	u, _ := url.Parse("smtp://localhost?from=bounce@example.com&to=a@example.com&to=b@example.com")
+	from, rcpts, err := envelope(u, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "bounce@example.com", from)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, rcpts)
+
+	u, _ = url.Parse("smtp://localhost")
+	_, _, err = envelope(u, map[string][]string{"From": {"a@example.com"}})
+	assert.Error(t, err)
+
+	_, _, err = envelope(u, map[string][]string{"From": {"bogus"}, "To": {"a@example.com"}})
+	assert.Error(t, err)
+}
+
+func TestStripBcc(t *testing.T) {
+	msg := "From: a@example.com\r\nBCC: b@example.com,\r\n c@example.com\r\nTo: d@example.com\r\n\r\nBcc: in the body\r\n"
+	assert.Equal(t, "From: a@example.com\r\nTo: d@example.com\r\n\r\nBcc: in the body\r\n", string(stripBcc([]byte(msg))))
+}
+
+func TestIsSMTPURL(t *testing.T) {
+	assert.True(t, IsSMTPURL("smtp://localhost"))
+	assert.True(t, IsSMTPURL("smtps://mail.example.com:465"))
+	assert.False(t, IsSMTPURL("out.txt"))
+	assert.False(t, IsSMTPURL("-"))
+}
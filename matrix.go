@@ -0,0 +1,160 @@
+package gomplate
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/provenance"
+)
+
+// matrixJob is the set of templates to render for one matrix item
+type matrixJob struct {
+	tctx      interface{}
+	templates []Template
+}
+
+// renderMatrix renders every template once for each item in the matrix
+// datasource, with the item available as '.Item'. The output paths are all
+// rendered first, so that conflicting paths are caught before anything is
+// written, and then the items are rendered concurrently.
+func (t *Renderer) renderMatrix(ctx context.Context, cfg *config.Config, templates []inputTemplate) error {
+	m := cfg.Matrix
+
+	// previews are masked with the secret values recorded by the tracker
+	if t.preview {
+		ctx = provenance.ContextWithTracker(ctx, provenance.New())
+	}
+
+	// set once here, since the items are rendered concurrently
+	t.data.Ctx = ctx
+
+	d, err := t.data.Datasource(m.Datasource)
+	if err != nil {
+		return fmt.Errorf("failed to read matrix datasource: %w", err)
+	}
+	items, ok := d.([]interface{})
+	if !ok {
+		return fmt.Errorf("matrix datasource %q must be a list, got %T", m.Datasource, d)
+	}
+
+	_, modeOverride, err := cfg.GetMode()
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]matrixJob, len(items))
+	outputs := map[string]string{}
+	for i, item := range items {
+		tctx, err := t.matrixContext(ctx, item)
+		if err != nil {
+			return fmt.Errorf("matrix item %d: %w", i, err)
+		}
+		jobs[i].tctx = tctx
+
+		for _, mt := range templates {
+			outPath, err := t.renderOutputPath(ctx, "<MatrixOutput>", m.OutputPath, tctx, mt.in)
+			if err != nil {
+				return fmt.Errorf("matrix item %d: %w", i, err)
+			}
+
+			desc := fmt.Sprintf("item %d (%s)", i, mt.name)
+			if prev, ok := outputs[outPath]; ok && outPath != "-" {
+				return fmt.Errorf("matrix output path %s is the same for %s and %s", outPath, prev, desc)
+			}
+			outputs[outPath] = desc
+
+			// no need to close the output, as it's closed after rendering
+			w, err := openOutput(cfg, outPath, mt.dirMode, mt.mode, modeOverride)
+			if err != nil {
+				return err
+			}
+			// the target's needed for previews, encryption, and checksums
+			jobs[i].templates = append(jobs[i].templates, Template{Name: mt.name, Text: mt.text, Writer: w, target: outPath})
+		}
+	}
+
+	updateMetrics(func(m *MetricsType) { m.TemplatesGathered = len(items) * len(templates) })
+
+	start := time.Now()
+	t.startChecksums()
+	parallelism := m.Parallelism
+	if t.preview {
+		// the previews are all written to stdout, so they mustn't interleave
+		parallelism = 1
+	}
+	err = runJobs(len(jobs), parallelism, func(i int) error {
+		if err := t.renderTemplatesWithData(ctx, jobs[i].templates, jobs[i].tctx); err != nil {
+			return fmt.Errorf("matrix item %d: %w", i, err)
+		}
+		return nil
+	})
+
+	updateMetrics(func(m *MetricsType) { m.TotalRenderDuration = time.Since(start) })
+	if err == nil {
+		err = t.finishChecksums()
+	}
+
+	return err
+}
+
+// matrixContext creates the template context for a matrix item
+func (t *Renderer) matrixContext(ctx context.Context, item interface{}) (interface{}, error) {
+	roots := tmplctx{"Item": item}
+	for k, v := range t.tctxRoots {
+		roots[k] = v
+	}
+	tctx, err := createTmplContext(ctx, t.tctxAliases, roots, t.data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tctx.(*tmplctx); !ok {
+		return nil, fmt.Errorf("the matrix item can't be added to a '.' context")
+	}
+	return tctx, nil
+}
+
+// runJobs calls fn for each index in [0, n), with up to parallelism calls
+// running at once (or the number of CPUs, if parallelism is 0). After the
+// first error no more calls are started, and that error is returned.
+func runJobs(n, parallelism int, fn func(int) error) error {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, parallelism)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,183 @@
+package gomplate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMatrix(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = aferoFS.MkdirAll("/in/sub", 0755)
+	_ = afero.WriteFile(aferoFS, "/in/a.txt", []byte(`{{ .Item.name }} in {{ .Item.region }}`), 0644)
+	_ = afero.WriteFile(aferoFS, "/in/sub/b.txt", []byte(`{{ .Values.x }}-{{ .Item.name }}`), 0644)
+
+	items := `[{"name": "acme", "region": "us"}, {"name": "globex", "region": "eu"}, {"name": "initech", "region": "ap"}]`
+	u, _ := url.Parse("stdin:///items.json")
+	ctx := data.ContextWithStdin(context.Background(), strings.NewReader(items))
+
+	cfg := &config.Config{
+		InputDir: "/in",
+		Matrix: &config.MatrixConfig{
+			Datasource:  "items",
+			OutputPath:  "/out/{{ .Item.name }}/{{ .in }}",
+			Parallelism: 2,
+		},
+	}
+	tmpl, err := readInputTemplates(cfg)
+	require.NoError(t, err)
+	require.Len(t, tmpl, 2)
+
+	tr := NewRenderer(Options{
+		Datasources: map[string]Datasource{"items": {URL: u}},
+		Values:      map[string]interface{}{"x": 42},
+	})
+	require.NoError(t, tr.renderMatrix(ctx, cfg, tmpl))
+
+	for _, d := range []struct{ name, region string }{{"acme", "us"}, {"globex", "eu"}, {"initech", "ap"}} {
+		b, err := afero.ReadFile(aferoFS, "/out/"+d.name+"/a.txt")
+		require.NoError(t, err)
+		assert.Equal(t, d.name+" in "+d.region, string(b))
+
+		b, err = afero.ReadFile(aferoFS, "/out/"+d.name+"/sub/b.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "42-"+d.name, string(b))
+	}
+
+	// output paths must be unique
+	cfg.Matrix.OutputPath = "/out/{{ .Item.region }}.txt"
+	err = tr.renderMatrix(ctx, cfg, tmpl)
+	assert.ErrorContains(t, err, "matrix output path /out/us.txt is the same for item 0 (/in/a.txt) and item 0 (/in/sub/b.txt)")
+
+	// the datasource must be a list
+	tr = NewRenderer(Options{
+		Datasources: map[string]Datasource{"items": {URL: u}},
+	})
+	ctx = data.ContextWithStdin(context.Background(), strings.NewReader(`{"name": "acme"}`))
+	err = tr.renderMatrix(ctx, cfg, tmpl)
+	assert.ErrorContains(t, err, `matrix datasource "items" must be a list, got map[string]interface {}`)
+}
+
+func TestRenderMatrixChecksums(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = aferoFS.MkdirAll("/in", 0755)
+	_ = afero.WriteFile(aferoFS, "/in/a.txt", []byte(`{{ .Item }}`), 0644)
+
+	u, _ := url.Parse("stdin:///items.json")
+	ctx := data.ContextWithStdin(context.Background(), strings.NewReader(`["acme", "globex"]`))
+
+	cfg := &config.Config{
+		InputDir: "/in",
+		Matrix: &config.MatrixConfig{
+			Datasource:  "items",
+			OutputPath:  "/out/{{ .Item }}/{{ .in }}",
+			Parallelism: 2,
+		},
+	}
+	tmpl, err := readInputTemplates(cfg)
+	require.NoError(t, err)
+
+	sumsFile := filepath.Join(t.TempDir(), "SHA256SUMS")
+	tr := NewRenderer(Options{
+		Datasources: map[string]Datasource{"items": {URL: u}},
+		Checksums:   sumsFile,
+	})
+	require.NoError(t, tr.renderMatrix(ctx, cfg, tmpl))
+
+	b, err := os.ReadFile(sumsFile)
+	require.NoError(t, err)
+	assert.Equal(t, sha256hex("acme")+"  /out/acme/a.txt\n"+sha256hex("globex")+"  /out/globex/a.txt\n", string(b))
+}
+
+func TestRenderMatrixStreams(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = aferoFS.MkdirAll("/in", 0755)
+	_ = afero.WriteFile(aferoFS, "/in/count.txt",
+		[]byte(`{{ $n := 0 }}{{ range stream "big" }}{{ $n = add $n 1 }}{{ end }}{{ $n }}`), 0644)
+
+	const lines = 5000
+	big := filepath.Join(t.TempDir(), "big.ndjson")
+	require.NoError(t, os.WriteFile(big, []byte(strings.Repeat(`{"a": 1}`+"\n", lines)), 0o600))
+	bu, _ := url.Parse("file://" + filepath.ToSlash(big))
+
+	items := make([]string, 8)
+	for i := range items {
+		items[i] = fmt.Sprintf(`"item%d"`, i)
+	}
+	u, _ := url.Parse("stdin:///items.json")
+	ctx := data.ContextWithStdin(context.Background(), strings.NewReader("["+strings.Join(items, ",")+"]"))
+
+	cfg := &config.Config{
+		InputDir: "/in",
+		Matrix: &config.MatrixConfig{
+			Datasource:  "items",
+			OutputPath:  "/out/{{ .Item }}/{{ .in }}",
+			Parallelism: len(items),
+		},
+	}
+	tmpl, err := readInputTemplates(cfg)
+	require.NoError(t, err)
+
+	// items rendered at the same time must not close each other's streams
+	tr := NewRenderer(Options{
+		Datasources: map[string]Datasource{"items": {URL: u}, "big": {URL: bu}},
+	})
+	require.NoError(t, tr.renderMatrix(ctx, cfg, tmpl))
+
+	for i := range items {
+		b, err := afero.ReadFile(aferoFS, fmt.Sprintf("/out/item%d/count.txt", i))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(lines), string(b), "item%d", i)
+	}
+}
+
+func TestRunJobs(t *testing.T) {
+	var running, maxRunning, calls int32
+	err := runJobs(20, 3, func(int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&calls, 1)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(20), calls)
+	assert.LessOrEqual(t, maxRunning, int32(3))
+
+	// no more jobs are started after an error
+	calls = 0
+	err = runJobs(20, 1, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i == 2 {
+			return fmt.Errorf("job %d failed", i)
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "job 2 failed")
+	assert.Less(t, calls, int32(20))
+}
@@ -1,11 +1,24 @@
 package gomplate
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // Metrics tracks interesting basic metrics around gomplate executions. Warning: experimental!
 // This may change in breaking ways without warning. This is not subject to any semantic versioning guarantees!
 var Metrics *MetricsType
 
+// metricsMu guards Metrics while templates are rendered concurrently
+var metricsMu sync.Mutex
+
+// updateMetrics calls fn with Metrics, while holding metricsMu
+func updateMetrics(fn func(m *MetricsType)) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	fn(Metrics)
+}
+
 // MetricsType - Warning: experimental! This may change in breaking ways without warning.
 // This is not subject to any semantic versioning guarantees!
 type MetricsType struct {
@@ -18,7 +31,15 @@ type MetricsType struct {
 
 	TemplatesGathered  int
 	TemplatesProcessed int
+	TemplatesSkipped   int
 	Errors             int
+
+	// templates that weren't rendered because they're unchanged since the
+	// last run (see --render-cache)
+	TemplatesCached int
+
+	// output files which were written to (unchanged files are skipped)
+	ChangedFiles []string
 }
 
 func newMetrics() *MetricsType {
@@ -0,0 +1,122 @@
+// Package money contains functions for formatting and converting amounts of
+// money, with exact (rational) arithmetic, rounded to each currency's minor
+// units (like cents).
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// ParseCurrency parses an ISO 4217 currency code, like "EUR"
+func ParseCurrency(code string) (currency.Unit, error) {
+	cur, err := currency.ParseISO(strings.TrimSpace(code))
+	if err != nil {
+		return cur, fmt.Errorf("unknown currency %q", code)
+	}
+	return cur, nil
+}
+
+// MinorUnits returns the number of decimal places of the currency's minor
+// units - 2 for "USD" (cents), 0 for "JPY", and 3 for "KWD"
+func MinorUnits(cur currency.Unit) int {
+	scale, _ := currency.Standard.Rounding(cur)
+	return scale
+}
+
+// Round rounds the amount to the currency's minor units, with halves rounded
+// away from zero
+func Round(amount *big.Rat, cur currency.Unit) *big.Rat {
+	return roundScale(amount, MinorUnits(cur))
+}
+
+func roundScale(amount *big.Rat, scale int) *big.Rat {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	scaled := new(big.Rat).Mul(amount, new(big.Rat).SetInt(factor))
+
+	half := big.NewRat(1, 2)
+	if scaled.Sign() < 0 {
+		half.Neg(half)
+	}
+	scaled.Add(scaled, half)
+	n := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+	return new(big.Rat).SetFrac(n, factor)
+}
+
+// ToMinor converts the amount to a whole number of the currency's minor
+// units, like cents
+func ToMinor(amount *big.Rat, cur currency.Unit) *big.Int {
+	scale := MinorUnits(cur)
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	r := new(big.Rat).Mul(Round(amount, cur), new(big.Rat).SetInt(factor))
+	return r.Num()
+}
+
+// FromMinor converts a whole number of the currency's minor units (like
+// cents) to an amount
+func FromMinor(minor *big.Int, cur currency.Unit) *big.Rat {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(MinorUnits(cur))), nil)
+	return new(big.Rat).SetFrac(minor, factor)
+}
+
+// languages that write the currency symbol after the amount, like "12,50 €"
+var symbolAfter = map[string]bool{
+	"bg": true, "ca": true, "cs": true, "da": true, "de": true, "el": true,
+	"es": true, "et": true, "fi": true, "fr": true, "hr": true, "hu": true,
+	"is": true, "it": true, "lt": true, "lv": true, "nb": true, "nn": true,
+	"no": true, "pl": true, "pt": true, "ro": true, "ru": true, "sk": true,
+	"sl": true, "sr": true, "sv": true, "uk": true,
+}
+
+// regions where the symbol comes first (separated by a space) even though
+// the language usually puts it after, like "CHF 12.50" or "R$ 12,50"
+var symbolFirstRegions = map[string]bool{"AT": true, "BR": true, "CH": true, "LI": true}
+
+// Format formats the amount in the currency, rounded to its minor units,
+// following the locale's conventions (like "$1,234.50" for "en-US", or
+// "1.234,50 €" for "de-DE").
+func Format(amount *big.Rat, cur currency.Unit, locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	p := message.NewPrinter(tag)
+
+	scale := MinorUnits(cur)
+	rounded := Round(amount, cur)
+	f, _ := new(big.Rat).Abs(rounded).Float64()
+	num := p.Sprint(number.Decimal(f, number.Scale(scale)))
+	sym := p.Sprint(currency.Symbol(cur))
+
+	sign := ""
+	if rounded.Sign() < 0 {
+		sign = "-"
+	}
+
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	// the region is inferred when the locale doesn't have one, so "pt" is
+	// Brazilian Portuguese
+	after := symbolAfter[base.String()] && !symbolFirstRegions[region.String()]
+
+	// the symbol is separated by a non-breaking space, like in CLDR's patterns
+	switch {
+	case after:
+		return sign + num + "\u00a0" + sym, nil
+	case symbolAfter[base.String()] || base.String() == "nl" || endsWithLetter(sym):
+		return sign + sym + "\u00a0" + num, nil
+	}
+	return sign + sym + num, nil
+}
+
+func endsWithLetter(s string) bool {
+	r := []rune(s)
+	return len(r) > 0 && unicode.IsLetter(r[len(r)-1])
+}
@@ -0,0 +1,81 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/currency"
+)
+
+func r(s string) *big.Rat {
+	v, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic(s)
+	}
+	return v
+}
+
+func TestParseCurrency(t *testing.T) {
+	cur, err := ParseCurrency("eur")
+	require.NoError(t, err)
+	assert.Equal(t, currency.EUR, cur)
+
+	_, err = ParseCurrency("XYZ")
+	assert.Error(t, err)
+}
+
+func TestRound(t *testing.T) {
+	testdata := []struct {
+		in, code, expected string
+	}{
+		{"2.675", "USD", "2.68"},
+		{"2.674", "USD", "2.67"},
+		{"-2.675", "USD", "-2.68"},
+		{"1234.5", "JPY", "1235"},
+		{"1.2345", "KWD", "1.235"},
+		{"7", "EUR", "7"},
+	}
+	for _, d := range testdata {
+		assert.Equal(t, r(d.expected).String(), Round(r(d.in), currency.MustParseISO(d.code)).String(), d)
+	}
+}
+
+func TestMinor(t *testing.T) {
+	assert.Equal(t, int64(1999), ToMinor(r("19.99"), currency.USD).Int64())
+	assert.Equal(t, int64(2000), ToMinor(r("19.995"), currency.USD).Int64())
+	assert.Equal(t, int64(1999), ToMinor(r("1999"), currency.JPY).Int64())
+	assert.Equal(t, int64(1500), ToMinor(r("1.5"), currency.MustParseISO("KWD")).Int64())
+
+	assert.Equal(t, r("19.99").String(), FromMinor(big.NewInt(1999), currency.USD).String())
+	assert.Equal(t, r("1999").String(), FromMinor(big.NewInt(1999), currency.JPY).String())
+}
+
+func TestFormat(t *testing.T) {
+	testdata := []struct {
+		amount, code, locale, expected string
+	}{
+		{"1234.5", "USD", "en-US", "$1,234.50"},
+		{"1234.5", "EUR", "de-DE", "1.234,50\u00a0€"},
+		{"1234.5", "EUR", "de-AT", "€\u00a01\u00a0234,50"},
+		{"1234.5", "EUR", "fr", "1\u00a0234,50\u00a0€"},
+		{"1234.5", "CHF", "de-CH", "CHF\u00a01’234.50"},
+		{"1234.5", "JPY", "ja-JP", "￥1,235"},
+		{"1234.5", "BRL", "pt", "R$\u00a01.234,50"},
+		{"1234.5", "EUR", "pt-PT", "1\u00a0234,50\u00a0€"},
+		{"1234.5", "EUR", "nl-NL", "€\u00a01.234,50"},
+		{"1234.5", "GBP", "en-GB", "£1,234.50"},
+		{"1234.5", "KWD", "en", "KWD\u00a01,234.500"},
+		{"-0.005", "USD", "en-US", "-$0.01"},
+		{"-12.5", "EUR", "de-DE", "-12,50\u00a0€"},
+	}
+	for _, d := range testdata {
+		out, err := Format(r(d.amount), currency.MustParseISO(d.code), d.locale)
+		require.NoError(t, err, d)
+		assert.Equal(t, d.expected, out, d)
+	}
+
+	_, err := Format(r("1"), currency.USD, "not a locale!")
+	assert.Error(t, err)
+}
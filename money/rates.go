@@ -0,0 +1,92 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// Rates - exchange rates, as the value of one unit of a common base currency
+// in each currency. The base currency itself has the rate 1.
+type Rates map[string]*big.Rat
+
+// ParseRates reads exchange rates from parsed datasource data, which is
+// either a map of currency codes to rates (like {"EUR": 0.92, "GBP": 0.79}),
+// or a map with "base" and "rates" keys, like most exchange rate APIs return
+// (like {"base": "USD", "rates": {"EUR": 0.92}}).
+func ParseRates(data interface{}) (Rates, error) {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("exchange rates must be a map, got %T", data)
+	}
+
+	base := ""
+	if nested, ok := m["rates"]; ok {
+		if b, ok := m["base"]; ok {
+			base = fmt.Sprint(b)
+		} else if b, ok := m["base_code"]; ok {
+			base = fmt.Sprint(b)
+		}
+		m, ok = nested.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("exchange rates must be a map, got %T", nested)
+		}
+	}
+
+	rates := make(Rates, len(m)+1)
+	for k, v := range m {
+		r, err := rat(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate for %s: %w", k, err)
+		}
+		if r.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate for %s: must be positive, got %s", k, r.FloatString(6))
+		}
+		rates[strings.ToUpper(k)] = r
+	}
+	if base != "" {
+		rates[strings.ToUpper(base)] = big.NewRat(1, 1)
+	}
+	return rates, nil
+}
+
+func rat(v interface{}) (*big.Rat, error) {
+	var s string
+	switch v := v.(type) {
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case json.Number:
+		s = v.String()
+	default:
+		s = strings.TrimSpace(fmt.Sprint(v))
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("not a number: %q", s)
+	}
+	return r, nil
+}
+
+// Convert converts the amount from one currency to another, rounded to the
+// minor units of the target currency
+func (r Rates) Convert(amount *big.Rat, from, to currency.Unit) (*big.Rat, error) {
+	if from == to {
+		return Round(amount, to), nil
+	}
+	fromRate, ok := r[from.String()]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := r[to.String()]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", to)
+	}
+
+	out := new(big.Rat).Mul(amount, toRate)
+	out.Quo(out, fromRate)
+	return Round(out, to), nil
+}
@@ -0,0 +1,67 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/currency"
+)
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(map[string]interface{}{
+		"base":  "USD",
+		"rates": map[string]interface{}{"eur": 0.92, "GBP": "0.79"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Rates{"USD": r("1"), "EUR": r("0.92"), "GBP": r("0.79")}, rates)
+
+	rates, err = ParseRates(map[string]interface{}{
+		"base_code": "EUR",
+		"rates":     map[string]interface{}{"USD": 1.09},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Rates{"EUR": r("1"), "USD": r("1.09")}, rates)
+
+	rates, err = ParseRates(map[string]interface{}{"EUR": 1, "USD": 1.09})
+	require.NoError(t, err)
+	assert.Equal(t, Rates{"EUR": r("1"), "USD": r("1.09")}, rates)
+
+	_, err = ParseRates([]interface{}{1})
+	assert.Error(t, err)
+
+	_, err = ParseRates(map[string]interface{}{"rates": "lots"})
+	assert.Error(t, err)
+
+	_, err = ParseRates(map[string]interface{}{"EUR": "lots"})
+	assert.Error(t, err)
+
+	_, err = ParseRates(map[string]interface{}{"EUR": 0})
+	assert.Error(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	rates := Rates{"USD": r("1"), "EUR": r("0.92"), "GBP": r("0.79"), "JPY": r("151.2")}
+
+	out, err := rates.Convert(r("100"), currency.USD, currency.EUR)
+	require.NoError(t, err)
+	assert.Equal(t, r("92").String(), out.String())
+
+	out, err = rates.Convert(r("100"), currency.EUR, currency.GBP)
+	require.NoError(t, err)
+	assert.Equal(t, r("85.87").String(), out.String())
+
+	out, err = rates.Convert(r("100"), currency.EUR, currency.JPY)
+	require.NoError(t, err)
+	assert.Equal(t, r("16435").String(), out.String())
+
+	out, err = rates.Convert(r("1.005"), currency.CHF, currency.CHF)
+	require.NoError(t, err)
+	assert.Equal(t, r("1.01").String(), out.String())
+
+	_, err = rates.Convert(r("1"), currency.USD, currency.CHF)
+	assert.Error(t, err)
+
+	_, err = rates.Convert(r("1"), currency.CHF, currency.USD)
+	assert.Error(t, err)
+}
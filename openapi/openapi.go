@@ -0,0 +1,229 @@
+// Package openapi contains functions for working with OpenAPI (and Swagger)
+// documents
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// methods are the HTTP methods that can appear in a path item, in the order
+// the OpenAPI specification lists them
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Resolve - return a copy of the document with all local references (like
+// `$ref: '#/components/schemas/Pet'`) replaced by the referenced values.
+//
+// References to other documents are left in place, as are recursive
+// references (which would otherwise expand infinitely).
+func Resolve(doc map[string]interface{}) (map[string]interface{}, error) {
+	r := &resolver{root: doc}
+	out, err := r.resolve(doc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]interface{}), nil
+}
+
+type resolver struct {
+	root map[string]interface{}
+}
+
+// resolve recursively copies v, replacing local references. The stack holds
+// the references currently being expanded, so cycles can be detected.
+func (r *resolver) resolve(v interface{}, stack []string) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := t["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			for _, s := range stack {
+				if s == ref {
+					return copyValue(t), nil
+				}
+			}
+			target, err := Pointer(r.root, ref[1:])
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve $ref %q: %w", ref, err)
+			}
+			return r.resolve(target, append(stack, ref))
+		}
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			c, err := r.resolve(e, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			c, err := r.resolve(e, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func copyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[k] = copyValue(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = copyValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Pointer - look up a value in the document with a JSON Pointer (RFC 6901),
+// such as `/components/schemas/Pet`. The pointer may be URL-encoded, as it
+// is in references.
+func Pointer(doc interface{}, ptr string) (interface{}, error) {
+	p, err := url.PathUnescape(ptr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pointer %q: %w", ptr, err)
+	}
+	if p == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid pointer %q: must start with /", ptr)
+	}
+
+	v := doc
+	for _, tok := range strings.Split(p[1:], "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch t := v.(type) {
+		case map[string]interface{}:
+			e, ok := t[tok]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", tok)
+			}
+			v = e
+		case []interface{}:
+			var i int
+			if _, err := fmt.Sscanf(tok, "%d", &i); err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("invalid array index %q", tok)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("can not index %T with %q", v, tok)
+		}
+	}
+	return v, nil
+}
+
+// Operations - list the document's operations, ordered by path and then by
+// method. Each operation is a copy of the operation object, with these extra
+// keys:
+//
+//   - path: the operation's path, like /pets/{id}
+//   - method: the HTTP method, in upper case
+//
+// Parameters defined on the path item are merged into each operation's
+// parameters, unless the operation overrides them. The parameters key is
+// always set, so it can be ranged over without checking for it.
+func Operations(doc map[string]interface{}) ([]interface{}, error) {
+	paths, err := optionalMap(doc, "paths")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ops := []interface{}{}
+	for _, path := range keys {
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("path %s must be a map, got %T", path, paths[path])
+		}
+		shared, _ := item["parameters"].([]interface{})
+		for _, method := range methods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			o := copyValue(op).(map[string]interface{})
+			o["path"] = path
+			o["method"] = strings.ToUpper(method)
+			o["parameters"] = mergeParameters(shared, op["parameters"])
+			ops = append(ops, o)
+		}
+	}
+	return ops, nil
+}
+
+// mergeParameters combines path-level and operation-level parameters. A
+// parameter is identified by its name and location, and operation-level
+// parameters take precedence.
+func mergeParameters(shared []interface{}, own interface{}) []interface{} {
+	ownList, _ := own.([]interface{})
+	key := func(p interface{}) string {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("%v\x00%v", m["name"], m["in"])
+	}
+	seen := map[string]bool{}
+	for _, p := range ownList {
+		seen[key(p)] = true
+	}
+	out := []interface{}{}
+	for _, p := range shared {
+		if k := key(p); k == "" || !seen[k] {
+			out = append(out, copyValue(p))
+		}
+	}
+	for _, p := range ownList {
+		out = append(out, copyValue(p))
+	}
+	return out
+}
+
+// Schemas - return the document's named schemas - components.schemas for
+// OpenAPI 3, or definitions for Swagger 2.0
+func Schemas(doc map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := doc["swagger"]; ok {
+		return optionalMap(doc, "definitions")
+	}
+	components, err := optionalMap(doc, "components")
+	if err != nil {
+		return nil, err
+	}
+	return optionalMap(components, "schemas")
+}
+
+// optionalMap returns a copy of the map at the given key, or an empty map if
+// there is none
+func optionalMap(m map[string]interface{}, key string) (map[string]interface{}, error) {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return map[string]interface{}{}, nil
+	}
+	out, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a map, got %T", key, v)
+	}
+	return copyValue(out).(map[string]interface{}), nil
+}
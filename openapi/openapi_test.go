@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func petstore() map[string]interface{} {
+	pet := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id", "name"},
+		"properties": map[string]interface{}{
+			"id":   map[string]interface{}{"type": "integer"},
+			"name": map[string]interface{}{"type": "string", "example": "Rex"},
+			"friends": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/components/schemas/Pet"},
+			},
+		},
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"paths": map[string]interface{}{
+			"/pets/{petId}": map[string]interface{}{
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "petId", "in": "path", "schema": map[string]interface{}{"type": "integer"}},
+					map[string]interface{}{"name": "verbose", "in": "query"},
+				},
+				"get": map[string]interface{}{
+					"operationId": "showPet",
+					"parameters": []interface{}{
+						map[string]interface{}{"name": "verbose", "in": "query", "description": "overridden"},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{"$ref": "#/components/schemas/Pet"},
+								},
+							},
+						},
+					},
+				},
+				"delete": map[string]interface{}{"operationId": "deletePet"},
+			},
+			"/pets": map[string]interface{}{
+				"post": map[string]interface{}{"operationId": "createPet"},
+			},
+		},
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{"Pet": pet},
+		},
+	}
+}
+
+func TestPointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"a/b": map[string]interface{}{"c~d": []interface{}{"x", "y"}},
+		"e f": 1,
+	}
+
+	v, err := Pointer(doc, "")
+	require.NoError(t, err)
+	assert.Equal(t, doc, v)
+
+	v, err = Pointer(doc, "/a~1b/c~0d/1")
+	require.NoError(t, err)
+	assert.Equal(t, "y", v)
+
+	v, err = Pointer(doc, "/e%20f")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	_, err = Pointer(doc, "/nope")
+	assert.Error(t, err)
+
+	_, err = Pointer(doc, "/a~1b/c~0d/2")
+	assert.Error(t, err)
+
+	_, err = Pointer(doc, "/e f/g")
+	assert.Error(t, err)
+
+	_, err = Pointer(doc, "a")
+	assert.Error(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	doc := petstore()
+	out, err := Resolve(doc)
+	require.NoError(t, err)
+
+	schema, err := Pointer(out, "/paths/~1pets~1{petId}/get/responses/200/content/application~1json/schema")
+	require.NoError(t, err)
+	assert.Equal(t, "object", schema.(map[string]interface{})["type"])
+
+	// recursive references are only expanded once
+	items, err := Pointer(out, "/paths/~1pets~1{petId}/get/responses/200/content/application~1json/schema/properties/friends/items")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/Pet"}, items)
+
+	// the input isn't modified
+	_, err = Pointer(doc, "/paths/~1pets~1{petId}/get/responses/200/content/application~1json/schema/$ref")
+	assert.NoError(t, err)
+
+	// external references are left alone
+	ext := map[string]interface{}{"a": map[string]interface{}{"$ref": "other.yaml#/Foo"}}
+	out, err = Resolve(ext)
+	require.NoError(t, err)
+	assert.Equal(t, ext, out)
+
+	_, err = Resolve(map[string]interface{}{"a": map[string]interface{}{"$ref": "#/nope"}})
+	assert.ErrorContains(t, err, `failed to resolve $ref "#/nope"`)
+}
+
+func TestOperations(t *testing.T) {
+	ops, err := Operations(petstore())
+	require.NoError(t, err)
+	require.Len(t, ops, 3)
+
+	ids := []string{}
+	for _, op := range ops {
+		o := op.(map[string]interface{})
+		ids = append(ids, o["method"].(string)+" "+o["path"].(string)+" "+o["operationId"].(string))
+	}
+	assert.Equal(t, []string{"POST /pets createPet", "GET /pets/{petId} showPet", "DELETE /pets/{petId} deletePet"}, ids)
+
+	get := ops[1].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "petId", "in": "path", "schema": map[string]interface{}{"type": "integer"}},
+		map[string]interface{}{"name": "verbose", "in": "query", "description": "overridden"},
+	}, get["parameters"])
+
+	assert.Equal(t, []interface{}{}, ops[0].(map[string]interface{})["parameters"])
+
+	ops, err = Operations(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+
+	_, err = Operations(map[string]interface{}{"paths": "foo"})
+	assert.Error(t, err)
+}
+
+func TestSchemas(t *testing.T) {
+	s, err := Schemas(petstore())
+	require.NoError(t, err)
+	assert.Contains(t, s, "Pet")
+
+	s, err = Schemas(map[string]interface{}{
+		"swagger":     "2.0",
+		"definitions": map[string]interface{}{"Pet": map[string]interface{}{}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, s, "Pet")
+
+	s, err = Schemas(map[string]interface{}{"openapi": "3.1.0"})
+	require.NoError(t, err)
+	assert.Empty(t, s)
+}
@@ -0,0 +1,520 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Validate - validate a value against a schema, returning a list of problems
+// (or an empty list if the value is valid). Local references in the schema
+// are resolved relative to the schema itself.
+//
+// The OpenAPI 3.0 and 3.1 dialects of JSON Schema are supported, apart from
+// the format keyword, which is not validated.
+func Validate(schema map[string]interface{}, value interface{}) []string {
+	v := &validator{root: schema}
+	v.validate(schema, value, "")
+	return v.problems
+}
+
+// ValidateExamples - validate the examples in an OpenAPI (or Swagger)
+// document against their schemas, returning a list of problems.
+//
+// Examples of named schemas (and their properties), parameters, request
+// bodies, and responses are validated.
+func ValidateExamples(doc map[string]interface{}) ([]string, error) {
+	v := &validator{root: doc}
+	schemas, err := Schemas(doc)
+	if err != nil {
+		return nil, err
+	}
+	schemasPtr := "/components/schemas/"
+	if _, ok := doc["swagger"]; ok {
+		schemasPtr = "/definitions/"
+	}
+	for _, name := range sortedKeys(schemas) {
+		if s, ok := schemas[name].(map[string]interface{}); ok {
+			v.schemaExamples(s, schemasPtr+escapePointer(name))
+		}
+	}
+
+	paths, err := optionalMap(doc, "paths")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range sortedKeys(paths) {
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ptr := "/paths/" + escapePointer(path)
+		v.parameterExamples(item["parameters"], ptr+"/parameters")
+		for _, method := range methods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			v.operationExamples(op, ptr+"/"+method)
+		}
+	}
+	return v.problems, nil
+}
+
+type validator struct {
+	root     map[string]interface{}
+	problems []string
+	// location is the JSON Pointer of the example being checked
+	location string
+}
+
+// addf records a problem with the value at the given JSON Pointer. When an
+// example in a document is being checked, the pointer is relative to the
+// example's location.
+func (v *validator) addf(ptr, format string, args ...interface{}) {
+	ptr = v.location + ptr
+	if ptr == "" {
+		ptr = "(root)"
+	}
+	v.problems = append(v.problems, ptr+": "+fmt.Sprintf(format, args...))
+}
+
+// check validates an example found at the given location in the document
+func (v *validator) check(schema, value interface{}, location string) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	v.location = location
+	v.validate(s, value, "")
+	v.location = ""
+}
+
+func (v *validator) schemaExamples(s map[string]interface{}, ptr string) {
+	if ex, ok := s["example"]; ok {
+		v.check(s, ex, ptr+"/example")
+	}
+	if exs, ok := s["examples"].([]interface{}); ok {
+		for i, ex := range exs {
+			v.check(s, ex, fmt.Sprintf("%s/examples/%d", ptr, i))
+		}
+	}
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(props) {
+			if p, ok := props[name].(map[string]interface{}); ok {
+				v.schemaExamples(p, ptr+"/properties/"+escapePointer(name))
+			}
+		}
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		v.schemaExamples(items, ptr+"/items")
+	}
+}
+
+func (v *validator) operationExamples(op map[string]interface{}, ptr string) {
+	v.parameterExamples(op["parameters"], ptr+"/parameters")
+	if body, ok := v.deref(op["requestBody"]).(map[string]interface{}); ok {
+		v.contentExamples(body["content"], ptr+"/requestBody/content")
+	}
+	responses, _ := op["responses"].(map[string]interface{})
+	for _, code := range sortedKeys(responses) {
+		resp, ok := v.deref(responses[code]).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rptr := ptr + "/responses/" + escapePointer(code)
+		v.contentExamples(resp["content"], rptr+"/content")
+		// Swagger 2.0 responses have a schema, and examples keyed by MIME type
+		if exs, ok := resp["examples"].(map[string]interface{}); ok {
+			for _, mt := range sortedKeys(exs) {
+				v.check(v.deref(resp["schema"]), exs[mt], rptr+"/examples/"+escapePointer(mt))
+			}
+		}
+	}
+}
+
+func (v *validator) parameterExamples(params interface{}, ptr string) {
+	l, _ := params.([]interface{})
+	for i, p := range l {
+		pm, ok := v.deref(p).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		v.mediaTypeExamples(pm, fmt.Sprintf("%s/%d", ptr, i))
+		v.contentExamples(pm["content"], fmt.Sprintf("%s/%d/content", ptr, i))
+	}
+}
+
+func (v *validator) contentExamples(content interface{}, ptr string) {
+	c, _ := content.(map[string]interface{})
+	for _, mt := range sortedKeys(c) {
+		if m, ok := c[mt].(map[string]interface{}); ok {
+			v.mediaTypeExamples(m, ptr+"/"+escapePointer(mt))
+		}
+	}
+}
+
+// mediaTypeExamples checks the example and examples of a media type or
+// parameter object against its schema
+func (v *validator) mediaTypeExamples(m map[string]interface{}, ptr string) {
+	schema := v.deref(m["schema"])
+	if ex, ok := m["example"]; ok {
+		v.check(schema, ex, ptr+"/example")
+	}
+	exs, _ := m["examples"].(map[string]interface{})
+	for _, name := range sortedKeys(exs) {
+		ex, ok := v.deref(exs[name]).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if val, ok := ex["value"]; ok {
+			v.check(schema, val, ptr+"/examples/"+escapePointer(name)+"/value")
+		}
+	}
+}
+
+// deref follows a local reference, returning the value unchanged if it isn't
+// one
+func (v *validator) deref(val interface{}) interface{} {
+	for i := 0; i < 100; i++ {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return val
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return val
+		}
+		target, err := Pointer(v.root, ref[1:])
+		if err != nil {
+			return val
+		}
+		val = target
+	}
+	return val
+}
+
+func (v *validator) validate(s map[string]interface{}, value interface{}, ptr string) {
+	if ref, ok := s["$ref"].(string); ok {
+		if !strings.HasPrefix(ref, "#") {
+			v.addf(ptr, "can not validate against non-local $ref %q", ref)
+			return
+		}
+		target, err := Pointer(v.root, ref[1:])
+		if err != nil {
+			v.addf(ptr, "failed to resolve $ref %q: %v", ref, err)
+			return
+		}
+		ts, ok := target.(map[string]interface{})
+		if !ok {
+			v.addf(ptr, "$ref %q does not refer to a schema", ref)
+			return
+		}
+		v.validate(ts, value, ptr)
+		return
+	}
+
+	if !v.validateType(s, value, ptr) {
+		return
+	}
+	if value == nil {
+		return
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addf(ptr, "value %v is not one of the allowed values %v", value, enum)
+		}
+	}
+	if c, ok := s["const"]; ok && !equal(c, value) {
+		v.addf(ptr, "value %v does not equal %v", value, c)
+	}
+
+	switch t := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, t, ptr)
+	case []interface{}:
+		v.validateArray(s, t, ptr)
+	case string:
+		v.validateString(s, t, ptr)
+	default:
+		if f, ok := toFloat(value); ok {
+			v.validateNumber(s, f, ptr)
+		}
+	}
+
+	v.validateComposition(s, value, ptr)
+}
+
+// validateType checks the type (and nullable) keywords, returning false if
+// the value doesn't match, in which case no further checks are useful
+func (v *validator) validateType(s map[string]interface{}, value interface{}, ptr string) bool {
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, e := range t {
+			types = append(types, fmt.Sprint(e))
+		}
+	}
+	if nullable, _ := s["nullable"].(bool); nullable && len(types) > 0 {
+		types = append(types, "null")
+	}
+	if len(types) == 0 {
+		return true
+	}
+
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	v.addf(ptr, "expected %s, got %s", strings.Join(types, " or "), actual)
+	return false
+}
+
+func (v *validator) validateObject(s map[string]interface{}, obj map[string]interface{}, ptr string) {
+	if req, ok := s["required"].([]interface{}); ok {
+		for _, r := range req {
+			name := fmt.Sprint(r)
+			if _, ok := obj[name]; !ok {
+				v.addf(ptr, "missing required property %q", name)
+			}
+		}
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	for _, k := range sortedKeys(obj) {
+		cptr := ptr + "/" + escapePointer(k)
+		if ps, ok := props[k].(map[string]interface{}); ok {
+			v.validate(ps, obj[k], cptr)
+			continue
+		}
+		switch ap := s["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				v.addf(ptr, "unexpected property %q", k)
+			}
+		case map[string]interface{}:
+			v.validate(ap, obj[k], cptr)
+		}
+	}
+	if n, ok := toInt(s["minProperties"]); ok && len(obj) < n {
+		v.addf(ptr, "must have at least %d properties, got %d", n, len(obj))
+	}
+	if n, ok := toInt(s["maxProperties"]); ok && len(obj) > n {
+		v.addf(ptr, "must have at most %d properties, got %d", n, len(obj))
+	}
+}
+
+func (v *validator) validateArray(s map[string]interface{}, arr []interface{}, ptr string) {
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		for i, e := range arr {
+			v.validate(items, e, fmt.Sprintf("%s/%d", ptr, i))
+		}
+	}
+	if n, ok := toInt(s["minItems"]); ok && len(arr) < n {
+		v.addf(ptr, "must have at least %d items, got %d", n, len(arr))
+	}
+	if n, ok := toInt(s["maxItems"]); ok && len(arr) > n {
+		v.addf(ptr, "must have at most %d items, got %d", n, len(arr))
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if equal(arr[i], arr[j]) {
+					v.addf(ptr, "items %d and %d are equal, but items must be unique", i, j)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validateString(s map[string]interface{}, str string, ptr string) {
+	l := utf8.RuneCountInString(str)
+	if n, ok := toInt(s["minLength"]); ok && l < n {
+		v.addf(ptr, "must be at least %d characters long, got %d", n, l)
+	}
+	if n, ok := toInt(s["maxLength"]); ok && l > n {
+		v.addf(ptr, "must be at most %d characters long, got %d", n, l)
+	}
+	if p, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			v.addf(ptr, "invalid pattern %q: %v", p, err)
+		} else if !re.MatchString(str) {
+			v.addf(ptr, "%q does not match pattern %q", str, p)
+		}
+	}
+}
+
+func (v *validator) validateNumber(s map[string]interface{}, f float64, ptr string) {
+	// exclusiveMinimum/exclusiveMaximum are booleans in OpenAPI 3.0, and
+	// numbers in OpenAPI 3.1
+	exclMin, _ := s["exclusiveMinimum"].(bool)
+	exclMax, _ := s["exclusiveMaximum"].(bool)
+	if m, ok := toFloat(s["minimum"]); ok {
+		if f < m || (exclMin && f == m) {
+			v.addf(ptr, "%v is less than the minimum of %v", f, m)
+		}
+	}
+	if m, ok := toFloat(s["maximum"]); ok {
+		if f > m || (exclMax && f == m) {
+			v.addf(ptr, "%v is greater than the maximum of %v", f, m)
+		}
+	}
+	if m, ok := toFloat(s["exclusiveMinimum"]); ok && f <= m {
+		v.addf(ptr, "%v must be greater than %v", f, m)
+	}
+	if m, ok := toFloat(s["exclusiveMaximum"]); ok && f >= m {
+		v.addf(ptr, "%v must be less than %v", f, m)
+	}
+	if m, ok := toFloat(s["multipleOf"]); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.addf(ptr, "%v is not a multiple of %v", f, m)
+		}
+	}
+}
+
+func (v *validator) validateComposition(s map[string]interface{}, value interface{}, ptr string) {
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if ss, ok := sub.(map[string]interface{}); ok {
+				v.validate(ss, value, ptr)
+			}
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok && v.countMatches(anyOf, value) == 0 {
+		v.addf(ptr, "does not match any of the anyOf schemas")
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		if n := v.countMatches(oneOf, value); n != 1 {
+			v.addf(ptr, "must match exactly one of the oneOf schemas, but matches %d", n)
+		}
+	}
+	if not, ok := s["not"].(map[string]interface{}); ok && v.countMatches([]interface{}{not}, value) == 1 {
+		v.addf(ptr, "must not match the schema in not")
+	}
+}
+
+// countMatches returns how many of the schemas the value is valid against
+func (v *validator) countMatches(schemas []interface{}, value interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		ss, ok := sub.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sv := &validator{root: v.root}
+		sv.validate(ss, value, "")
+		if len(sv.problems) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func typeOf(value interface{}) string {
+	switch t := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string, time.Time:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if f, ok := toFloat(t); ok {
+			if f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return "integer"
+			}
+			return "number"
+		}
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func toInt(v interface{}) (int, bool) {
+	f, ok := toFloat(v)
+	return int(f), ok
+}
+
+// equal compares two values, treating numbers of different types as equal
+// if they have the same value
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch at := a.(type) {
+	case map[string]interface{}:
+		bt, ok := b.(map[string]interface{})
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for k, av := range at {
+			bv, ok := bt[k]
+			if !ok || !equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bt, ok := b.([]interface{})
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for i := range at {
+			if !equal(at[i], bt[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []interface{}{"name"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+			"age":   map[string]interface{}{"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"score": map[string]interface{}{"type": "number", "multipleOf": 0.5},
+			"kind":  map[string]interface{}{"enum": []interface{}{"cat", "dog"}},
+			"tags": map[string]interface{}{
+				"type": "array", "items": map[string]interface{}{"type": "string"},
+				"maxItems": 2, "uniqueItems": true,
+			},
+			"owner":  map[string]interface{}{"$ref": "#/definitions/Person"},
+			"note":   map[string]interface{}{"type": "string", "nullable": true},
+			"legacy": map[string]interface{}{"type": []interface{}{"string", "null"}},
+		},
+		"definitions": map[string]interface{}{
+			"Person": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"email"},
+			},
+		},
+	}
+
+	assert.Empty(t, Validate(schema, map[string]interface{}{
+		"name": "rex", "age": 3, "score": 1.5, "kind": "dog",
+		"tags": []interface{}{"a", "b"}, "owner": map[string]interface{}{"email": "a@b"},
+		"note": nil, "legacy": nil,
+	}))
+
+	assert.Equal(t, []string{
+		`(root): missing required property "name"`,
+		"/age: 150 must be less than 150",
+		`/kind: value bird is not one of the allowed values [cat dog]`,
+		`/owner: missing required property "email"`,
+		"/score: 1.2 is not a multiple of 0.5",
+		`/tags: items 0 and 1 are equal, but items must be unique`,
+		`(root): unexpected property "zzz"`,
+	}, Validate(schema, map[string]interface{}{
+		"age": 150, "score": 1.2, "kind": "bird",
+		"tags": []interface{}{"a", "a"}, "owner": map[string]interface{}{},
+		"zzz": true,
+	}))
+
+	assert.Equal(t, []string{
+		"/name: must be at least 2 characters long, got 1",
+		`/name: "X" does not match pattern "^[a-z]+$"`,
+		"/tags/2: expected string, got integer",
+		"/tags: must have at most 2 items, got 3",
+	}, Validate(schema, map[string]interface{}{
+		"name": "X", "tags": []interface{}{"a", "b", 3},
+	}))
+
+	assert.Equal(t, []string{"(root): expected object, got array"}, Validate(schema, []interface{}{}))
+	assert.Equal(t, []string{"/note: expected string or null, got boolean"},
+		Validate(schema, map[string]interface{}{"name": "ab", "note": true}))
+}
+
+func TestValidateComposition(t *testing.T) {
+	oneOf := map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer"},
+			map[string]interface{}{"type": "number"},
+		},
+	}
+	assert.Empty(t, Validate(oneOf, 1.5))
+	assert.Equal(t, []string{"(root): must match exactly one of the oneOf schemas, but matches 2"}, Validate(oneOf, 1))
+
+	anyOf := map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "boolean"},
+		},
+	}
+	assert.Empty(t, Validate(anyOf, true))
+	assert.Equal(t, []string{"(root): does not match any of the anyOf schemas"}, Validate(anyOf, 1))
+
+	allOf := map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"minimum": 1},
+			map[string]interface{}{"maximum": 2, "exclusiveMaximum": true},
+		},
+	}
+	assert.Empty(t, Validate(allOf, 1))
+	assert.Equal(t, []string{"(root): 2 is greater than the maximum of 2"}, Validate(allOf, 2))
+
+	not := map[string]interface{}{"not": map[string]interface{}{"type": "string"}}
+	assert.Empty(t, Validate(not, 1))
+	assert.Equal(t, []string{"(root): must not match the schema in not"}, Validate(not, "a"))
+}
+
+func TestValidateExamples(t *testing.T) {
+	doc := petstore()
+	doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["Pet"].(map[string]interface{})["example"] = map[string]interface{}{"id": "one", "name": "Rex"}
+	get := doc["paths"].(map[string]interface{})["/pets/{petId}"].(map[string]interface{})["get"].(map[string]interface{})
+	media := get["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+	media["examples"] = map[string]interface{}{
+		"good": map[string]interface{}{"value": map[string]interface{}{"id": 1, "name": "Rex"}},
+		"bad":  map[string]interface{}{"value": map[string]interface{}{"id": 1, "friends": []interface{}{map[string]interface{}{"id": 2}}}},
+	}
+	get["parameters"] = []interface{}{
+		map[string]interface{}{"name": "verbose", "in": "query", "schema": map[string]interface{}{"type": "boolean"}, "example": "yes"},
+	}
+
+	problems, err := ValidateExamples(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/components/schemas/Pet/example/id: expected integer, got string",
+		"/paths/~1pets~1{petId}/get/parameters/0/example: expected boolean, got string",
+		`/paths/~1pets~1{petId}/get/responses/200/content/application~1json/examples/bad/value: missing required property "name"`,
+		`/paths/~1pets~1{petId}/get/responses/200/content/application~1json/examples/bad/value/friends/0: missing required property "name"`,
+	}, problems)
+
+	swagger := map[string]interface{}{
+		"swagger": "2.0",
+		"definitions": map[string]interface{}{
+			"Pet": map[string]interface{}{"type": "object", "required": []interface{}{"name"}},
+		},
+		"paths": map[string]interface{}{
+			"/pets": map[string]interface{}{
+				"get": map[string]interface{}{
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"schema":   map[string]interface{}{"$ref": "#/definitions/Pet"},
+							"examples": map[string]interface{}{"application/json": map[string]interface{}{}},
+						},
+					},
+				},
+			},
+		},
+	}
+	problems, err = ValidateExamples(swagger)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`/paths/~1pets/get/responses/200/examples/application~1json: missing required property "name"`,
+	}, problems)
+}
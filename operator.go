@@ -0,0 +1,272 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/rs/zerolog"
+)
+
+// Labels and annotations used by the operator
+const (
+	// OperatorLabel - ConfigMaps with this label set to "true" are rendered
+	// by the operator
+	OperatorLabel = "gomplate.hairyhenderson.ca/render"
+	// OperatorTargetAnnotation - where a ConfigMap's rendered data is
+	// written, as "configmap/NAME" or "secret/NAME", in the same namespace
+	OperatorTargetAnnotation = "gomplate.hairyhenderson.ca/target"
+
+	// operatorHashAnnotation - records a hash of the rendered data on the
+	// target, so it's only updated when the data changes
+	operatorHashAnnotation = "gomplate.hairyhenderson.ca/hash"
+)
+
+// OperatorOptions - options for RunOperator
+type OperatorOptions struct {
+	// Server - the Kubernetes API server's URL. Defaults to the cluster
+	// gomplate is running in.
+	Server string
+	// Namespace - the namespace to render ConfigMaps in. Empty for all
+	// namespaces.
+	Namespace string
+	// Interval - how often to render all ConfigMaps. Defaults to 30s.
+	Interval time.Duration
+	// Once - render all ConfigMaps once and return, rather than until the
+	// context is cancelled
+	Once bool
+}
+
+// RunOperator renders the data of each ConfigMap labelled with OperatorLabel
+// as templates, and writes the results to the ConfigMap or Secret named by
+// its OperatorTargetAnnotation, every Interval until the context is
+// cancelled. Datasources, plugins, and other options are set in the config,
+// and the ConfigMap's metadata is available to templates as .Source.
+//
+// Targets are created owned by their source ConfigMaps, so they're deleted
+// along with them, and existing objects not owned by the source are never
+// overwritten.
+func RunOperator(ctx context.Context, cfg *config.Config, o OperatorOptions) error {
+	defer runCleanupHooks()
+
+	if o.Interval == 0 {
+		o.Interval = 30 * time.Second
+	}
+
+	client, err := kube.New(o.Server)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.EnvFiles) > 0 {
+		restoreEnv, err := loadEnvFiles(cfg.EnvFiles, cfg.EnvFileNoOverride)
+		if err != nil {
+			return err
+		}
+		defer restoreEnv()
+	}
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err := reconcileAll(ctx, client, opts, o.Namespace)
+		// datasources are read again on each pass, so clean up after each
+		runCleanupHooks()
+		if o.Once {
+			return err
+		}
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Send()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(o.Interval):
+		}
+	}
+}
+
+// reconcileAll renders all labelled ConfigMaps. A failure to render one
+// doesn't stop the others from being rendered.
+func reconcileAll(ctx context.Context, c *kube.Client, opts Options, namespace string) error {
+	sources, err := c.List(ctx, "configmaps", namespace, OperatorLabel+"=true")
+	if err != nil {
+		return fmt.Errorf("failed to list ConfigMaps to render: %w", err)
+	}
+
+	tr := NewRenderer(opts)
+	tr.data.Ctx = ctx
+
+	failed := 0
+	for i := range sources {
+		src := &sources[i]
+		if err := tr.reconcile(ctx, c, src); err != nil {
+			failed++
+			zerolog.Ctx(ctx).Error().Err(err).
+				Str("namespace", src.Metadata.Namespace).
+				Str("configmap", src.Metadata.Name).
+				Msg("failed to render ConfigMap")
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to render %d of %d ConfigMaps", failed, len(sources))
+	}
+	return nil
+}
+
+// reconcile renders the source ConfigMap, and creates or updates its target
+// when the rendered data has changed
+func (t *Renderer) reconcile(ctx context.Context, c *kube.Client, src *kube.Object) error {
+	resource, name, err := operatorTarget(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := t.renderConfigMap(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{"resource": resource, "data": out})
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+
+	ns := src.Metadata.Namespace
+	target, err := c.Get(ctx, resource, ns, name)
+	switch {
+	case errors.Is(err, kube.ErrNotFound):
+		target = &kube.Object{
+			Metadata: kube.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				OwnerReferences: []kube.OwnerReference{{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       src.Metadata.Name,
+					UID:        src.Metadata.UID,
+					Controller: true,
+				}},
+			},
+		}
+	case err != nil:
+		return err
+	case !ownedBy(target, src):
+		return fmt.Errorf("%s %s/%s already exists, and isn't owned by ConfigMap %s", resource, ns, name, src.Metadata.Name)
+	case target.Metadata.Annotations[operatorHashAnnotation] == hash:
+		zerolog.Ctx(ctx).Debug().Str("namespace", ns).Str(resource, name).Msg("rendered data unchanged")
+		return nil
+	}
+
+	if target.Metadata.Annotations == nil {
+		target.Metadata.Annotations = map[string]string{}
+	}
+	target.Metadata.Annotations[operatorHashAnnotation] = hash
+	if resource == "secrets" {
+		target.Type = "Opaque"
+		target.Data = nil
+		target.StringData = out
+	} else {
+		target.Data = out
+	}
+
+	if target.Metadata.ResourceVersion == "" {
+		_, err = c.Create(ctx, resource, target)
+	} else {
+		_, err = c.Update(ctx, resource, target)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s %s/%s: %w", resource, ns, name, err)
+	}
+
+	zerolog.Ctx(ctx).Info().Str("namespace", ns).Str(resource, name).Msg("wrote rendered data")
+	return nil
+}
+
+// renderConfigMap renders each of the ConfigMap's data values as a template,
+// returning the outputs with the same keys
+func (t *Renderer) renderConfigMap(ctx context.Context, src *kube.Object) (map[string]string, error) {
+	roots := tmplctx{"Source": src.Metadata}
+	for k, v := range t.tctxRoots {
+		roots[k] = v
+	}
+	tctx, err := createTmplContext(ctx, t.tctxAliases, roots, t.data)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(src.Data))
+	for k := range src.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bufs := make([]bytes.Buffer, len(keys))
+	templates := make([]Template, len(keys))
+	for i, k := range keys {
+		templates[i] = Template{
+			Name:   src.Metadata.Namespace + "/" + src.Metadata.Name + "/" + k,
+			Text:   src.Data[k],
+			Writer: &bufs[i],
+		}
+	}
+
+	if err := t.renderTemplatesWithData(ctx, templates, tctx); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(keys))
+	for i, k := range keys {
+		out[k] = bufs[i].String()
+	}
+	return out, nil
+}
+
+// operatorTarget parses the source's target annotation into a resource and
+// name
+func operatorTarget(src *kube.Object) (resource, name string, err error) {
+	a, ok := src.Metadata.Annotations[OperatorTargetAnnotation]
+	if !ok {
+		return "", "", fmt.Errorf("missing %s annotation", OperatorTargetAnnotation)
+	}
+
+	kind, name, _ := strings.Cut(a, "/")
+	switch strings.ToLower(kind) {
+	case "configmap":
+		resource = "configmaps"
+	case "secret":
+		resource = "secrets"
+	default:
+		return "", "", fmt.Errorf("invalid %s annotation %q: must be configmap/NAME or secret/NAME", OperatorTargetAnnotation, a)
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("invalid %s annotation %q: missing name", OperatorTargetAnnotation, a)
+	}
+	if resource == "configmaps" && name == src.Metadata.Name {
+		return "", "", fmt.Errorf("invalid %s annotation %q: the target can't be the source", OperatorTargetAnnotation, a)
+	}
+	return resource, name, nil
+}
+
+func ownedBy(o, owner *kube.Object) bool {
+	for _, ref := range o.Metadata.OwnerReferences {
+		if ref.UID == owner.Metadata.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package gomplate
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube"
+	"github.com/hairyhenderson/gomplate/v3/internal/kube/kubetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sourceConfigMap(name, target string, data map[string]string) kube.Object {
+	return kube.Object{
+		Metadata: kube.ObjectMeta{
+			Name:        name,
+			Namespace:   "apps",
+			Labels:      map[string]string{OperatorLabel: "true"},
+			Annotations: map[string]string{OperatorTargetAnnotation: target},
+		},
+		Data: data,
+	}
+}
+
+func TestRunOperator(t *testing.T) {
+	srv := kubetest.NewServer()
+	defer srv.Close()
+
+	src := srv.Put("configmaps", sourceConfigMap("web", "configmap/web-rendered", map[string]string{
+		"app.conf": `name={{ .Source.Name }} greeting={{ .Values.greeting }}`,
+	}))
+	srv.Put("configmaps", sourceConfigMap("creds", "secret/creds", map[string]string{
+		"password": `{{ "hunter2" }}`,
+	}))
+	// not labelled, so not rendered
+	srv.Put("configmaps", kube.Object{
+		Metadata: kube.ObjectMeta{Name: "other", Namespace: "apps"},
+		Data:     map[string]string{"x": "{{ fail }}"},
+	})
+
+	ctx := context.Background()
+	cfg := &config.Config{SetValues: []string{"greeting=hi"}}
+	o := OperatorOptions{Server: srv.URL, Once: true}
+
+	err := RunOperator(ctx, cfg, o)
+	require.NoError(t, err)
+
+	out, ok := srv.Get("configmaps", "apps", "web-rendered")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"app.conf": "name=web greeting=hi"}, out.Data)
+	require.Len(t, out.Metadata.OwnerReferences, 1)
+	assert.Equal(t, src.Metadata.UID, out.Metadata.OwnerReferences[0].UID)
+
+	secret, ok := srv.Get("secrets", "apps", "creds")
+	require.True(t, ok)
+	assert.Equal(t, "Opaque", secret.Type)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("hunter2")), secret.Data["password"])
+
+	// unchanged data isn't written again
+	err = RunOperator(ctx, cfg, o)
+	require.NoError(t, err)
+	again, _ := srv.Get("configmaps", "apps", "web-rendered")
+	assert.Equal(t, out.Metadata.ResourceVersion, again.Metadata.ResourceVersion)
+
+	// changed data is
+	cfg.SetValues = []string{"greeting=hello"}
+	err = RunOperator(ctx, cfg, o)
+	require.NoError(t, err)
+	again, _ = srv.Get("configmaps", "apps", "web-rendered")
+	assert.NotEqual(t, out.Metadata.ResourceVersion, again.Metadata.ResourceVersion)
+	assert.Equal(t, map[string]string{"app.conf": "name=web greeting=hello"}, again.Data)
+}
+
+func TestRunOperatorErrors(t *testing.T) {
+	srv := kubetest.NewServer()
+	defer srv.Close()
+
+	// objects not owned by the source are never overwritten
+	srv.Put("configmaps", kube.Object{Metadata: kube.ObjectMeta{Name: "taken", Namespace: "apps"}})
+	srv.Put("configmaps", sourceConfigMap("a", "configmap/taken", map[string]string{"k": "v"}))
+	srv.Put("configmaps", sourceConfigMap("b", "deployment/b", map[string]string{"k": "v"}))
+	srv.Put("configmaps", sourceConfigMap("c", "configmap/c-out", map[string]string{"k": "{{ fail }}"}))
+	srv.Put("configmaps", sourceConfigMap("d", "configmap/d-out", map[string]string{"k": "ok"}))
+
+	err := RunOperator(context.Background(), &config.Config{}, OperatorOptions{Server: srv.URL, Once: true})
+	assert.EqualError(t, err, "failed to render 3 of 4 ConfigMaps")
+
+	taken, _ := srv.Get("configmaps", "apps", "taken")
+	assert.Empty(t, taken.Data)
+	_, ok := srv.Get("configmaps", "apps", "c-out")
+	assert.False(t, ok)
+
+	// the others are still rendered
+	d, ok := srv.Get("configmaps", "apps", "d-out")
+	require.True(t, ok)
+	assert.Equal(t, "ok", d.Data["k"])
+}
+
+func TestOperatorTarget(t *testing.T) {
+	testdata := []struct {
+		annotation string
+		resource   string
+		name       string
+	}{
+		{"configmap/out", "configmaps", "out"},
+		{"ConfigMap/out", "configmaps", "out"},
+		{"secret/out", "secrets", "out"},
+		{"secret/src", "secrets", "src"},
+	}
+	for _, d := range testdata {
+		src := &kube.Object{Metadata: kube.ObjectMeta{
+			Name:        "src",
+			Annotations: map[string]string{OperatorTargetAnnotation: d.annotation},
+		}}
+		resource, name, err := operatorTarget(src)
+		require.NoError(t, err)
+		assert.Equal(t, d.resource, resource)
+		assert.Equal(t, d.name, name)
+	}
+
+	for _, a := range []string{"configmap/src", "configmap/", "pod/out", "out"} {
+		src := &kube.Object{Metadata: kube.ObjectMeta{
+			Name:        "src",
+			Annotations: map[string]string{OperatorTargetAnnotation: a},
+		}}
+		_, _, err := operatorTarget(src)
+		assert.Error(t, err, a)
+	}
+
+	_, _, err := operatorTarget(&kube.Object{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package gomplate
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/yaml"
+)
+
+// A pack is a zip archive appended to a gomplate executable, containing a
+// compiled bundle, datasource snapshots, and the config to render them with.
+// Since zip archives are read from the end, the packed executable is a valid
+// zip file, as with self-extracting archives.
+const (
+	packBundle   = "gomplate-pack/bundle.json"
+	packSnapshot = "gomplate-pack/snapshot.json"
+	packConfig   = "gomplate-pack/config.yaml"
+
+	// the zip comment records the size of the executable, so that a packed
+	// executable can be packed again
+	packCommentPrefix = "gomplate-pack base="
+)
+
+// Pack writes a standalone executable to w, which renders the templates in
+// the config without reading any templates or datasources. It's a copy of the
+// base gomplate executable with a pack appended, containing a compiled bundle
+// of the templates, snapshots of the datasources (and context datasources),
+// and the rendering options from the config. Values are included, but plugins
+// are external commands, so can't be packed.
+//
+// Experimental: subject to breaking changes before the next major release
+func Pack(ctx context.Context, cfg *config.Config, base io.ReaderAt, baseSize int64, w io.Writer) error {
+	if len(cfg.Plugins) > 0 {
+		return fmt.Errorf("plugins can't be packed, as they're external commands")
+	}
+
+	bundle := &bytes.Buffer{}
+	err := Compile(ctx, cfg, bundle)
+	if err != nil {
+		return err
+	}
+
+	opts, err := runOptions(data.ContextWithStdin(ctx, cfg.Stdin), cfg)
+	if err != nil {
+		return err
+	}
+	defer runCleanupHooks()
+	snapshots, err := takeSnapshots(ctx, opts)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(snapshotFile{Version: snapshotVersion, Datasources: snapshots})
+	if err != nil {
+		return err
+	}
+
+	packCfg, err := yaml.Marshal(packedConfig(cfg, opts))
+	if err != nil {
+		return err
+	}
+
+	// a packed base is replaced, rather than packed twice
+	if size, ok := packBaseSize(base, baseSize); ok {
+		baseSize = size
+	}
+	n, err := io.Copy(w, io.NewSectionReader(base, 0, baseSize))
+	if err != nil {
+		return fmt.Errorf("failed to copy base executable: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	zw.SetOffset(n)
+	for _, f := range []struct {
+		name string
+		b    []byte
+	}{{packBundle, bundle.Bytes()}, {packSnapshot, snapshot}, {packConfig, packCfg}} {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(f.b); err != nil {
+			return err
+		}
+	}
+	if err := zw.SetComment(packCommentPrefix + strconv.FormatInt(n, 10)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// packedConfig returns the options from cfg that are needed to render the
+// pack. Datasources are snapshotted, so their headers aren't needed.
+func packedConfig(cfg *config.Config, opts Options) *config.Config {
+	out := &config.Config{
+		DataSources:      map[string]config.DataSource{},
+		Context:          map[string]config.DataSource{},
+		Values:           opts.Values,
+		OutMode:          cfg.OutMode,
+		SuppressEmpty:    cfg.SuppressEmpty,
+		Experimental:     cfg.Experimental,
+		HTMLEscape:       cfg.HTMLEscape,
+		OrderedMaps:      cfg.OrderedMaps,
+		PreserveComments: cfg.PreserveComments,
+
+		StrictDeprecations: cfg.StrictDeprecations,
+	}
+	for alias, ds := range cfg.DataSources {
+		out.DataSources[alias] = config.DataSource{URL: ds.URL}
+	}
+	for alias, ds := range cfg.Context {
+		out.Context[alias] = config.DataSource{URL: ds.URL}
+	}
+	return out
+}
+
+// packBaseSize returns the size of the executable that a pack was appended
+// to, if there is one
+func packBaseSize(r io.ReaderAt, size int64) (int64, bool) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil || !strings.HasPrefix(zr.Comment, packCommentPrefix) {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimPrefix(zr.Comment, packCommentPrefix), 10, 64)
+	if err != nil || n < 0 || n > size {
+		return 0, false
+	}
+	return n, true
+}
+
+// ExtractPack extracts the pack appended to the executable at path (if any)
+// into a temporary directory, and returns the config to render it with, and
+// a function to remove the directory. The config's Bundle and Snapshot refer
+// to the extracted files. When the executable isn't packed, the returned
+// config is nil.
+//
+// Experimental: subject to breaking changes before the next major release
+func ExtractPack(path string) (*config.Config, func(), error) {
+	zr, err := zip.OpenReader(path)
+	if errors.Is(err, zip.ErrFormat) {
+		return nil, func() {}, nil
+	}
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	if !strings.HasPrefix(zr.Comment, packCommentPrefix) {
+		return nil, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "gomplate-pack-")
+	if err != nil {
+		return nil, func() {}, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	cfg, err := extractPack(zr, path, dir)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	return cfg, cleanup, nil
+}
+
+func extractPack(zr *zip.ReadCloser, path, dir string) (*config.Config, error) {
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		b, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from pack: %w", f.Name, err)
+		}
+		files[f.Name] = b
+	}
+	for _, name := range []string{packBundle, packSnapshot, packConfig} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("invalid pack in %s: %s is missing", path, name)
+		}
+	}
+
+	cfg, err := config.Parse(bytes.NewReader(files[packConfig]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack in %s: %w", path, err)
+	}
+
+	cfg.Bundle = filepath.Join(dir, "bundle.json")
+	cfg.Snapshot = filepath.Join(dir, "snapshot.json")
+	if err := os.WriteFile(cfg.Bundle, files[packBundle], 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cfg.Snapshot, files[packSnapshot], 0o600); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
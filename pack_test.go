@@ -0,0 +1,81 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackAndExtract(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	dsFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(dsFile, []byte("name: acme\n"), 0o600))
+	u, err := config.ParseSourceURL(dsFile)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Input:       `{{ (ds "cfg").name }} {{ .Values.v }}`,
+		DataSources: map[string]config.DataSource{"cfg": {URL: u}},
+		Values:      map[string]interface{}{"v": 42},
+	}
+	base := []byte("#!not really an executable\n")
+	exe := &bytes.Buffer{}
+	require.NoError(t, Pack(ctx, cfg, bytes.NewReader(base), int64(len(base)), exe))
+	assert.True(t, bytes.HasPrefix(exe.Bytes(), base))
+
+	exePath := filepath.Join(dir, "render")
+	require.NoError(t, os.WriteFile(exePath, exe.Bytes(), 0o755))
+
+	// the datasource isn't read when rendering
+	require.NoError(t, os.Remove(dsFile))
+
+	packCfg, cleanup, err := ExtractPack(exePath)
+	require.NoError(t, err)
+	require.NotNil(t, packCfg)
+	defer cleanup()
+	assert.FileExists(t, packCfg.Bundle)
+	assert.FileExists(t, packCfg.Snapshot)
+
+	out := filepath.Join(dir, "out.txt")
+	packCfg.OutputFiles = []string{out}
+	require.NoError(t, Run(ctx, packCfg))
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "acme 42", string(b))
+
+	// packing a packed executable replaces the pack
+	repacked := &bytes.Buffer{}
+	cfg = &config.Config{Input: "hello"}
+	require.NoError(t, Pack(ctx, cfg, bytes.NewReader(exe.Bytes()), int64(exe.Len()), repacked))
+	size, ok := packBaseSize(bytes.NewReader(repacked.Bytes()), int64(repacked.Len()))
+	assert.True(t, ok)
+	assert.Equal(t, int64(len(base)), size)
+
+	cleanup()
+	assert.NoDirExists(t, filepath.Dir(packCfg.Bundle))
+
+	// plain executables aren't packed
+	packCfg, cleanup, err = ExtractPack(dsFile + ".missing")
+	assert.Error(t, err)
+	assert.Nil(t, packCfg)
+	cleanup()
+
+	require.NoError(t, os.WriteFile(exePath, base, 0o755))
+	packCfg, cleanup, err = ExtractPack(exePath)
+	assert.NoError(t, err)
+	assert.Nil(t, packCfg)
+	cleanup()
+
+	// plugins can't be packed
+	cfg = &config.Config{Input: "hello", Plugins: map[string]config.PluginConfig{"foo": {Cmd: "foo"}}}
+	err = Pack(ctx, cfg, bytes.NewReader(base), int64(len(base)), &bytes.Buffer{})
+	assert.EqualError(t, err, "plugins can't be packed, as they're external commands")
+}
@@ -0,0 +1,167 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// FromHTML renders an HTML document as a PDF. Only the document's structure
+// is used - headings, paragraphs, lists, preformatted text, and horizontal
+// rules are supported, and styles, scripts, and images are ignored.
+func FromHTML(in string, opts Options) ([]byte, error) {
+	doc, err := html.Parse(strings.NewReader(in))
+	if err != nil {
+		return nil, err
+	}
+	if opts.Title == "" {
+		opts.Title = htmlTitle(doc)
+	}
+
+	p := &htmlParser{}
+	p.walk(doc)
+	p.flush()
+	return render(p.blocks, opts)
+}
+
+type htmlParser struct {
+	blocks []block
+	text   strings.Builder
+	cur    block
+	// lists holds the item counter for each enclosing list - 0 for unordered
+	// lists
+	lists []int
+}
+
+// flush ends the current block of text
+func (p *htmlParser) flush() {
+	t := strings.Join(strings.Fields(p.text.String()), " ")
+	p.text.Reset()
+	if t != "" || p.cur.kind == listItem {
+		p.cur.text = t
+		p.blocks = append(p.blocks, p.cur)
+	}
+	p.cur = block{kind: paragraph}
+}
+
+func (p *htmlParser) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		p.text.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			p.walk(c)
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Template, atom.Noscript:
+		return
+	case atom.Br:
+		p.text.WriteString("\n")
+		return
+	case atom.Hr:
+		p.flush()
+		p.blocks = append(p.blocks, block{kind: rule})
+		return
+	case atom.Pre:
+		p.flush()
+		p.blocks = append(p.blocks, block{kind: code, text: strings.Trim(textContent(n), "\n")})
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		p.flush()
+		level, _ := strconv.Atoi(n.Data[1:])
+		p.blocks = append(p.blocks, block{kind: heading, level: level,
+			text: strings.Join(strings.Fields(textContent(n)), " ")})
+		return
+	case atom.Ul, atom.Ol:
+		p.flush()
+		start := 0
+		if n.DataAtom == atom.Ol {
+			start = 1
+			for _, a := range n.Attr {
+				if a.Key == "start" {
+					if s, err := strconv.Atoi(a.Val); err == nil {
+						start = s
+					}
+				}
+			}
+		}
+		p.lists = append(p.lists, start)
+		p.children(n)
+		p.flush()
+		p.lists = p.lists[:len(p.lists)-1]
+		return
+	case atom.Li:
+		p.flush()
+		p.cur = block{kind: listItem, level: len(p.lists), marker: "•"}
+		if p.cur.level == 0 {
+			p.cur.level = 1
+		} else if c := p.lists[len(p.lists)-1]; c > 0 {
+			p.cur.marker = strconv.Itoa(c) + "."
+			p.lists[len(p.lists)-1]++
+		}
+		p.children(n)
+		p.flush()
+		return
+	case atom.A:
+		p.children(n)
+		for _, a := range n.Attr {
+			if a.Key == "href" && a.Val != "" && !strings.HasPrefix(a.Val, "#") && a.Val != textContent(n) {
+				p.text.WriteString(" (" + a.Val + ")")
+			}
+		}
+		return
+	case atom.P, atom.Div, atom.Blockquote, atom.Section, atom.Article,
+		atom.Header, atom.Footer, atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd:
+		p.flush()
+		p.children(n)
+		p.flush()
+		return
+	case atom.Td, atom.Th:
+		p.text.WriteString(" ")
+		p.children(n)
+		p.text.WriteString(" ")
+		return
+	}
+	p.children(n)
+}
+
+func (p *htmlParser) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.walk(c)
+	}
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	b := &strings.Builder{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// htmlTitle finds the content of the document's <title> element
+func htmlTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Title {
+		return strings.TrimSpace(textContent(n))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if t := htmlTitle(c); t != "" {
+			return t
+		}
+	}
+	return ""
+}
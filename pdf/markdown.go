@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	mdBullet   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdNumbered = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	mdRule     = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+
+	mdImage = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink  = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)[^)]*\)`)
+	mdEmph  = []*regexp.Regexp{
+		regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`),
+		regexp.MustCompile(`__(\S(?:.*?\S)?)__`),
+		regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`),
+		regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`),
+		regexp.MustCompile(`\b_(\S(?:.*?\S)?)_\b`),
+	}
+	mdCode   = regexp.MustCompile("`([^`]*)`")
+	mdEscape = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!])`)
+)
+
+// FromMarkdown renders a Markdown document as a PDF. Headings, paragraphs,
+// (nested) lists, code blocks, and horizontal rules are supported. Inline
+// formatting is removed, and links are rendered with their URL.
+func FromMarkdown(in string, opts Options) ([]byte, error) {
+	return render(parseMarkdown(in), opts)
+}
+
+func parseMarkdown(in string) []block {
+	blocks := []block{}
+	para := []string{}
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, block{kind: paragraph, text: mdInline(strings.Join(para, " "))})
+			para = para[:0]
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(in, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		trimmed := strings.TrimSpace(l)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			src := []string{}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				src = append(src, lines[i])
+			}
+			blocks = append(blocks, block{kind: code, text: strings.Join(src, "\n")})
+		case trimmed == "":
+			flush()
+		case mdRule.MatchString(l):
+			flush()
+			blocks = append(blocks, block{kind: rule})
+		case mdHeading.MatchString(trimmed):
+			flush()
+			m := mdHeading.FindStringSubmatch(trimmed)
+			blocks = append(blocks, block{kind: heading, level: len(m[1]), text: mdInline(m[2])})
+		case mdBullet.MatchString(l):
+			flush()
+			m := mdBullet.FindStringSubmatch(l)
+			blocks = append(blocks, block{kind: listItem, level: listLevel(m[1]), marker: "•", text: mdInline(m[2])})
+		case mdNumbered.MatchString(l):
+			flush()
+			m := mdNumbered.FindStringSubmatch(l)
+			blocks = append(blocks, block{kind: listItem, level: listLevel(m[1]), marker: m[2] + ".", text: mdInline(m[3])})
+		case strings.HasPrefix(l, "    ") && len(para) == 0:
+			src := []string{}
+			for ; i < len(lines) && (strings.HasPrefix(lines[i], "    ") || strings.TrimSpace(lines[i]) == ""); i++ {
+				src = append(src, strings.TrimPrefix(lines[i], "    "))
+			}
+			i--
+			blocks = append(blocks, block{kind: code, text: strings.TrimRight(strings.Join(src, "\n"), "\n")})
+		case strings.HasPrefix(trimmed, ">"):
+			para = append(para, strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+		default:
+			// a lazy continuation of the previous list item
+			if len(para) == 0 && len(blocks) > 0 && blocks[len(blocks)-1].kind == listItem && i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+				blocks[len(blocks)-1].text += " " + mdInline(trimmed)
+				continue
+			}
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// listLevel determines the nesting depth of a list item from its indentation
+func listLevel(indent string) int {
+	return 1 + len(strings.ReplaceAll(indent, "\t", "    "))/2
+}
+
+// mdInline removes inline Markdown formatting
+func mdInline(s string) string {
+	// escaped characters are swapped for private-use placeholders so they're
+	// not treated as formatting
+	s = mdEscape.ReplaceAllStringFunc(s, func(m string) string {
+		return string(rune(0xe000) + rune(m[1]))
+	})
+
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdLink.FindStringSubmatch(m)
+		if sub[1] == sub[2] || sub[2] == "" {
+			return sub[1]
+		}
+		return sub[1] + " (" + sub[2] + ")"
+	})
+	s = mdCode.ReplaceAllString(s, "$1")
+	for _, re := range mdEmph {
+		s = re.ReplaceAllString(s, "$1")
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 0xe000 && r < 0xe080 {
+			return r - 0xe000
+		}
+		return r
+	}, s)
+}
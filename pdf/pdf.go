@@ -0,0 +1,337 @@
+// Package pdf contains functions for rendering simple documents (written in
+// Markdown or HTML) as PDF files, without external tools.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Options controls the layout of the rendered document
+type Options struct {
+	// Title is set in the document's metadata
+	Title string
+	// PageSize is one of "A4" (the default), "Letter", or "Legal"
+	PageSize string
+}
+
+var pageSizes = map[string][2]float64{
+	"a4":     {595.28, 841.89},
+	"letter": {612, 792},
+	"legal":  {612, 1008},
+}
+
+const pageMargin = 56.0
+
+// blockKind identifies the type of a block of text
+type blockKind int
+
+const (
+	paragraph blockKind = iota
+	heading
+	listItem
+	code
+	rule
+)
+
+// block is a single block-level element of a document, such as a paragraph
+// or a heading
+type block struct {
+	kind blockKind
+	// level is the heading level (1-6), or the list nesting depth
+	level int
+	// marker is the bullet or number for list items
+	marker string
+	text   string
+}
+
+// font describes one of the standard PDF fonts
+type font struct {
+	name   string
+	widths func(r rune) float64
+}
+
+var (
+	regular = font{"F1", helveticaWidth}
+	bold    = font{"F2", func(r rune) float64 { return helveticaWidth(r) * 1.08 }}
+	mono    = font{"F3", func(rune) float64 { return 600 }}
+)
+
+func (f font) textWidth(s string, size float64) float64 {
+	w := 0.0
+	for _, r := range s {
+		w += f.widths(r)
+	}
+	return w * size / 1000
+}
+
+// style returns the font, size, and indentation to use for a block
+func (b block) style() (font, float64, float64) {
+	switch b.kind {
+	case heading:
+		sizes := []float64{20, 16, 13.5, 12, 11, 11}
+		return bold, sizes[b.level-1], 0
+	case code:
+		return mono, 9, 10
+	case listItem:
+		return regular, 11, 18 * float64(b.level)
+	default:
+		return regular, 11, 0
+	}
+}
+
+// line is a single line of text positioned on a page
+type line struct {
+	font font
+	size float64
+	x, y float64
+	text string
+}
+
+type page struct {
+	lines []line
+	rules []float64
+}
+
+// layout flows the blocks onto pages
+func layout(blocks []block, width, height float64) []*page {
+	pages := []*page{{}}
+	cur := pages[0]
+	y := height - pageMargin
+	textWidth := width - 2*pageMargin
+
+	newPage := func() {
+		cur = &page{}
+		pages = append(pages, cur)
+		y = height - pageMargin
+	}
+
+	for i, b := range blocks {
+		if b.kind == rule {
+			y -= 8
+			if y < pageMargin {
+				newPage()
+			}
+			cur.rules = append(cur.rules, y)
+			y -= 8
+			continue
+		}
+
+		f, size, indent := b.style()
+		leading := size * 1.4
+		if b.kind == heading && i > 0 {
+			y -= size * 0.6
+		}
+
+		var lines []string
+		if b.kind == code {
+			lines = hardWrap(b.text, int((textWidth-indent)/(600*size/1000)))
+		} else {
+			lines = wordWrap(b.text, f, size, textWidth-indent)
+		}
+
+		for j, l := range lines {
+			if y-leading < pageMargin {
+				newPage()
+			}
+			y -= leading
+			if j == 0 && b.kind == listItem {
+				cur.lines = append(cur.lines, line{f, size, pageMargin + indent - 14, y, b.marker})
+			}
+			cur.lines = append(cur.lines, line{f, size, pageMargin + indent, y, l})
+		}
+
+		// space between blocks, except between consecutive list items
+		if !(b.kind == listItem && i+1 < len(blocks) && blocks[i+1].kind == listItem) {
+			y -= size * 0.6
+		}
+	}
+	return pages
+}
+
+// wordWrap splits text into lines no wider than the given width, breaking
+// between words where possible
+func wordWrap(text string, f font, size, width float64) []string {
+	lines := []string{}
+	for _, para := range strings.Split(text, "\n") {
+		cur := ""
+		for _, w := range strings.Fields(para) {
+			next := w
+			if cur != "" {
+				next = cur + " " + w
+			}
+			if cur != "" && f.textWidth(next, size) > width {
+				lines = append(lines, cur)
+				next = w
+			}
+			// break words that are too long to fit on a line by themselves
+			for f.textWidth(next, size) > width && len([]rune(next)) > 1 {
+				r := []rune(next)
+				n := len(r) - 1
+				for n > 1 && f.textWidth(string(r[:n]), size) > width {
+					n--
+				}
+				lines = append(lines, string(r[:n]))
+				next = string(r[n:])
+			}
+			cur = next
+		}
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// hardWrap splits monospaced text into lines of at most n characters,
+// preserving whitespace
+func hardWrap(text string, n int) []string {
+	if n < 1 {
+		n = 1
+	}
+	lines := []string{}
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		r := []rune(l)
+		for len(r) > n {
+			lines = append(lines, string(r[:n]))
+			r = r[n:]
+		}
+		lines = append(lines, string(r))
+	}
+	return lines
+}
+
+// render produces the PDF file for the given blocks
+func render(blocks []block, opts Options) ([]byte, error) {
+	size := strings.ToLower(opts.PageSize)
+	if size == "" {
+		size = "a4"
+	}
+	dims, ok := pageSizes[size]
+	if !ok {
+		return nil, fmt.Errorf("unsupported page size %q (must be one of A4, Letter, or Legal)", opts.PageSize)
+	}
+	pages := layout(blocks, dims[0], dims[1])
+
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// object numbers: 1 catalog, 2 page tree, 3-5 fonts, 6 info, then a page
+	// and a content stream for each page
+	pageObj := func(i int) int { return 7 + 2*i }
+
+	w.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj(i))
+	}
+	w.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+
+	for i, name := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		w.object(3+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	w.object(6, fmt.Sprintf("<< /Title %s /Producer (gomplate) >>", pdfString(opts.Title)))
+
+	for i, p := range pages {
+		content := &bytes.Buffer{}
+		for _, l := range p.lines {
+			fmt.Fprintf(content, "BT /%s %s Tf %s %s Td %s Tj ET\n",
+				l.font.name, num(l.size), num(l.x), num(l.y), pdfString(l.text))
+		}
+		for _, y := range p.rules {
+			fmt.Fprintf(content, "0.6 G 0.5 w %s %s m %s %s l S\n",
+				num(pageMargin), num(y), num(dims[0]-pageMargin), num(y))
+		}
+
+		w.object(pageObj(i), fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			num(dims[0]), num(dims[1]), pageObj(i)+1))
+		w.object(pageObj(i)+1, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	w.trailer(pageObj(len(pages)))
+	return w.buf.Bytes(), nil
+}
+
+// writer accumulates PDF objects, tracking their offsets for the
+// cross-reference table
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *writer) object(n int, body string) {
+	if w.offsets == nil {
+		w.offsets = map[int]int{}
+	}
+	w.offsets[n] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+// trailer writes the cross-reference table and trailer - size is the number
+// of objects, plus one
+func (w *writer) trailer(size int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for i := 1; i < size; i++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[i])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, xref)
+}
+
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// winAnsi maps the non-Latin-1 characters available in the WinAnsiEncoding
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// pdfString encodes text as a PDF literal string in the WinAnsiEncoding.
+// Characters which can't be encoded are replaced with '?'.
+func pdfString(s string) string {
+	b := &strings.Builder{}
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(b, `\%03o`, r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(b, `\%03o`, winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// helveticaWidths are the glyph widths for characters 32-126, from the
+// Helvetica AFM file
+var helveticaWidths = [...]float64{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func helveticaWidth(r rune) float64 {
+	if r >= 32 && r <= 126 {
+		return helveticaWidths[r-32]
+	}
+	// a reasonable guess for other characters
+	return 556
+}
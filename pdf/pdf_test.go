@@ -0,0 +1,111 @@
+package pdf
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertValidPDF checks the overall structure of the file, and that the
+// cross-reference table points at the right objects
+func assertValidPDF(t *testing.T, b []byte) {
+	t.Helper()
+	s := string(b)
+	require.True(t, strings.HasPrefix(s, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(s, "%%EOF\n"))
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(s)
+	require.Len(t, m, 2)
+	xref, _ := strconv.Atoi(m[1])
+	require.True(t, strings.HasPrefix(s[xref:], "xref\n"))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(s[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		assert.True(t, strings.HasPrefix(s[off:], strconv.Itoa(i+1)+" 0 obj\n"), "object %d", i+1)
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	in := "# Title #\n\nSome *emphasised* and **bold** text\nwith a [link](https://example.com) and `code`.\n\n" +
+		"- one\n- two\n  - nested\n1. first\n2) second\n\n---\n\n```go\nfunc main() {\n}\n```\n\n    indented\n\n> quoted \\*text\\*\n"
+	assert.Equal(t, []block{
+		{kind: heading, level: 1, text: "Title"},
+		{kind: paragraph, text: "Some emphasised and bold text with a link (https://example.com) and code."},
+		{kind: listItem, level: 1, marker: "•", text: "one"},
+		{kind: listItem, level: 1, marker: "•", text: "two"},
+		{kind: listItem, level: 2, marker: "•", text: "nested"},
+		{kind: listItem, level: 1, marker: "1.", text: "first"},
+		{kind: listItem, level: 1, marker: "2.", text: "second"},
+		{kind: rule},
+		{kind: code, text: "func main() {\n}"},
+		{kind: code, text: "indented"},
+		{kind: paragraph, text: "quoted *text*"},
+	}, parseMarkdown(in))
+}
+
+func TestFromMarkdown(t *testing.T) {
+	out, err := FromMarkdown("# Hello (world)\n\nCafé – naïve ☃\n", Options{Title: "Greeting"})
+	require.NoError(t, err)
+	assertValidPDF(t, out)
+	s := string(out)
+	assert.Contains(t, s, `/Title (Greeting)`)
+	assert.Contains(t, s, `/F2 20 Tf 56 757.89 Td (Hello \(world\)) Tj`)
+	assert.Contains(t, s, `(Caf\351 \226 na\357ve ?) Tj`)
+	assert.Contains(t, s, `/MediaBox [0 0 595.28 841.89]`)
+
+	_, err = FromMarkdown("foo", Options{PageSize: "tabloid"})
+	assert.Error(t, err)
+}
+
+func TestPagination(t *testing.T) {
+	out, err := FromMarkdown(strings.Repeat("A paragraph of text that goes on for a while. ", 1000), Options{PageSize: "letter"})
+	require.NoError(t, err)
+	assertValidPDF(t, out)
+
+	count := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(string(out))
+	n, _ := strconv.Atoi(count[1])
+	assert.Greater(t, n, 5)
+	assert.Equal(t, n, strings.Count(string(out), "/Type /Page /Parent"))
+}
+
+func TestWordWrap(t *testing.T) {
+	lines := wordWrap("the quick brown fox jumps", regular, 10, 60)
+	assert.Equal(t, []string{"the quick", "brown fox", "jumps"}, lines)
+
+	// long words are broken
+	lines = wordWrap(strings.Repeat("x", 30), regular, 10, 50)
+	assert.Equal(t, []string{strings.Repeat("x", 10), strings.Repeat("x", 10), strings.Repeat("x", 10)}, lines)
+
+	assert.Equal(t, []string{"abc", "def", "g"}, hardWrap("abcdefg", 3))
+}
+
+func TestFromHTML(t *testing.T) {
+	in := `<html><head><title>The Title</title><style>p { color: red }</style></head><body>
+<h2>Heading</h2>
+<p>Some <b>bold</b>
+  text with a <a href="https://example.com">link</a>.</p>
+<ul><li>one</li><li>two<ol start="3"><li>three</li></ol></li></ul>
+<hr>
+<pre>  indented
+code</pre>
+<script>alert("hi")</script>
+</body></html>`
+
+	out, err := FromHTML(in, Options{})
+	require.NoError(t, err)
+	assertValidPDF(t, out)
+	assert.Contains(t, string(out), "/Title (The Title)")
+
+	s := string(out)
+	assert.Contains(t, s, "(Heading) Tj")
+	assert.Contains(t, s, "(Some bold text with a link \\(https://example.com\\).) Tj")
+	assert.Contains(t, s, "(3.) Tj")
+	assert.Contains(t, s, "(  indented) Tj")
+	assert.NotContains(t, s, "alert")
+	assert.NotContains(t, s, "color")
+}
@@ -0,0 +1,295 @@
+// Package plot contains functions for rendering simple charts as SVG images.
+package plot
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultColors is the palette used when no colours are given
+var DefaultColors = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+const (
+	defaultWidth  = 400
+	defaultHeight = 300
+	margin        = 20.0
+	fontSize      = 12.0
+)
+
+// Options controls the size and appearance of a chart
+type Options struct {
+	// Width and Height of the image in pixels (defaults 400x300)
+	Width, Height int
+	// Title is drawn at the top of the chart, when set
+	Title string
+	// Labels for each value - drawn under the bars/points of bar and line
+	// charts, and in the legend of pie charts
+	Labels []string
+	// Colors (any SVG colour value) to use for the chart's series/slices
+	Colors []string
+}
+
+func (o Options) size() (float64, float64) {
+	w, h := o.Width, o.Height
+	if w <= 0 {
+		w = defaultWidth
+	}
+	if h <= 0 {
+		h = defaultHeight
+	}
+	return float64(w), float64(h)
+}
+
+func (o Options) color(i int) string {
+	c := o.Colors
+	if len(c) == 0 {
+		c = DefaultColors
+	}
+	return c[i%len(c)]
+}
+
+func (o Options) label(i int) string {
+	if i < len(o.Labels) {
+		return o.Labels[i]
+	}
+	return ""
+}
+
+// area is the rectangle available for plotting, inside the margins and below
+// the title
+type area struct {
+	x, y, w, h float64
+}
+
+// canvas accumulates SVG elements
+type canvas struct {
+	b    *strings.Builder
+	opts Options
+	area area
+}
+
+func newCanvas(opts Options, labelled bool) *canvas {
+	w, h := opts.size()
+	c := &canvas{b: &strings.Builder{}, opts: opts}
+	fmt.Fprintf(c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="sans-serif" font-size="%s">`,
+		num(w), num(h), num(w), num(h), num(fontSize))
+	c.b.WriteString("\n")
+
+	c.area = area{x: margin, y: margin, w: w - 2*margin, h: h - 2*margin}
+	if opts.Title != "" {
+		c.text(w/2, margin, "middle", opts.Title, ` font-weight="bold"`)
+		c.area.y += fontSize + margin/2
+		c.area.h -= fontSize + margin/2
+	}
+	if labelled {
+		c.area.h -= fontSize + margin/4
+	}
+	return c
+}
+
+func (c *canvas) text(x, y float64, anchor, s, extra string) {
+	fmt.Fprintf(c.b, `<text x="%s" y="%s" text-anchor="%s"%s>%s</text>`,
+		num(x), num(y), anchor, extra, html.EscapeString(s))
+	c.b.WriteString("\n")
+}
+
+func (c *canvas) String() string {
+	return c.b.String() + "</svg>\n"
+}
+
+// num formats a coordinate with at most 2 decimal places
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+func hasLabels(opts Options) bool {
+	for _, l := range opts.Labels {
+		if l != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// yRange returns the range of values to plot - always including 0, so that
+// bars have a baseline
+func yRange(values []float64) (lo, hi float64) {
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if lo == hi {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+func validate(values []float64) error {
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("value %d is not a finite number", i)
+		}
+	}
+	return nil
+}
+
+// Bar renders the values as a vertical bar chart
+func Bar(values []float64, opts Options) (string, error) {
+	if err := validate(values); err != nil {
+		return "", err
+	}
+	labelled := hasLabels(opts)
+	c := newCanvas(opts, labelled)
+	if len(values) == 0 {
+		return c.String(), nil
+	}
+
+	a := c.area
+	lo, hi := yRange(values)
+	scale := a.h / (hi - lo)
+	zero := a.y + hi*scale
+	slot := a.w / float64(len(values))
+	bw := slot * 0.8
+
+	for i, v := range values {
+		x := a.x + float64(i)*slot + (slot-bw)/2
+		y, h := zero-v*scale, v*scale
+		if v < 0 {
+			y, h = zero, -v*scale
+		}
+		fmt.Fprintf(c.b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"><title>%s</title></rect>`,
+			num(x), num(y), num(bw), num(h), html.EscapeString(c.opts.color(i)), num(v))
+		c.b.WriteString("\n")
+		if labelled {
+			c.text(x+bw/2, a.y+a.h+fontSize+margin/4, "middle", opts.label(i), "")
+		}
+	}
+	c.axis(zero)
+	return c.String(), nil
+}
+
+// Line renders the values as a line chart, with a point for each value
+func Line(values []float64, opts Options) (string, error) {
+	if err := validate(values); err != nil {
+		return "", err
+	}
+	labelled := hasLabels(opts)
+	c := newCanvas(opts, labelled)
+	if len(values) == 0 {
+		return c.String(), nil
+	}
+
+	a := c.area
+	lo, hi := yRange(values)
+	scale := a.h / (hi - lo)
+	zero := a.y + hi*scale
+	step := 0.0
+	if len(values) > 1 {
+		step = a.w / float64(len(values)-1)
+	}
+
+	c.axis(zero)
+	pts := make([]string, len(values))
+	for i, v := range values {
+		x := a.x + float64(i)*step
+		if len(values) == 1 {
+			x = a.x + a.w/2
+		}
+		pts[i] = num(x) + "," + num(zero-v*scale)
+	}
+	fmt.Fprintf(c.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`,
+		strings.Join(pts, " "), html.EscapeString(c.opts.color(0)))
+	c.b.WriteString("\n")
+	for i, p := range pts {
+		xy := strings.Split(p, ",")
+		fmt.Fprintf(c.b, `<circle cx="%s" cy="%s" r="3" fill="%s"><title>%s</title></circle>`,
+			xy[0], xy[1], html.EscapeString(c.opts.color(0)), num(values[i]))
+		c.b.WriteString("\n")
+		if labelled {
+			x, _ := strconv.ParseFloat(xy[0], 64)
+			c.text(x, a.y+a.h+fontSize+margin/4, "middle", opts.label(i), "")
+		}
+	}
+	return c.String(), nil
+}
+
+// axis draws the horizontal axis at the given y coordinate
+func (c *canvas) axis(y float64) {
+	a := c.area
+	fmt.Fprintf(c.b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#333"/>`,
+		num(a.x), num(y), num(a.x+a.w), num(y))
+	c.b.WriteString("\n")
+}
+
+// Pie renders the values as a pie chart, with a legend when labels are given.
+// Values must not be negative.
+func Pie(values []float64, opts Options) (string, error) {
+	if err := validate(values); err != nil {
+		return "", err
+	}
+	total := 0.0
+	for i, v := range values {
+		if v < 0 {
+			return "", fmt.Errorf("pie chart values must not be negative, but value %d is %s", i, num(v))
+		}
+		total += v
+	}
+
+	c := newCanvas(opts, false)
+	if total == 0 {
+		return c.String(), nil
+	}
+
+	a := c.area
+	labelled := hasLabels(opts)
+	pieW := a.w
+	if labelled {
+		// leave room on the right for the legend
+		pieW = a.w * 0.6
+	}
+	r := math.Min(pieW, a.h) / 2
+	cx, cy := a.x+pieW/2, a.y+a.h/2
+
+	angle := -math.Pi / 2
+	for i, v := range values {
+		if v == 0 {
+			continue
+		}
+		color := html.EscapeString(c.opts.color(i))
+		if v == total {
+			fmt.Fprintf(c.b, `<circle cx="%s" cy="%s" r="%s" fill="%s"><title>%s</title></circle>`,
+				num(cx), num(cy), num(r), color, num(v))
+			c.b.WriteString("\n")
+			continue
+		}
+		sweep := v / total * 2 * math.Pi
+		x1, y1 := cx+r*math.Cos(angle), cy+r*math.Sin(angle)
+		angle += sweep
+		x2, y2 := cx+r*math.Cos(angle), cy+r*math.Sin(angle)
+		large := 0
+		if sweep > math.Pi {
+			large = 1
+		}
+		fmt.Fprintf(c.b, `<path d="M%s,%s L%s,%s A%s,%s 0 %d 1 %s,%s Z" fill="%s"><title>%s</title></path>`,
+			num(cx), num(cy), num(x1), num(y1), num(r), num(r), large, num(x2), num(y2), color, num(v))
+		c.b.WriteString("\n")
+	}
+
+	if labelled {
+		lx := a.x + pieW + margin
+		for i := range values {
+			ly := a.y + float64(i)*(fontSize+margin/2)
+			fmt.Fprintf(c.b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+				num(lx), num(ly), num(fontSize), num(fontSize), html.EscapeString(c.opts.color(i)))
+			c.b.WriteString("\n")
+			c.text(lx+fontSize+margin/4, ly+fontSize-1, "start", opts.label(i), "")
+		}
+	}
+	return c.String(), nil
+}
@@ -0,0 +1,84 @@
+package plot
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertValidSVG checks that the output is well-formed XML
+func assertValidSVG(t *testing.T, s string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			assert.Equal(t, "EOF", err.Error())
+			return
+		}
+	}
+}
+
+func TestBar(t *testing.T) {
+	out, err := Bar([]float64{1, 3}, Options{Width: 100, Height: 100})
+	require.NoError(t, err)
+	assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100" viewBox="0 0 100 100" font-family="sans-serif" font-size="12">
+<rect x="23" y="60" width="24" height="20" fill="#4e79a7"><title>1</title></rect>
+<rect x="53" y="20" width="24" height="60" fill="#f28e2b"><title>3</title></rect>
+<line x1="20" y1="80" x2="80" y2="80" stroke="#333"/>
+</svg>
+`, out)
+
+	out, err = Bar([]float64{-2, 4, 0}, Options{
+		Title:  "A & B",
+		Labels: []string{"<x>", "y"},
+		Colors: []string{"red"},
+	})
+	require.NoError(t, err)
+	assertValidSVG(t, out)
+	assert.Contains(t, out, `>A &amp; B</text>`)
+	assert.Contains(t, out, `>&lt;x&gt;</text>`)
+	assert.Equal(t, 3, strings.Count(out, `fill="red"`))
+
+	out, err = Bar(nil, Options{})
+	require.NoError(t, err)
+	assertValidSVG(t, out)
+
+	_, err = Bar([]float64{math.NaN()}, Options{})
+	assert.Error(t, err)
+}
+
+func TestLine(t *testing.T) {
+	out, err := Line([]float64{0, 2, 1}, Options{Width: 100, Height: 100})
+	require.NoError(t, err)
+	assertValidSVG(t, out)
+	assert.Contains(t, out, `<polyline points="20,80 50,20 80,50"`)
+	assert.Equal(t, 3, strings.Count(out, "<circle"))
+
+	out, err = Line([]float64{5}, Options{Width: 100, Height: 100, Labels: []string{"only"}})
+	require.NoError(t, err)
+	assertValidSVG(t, out)
+	assert.Contains(t, out, `<polyline points="50,`)
+	assert.Contains(t, out, `>only</text>`)
+}
+
+func TestPie(t *testing.T) {
+	out, err := Pie([]float64{1, 1}, Options{Width: 100, Height: 100})
+	require.NoError(t, err)
+	assertValidSVG(t, out)
+	assert.Contains(t, out, `<path d="M50,50 L50,20 A30,30 0 0 1 50,80 Z" fill="#4e79a7">`)
+	assert.Contains(t, out, `<path d="M50,50 L50,80 A30,30 0 0 1 50,20 Z" fill="#f28e2b">`)
+
+	out, err = Pie([]float64{3, 0}, Options{Labels: []string{"a", "b"}})
+	require.NoError(t, err)
+	assertValidSVG(t, out)
+	assert.Equal(t, 1, strings.Count(out, "<circle"))
+	assert.Contains(t, out, `>b</text>`)
+
+	_, err = Pie([]float64{1, -1}, Options{})
+	assert.Error(t, err)
+}
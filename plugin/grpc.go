@@ -0,0 +1,234 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The service is described in plugin.proto. It only uses protobuf's
+// well-known types, so the descriptor is written by hand here rather than
+// generated.
+const serviceName = "gomplate.plugin.v1.Functions"
+
+type functionsServer interface {
+	funcs(ctx context.Context, in *emptypb.Empty) (*structpb.ListValue, error)
+	call(in *structpb.Struct, stream grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*functionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Funcs", Handler: funcsHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Call", Handler: callHandler, ServerStreams: true},
+	},
+	Metadata: "plugin.proto",
+}
+
+func funcsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := &emptypb.Empty{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(functionsServer).funcs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Funcs"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(functionsServer).funcs(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func callHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := &structpb.Struct{}
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(functionsServer).call(in, stream)
+}
+
+// server - the plugin side, calling the implementation
+type server struct {
+	impl Functions
+}
+
+func (s *server) funcs(ctx context.Context, _ *emptypb.Empty) (*structpb.ListValue, error) {
+	funcs, err := s.impl.Funcs(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	out := &structpb.ListValue{}
+	for _, f := range funcs {
+		params := make([]interface{}, len(f.Params))
+		for i, p := range f.Params {
+			params[i] = string(p)
+		}
+		spec, err := structpb.NewStruct(map[string]interface{}{
+			"name":     f.Name,
+			"doc":      f.Doc,
+			"params":   params,
+			"variadic": f.Variadic,
+			"stream":   f.Stream,
+		})
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		out.Values = append(out.Values, structpb.NewStructValue(spec))
+	}
+	return out, nil
+}
+
+func (s *server) call(in *structpb.Struct, stream grpc.ServerStream) error {
+	name := in.Fields["name"].GetStringValue()
+	args := in.Fields["args"].GetListValue().AsSlice()
+
+	err := s.impl.Call(stream.Context(), name, args, func(v interface{}) error {
+		out, err := toValue(v)
+		if err != nil {
+			return fmt.Errorf("invalid result from %s: %w", name, err)
+		}
+		return stream.SendMsg(out)
+	})
+	if err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return nil
+}
+
+// client - the gomplate side, calling the plugin
+type client struct {
+	cc *grpc.ClientConn
+
+	// the functions listed by the plugin, for checking arguments
+	funcs map[string]Func
+	mu    sync.RWMutex
+}
+
+func (c *client) Funcs(ctx context.Context) ([]Func, error) {
+	out := &structpb.ListValue{}
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/Funcs", &emptypb.Empty{}, out); err != nil {
+		return nil, fromStatus(err)
+	}
+
+	funcs := make([]Func, len(out.Values))
+	for i, v := range out.Values {
+		spec := v.GetStructValue().GetFields()
+		f := Func{
+			Name:     spec["name"].GetStringValue(),
+			Doc:      spec["doc"].GetStringValue(),
+			Variadic: spec["variadic"].GetBoolValue(),
+			Stream:   spec["stream"].GetBoolValue(),
+		}
+		if f.Name == "" {
+			return nil, fmt.Errorf("plugin listed a function with no name")
+		}
+		for _, p := range spec["params"].GetListValue().GetValues() {
+			t := Type(p.GetStringValue())
+			switch t {
+			case Any, String, Number, Bool, List, Map:
+			default:
+				return nil, fmt.Errorf("invalid type %q for a parameter of %s", t, f.Name)
+			}
+			f.Params = append(f.Params, t)
+		}
+		funcs[i] = f
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range funcs {
+		c.funcs[f.Name] = f
+	}
+	return funcs, nil
+}
+
+func (c *client) Call(ctx context.Context, name string, args []interface{}, send func(interface{}) error) error {
+	list := &structpb.ListValue{Values: make([]*structpb.Value, len(args))}
+	for i, a := range args {
+		v, err := toValue(a)
+		if err != nil {
+			return fmt.Errorf("invalid argument %d for %s: %w", i+1, name, err)
+		}
+		list.Values[i] = v
+	}
+
+	c.mu.RLock()
+	f, ok := c.funcs[name]
+	c.mu.RUnlock()
+	if ok {
+		if err := f.checkArgs(list.AsSlice()); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/"+serviceName+"/Call")
+	if err != nil {
+		return fromStatus(err)
+	}
+	req := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name": structpb.NewStringValue(name),
+		"args": structpb.NewListValue(list),
+	}}
+	if err := stream.SendMsg(req); err != nil {
+		return fromStatus(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fromStatus(err)
+	}
+
+	for {
+		v := &structpb.Value{}
+		err := stream.RecvMsg(v)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fromStatus(err)
+		}
+		if err := send(v.AsInterface()); err != nil {
+			return err
+		}
+	}
+}
+
+// toValue converts the value to a protobuf Value. Values that structpb can't
+// convert directly (structs, typed slices and maps, etc) are converted through
+// JSON.
+func toValue(v interface{}) (*structpb.Value, error) {
+	if out, err := structpb.NewValue(v); err == nil {
+		return out, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(n)
+}
+
+// fromStatus converts gRPC status errors to plain errors with the message
+func fromStatus(err error) error {
+	if s, ok := status.FromError(err); ok {
+		return errors.New(s.Message())
+	}
+	return err
+}
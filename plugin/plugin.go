@@ -0,0 +1,167 @@
+// Package plugin implements gomplate's gRPC plugin protocol, for providing
+// template functions from an external process.
+//
+// Unlike exec plugins, which run a command every time the function is called,
+// a gRPC plugin is started once (with HashiCorp's go-plugin), lists the
+// functions it provides, and serves calls to them until gomplate exits.
+// Arguments are typed, and functions can stream multiple results.
+//
+// To write a plugin in Go, implement Functions and call Serve from main:
+//
+//	func main() {
+//		plugin.Serve(&myFuncs{})
+//	}
+//
+// Plugins in other languages can implement the service in plugin.proto, and
+// follow go-plugin's handshake, using the values in Handshake.
+//
+// The package also implements the simpler stdio protocol used by persistent
+// exec plugins - see ServeStdio.
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// ProtocolVersion - the version of the plugin protocol. Plugins must speak the
+// same version as gomplate.
+const ProtocolVersion = 1
+
+// Name - the name the functions are dispensed with
+const Name = "funcs"
+
+// Handshake - the go-plugin handshake configuration. The magic cookie isn't a
+// security measure, it's just to stop plugins being run by mistake.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  ProtocolVersion,
+	MagicCookieKey:   "GOMPLATE_PLUGIN",
+	MagicCookieValue: "6f5e7cf2-4bd6-4b3c-9a54-ad1ba2b2d1c1",
+}
+
+// PluginSet - the plugins gomplate can dispense, for go-plugin's client
+// configuration
+var PluginSet = goplugin.PluginSet{Name: &GRPCPlugin{}}
+
+// Type - the type of a function parameter
+type Type string
+
+// Parameter types. Numbers are always received as float64, lists as
+// []interface{}, and maps as map[string]interface{}.
+const (
+	Any    Type = "any"
+	String Type = "string"
+	Number Type = "number"
+	Bool   Type = "bool"
+	List   Type = "list"
+	Map    Type = "map"
+)
+
+// Func - a function provided by a plugin
+type Func struct {
+	// Name - the name of the template function
+	Name string
+	// Doc - a description of the function
+	Doc string
+	// Params - the types of the function's parameters
+	Params []Type
+	// Variadic - whether the last parameter can be repeated (or omitted). A
+	// variadic function with no parameters accepts any arguments.
+	Variadic bool
+	// Stream - whether the function can send any number of results, which
+	// are returned to the template as an array. Otherwise the function must
+	// send exactly one result.
+	Stream bool
+}
+
+// Functions - the interface implemented by plugins, and by the client
+// gomplate uses to call them
+type Functions interface {
+	// Funcs lists the functions the plugin provides
+	Funcs(ctx context.Context) ([]Func, error)
+	// Call calls the named function, with send called for each result
+	Call(ctx context.Context, name string, args []interface{}, send func(interface{}) error) error
+}
+
+// GRPCPlugin - the go-plugin Plugin for serving (or calling) Functions over
+// gRPC
+type GRPCPlugin struct {
+	goplugin.NetRPCUnsupportedPlugin
+
+	// Impl - the plugin's functions (only needed when serving)
+	Impl Functions
+}
+
+var _ goplugin.GRPCPlugin = (*GRPCPlugin)(nil)
+
+// GRPCServer - registers the Functions service
+func (p *GRPCPlugin) GRPCServer(_ *goplugin.GRPCBroker, s *grpc.Server) error {
+	s.RegisterService(&serviceDesc, &server{impl: p.Impl})
+	return nil
+}
+
+// GRPCClient - returns a Functions client
+func (p *GRPCPlugin) GRPCClient(_ context.Context, _ *goplugin.GRPCBroker, cc *grpc.ClientConn) (interface{}, error) {
+	return &client{cc: cc, funcs: map[string]Func{}}, nil
+}
+
+// Serve serves the functions as a plugin. It's meant to be called from a
+// plugin's main function, and doesn't return.
+func Serve(impl Functions) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         goplugin.PluginSet{Name: &GRPCPlugin{Impl: impl}},
+		GRPCServer:      goplugin.DefaultGRPCServer,
+	})
+}
+
+// checkArgs checks the arguments' number and types against the function's
+// parameters
+func (f Func) checkArgs(args []interface{}) error {
+	n := len(f.Params)
+	if f.Variadic && n == 0 {
+		return nil
+	}
+	if f.Variadic && len(args) < n-1 {
+		return fmt.Errorf("wrong number of args for %s: want at least %d, got %d", f.Name, n-1, len(args))
+	}
+	if !f.Variadic && len(args) != n {
+		return fmt.Errorf("wrong number of args for %s: want %d, got %d", f.Name, n, len(args))
+	}
+
+	for i, a := range args {
+		t := f.Params[n-1]
+		if i < n {
+			t = f.Params[i]
+		}
+		if !t.matches(a) {
+			return fmt.Errorf("wrong type for argument %d of %s: want %s, got %s", i+1, f.Name, t, typeOf(a))
+		}
+	}
+	return nil
+}
+
+// matches - whether the (normalized) value is of this type
+func (t Type) matches(v interface{}) bool {
+	return t == Any || t == typeOf(v)
+}
+
+// typeOf - the type of a normalized value, or "null"
+func typeOf(v interface{}) Type {
+	switch v.(type) {
+	case string:
+		return String
+	case float64:
+		return Number
+	case bool:
+		return Bool
+	case []interface{}:
+		return List
+	case map[string]interface{}:
+		return Map
+	}
+	return "null"
+}
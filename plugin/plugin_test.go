@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFuncs struct{}
+
+func (testFuncs) Funcs(_ context.Context) ([]Func, error) {
+	return []Func{
+		{Name: "upper", Doc: "upper-cases a string", Params: []Type{String}},
+		{Name: "repeat", Params: []Type{String, Number}, Stream: true},
+		{Name: "join", Params: []Type{String, Any}, Variadic: true},
+	}, nil
+}
+
+func (testFuncs) Call(_ context.Context, name string, args []interface{}, send func(interface{}) error) error {
+	switch name {
+	case "upper":
+		return send(strings.ToUpper(args[0].(string)))
+	case "repeat":
+		for i := 0; i < int(args[1].(float64)); i++ {
+			if err := send(args[0]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "join":
+		parts := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			parts[i] = fmt.Sprint(a)
+		}
+		return send(strings.Join(parts, args[0].(string)))
+	}
+	return fmt.Errorf("no such function %q", name)
+}
+
+func testClient(t *testing.T) Functions {
+	t.Helper()
+
+	c, _ := goplugin.TestPluginGRPCConn(t, map[string]goplugin.Plugin{
+		Name: &GRPCPlugin{Impl: testFuncs{}},
+	})
+	t.Cleanup(func() { c.Close() })
+
+	raw, err := c.Dispense(Name)
+	require.NoError(t, err)
+	return raw.(Functions)
+}
+
+func call(ctx context.Context, f Functions, name string, args ...interface{}) ([]interface{}, error) {
+	out := []interface{}{}
+	err := f.Call(ctx, name, args, func(v interface{}) error {
+		out = append(out, v)
+		return nil
+	})
+	return out, err
+}
+
+func TestFuncs(t *testing.T) {
+	f := testClient(t)
+
+	funcs, err := f.Funcs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Func{
+		{Name: "upper", Doc: "upper-cases a string", Params: []Type{String}},
+		{Name: "repeat", Params: []Type{String, Number}, Stream: true},
+		{Name: "join", Params: []Type{String, Any}, Variadic: true},
+	}, funcs)
+}
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+	f := testClient(t)
+
+	// arguments are checked against the listed functions
+	_, err := f.Funcs(ctx)
+	require.NoError(t, err)
+
+	out, err := call(ctx, f, "upper", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"HELLO"}, out)
+
+	out, err = call(ctx, f, "repeat", "a", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "a", "a"}, out)
+
+	out, err = call(ctx, f, "join", ",", 1, true, []string{"x"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"1,true,[x]"}, out)
+
+	out, err = call(ctx, f, "join", ",")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{""}, out)
+
+	_, err = call(ctx, f, "upper", 42)
+	assert.EqualError(t, err, "wrong type for argument 1 of upper: want string, got number")
+
+	_, err = call(ctx, f, "upper")
+	assert.EqualError(t, err, "wrong number of args for upper: want 1, got 0")
+
+	_, err = call(ctx, f, "join")
+	assert.EqualError(t, err, "wrong number of args for join: want at least 1, got 0")
+
+	_, err = call(ctx, f, "bogus")
+	assert.EqualError(t, err, `no such function "bogus"`)
+}
+
+func TestCheckArgs(t *testing.T) {
+	f := Func{Name: "f", Variadic: true}
+	assert.NoError(t, f.checkArgs(nil))
+	assert.NoError(t, f.checkArgs([]interface{}{"a", 1.0, nil}))
+
+	f = Func{Name: "f", Params: []Type{List, Map, Bool}}
+	assert.NoError(t, f.checkArgs([]interface{}{[]interface{}{}, map[string]interface{}{}, false}))
+	assert.EqualError(t, f.checkArgs([]interface{}{[]interface{}{}, nil, false}),
+		"wrong type for argument 2 of f: want map, got null")
+}
+
+func TestToValue(t *testing.T) {
+	v, err := toValue(map[string][]int{"a": {1, 2}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{1.0, 2.0}}, v.AsInterface())
+
+	_, err = toValue(make(chan int))
+	assert.Error(t, err)
+}
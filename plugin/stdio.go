@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The stdio protocol is used by persistent exec plugins, which are started
+// once and called over stdin and stdout. It's simpler to implement than the
+// gRPC protocol, but has no typed arguments or streaming.
+//
+// Each message is a JSON object, preceded by its length in bytes, as a 32-bit
+// big-endian unsigned integer. When it starts, the plugin sends a StdioHello.
+// gomplate then sends a StdioRequest for each call, which the plugin must
+// answer with a StdioResponse, in order. When gomplate is finished, it closes
+// the plugin's stdin, and the plugin should exit.
+
+// StdioProtocol - the name of the stdio protocol, sent in the StdioHello
+const StdioProtocol = "gomplate-stdio"
+
+// StdioProtocolVersion - the version of the stdio protocol
+const StdioProtocolVersion = 1
+
+// MaxMessageSize - the largest stdio message that will be read
+const MaxMessageSize = 64 << 20
+
+// StdioHello - the handshake sent by the plugin when it starts
+type StdioHello struct {
+	Protocol string `json:"protocol"`
+	Version  int    `json:"version"`
+}
+
+// StdioRequest - a call to the plugin's function
+type StdioRequest struct {
+	// Input - the final argument, when the plugin's pipe option is set
+	Input *string       `json:"input,omitempty"`
+	Args  []interface{} `json:"args"`
+	ID    uint64        `json:"id"`
+}
+
+// StdioResponse - the result of a call. Error is set when the call failed.
+type StdioResponse struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	ID     uint64      `json:"id"`
+}
+
+// WriteMessage writes the value as a length-prefixed JSON message
+func WriteMessage(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(b) > MaxMessageSize {
+		return fmt.Errorf("message too large (%d bytes)", len(b))
+	}
+
+	msg := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(msg, uint32(len(b)))
+	copy(msg[4:], b)
+	_, err = w.Write(msg)
+	return err
+}
+
+// ReadMessage reads a length-prefixed JSON message into the value. It returns
+// io.EOF when there are no more messages.
+func ReadMessage(r io.Reader, v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MaxMessageSize {
+		return fmt.Errorf("message too large (%d bytes)", n)
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// StdioFunc - the function served by a persistent plugin. The input is set
+// when the plugin's pipe option is set.
+type StdioFunc func(args []interface{}, input *string) (interface{}, error)
+
+// ServeStdio serves the function as a persistent exec plugin, on stdin and
+// stdout. It returns when stdin is closed.
+func ServeStdio(fn StdioFunc) error {
+	w := bufio.NewWriter(os.Stdout)
+	return serveStdio(bufio.NewReader(os.Stdin), w, fn)
+}
+
+type flushWriter interface {
+	io.Writer
+	Flush() error
+}
+
+func serveStdio(r io.Reader, w flushWriter, fn StdioFunc) error {
+	err := WriteMessage(w, StdioHello{Protocol: StdioProtocol, Version: StdioProtocolVersion})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		req := StdioRequest{}
+		err := ReadMessage(r, &req)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		resp := StdioResponse{ID: req.ID}
+		resp.Result, err = fn(req.Args, req.Input)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := WriteMessage(w, resp); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
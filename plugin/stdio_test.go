@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteMessage(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteMessage(buf, map[string]int{"a": 1}))
+	assert.Equal(t, "\x00\x00\x00\x07{\"a\":1}", buf.String())
+
+	out := map[string]int{}
+	require.NoError(t, ReadMessage(buf, &out))
+	assert.Equal(t, map[string]int{"a": 1}, out)
+
+	assert.ErrorIs(t, ReadMessage(buf, &out), io.EOF)
+
+	err := ReadMessage(strings.NewReader("\x00\x00\x00\x07{\"a\""), &out)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	err = ReadMessage(strings.NewReader("\xff\xff\xff\xff"), &out)
+	assert.EqualError(t, err, "message too large (4294967295 bytes)")
+
+	assert.Error(t, WriteMessage(buf, make(chan int)))
+}
+
+func TestServeStdio(t *testing.T) {
+	in := &bytes.Buffer{}
+	input := "piped"
+	require.NoError(t, WriteMessage(in, StdioRequest{ID: 1, Args: []interface{}{"a", 1}}))
+	require.NoError(t, WriteMessage(in, StdioRequest{ID: 2, Args: []interface{}{}, Input: &input}))
+	require.NoError(t, WriteMessage(in, StdioRequest{ID: 3, Args: []interface{}{"fail"}}))
+
+	out := &bytes.Buffer{}
+	err := serveStdio(in, bufio.NewWriter(out), func(args []interface{}, input *string) (interface{}, error) {
+		if input != nil {
+			return *input, nil
+		}
+		if args[0] == "fail" {
+			return nil, errors.New("failed")
+		}
+		return args, nil
+	})
+	require.NoError(t, err)
+
+	hello := StdioHello{}
+	require.NoError(t, ReadMessage(out, &hello))
+	assert.Equal(t, StdioHello{Protocol: StdioProtocol, Version: StdioProtocolVersion}, hello)
+
+	resps := []StdioResponse{}
+	for {
+		resp := StdioResponse{}
+		if err := ReadMessage(out, &resp); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+		resps = append(resps, resp)
+	}
+	assert.Equal(t, []StdioResponse{
+		{ID: 1, Result: []interface{}{"a", 1.0}},
+		{ID: 2, Result: "piped"},
+		{ID: 3, Error: "failed"},
+	}, resps)
+}
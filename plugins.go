@@ -1,6 +1,7 @@
 package gomplate
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
@@ -10,33 +11,49 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/hairyhenderson/gomplate/v3/conv"
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	gplugin "github.com/hairyhenderson/gomplate/v3/plugin"
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
 )
 
 // bindPlugins creates custom plugin functions for each plugin specified by
 // the config, and adds them to the given funcMap. Uses the configuration's
 // PluginTimeout as the default plugin Timeout. Errors if a function name is
 // duplicated.
+//
+// gRPC plugins are started here, and bind all the functions they provide.
+// They're stopped by the cleanup hooks.
 func bindPlugins(ctx context.Context, cfg *config.Config, funcMap template.FuncMap) error {
 	for k, v := range cfg.Plugins {
-		if _, ok := funcMap[k]; ok {
-			return fmt.Errorf("function %q is already bound, and can not be overridden", k)
-		}
-
 		// default the timeout to the one in the config
 		timeout := cfg.PluginTimeout
 		if v.Timeout != 0 {
 			timeout = v.Timeout
 		}
 
+		if v.Protocol == "grpc" {
+			err := bindGRPCPlugin(ctx, k, v.Cmd, timeout, cfg.Stderr, funcMap)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := funcMap[k]; ok {
+			return fmt.Errorf("function %q is already bound, and can not be overridden", k)
+		}
+
 		funcMap[k] = PluginFunc(ctx, v.Cmd, PluginOpts{
-			Timeout: timeout,
-			Pipe:    v.Pipe,
-			Stderr:  cfg.Stderr,
+			Timeout:    timeout,
+			Pipe:       v.Pipe,
+			Persistent: v.Persistent,
+			Stderr:     cfg.Stderr,
 		})
 	}
 
@@ -56,6 +73,13 @@ type PluginOpts struct {
 	// Pipe indicates whether the last argument should be piped to the plugin's
 	// stdin (true) or processed as a commandline argument (false)
 	Pipe bool
+
+	// Persistent indicates whether the plugin should be started once and
+	// called over stdin and stdout, with the plugin package's stdio protocol,
+	// rather than run for every call. The process is stopped by gomplate's
+	// cleanup hooks, when rendering is finished. With Pipe, the last argument
+	// is sent as the request's input.
+	Persistent bool
 }
 
 // PluginFunc creates a template function that runs an external process - either
@@ -71,6 +95,17 @@ func PluginFunc(ctx context.Context, cmd string, opts PluginOpts) func(...interf
 		stderr = os.Stderr
 	}
 
+	if opts.Persistent {
+		p := &persistentPlugin{
+			ctx:     ctx,
+			path:    cmd,
+			timeout: timeout,
+			pipe:    opts.Pipe,
+			stderr:  stderr,
+		}
+		return p.call
+	}
+
 	plugin := &plugin{
 		ctx:     ctx,
 		path:    cmd,
@@ -173,3 +208,246 @@ func (p *plugin) run(args ...interface{}) (interface{}, error) {
 
 	return outBuf.String(), err
 }
+
+// shutdownGrace - how long a persistent plugin has to exit once its stdin is
+// closed, before it's killed
+const shutdownGrace = 2 * time.Second
+
+// persistentPlugin represents a custom function served by a long-running
+// process, which is spoken to with the stdio protocol. The process is started
+// on the first call, and restarted if it exits, or a call fails or times out.
+type persistentPlugin struct {
+	ctx     context.Context
+	stderr  io.Writer
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	path    string
+	timeout time.Duration
+	id      uint64
+	mu      sync.Mutex
+	pipe    bool
+}
+
+func (p *persistentPlugin) call(args ...interface{}) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+
+	if p.cmd != nil {
+		select {
+		case <-p.exited:
+			p.stop()
+		default:
+		}
+	}
+	if p.cmd == nil {
+		if err := p.start(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	p.id++
+	req := gplugin.StdioRequest{ID: p.id, Args: args}
+	if p.pipe && len(args) > 0 {
+		in := conv.ToString(args[len(args)-1])
+		req.Input = &in
+		req.Args = args[:len(args)-1]
+	}
+	if req.Args == nil {
+		req.Args = []interface{}{}
+	}
+
+	resp := gplugin.StdioResponse{}
+	err := p.exchange(ctx, func() error {
+		if err := gplugin.WriteMessage(p.stdin, req); err != nil {
+			return err
+		}
+		return gplugin.ReadMessage(p.stdout, &resp)
+	})
+	if err == nil && resp.ID != req.ID {
+		err = fmt.Errorf("plugin %s responded to request %d, expected %d", p.path, resp.ID, req.ID)
+	}
+	if err != nil {
+		// the plugin's state is unknown, so it's restarted on the next call
+		p.stop()
+		return nil, err
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s failed: %s", p.path, resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// start starts the plugin process, and waits for its handshake
+func (p *persistentPlugin) start(ctx context.Context) error {
+	name, a := (&plugin{path: p.path}).buildCommand(nil)
+	c := exec.Command(name, a...)
+	c.Stderr = p.stderr
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", p.path, err)
+	}
+
+	p.cmd, p.stdin, p.stdout = c, stdin, bufio.NewReader(stdout)
+	p.exited = make(chan struct{})
+	go func(exited chan struct{}) {
+		_ = c.Wait()
+		close(exited)
+	}(p.exited)
+	addCleanupHook(p.shutdown)
+
+	hello := gplugin.StdioHello{}
+	err = p.exchange(ctx, func() error {
+		return gplugin.ReadMessage(p.stdout, &hello)
+	})
+	if err == nil && (hello.Protocol != gplugin.StdioProtocol || hello.Version != gplugin.StdioProtocolVersion) {
+		err = fmt.Errorf("handshake was for protocol %s version %d, expected %s version %d",
+			hello.Protocol, hello.Version, gplugin.StdioProtocol, gplugin.StdioProtocolVersion)
+	}
+	if err != nil {
+		p.stop()
+		return fmt.Errorf("failed to start plugin %s: %w", p.path, err)
+	}
+	return nil
+}
+
+// exchange runs fn, which talks to the plugin, killing the plugin if it
+// doesn't complete before the context is done
+func (p *persistentPlugin) exchange(ctx context.Context, fn func() error) error {
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- fn()
+	}()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			return fmt.Errorf("plugin %s failed: %w", p.path, err)
+		}
+		return nil
+	case <-ctx.Done():
+		// killing the plugin unblocks fn
+		_ = p.cmd.Process.Kill()
+		<-errs
+		return fmt.Errorf("plugin timed out after %v: %w", time.Since(start), ctx.Err())
+	}
+}
+
+// stop closes the plugin's stdin and waits for it to exit, killing it if it
+// takes too long
+func (p *persistentPlugin) stop() {
+	if p.cmd == nil {
+		return
+	}
+
+	_ = p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(shutdownGrace):
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+	}
+	p.cmd = nil
+}
+
+// shutdown stops the plugin, if it's running
+func (p *persistentPlugin) shutdown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stop()
+}
+
+// bindGRPCPlugin starts the named gRPC plugin, and binds the functions it
+// provides
+func bindGRPCPlugin(ctx context.Context, name, cmd string, timeout time.Duration, stderr io.Writer, funcMap template.FuncMap) error {
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	c, a := (&plugin{path: cmd}).buildCommand(nil)
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig:  gplugin.Handshake,
+		Plugins:          gplugin.PluginSet,
+		Cmd:              exec.Command(c, a...),
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+		Stderr:           stderr,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:   "plugin." + name,
+			Output: stderr,
+			Level:  hclog.Warn,
+		}),
+	})
+	addCleanupHook(client.Kill)
+
+	rpc, err := client.Client()
+	if err != nil {
+		return fmt.Errorf("failed to start plugin %q: %w", name, err)
+	}
+	raw, err := rpc.Dispense(gplugin.Name)
+	if err != nil {
+		return fmt.Errorf("failed to start plugin %q: %w", name, err)
+	}
+
+	return bindPluginFuncs(ctx, name, raw.(gplugin.Functions), timeout, funcMap)
+}
+
+// bindPluginFuncs binds the functions listed by a gRPC plugin
+func bindPluginFuncs(ctx context.Context, name string, funcs gplugin.Functions, timeout time.Duration, funcMap template.FuncMap) error {
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	lctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	specs, err := funcs.Funcs(lctx)
+	if err != nil {
+		return fmt.Errorf("failed to list functions of plugin %q: %w", name, err)
+	}
+
+	for _, f := range specs {
+		if _, ok := funcMap[f.Name]; ok {
+			return fmt.Errorf("function %q (from plugin %q) is already bound, and can not be overridden", f.Name, name)
+		}
+		funcMap[f.Name] = grpcPluginFunc(ctx, funcs, f, timeout)
+	}
+	return nil
+}
+
+// grpcPluginFunc creates a template function that calls a gRPC plugin's
+// function. Streaming functions return an array of all their results.
+func grpcPluginFunc(ctx context.Context, funcs gplugin.Functions, f gplugin.Func, timeout time.Duration) func(...interface{}) (interface{}, error) {
+	return func(args ...interface{}) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		results := []interface{}{}
+		err := funcs.Call(ctx, f.Name, args, func(v interface{}) error {
+			results = append(results, v)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("plugin function %s failed: %w", f.Name, err)
+		}
+
+		if f.Stream {
+			return results, nil
+		}
+		if len(results) != 1 {
+			return nil, fmt.Errorf("plugin function %s returned %d results, expected 1", f.Name, len(results))
+		}
+		return results[0], nil
+	}
+}
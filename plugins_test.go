@@ -11,9 +11,42 @@ import (
 	"time"
 
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	gplugin "github.com/hairyhenderson/gomplate/v3/plugin"
+	goplugin "github.com/hashicorp/go-plugin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// testStdioPluginEnv - set to run the test binary as a persistent plugin
+const testStdioPluginEnv = "GOMPLATE_TEST_STDIO_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testStdioPluginEnv) == "1" {
+		err := gplugin.ServeStdio(func(args []interface{}, input *string) (interface{}, error) {
+			if input != nil {
+				return strings.ToUpper(*input), nil
+			}
+			switch args[0] {
+			case "pid":
+				return os.Getpid(), nil
+			case "sleep":
+				time.Sleep(time.Second)
+			case "exit":
+				os.Exit(1)
+			case "fail":
+				return nil, fmt.Errorf("failed")
+			}
+			return args, nil
+		})
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
 func TestBindPlugins(t *testing.T) {
 	ctx := context.Background()
 	fm := template.FuncMap{}
@@ -33,6 +66,127 @@ func TestBindPlugins(t *testing.T) {
 	assert.ErrorContains(t, err, "already bound")
 }
 
+type testPluginFuncs struct{}
+
+func (testPluginFuncs) Funcs(_ context.Context) ([]gplugin.Func, error) {
+	return []gplugin.Func{
+		{Name: "greet", Params: []gplugin.Type{gplugin.String}},
+		{Name: "count", Params: []gplugin.Type{gplugin.Number}, Stream: true},
+		{Name: "nothing"},
+	}, nil
+}
+
+func (testPluginFuncs) Call(_ context.Context, name string, args []interface{}, send func(interface{}) error) error {
+	switch name {
+	case "greet":
+		return send("hello, " + args[0].(string))
+	case "count":
+		for i := 1; i <= int(args[0].(float64)); i++ {
+			if err := send(i); err != nil {
+				return err
+			}
+		}
+	case "nothing":
+	}
+	return nil
+}
+
+func TestBindPluginFuncs(t *testing.T) {
+	ctx := context.Background()
+
+	c, _ := goplugin.TestPluginGRPCConn(t, map[string]goplugin.Plugin{
+		gplugin.Name: &gplugin.GRPCPlugin{Impl: testPluginFuncs{}},
+	})
+	defer c.Close()
+	raw, err := c.Dispense(gplugin.Name)
+	require.NoError(t, err)
+
+	fm := template.FuncMap{}
+	err = bindPluginFuncs(ctx, "test", raw.(gplugin.Functions), time.Second, fm)
+	require.NoError(t, err)
+
+	tmpl, err := template.New("t").Funcs(fm).Parse(`{{ greet "world" }} {{ range count 3 }}{{ . }}{{ end }}`)
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	require.NoError(t, tmpl.Execute(out, nil))
+	assert.Equal(t, "hello, world 123", out.String())
+
+	_, err = fm["greet"].(func(...interface{}) (interface{}, error))(42)
+	assert.EqualError(t, err, "plugin function greet failed: wrong type for argument 1 of greet: want string, got number")
+
+	_, err = fm["nothing"].(func(...interface{}) (interface{}, error))()
+	assert.EqualError(t, err, "plugin function nothing returned 0 results, expected 1")
+
+	err = bindPluginFuncs(ctx, "test", raw.(gplugin.Functions), time.Second, fm)
+	assert.ErrorContains(t, err, "already bound")
+}
+
+func TestBindGRPCPlugin_NotAPlugin(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	err := bindGRPCPlugin(context.Background(), "echo", "echo", time.Second, stderr, template.FuncMap{})
+	defer runCleanupHooks()
+	assert.ErrorContains(t, err, "failed to start plugin")
+}
+
+func TestPersistentPlugin(t *testing.T) {
+	t.Setenv(testStdioPluginEnv, "1")
+	defer runCleanupHooks()
+
+	stderr := &bytes.Buffer{}
+	f := PluginFunc(context.Background(), os.Args[0], PluginOpts{
+		Persistent: true,
+		Timeout:    500 * time.Millisecond,
+		Stderr:     stderr,
+	})
+
+	out, err := f("foo", 42, true)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"foo", 42.0, true}, out)
+
+	// the same process serves every call
+	pid, err := f("pid")
+	require.NoError(t, err)
+	pid2, err := f("pid")
+	require.NoError(t, err)
+	assert.Equal(t, pid, pid2)
+
+	_, err = f("fail")
+	assert.EqualError(t, err, "plugin "+os.Args[0]+" failed: failed")
+
+	// the plugin is restarted after it times out, or exits
+	_, err = f("sleep")
+	assert.ErrorContains(t, err, "plugin timed out")
+	pid2, err = f("pid")
+	require.NoError(t, err)
+	assert.NotEqual(t, pid, pid2)
+
+	_, err = f("exit")
+	assert.Error(t, err)
+	pid3, err := f("pid")
+	require.NoError(t, err)
+	assert.NotEqual(t, pid2, pid3)
+
+	assert.Equal(t, "", stderr.String())
+}
+
+func TestPersistentPlugin_Pipe(t *testing.T) {
+	t.Setenv(testStdioPluginEnv, "1")
+	defer runCleanupHooks()
+
+	f := PluginFunc(context.Background(), os.Args[0], PluginOpts{Persistent: true, Pipe: true})
+	out, err := f("foo", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, "BAR", out)
+}
+
+func TestPersistentPlugin_NoHandshake(t *testing.T) {
+	defer runCleanupHooks()
+
+	f := PluginFunc(context.Background(), "echo", PluginOpts{Persistent: true})
+	_, err := f("foo")
+	assert.ErrorContains(t, err, "failed to start plugin echo")
+}
+
 func TestBuildCommand(t *testing.T) {
 	ctx := context.Background()
 	data := []struct {
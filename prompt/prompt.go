@@ -0,0 +1,169 @@
+// Package prompt contains functions for interactively asking for values on
+// the terminal
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter asks questions on In, and writes prompts to Out.
+type Prompter struct {
+	In  io.Reader
+	Out io.Writer
+
+	// Interactive must be set for prompts to be shown - when it isn't,
+	// prompting is an error
+	Interactive bool
+
+	r *bufio.Reader
+}
+
+// Terminal returns a Prompter for the process's terminal. Prompts are
+// written to stderr, so they don't mix with rendered output on stdout.
+//
+// Prompting is disabled when stdin isn't a terminal, or when the CI
+// environment variable is set (as it is by most CI systems).
+func Terminal() *Prompter {
+	return &Prompter{
+		In:          os.Stdin,
+		Out:         os.Stderr,
+		Interactive: term.IsTerminal(int(os.Stdin.Fd())) && !isCI(),
+	}
+}
+
+func isCI() bool {
+	v, ok := os.LookupEnv("CI")
+	return ok && v != "false" && v != "0"
+}
+
+// String - ask for a string. An empty answer returns the default.
+func (p *Prompter) String(message, def string) (string, error) {
+	if err := p.check(message); err != nil {
+		return "", err
+	}
+	if def != "" {
+		fmt.Fprintf(p.Out, "%s [%s]: ", message, def)
+	} else {
+		fmt.Fprintf(p.Out, "%s: ", message)
+	}
+	answer, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// Password - ask for a secret. When reading from a terminal, the answer
+// isn't echoed. Empty answers are re-asked.
+func (p *Prompter) Password(message string) (string, error) {
+	if err := p.check(message); err != nil {
+		return "", err
+	}
+	for {
+		fmt.Fprintf(p.Out, "%s: ", message)
+		answer, err := p.readSecret()
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+	}
+}
+
+// Select - ask for one of the given choices, which can be picked by number
+// or by name. An empty answer returns the default, if there is one.
+// Invalid answers are re-asked.
+func (p *Prompter) Select(message string, choices []string, def string) (string, error) {
+	if len(choices) == 0 {
+		return "", fmt.Errorf("no choices given for %q", message)
+	}
+	defIndex := -1
+	if def != "" {
+		defIndex = indexOf(choices, def)
+		if defIndex < 0 {
+			return "", fmt.Errorf("default %q is not one of the choices for %q", def, message)
+		}
+	}
+	if err := p.check(message); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(p.Out, "%s\n", message)
+	for i, c := range choices {
+		fmt.Fprintf(p.Out, "  %d) %s\n", i+1, c)
+	}
+	for {
+		if defIndex >= 0 {
+			fmt.Fprintf(p.Out, "Choose 1-%d [%d]: ", len(choices), defIndex+1)
+		} else {
+			fmt.Fprintf(p.Out, "Choose 1-%d: ", len(choices))
+		}
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" && defIndex >= 0 {
+			return choices[defIndex], nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		if i := indexOf(choices, answer); i >= 0 {
+			return choices[i], nil
+		}
+		fmt.Fprintf(p.Out, "%q is not a valid choice\n", answer)
+	}
+}
+
+func (p *Prompter) check(message string) error {
+	if !p.Interactive {
+		return fmt.Errorf("can't prompt for %q: not running interactively (no terminal, or in CI)", message)
+	}
+	return nil
+}
+
+func (p *Prompter) readLine() (string, error) {
+	if p.r == nil {
+		p.r = bufio.NewReader(p.In)
+	}
+	line, err := p.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (p *Prompter) readSecret() (string, error) {
+	if f, ok := p.In.(*os.File); ok && (p.r == nil || p.r.Buffered() == 0) && term.IsTerminal(int(f.Fd())) {
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(p.Out)
+		if err != nil {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return p.readLine()
+}
+
+func indexOf(list []string, s string) int {
+	for i, e := range list {
+		if e == s {
+			return i
+		}
+	}
+	return -1
+}
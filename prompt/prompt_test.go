@@ -0,0 +1,94 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPrompter(in string) (*Prompter, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &Prompter{In: strings.NewReader(in), Out: out, Interactive: true}, out
+}
+
+func TestString(t *testing.T) {
+	p, out := testPrompter("my-app\n\n")
+	s, err := p.String("Name", "")
+	require.NoError(t, err)
+	assert.Equal(t, "my-app", s)
+	assert.Equal(t, "Name: ", out.String())
+
+	out.Reset()
+	s, err = p.String("Owner", "me")
+	require.NoError(t, err)
+	assert.Equal(t, "me", s)
+	assert.Equal(t, "Owner [me]: ", out.String())
+
+	// no more input
+	_, err = p.String("More", "")
+	assert.Error(t, err)
+
+	p, _ = testPrompter("last")
+	s, err = p.String("Name", "")
+	require.NoError(t, err)
+	assert.Equal(t, "last", s)
+}
+
+func TestPassword(t *testing.T) {
+	p, out := testPrompter("\n s3cret \n")
+	s, err := p.Password("Token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", s)
+	assert.Equal(t, "Token: Token: ", out.String())
+}
+
+func TestSelect(t *testing.T) {
+	choices := []string{"MIT", "Apache-2.0", "BSD-3-Clause"}
+
+	p, out := testPrompter("2\n")
+	s, err := p.Select("License", choices, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Apache-2.0", s)
+	assert.Equal(t, "License\n  1) MIT\n  2) Apache-2.0\n  3) BSD-3-Clause\nChoose 1-3: ", out.String())
+
+	p, out = testPrompter("4\nGPL\nBSD-3-Clause\n")
+	s, err = p.Select("License", choices, "MIT")
+	require.NoError(t, err)
+	assert.Equal(t, "BSD-3-Clause", s)
+	assert.Contains(t, out.String(), "Choose 1-3 [1]: \"4\" is not a valid choice\n")
+
+	p, _ = testPrompter("\n")
+	s, err = p.Select("License", choices, "MIT")
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", s)
+
+	_, err = p.Select("License", choices, "GPL")
+	assert.Error(t, err)
+	_, err = p.Select("License", nil, "")
+	assert.Error(t, err)
+}
+
+func TestNotInteractive(t *testing.T) {
+	p, out := testPrompter("answer\n")
+	p.Interactive = false
+
+	_, err := p.String("Name", "default")
+	assert.Error(t, err)
+	_, err = p.Password("Token")
+	assert.Error(t, err)
+	_, err = p.Select("License", []string{"MIT"}, "")
+	assert.Error(t, err)
+	assert.Empty(t, out.String())
+}
+
+func TestIsCI(t *testing.T) {
+	t.Setenv("CI", "true")
+	assert.True(t, isCI())
+	assert.False(t, Terminal().Interactive)
+
+	t.Setenv("CI", "false")
+	assert.False(t, isCI())
+}
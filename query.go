@@ -0,0 +1,171 @@
+package gomplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hairyhenderson/gomplate/v3/coll"
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/itchyny/gojq"
+	"github.com/jmespath/go-jmespath"
+)
+
+// QueryOptions - options for Query
+//
+// Experimental: subject to breaking changes before the next major release
+type QueryOptions struct {
+	// Expr - the query expression
+	Expr string
+	// Source - the datasource to query: an alias of a configured datasource,
+	// or a datasource URL (or file path, or "-" for Stdin)
+	Source string
+	// Lang - the query language: "jq" (the default), "jmespath", or
+	// "jsonpath"
+	Lang string
+	// From - the datasource's format (as accepted by convert). Defaults to the
+	// datasource's MIME type, as when it's read in a template.
+	From string
+	// JSON - print string results as JSON, rather than as raw strings
+	JSON bool
+
+	Stdout io.Writer
+}
+
+// Query reads a datasource and prints the results of querying it, one per
+// line. Strings are printed as-is (unless JSON is set), and other values as
+// JSON. Datasources are read as they are for templates, so they can be
+// configured with the usual datasource options.
+//
+// Experimental: subject to breaking changes before the next major release
+func Query(ctx context.Context, cfg *config.Config, o QueryOptions) error {
+	defer runCleanupHooks()
+
+	query, err := queryFunc(o.Lang, o.Expr)
+	if err != nil {
+		return err
+	}
+
+	cfg.ApplyDefaults()
+
+	// sources given on the command line are defined like --datasource, so
+	// that exec datasources can be queried
+	alias := o.Source
+	if _, ok := cfg.DataSources[alias]; !ok {
+		if _, ok := cfg.Context[alias]; !ok {
+			u, err := config.ParseSourceURL(o.Source)
+			if err != nil {
+				return fmt.Errorf("invalid datasource %q: %w", o.Source, err)
+			}
+			if cfg.DataSources == nil {
+				cfg.DataSources = map[string]config.DataSource{}
+			}
+			cfg.DataSources[alias] = config.DataSource{URL: u}
+		}
+	}
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	tr := NewRenderer(opts)
+	tr.data.Ctx = ctx
+
+	var in interface{}
+	if o.From != "" {
+		var s string
+		s, err = tr.data.Include(alias)
+		if err == nil {
+			in, err = data.Decode(o.From, []byte(s))
+		}
+	} else {
+		in, err = tr.data.Datasource(alias)
+	}
+	if err != nil {
+		return err
+	}
+
+	results, err := query(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	stdout := o.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	for _, r := range results {
+		if s, ok := r.(string); ok && !o.JSON {
+			_, err = fmt.Fprintln(stdout, s)
+		} else {
+			var b []byte
+			b, err = json.Marshal(r)
+			if err == nil {
+				_, err = fmt.Fprintln(stdout, string(b))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryFunc - parse the expression in the named language, returning a func
+// that runs it. jq expressions can produce any number of results, and the
+// others always produce one.
+func queryFunc(lang, expr string) (func(context.Context, interface{}) ([]interface{}, error), error) {
+	switch lang {
+	case "", "jq":
+		q, err := gojq.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse jq expression %q: %w", expr, err)
+		}
+		code, err := gojq.Compile(q)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't compile jq expression %q: %w", expr, err)
+		}
+		return func(ctx context.Context, in interface{}) ([]interface{}, error) {
+			// gojq only accepts the types encoding/json produces (YAML can
+			// produce int64s and times, for example)
+			out := []interface{}{}
+			iter := code.RunWithContext(ctx, jsonValue(in))
+			for {
+				v, ok := iter.Next()
+				if !ok {
+					return out, nil
+				}
+				if err, ok := v.(error); ok {
+					return nil, fmt.Errorf("jq query failed: %w", err)
+				}
+				out = append(out, v)
+			}
+		}, nil
+	case "jmespath":
+		jp, err := jmespath.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse JMESPath expression %q: %w", expr, err)
+		}
+		return func(_ context.Context, in interface{}) ([]interface{}, error) {
+			v, err := jp.Search(jsonValue(in))
+			if err != nil {
+				return nil, fmt.Errorf("JMESPath query failed: %w", err)
+			}
+			return []interface{}{v}, nil
+		}, nil
+	case "jsonpath":
+		return func(_ context.Context, in interface{}) ([]interface{}, error) {
+			v, err := coll.JSONPath(expr, in)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{v}, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown query language %q - must be jq, jmespath, or jsonpath", lang)
+	}
+}
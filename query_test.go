@@ -0,0 +1,54 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	in := `{"name": "web", "ports": [80, 443], "labels": {"team": "platform"}}`
+
+	testdata := []struct {
+		lang, expr, from string
+		json             bool
+		expected         string
+	}{
+		{"", ".name", "json", false, "web\n"},
+		{"jq", ".ports[]", "json", false, "80\n443\n"},
+		{"jq", ".labels", "json", false, `{"team":"platform"}` + "\n"},
+		{"jq", ".name", "json", true, `"web"` + "\n"},
+		{"jq", ".missing", "json", false, "null\n"},
+		{"jq", "empty", "json", false, ""},
+		{"jmespath", "ports[-1]", "json", false, "443\n"},
+		{"jmespath", "labels.team", "yaml", false, "platform\n"},
+		{"jsonpath", ".labels.team", "json", false, "platform\n"},
+	}
+	for _, d := range testdata {
+		out := &bytes.Buffer{}
+		cfg := &config.Config{Stdin: strings.NewReader(in)}
+		err := Query(context.Background(), cfg, QueryOptions{
+			Lang: d.lang, Expr: d.expr, Source: "-", From: d.from, JSON: d.json, Stdout: out,
+		})
+		require.NoError(t, err, d.expr)
+		assert.Equal(t, d.expected, out.String(), d.expr)
+	}
+}
+
+func TestQuery_Errors(t *testing.T) {
+	cfg := &config.Config{Stdin: strings.NewReader(`{}`)}
+	err := Query(context.Background(), cfg, QueryOptions{Expr: ".a.", Source: "-"})
+	assert.ErrorContains(t, err, "couldn't parse jq expression")
+
+	err = Query(context.Background(), cfg, QueryOptions{Lang: "xpath", Expr: "/a", Source: "-"})
+	assert.ErrorContains(t, err, `unknown query language "xpath"`)
+
+	cfg = &config.Config{Stdin: strings.NewReader(`{"a": "b"}`)}
+	err = Query(context.Background(), cfg, QueryOptions{Expr: ".a | keys", Source: "-", From: "json"})
+	assert.ErrorContains(t, err, "jq query failed")
+}
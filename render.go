@@ -1,18 +1,35 @@
 package gomplate
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"text/template"
 	"time"
 
 	"github.com/hairyhenderson/gomplate/v3/data"
 	"github.com/hairyhenderson/gomplate/v3/funcs" //nolint:staticcheck
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/credhelper"
+	"github.com/hairyhenderson/gomplate/v3/internal/deprecated"
+	"github.com/hairyhenderson/gomplate/v3/internal/encrypt"
+	"github.com/hairyhenderson/gomplate/v3/internal/iohelpers"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/hairyhenderson/gomplate/v3/internal/provenance"
+	"github.com/hairyhenderson/gomplate/v3/internal/ratelimit"
+	"github.com/hairyhenderson/gomplate/v3/internal/verify"
+	"github.com/hairyhenderson/gomplate/v3/script"
+	gtmpl "github.com/hairyhenderson/gomplate/v3/tmpl"
+
+	"github.com/rs/zerolog"
 )
 
 // Options for template rendering.
@@ -32,6 +49,12 @@ type Options struct {
 	// used by datasources defined in the template.
 	ExtraHeaders map[string]http.Header
 
+	// Schemes - URL scheme shortcuts for datasources and nested templates,
+	// mapping each shortcut to the URL it expands to (like "secrets" to
+	// "vault:///kv/data/team/", so "secrets://db" is read from
+	// "vault:///kv/data/team/db")
+	Schemes map[string]string
+
 	// Funcs - map of functions to be added to the default template functions.
 	// Duplicate functions will be overwritten by entries in this map.
 	Funcs template.FuncMap
@@ -45,6 +68,114 @@ type Options struct {
 
 	// Experimental - enable experimental features
 	Experimental bool
+
+	// HTMLEscape - execute templates with html/template's contextual
+	// auto-escaping, so that all output is safe to embed in HTML documents
+	HTMLEscape bool
+
+	// StrictDeprecations - fail when deprecated functions are used, instead
+	// of warning
+	StrictDeprecations bool
+
+	// ScriptLimits - the limits on the resources that Lua scripts run by the
+	// script functions can use. Zero limits use the defaults.
+	ScriptLimits script.Limits
+
+	// ProvenanceReport - path of a file to write a JSON report to, tracing
+	// each line of output to the datasources and environment variables its
+	// values came from
+	ProvenanceReport string
+	// ProvenanceComment - when set, lines of output are annotated with where
+	// their values came from, in a comment starting with this string (like
+	// "#" or "//")
+	ProvenanceComment string
+
+	// Preview - mask the values that came from secret datasources (like
+	// Vault) in the output, and prefix each output (other than standard
+	// output) with a header naming it, so secret-bearing templates can be
+	// reviewed safely. Nothing is cached, encrypted, or checksummed.
+	Preview bool
+
+	// OrderedMaps - preserve the key order of objects read from JSON, YAML,
+	// and TOML datasources when they're output with toJSON, toJSONPretty, or
+	// toYAML
+	OrderedMaps bool
+
+	// PreserveComments - preserve the comments and formatting of objects read
+	// from YAML datasources when they're output with toYAML. Implies
+	// OrderedMaps.
+	PreserveComments bool
+
+	// RenderCache - path of a file to record the fingerprints of rendered
+	// templates in, so that templates that are unchanged since the last run
+	// (including the datasources they read) aren't rendered again. Only
+	// templates that are output to files (or other non-stdout outputs) are
+	// cached.
+	RenderCache string
+
+	// Checksums - path of a file to write the SHA-256 digests of all outputs
+	// (except standard output) to, in the same format as sha256sum
+	Checksums string
+	// ContentAddressed - local output files are renamed to include the start
+	// of their digests (like 'app.<digest>.js'). Only used with Checksums.
+	ContentAddressed bool
+
+	// DatasourceCacheLimit - the maximum number of bytes of datasource data
+	// to hold in memory, after which the least-recently used data is evicted
+	// (and read again if needed). 0 means no limit.
+	DatasourceCacheLimit int64
+	// DatasourceSpillThreshold - datasource data larger than this many bytes
+	// is held in temporary files instead of in memory. 0 means never.
+	DatasourceSpillThreshold int64
+	// DatasourceMaxConnsPerHost - the maximum number of connections that
+	// datasources can have open to each host at once. 0 means no limit.
+	DatasourceMaxConnsPerHost int
+	// DatasourceConcurrency - when set, datasources are preloaded before
+	// rendering, this many at a time, instead of being read one at a time as
+	// they're used. 0 disables preloading.
+	DatasourceConcurrency int
+	// DatasourceCacheDir - when set, the data from datasources with a 'ttl'
+	// query parameter (like '?ttl=5m') is cached in this directory, and used
+	// by later renders until it's older than the TTL
+	DatasourceCacheDir string
+
+	// Snapshots - when set (even if empty), datasources are never read, and
+	// their data is read from these snapshots instead
+	Snapshots []data.Snapshot
+
+	// verifier checks the signatures of datasources and nested templates -
+	// it's configured with the Signatures and Verify config options
+	verifier *verify.Verifier
+	// netPolicy restricts the hosts datasources and nested templates can be
+	// read from - it's configured with the NetworkPolicy config option
+	netPolicy *netpolicy.Policy
+	// encrypter encrypts outputs before they're written - it's configured
+	// with the Encrypt config option
+	encrypter *encrypt.Encrypter
+	// rateLimiter limits the rate of datasource requests to each host - it's
+	// configured with the RateLimits config option
+	rateLimiter *ratelimit.Limiter
+	// credHelpers supply the credentials for datasources - they're
+	// configured with the CredentialHelpers config option
+	credHelpers *credhelper.Helpers
+	// lock is the lock held while rendering, added to the template's context
+	// as .Lock - it's configured with the Lock config option
+	lock *lockState
+	// event is the CloudEvent or webhook that triggered the render, added to
+	// the template's context as .Event - it's set by ServeEvents
+	event *event
+	// request is the HTTP request being served, added to the template's
+	// context as .Request - it's set by Serve
+	request *request
+
+	// Values - values to add to the template's context as .Values. Ignored
+	// when a datasource is used as the whole context (with the '.' alias).
+	Values map[string]interface{}
+
+	// Args - positional arguments to add to the template's context as .Args
+	Args []string
+	// NamedArgs - named arguments to add to the template's context as .Arg
+	NamedArgs map[string]string
 }
 
 // optionsFromConfig - create a set of options from the internal config struct.
@@ -53,33 +184,70 @@ func optionsFromConfig(cfg *config.Config) Options {
 	ds := make(map[string]Datasource, len(cfg.DataSources))
 	for k, v := range cfg.DataSources {
 		ds[k] = Datasource{
-			URL:    v.URL,
-			Header: v.Header,
+			URL:       v.URL,
+			Header:    v.Header,
+			Integrity: v.Integrity,
 		}
 	}
 	cs := make(map[string]Datasource, len(cfg.Context))
 	for k, v := range cfg.Context {
 		cs[k] = Datasource{
-			URL:    v.URL,
-			Header: v.Header,
+			URL:       v.URL,
+			Header:    v.Header,
+			Integrity: v.Integrity,
 		}
 	}
 	ts := make(map[string]Datasource, len(cfg.Templates))
 	for k, v := range cfg.Templates {
 		ts[k] = Datasource{
-			URL:    v.URL,
-			Header: v.Header,
+			URL:       v.URL,
+			Header:    v.Header,
+			Integrity: v.Integrity,
+		}
+	}
+
+	// the limits were already validated
+	cacheLimit, spillThreshold, _ := cfg.GetCacheLimits()
+	scriptLimits := script.Limits{}
+	if l := cfg.ScriptLimits; l != nil {
+		memory, _ := l.GetMemory()
+		scriptLimits = script.Limits{
+			Timeout:   l.Timeout,
+			CallDepth: l.CallDepth,
+			StackSize: l.StackSize,
+			Memory:    memory,
 		}
 	}
 
 	opts := Options{
-		Datasources:  ds,
-		Context:      cs,
-		Templates:    ts,
-		ExtraHeaders: cfg.ExtraHeaders,
-		LDelim:       cfg.LDelim,
-		RDelim:       cfg.RDelim,
-		Experimental: cfg.Experimental,
+		Datasources:      ds,
+		Context:          cs,
+		Templates:        ts,
+		ExtraHeaders:     cfg.ExtraHeaders,
+		Schemes:          cfg.Schemes,
+		LDelim:           cfg.LDelim,
+		RDelim:           cfg.RDelim,
+		Experimental:     cfg.Experimental,
+		HTMLEscape:       cfg.HTMLEscape,
+		OrderedMaps:      cfg.OrderedMaps,
+		PreserveComments: cfg.PreserveComments,
+		RenderCache:      cfg.RenderCache,
+		Checksums:        cfg.Checksums,
+		ContentAddressed: cfg.ContentAddressed,
+		Args:             cfg.Args,
+		NamedArgs:        cfg.NamedArgs,
+
+		StrictDeprecations: cfg.StrictDeprecations,
+		ScriptLimits:       scriptLimits,
+		ProvenanceReport:   cfg.ProvenanceReport,
+		ProvenanceComment:  cfg.ProvenanceComment,
+		Preview:            cfg.Preview,
+
+		DatasourceCacheLimit:      cacheLimit,
+		DatasourceSpillThreshold:  spillThreshold,
+		DatasourceMaxConnsPerHost: cfg.MaxConnsPerHost,
+		DatasourceConcurrency:     cfg.DatasourceConcurrency,
+		DatasourceCacheDir:        cfg.DatasourceCacheDir,
 	}
 
 	return opts
@@ -91,6 +259,9 @@ func optionsFromConfig(cfg *config.Config) Options {
 type Datasource struct {
 	URL    *url.URL
 	Header http.Header
+	// Integrity - the pinned SHA-256 digest of the content, like
+	// "sha256-<base64 digest>". Content that doesn't match isn't used.
+	Integrity string
 }
 
 // Renderer provides gomplate's core template rendering functionality.
@@ -104,6 +275,27 @@ type Renderer struct {
 	lDelim      string
 	rDelim      string
 	tctxAliases []string
+	tctxRoots   tmplctx
+	htmlEscape  bool
+	cachePath   string
+	cache       *renderCache
+	verifier    *verify.Verifier
+	netPolicy   *netpolicy.Policy
+	// checksumsPath and contentAddressed - see the Checksums options
+	checksumsPath    string
+	contentAddressed bool
+	checksums        *checksums
+	// encrypter - see Options.encrypter
+	encrypter *encrypt.Encrypter
+	// provenanceReport and provenanceComment - see the Provenance options
+	provenanceReport  string
+	provenanceComment string
+	// preview - see Options.Preview
+	preview bool
+	// strictDeprecations - fail renders that use deprecated functions
+	strictDeprecations bool
+	// scriptLimits - see Options.ScriptLimits
+	scriptLimits script.Limits
 }
 
 // NewRenderer creates a new template renderer with the specified options.
@@ -122,16 +314,18 @@ func NewRenderer(opts Options) *Renderer {
 	for alias, ds := range opts.Context {
 		tctxAliases = append(tctxAliases, alias)
 		sources[alias] = &data.Source{
-			Alias:  alias,
-			URL:    ds.URL,
-			Header: ds.Header,
+			Alias:     alias,
+			URL:       ds.URL,
+			Header:    ds.Header,
+			Integrity: ds.Integrity,
 		}
 	}
 	for alias, ds := range opts.Datasources {
 		sources[alias] = &data.Source{
-			Alias:  alias,
-			URL:    ds.URL,
-			Header: ds.Header,
+			Alias:     alias,
+			URL:       ds.URL,
+			Header:    ds.Header,
+			Integrity: ds.Integrity,
 		}
 	}
 
@@ -140,14 +334,34 @@ func NewRenderer(opts Options) *Renderer {
 	nested := config.Templates{}
 	for alias, ds := range opts.Templates {
 		nested[alias] = config.DataSource{
-			URL:    ds.URL,
-			Header: ds.Header,
+			URL:       config.ExpandScheme(opts.Schemes, ds.URL),
+			Header:    ds.Header,
+			Integrity: ds.Integrity,
 		}
 	}
 
 	d := &data.Data{
-		ExtraHeaders: opts.ExtraHeaders,
-		Sources:      sources,
+		ExtraHeaders:     opts.ExtraHeaders,
+		Schemes:          opts.Schemes,
+		Sources:          sources,
+		OrderedMaps:      opts.OrderedMaps,
+		PreserveComments: opts.PreserveComments,
+		CacheLimit:       opts.DatasourceCacheLimit,
+		SpillThreshold:   opts.DatasourceSpillThreshold,
+		NetworkPolicy:    opts.netPolicy,
+		RateLimiter:      opts.rateLimiter,
+		MaxConnsPerHost:  opts.DatasourceMaxConnsPerHost,
+		CacheDir:         opts.DatasourceCacheDir,
+
+		PreloadConcurrency: opts.DatasourceConcurrency,
+		CredentialHelpers:  opts.credHelpers,
+	}
+
+	if opts.Snapshots != nil {
+		d.UseSnapshots(opts.Snapshots)
+	}
+	if opts.verifier != nil {
+		d.Verifier = opts.verifier
 	}
 
 	// make sure data cleanups are run on exit
@@ -157,13 +371,59 @@ func NewRenderer(opts Options) *Renderer {
 		opts.Funcs = template.FuncMap{}
 	}
 
+	// .Args and .Arg are always set, so templates can check them without
+	// worrying about missing keys
+	tctxRoots := tmplctx{
+		"Args": opts.Args,
+		"Arg":  opts.NamedArgs,
+	}
+	if opts.Args == nil {
+		tctxRoots["Args"] = []string{}
+	}
+	if opts.NamedArgs == nil {
+		tctxRoots["Arg"] = map[string]string{}
+	}
+	if opts.Values != nil {
+		tctxRoots["Values"] = opts.Values
+	}
+	if opts.lock != nil {
+		tctxRoots["Lock"] = opts.lock
+	}
+	if opts.event != nil {
+		tctxRoots["Event"] = opts.event
+	}
+	if opts.request != nil {
+		tctxRoots["Request"] = opts.request
+	}
+
+	if opts.Preview {
+		// previews are never real outputs
+		opts.RenderCache, opts.Checksums, opts.encrypter = "", "", nil
+	}
+
 	return &Renderer{
 		nested:      nested,
 		data:        d,
 		funcs:       opts.Funcs,
 		tctxAliases: tctxAliases,
+		tctxRoots:   tctxRoots,
 		lDelim:      opts.LDelim,
 		rDelim:      opts.RDelim,
+		htmlEscape:  opts.HTMLEscape,
+		cachePath:   opts.RenderCache,
+		verifier:    opts.verifier,
+		netPolicy:   opts.netPolicy,
+
+		checksumsPath:    opts.Checksums,
+		contentAddressed: opts.ContentAddressed,
+
+		encrypter: opts.encrypter,
+
+		provenanceReport:   opts.ProvenanceReport,
+		provenanceComment:  opts.ProvenanceComment,
+		preview:            opts.Preview,
+		strictDeprecations: opts.StrictDeprecations,
+		scriptLimits:       opts.ScriptLimits,
 	}
 }
 
@@ -179,6 +439,12 @@ type Template struct {
 	Name string
 	// Text is the template text
 	Text string
+
+	// target is the output path, if the output is a file (or a URL), used
+	// as the key in the render cache
+	target string
+	// bundle is the compiled bundle the template is from, if any
+	bundle *templateBundle
 }
 
 // RenderTemplates renders a list of templates, parsing each template's Text
@@ -187,6 +453,16 @@ type Template struct {
 //
 // Experimental: subject to breaking changes before the next major release
 func (t *Renderer) RenderTemplates(ctx context.Context, templates []Template) error {
+	// deprecation warnings are collected, so each is reported once per render
+	deprecations := deprecated.NewCollector()
+	ctx = deprecated.ContextWithCollector(ctx, deprecations)
+
+	var tracker *provenance.Tracker
+	if t.provenanceReport != "" || t.provenanceComment != "" || t.preview {
+		tracker = provenance.New()
+		ctx = provenance.ContextWithTracker(ctx, tracker)
+	}
+
 	// we need to inject the current context into the Data value, because
 	// the Datasource method may need it
 	// TODO: remove this in v4
@@ -194,17 +470,270 @@ func (t *Renderer) RenderTemplates(ctx context.Context, templates []Template) er
 
 	// configure the template context with the refreshed Data value
 	// only done here because the data context may have changed
-	tmplctx, err := createTmplContext(ctx, t.tctxAliases, t.data)
+	tmplctx, err := createTmplContext(ctx, t.tctxAliases, t.tctxRoots, t.data)
 	if err != nil {
 		return err
 	}
 
-	return t.renderTemplatesWithData(ctx, templates, tmplctx)
+	if t.cachePath != "" && t.cache == nil {
+		t.cache, err = loadRenderCache(t.cachePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.startChecksums()
+	err = t.renderTemplatesWithData(ctx, templates, tmplctx)
+	if err == nil {
+		err = t.finishChecksums()
+	}
+	if t.cache != nil {
+		if serr := t.cache.save(); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	if derr := t.reportDeprecations(ctx, deprecations); derr != nil && err == nil {
+		err = derr
+	}
+	if t.provenanceReport != "" {
+		if perr := tracker.WriteReport(t.provenanceReport); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return err
+}
+
+// startChecksums starts recording the digests of outputs, if a checksums
+// file is configured
+func (t *Renderer) startChecksums() {
+	t.checksums = nil
+	if t.checksumsPath != "" {
+		t.checksums = newChecksums(t.checksumsPath, t.contentAddressed)
+	}
+}
+
+// finishChecksums writes the checksums file, if one is configured
+func (t *Renderer) finishChecksums() error {
+	if t.checksums == nil {
+		return nil
+	}
+	return t.checksums.finish()
 }
 
-func (t *Renderer) renderTemplatesWithData(ctx context.Context, templates []Template, tmplctx interface{}) error {
+// reportDeprecations logs the collected deprecation warnings, and fails if
+// strictDeprecations is set and there were any
+func (t *Renderer) reportDeprecations(ctx context.Context, c *deprecated.Collector) error {
+	c.Log(ctx)
+
+	warnings := c.Warnings()
+	if !t.strictDeprecations || len(warnings) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(warnings))
+	for i, w := range warnings {
+		msgs[i] = w.Message
+	}
+	return fmt.Errorf("deprecated functions were used (and strict deprecations are enabled): %s", strings.Join(msgs, "; "))
+}
+
+func (t *Renderer) renderTemplatesWithData(ctx context.Context, templates []Template, tmplctx interface{}) (err error) {
+	// streams are closed after each template, but only this render's - other
+	// renders (like matrix items) can be using the same data at the same time
+	ctx = data.ContextWithStreams(ctx)
+
 	// update funcs with the current context
 	// only done here to ensure the context is properly set in func namespaces
+	f := t.funcMap(ctx)
+
+	// track some metrics for debug output
+	start := time.Now()
+	defer updateMetrics(func(m *MetricsType) { m.TotalRenderDuration = time.Since(start) })
+	for _, template := range templates {
+		// set when the template calls skipFile (or fails), so the output is
+		// discarded
+		skipped := false
+		if template.Writer != nil {
+			wr, ok := template.Writer.(io.Closer)
+			if ok && wr != os.Stdout {
+				// some outputs (like email) are only delivered on close, so
+				// errors must be reported
+				name, target := template.Name, template.target
+				defer func() {
+					closeFn := wr.Close
+					if skipped {
+						closeFn = func() error { return iohelpers.Discard(wr) }
+					}
+					cerr := closeFn()
+					if cerr != nil && t.cache != nil {
+						// the output wasn't delivered, so it must be
+						// rendered again next time
+						delete(t.cache.Entries, target)
+					}
+					if cerr != nil && err == nil {
+						err = fmt.Errorf("failed to close output for template %s: %w", name, cerr)
+					}
+				}()
+			}
+		}
+
+		// stop early when rendering is cancelled (for example when a lock is
+		// lost), without writing the remaining outputs
+		if cerr := ctx.Err(); cerr != nil {
+			skipped = true
+			return fmt.Errorf("rendering cancelled before template %s: %w", template.Name, cerr)
+		}
+
+		tstart := time.Now()
+		tmpl, err := t.parse(ctx, template.Name, template.Text, template.bundle, f, tmplctx)
+		if err != nil {
+			skipped = true
+			return err
+		}
+
+		// with a render cache, unchanged templates aren't rendered at all,
+		// and the datasources read by the others are recorded
+		fp := ""
+		var stopRecording func() []data.Read
+		if t.cache != nil && cacheable(template.target) {
+			fp = fingerprint(tmpl, tmplctx, template.target, t.htmlEscape)
+			if t.cache.unchanged(t.data, template.target, fp, t.checksums != nil) {
+				if t.checksums != nil {
+					e := t.cache.Entries[template.target]
+					t.checksums.add(e.outputPath(template.target), strings.TrimPrefix(e.Output, "sha256:"))
+				}
+				skipped = true
+				updateMetrics(func(m *MetricsType) { m.TemplatesCached++ })
+				zerolog.Ctx(ctx).Debug().Str("template", template.Name).Str("output", template.target).Msg("template unchanged since last render")
+				continue
+			}
+			delete(t.cache.Entries, template.target)
+			stopRecording = t.data.RecordReads()
+		}
+
+		// render to a buffer first, so that nothing is written if the
+		// template is skipped. Outputs that don't need to be processed as a
+		// whole are written through once they're large, so that they aren't
+		// held in memory.
+		buf := &bytes.Buffer{}
+		var w io.Writer = buf
+		var sw *iohelpers.SpillWriter
+		if t.encrypter == nil && !t.preview && t.checksums == nil && provenance.FromContext(ctx) == nil {
+			sw = iohelpers.NewSpillWriter(template.Writer, buf, spillThreshold)
+			w = sw
+		}
+		if t.htmlEscape {
+			err = executeHTML(tmpl, f, w, tmplctx)
+		} else {
+			err = tmpl.Execute(w, tmplctx)
+		}
+		if errors.Is(err, gtmpl.ErrSkip) && sw != nil && sw.Spilled() {
+			err = fmt.Errorf("can't skip the output after more than %d bytes were written", spillThreshold)
+		}
+		// stop any streams the template didn't finish, and report errors
+		// from streams that failed partway through
+		if serr := t.data.CloseStreamsContext(ctx); serr != nil && err == nil {
+			err = serr
+		}
+		var reads []data.Read
+		if stopRecording != nil {
+			reads = stopRecording()
+		}
+		updateMetrics(func(m *MetricsType) { m.RenderDuration[template.Name] = time.Since(tstart) })
+		if errors.Is(err, gtmpl.ErrSkip) {
+			skipped = true
+			updateMetrics(func(m *MetricsType) { m.TemplatesSkipped++ })
+			zerolog.Ctx(ctx).Debug().Err(err).Str("template", template.Name).Msg("skipped template")
+			continue
+		}
+		if tracker := provenance.FromContext(ctx); tracker != nil && err == nil {
+			lines := tracker.Trace(template.Name, buf.String())
+			if t.provenanceComment != "" {
+				buf = bytes.NewBufferString(provenance.Annotate(buf.String(), lines, t.provenanceComment))
+			}
+		}
+		if t.preview && err == nil {
+			buf = bytes.NewBufferString(previewOutput(template.target, provenance.FromContext(ctx).Mask(buf.String())))
+		}
+		// outputs are encrypted last, so that everything written (like
+		// provenance comments) is encrypted
+		if t.encrypter != nil && err == nil {
+			var enc []byte
+			enc, err = t.encrypter.Encrypt(template.target, buf.Bytes())
+			// the plain text must never be written
+			buf = bytes.NewBuffer(enc)
+		}
+		// the output is hashed while it's in memory, so it doesn't need to
+		// be read again
+		digest := ""
+		if t.checksums != nil && err == nil {
+			sum := sha256.Sum256(buf.Bytes())
+			digest = hex.EncodeToString(sum[:])
+		}
+		// partial output from a failed render is only written as-is - never
+		// when it would have been encrypted, or masked for a preview, and
+		// never to outputs that can be discarded instead
+		_, discardable := template.Writer.(iohelpers.Discarder)
+		if err == nil || (t.encrypter == nil && !t.preview && !discardable) {
+			if _, werr := buf.WriteTo(template.Writer); werr != nil && err == nil {
+				err = werr
+			}
+		}
+		if err != nil {
+			// outputs delivered on close (like remote outputs) must not
+			// deliver the partial output
+			skipped = true
+			updateMetrics(func(m *MetricsType) { m.Errors++ })
+			return fmt.Errorf("failed to render template %s: %w", template.Name, err)
+		}
+		updateMetrics(func(m *MetricsType) { m.TemplatesProcessed++ })
+		outPath := template.target
+		if digest != "" && cacheable(template.target) && !iohelpers.Skipped(template.Writer) {
+			outPath = t.checksums.record(template.target, digest)
+			digest = "sha256:" + digest
+		} else {
+			digest = ""
+		}
+		if stopRecording != nil {
+			t.cache.store(template.target, renderCacheEntry{
+				Template:    template.Name,
+				Fingerprint: fp,
+				Datasources: reads,
+				Output:      digest,
+				Path:        outPath,
+			})
+		}
+	}
+	return nil
+}
+
+// spillThreshold - rendered outputs larger than this are written as they're
+// rendered, when they don't need to be processed as a whole
+const spillThreshold = 1 << 20
+
+// previewOutput - the masked output, with a header naming the target, so
+// that multiple outputs can be told apart on stdout
+func previewOutput(target, masked string) string {
+	if target == "" || target == "-" {
+		return masked
+	}
+	if masked != "" && !strings.HasSuffix(masked, "\n") {
+		masked += "\n"
+	}
+	return "==> " + target + " <==\n" + masked
+}
+
+// parse parses the template text, or uses the bundle's parser when the
+// template is from a compiled bundle
+func (t *Renderer) parse(ctx context.Context, name, text string, bundle *templateBundle, f template.FuncMap, tmplctx interface{}) (*template.Template, error) {
+	if bundle != nil {
+		return bundle.parse(name, text, f, tmplctx)
+	}
+	ctx = netpolicy.ContextWithPolicy(contextWithVerifier(ctx, t.verifier), t.netPolicy)
+	return parseTemplate(ctx, name, text, f, tmplctx, t.nested, t.lDelim, t.rDelim)
+}
+
+// funcMap creates the template functions, with the given context
+func (t *Renderer) funcMap(ctx context.Context) template.FuncMap {
 	f := template.FuncMap{}
 	addToMap(f, funcs.CreateDataFuncs(ctx, t.data))
 	addToMap(f, funcs.CreateAWSFuncs(ctx))
@@ -226,37 +755,32 @@ func (t *Renderer) renderTemplatesWithData(ctx context.Context, templates []Temp
 	addToMap(f, funcs.CreateCollFuncs(ctx))
 	addToMap(f, funcs.CreateUUIDFuncs(ctx))
 	addToMap(f, funcs.CreateRandomFuncs(ctx))
+	addToMap(f, funcs.CreateGoTypeFuncs(ctx))
+	addToMap(f, funcs.CreateEscFuncs(ctx))
+	addToMap(f, funcs.CreateTextFuncs(ctx))
+	addToMap(f, funcs.CreatePlotFuncs(ctx))
+	addToMap(f, funcs.CreateDocFuncs(ctx))
+	addToMap(f, funcs.CreateMailFuncs(ctx))
+	addToMap(f, funcs.CreateFeedFuncs(ctx))
+	addToMap(f, funcs.CreateOpenAPIFuncs(ctx))
+	addToMap(f, funcs.CreateSchemaFuncs(ctx))
+	addToMap(f, funcs.CreatePromptFuncs(ctx))
+	addToMap(f, funcs.CreateK8sFuncs(ctx))
+	addToMap(f, funcs.CreateScriptFuncs(script.ContextWithLimits(ctx, t.scriptLimits)))
+	addToMap(f, funcs.CreateExprFuncs(ctx))
+	addToMap(f, funcs.CreateFlagFuncs(ctx, t.data))
+	addToMap(f, funcs.CreateRolloutFuncs(ctx))
+	addToMap(f, funcs.CreateUnitsFuncs(ctx))
+	addToMap(f, funcs.CreateColorFuncs(ctx))
+	addToMap(f, funcs.CreateContactFuncs(ctx))
+	addToMap(f, funcs.CreateMoneyFuncs(ctx, t.data))
+	addToMap(f, funcs.CreateGeoFuncs(ctx))
+	addToMap(f, funcs.CreateGraphFuncs(ctx))
 
 	// add user-defined funcs last so they override the built-in funcs
 	addToMap(f, t.funcs)
 
-	// track some metrics for debug output
-	start := time.Now()
-	defer func() { Metrics.TotalRenderDuration = time.Since(start) }()
-	for _, template := range templates {
-		if template.Writer != nil {
-			wr, ok := template.Writer.(io.Closer)
-			if ok && wr != os.Stdout {
-				defer wr.Close()
-			}
-		}
-
-		tstart := time.Now()
-		tmpl, err := parseTemplate(ctx, template.Name, template.Text,
-			f, tmplctx, t.nested, t.lDelim, t.rDelim)
-		if err != nil {
-			return err
-		}
-
-		err = tmpl.Execute(template.Writer, tmplctx)
-		Metrics.RenderDuration[template.Name] = time.Since(tstart)
-		if err != nil {
-			Metrics.Errors++
-			return fmt.Errorf("failed to render template %s: %w", template.Name, err)
-		}
-		Metrics.TemplatesProcessed++
-	}
-	return nil
+	return f
 }
 
 // Render is a convenience method for rendering a single template. For more
@@ -4,15 +4,31 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"testing/fstest"
+	"time"
 
+	"filippo.io/age"
+	"filippo.io/age/armor"
 	"github.com/hairyhenderson/go-fsimpl"
 	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/cas"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/encrypt"
+	"github.com/hairyhenderson/gomplate/v3/script"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRenderTemplate(t *testing.T) {
@@ -69,6 +85,381 @@ func TestRenderTemplate(t *testing.T) {
 	tr = NewRenderer(Options{})
 	err = tr.Render(ctx, "foo", `{{ bogus }}`, &bytes.Buffer{})
 	assert.ErrorContains(t, err, "template: foo:")
+
+	// with contextual HTML auto-escaping
+	tr = NewRenderer(Options{HTMLEscape: true})
+	out = &bytes.Buffer{}
+	err = tr.Render(ctx, "test",
+		`{{ define "t" }}<b>{{ . }}</b>{{ end }}<a href="/?q={{ "a&b" }}" onclick="f({{ "x" }})">{{ template "t" "<i>" }}</a>`, out)
+	assert.NoError(t, err)
+	assert.Equal(t, `<a href="/?q=a%26b" onclick="f(&#34;x&#34;)"><b>&lt;i&gt;</b></a>`, out.String())
+
+	// errors closing the output are returned
+	tr = NewRenderer(Options{})
+	err = tr.Render(ctx, "test", "hello", &errCloser{})
+	assert.ErrorContains(t, err, "failed to close output for template test: close failed")
+
+	// skipped templates produce no output
+	tr = NewRenderer(Options{})
+	out = &bytes.Buffer{}
+	err = tr.Render(ctx, "test", `partial {{ if true }}{{ skipFile "disabled" }}{{ end }} more`, out)
+	assert.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	// output written before an error is kept
+	out = &bytes.Buffer{}
+	err = tr.Render(ctx, "test", `partial {{ fail "oops" }}`, out)
+	assert.Error(t, err)
+	assert.Equal(t, "partial ", out.String())
+}
+
+func TestRenderSkippedFile(t *testing.T) {
+	ctx := context.Background()
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = afero.WriteFile(aferoFS, "existing.txt", []byte("keep me"), 0o644)
+
+	tr := NewRenderer(Options{})
+	templates := []Template{}
+	for _, name := range []string{"new.txt", "existing.txt", "written.txt"} {
+		w, err := openOutFile(name, 0o755, 0o644, false, nil, false)
+		assert.NoError(t, err)
+		text := "{{ skipFile }}"
+		if name == "written.txt" {
+			text = "hello"
+		}
+		templates = append(templates, Template{Name: name, Text: text, Writer: w})
+	}
+	err := tr.RenderTemplates(ctx, templates)
+	assert.NoError(t, err)
+
+	_, err = aferoFS.Stat("new.txt")
+	assert.True(t, os.IsNotExist(err))
+
+	b, err := afero.ReadFile(aferoFS, "existing.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "keep me", string(b))
+
+	b, err = afero.ReadFile(aferoFS, "written.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
+
+func TestRenderDeprecations(t *testing.T) {
+	ctx := context.Background()
+	text := `{{ range seq 3 }}{{ deprecated "old is deprecated" }}{{ conv.Has (dict "a" 1) "a" }}{{ end }}`
+
+	// warnings are logged once per render
+	logs := &bytes.Buffer{}
+	lctx := zerolog.New(logs).WithContext(ctx)
+	out := &bytes.Buffer{}
+	err := NewRenderer(Options{}).Render(lctx, "test", text, out)
+	require.NoError(t, err)
+	assert.Equal(t, "truetruetrue", out.String())
+	assert.Equal(t, 1, strings.Count(logs.String(), "Deprecated: old is deprecated"))
+	assert.Equal(t, 1, strings.Count(logs.String(), "Deprecated: conv.Has is deprecated"))
+	assert.Contains(t, logs.String(), `"count":3`)
+
+	err = NewRenderer(Options{StrictDeprecations: true}).Render(ctx, "test", text, &bytes.Buffer{})
+	assert.EqualError(t, err, "deprecated functions were used (and strict deprecations are enabled): "+
+		"old is deprecated; conv.Has is deprecated - use coll.Has instead")
+
+	err = NewRenderer(Options{StrictDeprecations: true}).Render(ctx, "test", "fine", &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRenderScriptLimits(t *testing.T) {
+	ctx := context.Background()
+	text := `{{ script.Run "return string.rep('x', 4096)" | len }}`
+
+	out := &bytes.Buffer{}
+	err := NewRenderer(Options{}).Render(ctx, "test", text, out)
+	require.NoError(t, err)
+	assert.Equal(t, "4096", out.String())
+
+	err = NewRenderer(Options{ScriptLimits: script.Limits{Memory: 1024}}).Render(ctx, "test", text, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "script script exceeded its memory limit of 1024 bytes")
+}
+
+func TestRenderTimeout(t *testing.T) {
+	cancelled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.json" {
+			<-r.Context().Done()
+			close(cancelled)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"a": 1}`))
+	}))
+	defer srv.Close()
+
+	su, _ := url.Parse(srv.URL + "/slow.json")
+	fu, _ := url.Parse(srv.URL + "/fast.json")
+	tr := NewRenderer(Options{
+		Datasources: map[string]Datasource{"slow": {URL: su}, "fast": {URL: fu}},
+	})
+	out := &bytes.Buffer{}
+	err := tr.Render(context.Background(), "test", `{{ tryOr "fallback" "timeout" "50ms" "ds" "slow" }} {{ (ds "fast").a }}`, out)
+	require.NoError(t, err)
+	assert.Equal(t, "fallback 1", out.String())
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the timed-out read wasn't cancelled")
+	}
+}
+
+func TestRenderParallel(t *testing.T) {
+	mu := sync.Mutex{}
+	requests := map[string]int{}
+	// closed once both sources are being read at the same time
+	overlapped := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		if len(requests) == 2 && requests[r.URL.Path] == 1 {
+			close(overlapped)
+		}
+		mu.Unlock()
+
+		select {
+		case <-overlapped:
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"v": %q}`, strings.TrimSuffix(path.Base(r.URL.Path), ".json"))
+	}))
+	defer srv.Close()
+
+	au, _ := url.Parse(srv.URL + "/a.json")
+	bu, _ := url.Parse(srv.URL + "/b.json")
+	tr := NewRenderer(Options{
+		Datasources: map[string]Datasource{"a": {URL: au}, "b": {URL: bu}},
+	})
+	out := &bytes.Buffer{}
+	err := tr.Render(context.Background(), "test",
+		`{{ range parallel (coll.Slice "ds" "a") (coll.Slice "ds" "b") (coll.Slice "ds" "a") }}{{ .v }}{{ end }}`, out)
+	require.NoError(t, err)
+	assert.Equal(t, "aba", out.String())
+
+	// the same data is only read once
+	assert.Equal(t, map[string]int{"/a.json": 1, "/b.json": 1}, requests)
+}
+
+func TestRenderProvenance(t *testing.T) {
+	ctx := data.ContextWithStdin(context.Background(), strings.NewReader(`{"host": "db.example.com"}`))
+	t.Setenv("DB_USER", "admin")
+
+	u, _ := url.Parse("stdin:///in.json")
+	report := filepath.Join(t.TempDir(), "provenance.json")
+	tr := NewRenderer(Options{
+		Datasources:       map[string]Datasource{"config": {URL: u}},
+		ProvenanceComment: "#",
+		ProvenanceReport:  report,
+	})
+	out := &bytes.Buffer{}
+	err := tr.Render(ctx, "test", "host: {{ (ds \"config\").host }}\nuser: {{ getenv \"DB_USER\" }}\nport: 5432", out)
+	require.NoError(t, err)
+	assert.Equal(t, "host: db.example.com # from: datasource config .host\nuser: admin # from: env DB_USER\nport: 5432", out.String())
+
+	b, err := os.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"source": "datasource config"`)
+	assert.Contains(t, string(b), `"literal": true`)
+}
+
+func TestRenderEncrypted(t *testing.T) {
+	ctx := context.Background()
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	enc, err := encrypt.New([]config.EncryptConfig{{
+		Outputs:    []string{"*.key"},
+		Recipients: []string{id.Recipient().String()},
+	}})
+	require.NoError(t, err)
+
+	tr := NewRenderer(Options{encrypter: enc})
+	secret, plain := &bytes.Buffer{}, &bytes.Buffer{}
+	err = tr.RenderTemplates(ctx, []Template{
+		{Name: "secret", Text: "s3cr3t", Writer: secret, target: "out/tls.key"},
+		{Name: "plain", Text: "hello", Writer: plain, target: "out/hello.txt"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", plain.String())
+	assert.NotContains(t, secret.String(), "s3cr3t")
+
+	r, err := age.Decrypt(armor.NewReader(secret), id)
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(b))
+}
+
+func TestRenderEncryptedError(t *testing.T) {
+	ctx := context.Background()
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	enc, err := encrypt.New([]config.EncryptConfig{{
+		Outputs:    []string{"*.key"},
+		Recipients: []string{id.Recipient().String()},
+	}})
+	require.NoError(t, err)
+
+	tr := NewRenderer(Options{encrypter: enc})
+	secret := &bytes.Buffer{}
+	err = tr.RenderTemplates(ctx, []Template{
+		{Name: "secret", Text: `s3cr3t{{ fail "oops" }}`, Writer: secret, target: "out/tls.key"},
+	})
+	assert.ErrorContains(t, err, "oops")
+	assert.Empty(t, secret.String())
+}
+
+func TestRenderPreview(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "foo")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"user": "admin", "password": "hunter2"}}`))
+	}))
+	defer srv.Close()
+
+	vu, _ := url.Parse("vault+" + srv.URL + "/secret/db")
+	cu, _ := url.Parse("stdin:///config.yaml")
+	ctx := data.ContextWithStdin(context.Background(), strings.NewReader("host: db.example.com\n"))
+
+	tr := NewRenderer(Options{
+		Preview: true,
+		Context: map[string]Datasource{
+			"db":     {URL: vu},
+			"config": {URL: cu},
+		},
+	})
+	out := &bytes.Buffer{}
+	err := tr.RenderTemplates(ctx, []Template{
+		{Name: "db", Text: "host: {{ .config.host }}\npassword: {{ .db.password }}", Writer: out, target: "out/db.yaml"},
+		{Name: "<arg>", Text: `{{ include "db" }}`, Writer: out, target: "-"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "==> out/db.yaml <==\nhost: db.example.com\npassword: ****\n****\n", out.String())
+}
+
+func TestRenderPreviewError(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "foo")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"password": "hunter2"}}`))
+	}))
+	defer srv.Close()
+
+	vu, _ := url.Parse("vault+" + srv.URL + "/secret/db")
+	tr := NewRenderer(Options{
+		Preview:     true,
+		Datasources: map[string]Datasource{"db": {URL: vu}},
+	})
+	out := &bytes.Buffer{}
+	err := tr.RenderTemplates(context.Background(), []Template{
+		{Name: "db", Text: `password: {{ (ds "db").password }}{{ fail "oops" }}`, Writer: out, target: "out/db.yaml"},
+	})
+	assert.ErrorContains(t, err, "oops")
+	assert.Empty(t, out.String())
+}
+
+// storeRecorder - a cas.Store that records what's written
+type storeRecorder struct {
+	writes []string
+}
+
+func (s *storeRecorder) Version(context.Context) (string, error) {
+	return "", nil
+}
+
+func (s *storeRecorder) Write(_ context.Context, content []byte, _ string) (string, error) {
+	s.writes = append(s.writes, string(content))
+	return "1", nil
+}
+
+func TestRenderErrorRemoteOutput(t *testing.T) {
+	ctx := context.Background()
+
+	// outputs delivered on close get nothing when the template fails
+	store := &storeRecorder{}
+	w, err := cas.NewWriter(ctx, store)
+	require.NoError(t, err)
+	err = NewRenderer(Options{}).RenderTemplates(ctx, []Template{
+		{Name: "remote", Text: `partial {{ fail "oops" }}`, Writer: w, target: "https://example.com/out.txt"},
+	})
+	assert.ErrorContains(t, err, "oops")
+	assert.Empty(t, store.writes)
+
+	// ...while outputs of templates rendered before the failure are
+	// delivered
+	store = &storeRecorder{}
+	ok, err := cas.NewWriter(ctx, store)
+	require.NoError(t, err)
+	failed, err := cas.NewWriter(ctx, store)
+	require.NoError(t, err)
+	err = NewRenderer(Options{}).RenderTemplates(ctx, []Template{
+		{Name: "ok", Text: `hello`, Writer: ok, target: "https://example.com/ok.txt"},
+		{Name: "failed", Text: `{{ template "missing" }}`, Writer: failed, target: "https://example.com/failed.txt"},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"hello"}, store.writes)
+}
+
+// writeRecorder records the size of the largest write
+type writeRecorder struct {
+	n, largest int
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.n += len(p)
+	if len(p) > w.largest {
+		w.largest = len(p)
+	}
+	return len(p), nil
+}
+
+func TestRenderLargeOutput(t *testing.T) {
+	ctx := context.Background()
+	// about 8MiB of output
+	text := `{{ range seq 1 131072 }}` + strings.Repeat("x", 63) + "\n{{ end }}"
+
+	// large outputs are written as they're rendered, so they're never held
+	// in memory as a whole
+	w := &writeRecorder{}
+	err := NewRenderer(Options{}).RenderTemplates(ctx, []Template{{Name: "big", Text: text, Writer: w}})
+	require.NoError(t, err)
+	assert.Equal(t, 131072*64, w.n)
+	assert.LessOrEqual(t, w.largest, spillThreshold)
+
+	// ...unless the output has to be processed as a whole
+	w = &writeRecorder{}
+	err = NewRenderer(Options{Checksums: filepath.Join(t.TempDir(), "SHA256SUMS")}).RenderTemplates(ctx,
+		[]Template{{Name: "big", Text: text, Writer: w, target: "out/big.txt"}})
+	require.NoError(t, err)
+	assert.Equal(t, 131072*64, w.largest)
+
+	// small outputs can still be skipped, but large ones can't
+	out := &bytes.Buffer{}
+	err = NewRenderer(Options{}).RenderTemplates(ctx, []Template{{Name: "small", Text: `hello{{ skipFile }}`, Writer: out}})
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	err = NewRenderer(Options{}).RenderTemplates(ctx, []Template{{Name: "big", Text: text + `{{ skipFile }}`, Writer: &writeRecorder{}}})
+	assert.EqualError(t, err, "failed to render template big: can't skip the output after more than 1048576 bytes were written")
+}
+
+type errCloser struct {
+	bytes.Buffer
+}
+
+func (*errCloser) Close() error {
+	return fmt.Errorf("close failed")
 }
 
 //// examples
@@ -0,0 +1,289 @@
+package gomplate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/version"
+)
+
+// renderCacheVersion is incremented when the fingerprint's inputs change, so
+// that old caches are ignored
+const renderCacheVersion = 2
+
+// renderCache records a fingerprint of each rendered template, keyed by its
+// output path, so that templates whose inputs are unchanged can be skipped
+// on the next run. It's persisted as a JSON file.
+type renderCache struct {
+	path    string
+	Version int                         `json:"version"`
+	Entries map[string]renderCacheEntry `json:"entries"`
+}
+
+type renderCacheEntry struct {
+	Template    string      `json:"template"`
+	Fingerprint string      `json:"fingerprint"`
+	Datasources []data.Read `json:"datasources,omitempty"`
+	// Output - the digest of the output, recorded when checksums are enabled
+	Output string `json:"output,omitempty"`
+	// Path - the path the output was written to, if it's not the same as
+	// the target (for content-addressed outputs)
+	Path string `json:"path,omitempty"`
+}
+
+// outputPath returns the path the output was written to
+func (e renderCacheEntry) outputPath(target string) string {
+	if e.Path != "" {
+		return e.Path
+	}
+	return target
+}
+
+// loadRenderCache reads the cache file, if it exists. A cache written by a
+// different version is discarded.
+func loadRenderCache(path string) (*renderCache, error) {
+	c := &renderCache{path: path, Version: renderCacheVersion, Entries: map[string]renderCacheEntry{}}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read render cache: %w", err)
+	}
+
+	stored := &renderCache{}
+	if err := json.Unmarshal(b, stored); err != nil {
+		return nil, fmt.Errorf("failed to parse render cache %s: %w", path, err)
+	}
+	if stored.Version == renderCacheVersion && stored.Entries != nil {
+		c.Entries = stored.Entries
+	}
+	return c, nil
+}
+
+// save writes the cache file atomically
+func (c *renderCache) save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	return nil
+}
+
+// cacheable reports whether the output at target can be skipped when the
+// template is unchanged. Standard output is always rendered.
+func cacheable(target string) bool {
+	return target != "" && target != "-"
+}
+
+// unchanged reports whether the template was rendered to target with the
+// same fingerprint before, and the datasources it read are unchanged. Local
+// output files must also still exist. When needDigest is set, the output's
+// digest must also have been recorded.
+func (c *renderCache) unchanged(d *data.Data, target, fingerprint string, needDigest bool) bool {
+	e, ok := c.Entries[target]
+	if !ok || e.Fingerprint != fingerprint {
+		return false
+	}
+	if needDigest && e.Output == "" {
+		return false
+	}
+	if !strings.Contains(target, "://") {
+		if _, err := aferoFS.Stat(e.outputPath(target)); err != nil {
+			return false
+		}
+	}
+	for _, r := range e.Datasources {
+		if !d.Unchanged(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// store records the template's fingerprint, the datasources it read, and
+// (optionally) its output's digest
+func (c *renderCache) store(target string, e renderCacheEntry) {
+	if e.Path == target {
+		e.Path = ""
+	}
+	c.Entries[target] = e
+}
+
+// fingerprint hashes everything that affects a template's output, except for
+// the datasources it reads: the parsed template (including any nested
+// templates), the template context, the environment variables the template
+// references, the output path, and the rendering options.
+func fingerprint(tmpl *template.Template, tctx interface{}, target string, htmlEscape bool) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%t\x00", version.Version, target, htmlEscape)
+
+	templates := tmpl.Templates()
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name() < templates[j].Name()
+	})
+	env := &envRefs{keys: map[string]bool{}}
+	for _, t := range templates {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", t.Name(), t.Tree.Root.String())
+		env.walk(t.Tree.Root)
+	}
+
+	// maps are printed in key order, so this is stable
+	if c, ok := tctx.(*tmplctx); ok {
+		tctx = *c
+	}
+	fmt.Fprintf(h, "%#v", tctx)
+
+	// .Env is a method, so it isn't part of the context's hash
+	env.hash(h)
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// envRefs - the environment variables referenced by a template, with .Env
+// or the env functions. When a reference can't be resolved to a variable's
+// name (like 'range .Env' or 'getenv $name'), all of them are included.
+type envRefs struct {
+	keys map[string]bool
+	all  bool
+}
+
+func (e *envRefs) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			e.walk(c)
+		}
+	case *parse.ActionNode:
+		e.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			e.walk(c)
+		}
+	case *parse.CommandNode:
+		e.command(n)
+		for _, c := range n.Args {
+			e.walk(c)
+		}
+	case *parse.IfNode:
+		e.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		e.branch(&n.BranchNode)
+	case *parse.WithNode:
+		e.branch(&n.BranchNode)
+	case *parse.TemplateNode:
+		e.walk(n.Pipe)
+	case *parse.ChainNode:
+		e.walk(n.Node)
+	case *parse.FieldNode:
+		e.field(n.Ident)
+	case *parse.VariableNode:
+		// like $.Env.HOME
+		if len(n.Ident) > 1 {
+			e.field(n.Ident[1:])
+		}
+	}
+}
+
+func (e *envRefs) branch(n *parse.BranchNode) {
+	e.walk(n.Pipe)
+	e.walk(n.List)
+	e.walk(n.ElseList)
+}
+
+// field records a reference like .Env.HOME
+func (e *envRefs) field(ident []string) {
+	if len(ident) == 0 || ident[0] != "Env" {
+		return
+	}
+	if len(ident) == 1 {
+		e.all = true
+		return
+	}
+	e.keys[ident[1]] = true
+}
+
+// command records calls like 'getenv "HOME"' and 'env.Getenv "HOME"'
+func (e *envRefs) command(n *parse.CommandNode) {
+	name := ""
+	switch f := n.Args[0].(type) {
+	case *parse.IdentifierNode:
+		name = f.Ident
+	case *parse.ChainNode:
+		if id, ok := f.Node.(*parse.IdentifierNode); ok && id.Ident == "env" && len(f.Field) == 1 {
+			name = "env." + f.Field[0]
+		}
+	}
+	switch name {
+	case "getenv", "env.Getenv":
+		if len(n.Args) > 1 {
+			if key, ok := n.Args[1].(*parse.StringNode); ok {
+				// the value can also be read from a file named by KEY_FILE
+				e.keys[key.Text] = true
+				e.keys[key.Text+"_FILE"] = true
+				return
+			}
+		}
+		e.all = true
+	case "env.ExpandEnv":
+		e.all = true
+	}
+}
+
+// hash writes the referenced variables' values to h
+func (e *envRefs) hash(h io.Writer) {
+	keys := make([]string, 0, len(e.keys))
+	for k := range e.keys {
+		keys = append(keys, k)
+	}
+	if e.all {
+		keys = keys[:0]
+		for _, kv := range os.Environ() {
+			k, _, _ := strings.Cut(kv, "=")
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, ok := os.LookupEnv(k)
+		fmt.Fprintf(h, "%s\x00%t\x00%s\x00", k, ok, v)
+	}
+}
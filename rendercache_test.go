@@ -0,0 +1,154 @@
+package gomplate
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCache(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	dir := t.TempDir()
+	dsFile := filepath.Join(dir, "d.json")
+	require.NoError(t, os.WriteFile(dsFile, []byte(`{"v": 1}`), 0o600))
+	u, _ := url.Parse("file://" + filepath.ToSlash(dsFile))
+	cacheFile := filepath.Join(dir, "cache.json")
+
+	render := func(text string) *MetricsType {
+		t.Helper()
+		Metrics = newMetrics()
+		tr := NewRenderer(Options{
+			Datasources: map[string]Datasource{"d": {URL: u}},
+			RenderCache: cacheFile,
+		})
+		w, err := openOutFile("/out.txt", 0755, 0644, false, nil, false)
+		require.NoError(t, err)
+		err = tr.RenderTemplates(context.Background(), []Template{
+			{Name: "in", Text: text, Writer: w, target: "/out.txt"},
+		})
+		require.NoError(t, err)
+		return Metrics
+	}
+	output := func() string {
+		b, err := afero.ReadFile(aferoFS, "/out.txt")
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	m := render(`v={{ (ds "d").v }}`)
+	assert.Equal(t, 1, m.TemplatesProcessed)
+	assert.Equal(t, "v=1", output())
+
+	m = render(`v={{ (ds "d").v }}`)
+	assert.Equal(t, 0, m.TemplatesProcessed)
+	assert.Equal(t, 1, m.TemplatesCached)
+
+	// a changed datasource is detected
+	require.NoError(t, os.WriteFile(dsFile, []byte(`{"v": 2}`), 0o600))
+	m = render(`v={{ (ds "d").v }}`)
+	assert.Equal(t, 1, m.TemplatesProcessed)
+	assert.Equal(t, "v=2", output())
+
+	// so is a changed template
+	m = render(`v = {{ (ds "d").v }}`)
+	assert.Equal(t, 1, m.TemplatesProcessed)
+	assert.Equal(t, "v = 2", output())
+
+	// and a deleted output file
+	require.NoError(t, aferoFS.Remove("/out.txt"))
+	m = render(`v = {{ (ds "d").v }}`)
+	assert.Equal(t, 1, m.TemplatesProcessed)
+	assert.Equal(t, "v = 2", output())
+
+	m = render(`v = {{ (ds "d").v }}`)
+	assert.Equal(t, 0, m.TemplatesProcessed)
+}
+
+func TestRenderCacheEnv(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+
+	render := func(text string) (*MetricsType, string) {
+		t.Helper()
+		Metrics = newMetrics()
+		tr := NewRenderer(Options{RenderCache: cacheFile})
+		w, err := openOutFile("/out.txt", 0755, 0644, false, nil, false)
+		require.NoError(t, err)
+		err = tr.RenderTemplates(context.Background(), []Template{
+			{Name: "in", Text: text, Writer: w, target: "/out.txt"},
+		})
+		require.NoError(t, err)
+		b, err := afero.ReadFile(aferoFS, "/out.txt")
+		require.NoError(t, err)
+		return Metrics, string(b)
+	}
+
+	testdata := []string{
+		`{{ .Env.RC_TEST_VAR }}`,
+		`{{ $.Env.RC_TEST_VAR }}`,
+		`{{ getenv "RC_TEST_VAR" }}`,
+		`{{ env.Getenv "RC_TEST_VAR" "default" }}`,
+		`{{ range $k, $v := .Env }}{{ if eq $k "RC_TEST_VAR" }}{{ $v }}{{ end }}{{ end }}`,
+		`{{ define "t" }}{{ .Env.RC_TEST_VAR }}{{ end }}{{ template "t" . }}`,
+	}
+	for _, text := range testdata {
+		t.Setenv("RC_TEST_VAR", "one")
+		m, out := render(text)
+		assert.Equal(t, 1, m.TemplatesProcessed, text)
+		assert.Equal(t, "one", out, text)
+
+		m, _ = render(text)
+		assert.Equal(t, 1, m.TemplatesCached, text)
+
+		// changing a variable the template uses renders it again
+		t.Setenv("RC_TEST_VAR", "two")
+		m, out = render(text)
+		assert.Equal(t, 1, m.TemplatesProcessed, text)
+		assert.Equal(t, "two", out, text)
+	}
+
+	// other variables don't matter when specific ones are referenced
+	t.Setenv("RC_OTHER_VAR", "a")
+	render(`{{ .Env.RC_TEST_VAR }}`)
+	t.Setenv("RC_OTHER_VAR", "b")
+	m, _ := render(`{{ .Env.RC_TEST_VAR }}`)
+	assert.Equal(t, 1, m.TemplatesCached)
+}
+
+func TestLoadRenderCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.json")
+
+	c, err := loadRenderCache(path)
+	require.NoError(t, err)
+	assert.Empty(t, c.Entries)
+
+	c.store("out.txt", renderCacheEntry{Template: "in.tmpl", Fingerprint: "sha256:abc", Path: "out.txt"})
+	require.NoError(t, c.save())
+
+	c, err = loadRenderCache(path)
+	require.NoError(t, err)
+	assert.Equal(t, renderCacheEntry{Template: "in.tmpl", Fingerprint: "sha256:abc"}, c.Entries["out.txt"])
+
+	// caches from other versions are discarded
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 0, "entries": {"out.txt": {}}}`), 0o600))
+	c, err = loadRenderCache(path)
+	require.NoError(t, err)
+	assert.Empty(t, c.Entries)
+
+	require.NoError(t, os.WriteFile(path, []byte(`bogus`), 0o600))
+	_, err = loadRenderCache(path)
+	assert.Error(t, err)
+}
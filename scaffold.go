@@ -0,0 +1,292 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+	"github.com/hairyhenderson/gomplate/v3/prompt"
+	"github.com/hairyhenderson/yaml"
+	"github.com/spf13/afero"
+	"github.com/zealic/xignore"
+)
+
+// scaffoldSpecFile is the file in a scaffold's root directory that lists the
+// values to prompt for. It isn't rendered into the output.
+const scaffoldSpecFile = ".gomplate-new.yaml"
+
+// ScaffoldOptions - options for Scaffold
+//
+// Experimental: subject to breaking changes before the next major release
+type ScaffoldOptions struct {
+	// Source - the directory containing the scaffold templates
+	Source string
+	// Dest - the directory to render the scaffold into
+	Dest string
+	// Values - values (answers) that have already been supplied. Prompts
+	// aren't shown for values that are set here. They're overridden by
+	// values read from ValuesFiles, and then by SetValues, as with the
+	// --values and --set flags.
+	Values      map[string]interface{}
+	ValuesFiles []string
+	SetValues   []string
+	// Force - overwrite existing files
+	Force bool
+	// Prompter - used to prompt for missing values. Defaults to the terminal.
+	Prompter *prompt.Prompter
+}
+
+// scaffoldSpec - the contents of the scaffold spec file
+type scaffoldSpec struct {
+	Prompts  []scaffoldPrompt `yaml:"prompts"`
+	CopyOnly []string         `yaml:"copyOnly"`
+}
+
+type scaffoldPrompt struct {
+	Name    string   `yaml:"name"`
+	Message string   `yaml:"message"`
+	Default string   `yaml:"default"`
+	Choices []string `yaml:"choices"`
+	Secret  bool     `yaml:"secret"`
+}
+
+// scaffoldFile - a file to be written
+type scaffoldFile struct {
+	out     string
+	mode    os.FileMode
+	content []byte
+}
+
+// Scaffold renders a whole directory tree of templates (a "scaffold") into
+// a destination directory. File and directory names are templates too, and
+// files or directories whose names render to an empty string are skipped.
+//
+// Values are made available to templates as .Values. Any values listed in the
+// scaffold's spec file that haven't been supplied are prompted for.
+//
+// Experimental: subject to breaking changes before the next major release
+func Scaffold(ctx context.Context, opts ScaffoldOptions) error {
+	Metrics = newMetrics()
+
+	src := filepath.Clean(opts.Source)
+	if fi, err := aferoFS.Stat(src); err != nil {
+		return fmt.Errorf("couldn't stat scaffold %s: %w", src, err)
+	} else if !fi.IsDir() {
+		return fmt.Errorf("scaffold %s must be a directory", src)
+	}
+
+	spec, err := readScaffoldSpec(src)
+	if err != nil {
+		return err
+	}
+
+	defer runCleanupHooks()
+
+	// the renderer refers to the values map, so answers added to it are
+	// visible to templates rendered afterwards
+	values, err := loadValues(opts.Values, opts.ValuesFiles, opts.SetValues)
+	if err != nil {
+		return err
+	}
+	tr := NewRenderer(Options{Values: values})
+
+	p := opts.Prompter
+	if p == nil {
+		p = prompt.Terminal()
+	}
+	err = askScaffoldPrompts(ctx, tr, spec.Prompts, values, p)
+	if err != nil {
+		return err
+	}
+
+	files, err := planScaffold(ctx, tr, src, opts.Dest, spec)
+	if err != nil {
+		return err
+	}
+
+	if !opts.Force {
+		for _, f := range files {
+			if _, err := aferoFS.Stat(f.out); err == nil {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", f.out)
+			}
+		}
+	}
+
+	for _, f := range files {
+		if err := aferoFS.MkdirAll(filepath.Dir(f.out), 0o755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(aferoFS, f.out, f.content, f.mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.out, err)
+		}
+		Metrics.ChangedFiles = append(Metrics.ChangedFiles, f.out)
+	}
+	return nil
+}
+
+func readScaffoldSpec(dir string) (*scaffoldSpec, error) {
+	spec := &scaffoldSpec{}
+	b, err := afero.ReadFile(aferoFS, filepath.Join(dir, scaffoldSpecFile))
+	if os.IsNotExist(err) {
+		return spec, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", scaffoldSpecFile, err)
+	}
+	if err := yaml.Unmarshal(b, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", scaffoldSpecFile, err)
+	}
+	for i, sp := range spec.Prompts {
+		if sp.Name == "" {
+			return nil, fmt.Errorf("%s: prompt %d has no name", scaffoldSpecFile, i)
+		}
+	}
+	return spec, nil
+}
+
+// askScaffoldPrompts prompts for the values that haven't been supplied.
+// Defaults are templates, so they can be derived from earlier answers.
+func askScaffoldPrompts(ctx context.Context, tr *Renderer, prompts []scaffoldPrompt, values map[string]interface{}, p *prompt.Prompter) error {
+	for _, sp := range prompts {
+		if v, ok := values[sp.Name]; ok && v != nil && conv.ToString(v) != "" {
+			continue
+		}
+
+		def, err := renderString(ctx, tr, "default for "+sp.Name, sp.Default)
+		if err != nil {
+			return err
+		}
+
+		message := sp.Message
+		if message == "" {
+			message = sp.Name
+		}
+
+		var answer string
+		switch {
+		case sp.Secret:
+			answer, err = p.Password(message)
+		case len(sp.Choices) > 0:
+			answer, err = p.Select(message, sp.Choices, def)
+		default:
+			answer, err = p.String(message, def)
+		}
+		if err != nil {
+			return err
+		}
+		values[sp.Name] = answer
+	}
+	return nil
+}
+
+// planScaffold renders the scaffold's names and contents, without writing
+// anything, so that nothing is written if any template fails.
+func planScaffold(ctx context.Context, tr *Renderer, src, dest string, spec *scaffoldSpec) ([]scaffoldFile, error) {
+	matches, err := xignore.NewMatcher(aferoFS).Matches(src, &xignore.MatchesOptions{
+		Ignorefile: gomplateignore,
+		Nested:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ignore matching failed for %s: %w", src, err)
+	}
+
+	paths := matches.UnmatchedFiles
+	sort.Strings(paths)
+
+	files := []scaffoldFile{}
+	for _, rel := range paths {
+		if rel == scaffoldSpecFile || filepath.Base(rel) == gomplateignore {
+			continue
+		}
+
+		outRel, err := renderPath(ctx, tr, rel)
+		if err != nil {
+			return nil, err
+		}
+		if outRel == "" {
+			continue
+		}
+
+		in := filepath.Join(src, rel)
+		fi, err := aferoFS.Stat(in)
+		if err != nil {
+			return nil, err
+		}
+		b, err := afero.ReadFile(aferoFS, in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", in, err)
+		}
+
+		if !copyOnly(rel, spec.CopyOnly) && !isBinary(b) {
+			out := &bytes.Buffer{}
+			err = tr.RenderTemplates(ctx, []Template{{Name: in, Text: string(b), Writer: out}})
+			if err != nil {
+				return nil, err
+			}
+			b = out.Bytes()
+		}
+
+		files = append(files, scaffoldFile{
+			out:     filepath.Join(dest, outRel),
+			mode:    fi.Mode().Perm(),
+			content: b,
+		})
+	}
+	return files, nil
+}
+
+// renderPath renders each element of the path as a template. If any element
+// renders to an empty string, the result is empty, and the file is skipped.
+func renderPath(ctx context.Context, tr *Renderer, rel string) (string, error) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts {
+		s, err := renderString(ctx, tr, rel, part)
+		if err != nil {
+			return "", err
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return "", nil
+		}
+		if strings.ContainsAny(s, `/\`) || s == "." || s == ".." {
+			return "", fmt.Errorf("name %q in %s rendered to invalid name %q", part, rel, s)
+		}
+		parts[i] = s
+	}
+	return filepath.Join(parts...), nil
+}
+
+func renderString(ctx context.Context, tr *Renderer, name, text string) (string, error) {
+	out := &bytes.Buffer{}
+	err := tr.RenderTemplates(ctx, []Template{{Name: name, Text: text, Writer: out}})
+	return out.String(), err
+}
+
+// copyOnly reports whether the file matches one of the globs, by its path
+// or its base name
+func copyOnly(rel string, globs []string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(g, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary reports whether the content looks binary - like git, this is
+// judged by whether there's a NUL byte near the start
+func isBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
@@ -0,0 +1,114 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/prompt"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffold(t *testing.T) {
+	ctx := context.Background()
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = afero.WriteFile(aferoFS, "/sc/.gomplate-new.yaml", []byte(`prompts:
+  - name: name
+    message: Project name
+  - name: module
+    default: 'example.com/{{ .Values.name }}'
+  - name: license
+    choices: [MIT, Apache-2.0]
+copyOnly: ['*.png']
+`), 0o644)
+	_ = afero.WriteFile(aferoFS, "/sc/{{ .Values.name }}/go.mod", []byte("module {{ .Values.module }}\n"), 0o644)
+	_ = afero.WriteFile(aferoFS, "/sc/{{ .Values.name }}/run.sh", []byte("echo {{ .Values.name }}\n"), 0o755)
+	_ = afero.WriteFile(aferoFS, "/sc/{{ .Values.name }}/logo.png", []byte("{{ not a template"), 0o644)
+	_ = afero.WriteFile(aferoFS, "/sc/{{ .Values.name }}/data.bin", []byte("{{\x00"), 0o644)
+	_ = afero.WriteFile(aferoFS, `/sc/{{ if eq .Values.license "MIT" }}LICENSE{{ end }}`, []byte("MIT"), 0o644)
+	_ = afero.WriteFile(aferoFS, "/sc/.gomplateignore", []byte("*.bak\n"), 0o644)
+	_ = afero.WriteFile(aferoFS, "/sc/old.bak", []byte("{{ bogus }}"), 0o644)
+
+	out := &bytes.Buffer{}
+	p := &prompt.Prompter{In: strings.NewReader("\n2\n"), Out: out, Interactive: true}
+	err := Scaffold(ctx, ScaffoldOptions{
+		Source:    "/sc",
+		Dest:      "/out",
+		SetValues: []string{"name=app"},
+		Prompter:  p,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "module [example.com/app]: ")
+
+	b, err := afero.ReadFile(aferoFS, "/out/app/go.mod")
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/app\n", string(b))
+
+	fi, err := aferoFS.Stat("/out/app/run.sh")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0o755, fi.Mode().Perm())
+
+	b, err = afero.ReadFile(aferoFS, "/out/app/logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, "{{ not a template", string(b))
+	b, err = afero.ReadFile(aferoFS, "/out/app/data.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "{{\x00", string(b))
+
+	for _, f := range []string{"/out/LICENSE", "/out/old.bak", "/out/.gomplate-new.yaml", "/out/.gomplateignore"} {
+		_, err = aferoFS.Stat(f)
+		assert.Error(t, err, f)
+	}
+
+	// existing files aren't overwritten without Force
+	opts := ScaffoldOptions{
+		Source:   "/sc",
+		Dest:     "/out",
+		Values:   map[string]interface{}{"name": "app", "module": "m", "license": "MIT"},
+		Prompter: &prompt.Prompter{},
+	}
+	err = Scaffold(ctx, opts)
+	assert.Error(t, err)
+	_, err = aferoFS.Stat("/out/LICENSE")
+	assert.Error(t, err)
+
+	opts.Force = true
+	err = Scaffold(ctx, opts)
+	require.NoError(t, err)
+	b, err = afero.ReadFile(aferoFS, "/out/app/go.mod")
+	require.NoError(t, err)
+	assert.Equal(t, "module m\n", string(b))
+	_, err = aferoFS.Stat("/out/LICENSE")
+	assert.NoError(t, err)
+
+	// missing values can't be prompted for when not interactive
+	err = Scaffold(ctx, ScaffoldOptions{Source: "/sc", Dest: "/out2", Prompter: &prompt.Prompter{}})
+	assert.Error(t, err)
+
+	err = Scaffold(ctx, ScaffoldOptions{Source: "/missing", Dest: "/out"})
+	assert.Error(t, err)
+}
+
+func TestRenderPath(t *testing.T) {
+	ctx := context.Background()
+	tr := NewRenderer(Options{Values: map[string]interface{}{"name": "foo", "slash": "a/b"}})
+
+	p, err := renderPath(ctx, tr, "{{ .Values.name }}/x.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "foo/x.txt", p)
+
+	p, err = renderPath(ctx, tr, "{{ if false }}dir{{ end }}/x.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "", p)
+
+	_, err = renderPath(ctx, tr, "{{ .Values.slash }}")
+	assert.Error(t, err)
+	_, err = renderPath(ctx, tr, "{{ bogus }}")
+	assert.Error(t, err)
+}
@@ -0,0 +1,363 @@
+// Package schema contains functions for generating values from JSON Schemas
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/openapi"
+)
+
+// Defaults - generate a value from the defaults in the schema. Objects are
+// populated with the defaults of their properties, and properties without
+// defaults are omitted. If the schema has no defaults at all, the result is
+// nil (or an empty map, for object schemas).
+func Defaults(s map[string]interface{}) (interface{}, error) {
+	g := &generator{root: s, defaults: true}
+	v, err := g.generate(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil && isObject(s) {
+		return map[string]interface{}{}, nil
+	}
+	return v, nil
+}
+
+// Example - generate an example value from the schema. Values are taken from
+// the example, examples, default, const, and enum keywords where present,
+// and are otherwise made up to match the schema's type, format, and
+// constraints. All object properties are included.
+func Example(s map[string]interface{}) (interface{}, error) {
+	g := &generator{root: s}
+	return g.generate(s, nil)
+}
+
+type generator struct {
+	root map[string]interface{}
+	// defaults is set when only generating defaults
+	defaults bool
+}
+
+// generate the value for a schema. The stack holds the references being
+// expanded, so that recursive schemas don't expand infinitely.
+func (g *generator) generate(s map[string]interface{}, stack []string) (interface{}, error) {
+	if ref, ok := s["$ref"].(string); ok {
+		if !strings.HasPrefix(ref, "#") {
+			return nil, fmt.Errorf("can not resolve non-local $ref %q", ref)
+		}
+		for _, r := range stack {
+			if r == ref {
+				return nil, nil
+			}
+		}
+		target, err := openapi.Pointer(g.root, ref[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve $ref %q: %w", ref, err)
+		}
+		ts, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref %q does not refer to a schema", ref)
+		}
+		return g.generate(ts, append(stack, ref))
+	}
+
+	if v, ok := g.literal(s); ok {
+		return v, nil
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		return g.allOf(s, all, stack)
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if l, ok := s[k].([]interface{}); ok && len(l) > 0 {
+			if sub, ok := l[0].(map[string]interface{}); ok {
+				return g.generate(sub, stack)
+			}
+		}
+	}
+
+	switch schemaType(s) {
+	case "object":
+		return g.object(s, stack)
+	case "array":
+		return g.array(s, stack)
+	}
+	if g.defaults {
+		return nil, nil
+	}
+	return scalarExample(s), nil
+}
+
+// literal returns a value given directly in the schema
+func (g *generator) literal(s map[string]interface{}) (interface{}, bool) {
+	keys := []string{"default"}
+	if !g.defaults {
+		keys = []string{"example", "examples", "default", "const", "enum"}
+	}
+	for _, k := range keys {
+		v, ok := s[k]
+		if !ok {
+			continue
+		}
+		switch k {
+		case "examples", "enum":
+			// only JSON Schema's list form of examples can be used - in
+			// OpenAPI, examples is a map of named example objects
+			if l, ok := v.([]interface{}); ok && len(l) > 0 {
+				return copyValue(l[0]), true
+			}
+			continue
+		}
+		return copyValue(v), true
+	}
+	return nil, false
+}
+
+func (g *generator) object(s map[string]interface{}, stack []string) (interface{}, error) {
+	out := map[string]interface{}{}
+	props, _ := s["properties"].(map[string]interface{})
+	names := make([]string, 0, len(props))
+	for k := range props {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ps, ok := props[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		v, err := g.generate(ps, stack)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if v == nil && (g.defaults || isRef(ps)) {
+			// omit properties without defaults, and recursive properties
+			continue
+		}
+		if m, ok := v.(map[string]interface{}); ok && g.defaults && len(m) == 0 {
+			continue
+		}
+		out[name] = v
+	}
+	if g.defaults && len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func (g *generator) array(s map[string]interface{}, stack []string) (interface{}, error) {
+	if g.defaults {
+		return nil, nil
+	}
+	items, ok := s["items"].(map[string]interface{})
+	if !ok {
+		return []interface{}{}, nil
+	}
+	v, err := g.generate(items, stack)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil && isRef(items) {
+		return []interface{}{}, nil
+	}
+	n := 1
+	if m, ok := toInt(s["minItems"]); ok && m > n {
+		n = m
+	}
+	out := make([]interface{}, n)
+	for i := range out {
+		out[i] = copyValue(v)
+	}
+	return out, nil
+}
+
+// allOf merges the values generated for each subschema, along with the
+// schema's own properties
+func (g *generator) allOf(s map[string]interface{}, all []interface{}, stack []string) (interface{}, error) {
+	var out interface{}
+	merge := func(v interface{}) {
+		om, ok1 := out.(map[string]interface{})
+		vm, ok2 := v.(map[string]interface{})
+		if ok1 && ok2 {
+			for k, e := range vm {
+				om[k] = e
+			}
+			return
+		}
+		if v != nil {
+			out = v
+		}
+	}
+	for _, sub := range all {
+		ss, ok := sub.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		v, err := g.generate(ss, stack)
+		if err != nil {
+			return nil, err
+		}
+		merge(v)
+	}
+
+	rest := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		if k != "allOf" {
+			rest[k] = v
+		}
+	}
+	if _, ok := rest["properties"]; ok {
+		v, err := g.generate(rest, stack)
+		if err != nil {
+			return nil, err
+		}
+		merge(v)
+	}
+	return out, nil
+}
+
+// schemaType returns the schema's type, inferring it from other keywords if
+// it's not set. When a list of types is given, the first non-null type is
+// used.
+func schemaType(s map[string]interface{}) string {
+	switch t := s["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, e := range t {
+			if e != "null" {
+				return fmt.Sprint(e)
+			}
+		}
+		return "null"
+	}
+	switch {
+	case s["properties"] != nil || s["additionalProperties"] != nil || s["required"] != nil:
+		return "object"
+	case s["items"] != nil:
+		return "array"
+	case s["minLength"] != nil || s["maxLength"] != nil || s["pattern"] != nil || s["format"] != nil:
+		return "string"
+	case s["minimum"] != nil || s["maximum"] != nil || s["multipleOf"] != nil:
+		return "number"
+	}
+	return ""
+}
+
+func isObject(s map[string]interface{}) bool {
+	return schemaType(s) == "object"
+}
+
+func isRef(s map[string]interface{}) bool {
+	_, ok := s["$ref"]
+	return ok
+}
+
+// formatExamples are example values for the common string formats
+var formatExamples = map[string]string{
+	"date-time": "2006-01-02T15:04:05Z",
+	"date":      "2006-01-02",
+	"time":      "15:04:05Z",
+	"duration":  "P1D",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"byte":      "c3RyaW5n",
+	"password":  "********",
+}
+
+func scalarExample(s map[string]interface{}) interface{} {
+	switch schemaType(s) {
+	case "string":
+		str := "string"
+		if f, ok := formatExamples[fmt.Sprint(s["format"])]; ok {
+			str = f
+		}
+		if n, ok := toInt(s["minLength"]); ok && len(str) < n {
+			str += strings.Repeat("x", n-len(str))
+		}
+		if n, ok := toInt(s["maxLength"]); ok && len(str) > n {
+			str = str[:n]
+		}
+		return str
+	case "integer":
+		return int(numberExample(s, 1))
+	case "number":
+		return numberExample(s, 0.5)
+	case "boolean":
+		return false
+	default:
+		return nil
+	}
+}
+
+// numberExample returns 0 if it's within the schema's bounds, or else the
+// lowest (or highest) allowed value. The step is used to move past exclusive
+// bounds.
+func numberExample(s map[string]interface{}, step float64) float64 {
+	v := 0.0
+	if m, ok := toFloat(s["minimum"]); ok && v < m {
+		v = m
+		if excl, _ := s["exclusiveMinimum"].(bool); excl {
+			v += step
+		}
+	}
+	if m, ok := toFloat(s["exclusiveMinimum"]); ok && v <= m {
+		v = m + step
+	}
+	if m, ok := toFloat(s["maximum"]); ok && v > m {
+		v = m
+		if excl, _ := s["exclusiveMaximum"].(bool); excl {
+			v -= step
+		}
+	}
+	if m, ok := toFloat(s["exclusiveMaximum"]); ok && v >= m {
+		v = m - step
+	}
+	return v
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func toInt(v interface{}) (int, bool) {
+	f, ok := toFloat(v)
+	return int(f), ok
+}
+
+func copyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[k] = copyValue(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = copyValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
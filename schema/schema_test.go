@@ -0,0 +1,142 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"name"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "examples": []interface{}{"svc"}},
+			"port":  map[string]interface{}{"type": "integer", "default": 8080},
+			"debug": map[string]interface{}{"type": "boolean"},
+			"tls": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"enabled": map[string]interface{}{"type": "boolean", "default": true},
+					"cert":    map[string]interface{}{"type": "string"},
+				},
+			},
+			"empty": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"a": map[string]interface{}{"type": "string"},
+				},
+			},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "default": []interface{}{"a"}},
+			"owner": map[string]interface{}{"$ref": "#/$defs/Person"},
+		},
+		"$defs": map[string]interface{}{
+			"Person": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"email":   map[string]interface{}{"type": "string", "format": "email", "default": "ops@example.com"},
+					"manager": map[string]interface{}{"$ref": "#/$defs/Person"},
+				},
+			},
+		},
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	v, err := Defaults(testSchema())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"port":  8080,
+		"tls":   map[string]interface{}{"enabled": true},
+		"tags":  []interface{}{"a"},
+		"owner": map[string]interface{}{"email": "ops@example.com"},
+	}, v)
+
+	v, err = Defaults(map[string]interface{}{"type": "object"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, v)
+
+	v, err = Defaults(map[string]interface{}{"type": "string"})
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = Defaults(map[string]interface{}{"type": "string", "default": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "x", v)
+
+	// defaults of allOf subschemas are merged
+	v, err = Defaults(map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"properties": map[string]interface{}{"a": map[string]interface{}{"default": 1}}},
+			map[string]interface{}{"properties": map[string]interface{}{"b": map[string]interface{}{"default": 2}}},
+		},
+		"properties": map[string]interface{}{"c": map[string]interface{}{"default": 3}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2, "c": 3}, v)
+
+	_, err = Defaults(map[string]interface{}{
+		"properties": map[string]interface{}{"a": map[string]interface{}{"$ref": "other.json"}},
+	})
+	assert.ErrorContains(t, err, `a: can not resolve non-local $ref "other.json"`)
+
+	_, err = Defaults(map[string]interface{}{"$ref": "#/nope"})
+	assert.ErrorContains(t, err, `failed to resolve $ref "#/nope"`)
+}
+
+func TestExample(t *testing.T) {
+	v, err := Example(testSchema())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":  "svc",
+		"port":  8080,
+		"debug": false,
+		"tls":   map[string]interface{}{"enabled": true, "cert": "string"},
+		"empty": map[string]interface{}{"a": "string"},
+		"tags":  []interface{}{"a"},
+		"owner": map[string]interface{}{"email": "ops@example.com"},
+	}, v)
+
+	testdata := []struct {
+		schema   map[string]interface{}
+		expected interface{}
+	}{
+		{map[string]interface{}{"type": "string", "example": "ex"}, "ex"},
+		{map[string]interface{}{"const": "c"}, "c"},
+		{map[string]interface{}{"enum": []interface{}{"a", "b"}}, "a"},
+		{map[string]interface{}{"type": "string", "format": "uuid"}, "00000000-0000-0000-0000-000000000000"},
+		{map[string]interface{}{"type": "string", "minLength": 8}, "stringxx"},
+		{map[string]interface{}{"type": "string", "maxLength": 3}, "str"},
+		{map[string]interface{}{"type": "integer"}, 0},
+		{map[string]interface{}{"type": "integer", "minimum": 5}, 5},
+		{map[string]interface{}{"type": "integer", "minimum": 5, "exclusiveMinimum": true}, 6},
+		{map[string]interface{}{"type": "integer", "exclusiveMinimum": 5}, 6},
+		{map[string]interface{}{"type": "integer", "maximum": -5}, -5},
+		{map[string]interface{}{"type": "number", "exclusiveMaximum": -1}, -1.5},
+		{map[string]interface{}{"type": "boolean"}, false},
+		{map[string]interface{}{"type": "null"}, nil},
+		{map[string]interface{}{"type": []interface{}{"null", "integer"}}, 0},
+		{map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}, "minItems": 2}, []interface{}{0, 0}},
+		{map[string]interface{}{"type": "array"}, []interface{}{}},
+		{map[string]interface{}{"oneOf": []interface{}{map[string]interface{}{"type": "boolean"}, map[string]interface{}{"type": "string"}}}, false},
+		{map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "string"}}}, "string"},
+		{map[string]interface{}{"examples": map[string]interface{}{"named": map[string]interface{}{"value": 1}}, "type": "integer"}, 0},
+	}
+	for _, d := range testdata {
+		v, err := Example(d.schema)
+		require.NoError(t, err)
+		assert.Equal(t, d.expected, v, "schema: %v", d.schema)
+	}
+
+	// recursive arrays are empty
+	v, err = Example(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"children": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"children": []interface{}{map[string]interface{}{"children": []interface{}{}}},
+	}, v)
+}
@@ -0,0 +1,512 @@
+package script
+
+import (
+	"fmt"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/ast"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// approximate sizes of tables, and of each value in them, for the memory
+// budget
+const (
+	tableSize = 128
+	slotSize  = 16
+)
+
+// names of the functions that the '..' operator, table constructors, and
+// assignments to table fields are rewritten to call. They aren't valid Lua
+// names, so scripts can't refer to them.
+const (
+	concatFn   = "\x00concat"
+	newTableFn = "\x00table"
+	setFieldFn = "\x00set"
+)
+
+// errNoMemory is the error raised in scripts that exceed the memory limit -
+// it's the same message as Lua's
+const errNoMemory = "not enough memory"
+
+// sandbox runs scripts with a budget of bytes they can allocate. Lua strings
+// and tables are allocated by the '..' operator, table constructors, and
+// assignments to table fields, which are rewritten into calls to functions
+// that charge the budget, and by library functions, which are wrapped to do
+// the same. The budget is for everything a script allocates, including values
+// that are no longer used.
+type sandbox struct {
+	L        *lua.LState
+	helpers  []lua.LValue
+	limit    int64
+	used     int64
+	exceeded bool
+}
+
+func newSandbox(L *lua.LState, limit int64) *sandbox {
+	sb := &sandbox{L: L, limit: limit}
+	sb.helpers = []lua.LValue{
+		L.NewFunction(sb.concat),
+		L.NewFunction(sb.newTable),
+		L.NewFunction(sb.setField),
+	}
+	sb.wrapLibs()
+	return sb
+}
+
+// check raises an error in the script if allocating n more bytes would exceed
+// the budget. Once it's exceeded, every later allocation fails too, even if
+// the script catches the error.
+func (sb *sandbox) check(n int64) {
+	if sb.exceeded || n > sb.limit-sb.used {
+		sb.exceeded = true
+		sb.L.RaiseError(errNoMemory)
+	}
+}
+
+// reserve charges n bytes to the budget, raising an error in the script when
+// the budget is exceeded
+func (sb *sandbox) reserve(n int64) {
+	sb.check(n)
+	sb.used += n
+}
+
+// compile parses the code and compiles it into a function that charges the
+// budget for what it allocates
+func (sb *sandbox) compile(code, name string) (*lua.LFunction, error) {
+	chunk, err := parse.Parse(strings.NewReader(code), name)
+	if err != nil {
+		return nil, err
+	}
+	rewriteStmts(chunk)
+
+	// wrap the chunk as 'return function(concat, table, set) return
+	// function(...) <chunk> end end', so that the helpers are upvalues that
+	// scripts can't replace
+	inner := &ast.FunctionExpr{ParList: &ast.ParList{HasVargs: true}, Stmts: chunk}
+	outer := &ast.FunctionExpr{
+		ParList: &ast.ParList{Names: []string{concatFn, newTableFn, setFieldFn}},
+		Stmts:   []ast.Stmt{&ast.ReturnStmt{Exprs: []ast.Expr{inner}}},
+	}
+	proto, err := lua.Compile([]ast.Stmt{&ast.ReturnStmt{Exprs: []ast.Expr{outer}}}, name)
+	if err != nil {
+		return nil, err
+	}
+
+	L := sb.L
+	L.Push(L.NewFunctionFromProto(proto))
+	L.Call(0, 1)
+	for _, h := range sb.helpers {
+		L.Push(h)
+	}
+	L.Call(len(sb.helpers), 1)
+	fn := L.CheckFunction(-1)
+	L.Pop(1)
+	return fn, nil
+}
+
+// concat implements the '..' operator for all of its operands at once
+func (sb *sandbox) concat(L *lua.LState) int {
+	n := L.GetTop()
+	size := int64(0)
+	for i := 1; i <= n; i++ {
+		if !lua.LVCanConvToString(L.Get(i)) {
+			size = -1
+			break
+		}
+		size += int64(len(lua.LVAsString(L.Get(i))))
+	}
+	if size >= 0 {
+		sb.reserve(size)
+		parts := make([]string, n)
+		for i := 1; i <= n; i++ {
+			parts[i-1] = lua.LVAsString(L.Get(i))
+		}
+		L.Push(lua.LString(strings.Join(parts, "")))
+		return 1
+	}
+
+	// some operands need the __concat metamethod, so concatenate them in
+	// pairs from the right, like Lua does
+	rhs := L.Get(n)
+	for i := n - 1; i >= 1; i-- {
+		lhs := L.Get(i)
+		if lua.LVCanConvToString(lhs) && lua.LVCanConvToString(rhs) {
+			l, r := lua.LVAsString(lhs), lua.LVAsString(rhs)
+			sb.reserve(int64(len(l) + len(r)))
+			rhs = lua.LString(l + r)
+			continue
+		}
+		op := L.GetMetaField(lhs, "__concat")
+		if op == lua.LNil {
+			op = L.GetMetaField(rhs, "__concat")
+		}
+		if op.Type() != lua.LTFunction {
+			L.RaiseError("cannot perform concat operation between %v and %v", lhs.Type(), rhs.Type())
+		}
+		L.Push(op)
+		L.Push(lhs)
+		L.Push(rhs)
+		L.Call(2, 1)
+		rhs = L.Get(-1)
+		L.Pop(1)
+	}
+	L.Push(rhs)
+	return 1
+}
+
+// newTable charges the budget for a table built by a table constructor
+func (sb *sandbox) newTable(L *lua.LState) int {
+	t := L.CheckTable(1)
+	n := 0
+	t.ForEach(func(_, _ lua.LValue) { n++ })
+	sb.reserve(tableSize + int64(n)*slotSize)
+	L.SetTop(1)
+	return 1
+}
+
+// setField implements assignments to table fields ('t[k] = v' and 't.k =
+// v'), charging the budget when a field is added
+func (sb *sandbox) setField(L *lua.LState) int {
+	obj, k, v := L.Get(1), L.Get(2), L.Get(3)
+	sb.chargeField(obj, k, v)
+	L.SetTable(obj, k, v)
+	return 0
+}
+
+// chargeField charges the budget when setting the key would add a field to
+// the table
+func (sb *sandbox) chargeField(obj, k, v lua.LValue) {
+	if t, ok := obj.(*lua.LTable); ok && v != lua.LNil && k != lua.LNil && t.RawGet(k) == lua.LNil {
+		sb.reserve(slotSize)
+	}
+}
+
+// wrapLibs replaces the library functions that allocate strings or grow
+// tables with versions that charge the budget. Must be called after the
+// libraries are opened.
+func (sb *sandbox) wrapLibs() {
+	L := sb.L
+	strs := L.GetGlobal(lua.StringLibName).(*lua.LTable)
+	tabs := L.GetGlobal(lua.TabLibName).(*lua.LTable)
+	global := L.G.Global
+
+	// wrap replaces the function with one that calls check (if it's set)
+	// first, and charges for the strings it returns
+	wrap := func(t *lua.LTable, name string, check func(L *lua.LState)) {
+		orig := t.RawGetString(name).(*lua.LFunction).GFunction
+		t.RawSetString(name, L.NewFunction(func(L *lua.LState) int {
+			if check != nil {
+				check(L)
+			}
+			n := orig(L)
+			for i := 1; i <= n; i++ {
+				if s, ok := L.Get(-i).(lua.LString); ok {
+					sb.reserve(int64(len(s)))
+				}
+			}
+			return n
+		}))
+	}
+
+	wrap(strs, "rep", func(L *lua.LState) {
+		s, n := L.CheckString(1), L.CheckInt(2)
+		if n > 0 && len(s) > 0 {
+			if int64(n) > (sb.limit-sb.used)/int64(len(s)) {
+				sb.check(sb.limit + 1)
+			}
+			sb.check(int64(len(s)) * int64(n))
+		}
+	})
+	wrap(strs, "format", func(L *lua.LState) {
+		if err := checkFormat(L.CheckString(1)); err != nil {
+			L.RaiseError(err.Error())
+		}
+	})
+	for _, name := range []string{"char", "gsub", "lower", "reverse", "upper"} {
+		wrap(strs, name, nil)
+	}
+	wrap(global, "tostring", nil)
+
+	wrap(tabs, "concat", func(L *lua.LState) {
+		t := L.CheckTable(1)
+		sep := L.OptString(2, "")
+		i, j := L.OptInt(3, 1), L.OptInt(4, t.Len())
+		size := int64(0)
+		for ; i <= j; i++ {
+			v := t.RawGetInt(i)
+			if !lua.LVCanConvToString(v) {
+				// the original raises the error
+				return
+			}
+			size += int64(len(lua.LVAsString(v)))
+			if i < j {
+				size += int64(len(sep))
+			}
+			if size > sb.limit-sb.used {
+				break
+			}
+		}
+		sb.check(size)
+	})
+	wrap(tabs, "insert", func(L *lua.LState) {
+		sb.reserve(slotSize)
+	})
+	wrap(global, "rawset", func(L *lua.LState) {
+		sb.chargeField(L.Get(1), L.Get(2), L.Get(3))
+	})
+
+	// chunks loaded by scripts are compiled the same way, so that they're
+	// charged too
+	global.RawSetString("loadstring", L.NewFunction(func(L *lua.LState) int {
+		return sb.load(L, L.CheckString(1), L.OptString(2, "<string>"))
+	}))
+	global.RawSetString("load", L.NewFunction(func(L *lua.LState) int {
+		fn := L.CheckFunction(1)
+		name := L.OptString(2, "=(load)")
+		parts := []string{}
+		for {
+			L.Push(fn)
+			L.Call(0, 1)
+			part := L.Get(-1)
+			L.Pop(1)
+			if part == lua.LNil || part == lua.LString("") {
+				break
+			}
+			if !lua.LVCanConvToString(part) {
+				L.Push(lua.LNil)
+				L.Push(lua.LString("reader function must return a string"))
+				return 2
+			}
+			s := lua.LVAsString(part)
+			sb.reserve(int64(len(s)))
+			parts = append(parts, s)
+		}
+		return sb.load(L, strings.Join(parts, ""), name)
+	}))
+}
+
+// load compiles a chunk for loadstring and load, returning the function, or
+// nil and the error
+func (sb *sandbox) load(L *lua.LState, code, name string) int {
+	fn, err := sb.compile(code, name)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(fn)
+	return 1
+}
+
+// checkFormat rejects string.format formats with widths or precisions of
+// more than 2 digits, like Lua does - they could make huge strings
+func checkFormat(format string) error {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+			i++
+		}
+		for part := 0; part < 2 && i < len(format); part++ {
+			digits := 0
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				digits++
+				i++
+			}
+			if digits > 2 {
+				return fmt.Errorf("invalid format (width or precision too long)")
+			}
+			if part > 0 || i >= len(format) || format[i] != '.' {
+				break
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// rewriteStmts rewrites the '..' operator, table constructors, and
+// assignments to table fields in the statements into calls to the sandbox's
+// functions
+func rewriteStmts(stmts []ast.Stmt) {
+	for i, s := range stmts {
+		stmts[i] = rewriteStmt(s)
+	}
+}
+
+func rewriteStmt(s ast.Stmt) ast.Stmt {
+	switch s := s.(type) {
+	case *ast.AssignStmt:
+		rewriteExprs(s.Rhs)
+		return rewriteAssign(s)
+	case *ast.LocalAssignStmt:
+		rewriteExprs(s.Exprs)
+	case *ast.FuncCallStmt:
+		s.Expr = rewriteExpr(s.Expr)
+	case *ast.DoBlockStmt:
+		rewriteStmts(s.Stmts)
+	case *ast.WhileStmt:
+		s.Condition = rewriteExpr(s.Condition)
+		rewriteStmts(s.Stmts)
+	case *ast.RepeatStmt:
+		s.Condition = rewriteExpr(s.Condition)
+		rewriteStmts(s.Stmts)
+	case *ast.IfStmt:
+		s.Condition = rewriteExpr(s.Condition)
+		rewriteStmts(s.Then)
+		rewriteStmts(s.Else)
+	case *ast.NumberForStmt:
+		s.Init = rewriteExpr(s.Init)
+		s.Limit = rewriteExpr(s.Limit)
+		s.Step = rewriteExpr(s.Step)
+		rewriteStmts(s.Stmts)
+	case *ast.GenericForStmt:
+		rewriteExprs(s.Exprs)
+		rewriteStmts(s.Stmts)
+	case *ast.FuncDefStmt:
+		s.Name.Func = rewriteExpr(s.Name.Func)
+		s.Name.Receiver = rewriteExpr(s.Name.Receiver)
+		rewriteStmts(s.Func.Stmts)
+	case *ast.ReturnStmt:
+		rewriteExprs(s.Exprs)
+	}
+	return s
+}
+
+// rewriteAssign rewrites assignments to table fields into calls to the set
+// function. When several values are assigned at once, they're assigned to
+// locals first, so that they're all evaluated before any are assigned.
+func rewriteAssign(s *ast.AssignStmt) ast.Stmt {
+	fields := false
+	for _, lhs := range s.Lhs {
+		if a, ok := lhs.(*ast.AttrGetExpr); ok {
+			a.Object = rewriteExpr(a.Object)
+			a.Key = rewriteExpr(a.Key)
+			fields = true
+		}
+	}
+	if !fields {
+		return s
+	}
+
+	if len(s.Lhs) == 1 {
+		a := s.Lhs[0].(*ast.AttrGetExpr)
+		return callStmt(s, setFieldFn, append([]ast.Expr{a.Object, a.Key}, s.Rhs...)...)
+	}
+
+	names := make([]string, len(s.Lhs))
+	for i := range names {
+		names[i] = fmt.Sprintf("\x00v%d", i)
+	}
+	local := &ast.LocalAssignStmt{Names: names, Exprs: s.Rhs}
+	at(local, s)
+	block := &ast.DoBlockStmt{Stmts: []ast.Stmt{local}}
+	at(block, s)
+	for i, lhs := range s.Lhs {
+		v := &ast.IdentExpr{Value: names[i]}
+		at(v, s)
+		if a, ok := lhs.(*ast.AttrGetExpr); ok {
+			block.Stmts = append(block.Stmts, callStmt(s, setFieldFn, a.Object, a.Key, v))
+			continue
+		}
+		assign := &ast.AssignStmt{Lhs: []ast.Expr{lhs}, Rhs: []ast.Expr{v}}
+		at(assign, s)
+		block.Stmts = append(block.Stmts, assign)
+	}
+	return block
+}
+
+func rewriteExprs(exprs []ast.Expr) {
+	for i, e := range exprs {
+		exprs[i] = rewriteExpr(e)
+	}
+}
+
+func rewriteExpr(e ast.Expr) ast.Expr {
+	switch e := e.(type) {
+	case *ast.AttrGetExpr:
+		e.Object = rewriteExpr(e.Object)
+		e.Key = rewriteExpr(e.Key)
+	case *ast.TableExpr:
+		for _, f := range e.Fields {
+			f.Key = rewriteExpr(f.Key)
+			f.Value = rewriteExpr(f.Value)
+		}
+		return call(e, newTableFn, e)
+	case *ast.FuncCallExpr:
+		e.Func = rewriteExpr(e.Func)
+		e.Receiver = rewriteExpr(e.Receiver)
+		rewriteExprs(e.Args)
+	case *ast.LogicalOpExpr:
+		e.Lhs = rewriteExpr(e.Lhs)
+		e.Rhs = rewriteExpr(e.Rhs)
+	case *ast.RelationalOpExpr:
+		e.Lhs = rewriteExpr(e.Lhs)
+		e.Rhs = rewriteExpr(e.Rhs)
+	case *ast.ArithmeticOpExpr:
+		e.Lhs = rewriteExpr(e.Lhs)
+		e.Rhs = rewriteExpr(e.Rhs)
+	case *ast.StringConcatOpExpr:
+		operands := concatOperands(e)
+		for i, o := range operands {
+			operands[i] = singleValue(rewriteExpr(o))
+		}
+		return call(e, concatFn, operands...)
+	case *ast.UnaryMinusOpExpr:
+		e.Expr = rewriteExpr(e.Expr)
+	case *ast.UnaryNotOpExpr:
+		e.Expr = rewriteExpr(e.Expr)
+	case *ast.UnaryLenOpExpr:
+		e.Expr = rewriteExpr(e.Expr)
+	case *ast.FunctionExpr:
+		rewriteStmts(e.Stmts)
+	}
+	return e
+}
+
+// concatOperands - the operands of a chain of '..' operators, in order
+func concatOperands(e ast.Expr) []ast.Expr {
+	c, ok := e.(*ast.StringConcatOpExpr)
+	if !ok {
+		return []ast.Expr{e}
+	}
+	return append(concatOperands(c.Lhs), concatOperands(c.Rhs)...)
+}
+
+// singleValue truncates function calls and '...' to their first values, as
+// operands of operators are
+func singleValue(e ast.Expr) ast.Expr {
+	switch e := e.(type) {
+	case *ast.FuncCallExpr:
+		e.AdjustRet = true
+	case *ast.Comma3Expr:
+		e.AdjustRet = true
+	}
+	return e
+}
+
+// call - a call to the named function, at the position of the node
+func call(pos ast.PositionHolder, name string, args ...ast.Expr) *ast.FuncCallExpr {
+	fn := &ast.IdentExpr{Value: name}
+	at(fn, pos)
+	c := &ast.FuncCallExpr{Func: fn, Args: args}
+	at(c, pos)
+	return c
+}
+
+// callStmt - a call statement for the named function, at the position of the
+// node
+func callStmt(pos ast.PositionHolder, name string, args ...ast.Expr) *ast.FuncCallStmt {
+	s := &ast.FuncCallStmt{Expr: call(pos, name, args...)}
+	at(s, pos)
+	return s
+}
+
+// at sets the node's position to pos's
+func at(node, pos ast.PositionHolder) {
+	node.SetLine(pos.Line())
+	node.SetLastLine(pos.LastLine())
+}
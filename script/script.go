@@ -0,0 +1,304 @@
+// Package script runs Lua scripts in a sandbox, for the script namespace.
+//
+// Scripts can only use Lua's base, string, table, and math libraries - there's
+// no access to files, the network, the environment, or other processes. Each
+// script runs in a new interpreter, with limits on its run time, call depth,
+// stack size, and the memory it allocates.
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Limits - the resources a script can use
+type Limits struct {
+	// Timeout - the maximum time a script can run for
+	Timeout time.Duration
+	// CallDepth - the maximum depth of nested function calls
+	CallDepth int
+	// StackSize - the maximum number of values on the data stack
+	StackSize int
+	// Memory - the maximum number of bytes of strings and tables a script
+	// can allocate, including those it no longer uses
+	Memory int64
+}
+
+// DefaultLimits - the limits used when none are given
+var DefaultLimits = Limits{
+	Timeout:   5 * time.Second,
+	CallDepth: 200,
+	StackSize: 256 * 1024,
+	Memory:    256 << 20,
+}
+
+type limitsCtxKey struct{}
+
+// ContextWithLimits returns a context with the limits, for the script
+// functions to use
+func ContextWithLimits(ctx context.Context, limits Limits) context.Context {
+	return context.WithValue(ctx, limitsCtxKey{}, limits)
+}
+
+// LimitsFromContext returns the limits in the context, or DefaultLimits if
+// there are none
+func LimitsFromContext(ctx context.Context) Limits {
+	if l, ok := ctx.Value(limitsCtxKey{}).(Limits); ok {
+		return l
+	}
+	return DefaultLimits
+}
+
+// maxDepth - the deepest nesting of tables (or Go maps and slices) that's
+// converted
+const maxDepth = 100
+
+// unsafe base functions, which are removed from the sandbox
+var unsafeFuncs = []string{"dofile", "loadfile", "print", "_printregs", "module", "require"}
+
+// Run runs the script, with the arguments in the args table (and as the
+// chunk's varargs, "..."), and returns its result converted to a Go value.
+// Multiple results are returned as a slice, and no result as nil. Zero limits
+// are set to the defaults. The name is used in error messages.
+func Run(ctx context.Context, name, code string, args []interface{}, limits Limits) (interface{}, error) {
+	if limits.Timeout == 0 {
+		limits.Timeout = DefaultLimits.Timeout
+	}
+	if limits.CallDepth == 0 {
+		limits.CallDepth = DefaultLimits.CallDepth
+	}
+	if limits.StackSize == 0 {
+		limits.StackSize = DefaultLimits.StackSize
+	}
+	if limits.Memory == 0 {
+		limits.Memory = DefaultLimits.Memory
+	}
+
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       limits.CallDepth,
+		RegistryMaxSize:     limits.StackSize,
+		MinimizeStackMemory: true,
+	})
+	defer L.Close()
+	openLibs(L)
+	sb := newSandbox(L, limits.Memory)
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	fn, err := sb.compile(code, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse script %s: %w", name, err)
+	}
+
+	argTable := L.NewTable()
+	L.Push(fn)
+	for _, a := range args {
+		v, err := toLua(L, reflect.ValueOf(a), 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument for script %s: %w", name, err)
+		}
+		argTable.Append(v)
+		L.Push(v)
+	}
+	L.SetGlobal("args", argTable)
+
+	if err := pcall(L, len(args)); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("script %s timed out after %v", name, limits.Timeout)
+		}
+		if sb.exceeded {
+			return nil, fmt.Errorf("script %s exceeded its memory limit of %d bytes", name, limits.Memory)
+		}
+		return nil, fmt.Errorf("script %s failed: %w", name, err)
+	}
+
+	n := L.GetTop()
+	results := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		v, err := fromLua(L.Get(i+1), 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result from script %s: %w", name, err)
+		}
+		results[i] = v
+	}
+
+	switch n {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
+
+// pcall calls the function on the stack. Some errors (like exceeding the stack
+// size) can make the interpreter panic while it builds the error, so panics
+// are recovered too.
+func pcall(L *lua.LState, nargs int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("exceeded resource limits: %v", r)
+		}
+	}()
+	return L.PCall(nargs, lua.MultRet, nil)
+}
+
+// openLibs opens the safe standard libraries
+func openLibs(L *lua.LState) {
+	for _, lib := range []struct {
+		fn   lua.LGFunction
+		name string
+	}{
+		{lua.OpenBase, lua.BaseLibName},
+		{lua.OpenTable, lua.TabLibName},
+		{lua.OpenString, lua.StringLibName},
+		{lua.OpenMath, lua.MathLibName},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, f := range unsafeFuncs {
+		L.SetGlobal(f, lua.LNil)
+	}
+}
+
+// toLua converts a Go value to a Lua value. Slices become sequences, and maps
+// become tables.
+func toLua(L *lua.LState, v reflect.Value, depth int) (lua.LValue, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("value nested too deeply (more than %d levels)", maxDepth)
+	}
+	if !v.IsValid() {
+		return lua.LNil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return lua.LNil, nil
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok && v.Kind() == reflect.Ptr {
+			return lua.LString(s.String()), nil
+		}
+		return toLua(L, v.Elem(), depth)
+	case reflect.Bool:
+		return lua.LBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lua.LNumber(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return lua.LNumber(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return lua.LNumber(v.Float()), nil
+	case reflect.String:
+		return lua.LString(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return lua.LString(v.Bytes()), nil
+		}
+		t := L.CreateTable(v.Len(), 0)
+		for i := 0; i < v.Len(); i++ {
+			e, err := toLua(L, v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t.Append(e)
+		}
+		return t, nil
+	case reflect.Map:
+		t := L.CreateTable(0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := toLua(L, iter.Key(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			e, err := toLua(L, iter.Value(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t.RawSet(k, e)
+		}
+		return t, nil
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return lua.LString(s.String()), nil
+	}
+	return nil, fmt.Errorf("can't convert %s to a Lua value", v.Type())
+}
+
+// fromLua converts a Lua value to a Go value. Whole numbers become ints, and
+// tables become slices when they're sequences (or empty), or maps otherwise.
+func fromLua(v lua.LValue, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("value nested too deeply (more than %d levels)", maxDepth)
+	}
+
+	switch v := v.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LString:
+		return string(v), nil
+	case lua.LNumber:
+		f := float64(v)
+		if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int(f), nil
+		}
+		return f, nil
+	case *lua.LTable:
+		return fromTable(v, depth)
+	}
+	return nil, fmt.Errorf("can't convert a Lua %s", v.Type())
+}
+
+func fromTable(t *lua.LTable, depth int) (interface{}, error) {
+	keys := []lua.LValue{}
+	t.ForEach(func(k, _ lua.LValue) {
+		keys = append(keys, k)
+	})
+
+	if n := t.MaxN(); n == len(keys) && isSequence(t, n) {
+		out := make([]interface{}, n)
+		for i := 0; i < n; i++ {
+			v, err := fromLua(t.RawGetInt(i+1), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	}
+
+	out := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		v, err := fromLua(t.RawGet(k), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out[k.String()] = v
+	}
+	return out, nil
+}
+
+// isSequence - whether the keys 1 to n are all set in the table
+func isSequence(t *lua.LTable, n int) bool {
+	for i := 1; i <= n; i++ {
+		if t.RawGetInt(i) == lua.LNil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package script
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	testdata := []struct {
+		expected interface{}
+		code     string
+		args     []interface{}
+	}{
+		{nil, `local x = 1`, nil},
+		{"hello", `return "hello"`, nil},
+		{3, `return 1 + 2`, nil},
+		{1.5, `return 3 / 2`, nil},
+		{true, `return args[1] == "a"`, []interface{}{"a"}},
+		{[]interface{}{"b", "a"}, `local a, b = ... return b, a`, []interface{}{"a", "b"}},
+		{[]interface{}{}, `return {}`, nil},
+		{[]interface{}{1, "two", false}, `return {1, "two", false}`, nil},
+		{map[string]interface{}{"a": 1, "2": "b"}, `return {a = 1, [2] = "b"}`, nil},
+		{
+			map[string]interface{}{"total": 6, "names": []interface{}{"X", "Y"}},
+			`local total, names = 0, {}
+			for _, item in ipairs(args[1]) do
+				total = total + item.n
+				table.insert(names, string.upper(item.name))
+			end
+			return {total = total, names = names}`,
+			[]interface{}{[]map[string]interface{}{{"n": 1, "name": "x"}, {"n": 5, "name": "y"}}},
+		},
+		{"2024-01-02 03:04:05 +0000 UTC", `return args[1]`, []interface{}{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}},
+	}
+
+	for _, d := range testdata {
+		d := d
+		t.Run(d.code, func(t *testing.T) {
+			out, err := Run(ctx, "test", d.code, d.args, Limits{})
+			require.NoError(t, err)
+			assert.Equal(t, d.expected, out)
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := Run(ctx, "test", `return (`, nil, Limits{})
+	assert.ErrorContains(t, err, "failed to parse script test")
+
+	_, err = Run(ctx, "test", `error("oops")`, nil, Limits{})
+	assert.ErrorContains(t, err, "script test failed")
+	assert.ErrorContains(t, err, "oops")
+
+	_, err = Run(ctx, "test", `return function() end`, nil, Limits{})
+	assert.EqualError(t, err, "invalid result from script test: can't convert a Lua function")
+
+	_, err = Run(ctx, "test", `return args[1]`, []interface{}{make(chan int)}, Limits{})
+	assert.EqualError(t, err, "invalid argument for script test: can't convert chan int to a Lua value")
+}
+
+func TestRun_Sandbox(t *testing.T) {
+	ctx := context.Background()
+
+	for _, code := range []string{
+		`return os.getenv("HOME")`,
+		`return io.open("/etc/passwd")`,
+		`return dofile("/etc/passwd")`,
+		`return loadfile("/etc/passwd")`,
+		`return require("os")`,
+		`print("hi")`,
+	} {
+		_, err := Run(ctx, "test", code, nil, Limits{})
+		assert.Error(t, err, code)
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := Run(ctx, "test", `while true do end`, nil, Limits{Timeout: 50 * time.Millisecond})
+	assert.EqualError(t, err, "script test timed out after 50ms")
+
+	_, err = Run(ctx, "test", `local function f(n) return 1 + f(n + 1) end return f(1)`, nil, Limits{CallDepth: 50})
+	assert.ErrorContains(t, err, "stack overflow")
+
+	_, err = Run(ctx, "test", `local function f(...) return f(1, ...) end return f()`, nil, Limits{StackSize: 1024})
+	assert.ErrorContains(t, err, "exceeded resource limits")
+
+	// the context's deadline applies too
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = Run(ctx, "test", `while true do end`, nil, Limits{})
+	assert.Error(t, err)
+}
+
+func TestRun_Memory(t *testing.T) {
+	ctx := context.Background()
+	limits := Limits{Memory: 1 << 20}
+
+	for _, code := range []string{
+		`local s = "x" for i = 1, 40 do s = s .. s end`,
+		`return string.rep("x", 1e12)`,
+		`return ("x"):rep(1e6):rep(1e6)`,
+		`local t = {} for i = 1, 1e9 do t[i] = i end`,
+		`local t = {} for i = 1, 1e9 do t = {t, t} end`,
+		`local t = {} for i = 1, 1e9 do table.insert(t, i) end`,
+		`local t = {} for i = 1, 1e9 do rawset(t, i, i) end`,
+		`local t = {} for i = 1, 1e9 do t[#t + 1] = string.upper("abc") end`,
+		`loadstring("local s = 'x' for i = 1, 40 do s = s .. s end")()`,
+		// once the limit's exceeded, catching the error doesn't help
+		`pcall(string.rep, "x", 1e12) return string.rep("x", 10)`,
+	} {
+		_, err := Run(ctx, "test", code, nil, limits)
+		assert.EqualError(t, err, "script test exceeded its memory limit of 1048576 bytes", code)
+	}
+
+	_, err := Run(ctx, "test", `return string.format("%999d", 1)`, nil, limits)
+	assert.ErrorContains(t, err, "invalid format (width or precision too long)")
+
+	// the operators and assignments that are charged still work as usual
+	out, err := Run(ctx, "test", `
+		local t = setmetatable({}, {
+			__concat = function(a, b) return "cat" end,
+			__newindex = function(t, k, v) rawset(t, k, v * 2) end,
+		})
+		t.a = 2
+		local u, v = {}, {}
+		u.x, v.y, w = 1, 2, 3
+		local function f() return "b", "c" end
+		return "a" .. 1 .. f(), t .. "x", t.a, u.x + v.y + w, table.concat({"x", "y"}, ",")`, nil, limits)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a1b", "cat", 4, 6, "x,y"}, out)
+
+	_, err = Run(ctx, "test", `return {} .. "x"`, nil, limits)
+	assert.ErrorContains(t, err, "cannot perform concat operation between table and string")
+}
+
+func TestLimitsFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, DefaultLimits, LimitsFromContext(ctx))
+
+	limits := Limits{Timeout: time.Second, Memory: 1024}
+	assert.Equal(t, limits, LimitsFromContext(ContextWithLimits(ctx, limits)))
+}
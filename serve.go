@@ -0,0 +1,206 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// ServeOptions - options for Serve
+//
+// Experimental: subject to breaking changes before the next major release
+type ServeOptions struct {
+	// Addr - the address to listen on. Defaults to ":8080".
+	Addr string
+}
+
+// request - an HTTP request being served, available to templates as .Request
+type request struct {
+	// Method - the request's HTTP method
+	Method string
+	// Path - the request's URL path
+	Path string
+	// Query - the request's query parameters
+	Query url.Values
+	// Headers - the request's HTTP headers
+	Headers http.Header
+}
+
+type requestCtxKey struct{}
+
+func contextWithRequest(ctx context.Context, req *request) context.Context {
+	return context.WithValue(ctx, requestCtxKey{}, req)
+}
+
+func requestFromContext(ctx context.Context) *request {
+	req, _ := ctx.Value(requestCtxKey{}).(*request)
+	return req
+}
+
+// Serve serves the configured templates over HTTP, until the context is
+// cancelled. Each request renders a template, with the request available as
+// .Request, and responds with the output. Templates and datasources are read
+// again for each request, so changes are seen without restarting.
+//
+// With a single template (an input file or --in), every path renders it. With
+// an input directory (or several input files, or a bundle), the request's path
+// selects the template, relative to the directory - directory paths render
+// their index.html, if there is one.
+//
+// Experimental: subject to breaking changes before the next major release
+func Serve(ctx context.Context, cfg *config.Config, o ServeOptions) error {
+	if o.Addr == "" {
+		o.Addr = ":8080"
+	}
+	if cfg.Matrix != nil {
+		return fmt.Errorf("matrix renders can't be served")
+	}
+	if err := validateInputs(cfg); err != nil {
+		return err
+	}
+	for _, f := range cfg.InputFiles {
+		if f == "-" {
+			return fmt.Errorf("templates can't be read from stdin when serving")
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              o.Addr,
+		Handler:           &serveHandler{ctx: ctx, cfg: cfg},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+
+	zerolog.Ctx(ctx).Info().Str("addr", o.Addr).Msg("serving templates")
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+type serveHandler struct {
+	ctx context.Context
+	cfg *config.Config
+
+	// renders happen one at a time, since datasources are cleaned up after
+	// each one
+	mu sync.Mutex
+}
+
+func (h *serveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &request{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Headers: r.Header,
+	}
+	logger := zerolog.Ctx(h.ctx).With().Str("method", r.Method).Str("path", r.URL.Path).Logger()
+
+	h.mu.Lock()
+	name, out, err := h.render(req)
+	h.mu.Unlock()
+	if errors.Is(err, errTemplateNotFound) {
+		logger.Debug().Msg("no template for path")
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("render failed")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if ctype == "" {
+		ctype = http.DetectContentType(out)
+	}
+	w.Header().Set("Content-Type", ctype)
+	logger.Debug().Str("template", name).Msg("rendered template")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+var errTemplateNotFound = errors.New("template not found")
+
+// render renders the template for the request with a copy of the config,
+// returning the template's name and its output
+func (h *serveHandler) render(req *request) (string, []byte, error) {
+	defer runCleanupHooks()
+
+	cfg := *h.cfg
+	ctx := contextWithRequest(h.ctx, req)
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+
+	templates, err := readInputTemplates(&cfg)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+	tmpl, ok := serveTemplate(templates, req.Path)
+	if !ok {
+		return "", nil, errTemplateNotFound
+	}
+
+	opts, err := runOptions(ctx, &cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	tr := NewRenderer(opts)
+
+	buf := &bytes.Buffer{}
+	t := Template{Name: tmpl.name, Text: tmpl.text, Writer: buf, bundle: tmpl.bundle}
+	if err := tr.RenderTemplates(ctx, []Template{t}); err != nil {
+		return "", nil, err
+	}
+	return tmpl.name, buf.Bytes(), nil
+}
+
+// serveTemplate finds the template to render for a request path. A single
+// template is rendered for every path, and otherwise the path is matched
+// against the templates' input paths.
+func serveTemplate(templates []inputTemplate, p string) (inputTemplate, bool) {
+	if len(templates) == 1 && (templates[0].in == "" || templates[0].in == templates[0].name) {
+		return templates[0], true
+	}
+
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	candidates := []string{p}
+	if p == "" {
+		candidates = []string{"index.html"}
+	} else {
+		candidates = append(candidates, path.Join(p, "index.html"))
+	}
+	for _, c := range candidates {
+		for _, t := range templates {
+			if filepath.ToSlash(filepath.Clean(t.in)) == c {
+				return t, true
+			}
+		}
+	}
+	return inputTemplate{}, false
+}
@@ -0,0 +1,103 @@
+package gomplate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getServed(t *testing.T, h http.Handler, target string, hdr http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range hdr {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeHandler(t *testing.T) {
+	cfg := &config.Config{
+		Input: `{{ .Request.Method }} {{ .Request.Path }} {{ .Request.Query.Get "env" }} {{ .Request.Headers.Get "X-Team" }}`,
+	}
+	require.NoError(t, validateInputs(cfg))
+	h := &serveHandler{ctx: context.Background(), cfg: cfg}
+
+	w := getServed(t, h, "/apps/web?env=prod", http.Header{"X-Team": {"platform"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "GET /apps/web prod platform", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	cfg = &config.Config{Input: `{{ fail "no config for " .Request.Path }}`}
+	require.NoError(t, validateInputs(cfg))
+	h = &serveHandler{ctx: context.Background(), cfg: cfg}
+	w = getServed(t, h, "/x", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "no config for")
+}
+
+func TestServeHandlerInputDir(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+
+	_ = afero.WriteFile(aferoFS, "/in/index.html", []byte(`<p>{{ .Request.Path }}</p>`), 0o644)
+	_ = afero.WriteFile(aferoFS, "/in/apps/config.json", []byte(`{"env": {{ .Request.Query.Get "env" | data.ToJSON }}}`), 0o644)
+	_ = afero.WriteFile(aferoFS, "/in/apps/index.html", []byte(`apps`), 0o644)
+
+	cfg := &config.Config{InputDir: "/in"}
+	require.NoError(t, validateInputs(cfg))
+	h := &serveHandler{ctx: context.Background(), cfg: cfg}
+
+	w := getServed(t, h, "/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<p>/</p>", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = getServed(t, h, "/apps/config.json?env=dev", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"env": "dev"}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	w = getServed(t, h, "/apps/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "apps", w.Body.String())
+
+	w = getServed(t, h, "/missing.txt", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// paths can't escape the input directory
+	w = getServed(t, h, "/../in/index.html", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServeTemplate(t *testing.T) {
+	single := []inputTemplate{{name: "<arg>"}}
+	tmpl, ok := serveTemplate(single, "/anything")
+	assert.True(t, ok)
+	assert.Equal(t, "<arg>", tmpl.name)
+
+	files := []inputTemplate{{name: "a.txt", in: "a.txt"}, {name: "sub/b.txt", in: "sub/b.txt"}}
+	tmpl, ok = serveTemplate(files, "/sub/b.txt")
+	assert.True(t, ok)
+	assert.Equal(t, "sub/b.txt", tmpl.name)
+
+	_, ok = serveTemplate(files, "/c.txt")
+	assert.False(t, ok)
+}
+
+func TestServe_Stdin(t *testing.T) {
+	err := Serve(context.Background(), &config.Config{}, ServeOptions{})
+	assert.Error(t, err)
+}
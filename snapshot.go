@@ -0,0 +1,201 @@
+package gomplate
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/integrity"
+)
+
+// snapshotVersion is incremented when the snapshot format changes
+const snapshotVersion = 1
+
+type snapshotFile struct {
+	Version     int             `json:"version"`
+	Datasources []data.Snapshot `json:"datasources"`
+}
+
+// A snapshot directory (written by 'gomplate snapshot create') has an index
+// of the snapshots, and their data in files named by their SHA-256 digests,
+// so identical data is only stored once, and can be checked for tampering.
+const (
+	snapshotIndex   = "index.json"
+	snapshotObjects = "sha256"
+)
+
+type snapshotDirIndex struct {
+	Version     int           `json:"version"`
+	Datasources []snapshotRef `json:"datasources"`
+}
+
+// snapshotRef - a snapshot in a snapshot directory, without its data
+type snapshotRef struct {
+	Alias     string   `json:"alias"`
+	URL       string   `json:"url"`
+	Args      []string `json:"args,omitempty"`
+	MediaType string   `json:"mediaType,omitempty"`
+	Integrity string   `json:"integrity"`
+}
+
+// loadSnapshots reads a snapshot file, or a snapshot directory
+func loadSnapshots(path string) ([]data.Snapshot, error) {
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return loadSnapshotDir(path)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	f := snapshotFile{}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if f.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has unsupported version %d (expected %d)", path, f.Version, snapshotVersion)
+	}
+	if f.Datasources == nil {
+		f.Datasources = []data.Snapshot{}
+	}
+	return f.Datasources, nil
+}
+
+// loadSnapshotDir reads the snapshots in a snapshot directory, making sure
+// their data hasn't been modified
+func loadSnapshotDir(dir string) ([]data.Snapshot, error) {
+	b, err := os.ReadFile(filepath.Join(dir, snapshotIndex))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	index := snapshotDirIndex{}
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot index in %s: %w", dir, err)
+	}
+	if index.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has unsupported version %d (expected %d)", dir, index.Version, snapshotVersion)
+	}
+
+	snapshots := make([]data.Snapshot, len(index.Datasources))
+	for i, ref := range index.Datasources {
+		name, err := snapshotObjectName(ref.Integrity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot of datasource '%s' in %s: %w", ref.Alias, dir, err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot of datasource '%s': %w", ref.Alias, err)
+		}
+		if err := integrity.Check(ref.Integrity, b); err != nil {
+			return nil, fmt.Errorf("snapshot of datasource '%s' in %s was modified: %w", ref.Alias, dir, err)
+		}
+
+		snapshots[i] = data.Snapshot{
+			Alias:     ref.Alias,
+			URL:       ref.URL,
+			Args:      ref.Args,
+			MediaType: ref.MediaType,
+			Data:      b,
+		}
+	}
+	return snapshots, nil
+}
+
+// snapshotObjectName - the path of the data with the given integrity, within
+// a snapshot directory
+func snapshotObjectName(s string) (string, error) {
+	digest, err := integrity.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(snapshotObjects, hex.EncodeToString(digest)), nil
+}
+
+// takeSnapshots reads all datasources and context datasources in the config
+func takeSnapshots(ctx context.Context, opts Options) ([]data.Snapshot, error) {
+	tr := NewRenderer(opts)
+	tr.data.Ctx = ctx
+
+	snapshots := []data.Snapshot{}
+	for _, alias := range tr.data.ListDatasources() {
+		s, err := tr.data.TakeSnapshot(alias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot datasource: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// CreateSnapshot reads all datasources (and context datasources) in the
+// config, and writes them to a snapshot directory, which can be rendered from
+// later (see the config's Snapshot option) without reading the datasources
+// again. Data is stored in files named by its SHA-256 digest, and files that
+// are no longer needed by the snapshot are removed.
+//
+// Experimental: subject to breaking changes before the next major release
+func CreateSnapshot(ctx context.Context, cfg *config.Config, dir string) error {
+	defer runCleanupHooks()
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	snapshots, err := takeSnapshots(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	objDir := filepath.Join(dir, snapshotObjects)
+	if err := os.MkdirAll(objDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	index := snapshotDirIndex{Version: snapshotVersion, Datasources: make([]snapshotRef, len(snapshots))}
+	keep := map[string]bool{}
+	for i, s := range snapshots {
+		ref := snapshotRef{
+			Alias:     s.Alias,
+			URL:       s.URL,
+			Args:      s.Args,
+			MediaType: s.MediaType,
+			Integrity: integrity.Of(s.Data),
+		}
+		name, _ := snapshotObjectName(ref.Integrity)
+		keep[filepath.Base(name)] = true
+		if err := os.WriteFile(filepath.Join(dir, name), s.Data, 0o644); err != nil {
+			return fmt.Errorf("failed to write snapshot of datasource '%s': %w", s.Alias, err)
+		}
+		index.Datasources[i] = ref
+	}
+
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotIndex), append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot index: %w", err)
+	}
+
+	// remove data from earlier snapshots
+	entries, err := os.ReadDir(objDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !keep[e.Name()] {
+			if err := os.Remove(filepath.Join(objDir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
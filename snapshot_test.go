@@ -0,0 +1,61 @@
+package gomplate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snapDir := filepath.Join(dir, "snap")
+
+	dsFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(dsFile, []byte("name: acme\n"), 0o600))
+	u, err := config.ParseSourceURL(dsFile)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		DataSources: map[string]config.DataSource{"a": {URL: u}, "b": {URL: u}},
+	}
+	require.NoError(t, CreateSnapshot(ctx, cfg, snapDir))
+
+	// identical data is only stored once
+	objects, err := os.ReadDir(filepath.Join(snapDir, snapshotObjects))
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	snapshots, err := loadSnapshots(snapDir)
+	require.NoError(t, err)
+	assert.Equal(t, []data.Snapshot{
+		{Alias: "a", URL: u.String(), MediaType: "application/yaml", Data: []byte("name: acme\n")},
+		{Alias: "b", URL: u.String(), MediaType: "application/yaml", Data: []byte("name: acme\n")},
+	}, snapshots)
+
+	// data that's no longer needed is removed
+	require.NoError(t, os.WriteFile(dsFile, []byte("name: other\n"), 0o600))
+	require.NoError(t, CreateSnapshot(ctx, cfg, snapDir))
+	objects, err = os.ReadDir(filepath.Join(snapDir, snapshotObjects))
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	// modified data is refused
+	obj := filepath.Join(snapDir, snapshotObjects, objects[0].Name())
+	require.NoError(t, os.WriteFile(obj, []byte("name: tampered\n"), 0o600))
+	_, err = loadSnapshots(snapDir)
+	assert.ErrorContains(t, err, "snapshot of datasource 'a' in "+snapDir+" was modified")
+
+	require.NoError(t, os.Remove(obj))
+	_, err = loadSnapshots(snapDir)
+	assert.Error(t, err)
+
+	_, err = loadSnapshots(dir)
+	assert.Error(t, err)
+}
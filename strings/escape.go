@@ -0,0 +1,34 @@
+package strings
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSSEscape - escape a string for safe use in a CSS identifier or quoted
+// string. All ASCII characters other than letters, digits, '-', and '_' are
+// replaced with hexadecimal escape sequences (e.g. '\22 ' for '"'). A
+// terminating space is only added when the next character would otherwise be
+// read as part of the escape.
+func CSSEscape(s string) string {
+	out := &strings.Builder{}
+	for i, r := range s {
+		switch {
+		case r == 0:
+			out.WriteRune(utf8.RuneError)
+		case r >= utf8.RuneSelf || r == '-' || r == '_' || isAlnum(byte(r)):
+			out.WriteRune(r)
+		default:
+			fmt.Fprintf(out, `\%x`, r)
+			if next := i + 1; next < len(s) && (isHex(s[next]) || s[next] == ' ') {
+				out.WriteByte(' ')
+			}
+		}
+	}
+	return out.String()
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,25 @@
+package strings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSSEscape(t *testing.T) {
+	testdata := []struct {
+		in, expected string
+	}{
+		{"", ""},
+		{"foo-bar_baz", "foo-bar_baz"},
+		{`"quoted"`, `\22quoted\22`},
+		{"</style>", `\3c\2fstyle\3e`},
+		{"a b", `a\20 b`},
+		{"1;2", `1\3b 2`},
+		{"naïve", "naïve"},
+		{"nul\x00", "nul�"},
+	}
+	for _, d := range testdata {
+		assert.Equal(t, d.expected, CSSEscape(d.in), d.in)
+	}
+}
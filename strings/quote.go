@@ -0,0 +1,124 @@
+package strings
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// shellSafe matches words that don't need quoting in a POSIX shell
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+// ShellJoin - join the given words into a single string that a POSIX shell
+// will split back into the same words. Unlike ShellQuote, words are only
+// quoted when necessary.
+func ShellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		if shellSafe.MatchString(w) {
+			quoted[i] = w
+		} else {
+			quoted[i] = ShellQuote(w)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// SQLQuoteIdent - quote an SQL identifier (such as a table or column name)
+// for the given dialect. Supported dialects are "ansi" (the default, also
+// used for "postgres", "sqlite", and "oracle"), "mysql" (or "mariadb"), and
+// "mssql" (or "sqlserver").
+func SQLQuoteIdent(dialect, s string) (string, error) {
+	if strings.ContainsRune(s, 0) {
+		return "", fmt.Errorf("identifier must not contain NUL characters")
+	}
+
+	switch strings.ToLower(dialect) {
+	case "", "ansi", "postgres", "postgresql", "sqlite", "sqlite3", "oracle":
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`, nil
+	case "mysql", "mariadb":
+		return "`" + strings.ReplaceAll(s, "`", "``") + "`", nil
+	case "mssql", "sqlserver":
+		return "[" + strings.ReplaceAll(s, "]", "]]") + "]", nil
+	default:
+		return "", fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+}
+
+// mysqlEscaper escapes the characters MySQL interprets specially in string
+// literals (when NO_BACKSLASH_ESCAPES is not set, which is the default)
+var mysqlEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `''`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+// SQLQuoteLiteral - quote a string as an SQL string literal for the given
+// dialect. See SQLQuoteIdent for supported dialects.
+//
+// For the "postgres" dialect, strings with backslashes are E-prefixed, so
+// the output is correct regardless of the standard_conforming_strings setting.
+func SQLQuoteLiteral(dialect, s string) (string, error) {
+	switch strings.ToLower(dialect) {
+	case "", "ansi", "sqlite", "sqlite3", "oracle", "mssql", "sqlserver":
+		if strings.ContainsRune(s, 0) {
+			return "", fmt.Errorf("string literal must not contain NUL characters")
+		}
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+	case "postgres", "postgresql":
+		if strings.ContainsRune(s, 0) {
+			return "", fmt.Errorf("string literal must not contain NUL characters")
+		}
+		s = strings.ReplaceAll(s, "'", "''")
+		if strings.Contains(s, `\`) {
+			return `E'` + strings.ReplaceAll(s, `\`, `\\`) + "'", nil
+		}
+		return "'" + s + "'", nil
+	case "mysql", "mariadb":
+		return "'" + mysqlEscaper.Replace(s) + "'", nil
+	default:
+		return "", fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+}
+
+// SystemdEscape - escape a string for use in a systemd unit name, in the same
+// way as the systemd-escape(1) command.
+func SystemdEscape(s string) string {
+	out := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			out.WriteByte('-')
+		case c == '.' && i == 0,
+			!(isAlnum(c) || c == ':' || c == '_' || c == '.'):
+			fmt.Fprintf(out, `\x%02x`, c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// SystemdEscapePath - escape a filesystem path for use in a systemd unit
+// name, in the same way as the `systemd-escape --path` command. Redundant
+// slashes are removed, and the root directory is escaped as "-".
+func SystemdEscapePath(s string) string {
+	parts := []string{}
+	for _, p := range strings.Split(s, "/") {
+		if p != "" && p != "." {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return SystemdEscape(strings.Join(parts, "/"))
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,76 @@
+package strings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellJoin(t *testing.T) {
+	assert.Equal(t, ``, ShellJoin(nil))
+	assert.Equal(t, `echo hello`, ShellJoin([]string{"echo", "hello"}))
+	assert.Equal(t, `echo 'hello world' '' 'it'"'"'s' a=b,c/d`,
+		ShellJoin([]string{"echo", "hello world", "", "it's", "a=b,c/d"}))
+	assert.Equal(t, `'$HOME' '*'`, ShellJoin([]string{"$HOME", "*"}))
+}
+
+func TestSQLQuoteIdent(t *testing.T) {
+	testdata := []struct {
+		dialect, in, out string
+	}{
+		{"", "foo", `"foo"`},
+		{"postgres", `my "table"`, `"my ""table"""`},
+		{"sqlite", "a.b", `"a.b"`},
+		{"mysql", "fo`o", "`fo``o`"},
+		{"MariaDB", "foo", "`foo`"},
+		{"mssql", "foo]bar", "[foo]]bar]"},
+	}
+	for _, d := range testdata {
+		out, err := SQLQuoteIdent(d.dialect, d.in)
+		assert.NoError(t, err)
+		assert.Equal(t, d.out, out)
+	}
+
+	_, err := SQLQuoteIdent("bogus", "foo")
+	assert.Error(t, err)
+
+	_, err = SQLQuoteIdent("", "fo\x00o")
+	assert.Error(t, err)
+}
+
+func TestSQLQuoteLiteral(t *testing.T) {
+	testdata := []struct {
+		dialect, in, out string
+	}{
+		{"", "it's", `'it''s'`},
+		{"ansi", `back\slash`, `'back\slash'`},
+		{"postgres", "it's", `'it''s'`},
+		{"postgres", `it's a \ slash`, `E'it''s a \\ slash'`},
+		{"mysql", "it's\n\\\x00", `'it''s\n\\\0'`},
+		{"mssql", "it's", `'it''s'`},
+	}
+	for _, d := range testdata {
+		out, err := SQLQuoteLiteral(d.dialect, d.in)
+		assert.NoError(t, err)
+		assert.Equal(t, d.out, out)
+	}
+
+	_, err := SQLQuoteLiteral("bogus", "foo")
+	assert.Error(t, err)
+
+	_, err = SQLQuoteLiteral("postgres", "fo\x00o")
+	assert.Error(t, err)
+}
+
+func TestSystemdEscape(t *testing.T) {
+	assert.Equal(t, `foo`, SystemdEscape("foo"))
+	assert.Equal(t, `foo\x20bar`, SystemdEscape("foo bar"))
+	assert.Equal(t, `-foo-bar.baz`, SystemdEscape("/foo/bar.baz"))
+	assert.Equal(t, `\x2ehidden`, SystemdEscape(".hidden"))
+	assert.Equal(t, `a\x2db:c_d`, SystemdEscape("a-b:c_d"))
+	assert.Equal(t, `\xc3\xbc`, SystemdEscape("ü"))
+
+	assert.Equal(t, `-`, SystemdEscapePath("/"))
+	assert.Equal(t, `var-lib-foo`, SystemdEscapePath("/var//lib/foo/"))
+	assert.Equal(t, `dev-disk-by\x2dlabel-data`, SystemdEscapePath("/dev/disk/by-label/data"))
+}
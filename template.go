@@ -3,17 +3,28 @@ package gomplate
 import (
 	"context"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
 	"io/fs"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/hairyhenderson/go-fsimpl"
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/funcs"
 	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/hairyhenderson/gomplate/v3/internal/integrity"
 	"github.com/hairyhenderson/gomplate/v3/internal/iohelpers"
+	"github.com/hairyhenderson/gomplate/v3/internal/natsclient"
+	"github.com/hairyhenderson/gomplate/v3/internal/netpolicy"
+	"github.com/hairyhenderson/gomplate/v3/internal/verify"
+	"github.com/hairyhenderson/gomplate/v3/mail"
 	"github.com/hairyhenderson/gomplate/v3/tmpl"
 
 	"github.com/spf13/afero"
@@ -31,6 +42,16 @@ func addTmplFuncs(f template.FuncMap, root *template.Template, tctx interface{},
 	tns := func() *tmpl.Template { return t }
 	f["tmpl"] = tns
 	f["tpl"] = t.Inline
+	f["skipFile"] = t.Skip
+	// memoize and the flow funcs resolve function names in this map, so they
+	// can call the tmpl funcs too
+	f["memoize"] = tmpl.NewMemo(f).Memoize
+	// timed-out datasource reads are cancelled
+	flow := tmpl.NewFlow(f).WithContextFuncs(funcs.DataContextFuncs(f))
+	f["tryOr"] = flow.TryOr
+	f["retry"] = flow.Retry
+	f["timeout"] = flow.Timeout
+	f["parallel"] = flow.Parallel
 }
 
 // copyFuncMap - copies the template.FuncMap into a new map so we can modify it
@@ -64,6 +85,22 @@ func FSProviderFromContext(ctx context.Context) fsimpl.FSProvider {
 	return nil
 }
 
+type verifierCtxKey struct{}
+
+// contextWithVerifier returns a context with the verifier for nested
+// templates' signatures
+func contextWithVerifier(ctx context.Context, v *verify.Verifier) context.Context {
+	if v == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, verifierCtxKey{}, v)
+}
+
+func verifierFromContext(ctx context.Context) *verify.Verifier {
+	v, _ := ctx.Value(verifierCtxKey{}).(*verify.Verifier)
+	return v
+}
+
 // parseTemplate - parses text as a Go template with the given name and options
 func parseTemplate(ctx context.Context, name, text string, funcs template.FuncMap, tmplctx interface{}, nested config.Templates, leftDelim, rightDelim string) (tmpl *template.Template, err error) {
 	tmpl = template.New(name)
@@ -88,11 +125,74 @@ func parseTemplate(ctx context.Context, name, text string, funcs template.FuncMa
 	return tmpl, nil
 }
 
+// executeHTML - executes the parsed template (and all associated templates)
+// with html/template's contextual auto-escaping. The parse trees are copied
+// first, because the escaper rewrites them and the original template is still
+// used by the tmpl namespace.
+func executeHTML(tmpl *template.Template, funcs template.FuncMap, wr io.Writer, tmplctx interface{}) error {
+	funcMap := copyFuncMap(funcs)
+	addTmplFuncs(funcMap, tmpl, tmplctx, tmpl.Name())
+
+	h := htmltemplate.New(tmpl.Name())
+	h.Option("missingkey=error")
+	h.Funcs(htmltemplate.FuncMap(funcMap))
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		_, err := h.AddParseTree(t.Name(), t.Tree.Copy())
+		if err != nil {
+			return fmt.Errorf("add parse tree %q: %w", t.Name(), err)
+		}
+	}
+
+	return h.ExecuteTemplate(wr, tmpl.Name(), tmplctx)
+}
+
 func parseNestedTemplates(ctx context.Context, nested config.Templates, tmpl *template.Template) error {
+	templates, err := readNestedTemplates(ctx, nested)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range templates {
+		_, err = tmpl.New(n.name).Parse(n.text)
+		if err != nil {
+			return fmt.Errorf("parse nested template %q: %w", n.file, err)
+		}
+	}
+
+	return nil
+}
+
+// nestedTemplate is a nested template's text, named by its alias
+type nestedTemplate struct {
+	name string
+	// file is the path the template was read from
+	file string
+	text string
+}
+
+// readNestedTemplates reads the nested templates, sorted by name
+func readNestedTemplates(ctx context.Context, nested config.Templates) ([]nestedTemplate, error) {
 	fsp := FSProviderFromContext(ctx)
+	v := verifierFromContext(ctx)
+	np := netpolicy.FromContext(ctx)
+
+	aliases := make([]string, 0, len(nested))
+	for alias := range nested {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
 
-	for alias, n := range nested {
+	templates := []nestedTemplate{}
+	for _, alias := range aliases {
+		n := nested[alias]
 		u := *n.URL
+		if err := np.CheckURL(&u); err != nil {
+			return nil, fmt.Errorf("can't read nested template %q: %w", alias, err)
+		}
 
 		fname := path.Base(u.Path)
 		if strings.HasSuffix(u.Path, "/") {
@@ -103,12 +203,15 @@ func parseNestedTemplates(ctx context.Context, nested config.Templates, tmpl *te
 
 		fsys, err := fsp.New(&u)
 		if err != nil {
-			return fmt.Errorf("filesystem provider for %q unavailable: %w", &u, err)
+			return nil, fmt.Errorf("filesystem provider for %q unavailable: %w", &u, err)
 		}
 
 		// inject context & header in case they're useful...
 		fsys = fsimpl.WithContextFS(ctx, fsys)
 		fsys = fsimpl.WithHeaderFS(n.Header, fsys)
+		if np != nil {
+			fsys = fsimpl.WithHTTPClientFS(&http.Client{Transport: np.Transport(u.Scheme)}, fsys)
+		}
 
 		// valid fs.FS paths have no trailing slash
 		fname = strings.TrimRight(fname, "/")
@@ -117,56 +220,85 @@ func parseNestedTemplates(ctx context.Context, nested config.Templates, tmpl *te
 		// need to load all the files in the directory (but not recursively)
 		fi, err := fs.Stat(fsys, fname)
 		if err != nil {
-			return fmt.Errorf("stat %q: %w", fname, err)
+			return nil, fmt.Errorf("stat %q: %w", fname, err)
+		}
+
+		if fi.IsDir() && n.Integrity != "" {
+			return nil, fmt.Errorf("nested template %q is a directory, so its integrity can't be pinned", alias)
 		}
 
+		var t []nestedTemplate
 		if fi.IsDir() {
-			err = parseNestedTemplateDir(ctx, fsys, alias, fname, tmpl)
+			t, err = readNestedTemplateDir(fsys, alias, fname)
 		} else {
-			err = parseNestedTemplate(ctx, fsys, alias, fname, tmpl)
+			t, err = readNestedTemplate(fsys, alias, fname)
+		}
+		if err == nil && v != nil {
+			err = verifyNestedTemplates(fsys, &u, t, v)
+		}
+		if err == nil && n.Integrity != "" {
+			if ierr := integrity.Check(n.Integrity, []byte(t[0].text)); ierr != nil {
+				err = fmt.Errorf("integrity check failed for nested template %q (%s): %w", alias, n.URL, ierr)
+			}
 		}
 
 		if err != nil {
-			return err
+			return nil, err
 		}
+		templates = append(templates, t...)
 	}
 
-	return nil
+	return templates, nil
 }
 
-func parseNestedTemplateDir(ctx context.Context, fsys fs.FS, alias, fname string, tmpl *template.Template) error {
+func readNestedTemplateDir(fsys fs.FS, alias, fname string) ([]nestedTemplate, error) {
 	files, err := fs.ReadDir(fsys, fname)
 	if err != nil {
-		return fmt.Errorf("readDir %q: %w", fname, err)
+		return nil, fmt.Errorf("readDir %q: %w", fname, err)
 	}
 
+	templates := []nestedTemplate{}
 	for _, f := range files {
 		if !f.IsDir() {
-			err = parseNestedTemplate(ctx, fsys,
+			t, err := readNestedTemplate(fsys,
 				path.Join(alias, f.Name()),
 				path.Join(fname, f.Name()),
-				tmpl,
 			)
 			if err != nil {
-				return err
+				return nil, err
 			}
+			templates = append(templates, t...)
 		}
 	}
 
-	return nil
+	return templates, nil
 }
 
-func parseNestedTemplate(ctx context.Context, fsys fs.FS, alias, fname string, tmpl *template.Template) error {
+func readNestedTemplate(fsys fs.FS, alias, fname string) ([]nestedTemplate, error) {
 	b, err := fs.ReadFile(fsys, fname)
 	if err != nil {
-		return fmt.Errorf("readFile %q: %w", fname, err)
+		return nil, fmt.Errorf("readFile %q: %w", fname, err)
 	}
 
-	_, err = tmpl.New(alias).Parse(string(b))
-	if err != nil {
-		return fmt.Errorf("parse nested template %q: %w", fname, err)
-	}
+	return []nestedTemplate{{name: alias, file: fname, text: string(b)}}, nil
+}
 
+// verifyNestedTemplates verifies the signatures of the templates read from
+// fsys, which is at the URL dir
+func verifyNestedTemplates(fsys fs.FS, dir *url.URL, templates []nestedTemplate, v *verify.Verifier) error {
+	for _, t := range templates {
+		u := *dir
+		u.Path = path.Join(u.Path, t.file)
+		if v.IsSignature(&u) {
+			continue
+		}
+		err := v.Verify(&u, []byte(t.text), func(suffix string) ([]byte, error) {
+			return fs.ReadFile(fsys, t.file+suffix)
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -179,11 +311,16 @@ func gatherTemplates(ctx context.Context, cfg *config.Config, outFileNamer func(
 	}
 
 	switch {
+	case cfg.Bundle != "":
+		templates, err = bundleToTemplates(ctx, cfg, outFileNamer, modeOverride)
+		if err != nil {
+			return nil, err
+		}
 	// the arg-provided input string gets a special name
 	case cfg.Input != "":
 		// open the output file - no need to close it, as it will be closed by the
 		// caller later
-		target, oerr := openOutFile(cfg.OutputFiles[0], 0755, mode, modeOverride, cfg.Stdout, cfg.SuppressEmpty)
+		target, oerr := openOutput(cfg, cfg.OutputFiles[0], 0755, mode, modeOverride)
 		if oerr != nil {
 			return nil, oerr
 		}
@@ -192,6 +329,7 @@ func gatherTemplates(ctx context.Context, cfg *config.Config, outFileNamer func(
 			Name:   "<arg>",
 			Text:   cfg.Input,
 			Writer: target,
+			target: cfg.OutputFiles[0],
 		}}
 	case cfg.InputDir != "":
 		// input dirs presume output dirs are set too
@@ -218,13 +356,45 @@ func gatherTemplates(ctx context.Context, cfg *config.Config, outFileNamer func(
 func walkDir(ctx context.Context, cfg *config.Config, dir string, outFileNamer func(context.Context, string) (string, error), excludeGlob []string, mode os.FileMode, modeOverride bool) ([]Template, error) {
 	dir = filepath.Clean(dir)
 
-	dirStat, err := aferoFS.Stat(dir)
+	files, dirMode, err := listDir(dir, excludeGlob)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't stat %s: %w", dir, err)
+		return nil, err
 	}
-	dirMode := dirStat.Mode()
 
 	templates := make([]Template, 0)
+	for _, file := range files {
+		inFile := filepath.Join(dir, file)
+		outFile, err := outFileNamer(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+
+		tpl, err := fileToTemplate(cfg, inFile, outFile, mode, modeOverride)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ensure file parent dirs
+		if !data.IsOutputURL(outFile) && !cfg.Preview {
+			if err = aferoFS.MkdirAll(filepath.Dir(outFile), dirMode); err != nil {
+				return nil, err
+			}
+		}
+
+		templates = append(templates, tpl)
+	}
+
+	return templates, nil
+}
+
+// listDir - list the files in dir (relative to it) that aren't excluded by
+// .gomplateignore files or exclude globs, and return the dir's mode
+func listDir(dir string, excludeGlob []string) ([]string, os.FileMode, error) {
+	dirStat, err := aferoFS.Stat(dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't stat %s: %w", dir, err)
+	}
+
 	matcher := xignore.NewMatcher(aferoFS)
 
 	// work around bug in xignore - a basedir of '.' doesn't work
@@ -238,86 +408,168 @@ func walkDir(ctx context.Context, cfg *config.Config, dir string, outFileNamer f
 		AfterPatterns: excludeGlob,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("ignore matching failed for %s: %w", basedir, err)
+		return nil, 0, fmt.Errorf("ignore matching failed for %s: %w", basedir, err)
 	}
 
 	// Unmatched ignorefile rules's files
-	files := matches.UnmatchedFiles
-	for _, file := range files {
-		inFile := filepath.Join(dir, file)
-		outFile, err := outFileNamer(ctx, file)
-		if err != nil {
-			return nil, err
-		}
+	return matches.UnmatchedFiles, dirStat.Mode(), nil
+}
 
-		tpl, err := fileToTemplate(cfg, inFile, outFile, mode, modeOverride)
-		if err != nil {
-			return nil, err
-		}
+func fileToTemplate(cfg *config.Config, inFile, outFile string, mode os.FileMode, modeOverride bool) (Template, error) {
+	source, mode, err := readTemplateFile(cfg, inFile, mode)
+	if err != nil {
+		return Template{}, err
+	}
 
-		// Ensure file parent dirs
-		if err = aferoFS.MkdirAll(filepath.Dir(outFile), dirMode); err != nil {
-			return nil, err
-		}
+	// open the output file - no need to close it, as it will be closed by the
+	// caller later
+	target, err := openOutput(cfg, outFile, 0755, mode, modeOverride)
+	if err != nil {
+		return Template{}, err
+	}
 
-		templates = append(templates, tpl)
+	tmpl := Template{
+		Name:   inFile,
+		Text:   source,
+		Writer: target,
+		target: outFile,
 	}
 
-	return templates, nil
+	return tmpl, nil
 }
 
-func fileToTemplate(cfg *config.Config, inFile, outFile string, mode os.FileMode, modeOverride bool) (Template, error) {
-	source := ""
+// inputTemplate is an input template that hasn't been given an output yet
+type inputTemplate struct {
+	name string
+	text string
+	// in is the input path, as given to the output path template
+	in      string
+	mode    os.FileMode
+	dirMode os.FileMode
+	// bundle is the compiled bundle the template is from, if any
+	bundle *templateBundle
+}
 
-	//nolint:nestif
-	if inFile == "-" {
-		b, err := io.ReadAll(cfg.Stdin)
+// readInputTemplates reads the input templates. Unlike gatherTemplates, no
+// outputs are opened, for when they're decided later (or not needed).
+func readInputTemplates(cfg *config.Config) ([]inputTemplate, error) {
+	mode, _, err := cfg.GetMode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.Bundle != "":
+		b, err := loadBundle(cfg.Bundle)
 		if err != nil {
-			return Template{}, fmt.Errorf("failed to read from stdin: %w", err)
+			return nil, err
 		}
-
-		source = string(b)
-	} else {
-		si, err := aferoFS.Stat(inFile)
+		templates := make([]inputTemplate, len(b.Templates))
+		for i, t := range b.Templates {
+			templates[i] = inputTemplate{name: t.Name, text: t.Text, in: t.In, mode: mode, dirMode: t.DirMode, bundle: b}
+			if mode == 0 {
+				templates[i].mode = t.Mode
+			}
+			if t.DirMode == 0 {
+				templates[i].dirMode = 0755
+			}
+		}
+		return templates, nil
+	case cfg.Input != "":
+		return []inputTemplate{{name: "<arg>", text: cfg.Input, mode: mode, dirMode: 0755}}, nil
+	case cfg.InputDir != "":
+		dir := filepath.Clean(cfg.InputDir)
+		files, dirMode, err := listDir(dir, cfg.ExcludeGlob)
 		if err != nil {
-			return Template{}, err
+			return nil, err
 		}
-		if mode == 0 {
-			mode = si.Mode()
+		templates := make([]inputTemplate, len(files))
+		for i, file := range files {
+			inFile := filepath.Join(dir, file)
+			text, fmode, err := readTemplateFile(cfg, inFile, mode)
+			if err != nil {
+				return nil, err
+			}
+			templates[i] = inputTemplate{name: inFile, text: text, in: file, mode: fmode, dirMode: dirMode}
 		}
+		return templates, nil
+	default:
+		templates := make([]inputTemplate, len(cfg.InputFiles))
+		for i, inFile := range cfg.InputFiles {
+			text, fmode, err := readTemplateFile(cfg, inFile, mode)
+			if err != nil {
+				return nil, err
+			}
+			templates[i] = inputTemplate{name: inFile, text: text, in: inFile, mode: fmode, dirMode: 0755}
+		}
+		return templates, nil
+	}
+}
 
-		// we read the file and store in memory immediately, to prevent leaking
-		// file descriptors.
-		f, err := aferoFS.OpenFile(inFile, os.O_RDONLY, 0)
+// readTemplateFile reads the template text from inFile (or stdin, for "-").
+// When mode is 0, the input file's mode is returned in its place.
+func readTemplateFile(cfg *config.Config, inFile string, mode os.FileMode) (string, os.FileMode, error) {
+	if inFile == "-" {
+		b, err := io.ReadAll(cfg.Stdin)
 		if err != nil {
-			return Template{}, fmt.Errorf("failed to open %s: %w", inFile, err)
+			return "", 0, fmt.Errorf("failed to read from stdin: %w", err)
 		}
 
-		//nolint: errcheck
-		defer f.Close()
+		return string(b), mode, nil
+	}
 
-		b, err := io.ReadAll(f)
-		if err != nil {
-			return Template{}, fmt.Errorf("failed to read %s: %w", inFile, err)
-		}
+	si, err := aferoFS.Stat(inFile)
+	if err != nil {
+		return "", 0, err
+	}
+	if mode == 0 {
+		mode = si.Mode()
+	}
 
-		source = string(b)
+	// we read the file and store in memory immediately, to prevent leaking
+	// file descriptors.
+	f, err := aferoFS.OpenFile(inFile, os.O_RDONLY, 0)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", inFile, err)
 	}
 
-	// open the output file - no need to close it, as it will be closed by the
-	// caller later
-	target, err := openOutFile(outFile, 0755, mode, modeOverride, cfg.Stdout, cfg.SuppressEmpty)
+	//nolint: errcheck
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
 	if err != nil {
-		return Template{}, err
+		return "", 0, fmt.Errorf("failed to read %s: %w", inFile, err)
 	}
 
-	tmpl := Template{
-		Name:   inFile,
-		Text:   source,
-		Writer: target,
+	return string(b), mode, nil
+}
+
+// openOutput returns a writer for the given output, or standard output when
+// previewing (see --preview), so that nothing is written
+func openOutput(cfg *config.Config, filename string, dirMode, mode os.FileMode, modeOverride bool) (io.Writer, error) {
+	if cfg.Preview {
+		return cfg.Stdout, nil
+	}
+	if err := checkOutputURL(cfg, filename); err != nil {
+		return nil, err
 	}
+	return openOutFile(filename, dirMode, mode, modeOverride, cfg.Stdout, cfg.SuppressEmpty)
+}
 
-	return tmpl, nil
+// checkOutputURL - remote outputs (which are given as URLs) must be allowed
+// by the network policy
+func checkOutputURL(cfg *config.Config, filename string) error {
+	if !mail.IsSMTPURL(filename) && !natsclient.IsNATSURL(filename) && !data.IsOutputURL(filename) {
+		return nil
+	}
+	u, err := url.Parse(filename)
+	if err != nil {
+		return fmt.Errorf("invalid output URL: %w", err)
+	}
+	if err := networkPolicy(cfg).CheckURL(u); err != nil {
+		return fmt.Errorf("can't write output: %w", err)
+	}
+	return nil
 }
 
 // openOutFile returns a writer for the given file, creating the file if it
@@ -334,6 +586,15 @@ func openOutFile(filename string, dirMode, mode os.FileMode, modeOverride bool,
 			if filename == "-" {
 				return stdout, nil
 			}
+			if mail.IsSMTPURL(filename) {
+				return createMailSink(filename)
+			}
+			if natsclient.IsNATSURL(filename) {
+				return createNATSSink(filename)
+			}
+			if data.IsOutputURL(filename) {
+				return data.OpenOutput(context.Background(), filename)
+			}
 			return createOutFile(filename, dirMode, mode, modeOverride)
 		})
 		return out, nil
@@ -342,9 +603,38 @@ func openOutFile(filename string, dirMode, mode os.FileMode, modeOverride bool,
 	if filename == "-" {
 		return stdout, nil
 	}
+	if mail.IsSMTPURL(filename) {
+		return createMailSink(filename)
+	}
+	if natsclient.IsNATSURL(filename) {
+		return createNATSSink(filename)
+	}
+	if data.IsOutputURL(filename) {
+		return data.OpenOutput(context.Background(), filename)
+	}
 	return createOutFile(filename, dirMode, mode, modeOverride)
 }
 
+// createMailSink - the output is an email message, to be sent with SMTP when
+// the writer is closed
+func createMailSink(u string) (io.WriteCloser, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP URL: %w", err)
+	}
+	return mail.NewWriter(parsed), nil
+}
+
+// createNATSSink - the output is a NATS message, or a value in a NATS
+// key/value bucket, to be published when the writer is closed
+func createNATSSink(u string) (io.WriteCloser, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	return natsclient.NewWriter(parsed), nil
+}
+
 func createOutFile(filename string, dirMode, mode os.FileMode, modeOverride bool) (out io.WriteCloser, err error) {
 	mode = iohelpers.NormalizeFileMode(mode.Perm())
 	if modeOverride {
@@ -365,6 +655,12 @@ func createOutFile(filename string, dirMode, mode os.FileMode, modeOverride bool
 			return out, fmt.Errorf("failed to open output file '%s' for writing: %w", filename, err)
 		}
 
+		updateMetrics(func(m *MetricsType) {
+			if m != nil {
+				m.ChangedFiles = append(m.ChangedFiles, filename)
+			}
+		})
+
 		return out, err
 	}
 
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"testing"
@@ -43,6 +45,26 @@ func TestOpenOutFile(t *testing.T) {
 	f, err = openOutFile("-", 0755, 0644, false, out, false)
 	assert.NoError(t, err)
 	assert.Equal(t, cfg.Stdout, f)
+
+	// remote outputs are written when they're closed
+	put := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			b, _ := io.ReadAll(r.Body)
+			put = string(b)
+		}
+	}))
+	defer srv.Close()
+
+	f, err = openOutFile(srv.URL+"/out.txt", 0755, 0644, false, nil, false)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, f.(io.Closer).Close())
+	assert.Equal(t, "hello", put)
+
+	_, err = aferoFS.Stat(srv.URL + "/out.txt")
+	assert.True(t, os.IsNotExist(err))
 }
 
 func TestGatherTemplates(t *testing.T) {
@@ -201,3 +223,31 @@ func TestParseNestedTemplates(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "foo bar", out.String())
 }
+
+func TestReadNestedTemplatesIntegrity(t *testing.T) {
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"foo.t":     {Data: []byte("hello world"), Mode: 0o600},
+		"dir/bar.t": {Data: []byte("bar"), Mode: 0o600},
+	}
+	ctx = ContextWithFSProvider(ctx, fsimpl.WrappedFSProvider(fsys, "file"))
+
+	u, _ := url.Parse("file:///foo.t")
+	nested := config.Templates{"foo": {
+		URL:       u,
+		Integrity: "sha256-uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=",
+	}}
+	templates, err := readNestedTemplates(ctx, nested)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", templates[0].text)
+
+	fsys["foo.t"] = &fstest.MapFile{Data: []byte("goodbye world"), Mode: 0o600}
+	_, err = readNestedTemplates(ctx, nested)
+	assert.ErrorContains(t, err, `integrity check failed for nested template "foo"`)
+
+	u, _ = url.Parse("file:///dir/")
+	nested = config.Templates{"dir": {URL: u, Integrity: "sha256-uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="}}
+	_, err = readNestedTemplates(ctx, nested)
+	assert.EqualError(t, err, `nested template "dir" is a directory, so its integrity can't be pinned`)
+}
@@ -0,0 +1,264 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/gen"
+)
+
+// TemplateTest - a property-based test of a template: the template is
+// rendered once for each case, with generated inputs, and the output must
+// satisfy all of the assertions
+//
+// Experimental: subject to breaking changes before the next major release
+type TemplateTest struct {
+	Name     string `yaml:"name"`
+	Template string `yaml:"template"`
+	// Inputs - how to generate each input, by name. The inputs are available
+	// in the template's context, like `.port`.
+	Inputs map[string]TemplateTestInput `yaml:"inputs,omitempty"`
+	Assert []TemplateTestAssertion      `yaml:"assert,omitempty"`
+	// Cases - the number of cases to render (default 100)
+	Cases int `yaml:"cases,omitempty"`
+	// Seed - the random seed for generating the inputs. The same seed always
+	// generates the same cases.
+	Seed int64 `yaml:"seed,omitempty"`
+}
+
+// TemplateTestInput - how to generate an input. Exactly one of the fields
+// must be set.
+//
+// Experimental: subject to breaking changes before the next major release
+type TemplateTestInput struct {
+	// Range - the minimum and maximum (inclusive) of a number, which is an
+	// integer when both are integers. The first two cases use the minimum and
+	// maximum.
+	Range []interface{} `yaml:"range,omitempty"`
+	// Enum - values to choose from. The first cases use each value in turn.
+	Enum []interface{} `yaml:"enum,omitempty"`
+	// Pattern - a regular expression that generated strings match
+	Pattern string `yaml:"pattern,omitempty"`
+}
+
+// TemplateTestAssertion - an invariant that the output must satisfy. Exactly
+// one of the fields must be set.
+//
+// Experimental: subject to breaking changes before the next major release
+type TemplateTestAssertion struct {
+	// Matches - a regular expression that the output must match
+	Matches string `yaml:"matches,omitempty"`
+	// Contains - a string that the output must contain
+	Contains string `yaml:"contains,omitempty"`
+	// NotContains - a string that the output must not contain
+	NotContains string `yaml:"notContains,omitempty"`
+	// Check - a template that must render "true", with the output as
+	// `.Output`, and the inputs as `.Inputs`
+	Check string `yaml:"check,omitempty"`
+}
+
+// TemplateTestResult - the result of a TemplateTest
+//
+// Experimental: subject to breaking changes before the next major release
+type TemplateTestResult struct {
+	Name string
+	// Cases - the number of cases rendered
+	Cases int
+	// Failed - the number of cases that failed
+	Failed int
+	// Failure - the first case that failed, if any
+	Failure *TemplateTestFailure
+}
+
+// TemplateTestFailure - a case that failed to render, or whose output didn't
+// satisfy an assertion
+//
+// Experimental: subject to breaking changes before the next major release
+type TemplateTestFailure struct {
+	// Case - the case's number (from 0), which with the seed identifies it
+	Case    int
+	Inputs  map[string]interface{}
+	Output  string
+	Message string
+}
+
+// defaultTestCases - the number of cases rendered for each test, unless
+// set
+const defaultTestCases = 100
+
+// RunTemplateTests renders each test's template once for each case, and
+// checks its assertions. Tests that are invalid (for example, with an
+// invalid input or assertion) fail with an error, while failing cases are
+// reported in the results.
+//
+// Experimental: subject to breaking changes before the next major release
+func RunTemplateTests(ctx context.Context, tests []TemplateTest) ([]TemplateTestResult, error) {
+	results := make([]TemplateTestResult, len(tests))
+	for i, tt := range tests {
+		r, err := runTemplateTest(ctx, tt)
+		if err != nil {
+			return nil, fmt.Errorf("test %q: %w", tt.Name, err)
+		}
+		results[i] = r
+	}
+	return results, nil
+}
+
+// testAssertion - checks an output, returning a message when it fails
+type testAssertion func(ctx context.Context, out string, inputs map[string]interface{}) string
+
+func runTemplateTest(ctx context.Context, tt TemplateTest) (TemplateTestResult, error) {
+	names := make([]string, 0, len(tt.Inputs))
+	for name := range tt.Inputs {
+		names = append(names, name)
+	}
+	// sorted, so that the same seed generates the same inputs
+	sort.Strings(names)
+
+	gens := make([]gen.Generator, len(names))
+	for i, name := range names {
+		g, err := inputGenerator(tt.Inputs[name])
+		if err != nil {
+			return TemplateTestResult{}, fmt.Errorf("input %s: %w", name, err)
+		}
+		gens[i] = g
+	}
+
+	asserts := make([]testAssertion, len(tt.Assert))
+	for i, a := range tt.Assert {
+		check, err := a.compile()
+		if err != nil {
+			return TemplateTestResult{}, fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+		asserts[i] = check
+	}
+
+	cases := tt.Cases
+	if cases <= 0 {
+		cases = defaultTestCases
+	}
+
+	result := TemplateTestResult{Name: tt.Name, Cases: cases}
+	//nolint:gosec
+	r := rand.New(rand.NewSource(tt.Seed))
+	for c := 0; c < cases; c++ {
+		if err := ctx.Err(); err != nil {
+			return TemplateTestResult{}, err
+		}
+
+		inputs := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			inputs[name] = gens[i].Generate(r, c)
+		}
+
+		msg := ""
+		out, err := renderTestCase(ctx, tt.Name, tt.Template, tmplctx(inputs))
+		if err != nil {
+			msg = err.Error()
+		}
+		for _, check := range asserts {
+			if msg != "" {
+				break
+			}
+			msg = check(ctx, out, inputs)
+		}
+		if msg == "" {
+			continue
+		}
+
+		result.Failed++
+		if result.Failure == nil {
+			result.Failure = &TemplateTestFailure{Case: c, Inputs: inputs, Output: out, Message: msg}
+		}
+	}
+	return result, nil
+}
+
+func inputGenerator(in TemplateTestInput) (gen.Generator, error) {
+	set := 0
+	for _, ok := range []bool{in.Range != nil, in.Enum != nil, in.Pattern != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of range, enum, or pattern must be set")
+	}
+
+	switch {
+	case in.Range != nil:
+		if len(in.Range) != 2 {
+			return nil, fmt.Errorf("range must be a minimum and a maximum, got %d values", len(in.Range))
+		}
+		return gen.Range(in.Range[0], in.Range[1])
+	case in.Enum != nil:
+		return gen.Enum(in.Enum)
+	default:
+		return gen.Pattern(in.Pattern)
+	}
+}
+
+func (a TemplateTestAssertion) compile() (testAssertion, error) {
+	set := 0
+	for _, s := range []string{a.Matches, a.Contains, a.NotContains, a.Check} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of matches, contains, notContains, or check must be set")
+	}
+
+	switch {
+	case a.Matches != "":
+		re, err := regexp.Compile(a.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return func(_ context.Context, out string, _ map[string]interface{}) string {
+			if re.MatchString(out) {
+				return ""
+			}
+			return fmt.Sprintf("output doesn't match %q", a.Matches)
+		}, nil
+	case a.Contains != "":
+		return func(_ context.Context, out string, _ map[string]interface{}) string {
+			if strings.Contains(out, a.Contains) {
+				return ""
+			}
+			return fmt.Sprintf("output doesn't contain %q", a.Contains)
+		}, nil
+	case a.NotContains != "":
+		return func(_ context.Context, out string, _ map[string]interface{}) string {
+			if !strings.Contains(out, a.NotContains) {
+				return ""
+			}
+			return fmt.Sprintf("output contains %q", a.NotContains)
+		}, nil
+	default:
+		return func(ctx context.Context, out string, inputs map[string]interface{}) string {
+			res, err := renderTestCase(ctx, "check", a.Check, tmplctx{"Output": out, "Inputs": inputs})
+			if err != nil {
+				return fmt.Sprintf("check %q failed: %v", a.Check, err)
+			}
+			if strings.TrimSpace(res) != "true" {
+				return fmt.Sprintf("check %q rendered %q, not true", a.Check, res)
+			}
+			return ""
+		}, nil
+	}
+}
+
+// renderTestCase renders the template with the given context
+func renderTestCase(ctx context.Context, name, text string, tctx tmplctx) (string, error) {
+	tr := NewRenderer(Options{})
+	tr.data.Ctx = ctx
+	buf := &bytes.Buffer{}
+	err := tr.renderTemplatesWithData(ctx, []Template{{Name: name, Text: text, Writer: buf}}, &tctx)
+	return buf.String(), err
+}
@@ -0,0 +1,89 @@
+package gomplate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTemplateTests(t *testing.T) {
+	ctx := context.Background()
+
+	results, err := RunTemplateTests(ctx, []TemplateTest{
+		{
+			Name:     "ports",
+			Template: `{{ printf "%05d" .port }}`,
+			Inputs:   map[string]TemplateTestInput{"port": {Range: []interface{}{1, 65535}}},
+			Assert: []TemplateTestAssertion{
+				{Matches: `^\d{5}$`},
+				{Check: `{{ eq (len .Output) 5 }}`},
+			},
+		},
+		{
+			Name:     "hosts",
+			Template: `{{ .env }}.{{ .host }}`,
+			Inputs: map[string]TemplateTestInput{
+				"env":  {Enum: []interface{}{"dev", "prod"}},
+				"host": {Pattern: `[a-z]{1,8}\.example\.com`},
+			},
+			Assert: []TemplateTestAssertion{{Contains: ".example.com"}, {NotContains: "<no value>"}},
+			Cases:  10,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateTestResult{
+		{Name: "ports", Cases: 100},
+		{Name: "hosts", Cases: 10},
+	}, results)
+
+	// the first failing case is reported, and the bounds are tried first
+	results, err = RunTemplateTests(ctx, []TemplateTest{
+		{
+			Name:     "percent",
+			Template: `{{ div 100 .n }}%`,
+			Inputs:   map[string]TemplateTestInput{"n": {Range: []interface{}{0, 10}}},
+			Assert:   []TemplateTestAssertion{{Matches: `^\d+%$`}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NotNil(t, results[0].Failure)
+	assert.Equal(t, 0, results[0].Failure.Case)
+	assert.Equal(t, map[string]interface{}{"n": int64(0)}, results[0].Failure.Inputs)
+	assert.Contains(t, results[0].Failure.Message, "division by 0")
+
+	// the same seed generates the same cases
+	tt := TemplateTest{
+		Name:     "seeded",
+		Template: `{{ .s }}`,
+		Inputs:   map[string]TemplateTestInput{"s": {Pattern: `[a-z]{3}`}},
+		Assert:   []TemplateTestAssertion{{Matches: `^a`}},
+		Seed:     7,
+	}
+	a, err := RunTemplateTests(ctx, []TemplateTest{tt})
+	require.NoError(t, err)
+	b, err := RunTemplateTests(ctx, []TemplateTest{tt})
+	require.NoError(t, err)
+	require.NotNil(t, a[0].Failure)
+	assert.Equal(t, a, b)
+
+	_, err = RunTemplateTests(ctx, []TemplateTest{{
+		Name:   "bad",
+		Inputs: map[string]TemplateTestInput{"x": {Range: []interface{}{1}}},
+	}})
+	assert.EqualError(t, err, `test "bad": input x: range must be a minimum and a maximum, got 1 values`)
+
+	_, err = RunTemplateTests(ctx, []TemplateTest{{
+		Name:   "bad",
+		Inputs: map[string]TemplateTestInput{"x": {Enum: []interface{}{1}, Pattern: "a"}},
+	}})
+	assert.EqualError(t, err, `test "bad": input x: exactly one of range, enum, or pattern must be set`)
+
+	_, err = RunTemplateTests(ctx, []TemplateTest{{
+		Name:   "bad",
+		Assert: []TemplateTestAssertion{{}},
+	}})
+	assert.EqualError(t, err, `test "bad": assertion 1: exactly one of matches, contains, notContains, or check must be set`)
+}
@@ -3,6 +3,10 @@
 package test
 
 import (
+	"fmt"
+	"reflect"
+	"regexp"
+
 	"github.com/pkg/errors"
 )
 
@@ -37,3 +41,46 @@ func Required(message string, value interface{}) (interface{}, error) {
 
 	return value, nil
 }
+
+// NotEmpty - passes through the value if it's not empty (nil, or an empty
+// string, slice, or map), and fails otherwise
+func NotEmpty(message string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		if message == "" {
+			message = "value is empty"
+		}
+		return nil, errors.Errorf("assertion failed: %s", message)
+	}
+	return value, nil
+}
+
+// Matches - passes through the value if it matches the regular expression,
+// and fails otherwise
+func Matches(pattern, message string, value interface{}) (interface{}, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern %q", pattern)
+	}
+	s := fmt.Sprint(value)
+	if value == nil || !re.MatchString(s) {
+		if message == "" {
+			message = fmt.Sprintf("%q does not match %q", s, pattern)
+		}
+		return nil, errors.Errorf("assertion failed: %s", message)
+	}
+	return value, nil
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
@@ -49,3 +49,38 @@ func TestRequired(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, v)
 }
+
+func TestNotEmpty(t *testing.T) {
+	for _, v := range []interface{}{nil, "", []string{}, map[string]interface{}{}, (*int)(nil)} {
+		_, err := NotEmpty("", v)
+		assert.EqualError(t, err, "assertion failed: value is empty", v)
+	}
+
+	_, err := NotEmpty("need a name", "")
+	assert.EqualError(t, err, "assertion failed: need a name")
+
+	for _, v := range []interface{}{"a", 0, false, []int{1}, map[string]int{"a": 1}} {
+		out, err := NotEmpty("", v)
+		assert.NoError(t, err)
+		assert.Equal(t, v, out)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	out, err := Matches(`^v\d+$`, "", "v12")
+	assert.NoError(t, err)
+	assert.Equal(t, "v12", out)
+
+	out, err = Matches(`^\d+$`, "", 42)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, out)
+
+	_, err = Matches(`^v\d+$`, "", "12")
+	assert.EqualError(t, err, `assertion failed: "12" does not match "^v\\d+$"`)
+
+	_, err = Matches(`^v\d+$`, "version must be like v1", nil)
+	assert.EqualError(t, err, "assertion failed: version must be like v1")
+
+	_, err = Matches(`(`, "", "x")
+	assert.ErrorContains(t, err, "invalid pattern")
+}
@@ -0,0 +1,107 @@
+package text
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffOptions controls how a diff is rendered
+type DiffOptions struct {
+	// FromName and ToName are the names shown in the diff's header
+	FromName string
+	ToName   string
+	// Context is the number of unchanged lines shown around each change
+	Context int
+}
+
+// Diff returns a unified diff between the old and new text, or an empty
+// string when they're the same
+func Diff(oldText, newText string, opts DiffOptions) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(oldText),
+		B:        splitLines(newText),
+		FromFile: opts.FromName,
+		ToFile:   opts.ToName,
+		Context:  opts.Context,
+	})
+}
+
+// DiffHTML returns the diff between the old and new text as an HTML table,
+// or an empty string when they're the same. Rows have the classes
+// "diff-hunk", "diff-ctx", "diff-del", and "diff-add", for styling, and the
+// first two columns hold the old and new line numbers.
+func DiffHTML(oldText, newText string, opts DiffOptions) string {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	groups := difflib.NewMatcher(a, b).GetGroupedOpCodes(opts.Context)
+	if len(groups) == 0 {
+		return ""
+	}
+
+	sb := &strings.Builder{}
+	row := func(class, oldNum, newNum, prefix, line string) {
+		fmt.Fprintf(sb, "<tr class=\"%s\"><td>%s</td><td>%s</td><td>%s%s</td></tr>\n",
+			class, oldNum, newNum, prefix, html.EscapeString(strings.TrimSuffix(line, "\n")))
+	}
+
+	sb.WriteString("<table class=\"diff\">\n")
+	if opts.FromName != "" || opts.ToName != "" {
+		fmt.Fprintf(sb, "<thead><tr><th colspan=\"3\">--- %s<br>+++ %s</th></tr></thead>\n",
+			html.EscapeString(opts.FromName), html.EscapeString(opts.ToName))
+	}
+	sb.WriteString("<tbody>\n")
+	for _, g := range groups {
+		first, last := g[0], g[len(g)-1]
+		fmt.Fprintf(sb, "<tr class=\"diff-hunk\"><td colspan=\"3\">@@ -%s +%s @@</td></tr>\n",
+			hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2))
+		for _, c := range g {
+			switch c.Tag {
+			case 'e':
+				for i := c.I1; i < c.I2; i++ {
+					row("diff-ctx", fmt.Sprint(i+1), fmt.Sprint(c.J1+i-c.I1+1), " ", a[i])
+				}
+			case 'r', 'd', 'i':
+				for i := c.I1; i < c.I2; i++ {
+					row("diff-del", fmt.Sprint(i+1), "", "-", a[i])
+				}
+				for j := c.J1; j < c.J2; j++ {
+					row("diff-add", "", fmt.Sprint(j+1), "+", b[j])
+				}
+			}
+		}
+	}
+	sb.WriteString("</tbody>\n</table>\n")
+	return sb.String()
+}
+
+// hunkRange formats a range of lines for a hunk header, like in unified
+// diffs
+func hunkRange(start, stop int) string {
+	beginning := start + 1
+	length := stop - start
+	switch length {
+	case 1:
+		return fmt.Sprint(beginning)
+	case 0:
+		beginning--
+	}
+	return fmt.Sprintf("%d,%d", beginning, length)
+}
+
+// splitLines splits the text into lines, each ending with a newline. Unlike
+// difflib.SplitLines, a trailing newline doesn't add an empty line.
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
@@ -0,0 +1,63 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldText := "a: 1\nb: 2\nc: 3\n"
+	newText := "a: 1\nb: 20\nc: 3\nd: 4\n"
+
+	out, err := Diff(oldText, newText, DiffOptions{FromName: "old", ToName: "new", Context: 3})
+	require.NoError(t, err)
+	assert.Equal(t, `--- old
++++ new
+@@ -1,3 +1,4 @@
+ a: 1
+-b: 2
++b: 20
+ c: 3
++d: 4
+`, out)
+
+	out, err = Diff(oldText, newText, DiffOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `@@ -2 +2 @@
+-b: 2
++b: 20
+@@ -3,0 +4 @@
++d: 4
+`, out)
+
+	out, err = Diff(oldText, oldText, DiffOptions{FromName: "old", ToName: "new", Context: 3})
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	out, err = Diff("", "a\n", DiffOptions{Context: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "@@ -0,0 +1 @@\n+a\n", out)
+
+	// a missing trailing newline isn't a difference
+	out, err = Diff("a\nb", "a\nb\n", DiffOptions{Context: 3})
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestDiffHTML(t *testing.T) {
+	out := DiffHTML("a\n<b>\n", "a\n<c>\n", DiffOptions{FromName: "x.html", ToName: "y.html", Context: 3})
+	assert.Equal(t, `<table class="diff">
+<thead><tr><th colspan="3">--- x.html<br>+++ y.html</th></tr></thead>
+<tbody>
+<tr class="diff-hunk"><td colspan="3">@@ -1,2 +1,2 @@</td></tr>
+<tr class="diff-ctx"><td>1</td><td>1</td><td> a</td></tr>
+<tr class="diff-del"><td>2</td><td></td><td>-&lt;b&gt;</td></tr>
+<tr class="diff-add"><td></td><td>2</td><td>+&lt;c&gt;</td></tr>
+</tbody>
+</table>
+`, out)
+
+	assert.Empty(t, DiffHTML("a\n", "a\n", DiffOptions{}))
+}
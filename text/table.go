@@ -0,0 +1,209 @@
+// Package text contains functions for formatting text for human consumption.
+package text
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hairyhenderson/gomplate/v3/conv"
+)
+
+// TableOptions controls how a table is rendered
+type TableOptions struct {
+	// Format is one of "ascii" (the default), "markdown", or "csv"
+	Format string
+	// Columns selects (and orders) the columns to include. When empty, all
+	// keys found in any row are used, in alphabetical order.
+	Columns []string
+	// Sort is the name of the column to sort rows by. Prefix with "-" to sort
+	// in descending order. When empty, the input order is kept.
+	Sort string
+}
+
+// Table renders a list of maps as a table, with one row per map and one
+// column per key.
+func Table(rows []map[string]interface{}, opts TableOptions) (string, error) {
+	cols := opts.Columns
+	if len(cols) == 0 {
+		cols = allKeys(rows)
+	}
+
+	if opts.Sort != "" {
+		key := strings.TrimPrefix(opts.Sort, "-")
+		desc := key != opts.Sort
+		rows = append([]map[string]interface{}{}, rows...)
+		sort.SliceStable(rows, func(i, j int) bool {
+			if desc {
+				return less(rows[j][key], rows[i][key])
+			}
+			return less(rows[i][key], rows[j][key])
+		})
+	}
+
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		cells[i] = make([]string, len(cols))
+		for j, c := range cols {
+			if v, ok := r[c]; ok && v != nil {
+				cells[i][j] = conv.ToString(v)
+			}
+		}
+	}
+
+	switch opts.Format {
+	case "", "ascii":
+		return asciiTable(cols, cells), nil
+	case "markdown", "md":
+		return markdownTable(cols, cells), nil
+	case "csv":
+		return csvTable(cols, cells)
+	default:
+		return "", fmt.Errorf("unsupported table format %q (must be one of ascii, markdown, or csv)", opts.Format)
+	}
+}
+
+func allKeys(rows []map[string]interface{}) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, r := range rows {
+		for k := range r {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// less compares values numerically when both are numbers, and as strings
+// otherwise. Missing values sort first.
+func less(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	as, bs := conv.ToString(a), conv.ToString(b)
+	af, aerr := strconv.ParseFloat(as, 64)
+	bf, berr := strconv.ParseFloat(bs, 64)
+	if aerr == nil && berr == nil {
+		return af < bf
+	}
+	return as < bs
+}
+
+func widths(cols []string, cells [][]string) []int {
+	w := make([]int, len(cols))
+	for i, c := range cols {
+		w[i] = utf8.RuneCountInString(c)
+	}
+	for _, row := range cells {
+		for i, c := range row {
+			if n := utf8.RuneCountInString(c); n > w[i] {
+				w[i] = n
+			}
+		}
+	}
+	return w
+}
+
+func pad(s string, w int) string {
+	return s + strings.Repeat(" ", w-utf8.RuneCountInString(s))
+}
+
+func asciiTable(cols []string, cells [][]string) string {
+	// multi-line values would break the layout
+	for _, row := range cells {
+		for i, c := range row {
+			row[i] = strings.ReplaceAll(c, "\n", " ")
+		}
+	}
+	w := widths(cols, cells)
+
+	out := &strings.Builder{}
+	sep := &strings.Builder{}
+	sep.WriteString("+")
+	for _, n := range w {
+		sep.WriteString(strings.Repeat("-", n+2) + "+")
+	}
+	sep.WriteString("\n")
+
+	writeRow := func(row []string) {
+		out.WriteString("|")
+		for i, c := range row {
+			out.WriteString(" " + pad(c, w[i]) + " |")
+		}
+		out.WriteString("\n")
+	}
+
+	out.WriteString(sep.String())
+	writeRow(cols)
+	out.WriteString(sep.String())
+	for _, row := range cells {
+		writeRow(row)
+	}
+	if len(cells) > 0 {
+		out.WriteString(sep.String())
+	}
+	return out.String()
+}
+
+var mdEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func markdownTable(cols []string, cells [][]string) string {
+	hdr := make([]string, len(cols))
+	for i, c := range cols {
+		hdr[i] = mdEscaper.Replace(c)
+	}
+	for _, row := range cells {
+		for i, c := range row {
+			row[i] = mdEscaper.Replace(c)
+		}
+	}
+	w := widths(hdr, cells)
+	for i := range w {
+		// the delimiter row needs at least 3 dashes
+		if w[i] < 3 {
+			w[i] = 3
+		}
+	}
+
+	out := &strings.Builder{}
+	writeRow := func(row []string) {
+		out.WriteString("|")
+		for i, c := range row {
+			out.WriteString(" " + pad(c, w[i]) + " |")
+		}
+		out.WriteString("\n")
+	}
+
+	writeRow(hdr)
+	out.WriteString("|")
+	for _, n := range w {
+		out.WriteString(" " + strings.Repeat("-", n) + " |")
+	}
+	out.WriteString("\n")
+	for _, row := range cells {
+		writeRow(row)
+	}
+	return out.String()
+}
+
+func csvTable(cols []string, cells [][]string) (string, error) {
+	b := &bytes.Buffer{}
+	c := csv.NewWriter(b)
+	// RFC4180 CSV, as with data.ToCSV
+	c.UseCRLF = true
+	if err := c.Write(cols); err != nil {
+		return "", err
+	}
+	if err := c.WriteAll(cells); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,71 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRows() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"name": "bar", "size": 10, "owner": "alice"},
+		{"name": "foo", "size": 9},
+		{"name": "baz|qux", "size": 100.5, "owner": nil},
+	}
+}
+
+func TestTable_ASCII(t *testing.T) {
+	out, err := Table(testRows(), TableOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `+---------+-------+-------+
+| name    | owner | size  |
++---------+-------+-------+
+| bar     | alice | 10    |
+| foo     |       | 9     |
+| baz|qux |       | 100.5 |
++---------+-------+-------+
+`, out)
+
+	out, err = Table(nil, TableOptions{Columns: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "+---+---+\n| a | b |\n+---+---+\n", out)
+}
+
+func TestTable_Sort(t *testing.T) {
+	rows := testRows()
+	out, err := Table(rows, TableOptions{Format: "csv", Columns: []string{"size", "name"}, Sort: "size"})
+	require.NoError(t, err)
+	assert.Equal(t, "size,name\r\n9,foo\r\n10,bar\r\n100.5,baz|qux\r\n", out)
+
+	out, err = Table(rows, TableOptions{Format: "csv", Columns: []string{"name"}, Sort: "-name"})
+	require.NoError(t, err)
+	assert.Equal(t, "name\r\nfoo\r\nbaz|qux\r\nbar\r\n", out)
+
+	out, err = Table(rows, TableOptions{Format: "csv", Columns: []string{"name"}, Sort: "owner"})
+	require.NoError(t, err)
+	assert.Equal(t, "name\r\nfoo\r\nbaz|qux\r\nbar\r\n", out)
+
+	// input must not be reordered
+	assert.Equal(t, "bar", rows[0]["name"])
+}
+
+func TestTable_Markdown(t *testing.T) {
+	out, err := Table(testRows(), TableOptions{Format: "markdown", Columns: []string{"name", "id"}})
+	require.NoError(t, err)
+	assert.Equal(t, `| name     | id  |
+| -------- | --- |
+| bar      |     |
+| foo      |     |
+| baz\|qux |     |
+`, out)
+
+	out, err = Table([]map[string]interface{}{{"a": "multi\nline"}}, TableOptions{Format: "md"})
+	require.NoError(t, err)
+	assert.Equal(t, "| a             |\n| ------------- |\n| multi<br>line |\n", out)
+}
+
+func TestTable_Errors(t *testing.T) {
+	_, err := Table(testRows(), TableOptions{Format: "html"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package time
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event - a calendar event, for rendering with ToICS
+type Event struct {
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Status      string
+	RRule       string
+	Organizer   string
+	Attendees   []string
+	Categories  []string
+	// AllDay events use only the date parts of Start and End
+	AllDay bool
+}
+
+// CalendarOptions - options for ToICS
+type CalendarOptions struct {
+	// Name is the calendar's display name (X-WR-CALNAME)
+	Name string
+	// ProdID identifies the product that created the calendar
+	ProdID string
+}
+
+const (
+	icsDateTime = "20060102T150405Z"
+	icsDate     = "20060102"
+
+	// icsLineLength is the maximum length of a content line, in octets,
+	// excluding the line break (RFC 5545 section 3.1)
+	icsLineLength = 75
+
+	defaultProdID = "-//gomplate//gomplate//EN"
+)
+
+// ToICS - render the given events as an iCalendar (RFC 5545) document
+func ToICS(events []Event, opts CalendarOptions) (string, error) {
+	if opts.ProdID == "" {
+		opts.ProdID = defaultProdID
+	}
+
+	w := &icsWriter{}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + escapeText(opts.ProdID))
+	w.line("CALSCALE:GREGORIAN")
+	if opts.Name != "" {
+		w.line("X-WR-CALNAME:" + escapeText(opts.Name))
+	}
+
+	for i, e := range events {
+		if err := w.event(e); err != nil {
+			return "", fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+
+	w.line("END:VCALENDAR")
+	return w.String(), nil
+}
+
+type icsWriter struct {
+	strings.Builder
+}
+
+func (w *icsWriter) event(e Event) error {
+	if e.Start.IsZero() {
+		return fmt.Errorf("start is required")
+	}
+	if !e.End.IsZero() && e.End.Before(e.Start) {
+		return fmt.Errorf("end (%s) is before start (%s)", e.End, e.Start)
+	}
+	status := strings.ToUpper(e.Status)
+	switch status {
+	case "", "TENTATIVE", "CONFIRMED", "CANCELLED":
+	default:
+		return fmt.Errorf("invalid status %q: must be one of tentative, confirmed, or cancelled", e.Status)
+	}
+
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	uid := e.UID
+	if uid == "" {
+		uid = eventUID(e)
+	}
+
+	w.line("BEGIN:VEVENT")
+	w.line("UID:" + escapeText(uid))
+	w.line("DTSTAMP:" + stamp.UTC().Format(icsDateTime))
+	if e.AllDay {
+		end := e.End
+		if end.IsZero() || !end.After(e.Start) {
+			// DTEND is exclusive, so a single-day event ends the next day
+			end = e.Start.AddDate(0, 0, 1)
+		}
+		w.line("DTSTART;VALUE=DATE:" + e.Start.Format(icsDate))
+		w.line("DTEND;VALUE=DATE:" + end.Format(icsDate))
+	} else {
+		w.line("DTSTART:" + e.Start.UTC().Format(icsDateTime))
+		if !e.End.IsZero() {
+			w.line("DTEND:" + e.End.UTC().Format(icsDateTime))
+		}
+	}
+	w.optional("SUMMARY:", escapeText(e.Summary))
+	w.optional("DESCRIPTION:", escapeText(e.Description))
+	w.optional("LOCATION:", escapeText(e.Location))
+	w.optional("URL:", e.URL)
+	w.optional("STATUS:", status)
+	w.optional("RRULE:", e.RRule)
+	if len(e.Categories) > 0 {
+		c := make([]string, len(e.Categories))
+		for i, s := range e.Categories {
+			c[i] = escapeText(s)
+		}
+		w.line("CATEGORIES:" + strings.Join(c, ","))
+	}
+	w.optional("ORGANIZER:", mailto(e.Organizer))
+	for _, a := range e.Attendees {
+		w.line("ATTENDEE:" + mailto(a))
+	}
+	w.line("END:VEVENT")
+	return nil
+}
+
+func (w *icsWriter) optional(name, value string) {
+	if value != "" {
+		w.line(name + value)
+	}
+}
+
+// line writes a content line, folding it so that no line is longer than 75
+// octets. Continuation lines start with a single space. Lines are only folded
+// on character boundaries, so multi-byte characters aren't split.
+func (w *icsWriter) line(s string) {
+	limit := icsLineLength
+	for len(s) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		w.WriteString(s[:i])
+		w.WriteString("\r\n ")
+		s = s[i:]
+		// leave room for the leading space
+		limit = icsLineLength - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11)
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+func mailto(addr string) string {
+	if addr == "" || strings.Contains(addr, ":") {
+		return addr
+	}
+	return "mailto:" + addr
+}
+
+// eventUID generates a stable UID for events that don't have one, so that
+// calendar clients can recognize the same event when the calendar is
+// regenerated.
+func eventUID(e Event) string {
+	h := sha256.Sum256([]byte(e.Start.UTC().Format(time.RFC3339) + "\x00" + e.Summary + "\x00" + e.Location))
+	return fmt.Sprintf("%x@gomplate", h[:12])
+}
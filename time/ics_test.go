@@ -0,0 +1,109 @@
+package time
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToICS(t *testing.T) {
+	stamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	out, err := ToICS(nil, CalendarOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//gomplate//gomplate//EN\r\n"+
+		"CALSCALE:GREGORIAN\r\nEND:VCALENDAR\r\n", out)
+
+	events := []Event{
+		{
+			UID:        "1@example.com",
+			Stamp:      stamp,
+			Start:      time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			AllDay:     true,
+			Summary:    "On call: Alice, Bob; backup",
+			Categories: []string{"on-call", "a,b"},
+		},
+		{
+			UID:         "2@example.com",
+			Stamp:       stamp,
+			Start:       time.Date(2024, 3, 5, 23, 0, 0, 0, time.FixedZone("", 3600)),
+			End:         time.Date(2024, 3, 6, 1, 0, 0, 0, time.FixedZone("", 3600)),
+			Summary:     "Maintenance",
+			Description: "line one\nline two",
+			Status:      "confirmed",
+			RRule:       "FREQ=WEEKLY;COUNT=4",
+			Organizer:   "ops@example.com",
+			Attendees:   []string{"a@example.com", "mailto:b@example.com"},
+		},
+	}
+	out, err = ToICS(events, CalendarOptions{Name: "Ops", ProdID: "-//Example//Ops//EN"})
+	require.NoError(t, err)
+	expected := `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Example//Ops//EN
+CALSCALE:GREGORIAN
+X-WR-CALNAME:Ops
+BEGIN:VEVENT
+UID:1@example.com
+DTSTAMP:20240101T000000Z
+DTSTART;VALUE=DATE:20240304
+DTEND;VALUE=DATE:20240305
+SUMMARY:On call: Alice\, Bob\; backup
+CATEGORIES:on-call,a\,b
+END:VEVENT
+BEGIN:VEVENT
+UID:2@example.com
+DTSTAMP:20240101T000000Z
+DTSTART:20240305T220000Z
+DTEND:20240306T000000Z
+SUMMARY:Maintenance
+DESCRIPTION:line one\nline two
+STATUS:CONFIRMED
+RRULE:FREQ=WEEKLY;COUNT=4
+ORGANIZER:mailto:ops@example.com
+ATTENDEE:mailto:a@example.com
+ATTENDEE:mailto:b@example.com
+END:VEVENT
+END:VCALENDAR
+`
+	assert.Equal(t, strings.ReplaceAll(expected, "\n", "\r\n"), out)
+
+	_, err = ToICS([]Event{{Summary: "no start"}}, CalendarOptions{})
+	assert.ErrorContains(t, err, "event 0: start is required")
+
+	_, err = ToICS([]Event{{Start: stamp, End: stamp.Add(-time.Hour)}}, CalendarOptions{})
+	assert.ErrorContains(t, err, "is before start")
+
+	_, err = ToICS([]Event{{Start: stamp, Status: "maybe"}}, CalendarOptions{})
+	assert.ErrorContains(t, err, "invalid status")
+}
+
+func TestEventUID(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	a := eventUID(Event{Start: start, Summary: "a"})
+	assert.Equal(t, a, eventUID(Event{Start: start.In(time.FixedZone("", 3600)), Summary: "a"}))
+	assert.NotEqual(t, a, eventUID(Event{Start: start, Summary: "b"}))
+	assert.True(t, strings.HasSuffix(a, "@gomplate"))
+}
+
+func TestICSLineFolding(t *testing.T) {
+	w := &icsWriter{}
+	w.line(strings.Repeat("a", 75))
+	assert.Equal(t, strings.Repeat("a", 75)+"\r\n", w.String())
+
+	w = &icsWriter{}
+	w.line(strings.Repeat("a", 160))
+	assert.Equal(t, strings.Repeat("a", 75)+"\r\n "+strings.Repeat("a", 74)+"\r\n "+strings.Repeat("a", 11)+"\r\n", w.String())
+
+	// multi-byte characters aren't split across lines
+	w = &icsWriter{}
+	w.line(strings.Repeat("a", 74) + "é")
+	assert.Equal(t, strings.Repeat("a", 74)+"\r\n é\r\n", w.String())
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne\nf`, escapeText("a\\b;c,d\r\ne\nf"))
+}
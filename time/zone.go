@@ -0,0 +1,121 @@
+package time
+
+import (
+	"time"
+)
+
+// ZoneState - the state of a time zone at an instant
+type ZoneState struct {
+	// Abbreviation - the zone's abbreviated name, like "CET" or "PDT"
+	Abbreviation string
+	// Offset - the offset from UTC, in seconds east of UTC
+	Offset int
+	// IsDST - whether daylight saving time is in effect
+	IsDST bool
+}
+
+// Transition - a change in a time zone's offset or abbreviation, like the
+// start or end of daylight saving time
+type Transition struct {
+	// Time - the first instant the After state is in effect
+	Time   time.Time
+	Before ZoneState
+	After  ZoneState
+}
+
+// ZoneInfo - details of a time zone at an instant
+type ZoneInfo struct {
+	ZoneState
+	// Name - the location's name, like "Europe/Paris"
+	Name string
+	// ObservesDST - whether the zone has daylight saving time in the year
+	ObservesDST bool
+	// Transitions - the zone's transitions in the year
+	Transitions []Transition
+}
+
+// maxTransitionSearch - how far ahead NextTransition looks before deciding a
+// zone has no more transitions
+const maxTransitionSearch = 4 * 366 * 24 * time.Hour
+
+func stateAt(t time.Time) ZoneState {
+	name, offset := t.Zone()
+	return ZoneState{Abbreviation: name, Offset: offset, IsDST: t.IsDST()}
+}
+
+// GetZoneInfo returns details of the location's time zone at the time, along
+// with all of its transitions in that (local) calendar year
+func GetZoneInfo(loc *time.Location, t time.Time) ZoneInfo {
+	t = t.In(loc)
+	start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+	transitions := Transitions(loc, start, end)
+
+	info := ZoneInfo{
+		ZoneState:   stateAt(t),
+		Name:        loc.String(),
+		Transitions: transitions,
+	}
+	for _, tr := range transitions {
+		if tr.Before.IsDST || tr.After.IsDST {
+			info.ObservesDST = true
+		}
+	}
+	return info
+}
+
+// NextTransition returns the location's first transition after the time, or
+// nil when there are none in the next few years
+func NextTransition(loc *time.Location, t time.Time) *Transition {
+	transitions := transitionsUntil(loc, t, t.Add(maxTransitionSearch), 1)
+	if len(transitions) == 0 {
+		return nil
+	}
+	return &transitions[0]
+}
+
+// Transitions returns the location's transitions after start, up to and
+// including end
+func Transitions(loc *time.Location, start, end time.Time) []Transition {
+	return transitionsUntil(loc, start, end, -1)
+}
+
+// transitionsUntil finds up to max (or unlimited, if negative) transitions in
+// (start, end]. Zone changes aren't exposed by the time package, so this
+// steps through the range a day at a time, and bisects each day where the
+// zone changed to find the second it changed at.
+func transitionsUntil(loc *time.Location, start, end time.Time, max int) []Transition {
+	const step = 24 * time.Hour
+
+	out := []Transition{}
+	prev := start.In(loc)
+	prevState := stateAt(prev)
+	for prev.Before(end) && (max < 0 || len(out) < max) {
+		next := prev.Add(step)
+		if next.After(end) {
+			next = end.In(loc)
+		}
+		nextState := stateAt(next)
+		if nextState != prevState {
+			at := bisect(loc, prev, next, prevState)
+			out = append(out, Transition{Time: at, Before: prevState, After: stateAt(at)})
+		}
+		prev, prevState = next, nextState
+	}
+	return out
+}
+
+// bisect finds the first second in (lo, hi] where the zone's state is no
+// longer the given state
+func bisect(loc *time.Location, lo, hi time.Time, state ZoneState) time.Time {
+	l, h := lo.Unix(), hi.Unix()
+	for h-l > 1 {
+		mid := l + (h-l)/2
+		if stateAt(time.Unix(mid, 0).In(loc)) == state {
+			l = mid
+		} else {
+			h = mid
+		}
+	}
+	return time.Unix(h, 0).In(loc)
+}
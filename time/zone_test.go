@@ -0,0 +1,52 @@
+package time
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextTransition(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	tr := NextTransition(london, time.Date(2022, time.January, 15, 0, 0, 0, 0, time.UTC))
+	require.NotNil(t, tr)
+	assert.Equal(t, time.Date(2022, time.March, 27, 1, 0, 0, 0, time.UTC), tr.Time.UTC())
+	assert.Equal(t, ZoneState{Abbreviation: "GMT", Offset: 0}, tr.Before)
+	assert.Equal(t, ZoneState{Abbreviation: "BST", Offset: 3600, IsDST: true}, tr.After)
+
+	// the instant of a transition isn't after itself
+	tr = NextTransition(london, tr.Time)
+	require.NotNil(t, tr)
+	assert.Equal(t, time.Date(2022, time.October, 30, 1, 0, 0, 0, time.UTC), tr.Time.UTC())
+	assert.False(t, tr.After.IsDST)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Nil(t, NextTransition(tokyo, time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestGetZoneInfo(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+
+	info := GetZoneInfo(sydney, time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "Australia/Sydney", info.Name)
+	assert.Equal(t, "AEST", info.Abbreviation)
+	assert.Equal(t, 10*3600, info.Offset)
+	assert.False(t, info.IsDST)
+	assert.True(t, info.ObservesDST)
+	require.Len(t, info.Transitions, 2)
+	assert.Equal(t, time.Date(2022, time.April, 2, 16, 0, 0, 0, time.UTC), info.Transitions[0].Time.UTC())
+	assert.Equal(t, time.Date(2022, time.October, 1, 16, 0, 0, 0, time.UTC), info.Transitions[1].Time.UTC())
+	assert.True(t, info.Transitions[1].After.IsDST)
+
+	utc := GetZoneInfo(time.UTC, time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "UTC", utc.Name)
+	assert.False(t, utc.ObservesDST)
+	assert.Empty(t, utc.Transitions)
+}
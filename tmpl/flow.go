@@ -0,0 +1,148 @@
+package tmpl
+
+import (
+	"context"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Flow - functions for recovering from failing calls, so templates can
+// degrade gracefully (for example when an optional datasource is down). The
+// functions are given by name or value, like Memoize, because an error in a
+// pipeline stops the template before its result could be handled.
+type Flow struct {
+	funcs template.FuncMap
+	// ctxFuncs - see WithContextFuncs
+	ctxFuncs template.FuncMap
+	// sleep is overridden in tests
+	sleep func(time.Duration)
+}
+
+// NewFlow - creates a Flow, which resolves function names in funcs
+func NewFlow(funcs template.FuncMap) *Flow {
+	return &Flow{funcs: funcs, sleep: time.Sleep}
+}
+
+// WithContextFuncs - sets variants of some of the functions (by the same
+// names) that take a context as their first argument. Timeout calls these
+// instead, with a context that's cancelled when the call times out, so that
+// the call stops rather than carrying on in the background.
+func (f *Flow) WithContextFuncs(funcs template.FuncMap) *Flow {
+	f.ctxFuncs = funcs
+	return f
+}
+
+// TryOr - calls the function with the arguments, and returns the default
+// value if it fails (or panics)
+func (f *Flow) TryOr(def interface{}, fn interface{}, args ...interface{}) (interface{}, error) {
+	_, call, err := bind(f.funcs, fn, args)
+	if err != nil {
+		return nil, fmt.Errorf("tryOr: %w", err)
+	}
+
+	out, err := safeCall(call)
+	if err != nil {
+		return def, nil
+	}
+	return out, nil
+}
+
+// Retry - calls the function with the arguments up to n times, until it
+// succeeds. The backoff is the delay before the first retry, and doubles
+// after each. It can be a duration string (like "500ms") or a time.Duration.
+func (f *Flow) Retry(n int, backoff interface{}, fn interface{}, args ...interface{}) (interface{}, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("retry: number of attempts must be at least 1, got %d", n)
+	}
+	delay, err := toDuration(backoff)
+	if err != nil {
+		return nil, fmt.Errorf("retry: invalid backoff: %w", err)
+	}
+	name, call, err := bind(f.funcs, fn, args)
+	if err != nil {
+		return nil, fmt.Errorf("retry: %w", err)
+	}
+
+	for i := 1; ; i++ {
+		out, err := safeCall(call)
+		if err == nil {
+			return out, nil
+		}
+		if i == n {
+			return nil, fmt.Errorf("%s failed after %d attempts: %w", name, n, err)
+		}
+		f.sleep(delay)
+		delay *= 2
+	}
+}
+
+// Timeout - calls the function with the arguments, and fails if it doesn't
+// return within the duration, which can be a duration string (like "5s") or a
+// time.Duration. Functions with a context-aware variant (see
+// WithContextFuncs) are cancelled when they time out - others can't be
+// interrupted, so the call keeps running in the background, but its result is
+// discarded.
+func (f *Flow) Timeout(d interface{}, fn interface{}, args ...interface{}) (interface{}, error) {
+	dur, err := toDuration(d)
+	if err != nil {
+		return nil, fmt.Errorf("timeout: invalid duration: %w", err)
+	}
+	name, call, err := bind(f.funcs, fn, args)
+	if err != nil {
+		return nil, fmt.Errorf("timeout: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, ok := f.ctxFuncs[name]; ok {
+		_, call, err = bind(f.ctxFuncs, name, append([]interface{}{ctx}, args...))
+		if err != nil {
+			return nil, fmt.Errorf("timeout: %w", err)
+		}
+	}
+
+	type result struct {
+		out interface{}
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		out, err := safeCall(call)
+		ch <- result{out, err}
+	}()
+
+	t := time.NewTimer(dur)
+	defer t.Stop()
+	select {
+	case r := <-ch:
+		return r.out, r.err
+	case <-t.C:
+		return nil, fmt.Errorf("%s timed out after %v", name, dur)
+	}
+}
+
+// safeCall calls the function, returning panics as errors, like text/template
+// does
+func safeCall(call func() (interface{}, error)) (out interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	return call()
+}
+
+func toDuration(d interface{}) (time.Duration, error) {
+	switch d := d.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		return time.ParseDuration(d)
+	}
+	return 0, fmt.Errorf("want a duration or a duration string, got %T", d)
+}
@@ -0,0 +1,110 @@
+package tmpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryOr(t *testing.T) {
+	f := NewFlow(template.FuncMap{
+		"ok":    func(s string) string { return s },
+		"fail":  func() (string, error) { return "", errors.New("oops") },
+		"panic": func() string { panic("boom") },
+	})
+
+	out, err := f.TryOr("default", "ok", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", out)
+
+	out, err = f.TryOr("default", "fail")
+	require.NoError(t, err)
+	assert.Equal(t, "default", out)
+
+	out, err = f.TryOr(nil, "panic")
+	require.NoError(t, err)
+	assert.Nil(t, out)
+
+	// mistakes in the call itself aren't hidden
+	_, err = f.TryOr("default", "nope")
+	assert.EqualError(t, err, `tryOr: function "nope" not defined`)
+	_, err = f.TryOr("default", "ok")
+	assert.EqualError(t, err, "tryOr: wrong number of args for ok: want 1, got 0")
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	f := NewFlow(template.FuncMap{
+		"flaky": func(n int) (int, error) {
+			calls++
+			if calls < n {
+				return 0, errors.New("not yet")
+			}
+			return calls, nil
+		},
+	})
+	delays := []time.Duration{}
+	f.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	out, err := f.Retry(5, "100ms", "flaky", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, out)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, delays)
+
+	calls = 0
+	delays = []time.Duration{}
+	_, err = f.Retry(2, time.Second, "flaky", 10)
+	assert.EqualError(t, err, "flaky failed after 2 attempts: not yet")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []time.Duration{time.Second}, delays)
+
+	_, err = f.Retry(0, "1s", "flaky", 1)
+	assert.EqualError(t, err, "retry: number of attempts must be at least 1, got 0")
+
+	_, err = f.Retry(1, 42, "flaky", 1)
+	assert.EqualError(t, err, "retry: invalid backoff: want a duration or a duration string, got int")
+}
+
+func TestTimeout(t *testing.T) {
+	f := NewFlow(template.FuncMap{
+		"sleep": func(d time.Duration) string {
+			time.Sleep(d)
+			return "done"
+		},
+	})
+
+	out, err := f.Timeout("1s", "sleep", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "done", out)
+
+	_, err = f.Timeout("10ms", "sleep", time.Second)
+	assert.EqualError(t, err, "sleep timed out after 10ms")
+
+	_, err = f.Timeout("soon", "sleep", time.Second)
+	assert.EqualError(t, err, `timeout: invalid duration: time: invalid duration "soon"`)
+}
+
+func TestTimeout_Context(t *testing.T) {
+	cancelled := make(chan error, 1)
+	f := NewFlow(template.FuncMap{
+		"wait": func(s string) string { return s },
+	}).WithContextFuncs(template.FuncMap{
+		"wait": func(ctx context.Context, s string) (string, error) {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return "", ctx.Err()
+		},
+	})
+
+	_, err := f.Timeout("10ms", "wait", "x")
+	assert.EqualError(t, err, "wait timed out after 10ms")
+	assert.Equal(t, context.Canceled, <-cancelled)
+
+	_, err = f.Timeout("10ms", "wait")
+	assert.EqualError(t, err, "timeout: wrong number of args for wait: want 1, got 0")
+}
@@ -0,0 +1,154 @@
+package tmpl
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// bind resolves the function and checks the arguments, returning a name for
+// the function (for cache keys and errors), and a func to call it with the
+// arguments.
+//
+// The function can be a function value, or the name of a template function -
+// either a plain function (like "ds"), or a namespaced one (like "data.JSON").
+func bind(funcs template.FuncMap, fn interface{}, args []interface{}) (string, func() (interface{}, error), error) {
+	name, f, err := lookup(funcs, fn)
+	if err != nil {
+		return "", nil, err
+	}
+
+	in, err := argValues(name, f.Type(), args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, func() (interface{}, error) {
+		out := f.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			return nil, out[1].Interface().(error)
+		}
+		return out[0].Interface(), nil
+	}, nil
+}
+
+// lookup resolves the function, returning its name - the name it was given
+// by, or for a function value, its name in funcs (or its Go name if it's not
+// a template function).
+func lookup(funcs template.FuncMap, fn interface{}) (string, reflect.Value, error) {
+	name, ok := fn.(string)
+	if !ok {
+		f := reflect.ValueOf(fn)
+		if f.Kind() != reflect.Func || f.IsNil() {
+			return "", reflect.Value{}, fmt.Errorf("function must be a name or a function, got %T", fn)
+		}
+		if name, ok := funcName(funcs, f); ok {
+			return name, f, nil
+		}
+		return runtime.FuncForPC(f.Pointer()).Name(), f, nil
+	}
+
+	nsName, method, namespaced := strings.Cut(name, ".")
+	f, ok := funcs[nsName]
+	if !ok {
+		return "", reflect.Value{}, fmt.Errorf("function %q not defined", name)
+	}
+	fv := reflect.ValueOf(f)
+	if !namespaced {
+		return name, fv, nil
+	}
+
+	// namespaces are functions with no arguments, which return the namespace
+	if fv.Kind() != reflect.Func || fv.Type().NumIn() != 0 || fv.Type().NumOut() != 1 {
+		return "", reflect.Value{}, fmt.Errorf("%q is not a namespace", nsName)
+	}
+	ns := fv.Call(nil)[0]
+	if ns.Kind() == reflect.Interface {
+		ns = ns.Elem()
+	}
+	mv := ns.MethodByName(method)
+	if !mv.IsValid() {
+		return "", reflect.Value{}, fmt.Errorf("function %q not defined", name)
+	}
+	return name, mv, nil
+}
+
+// funcName finds the name of the function value in funcs. Closures made by the
+// same function literal can't be told apart, so when more than one template
+// function could be the value, it's not found.
+func funcName(funcs template.FuncMap, f reflect.Value) (string, bool) {
+	found := ""
+	for name, v := range funcs {
+		fv := reflect.ValueOf(v)
+		if fv.Kind() != reflect.Func || fv.Type() != f.Type() || fv.Pointer() != f.Pointer() {
+			continue
+		}
+		if found != "" {
+			return "", false
+		}
+		found = name
+	}
+	return found, found != ""
+}
+
+// argValues converts the arguments to the function's parameter types where
+// that's safe, like text/template does for numbers
+func argValues(name string, t reflect.Type, args []interface{}) ([]reflect.Value, error) {
+	if t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s is not a function", name)
+	}
+	n := t.NumIn()
+	if t.IsVariadic() && len(args) < n-1 {
+		return nil, fmt.Errorf("wrong number of args for %s: want at least %d, got %d", name, n-1, len(args))
+	}
+	if !t.IsVariadic() && len(args) != n {
+		return nil, fmt.Errorf("wrong number of args for %s: want %d, got %d", name, n, len(args))
+	}
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, fmt.Errorf("can't call %s: it must return one value, or a value and an error", name)
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, a := range args {
+		var pt reflect.Type
+		if t.IsVariadic() && i >= n-1 {
+			pt = t.In(n - 1).Elem()
+		} else {
+			pt = t.In(i)
+		}
+		v, err := argValue(a, pt)
+		if err != nil {
+			return nil, fmt.Errorf("wrong type for argument %d of %s: %w", i+1, name, err)
+		}
+		in[i] = v
+	}
+	return in, nil
+}
+
+func argValue(a interface{}, t reflect.Type) (reflect.Value, error) {
+	if a == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(a)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	if isNumber(v.Kind()) && isNumber(t.Kind()) {
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("want %s, got %s", t, v.Type())
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
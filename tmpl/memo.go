@@ -0,0 +1,67 @@
+package tmpl
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"text/template"
+)
+
+// Memo caches the results of function calls for the rest of a template's
+// rendering. See Memoize.
+type Memo struct {
+	funcs template.FuncMap
+	cache map[string]interface{}
+	mu    sync.Mutex
+}
+
+// NewMemo - creates a Memo, which resolves function names in funcs
+func NewMemo(funcs template.FuncMap) *Memo {
+	return &Memo{funcs: funcs, cache: map[string]interface{}{}}
+}
+
+// Memoize - calls the function with the arguments, and caches the result, so
+// later calls with the same key, function, and arguments return it without
+// calling the function again. Errors aren't cached.
+//
+// The function can be the name of a template function - either a plain
+// function (like "ds"), or a namespaced one (like "data.JSON") - or a template
+// function's value. Results are cached by the function's template name, so
+// other function values (which can't be told apart reliably) are refused. A
+// named template can be cached by memoizing "tmpl.Exec".
+func (m *Memo) Memoize(key string, fn interface{}, args ...interface{}) (interface{}, error) {
+	if _, ok := fn.(string); !ok {
+		f := reflect.ValueOf(fn)
+		if f.Kind() == reflect.Func && !f.IsNil() {
+			name, ok := funcName(m.funcs, f)
+			if !ok {
+				return nil, fmt.Errorf("memoize: can't cache calls to a function that's not a template function - give a template function's name instead")
+			}
+			fn = name
+		}
+	}
+
+	name, call, err := bind(m.funcs, fn, args)
+	if err != nil {
+		return nil, fmt.Errorf("memoize: %w", err)
+	}
+
+	k := fmt.Sprintf("%s\x00%s\x00%#v", key, name, args)
+
+	m.mu.Lock()
+	out, ok := m.cache[k]
+	m.mu.Unlock()
+	if ok {
+		return out, nil
+	}
+
+	out, err = call()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.cache[k] = out
+	m.mu.Unlock()
+	return out, nil
+}
@@ -0,0 +1,137 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNS struct {
+	calls *int
+}
+
+func (n testNS) Upper(s string) string {
+	*n.calls++
+	return strings.ToUpper(s)
+}
+
+func TestMemoize(t *testing.T) {
+	calls := 0
+	ns := testNS{&calls}
+	funcs := template.FuncMap{
+		"ns": func() interface{} { return ns },
+		"add": func(a, b int) int {
+			calls++
+			return a + b
+		},
+		"fail": func() (string, error) {
+			calls++
+			return "", errors.New("oops")
+		},
+	}
+	m := NewMemo(funcs)
+
+	out, err := m.Memoize("k", "ns.Upper", "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "FOO", out)
+	out, err = m.Memoize("k", "ns.Upper", "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "FOO", out)
+	assert.Equal(t, 1, calls)
+
+	// different args, keys, or functions aren't cached together
+	_, _ = m.Memoize("k", "ns.Upper", "bar")
+	_, _ = m.Memoize("other", "ns.Upper", "foo")
+	assert.Equal(t, 3, calls)
+
+	out, err = m.Memoize("k", "add", 1, 2.0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, out)
+	_, _ = m.Memoize("k", "add", 1, 2.0)
+	assert.Equal(t, 4, calls)
+
+	// template functions' values are cached by their names
+	out, err = m.Memoize("k", funcs["add"], 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, out)
+	assert.Equal(t, 4, calls)
+
+	// errors aren't cached
+	_, err = m.Memoize("k", "fail")
+	assert.EqualError(t, err, "oops")
+	_, err = m.Memoize("k", "fail")
+	assert.EqualError(t, err, "oops")
+	assert.Equal(t, 6, calls)
+}
+
+func TestMemoize_Errors(t *testing.T) {
+	m := NewMemo(template.FuncMap{
+		"ns":    func() interface{} { return testNS{} },
+		"add":   func(a, b int) int { return a + b },
+		"multi": func() (int, int) { return 1, 2 },
+	})
+
+	_, err := m.Memoize("k", "nope")
+	assert.EqualError(t, err, `memoize: function "nope" not defined`)
+
+	_, err = m.Memoize("k", "ns.Nope")
+	assert.EqualError(t, err, `memoize: function "ns.Nope" not defined`)
+
+	_, err = m.Memoize("k", "add.Nope")
+	assert.EqualError(t, err, `memoize: "add" is not a namespace`)
+
+	_, err = m.Memoize("k", 42)
+	assert.EqualError(t, err, "memoize: function must be a name or a function, got int")
+
+	// other function values can't be told apart, so they aren't cached
+	prefix := func(p string) func(string) string {
+		return func(s string) string { return p + s }
+	}
+	_, err = m.Memoize("k", prefix("a"), "b")
+	assert.EqualError(t, err, "memoize: can't cache calls to a function that's not a template function - give a template function's name instead")
+
+	// nor are template functions made by the same function literal
+	m2 := NewMemo(template.FuncMap{"a": prefix("a"), "b": prefix("b")})
+	_, err = m2.Memoize("k", prefix("a"), "x")
+	assert.Error(t, err)
+	out, err := m2.Memoize("k", "b", "x")
+	require.NoError(t, err)
+	assert.Equal(t, "bx", out)
+
+	_, err = m.Memoize("k", "add", 1)
+	assert.EqualError(t, err, "memoize: wrong number of args for add: want 2, got 1")
+
+	_, err = m.Memoize("k", "add", 1, "2")
+	assert.EqualError(t, err, "memoize: wrong type for argument 2 of add: want int, got string")
+
+	_, err = m.Memoize("k", "multi")
+	assert.EqualError(t, err, "memoize: can't call multi: it must return one value, or a value and an error")
+}
+
+func TestMemoize_Template(t *testing.T) {
+	root := template.New("root")
+	tns := New(root, nil, "")
+	funcs := template.FuncMap{"tmpl": func() *Template { return tns }}
+	funcs["memoize"] = NewMemo(funcs).Memoize
+
+	calls := 0
+	funcs["count"] = func() int {
+		calls++
+		return calls
+	}
+	funcs["list"] = func(v ...interface{}) []interface{} { return v }
+	root.Funcs(funcs)
+
+	_, err := root.Parse(`{{ define "T" }}{{ count }}{{ end }}` +
+		`{{ range $i := list "a" "b" "a" }}{{ memoize "t" "tmpl.Exec" "T" $i }} {{ end }}`)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, root.Execute(out, nil))
+	assert.Equal(t, "1 2 1 ", out.String())
+}
@@ -0,0 +1,114 @@
+package tmpl
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	iconv "github.com/hairyhenderson/gomplate/v3/internal/conv"
+)
+
+// DefaultParallelism - how many calls Parallel makes at once, unless told
+// otherwise
+const DefaultParallelism = 8
+
+// Parallel - makes the calls concurrently, at most limit at a time, and
+// returns their results in the same order as the calls. Each call is a list
+// of a function (by name or value, like TryOr) followed by its arguments. A
+// function with no arguments can also be given on its own.
+//
+// The calls are given either as separate arguments, or as a single list of
+// calls, optionally preceded by the limit (default DefaultParallelism).
+//
+// All calls are made even if some fail, and the error from the first failed
+// call (in order) is returned.
+func (f *Flow) Parallel(args ...interface{}) ([]interface{}, error) {
+	limit := DefaultParallelism
+	if len(args) > 0 && isInt(args[0]) {
+		limit = int(reflect.ValueOf(args[0]).Convert(reflect.TypeOf(0)).Int())
+		if limit < 1 {
+			return nil, fmt.Errorf("parallel: limit must be at least 1, got %d", limit)
+		}
+		args = args[1:]
+	}
+	if len(args) == 1 {
+		if l, err := iconv.InterfaceSlice(args[0]); err == nil && len(l) > 0 && isCall(l[0]) {
+			args = l
+		}
+	}
+
+	type bound struct {
+		name string
+		call func() (interface{}, error)
+	}
+	calls := make([]bound, len(args))
+	for i, c := range args {
+		fn, fargs, err := splitCall(c)
+		if err != nil {
+			return nil, fmt.Errorf("parallel: call %d: %w", i+1, err)
+		}
+		name, call, err := bind(f.funcs, fn, fargs)
+		if err != nil {
+			return nil, fmt.Errorf("parallel: call %d: %w", i+1, err)
+		}
+		calls[i] = bound{name, call}
+	}
+
+	results := make([]interface{}, len(calls))
+	errs := make([]error, len(calls))
+	sem := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	for i, c := range calls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, call func() (interface{}, error)) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = safeCall(call)
+		}(i, c.call)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("parallel: call %d (%s) failed: %w", i+1, calls[i].name, err)
+		}
+	}
+	return results, nil
+}
+
+// splitCall splits a call into the function and its arguments
+func splitCall(c interface{}) (interface{}, []interface{}, error) {
+	if _, ok := c.(string); ok {
+		return c, nil, nil
+	}
+	if reflect.ValueOf(c).Kind() == reflect.Func {
+		return c, nil, nil
+	}
+	l, err := iconv.InterfaceSlice(c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("must be a function, or a list of a function and its arguments, got %T", c)
+	}
+	if len(l) == 0 {
+		return nil, nil, fmt.Errorf("empty call")
+	}
+	return l[0], l[1:], nil
+}
+
+// isCall returns true if the value is a list, as a call with arguments is
+func isCall(v interface{}) bool {
+	if _, ok := v.(string); ok {
+		return false
+	}
+	k := reflect.ValueOf(v).Kind()
+	return k == reflect.Slice || k == reflect.Array
+}
+
+func isInt(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
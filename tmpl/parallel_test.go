@@ -0,0 +1,67 @@
+package tmpl
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallel(t *testing.T) {
+	var running, maxRunning int32
+	f := NewFlow(template.FuncMap{
+		"slow": func(s string) string {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return s
+		},
+		"now":  func() string { return "now" },
+		"fail": func() (string, error) { return "", errors.New("oops") },
+	})
+
+	out, err := f.Parallel([]interface{}{"slow", "a"}, []interface{}{"slow", "b"}, "now")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "now"}, out)
+
+	calls := []interface{}{}
+	for _, s := range []string{"a", "b", "c", "d", "e", "f"} {
+		calls = append(calls, []interface{}{"slow", s})
+	}
+	atomic.StoreInt32(&maxRunning, 0)
+	out, err = f.Parallel(2, calls)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c", "d", "e", "f"}, out)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+
+	// calls overlap, so the total time is less than the sum
+	start := time.Now()
+	_, err = f.Parallel(calls)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	out, err = f.Parallel()
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	_, err = f.Parallel([]interface{}{"slow", "a"}, "fail", "panic")
+	assert.EqualError(t, err, `parallel: call 3: function "panic" not defined`)
+	_, err = f.Parallel([]interface{}{"slow", "a"}, "fail")
+	assert.EqualError(t, err, "parallel: call 2 (fail) failed: oops")
+	_, err = f.Parallel(0, "now")
+	assert.Error(t, err)
+	_, err = f.Parallel([]interface{}{})
+	assert.Error(t, err)
+	_, err = f.Parallel(map[string]interface{}{})
+	assert.Error(t, err)
+}
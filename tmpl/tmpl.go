@@ -3,12 +3,17 @@ package tmpl
 
 import (
 	"bytes"
+	"fmt"
 	"path/filepath"
 	"text/template"
 
 	"github.com/pkg/errors"
 )
 
+// ErrSkip is returned (wrapped) by Skip, to stop rendering the template and
+// discard its output.
+var ErrSkip = errors.New("template skipped")
+
 // Template -
 type Template struct {
 	root       *template.Template
@@ -37,6 +42,15 @@ func (t *Template) PathDir() (string, error) {
 	return filepath.Dir(t.path), nil
 }
 
+// Skip - stop rendering the current template, and discard its output, so no
+// output file is written. An optional reason can be given, which is logged.
+func (t *Template) Skip(reason ...interface{}) (string, error) {
+	if len(reason) > 0 {
+		return "", errors.Wrap(ErrSkip, fmt.Sprint(reason...))
+	}
+	return "", ErrSkip
+}
+
 // Inline - a template function to do inline template processing
 //
 // Can be called 4 ways:
@@ -119,3 +119,13 @@ func TestPathDir(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "foo", p)
 }
+
+func TestSkip(t *testing.T) {
+	tmpl := &Template{}
+	_, err := tmpl.Skip()
+	assert.ErrorIs(t, err, ErrSkip)
+
+	_, err = tmpl.Skip("disabled")
+	assert.ErrorIs(t, err, ErrSkip)
+	assert.EqualError(t, err, "disabled: template skipped")
+}
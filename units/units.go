@@ -0,0 +1,119 @@
+// Package units converts values between units of data size, duration, and
+// temperature, with exact (rational) arithmetic, so that converting between
+// "MiB" and "GB" doesn't accumulate floating-point error.
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Dimension - what a unit measures. Values can only be converted between
+// units of the same dimension.
+type Dimension string
+
+// Dimensions
+const (
+	DataSize    Dimension = "data size"
+	Duration    Dimension = "duration"
+	Temperature Dimension = "temperature"
+)
+
+// unit - a value in the unit is converted to the dimension's base unit (bytes,
+// nanoseconds, or kelvin) as (value + offset) * factor
+type unit struct {
+	factor *big.Rat
+	offset *big.Rat
+	dim    Dimension
+}
+
+var units = map[string]unit{}
+
+func init() {
+	def := func(dim Dimension, factor, offset string, names ...string) {
+		f, _ := new(big.Rat).SetString(factor)
+		o, _ := new(big.Rat).SetString(offset)
+		for _, n := range names {
+			units[n] = unit{dim: dim, factor: f, offset: o}
+		}
+	}
+
+	// data sizes - decimal (SI) and binary (IEC) multiples of bytes and bits.
+	// Kubernetes-style quantities ("Mi", "G") are bytes.
+	def(DataSize, "1", "0", "B", "byte", "bytes")
+	def(DataSize, "1/8", "0", "bit", "bits")
+	si := []string{"k", "M", "G", "T", "P", "E"}
+	iec := []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+	dec, bin := big.NewInt(1), big.NewInt(1)
+	for i := range si {
+		dec = new(big.Int).Mul(dec, big.NewInt(1000))
+		bin = new(big.Int).Mul(bin, big.NewInt(1024))
+		d, b := dec.String(), bin.String()
+		def(DataSize, d, "0", si[i]+"B", strings.ToUpper(si[i])+"B", si[i])
+		def(DataSize, b, "0", iec[i]+"B", iec[i])
+		def(DataSize, d+"/8", "0", si[i]+"b", strings.ToUpper(si[i])+"b", si[i]+"bit")
+		def(DataSize, b+"/8", "0", iec[i]+"b", iec[i]+"bit")
+	}
+
+	// durations
+	def(Duration, "1", "0", "ns", "nanosecond", "nanoseconds")
+	def(Duration, "1000", "0", "us", "µs", "μs", "microsecond", "microseconds")
+	def(Duration, "1000000", "0", "ms", "millisecond", "milliseconds")
+	def(Duration, "1000000000", "0", "s", "sec", "second", "seconds")
+	def(Duration, "60000000000", "0", "m", "min", "minute", "minutes")
+	def(Duration, "3600000000000", "0", "h", "hr", "hour", "hours")
+	def(Duration, "86400000000000", "0", "d", "day", "days")
+	def(Duration, "604800000000000", "0", "w", "week", "weeks")
+
+	// temperatures
+	def(Temperature, "1", "0", "K", "kelvin")
+	def(Temperature, "1", "273.15", "C", "°C", "celsius")
+	def(Temperature, "5/9", "459.67", "F", "°F", "fahrenheit")
+}
+
+// lookup finds a unit by name. Symbols are case-sensitive (so "Mb" is
+// megabits, and "MB" is megabytes), but long names aren't.
+func lookup(name string) (unit, error) {
+	if u, ok := units[name]; ok {
+		return u, nil
+	}
+	if u, ok := units[strings.ToLower(name)]; ok && len(name) > 3 {
+		return u, nil
+	}
+	return unit{}, fmt.Errorf("unknown unit %q", name)
+}
+
+// Convert converts the value from one unit to another. Converting
+// temperatures below absolute zero is an error.
+func Convert(value *big.Rat, from, to string) (*big.Rat, error) {
+	f, err := lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	if f.dim != t.dim {
+		return nil, fmt.Errorf("can not convert %s (%s) to %s (%s)", from, f.dim, to, t.dim)
+	}
+
+	base := new(big.Rat).Add(value, f.offset)
+	base.Mul(base, f.factor)
+	if f.dim == Temperature && base.Sign() < 0 {
+		return nil, fmt.Errorf("%s%s is below absolute zero", value.FloatString(2), from)
+	}
+
+	out := new(big.Rat).Quo(base, t.factor)
+	return out.Sub(out, t.offset), nil
+}
+
+// DimensionOf returns the dimension the unit measures
+func DimensionOf(name string) (Dimension, error) {
+	u, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return u.dim, nil
+}
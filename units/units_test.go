@@ -0,0 +1,70 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	testdata := []struct {
+		value, from, to string
+		expected        string
+	}{
+		{"1", "MiB", "B", "1048576"},
+		{"1", "GiB", "GB", "1.073741824"},
+		{"512", "Mi", "MB", "536.870912"},
+		{"1", "GB", "MiB", "953.67431640625"},
+		{"1", "Gb", "MB", "125"},
+		{"8", "bits", "B", "1"},
+		{"100", "Mbit", "MiB", "11.920928955078125"},
+		{"1", "kB", "KB", "1"},
+		{"1.5", "h", "min", "90"},
+		{"1", "w", "d", "7"},
+		{"250", "ms", "s", "0.25"},
+		{"3", "Days", "hours", "72"},
+		{"100", "C", "F", "212"},
+		{"-40", "F", "C", "-40"},
+		{"0", "K", "C", "-273.15"},
+		{"98.6", "°F", "K", "310.15"},
+	}
+	for _, d := range testdata {
+		v, _ := new(big.Rat).SetString(d.value)
+		out, err := Convert(v, d.from, d.to)
+		require.NoError(t, err, d)
+		expected, _ := new(big.Rat).SetString(d.expected)
+		assert.Equal(t, expected.String(), out.String(), d)
+	}
+
+	one := big.NewRat(1, 1)
+	_, err := Convert(one, "MB", "s")
+	assert.Error(t, err)
+
+	_, err = Convert(one, "furlong", "m")
+	assert.Error(t, err)
+
+	_, err = Convert(one, "s", "fortnight")
+	assert.Error(t, err)
+
+	// symbols are case-sensitive
+	_, err = Convert(one, "mib", "B")
+	assert.Error(t, err)
+
+	_, err = Convert(big.NewRat(-1, 1), "K", "C")
+	assert.Error(t, err)
+}
+
+func TestDimensionOf(t *testing.T) {
+	d, err := DimensionOf("GiB")
+	require.NoError(t, err)
+	assert.Equal(t, DataSize, d)
+
+	d, err = DimensionOf("celsius")
+	require.NoError(t, err)
+	assert.Equal(t, Temperature, d)
+
+	_, err = DimensionOf("parsec")
+	assert.Error(t, err)
+}
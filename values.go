@@ -0,0 +1,165 @@
+package gomplate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/spf13/afero"
+)
+
+// loadValues builds the map made available to templates as .Values. The
+// base values are overridden by the values files, in order, and then by the
+// --set-style assignments.
+func loadValues(base map[string]interface{}, files, sets []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	mergeValues(values, base)
+
+	for _, f := range files {
+		b, err := afero.ReadFile(aferoFS, f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+		v, err := data.YAML(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", f, err)
+		}
+		mergeValues(values, v)
+	}
+
+	for _, s := range sets {
+		if err := parseSetValue(values, s); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// mergeValues deeply merges src into dst. Maps are merged recursively, and
+// any other values in src replace those in dst.
+func mergeValues(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sm, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dm, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dm = map[string]interface{}{}
+			dst[k] = dm
+		}
+		mergeValues(dm, sm)
+	}
+}
+
+// parseSetValue applies a Helm-style assignment to values. The assignment
+// can contain several comma-separated key=value pairs, where keys are dotted
+// paths (like image.tag), and values are given types where possible. A
+// value in braces (like {a,b}) is a list. Commas and dots can be escaped
+// with a backslash.
+func parseSetValue(values map[string]interface{}, s string) error {
+	for _, pair := range splitUnescaped(s, ',', true) {
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid value %q: must be in the form key=value", pair)
+		}
+		path := splitUnescaped(kv[0], '.', false)
+		for i, p := range path {
+			path[i] = unescape(p)
+			if path[i] == "" {
+				return fmt.Errorf("invalid key %q: empty path element", kv[0])
+			}
+		}
+
+		var v interface{}
+		raw := kv[1]
+		if strings.HasPrefix(raw, "{") && strings.HasSuffix(raw, "}") {
+			list := []interface{}{}
+			if inner := raw[1 : len(raw)-1]; inner != "" {
+				for _, e := range splitUnescaped(inner, ',', false) {
+					list = append(list, inferValue(unescape(e)))
+				}
+			}
+			v = list
+		} else {
+			v = inferValue(unescape(raw))
+		}
+
+		m := values
+		for _, p := range path[:len(path)-1] {
+			next, ok := m[p].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[p] = next
+			}
+			m = next
+		}
+		m[path[len(path)-1]] = v
+	}
+	return nil
+}
+
+// splitUnescaped splits s on sep, ignoring separators preceded by a
+// backslash, and (if braces is set) separators inside braces. Escapes are
+// left in place.
+func splitUnescaped(s string, sep byte, braces bool) []string {
+	parts := []string{}
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case braces && c == '{':
+			depth++
+		case braces && c == '}' && depth > 0:
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// inferValue converts booleans, null, and integers to their typed values,
+// leaving everything else as a string. As with Helm, decimals aren't
+// converted, since they're more often versions (like 1.10) than numbers, and
+// integers with leading zeros (like zip codes or octal modes) are left as
+// strings.
+func inferValue(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n := strings.TrimLeft(s, "+-"); len(n) > 1 && n[0] == '0' {
+		return s
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package gomplate
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetValue(t *testing.T) {
+	testdata := []struct {
+		in       string
+		expected map[string]interface{}
+	}{
+		{"a=b", map[string]interface{}{"a": "b"}},
+		{"a.b.c=1", map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": int64(1)}}}},
+		{"a=1,b=true,c=null,d=1.5,e=007", map[string]interface{}{
+			"a": int64(1), "b": true, "c": nil, "d": "1.5", "e": "007",
+		}},
+		{"a={x,2,false},b={}", map[string]interface{}{
+			"a": []interface{}{"x", int64(2), false}, "b": []interface{}{},
+		}},
+		{`a=x\,y,b\.c=d`, map[string]interface{}{"a": "x,y", "b.c": "d"}},
+		{"a=b=c", map[string]interface{}{"a": "b=c"}},
+		{"a=", map[string]interface{}{"a": ""}},
+		{"a=NaN,b=0x10", map[string]interface{}{"a": "NaN", "b": "0x10"}},
+	}
+	for _, d := range testdata {
+		d := d
+		t.Run(d.in, func(t *testing.T) {
+			v := map[string]interface{}{}
+			require.NoError(t, parseSetValue(v, d.in))
+			assert.Equal(t, d.expected, v)
+		})
+	}
+
+	for _, in := range []string{"a", "a..b=c", ".a=b"} {
+		assert.Error(t, parseSetValue(map[string]interface{}{}, in), in)
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	origfs := aferoFS
+	defer func() { aferoFS = origfs }()
+	aferoFS = afero.NewMemMapFs()
+	_ = afero.WriteFile(aferoFS, "values.yaml", []byte("image:\n  repo: nginx\n  tag: latest\nreplicas: 1\n"), 0o600)
+	_ = afero.WriteFile(aferoFS, "prod.json", []byte(`{"replicas": 3, "image": {"tag": "1.21"}}`), 0o600)
+	_ = afero.WriteFile(aferoFS, "bad.yaml", []byte("foo: [\n"), 0o600)
+
+	base := map[string]interface{}{"name": "web", "replicas": 0}
+	v, err := loadValues(base, []string{"values.yaml", "prod.json"}, []string{"image.tag=1.22", "debug=true"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":     "web",
+		"replicas": 3,
+		"debug":    true,
+		"image":    map[string]interface{}{"repo": "nginx", "tag": "1.22"},
+	}, v)
+	// the base values must not be modified
+	assert.Equal(t, 0, base["replicas"])
+
+	_, err = loadValues(nil, []string{"missing.yaml"}, nil)
+	assert.Error(t, err)
+	_, err = loadValues(nil, []string{"bad.yaml"}, nil)
+	assert.Error(t, err)
+	_, err = loadValues(nil, nil, []string{"nokey"})
+	assert.Error(t, err)
+}
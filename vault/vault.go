@@ -2,10 +2,14 @@ package vault
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"net"
+	"net/http"
 	"net/url"
 
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 
 	vaultapi "github.com/hashicorp/vault/api"
 )
@@ -15,8 +19,44 @@ type Vault struct {
 	client *vaultapi.Client
 }
 
+// Option - configures the Vault client
+type Option func(*vaultapi.Config)
+
+// WithDialContext - dial connections to Vault with the given function
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(c *vaultapi.Config) {
+		if t, ok := c.HttpClient.Transport.(*http.Transport); ok {
+			t.DialContext = dial
+		}
+	}
+}
+
+// WithMaxConnsPerHost - limit the number of connections open to Vault at once
+func WithMaxConnsPerHost(n int) Option {
+	return func(c *vaultapi.Config) {
+		if t, ok := c.HttpClient.Transport.(*http.Transport); ok {
+			t.MaxConnsPerHost = n
+		}
+	}
+}
+
+// WithRateLimit - limit the rate of requests to Vault with the limiter that
+// limiter returns for Vault's host. When it returns nil, the rate limit from
+// the environment (if any) is kept.
+func WithRateLimit(limiter func(host string) *rate.Limiter) Option {
+	return func(c *vaultapi.Config) {
+		u, err := url.Parse(c.Address)
+		if err != nil {
+			return
+		}
+		if l := limiter(u.Host); l != nil {
+			c.Limiter = l
+		}
+	}
+}
+
 // New -
-func New(u *url.URL) (*Vault, error) {
+func New(u *url.URL, opts ...Option) (*Vault, error) {
 	vaultConfig := vaultapi.DefaultConfig()
 
 	err := vaultConfig.ReadEnvironment()
@@ -26,6 +66,10 @@ func New(u *url.URL) (*Vault, error) {
 
 	setVaultURL(vaultConfig, u)
 
+	for _, opt := range opts {
+		opt(vaultConfig)
+	}
+
 	client, err := vaultapi.NewClient(vaultConfig)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Vault setup failed")
@@ -54,6 +98,16 @@ func (v *Vault) Login() error {
 	return nil
 }
 
+// SetToken - use the given token, instead of logging in
+func (v *Vault) SetToken(token string) {
+	v.client.SetToken(token)
+}
+
+// Address - the address of the Vault server, like https://vault:8200
+func (v *Vault) Address() string {
+	return v.client.Address()
+}
+
 // Logout -
 func (v *Vault) Logout() {
 	v.client.ClearToken()
@@ -62,7 +116,17 @@ func (v *Vault) Logout() {
 // Read - returns the value of a given path. If no value is found at the given
 // path, returns empty slice.
 func (v *Vault) Read(path string) ([]byte, error) {
-	secret, err := v.client.Logical().Read(path)
+	return v.read(path, nil)
+}
+
+// ReadVersion - like Read, but returns the given version of a KV version 2
+// secret, rather than the latest
+func (v *Vault) ReadVersion(path, version string) ([]byte, error) {
+	return v.read(path, map[string][]string{"version": {version}})
+}
+
+func (v *Vault) read(path string, data map[string][]string) ([]byte, error) {
+	secret, err := v.client.Logical().ReadWithData(path, data)
 	if err != nil {
 		return nil, err
 	}
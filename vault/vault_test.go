@@ -1,11 +1,13 @@
 package vault
 
 import (
+	"net/http"
 	"net/url"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
 )
 
 func TestNew(t *testing.T) {
@@ -52,6 +54,15 @@ func TestRead(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestReadVersion(t *testing.T) {
+	expected := "{\"data\":{\"value\":\"foo\"},\"metadata\":{\"version\":3}}\n"
+	server, v := MockServer(200, `{"data":`+expected+`}`)
+	defer server.Close()
+	val, err := v.ReadVersion("secret/data/s", "3")
+	assert.Equal(t, expected, string(val))
+	assert.NoError(t, err)
+}
+
 func TestWrite(t *testing.T) {
 	server, v := MockServer(404, "Not Found")
 	defer server.Close()
@@ -66,3 +77,26 @@ func TestWrite(t *testing.T) {
 	assert.Equal(t, expected, string(val))
 	assert.NoError(t, err)
 }
+
+func TestNewWithOptions(t *testing.T) {
+	os.Unsetenv("VAULT_ADDR")
+	limiter := rate.NewLimiter(5, 1)
+	hosts := []string{}
+
+	u, _ := url.Parse("vault://vault.rocks:8200/secret/foo/bar")
+	v, err := New(u,
+		WithMaxConnsPerHost(3),
+		WithRateLimit(func(host string) *rate.Limiter {
+			hosts = append(hosts, host)
+			return limiter
+		}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vault.rocks:8200"}, hosts)
+	assert.Same(t, limiter, v.client.Limiter())
+	assert.Equal(t, 3, v.client.CloneConfig().HttpClient.Transport.(*http.Transport).MaxConnsPerHost)
+
+	// when there's no limit for the host, there's no limiter
+	v, err = New(u, WithRateLimit(func(string) *rate.Limiter { return nil }))
+	assert.NoError(t, err)
+	assert.Nil(t, v.client.Limiter())
+}
@@ -0,0 +1,298 @@
+package gomplate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// defaultWatchDebounce - how long to wait after a change for more changes,
+// before rendering. Editors and tools like Kubernetes often write several
+// files (or the same file several times) at once.
+const defaultWatchDebounce = 100 * time.Millisecond
+
+// WatchOptions - options for Watch
+type WatchOptions struct {
+	// Debounce - how long to wait after a change for further changes before
+	// rendering. Defaults to 100ms.
+	Debounce time.Duration
+	// AfterRender - called after each successful render, with the config it
+	// was rendered with (for example, to run a post-exec command)
+	AfterRender func(ctx context.Context, cfg *config.Config) error
+}
+
+// Watch renders the configured templates, and then renders them again each
+// time an input template, nested template, or file-based datasource (or
+// values or env file) changes. Render errors are logged, and the files are
+// watched for the next change. It returns when the context is cancelled.
+func Watch(ctx context.Context, cfg *config.Config, o WatchOptions) error {
+	if o.Debounce <= 0 {
+		o.Debounce = defaultWatchDebounce
+	}
+
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %w\n%+v", err, cfg)
+	}
+
+	ws, err := newWatchSet(cfg)
+	if err != nil {
+		return err
+	}
+	if len(ws.files) == 0 && len(ws.dirs) == 0 {
+		return fmt.Errorf("nothing to watch - templates and datasources must be files to be watched")
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch files: %w", err)
+	}
+	defer w.Close()
+
+	for _, dir := range ws.watchDirs() {
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	log := zerolog.Ctx(ctx)
+	render := func() {
+		err := Run(ctx, cfg)
+		if err == nil && o.AfterRender != nil {
+			err = o.AfterRender(ctx, cfg)
+		}
+		// outputs written while rendering aren't changes to watch for
+		for _, f := range Metrics.ChangedFiles {
+			if abs, aerr := filepath.Abs(f); aerr == nil {
+				ws.outputs[abs] = true
+			}
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("render failed - waiting for changes")
+			return
+		}
+		log.Info().Int("changed", len(Metrics.ChangedFiles)).Msg("rendered templates")
+	}
+
+	render()
+	log.Info().Int("files", len(ws.files)).Int("dirs", len(ws.dirs)).Msg("watching for changes")
+
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			// directories created in watched directories are watched too
+			if ev.Op&fsnotify.Create != 0 && ws.inDir(ev.Name) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					_ = addDirs(w, ev.Name)
+				}
+			}
+			if !ws.relevant(ev.Name) {
+				continue
+			}
+			log.Debug().Str("file", ev.Name).Str("op", ev.Op.String()).Msg("change detected")
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(o.Debounce)
+			fire = timer.C
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warn().Err(err).Msg("error watching files")
+		case <-fire:
+			timer, fire = nil, nil
+			render()
+		}
+	}
+}
+
+// watchSet - the files and directories to watch for changes, as absolute
+// paths
+type watchSet struct {
+	// files - individual files, which are watched through their parent
+	// directories, so that files replaced by renaming are still seen
+	files map[string]bool
+	// dirs - directories, which are watched recursively
+	dirs map[string]bool
+	// outputDirs - directories that outputs are written to, which aren't
+	// watched for changes
+	outputDirs []string
+	// outputs - output files, including those written by earlier renders
+	outputs map[string]bool
+}
+
+func newWatchSet(cfg *config.Config) (*watchSet, error) {
+	ws := &watchSet{files: map[string]bool{}, dirs: map[string]bool{}, outputs: map[string]bool{}}
+
+	paths := []string{}
+	for _, f := range cfg.InputFiles {
+		if f != "-" {
+			paths = append(paths, f)
+		}
+	}
+	if cfg.InputDir != "" {
+		paths = append(paths, cfg.InputDir)
+	}
+	if cfg.Bundle != "" {
+		paths = append(paths, cfg.Bundle)
+	}
+	paths = append(paths, cfg.ValuesFiles...)
+	paths = append(paths, cfg.EnvFiles...)
+	for _, sources := range []map[string]config.DataSource{cfg.Templates, cfg.DataSources, cfg.Context} {
+		for _, ds := range sources {
+			u := config.ExpandScheme(cfg.Schemes, ds.URL)
+			if u != nil && u.Scheme == "file" && u.Path != "" {
+				paths = append(paths, filepath.FromSlash(u.Path))
+			}
+		}
+	}
+
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		fi, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("can't watch %s: %w", p, err)
+		}
+		if fi.IsDir() {
+			ws.dirs[abs] = true
+		} else {
+			ws.files[abs] = true
+		}
+	}
+
+	// an output directory inside the input directory isn't watched (but
+	// one that contains it, like the default ".", can't be ignored)
+	if cfg.InputDir != "" && cfg.OutputDir != "" {
+		in, err := filepath.Abs(cfg.InputDir)
+		if err != nil {
+			return nil, err
+		}
+		out, err := filepath.Abs(cfg.OutputDir)
+		if err != nil {
+			return nil, err
+		}
+		if isWithin(in, out) {
+			ws.outputDirs = append(ws.outputDirs, out)
+		}
+	}
+	for _, f := range cfg.OutputFiles {
+		if f == "-" {
+			continue
+		}
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return nil, err
+		}
+		ws.outputs[abs] = true
+	}
+
+	return ws, nil
+}
+
+// watchDirs - all directories that need to be watched: the parents of the
+// files, and the directories (with all of their subdirectories)
+func (ws *watchSet) watchDirs() []string {
+	dirs := map[string]bool{}
+	for f := range ws.files {
+		dirs[filepath.Dir(f)] = true
+	}
+	for d := range ws.dirs {
+		_ = filepath.Walk(d, func(path string, fi os.FileInfo, err error) error {
+			if err == nil && fi.IsDir() && !ws.isOutput(path) {
+				dirs[path] = true
+			}
+			return nil
+		})
+	}
+
+	out := make([]string, 0, len(dirs))
+	for d := range dirs {
+		out = append(out, d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func addDirs(w *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return w.Add(path)
+		}
+		return nil
+	})
+}
+
+// relevant reports whether a change to the named file should trigger a
+// render
+func (ws *watchSet) relevant(name string) bool {
+	if ws.isOutput(name) {
+		return false
+	}
+	if ws.files[name] || ws.inDir(name) {
+		return true
+	}
+
+	// Kubernetes updates mounted ConfigMaps and Secrets by swapping the
+	// target of a "..data" symlink, so the files themselves don't change
+	if strings.HasPrefix(filepath.Base(name), "..") {
+		dir := filepath.Dir(name)
+		for f := range ws.files {
+			if filepath.Dir(f) == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ws *watchSet) inDir(name string) bool {
+	for d := range ws.dirs {
+		if name == d || isWithin(d, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ws *watchSet) isOutput(name string) bool {
+	if ws.outputs[name] {
+		return true
+	}
+	for _, d := range ws.outputDirs {
+		if name == d || isWithin(d, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is inside the directory dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
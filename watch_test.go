@@ -0,0 +1,113 @@
+package gomplate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.tmpl")
+	out := filepath.Join(dir, "out.txt")
+	dsFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(in, []byte(`name={{ (ds "config").name }}`), 0o600))
+	require.NoError(t, os.WriteFile(dsFile, []byte("name: one\n"), 0o600))
+	u, err := config.ParseSourceURL(dsFile)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	renders := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		cfg := &config.Config{
+			InputFiles:  []string{in},
+			OutputFiles: []string{out},
+			DataSources: map[string]config.DataSource{"config": {URL: u}},
+		}
+		done <- Watch(ctx, cfg, WatchOptions{
+			Debounce: 10 * time.Millisecond,
+			AfterRender: func(context.Context, *config.Config) error {
+				renders <- struct{}{}
+				return nil
+			},
+		})
+	}()
+
+	output := func() string {
+		b, _ := os.ReadFile(out)
+		return string(b)
+	}
+	wait := func(expected string) {
+		t.Helper()
+		select {
+		case <-renders:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for render")
+		}
+		assert.Equal(t, expected, output())
+	}
+
+	wait("name=one")
+
+	// a changed datasource
+	require.NoError(t, os.WriteFile(dsFile, []byte("name: two\n"), 0o600))
+	wait("name=two")
+
+	// a template replaced by renaming, as editors do
+	tmp := filepath.Join(dir, "in.tmpl.swp")
+	require.NoError(t, os.WriteFile(tmp, []byte(`NAME={{ (ds "config").name }}`), 0o600))
+	require.NoError(t, os.Rename(tmp, in))
+	wait("NAME=two")
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Watch to return")
+	}
+}
+
+func TestWatchSet(t *testing.T) {
+	dir := t.TempDir()
+	inDir := filepath.Join(dir, "in")
+	require.NoError(t, os.MkdirAll(filepath.Join(inDir, "sub"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(inDir, "out"), 0o755))
+	values := filepath.Join(dir, "values.yaml")
+	require.NoError(t, os.WriteFile(values, nil, 0o600))
+
+	cfg := &config.Config{
+		InputDir:    inDir,
+		OutputDir:   filepath.Join(inDir, "out"),
+		ValuesFiles: []string{values},
+	}
+	ws, err := newWatchSet(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{dir, inDir, filepath.Join(inDir, "sub")}, ws.watchDirs())
+
+	assert.True(t, ws.relevant(values))
+	assert.True(t, ws.relevant(filepath.Join(inDir, "sub", "a.tmpl")))
+	assert.True(t, ws.relevant(filepath.Join(dir, "..data")))
+	assert.False(t, ws.relevant(filepath.Join(dir, "other.yaml")))
+	assert.False(t, ws.relevant(filepath.Join(inDir, "out", "a.tmpl")))
+
+	// an output dir that contains the input dir (like the default ".") isn't
+	// ignored
+	cfg.OutputDir = dir
+	ws, err = newWatchSet(cfg)
+	require.NoError(t, err)
+	assert.True(t, ws.relevant(filepath.Join(inDir, "a.tmpl")))
+
+	_, err = newWatchSet(&config.Config{InputFiles: []string{filepath.Join(dir, "missing")}})
+	assert.ErrorContains(t, err, "can't watch")
+}
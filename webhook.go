@@ -0,0 +1,235 @@
+package gomplate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hairyhenderson/gomplate/v3/data"
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// WebhookOptions - options for ServeWebhook
+type WebhookOptions struct {
+	// Addr - the address to listen on. Defaults to ":8443".
+	Addr string
+	// TLSCertFile and TLSKeyFile - the server's certificate and key. When
+	// not set, plain HTTP is served.
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// admissionReview - a Kubernetes admission.k8s.io/v1 AdmissionReview. The
+// request is kept as generic data, for templates.
+type admissionReview struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Request    map[string]interface{} `json:"request,omitempty"`
+	Response   *admissionResponse     `json:"response,omitempty"`
+}
+
+type admissionResponse struct {
+	UID       string           `json:"uid"`
+	Allowed   bool             `json:"allowed"`
+	PatchType string           `json:"patchType,omitempty"`
+	Patch     []byte           `json:"patch,omitempty"`
+	Result    *admissionStatus `json:"status,omitempty"`
+}
+
+type admissionStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ServeWebhook serves a Kubernetes mutating admission webhook, until the
+// context is cancelled. The configured template is rendered for each
+// admission request, with the request available as .Request (and its
+// object as .Object and .OldObject), and must output a JSON patch (an array
+// of operations) to apply to the object, or nothing to admit it unchanged.
+// If the template fails to render (for example because it calls fail), the
+// request is denied with the error.
+func ServeWebhook(ctx context.Context, cfg *config.Config, o WebhookOptions) error {
+	defer runCleanupHooks()
+
+	if o.Addr == "" {
+		o.Addr = ":8443"
+	}
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		return fmt.Errorf("both a TLS certificate and key must be given, or neither")
+	}
+
+	h, err := newWebhookHandler(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              o.Addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+
+	zerolog.Ctx(ctx).Info().Str("addr", o.Addr).Msg("serving admission webhook")
+	if o.TLSCertFile != "" {
+		err = srv.ListenAndServeTLS(o.TLSCertFile, o.TLSKeyFile)
+	} else {
+		zerolog.Ctx(ctx).Warn().Msg("serving plain HTTP - the Kubernetes API server requires TLS, so it must be terminated elsewhere")
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// webhookHandler renders the template for each admission request. The
+// renderer is shared by all requests, so datasources are read once, and
+// cached until the webhook is restarted.
+type webhookHandler struct {
+	ctx  context.Context
+	tr   *Renderer
+	tmpl inputTemplate
+}
+
+func newWebhookHandler(ctx context.Context, cfg *config.Config) (*webhookHandler, error) {
+	if cfg.Matrix != nil {
+		return nil, fmt.Errorf("matrix renders can't be used as a webhook")
+	}
+
+	err := validateInputs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = data.ContextWithStdin(ctx, cfg.Stdin)
+	opts, err := runOptions(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := readInputTemplates(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook template: %w", err)
+	}
+	if len(templates) != 1 {
+		return nil, fmt.Errorf("a webhook must have exactly one template, got %d", len(templates))
+	}
+
+	tr := NewRenderer(opts)
+	tr.data.Ctx = ctx
+
+	return &webhookHandler{ctx: ctx, tr: tr, tmpl: templates[0]}, nil
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	review := admissionReview{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "invalid AdmissionReview: missing request", http.StatusBadRequest)
+		return
+	}
+
+	resp := h.admit(r.Context(), review.Request)
+	out := admissionReview{
+		APIVersion: review.APIVersion,
+		Kind:       review.Kind,
+		Response:   resp,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// admit renders the template for the request, and returns the response
+func (h *webhookHandler) admit(ctx context.Context, req map[string]interface{}) *admissionResponse {
+	uid, _ := req["uid"].(string)
+	resp := &admissionResponse{UID: uid}
+	logger := zerolog.Ctx(h.ctx).With().Str("uid", uid).Logger()
+
+	patch, err := h.render(ctx, req)
+	if err != nil {
+		logger.Info().Err(err).Msg("denied admission request")
+		resp.Result = &admissionStatus{Code: http.StatusForbidden, Message: err.Error()}
+		return resp
+	}
+
+	resp.Allowed = true
+	if patch != nil {
+		resp.PatchType = "JSONPatch"
+		resp.Patch = patch
+	}
+	logger.Debug().Bool("patched", patch != nil).Msg("admitted admission request")
+	return resp
+}
+
+// render renders the template for the request, returning the JSON patch it
+// output, or nil if it output nothing
+func (h *webhookHandler) render(ctx context.Context, req map[string]interface{}) ([]byte, error) {
+	roots := tmplctx{
+		"Request":   req,
+		"Object":    req["object"],
+		"OldObject": req["oldObject"],
+	}
+	for k, v := range h.tr.tctxRoots {
+		roots[k] = v
+	}
+	tctx, err := createTmplContext(ctx, h.tr.tctxAliases, roots, h.tr.data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tctx.(*tmplctx); !ok {
+		return nil, fmt.Errorf("the admission request can't be added to a '.' context")
+	}
+
+	buf := &bytes.Buffer{}
+	t := Template{Name: h.tmpl.name, Text: h.tmpl.text, Writer: buf, bundle: h.tmpl.bundle}
+	err = h.tr.renderTemplatesWithData(ctx, []Template{t}, tctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.TrimSpace(buf.Bytes())
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	ops := []map[string]interface{}{}
+	if err := json.Unmarshal(out, &ops); err != nil {
+		return nil, fmt.Errorf("template output must be a JSON patch (an array of operations): %w", err)
+	}
+	for i, op := range ops {
+		name, _ := op["op"].(string)
+		switch name {
+		case "add", "remove", "replace", "move", "copy", "test":
+		default:
+			return nil, fmt.Errorf("template output must be a JSON patch: invalid op %q in operation %d", name, i)
+		}
+		if _, ok := op["path"].(string); !ok {
+			return nil, fmt.Errorf("template output must be a JSON patch: missing path in operation %d", i)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
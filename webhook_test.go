@@ -0,0 +1,97 @@
+package gomplate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hairyhenderson/gomplate/v3/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookTemplate = `{{- if not (has .Object.metadata "labels") -}}
+[{"op": "add", "path": "/metadata/labels", "value": {"team": {{ .Values.team | data.ToJSON }}}}]
+{{- else if eq .Object.metadata.labels.team "forbidden" -}}
+{{ fail "team is forbidden" }}
+{{- end -}}`
+
+func postReview(t *testing.T, h http.Handler, object string) admissionReview {
+	t.Helper()
+	body := `{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview",
+	"request": {"uid": "abc-123", "operation": "CREATE", "object": ` + object + `}}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	out := admissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	assert.Equal(t, "admission.k8s.io/v1", out.APIVersion)
+	assert.Equal(t, "AdmissionReview", out.Kind)
+	require.NotNil(t, out.Response)
+	assert.Equal(t, "abc-123", out.Response.UID)
+	return out
+}
+
+func TestWebhookHandler(t *testing.T) {
+	cfg := &config.Config{Input: webhookTemplate, SetValues: []string{"team=platform"}}
+	h, err := newWebhookHandler(context.Background(), cfg)
+	require.NoError(t, err)
+
+	out := postReview(t, h, `{"metadata": {"name": "web"}}`)
+	assert.True(t, out.Response.Allowed)
+	assert.Equal(t, "JSONPatch", out.Response.PatchType)
+	assert.JSONEq(t, `[{"op": "add", "path": "/metadata/labels", "value": {"team": "platform"}}]`, string(out.Response.Patch))
+
+	// no output admits the object unchanged
+	out = postReview(t, h, `{"metadata": {"name": "web", "labels": {"team": "a"}}}`)
+	assert.True(t, out.Response.Allowed)
+	assert.Empty(t, out.Response.PatchType)
+	assert.Nil(t, out.Response.Patch)
+
+	out = postReview(t, h, `{"metadata": {"name": "web", "labels": {"team": "forbidden"}}}`)
+	assert.False(t, out.Response.Allowed)
+	require.NotNil(t, out.Response.Result)
+	assert.Equal(t, http.StatusForbidden, out.Response.Result.Code)
+	assert.Contains(t, out.Response.Result.Message, "team is forbidden")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"kind": "AdmissionReview"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWebhookHandlerInvalidPatch(t *testing.T) {
+	testdata := []struct {
+		in, err string
+	}{
+		{`{"op": "add"}`, "must be a JSON patch (an array of operations)"},
+		{`[{"op": "merge", "path": "/a"}]`, `invalid op "merge" in operation 0`},
+		{`[{"op": "remove"}]`, "missing path in operation 0"},
+	}
+	for _, d := range testdata {
+		h, err := newWebhookHandler(context.Background(), &config.Config{Input: d.in})
+		require.NoError(t, err)
+
+		out := postReview(t, h, `{}`)
+		assert.False(t, out.Response.Allowed)
+		assert.Contains(t, out.Response.Result.Message, d.err)
+	}
+
+	h, err := newWebhookHandler(context.Background(), &config.Config{Input: "[]"})
+	require.NoError(t, err)
+	out := postReview(t, h, `{}`)
+	assert.True(t, out.Response.Allowed)
+	assert.Nil(t, out.Response.Patch)
+}
+
+func TestServeWebhookTLSOptions(t *testing.T) {
+	err := ServeWebhook(context.Background(), &config.Config{Input: "[]"}, WebhookOptions{TLSCertFile: "cert.pem"})
+	assert.Error(t, err)
+}